@@ -5,21 +5,33 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"repomix-mcp/internal/cache"
 	"repomix-mcp/internal/config"
+	"repomix-mcp/internal/godoc"
 	"repomix-mcp/internal/indexer"
+	"repomix-mcp/internal/logging"
 	"repomix-mcp/internal/mcp"
 	"repomix-mcp/internal/mcpclient"
 	"repomix-mcp/internal/repository"
+	"repomix-mcp/internal/search"
+	"repomix-mcp/internal/tracing"
 	"repomix-mcp/pkg/types"
 
 	"github.com/spf13/cobra"
@@ -34,6 +46,9 @@ type Application struct {
 	indexer       *indexer.Indexer
 	searchEngine  SearchInterface
 	mcpServer     *mcp.Server
+	logFile       io.Closer
+	tracer        io.Closer
+	serving       atomic.Bool // Set once the MCP server is started, so signals stop it gracefully
 }
 
 // ************************************************************************************************
@@ -42,16 +57,6 @@ type SearchInterface interface {
 	Search(query types.SearchQuery) ([]types.SearchResult, error)
 }
 
-// ************************************************************************************************
-// MockSearchEngine provides a simple search implementation.
-type MockSearchEngine struct{}
-
-// Search implements a basic search functionality.
-func (m *MockSearchEngine) Search(query types.SearchQuery) ([]types.SearchResult, error) {
-	// Simple mock implementation for now
-	return []types.SearchResult{}, nil
-}
-
 // ************************************************************************************************
 // NewApplication creates a new application instance.
 //
@@ -81,6 +86,18 @@ func (app *Application) Initialize(configPath string) error {
 		return fmt.Errorf("%w: configuration is nil", types.ErrNotInitialized)
 	}
 
+	// Write logs to the configured log file from here on
+	app.logFile, err = logging.Setup(&config.Server)
+	if err != nil {
+		return fmt.Errorf("failed to set up log file\n>    %w", err)
+	}
+
+	// Export the spans of tool calls and indexing when a collector is configured
+	app.tracer, err = tracing.Setup(&config.Server)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing\n>    %w", err)
+	}
+
 	// Initialize cache
 	app.cache, err = cache.NewCache(&config.Cache)
 	if err != nil {
@@ -100,60 +117,184 @@ func (app *Application) Initialize(configPath string) error {
 		return fmt.Errorf("failed to initialize indexer\n>    %w", err)
 	}
 
-	// Initialize search engine
-	app.searchEngine = &MockSearchEngine{}
+	// Initialize search engine, searching the repositories the server indexes into it
+	app.searchEngine = search.NewEngine().Indexed()
 
 	// Initialize MCP server
 	app.mcpServer, err = mcp.NewServer(config, app.cache, app.searchEngine)
 	if err != nil {
 		return fmt.Errorf("failed to initialize MCP server\n>    %w", err)
 	}
+	app.mcpServer.SetFileLister(app.repoManager)
+	app.mcpServer.SetRepositoryIndexer(app)
+
+	// Send the log to the MCP clients listening for notifications, at their log level
+	logging.AddHandler(app.mcpServer.LogHandler())
 
 	return nil
 }
 
 // ************************************************************************************************
 // IndexAllRepositories indexes all configured repositories.
-// It automatically expands glob patterns and indexes each discovered repository.
+// It automatically expands glob patterns and indexes each discovered repository, up to
+// index.parallelism at once. A failed repository does not stop the others; the failures are
+// listed in the summary logged at the end.
 //
 // Returns:
-//   - error: An error if indexing fails.
+//   - error: An error listing the repositories that failed, nil if none did.
 func (app *Application) IndexAllRepositories() error {
 	aliases := app.configManager.GetRepositoryAliases()
+	started := time.Now()
 
-	log.Printf("Starting indexing of %d configured repositories", len(aliases))
+	slog.Info("Starting indexing of the configured repositories", "repositories", len(aliases))
+
+	// Configured aliases own their IDs; glob expansions must not take them over
+	repoIDs := types.NewRepositoryIDSet()
+	for _, alias := range aliases {
+		repoIDs.Claim(alias, alias)
+	}
 
-	totalIndexed := 0
+	totalIndexed, totalSkipped := 0, 0
+	var failures []error
+	var tasks []indexTask
 	for _, alias := range aliases {
 		// Get repository configuration
 		repoConfig, err := app.configManager.GetRepository(alias)
 		if err != nil {
-			log.Printf("Warning: failed to get repository config for %s: %v", alias, err)
+			slog.Warn("Failed to get repository config", "repository", alias, "error", err)
+			failures = append(failures, fmt.Errorf("%s: failed to get repository config: %w", alias, err))
+			continue
+		}
+
+		// Disabling a repository is intentional, not a failure
+		if repoConfig.Disabled || !repoConfig.Indexing.Enabled {
+			slog.Info("Skipping disabled repository", "repository", alias)
+			totalSkipped++
 			continue
 		}
 
 		// Expand glob patterns if present
 		expandedRepos, err := app.repoManager.ExpandGlobRepositories(alias, repoConfig)
 		if err != nil {
-			log.Printf("Warning: failed to expand glob for repository %s: %v", alias, err)
+			slog.Warn("Failed to expand glob", "repository", alias, "error", err)
+			failures = append(failures, fmt.Errorf("%s: failed to expand glob: %w", alias, err))
 			continue
 		}
 
-		log.Printf("Repository %s expanded to %d repositories", alias, len(expandedRepos))
+		slog.Info("Expanded repository", "repository", alias, "repositories", len(expandedRepos))
 
-		// Index each expanded repository
-		for expandedAlias, expandedConfig := range expandedRepos {
-			if err := app.indexExpandedRepository(expandedAlias, expandedConfig); err != nil {
-				log.Printf("Warning: failed to index repository %s: %v", expandedAlias, err)
+		for _, task := range sortedIndexTasks(expandedRepos) {
+			if err := repoIDs.Claim(task.alias, alias); err != nil {
+				slog.Warn("Skipping repository", "repository", task.alias, "error", err)
+				failures = append(failures, fmt.Errorf("%s: %w", task.alias, err))
 				continue
 			}
-			log.Printf("Successfully indexed repository: %s", expandedAlias)
+			tasks = append(tasks, task)
+		}
+	}
+
+	// Index the expanded repositories
+	app.indexExpandedRepositories(context.Background(), tasks, func(result indexResult) {
+		switch {
+		case errors.Is(result.err, types.ErrIndexingDisabled):
+			slog.Info("Skipping disabled repository", "repository", result.alias)
+			totalSkipped++
+		case result.err != nil:
+			slog.Warn("Failed to index repository", "repository", result.alias, "error", result.err)
+			failures = append(failures, fmt.Errorf("%s: %w", result.alias, result.err))
+		default:
+			slog.Info("Indexed repository", "repository", result.alias)
 			totalIndexed++
 		}
+	})
+
+	slog.Info("Completed indexing", "duration", time.Since(started).Round(time.Millisecond).String(), "indexed", totalIndexed, "skipped", totalSkipped, "failed", len(failures))
+	if len(failures) == 0 {
+		return nil
 	}
+	for _, failure := range failures {
+		slog.Error("Repository failed to index", "error", failure)
+	}
+	return fmt.Errorf("%d repositories failed to index\n>    %w", len(failures), errors.Join(failures...))
+}
 
-	log.Printf("Completed indexing %d repositories", totalIndexed)
-	return nil
+// ************************************************************************************************
+// indexTask is an expanded repository to index.
+type indexTask struct {
+	alias  string
+	config *types.RepositoryConfig
+}
+
+// ************************************************************************************************
+// indexResult is the outcome of an indexTask.
+type indexResult struct {
+	alias string
+	index *types.RepositoryIndex // Indexed repository, nil on error
+	err   error
+}
+
+// ************************************************************************************************
+// sortedIndexTasks returns the tasks indexing glob-expanded repositories, sorted by alias.
+func sortedIndexTasks(expandedRepos map[string]*types.RepositoryConfig) []indexTask {
+	tasks := make([]indexTask, 0, len(expandedRepos))
+	for alias, repoConfig := range expandedRepos {
+		tasks = append(tasks, indexTask{alias: alias, config: repoConfig})
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].alias < tasks[j].alias })
+	return tasks
+}
+
+// ************************************************************************************************
+// indexExpandedRepositories indexes expanded repositories, up to index.parallelism at once, or
+// --parallel for the index command. done is called with the outcome of each repository as it
+// ends, one call at a time.
+//
+// Example usage:
+//
+//	app.indexExpandedRepositories(ctx, tasks, func(result indexResult) {
+//		if result.err != nil {
+//			failed++
+//		}
+//	})
+func (app *Application) indexExpandedRepositories(ctx context.Context, tasks []indexTask, done func(indexResult)) {
+	parallelism := app.configManager.GetConfig().Index.Parallelism
+	if indexParallelism > 0 {
+		parallelism = indexParallelism
+	}
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if parallelism > 1 && len(tasks) > 1 {
+		slog.InfoContext(ctx, "Indexing repositories in parallel", "repositories", len(tasks), "parallelism", min(parallelism, len(tasks)))
+	}
+
+	var wg sync.WaitGroup
+	var doneMu sync.Mutex
+	slots := make(chan struct{}, parallelism)
+	for _, task := range tasks {
+		slots <- struct{}{}
+
+		// Repositories not started yet are not indexed once ctx is cancelled
+		if err := ctx.Err(); err != nil {
+			<-slots
+			doneMu.Lock()
+			done(indexResult{alias: task.alias, err: err})
+			doneMu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(task indexTask) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			repoIndex, err := app.indexExpandedRepository(ctx, task.alias, task.config)
+			doneMu.Lock()
+			defer doneMu.Unlock()
+			done(indexResult{alias: task.alias, index: repoIndex, err: err})
+		}(task)
+	}
+	wg.Wait()
 }
 
 // ************************************************************************************************
@@ -163,55 +304,107 @@ func (app *Application) IndexAllRepositories() error {
 // Returns:
 //   - error: An error if indexing fails.
 func (app *Application) IndexRepository(alias string) error {
+	return app.IndexRepositoryContext(context.Background(), alias, nil)
+}
+
+// ************************************************************************************************
+// IndexRepositoryContext indexes a specific repository like IndexRepository, reporting the
+// repositories and files indexed to progress when not nil. Cloning, downloading and repomix
+// stop when ctx is cancelled.
+//
+// Returns:
+//   - error: An error if indexing fails or ctx is cancelled.
+func (app *Application) IndexRepositoryContext(ctx context.Context, alias string, progress types.ProgressFunc) error {
 	// Get repository configuration
 	repoConfig, err := app.configManager.GetRepository(alias)
 	if err != nil {
 		return fmt.Errorf("failed to get repository config\n>    %w", err)
 	}
 
+	if repoConfig.Disabled || !repoConfig.Indexing.Enabled {
+		slog.InfoContext(ctx, "Skipping disabled repository", "repository", alias)
+		return nil
+	}
+
 	// Expand glob patterns if present
 	expandedRepos, err := app.repoManager.ExpandGlobRepositories(alias, repoConfig)
 	if err != nil {
 		return fmt.Errorf("failed to expand glob for repository %s\n>    %w", alias, err)
 	}
 
-	log.Printf("Repository %s expanded to %d repositories", alias, len(expandedRepos))
+	slog.Info("Expanded repository", "repository", alias, "repositories", len(expandedRepos))
 
-	// Index each expanded repository
-	for expandedAlias, expandedConfig := range expandedRepos {
-		if err := app.indexExpandedRepository(expandedAlias, expandedConfig); err != nil {
-			return fmt.Errorf("failed to index repository %s\n>    %w", expandedAlias, err)
+	// Configured aliases own their IDs; glob expansions must not take them over
+	repoIDs := types.NewRepositoryIDSet()
+	for _, configuredAlias := range app.configManager.GetRepositoryAliases() {
+		repoIDs.Claim(configuredAlias, configuredAlias)
+	}
+
+	tasks := sortedIndexTasks(expandedRepos)
+	for _, task := range tasks {
+		if err := repoIDs.Claim(task.alias, alias); err != nil {
+			return fmt.Errorf("failed to index repository %s\n>    %w", task.alias, err)
 		}
-		log.Printf("Successfully indexed repository: %s", expandedAlias)
 	}
 
-	return nil
+	// Index each expanded repository, the others going on when one fails
+	done, files := 0, 0
+	var failures []error
+	app.indexExpandedRepositories(ctx, tasks, func(result indexResult) {
+		done++
+		if result.err != nil {
+			if errors.Is(result.err, types.ErrIndexingDisabled) {
+				slog.InfoContext(ctx, "Skipping disabled repository", "repository", result.alias)
+				return
+			}
+			failures = append(failures, fmt.Errorf("failed to index repository %s\n>    %w", result.alias, result.err))
+			return
+		}
+		slog.InfoContext(ctx, "Indexed repository", "repository", result.alias)
+
+		files += len(result.index.Files)
+		if progress != nil {
+			progress(done, len(tasks), fmt.Sprintf("Indexed %s: %d files, %d files in total", result.alias, len(result.index.Files), files))
+		}
+	})
+
+	return errors.Join(failures...)
 }
 
 // ************************************************************************************************
 // indexExpandedRepository indexes a single expanded repository (internal method).
 //
 // Returns:
+//   - *types.RepositoryIndex: The indexed repository.
 //   - error: An error if indexing fails.
-func (app *Application) indexExpandedRepository(alias string, repoConfig *types.RepositoryConfig) error {
-	log.Printf("Indexing repository: %s", alias)
+func (app *Application) indexExpandedRepository(ctx context.Context, alias string, repoConfig *types.RepositoryConfig) (*types.RepositoryIndex, error) {
+	slog.InfoContext(ctx, "Indexing repository", "repository", alias)
+
+	// Nothing to prepare for a repository that will not be indexed
+	if !repoConfig.Indexing.Enabled {
+		return nil, fmt.Errorf("%w: %s", types.ErrIndexingDisabled, alias)
+	}
 
 	// Prepare repository (clone/update if needed)
-	localPath, err := app.repoManager.PrepareRepository(alias, repoConfig)
+	localPath, err := app.repoManager.PrepareRepositoryContext(ctx, alias, repoConfig)
 	if err != nil {
-		return fmt.Errorf("failed to prepare repository\n>    %w", err)
+		return nil, fmt.Errorf("failed to prepare repository\n>    %w", err)
 	}
 
-	// Index repository content
-	repoIndex, err := app.indexer.IndexRepository(alias, localPath, repoConfig.Indexing)
+	// Index repository content, only the files changed since the cached index when possible
+	previous, err := app.cache.GetRepositoryContext(ctx, alias)
+	if err != nil {
+		previous = nil
+	}
+	repoIndex, err := app.indexer.IndexRepositoryIncremental(ctx, previous, alias, localPath, repoConfig.Indexing)
 	if err != nil {
-		return fmt.Errorf("failed to index repository content\n>    %w", err)
+		return nil, fmt.Errorf("failed to index repository content\n>    %w", err)
 	}
 
 	// Get additional repository metadata
 	repoInfo, err := app.repoManager.GetRepositoryInfo(alias, localPath)
 	if err != nil {
-		log.Printf("Warning: failed to get repository info for %s: %v", alias, err)
+		slog.WarnContext(ctx, "Failed to get repository info", "repository", alias, "error", err)
 	} else {
 		// Merge metadata
 		repoIndex.CommitHash = repoInfo.CommitHash
@@ -221,30 +414,30 @@ func (app *Application) indexExpandedRepository(alias string, repoConfig *types.
 	}
 
 	// Store in cache
-	if err = app.cache.StoreRepository(repoIndex); err != nil {
-		return fmt.Errorf("failed to store repository in cache\n>    %w", err)
+	if err = app.cache.StoreRepositoryContext(ctx, repoIndex); err != nil {
+		return nil, fmt.Errorf("failed to store repository in cache\n>    %w", err)
 	}
 
 	// Verbose logging for cache operations
 	if verbose {
 		data, _ := json.Marshal(repoIndex)
 		preview := app.cache.FormatValuePreview(data)
-		log.Printf("[CACHE] Stored key: repo:%s -> %s", repoIndex.ID, preview)
+		slog.InfoContext(ctx, "Stored cache key", "key", "repo:"+repoIndex.ID, "value", preview)
 
 		// Log file-level storage if any files were indexed
 		for _, file := range repoIndex.Files {
 			fileData, _ := json.Marshal(file)
 			filePreview := app.cache.FormatValuePreview(fileData)
-			log.Printf("[CACHE] Stored key: file:%s:%s -> %s", repoIndex.ID, file.Path, filePreview)
+			slog.InfoContext(ctx, "Stored cache key", "key", "file:"+repoIndex.ID+":"+file.Path, "value", filePreview)
 		}
 	}
 
 	// Update MCP server
 	if err = app.mcpServer.UpdateRepository(repoIndex); err != nil {
-		return fmt.Errorf("failed to update MCP server\n>    %w", err)
+		return nil, fmt.Errorf("failed to update MCP server\n>    %w", err)
 	}
 
-	return nil
+	return repoIndex, nil
 }
 
 // ************************************************************************************************
@@ -253,34 +446,127 @@ func (app *Application) indexExpandedRepository(alias string, repoConfig *types.
 // Returns:
 //   - error: An error if server startup fails.
 func (app *Application) StartServer() error {
-	log.Println("Starting MCP server...")
+	slog.Info("Starting MCP server")
 
 	// Set verbose mode if enabled
 	if verbose {
 		app.mcpServer.SetVerbose(true)
-		log.Println("Verbose cache logging enabled for MCP server")
+		slog.Info("Verbose cache logging enabled for MCP server")
+	}
+
+	// Override configured listen addresses with --bind
+	if len(bindAddresses) > 0 {
+		for _, address := range bindAddresses {
+			if err := config.ValidateListenAddress(address); err != nil {
+				return fmt.Errorf("invalid --bind address\n>    %w", err)
+			}
+		}
+		app.configManager.GetConfig().Server.ListenAddresses = bindAddresses
+	}
+
+	// Load the cached repositories, which indexes them for search
+	if _, _, err := app.mcpServer.ReloadRepositories(); err != nil {
+		slog.Warn("Failed to load cached repositories", "error", err)
 	}
 
+	// Delete expired cache entries periodically when configured
+	if interval := app.configManager.GetConfig().Cache.PruneInterval; interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+			go app.pruneExpiredPeriodically(d)
+		}
+	}
+
+	app.serving.Store(true)
 	return app.mcpServer.Start()
 }
 
+// ************************************************************************************************
+// pruneExpiredPeriodically deletes the expired cache entries at every interval, for the
+// lifetime of the process.
+func (app *Application) pruneExpiredPeriodically(interval time.Duration) {
+	slog.Info("Pruning expired cache entries periodically", "interval", interval.String())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		pruned, err := app.cache.PruneExpired()
+		if err != nil {
+			slog.Warn("Failed to prune expired cache entries", "error", err)
+			continue
+		}
+		if pruned > 0 {
+			slog.Info("Pruned expired cache entries", "entries", pruned)
+		}
+	}
+}
+
+// ************************************************************************************************
+// WarmGoModules fetches the documentation of Go modules into the cache. Progress is
+// checkpointed in a manifest so an interrupted run can be resumed.
+//
+// Returns:
+//   - error: An error if warming cannot start, the manifest cannot be saved or a module failed.
+func (app *Application) WarmGoModules(modules []string, manifestPath string, retryFailed bool) error {
+	config := app.configManager.GetConfig()
+	if !config.GoModule.Enabled {
+		return fmt.Errorf("%w: Go module documentation is disabled (goModule.enabled)", types.ErrInvalidConfig)
+	}
+
+	retriever, err := godoc.NewGoDocRetriever(&config.GoModule, app.cache)
+	if err != nil {
+		return fmt.Errorf("failed to initialize Go module retriever\n>    %w", err)
+	}
+	retriever.SetVerbose(verbose)
+	retriever.SetHashAlgorithm(config.Cache.HashAlgorithm)
+
+	if manifestPath == "" {
+		manifestPath = filepath.Join(config.Cache.Path, "warm-manifest.json")
+	}
+	manifest, err := godoc.LoadWarmManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	summary, err := retriever.WarmModules(modules, manifest, retryFailed)
+	slog.Info("Completed warming", "warmed", summary.Warmed, "skipped", summary.Skipped, "failed", summary.Failed, "manifest", manifestPath)
+	if err != nil {
+		return fmt.Errorf("warming interrupted\n>    %w", err)
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d modules failed to warm, rerun with --retry-failed to try them again", summary.Failed)
+	}
+	return nil
+}
+
 // ************************************************************************************************
 // Cleanup cleans up application resources.
 //
 // Returns:
 //   - error: An error if cleanup fails.
 func (app *Application) Cleanup() error {
-	log.Println("Cleaning up application resources...")
+	slog.Info("Cleaning up application resources")
 
 	if app.indexer != nil {
 		if err := app.indexer.Close(); err != nil {
-			log.Printf("Warning: failed to close indexer: %v", err)
+			slog.Warn("Failed to close indexer", "error", err)
 		}
 	}
 
 	if app.cache != nil {
 		if err := app.cache.Close(); err != nil {
-			log.Printf("Warning: failed to close cache: %v", err)
+			slog.Warn("Failed to close cache", "error", err)
+		}
+	}
+
+	if app.tracer != nil {
+		if err := app.tracer.Close(); err != nil {
+			slog.Warn("Failed to export pending traces", "error", err)
+		}
+	}
+
+	if app.logFile != nil {
+		if err := app.logFile.Close(); err != nil {
+			slog.Warn("Failed to close log file", "error", err)
 		}
 	}
 
@@ -333,7 +619,7 @@ func runListKeysCommand(cmd *cobra.Command, args []string) error {
 	}
 
 	// Format and display output
-	return formatKeysOutput(cacheInstance, keys, format, verbose)
+	return formatKeysOutput(cacheInstance, keys, format, verbose, previewLength)
 }
 
 // ************************************************************************************************
@@ -365,21 +651,100 @@ func runGetContentCommand(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		// Get specific key content
 		key := args[0]
-		return getSpecificKeyContent(cacheInstance, key, format)
+		if outputFile == "" {
+			return getSpecificKeyContent(cacheInstance, key, format, decodeValue, os.Stdout)
+		}
+
+		err := writeOutputFile(outputFile, func(out io.Writer) error {
+			return getSpecificKeyContent(cacheInstance, key, format, decodeValue, out)
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Content of %s written to %s\n", key, outputFile)
+		return nil
+	} else if outputFile != "" {
+		return fmt.Errorf("--output-file requires a key argument")
+	} else if decodeValue {
+		return fmt.Errorf("--decode requires a key argument")
 	} else {
 		// Get all keys with content preview
-		return getAllKeysContent(cacheInstance, format, filter)
+		return getAllKeysContent(cacheInstance, format, filter, previewLength)
 	}
 }
 
+// ************************************************************************************************
+// runRenameCommand executes the rename command logic.
+func runRenameCommand(cmd *cobra.Command, args []string) error {
+	oldID, newID := args[0], args[1]
+
+	var cacheInstance *cache.Cache
+	var err error
+
+	// Initialize cache instance based on flags
+	if dbPath != "" {
+		cacheInstance, err = cache.NewCacheFromPath(dbPath)
+		if err != nil {
+			return fmt.Errorf("failed to open cache from path %s\n>    %w", dbPath, err)
+		}
+		defer cacheInstance.Close()
+	} else {
+		if app == nil {
+			return fmt.Errorf("application not initialized")
+		}
+		cacheInstance = app.cache
+	}
+
+	if err := cacheInstance.RenameRepository(oldID, newID); err != nil {
+		return fmt.Errorf("failed to rename %s to %s\n>    %w", oldID, newID, err)
+	}
+	fmt.Printf("Renamed repository %s to %s\n", oldID, newID)
+
+	// The cache follows the configuration, remind to rename the alias there too
+	if app != nil && app.configManager != nil {
+		if _, exists := app.configManager.GetConfig().Repositories[newID]; !exists {
+			fmt.Printf("Note: %s is not a repository alias of %s, rename it in the configuration\n", newID, configFile)
+		}
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// runPruneExpiredCommand executes the prune-expired command logic.
+func runPruneExpiredCommand(cmd *cobra.Command, args []string) error {
+	var cacheInstance *cache.Cache
+	var err error
+
+	// Initialize cache instance based on flags
+	if dbPath != "" {
+		cacheInstance, err = cache.NewCacheFromPath(dbPath)
+		if err != nil {
+			return fmt.Errorf("failed to open cache from path %s\n>    %w", dbPath, err)
+		}
+		defer cacheInstance.Close()
+	} else {
+		if app == nil {
+			return fmt.Errorf("application not initialized")
+		}
+		cacheInstance = app.cache
+	}
+
+	pruned, err := cacheInstance.PruneExpired()
+	if err != nil {
+		return fmt.Errorf("failed to prune expired entries\n>    %w", err)
+	}
+	fmt.Printf("Pruned %d expired cache entries\n", pruned)
+	return nil
+}
+
 // ************************************************************************************************
 // formatKeysOutput formats and displays the keys output based on the specified format.
-func formatKeysOutput(cacheInstance *cache.Cache, keys []string, outputFormat string, verbose bool) error {
+func formatKeysOutput(cacheInstance *cache.Cache, keys []string, outputFormat string, verbose bool, previewLength int) error {
 	switch outputFormat {
 	case "table":
-		return formatKeysTable(cacheInstance, keys, verbose)
+		return formatKeysTable(cacheInstance, keys, verbose, previewLength)
 	case "json":
-		return formatKeysJSON(cacheInstance, keys, verbose)
+		return formatKeysJSON(cacheInstance, keys, verbose, previewLength)
 	case "raw":
 		return formatKeysRaw(keys)
 	default:
@@ -389,7 +754,7 @@ func formatKeysOutput(cacheInstance *cache.Cache, keys []string, outputFormat st
 
 // ************************************************************************************************
 // formatKeysTable formats keys output as a human-readable table.
-func formatKeysTable(cacheInstance *cache.Cache, keys []string, verbose bool) error {
+func formatKeysTable(cacheInstance *cache.Cache, keys []string, verbose bool, previewLength int) error {
 	if len(keys) == 0 {
 		fmt.Println("No keys found in cache.")
 		return nil
@@ -412,7 +777,7 @@ func formatKeysTable(cacheInstance *cache.Cache, keys []string, verbose bool) er
 				continue
 			}
 
-			preview := cacheInstance.FormatValuePreview(rawValue)
+			preview := cacheInstance.FormatValuePreviewLength(rawValue, previewLength)
 			keyType := info["type"].(string)
 			size := fmt.Sprintf("%d bytes", info["value_size"].(int))
 
@@ -444,7 +809,7 @@ func formatKeysTable(cacheInstance *cache.Cache, keys []string, verbose bool) er
 
 // ************************************************************************************************
 // formatKeysJSON formats keys output as JSON.
-func formatKeysJSON(cacheInstance *cache.Cache, keys []string, verbose bool) error {
+func formatKeysJSON(cacheInstance *cache.Cache, keys []string, verbose bool, previewLength int) error {
 	if verbose {
 		var detailedKeys []map[string]interface{}
 		for _, key := range keys {
@@ -461,7 +826,7 @@ func formatKeysJSON(cacheInstance *cache.Cache, keys []string, verbose bool) err
 			if err != nil {
 				info["preview_error"] = err.Error()
 			} else {
-				info["preview"] = cacheInstance.FormatValuePreview(rawValue)
+				info["preview"] = cacheInstance.FormatValuePreviewLength(rawValue, previewLength)
 			}
 
 			detailedKeys = append(detailedKeys, info)
@@ -517,9 +882,41 @@ func formatKeysRaw(keys []string) error {
 }
 
 // ************************************************************************************************
-// getSpecificKeyContent retrieves and displays content for a specific key.
-func getSpecificKeyContent(cacheInstance *cache.Cache, key, outputFormat string) error {
-	rawValue, err := cacheInstance.GetRawValue(key)
+// writeOutputFile writes a file through a temporary file in the same directory, renamed over
+// the target once complete, so a failure leaves an existing file untouched.
+func writeOutputFile(path string, write func(out io.Writer) error) error {
+	out, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create output file %s\n>    %w", path, err)
+	}
+	defer os.Remove(out.Name())
+
+	if err := write(out); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Chmod(0644); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to write output file %s\n>    %w", path, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write output file %s\n>    %w", path, err)
+	}
+	if err := os.Rename(out.Name(), path); err != nil {
+		return fmt.Errorf("failed to write output file %s\n>    %w", path, err)
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// getSpecificKeyContent retrieves content for a specific key and writes it to out. With decode,
+// the value is decoded as the application reads it, see cache.GetDecodedValue.
+func getSpecificKeyContent(cacheInstance *cache.Cache, key, outputFormat string, decode bool, out io.Writer) error {
+	getValue := cacheInstance.GetRawValue
+	if decode {
+		getValue = cacheInstance.GetDecodedValue
+	}
+	rawValue, err := getValue(key)
 	if err != nil {
 		return fmt.Errorf("failed to get content for key %s\n>    %w", key, err)
 	}
@@ -531,16 +928,16 @@ func getSpecificKeyContent(cacheInstance *cache.Cache, key, outputFormat string)
 			return fmt.Errorf("failed to get key info: %w", err)
 		}
 
-		fmt.Printf("Key: %s\n", key)
-		fmt.Printf("Type: %s\n", info["type"])
-		fmt.Printf("Size: %d bytes\n", info["value_size"])
+		fmt.Fprintf(out, "Key: %s\n", key)
+		fmt.Fprintf(out, "Type: %s\n", info["type"])
+		fmt.Fprintf(out, "Size: %d bytes\n", info["value_size"])
 		if info["ttl_seconds"] != nil {
-			fmt.Printf("TTL: %d seconds\n", info["ttl_seconds"])
+			fmt.Fprintf(out, "TTL: %d seconds\n", info["ttl_seconds"])
 		} else {
-			fmt.Printf("TTL: No expiration\n")
+			fmt.Fprintf(out, "TTL: No expiration\n")
 		}
-		fmt.Println(strings.Repeat("-", 50))
-		fmt.Println(string(rawValue))
+		fmt.Fprintln(out, strings.Repeat("-", 50))
+		fmt.Fprintln(out, string(rawValue))
 
 	case "json":
 		info, err := cacheInstance.GetKeyInfo(key)
@@ -558,10 +955,10 @@ func getSpecificKeyContent(cacheInstance *cache.Cache, key, outputFormat string)
 		if err != nil {
 			return fmt.Errorf("failed to marshal JSON: %w", err)
 		}
-		fmt.Println(string(data))
+		fmt.Fprintln(out, string(data))
 
 	case "raw":
-		fmt.Print(string(rawValue))
+		fmt.Fprint(out, string(rawValue))
 
 	default:
 		return fmt.Errorf("invalid format: %s", outputFormat)
@@ -572,7 +969,7 @@ func getSpecificKeyContent(cacheInstance *cache.Cache, key, outputFormat string)
 
 // ************************************************************************************************
 // getAllKeysContent retrieves and displays content preview for all keys.
-func getAllKeysContent(cacheInstance *cache.Cache, outputFormat, filter string) error {
+func getAllKeysContent(cacheInstance *cache.Cache, outputFormat, filter string, previewLength int) error {
 	// Determine key prefix based on filter
 	var prefix string
 	switch filter {
@@ -594,7 +991,7 @@ func getAllKeysContent(cacheInstance *cache.Cache, outputFormat, filter string)
 	switch outputFormat {
 	case "table":
 		for key, value := range keysWithValues {
-			preview := cacheInstance.FormatValuePreview(value)
+			preview := cacheInstance.FormatValuePreviewLength(value, previewLength)
 			fmt.Printf("%s\n\t%s\n\n", key, preview)
 		}
 		fmt.Printf("Total keys: %d\n", len(keysWithValues))
@@ -604,7 +1001,7 @@ func getAllKeysContent(cacheInstance *cache.Cache, outputFormat, filter string)
 		for key, value := range keysWithValues {
 			output[key] = map[string]interface{}{
 				"size":    len(value),
-				"preview": cacheInstance.FormatValuePreview(value),
+				"preview": cacheInstance.FormatValuePreviewLength(value, previewLength),
 				"content": string(value),
 			}
 		}
@@ -684,10 +1081,48 @@ The server will listen on the configured host and port and provide the following
 - resolve-library-id: Resolve library names to repository IDs
 - get-library-docs: Retrieve repository documentation content`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// The server returns once stopped by a signal, close the cache before exiting
+		defer app.Cleanup()
 		return app.StartServer()
 	},
 }
 
+// ************************************************************************************************
+// warmCmd represents the warm command
+var warmCmd = &cobra.Command{
+	Use:   "warm [module...]",
+	Short: "Fetch Go module documentation into the cache",
+	Long: `Fetch the documentation of Go modules into the cache ahead of time, so the MCP server
+can serve them without fetching on the first request.
+
+Modules are given as arguments and/or in a list file (one module per line, '#' starts a
+comment). At most goModule.maxConcurrent modules are fetched at a time and every go command
+is bound by goModule.commandTimeout.
+
+Every outcome is recorded in a manifest. A rerun skips the modules that succeeded, so an
+interrupted warm resumes where it stopped, and the modules that failed, unless
+--retry-failed is given.
+
+Examples:
+  repomix-mcp warm github.com/gin-gonic/gin golang.org/x/sync
+  repomix-mcp warm --file modules.txt
+  repomix-mcp warm --file modules.txt --manifest ./warm.json --retry-failed`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		modules := args
+		if warmFile != "" {
+			data, err := os.ReadFile(warmFile)
+			if err != nil {
+				return fmt.Errorf("failed to read module list\n>    %w", err)
+			}
+			modules = append(modules, godoc.ParseModuleList(string(data))...)
+		}
+		if len(modules) == 0 {
+			return fmt.Errorf("no modules given: pass module paths or --file")
+		}
+		return app.WarmGoModules(modules, warmManifest, warmRetryFailed)
+	},
+}
+
 // ************************************************************************************************
 // validateCmd represents the validate command
 var validateCmd = &cobra.Command{
@@ -699,39 +1134,64 @@ This command will:
 - Validate the configuration file syntax and settings
 - Check that repomix CLI is available
 - Verify repository access (for remote repositories)
-- Test cache directory permissions`,
+- Test cache directory permissions
+
+All problems are reported before the command fails; it exits with a non-zero
+status when any check failed. Use --fail-fast to stop at the first failure.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		log.Println("Validating configuration...")
+		slog.Info("Validating configuration")
+
+		// Failures are counted and reported at the end unless --fail-fast is set
+		failures := 0
+		fail := func(err error) error {
+			slog.Error("Validation check failed", "error", err)
+			failures++
+			if validateFailFast {
+				return err
+			}
+			return nil
+		}
 
 		// Validate repomix availability
 		if err := app.indexer.ValidateRepomix(); err != nil {
-			return fmt.Errorf("repomix validation failed\n>    %w", err)
+			if err := fail(fmt.Errorf("repomix validation failed\n>    %w", err)); err != nil {
+				return err
+			}
 		}
 
 		// Get repomix version
 		version, err := app.indexer.GetRepomixVersion()
 		if err != nil {
-			log.Printf("Warning: could not get repomix version: %v", err)
+			slog.Warn("Could not get repomix version", "error", err)
 		} else {
-			log.Printf("Repomix version: %s", version)
+			slog.Info("Repomix version", "version", version)
 		}
 
 		// Validate repository access
 		aliases := app.configManager.GetRepositoryAliases()
-		log.Printf("Validating %d repositories...", len(aliases))
+		slog.Info("Validating repositories", "repositories", len(aliases))
 
 		totalValidated := 0
 		for _, alias := range aliases {
 			repoConfig, err := app.configManager.GetRepository(alias)
 			if err != nil {
-				log.Printf("Error: invalid repository config for %s: %v", alias, err)
+				if err := fail(fmt.Errorf("invalid repository config for %s: %w", alias, err)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if repoConfig.Disabled {
+				slog.Info("Skipping disabled repository", "repository", alias)
 				continue
 			}
 
 			// Expand glob patterns if present
 			expandedRepos, err := app.repoManager.ExpandGlobRepositories(alias, repoConfig)
 			if err != nil {
-				log.Printf("Error: failed to expand glob for repository %s: %v", alias, err)
+				if err := fail(fmt.Errorf("failed to expand glob for repository %s: %w", alias, err)); err != nil {
+					return err
+				}
 				continue
 			}
 
@@ -740,25 +1200,34 @@ This command will:
 				// Test repository preparation (without full indexing)
 				_, err = app.repoManager.PrepareRepository(expandedAlias, expandedConfig)
 				if err != nil {
-					log.Printf("Error: cannot access repository %s: %v", expandedAlias, err)
+					if err := fail(fmt.Errorf("cannot access repository %s: %w", expandedAlias, err)); err != nil {
+						return err
+					}
 					continue
 				}
 
-				log.Printf("✓ Repository %s is accessible", expandedAlias)
+				slog.Info("✓ Repository is accessible", "repository", expandedAlias)
 				totalValidated++
 			}
 		}
 
-		log.Printf("✓ Validated %d total repositories (including expanded glob patterns)", totalValidated)
+		slog.Info("✓ Validated repositories, including expanded glob patterns", "repositories", totalValidated)
 
 		// Test cache operations
 		stats, err := app.cache.GetCacheStats()
 		if err != nil {
-			return fmt.Errorf("cache validation failed\n>    %w", err)
+			if err := fail(fmt.Errorf("cache validation failed\n>    %w", err)); err != nil {
+				return err
+			}
+		} else {
+			slog.Info("Cache statistics", "stats", stats)
 		}
 
-		log.Printf("Cache statistics: %+v", stats)
-		log.Println("✓ All validations passed")
+		if failures > 0 {
+			return fmt.Errorf("validation failed: %d check(s) failed", failures)
+		}
+
+		slog.Info("✓ All validations passed")
 
 		return nil
 	},
@@ -797,7 +1266,7 @@ Examples:
 			return fmt.Errorf("failed to create example config\n>    %w", err)
 		}
 
-		log.Printf("Example configuration saved to: %s", outputFile)
+		slog.Info("Example configuration saved", "path", outputFile)
 		return nil
 	},
 }
@@ -818,7 +1287,8 @@ Examples:
   repomix-mcp listkeys --verbose                         # Show detailed key information
   repomix-mcp listkeys --format json                     # Output in JSON format
   repomix-mcp listkeys --filter repo                     # Show only repository keys
-  repomix-mcp listkeys --filter file                     # Show only file keys`,
+  repomix-mcp listkeys --filter file                     # Show only file keys
+  repomix-mcp listkeys --verbose --preview-length 200    # Show longer value previews`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runListKeysCommand(cmd, args)
 	},
@@ -839,12 +1309,63 @@ Examples:
   repomix-mcp getcontent "repo:my-project"               # Show full content for specific key
   repomix-mcp getcontent --db-path ~/.repomix-mcp        # Use direct cache path
   repomix-mcp getcontent --format json                   # Output in JSON format
-  repomix-mcp getcontent --filter repo                   # Show only repository content`,
+  repomix-mcp getcontent --filter repo                   # Show only repository content
+  repomix-mcp getcontent --preview-length 200            # Show longer content previews
+  repomix-mcp getcontent "blob:<hash>" --format raw -o repomix.xml  # Dump stored file content to disk
+  repomix-mcp getcontent "repo:my-project" --decode --format raw -o index.json  # Dump the whole index
+
+With --output-file, the content of the given key is written to the file in the selected
+format; --format raw writes the stored value unchanged. File contents are stored once under
+"blob:<sha256>" keys, referenced by the contentRef of repository and file entries, and large
+repository entries are split into "repopart:" keys. With --decode, a repository entry is
+reassembled and shown with the contents of its files, and a file entry shows the file content.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runGetContentCommand(cmd, args)
 	},
 }
 
+// ************************************************************************************************
+// renameCmd represents the rename command
+var renameCmd = &cobra.Command{
+	Use:   "rename <old-id> <new-id>",
+	Short: "Rename a repository ID in the cache",
+	Long: `Move a cached repository index and its file entries to a new repository ID.
+
+Renaming a repository alias in the configuration leaves its index under the old ID and
+starts the new alias empty; renaming the cache entries as well avoids indexing it again.
+The file entries are copied in batches, then the repository entry is switched to the new ID
+and the old entries are deleted; content is kept as is. The command fails if the new ID is
+already cached.
+
+Examples:
+  repomix-mcp rename my-project my-app                    # Rename using config file
+  repomix-mcp rename my-project my-app --db-path ~/.repomix-mcp`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRenameCommand(cmd, args)
+	},
+}
+
+// ************************************************************************************************
+// pruneExpiredCmd represents the prune-expired command
+var pruneExpiredCmd = &cobra.Command{
+	Use:   "prune-expired",
+	Short: "Delete expired cache entries",
+	Long: `Delete the cache entries whose TTL (cache.ttl) has passed, then reclaim value log space.
+
+BadgerDB drops expired entries lazily during compaction, so their space stays in use until
+then. This command deletes them explicitly and reports how many were pruned. A running
+server can do the same periodically with cache.pruneInterval.
+
+Examples:
+  repomix-mcp prune-expired                               # Prune using config file
+  repomix-mcp prune-expired --db-path ~/.repomix-mcp`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPruneExpiredCommand(cmd, args)
+	},
+}
+
 // ************************************************************************************************
 // clientCmd represents the client command
 var clientCmd = &cobra.Command{
@@ -872,8 +1393,12 @@ func runClientCommand(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create MCP client: %w", err)
 	}
 
-	// Set verbose mode
+	// Set verbose mode and credentials
 	client.SetVerbose(verbose)
+	if mcpAPIKey == "" {
+		mcpAPIKey = os.Getenv("REPOMIX_MCP_API_KEY")
+	}
+	client.SetAPIKey(mcpAPIKey)
 
 	// Connect to server
 	if err := client.Connect(); err != nil {
@@ -928,7 +1453,7 @@ func handleToolExecution(client *mcpclient.Client, toolName, argsString string)
 	}
 
 	if verbose {
-		log.Printf("Executing tool '%s' with arguments: %+v", toolName, args)
+		slog.Info("Executing tool", "tool", toolName, "arguments", args)
 	}
 
 	// Execute tool
@@ -963,11 +1488,31 @@ var (
 	format     string
 	filter     string
 
+	// Cache inspection flags
+	previewLength int
+	outputFile    string
+	decodeValue   bool
+
+	// Index flags
+	indexParallelism int
+
+	// Server flags
+	bindAddresses []string
+
+	// Validate flags
+	validateFailFast bool
+
+	// Warm flags
+	warmFile        string
+	warmManifest    string
+	warmRetryFailed bool
+
 	// MCP client flags
 	mcpServerAddress string
 	mcpListTools     bool
 	mcpToolName      string
 	mcpToolArgs      string
+	mcpAPIKey        string
 )
 
 func init() {
@@ -979,20 +1524,39 @@ func init() {
 	listKeysCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "show detailed key information")
 	listKeysCmd.Flags().StringVar(&format, "format", "table", "output format (table, json, raw)")
 	listKeysCmd.Flags().StringVar(&filter, "filter", "", "filter keys by type (repo, file)")
+	listKeysCmd.Flags().IntVar(&previewLength, "preview-length", cache.DefaultPreviewLength, "number of characters shown in value previews")
 
 	getContentCmd.Flags().StringVarP(&dbPath, "db-path", "d", "", "direct path to cache directory (bypasses config file)")
 	getContentCmd.Flags().StringVar(&format, "format", "table", "output format (table, json, raw)")
 	getContentCmd.Flags().StringVar(&filter, "filter", "", "filter keys by type (repo, file)")
+	getContentCmd.Flags().IntVar(&previewLength, "preview-length", cache.DefaultPreviewLength, "number of characters shown in value previews")
+	getContentCmd.Flags().StringVarP(&outputFile, "output-file", "o", "", "write the content of the given key to a file instead of stdout")
+	getContentCmd.Flags().BoolVar(&decodeValue, "decode", false, "reassemble repository entries and resolve file contents instead of showing the stored value")
+
+	renameCmd.Flags().StringVarP(&dbPath, "db-path", "d", "", "direct path to cache directory (bypasses config file)")
+	pruneExpiredCmd.Flags().StringVarP(&dbPath, "db-path", "d", "", "direct path to cache directory (bypasses config file)")
 
 	// Add verbose flag to existing commands
 	indexCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "show detailed cache operations during indexing")
+	indexCmd.Flags().IntVarP(&indexParallelism, "parallel", "j", 0, "repositories indexed at once (default: index.parallelism from the configuration)")
 	serveCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "show detailed cache operations during serving")
+	serveCmd.Flags().StringSliceVar(&bindAddresses, "bind", nil, "HTTP listen addresses (host:port), repeatable or comma-separated; overrides the configuration")
+
+	// Add validate command flags
+	validateCmd.Flags().BoolVar(&validateFailFast, "fail-fast", false, "stop at the first failed check instead of reporting all problems")
+
+	// Add warm command flags
+	warmCmd.Flags().StringVarP(&warmFile, "file", "f", "", "file listing the modules to warm, one per line")
+	warmCmd.Flags().StringVar(&warmManifest, "manifest", "", "progress manifest path (default: <cache path>/warm-manifest.json)")
+	warmCmd.Flags().BoolVar(&warmRetryFailed, "retry-failed", false, "retry modules recorded as failed in the manifest")
+	warmCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "log every go command")
 
 	// Add MCP client command flags
 	clientCmd.Flags().StringVar(&mcpServerAddress, "mcp-srv", "127.0.0.1:9080", "MCP server address (e.g., 127.0.0.1:9080 or https://server.com:9443)")
 	clientCmd.Flags().BoolVar(&mcpListTools, "mcp-list", false, "list available tools from the MCP server")
 	clientCmd.Flags().StringVar(&mcpToolName, "mcp-use", "", "tool name to execute")
 	clientCmd.Flags().StringVar(&mcpToolArgs, "mcp-args", "", "tool arguments in 'key=value,key2=value2' format")
+	clientCmd.Flags().StringVar(&mcpAPIKey, "mcp-key", "", "API key of servers requiring authentication (default: $REPOMIX_MCP_API_KEY)")
 	clientCmd.Flags().StringVar(&format, "format", "json", "output format (json, table, raw)")
 	clientCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "show detailed connection and execution information")
 
@@ -1000,10 +1564,13 @@ func init() {
 	rootCmd.AddCommand(indexCmd)
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(warmCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(clientCmd)
 	rootCmd.AddCommand(listKeysCmd)
 	rootCmd.AddCommand(getContentCmd)
+	rootCmd.AddCommand(renameCmd)
+	rootCmd.AddCommand(pruneExpiredCmd)
 
 	// Add config subcommands
 	configCmd.AddCommand(configExampleCmd)
@@ -1018,13 +1585,41 @@ func main() {
 
 	go func() {
 		<-sigChan
-		log.Println("Received shutdown signal...")
+
+		// A running server drains its requests and returns from serve, which cleans up; a second
+		// signal does not wait for it
+		if app != nil && app.serving.Load() {
+			slog.Info("Received shutdown signal, draining in-flight requests")
+			go app.mcpServer.Stop()
+			<-sigChan
+			slog.Warn("Received second shutdown signal, exiting immediately")
+			os.Exit(1)
+		}
+
+		slog.Info("Received shutdown signal")
 		if app != nil {
 			app.Cleanup()
 		}
 		os.Exit(0)
 	}()
 
+	// Reload repositories from cache on SIGHUP so a running server picks up
+	// repositories indexed by a separate invocation
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+
+	go func() {
+		for range reloadChan {
+			if app == nil || app.mcpServer == nil {
+				continue
+			}
+			slog.Info("Received reload signal, reloading repositories from cache")
+			if _, _, err := app.mcpServer.ReloadRepositories(); err != nil {
+				slog.Warn("Failed to reload repositories", "error", err)
+			}
+		}
+	}()
+
 	// Create and initialize application
 	var err error
 	app, err = NewApplication()
@@ -1045,7 +1640,7 @@ func main() {
 		}
 
 		// Skip initialization for cache inspection commands when using direct db-path
-		if (cmd.Name() == "listkeys" || cmd.Name() == "getcontent") && dbPath != "" {
+		if (cmd.Name() == "listkeys" || cmd.Name() == "getcontent" || cmd.Name() == "rename" || cmd.Name() == "prune-expired") && dbPath != "" {
 			return nil
 		}
 
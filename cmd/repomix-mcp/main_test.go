@@ -0,0 +1,91 @@
+// ************************************************************************************************
+// Main package - Unit tests for the cache inspection commands.
+// This file covers writing the content of a cache key to a file.
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"repomix-mcp/internal/cache"
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// Test that getcontent writes raw and decoded values to the output file, and leaves an existing
+// file untouched when the key cannot be read
+func TestRunGetContentCommand_OutputFile(t *testing.T) {
+	cachePath := t.TempDir()
+	store, err := cache.NewCache(&types.CacheConfig{Path: cachePath, MaxEntrySize: "256"})
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	summary := strings.Repeat("func Exported()\n", 64)
+	err = store.StoreRepository(&types.RepositoryIndex{
+		ID:       "docs",
+		Name:     "docs",
+		Files:    map[string]types.IndexedFile{".repomix.xml": {Path: ".repomix.xml", Content: "<repository/>", RepositoryID: "docs"}},
+		Metadata: map[string]interface{}{"api_summary": summary},
+	})
+	if err == nil {
+		err = store.StoreFile("docs", &types.IndexedFile{Path: "README.md", Content: "# Docs", RepositoryID: "docs"})
+	}
+	store.Close()
+	if err != nil {
+		t.Fatalf("Failed to store repository: %v", err)
+	}
+
+	defer func(db, output, outputFormat string, decode bool) {
+		dbPath, outputFile, format, decodeValue = db, output, outputFormat, decode
+	}(dbPath, outputFile, format, decodeValue)
+	dbPath, format = cachePath, "raw"
+	outputDir := t.TempDir()
+
+	// Stored values hold references: a manifest for the chunked repository
+	outputFile, decodeValue = filepath.Join(outputDir, "raw.json"), false
+	if err := runGetContentCommand(nil, []string{"repo:docs"}); err != nil {
+		t.Fatalf("runGetContentCommand failed: %v", err)
+	}
+	if raw, _ := os.ReadFile(outputFile); strings.Contains(string(raw), "<repository/>") {
+		t.Errorf("Expected the stored manifest without decoding, got %.80q", raw)
+	}
+
+	// Decoded values are reassembled with their contents
+	outputFile, decodeValue = filepath.Join(outputDir, "index.json"), true
+	if err := runGetContentCommand(nil, []string{"repo:docs"}); err != nil {
+		t.Fatalf("runGetContentCommand failed: %v", err)
+	}
+	var repo types.RepositoryIndex
+	if data, err := os.ReadFile(outputFile); err != nil || json.Unmarshal(data, &repo) != nil {
+		t.Fatalf("Expected the decoded repository as JSON, got %v", err)
+	}
+	if repo.Files[".repomix.xml"].Content != "<repository/>" || repo.Metadata["api_summary"] != summary {
+		t.Errorf("Expected the reassembled repository with its contents, got %+v", repo)
+	}
+
+	outputFile = filepath.Join(outputDir, "README.md")
+	if err := runGetContentCommand(nil, []string{"file:docs:README.md"}); err != nil {
+		t.Fatalf("runGetContentCommand failed: %v", err)
+	}
+	if content, _ := os.ReadFile(outputFile); string(content) != "# Docs" {
+		t.Errorf("Expected the decoded file content, got %q", content)
+	}
+
+	// A failure keeps the existing file and leaves no temporary file behind
+	outputFile = filepath.Join(outputDir, "keep.txt")
+	if err := os.WriteFile(outputFile, []byte("keep"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := runGetContentCommand(nil, []string{"repo:missing"}); err == nil {
+		t.Error("Expected an error for a missing key")
+	}
+	if content, _ := os.ReadFile(outputFile); string(content) != "keep" {
+		t.Errorf("Expected the existing file to be kept, got %q", content)
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 4 {
+		t.Errorf("Expected no temporary file left, got %d entries", len(entries))
+	}
+}
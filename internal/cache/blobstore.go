@@ -0,0 +1,357 @@
+// ************************************************************************************************
+// Package cache provides content-addressable file storage for the repomix-mcp application.
+// File contents are stored once under "blob:<sha256>" with a reference count under
+// "blobref:<sha256>"; repository and file entries keep only the hash, so identical files
+// across repositories (vendored copies, monorepo duplicates) share a single stored copy.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"repomix-mcp/pkg/types"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ************************************************************************************************
+// Key prefixes of the content-addressable store.
+const (
+	blobPrefix    = "blob:"
+	blobRefPrefix = "blobref:"
+)
+
+// ************************************************************************************************
+// contentHash returns the content address of a file body.
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// ************************************************************************************************
+// newEntry creates a cache entry carrying the configured TTL.
+func (c *Cache) newEntry(key string, value []byte) *badger.Entry {
+	entry := badger.NewEntry([]byte(key), value)
+	if c.config.TTL != "" {
+		if ttl, err := mock_timeParseDuration(c.config.TTL); err == nil {
+			entry = entry.WithTTL(ttl)
+		}
+	}
+	return entry
+}
+
+// ************************************************************************************************
+// update runs a read-write transaction, retrying when a concurrent transaction touched
+// the same reference counts.
+func (c *Cache) update(fn func(txn *badger.Txn) error) error {
+	for {
+		err := c.db.Update(fn)
+		if err != badger.ErrConflict {
+			return err
+		}
+	}
+}
+
+// ************************************************************************************************
+// readRefCount returns the reference count of a blob, 0 when it is not stored.
+func readRefCount(txn *badger.Txn, hash string) (int, error) {
+	item, err := txn.Get([]byte(blobRefPrefix + hash))
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = item.Value(func(val []byte) error {
+		count, err = strconv.Atoi(string(val))
+		return err
+	})
+	return count, err
+}
+
+// ************************************************************************************************
+// countHashes groups hashes by value, keeping the order of their first occurrence.
+//
+// Returns:
+//   - []string: The distinct hashes.
+//   - map[string]int: The number of occurrences of each hash.
+func countHashes(hashes []string) ([]string, map[string]int) {
+	var distinct []string
+	counts := make(map[string]int, len(hashes))
+	for _, hash := range hashes {
+		if counts[hash] == 0 {
+			distinct = append(distinct, hash)
+		}
+		counts[hash]++
+	}
+	return distinct, counts
+}
+
+// ************************************************************************************************
+// updateBatched applies fn to every hash in as few read-write transactions as possible: a
+// transaction is committed and the next one started when it grows too big.
+//
+// Returns:
+//   - int: The number of leading hashes whose transactions were committed.
+//   - error: An error if a transaction fails, or if a single hash does not fit in one.
+func (c *Cache) updateBatched(hashes []string, fn func(txn *badger.Txn, hash string) error) (int, error) {
+	committed := 0
+	for committed < len(hashes) {
+		done := 0
+		err := c.update(func(txn *badger.Txn) error {
+			done = 0
+			for _, hash := range hashes[committed:] {
+				if err := fn(txn, hash); err != nil {
+					if errors.Is(err, badger.ErrTxnTooBig) && done > 0 {
+						return nil // Commit this batch, the rest goes in the next one
+					}
+					return err
+				}
+				done++
+			}
+			return nil
+		})
+		if err != nil {
+			return committed, err
+		}
+		committed += done
+	}
+	return committed, nil
+}
+
+// ************************************************************************************************
+// acquireBlobs stores contents under their hashes, or adds references when they are already
+// stored, in batched transactions. The blob TTL is refreshed on every reference so shared
+// content outlives its newest user.
+//
+// Returns:
+//   - []string: The content hash of each content, in order, to keep as references.
+//   - error: An error if the store fails, no reference being left acquired.
+func (c *Cache) acquireBlobs(contents []string) ([]string, error) {
+	hashes := make([]string, len(contents))
+	bodies := make(map[string]string, len(contents))
+	for index, content := range contents {
+		hashes[index] = contentHash(content)
+		bodies[hashes[index]] = content
+	}
+	distinct, counts := countHashes(hashes)
+
+	committed, err := c.updateBatched(distinct, func(txn *badger.Txn, hash string) error {
+		count, err := readRefCount(txn, hash)
+		if err != nil {
+			return err
+		}
+		if err := txn.SetEntry(c.newEntry(blobPrefix+hash, []byte(bodies[hash]))); err != nil {
+			return err
+		}
+		return txn.SetEntry(c.newEntry(blobRefPrefix+hash, []byte(strconv.Itoa(count+counts[hash]))))
+	})
+	if err != nil {
+		// Batches already committed keep their references, give them back
+		var acquired []string
+		for _, hash := range distinct[:committed] {
+			for range counts[hash] {
+				acquired = append(acquired, hash)
+			}
+		}
+		c.releaseBlobs(acquired)
+		return nil, fmt.Errorf("failed to store file content\n>    %w", err)
+	}
+
+	return hashes, nil
+}
+
+// ************************************************************************************************
+// releaseBlobs drops one reference per hash, deleting blobs that are no longer referenced, in
+// batched transactions.
+//
+// Returns:
+//   - error: An error if a reference count cannot be updated.
+func (c *Cache) releaseBlobs(hashes []string) error {
+	distinct, counts := countHashes(hashes)
+
+	_, err := c.updateBatched(distinct, func(txn *badger.Txn, hash string) error {
+		count, err := readRefCount(txn, hash)
+		if err != nil || count == 0 {
+			return err
+		}
+		if count > counts[hash] {
+			return txn.SetEntry(c.newEntry(blobRefPrefix+hash, []byte(strconv.Itoa(count-counts[hash]))))
+		}
+		if err := txn.Delete([]byte(blobPrefix + hash)); err != nil {
+			return err
+		}
+		return txn.Delete([]byte(blobRefPrefix + hash))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release file content\n>    %w", err)
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// lockRepository serializes the writes of the entries of a repository, so the content
+// references a previous entry holds are read and released by a single writer.
+//
+// Returns:
+//   - func(): Releases the lock.
+//
+// Example usage:
+//
+//	defer c.lockRepository(repo.ID)()
+func (c *Cache) lockRepository(repositoryID string) func() {
+	c.repoLocksMu.Lock()
+	if c.repoLocks == nil {
+		c.repoLocks = make(map[string]*sync.Mutex)
+	}
+	lock, exists := c.repoLocks[repositoryID]
+	if !exists {
+		lock = &sync.Mutex{}
+		c.repoLocks[repositoryID] = lock
+	}
+	c.repoLocksMu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// ************************************************************************************************
+// resolveContent replaces a file's content reference with the stored content.
+// Files written before the content-addressable store carry inline content and are left as is.
+//
+// Returns:
+//   - error: An error if the referenced blob is missing.
+func resolveContent(txn *badger.Txn, file *types.IndexedFile) error {
+	if file.ContentRef == "" {
+		return nil
+	}
+
+	item, err := txn.Get([]byte(blobPrefix + file.ContentRef))
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return fmt.Errorf("%w: missing content %s for %s", types.ErrCacheCorrupted, file.ContentRef, file.Path)
+		}
+		return err
+	}
+
+	return item.Value(func(val []byte) error {
+		file.Content = string(val)
+		file.ContentRef = ""
+		return nil
+	})
+}
+
+// ************************************************************************************************
+// storedContentRefs returns the content references held by the entry stored under key,
+// which is either a repository or a single file. A missing entry holds no references.
+//
+// Returns:
+//   - []string: The referenced content hashes.
+//   - error: An error if the entry cannot be read.
+func (c *Cache) storedContentRefs(key string) ([]string, error) {
+	var data []byte
+	err := c.db.View(func(txn *badger.Txn) error {
+		if repositoryID, isRepo := strings.CutPrefix(key, "repo:"); isRepo {
+			var err error
+			data, err = readRepositoryEntry(txn, repositoryID)
+			return err
+		}
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		data, err = item.ValueCopy(nil)
+		return err
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []string
+	if strings.HasPrefix(key, "repo:") {
+		var repo types.RepositoryIndex
+		if err := json.Unmarshal(data, &repo); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal repository data\n>    %w", err)
+		}
+		for _, file := range repo.Files {
+			if file.ContentRef != "" {
+				refs = append(refs, file.ContentRef)
+			}
+		}
+		return refs, nil
+	}
+
+	var file types.IndexedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal file data\n>    %w", err)
+	}
+	if file.ContentRef != "" {
+		refs = append(refs, file.ContentRef)
+	}
+	return refs, nil
+}
+
+// ************************************************************************************************
+// dedupStats reports the content-addressable store usage.
+//
+// Returns:
+//   - map[string]interface{}: blob_count, blob_bytes (stored once), logical_bytes (as
+//     referenced) and dedup_ratio (logical_bytes / blob_bytes, 1 when nothing is stored).
+//   - error: An error if the scan fails.
+func (c *Cache) dedupStats() (map[string]interface{}, error) {
+	sizes := make(map[string]int64)
+	refs := make(map[string]int)
+
+	err := c.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek([]byte(blobPrefix)); it.ValidForPrefix([]byte(blobPrefix)); it.Next() {
+			item := it.Item()
+			sizes[strings.TrimPrefix(string(item.Key()), blobPrefix)] = item.ValueSize()
+		}
+
+		for hash := range sizes {
+			count, err := readRefCount(txn, hash)
+			if err != nil {
+				return err
+			}
+			refs[hash] = count
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var blobBytes, logicalBytes int64
+	for hash, size := range sizes {
+		blobBytes += size
+		logicalBytes += size * int64(refs[hash])
+	}
+
+	ratio := 1.0
+	if blobBytes > 0 {
+		ratio = float64(logicalBytes) / float64(blobBytes)
+	}
+
+	return map[string]interface{}{
+		"blob_count":    len(sizes),
+		"blob_bytes":    blobBytes,
+		"logical_bytes": logicalBytes,
+		"dedup_ratio":   ratio,
+	}, nil
+}
@@ -5,11 +5,15 @@
 package cache
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
 
+	"repomix-mcp/internal/tracing"
 	"repomix-mcp/pkg/types"
 
 	"github.com/dgraph-io/badger/v4"
@@ -22,6 +26,9 @@ import (
 type Cache struct {
 	db     *badger.DB
 	config *types.CacheConfig
+
+	repoLocksMu sync.Mutex
+	repoLocks   map[string]*sync.Mutex // Per-repository write locks, see lockRepository
 }
 
 // ************************************************************************************************
@@ -91,7 +98,10 @@ func (c *Cache) Close() error {
 
 // ************************************************************************************************
 // StoreRepository stores a complete repository index in the cache.
-// It serializes the repository data and stores it with an expiration time.
+// It serializes the repository data and stores it with an expiration time, split into
+// parts when larger than the maximum entry size.
+// File contents go to the content-addressable store, so content shared with other
+// repositories is kept once; references held by a previous version are released.
 //
 // Returns:
 //   - error: An error if storage fails.
@@ -107,29 +117,70 @@ func (c *Cache) StoreRepository(repo *types.RepositoryIndex) error {
 		return fmt.Errorf("%w: repository index is nil", types.ErrInvalidConfig)
 	}
 
+	// Create cache key
+	key := fmt.Sprintf("repo:%s", repo.ID)
+
+	// A concurrent store of the same repository would release the same previous references
+	defer c.lockRepository(repo.ID)()
+
+	previousRefs, err := c.storedContentRefs(key)
+	if err != nil {
+		return fmt.Errorf("failed to read previous repository entry\n>    %w", err)
+	}
+
+	// Move file contents to the content-addressable store, leaving the caller's index untouched
+	paths := make([]string, 0, len(repo.Files))
+	contents := make([]string, 0, len(repo.Files))
+	for path, file := range repo.Files {
+		paths = append(paths, path)
+		contents = append(contents, file.Content)
+	}
+	acquired, err := c.acquireBlobs(contents)
+	if err != nil {
+		return err
+	}
+	stored := *repo
+	stored.Files = make(map[string]types.IndexedFile, len(repo.Files))
+	for index, path := range paths {
+		file := repo.Files[path]
+		file.Content = ""
+		file.ContentRef = acquired[index]
+		stored.Files[path] = file
+	}
+
 	// Serialize repository data
-	data, err := json.Marshal(repo)
+	data, err := json.Marshal(&stored)
 	if err != nil {
+		c.releaseBlobs(acquired)
 		return fmt.Errorf("failed to marshal repository data\n>    %w", err)
 	}
 
-	// Create cache key
-	key := fmt.Sprintf("repo:%s", repo.ID)
+	// Store in BadgerDB with TTL, then drop the parts and content of the previous entry
+	replaced, err := c.writeRepositoryEntry(repo.ID, data, 0)
+	if err != nil {
+		c.releaseBlobs(acquired)
+		return err
+	}
 
-	// Store in BadgerDB with TTL
-	return c.db.Update(func(txn *badger.Txn) error {
-		entry := badger.NewEntry([]byte(key), data)
-		
-		// Set TTL if configured
-		if c.config.TTL != "" {
-			ttl, err := mock_timeParseDuration(c.config.TTL)
-			if err == nil {
-				entry = entry.WithTTL(ttl)
-			}
-		}
-		
-		return txn.SetEntry(entry)
-	})
+	return errors.Join(c.deleteRepositoryParts(repo.ID, replaced), c.releaseBlobs(previousRefs))
+}
+
+// ************************************************************************************************
+// StoreRepositoryContext stores a repository index like StoreRepository, recording the storage
+// as a span of the trace of ctx.
+//
+// Returns:
+//   - error: An error if storage fails.
+func (c *Cache) StoreRepositoryContext(ctx context.Context, repo *types.RepositoryIndex) error {
+	_, span := tracing.Start(ctx, "cache.StoreRepository")
+	defer span.End()
+	if repo != nil {
+		span.SetAttributes(tracing.String("repository.id", repo.ID), tracing.Int("repository.files", len(repo.Files)))
+	}
+
+	err := c.StoreRepository(repo)
+	span.RecordError(err)
+	return err
 }
 
 // ************************************************************************************************
@@ -151,19 +202,13 @@ func (c *Cache) GetRepository(repositoryID string) (*types.RepositoryIndex, erro
 		return nil, fmt.Errorf("%w: repository ID is empty", types.ErrInvalidConfig)
 	}
 
-	key := fmt.Sprintf("repo:%s", repositoryID)
 	var repoData []byte
 
+	// Chunked entries are reassembled from their parts
 	err := c.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(key))
-		if err != nil {
-			return err
-		}
-
-		return item.Value(func(val []byte) error {
-			repoData = append([]byte{}, val...)
-			return nil
-		})
+		var err error
+		repoData, err = readRepositoryEntry(txn, repositoryID)
+		return err
 	})
 
 	if err != nil {
@@ -179,9 +224,42 @@ func (c *Cache) GetRepository(repositoryID string) (*types.RepositoryIndex, erro
 		return nil, fmt.Errorf("failed to unmarshal repository data\n>    %w", err)
 	}
 
+	// Resolve file contents from the content-addressable store
+	err = c.db.View(func(txn *badger.Txn) error {
+		for path, file := range repo.Files {
+			if err := resolveContent(txn, &file); err != nil {
+				return err
+			}
+			repo.Files[path] = file
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve repository content\n>    %w", err)
+	}
+
 	return &repo, nil
 }
 
+// ************************************************************************************************
+// GetRepositoryContext retrieves a repository index like GetRepository, recording the retrieval
+// as a span of the trace of ctx.
+//
+// Returns:
+//   - *types.RepositoryIndex: The repository index if found.
+//   - error: An error if retrieval fails or repository is not found.
+func (c *Cache) GetRepositoryContext(ctx context.Context, repositoryID string) (*types.RepositoryIndex, error) {
+	_, span := tracing.Start(ctx, "cache.GetRepository", tracing.String("repository.id", repositoryID))
+	defer span.End()
+
+	repo, err := c.GetRepository(repositoryID)
+	span.SetAttributes(tracing.Bool("cache.hit", err == nil))
+	if err != nil && !errors.Is(err, types.ErrRepositoryNotFound) {
+		span.RecordError(err)
+	}
+	return repo, err
+}
+
 // ************************************************************************************************
 // StoreFile stores an individual file in the cache.
 // It creates a separate cache entry for the file to enable efficient file-level operations.
@@ -200,34 +278,49 @@ func (c *Cache) StoreFile(repositoryID string, file *types.IndexedFile) error {
 		return fmt.Errorf("%w: invalid parameters", types.ErrInvalidConfig)
 	}
 
+	// Create cache key
+	key := fmt.Sprintf("file:%s:%s", repositoryID, file.Path)
+
+	defer c.lockRepository(repositoryID)()
+
+	previousRefs, err := c.storedContentRefs(key)
+	if err != nil {
+		return fmt.Errorf("failed to read previous file entry\n>    %w", err)
+	}
+
+	// Keep only a reference to the content-addressable store in the file entry
+	hashes, err := c.acquireBlobs([]string{file.Content})
+	if err != nil {
+		return err
+	}
+	hash := hashes[0]
+	stored := *file
+	stored.Content = ""
+	stored.ContentRef = hash
+
 	// Serialize file data
-	data, err := json.Marshal(file)
+	data, err := json.Marshal(&stored)
 	if err != nil {
+		c.releaseBlobs([]string{hash})
 		return fmt.Errorf("failed to marshal file data\n>    %w", err)
 	}
 
-	// Create cache key
-	key := fmt.Sprintf("file:%s:%s", repositoryID, file.Path)
-
 	// Store in BadgerDB with TTL
-	return c.db.Update(func(txn *badger.Txn) error {
-		entry := badger.NewEntry([]byte(key), data)
-		
-		// Set TTL if configured
-		if c.config.TTL != "" {
-			ttl, err := mock_timeParseDuration(c.config.TTL)
-			if err == nil {
-				entry = entry.WithTTL(ttl)
-			}
-		}
-		
-		return txn.SetEntry(entry)
+	err = c.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(c.newEntry(key, data))
 	})
+	if err != nil {
+		c.releaseBlobs([]string{hash})
+		return err
+	}
+
+	return c.releaseBlobs(previousRefs)
 }
 
 // ************************************************************************************************
 // GetFile retrieves a specific file from the cache.
-// It looks up the file by repository ID and file path.
+// It looks up the file by repository ID and file path and resolves its content
+// from the content-addressable store.
 //
 // Returns:
 //   - *types.IndexedFile: The indexed file if found.
@@ -272,6 +365,13 @@ func (c *Cache) GetFile(repositoryID, filePath string) (*types.IndexedFile, erro
 		return nil, fmt.Errorf("failed to unmarshal file data\n>    %w", err)
 	}
 
+	err = c.db.View(func(txn *badger.Txn) error {
+		return resolveContent(txn, &file)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve file content\n>    %w", err)
+	}
+
 	return &file, nil
 }
 
@@ -321,7 +421,8 @@ func (c *Cache) ListRepositories() ([]string, error) {
 
 // ************************************************************************************************
 // DeleteRepository removes a repository and all its associated files from the cache.
-// It performs a cascading delete operation to maintain cache consistency.
+// It performs a cascading delete operation to maintain cache consistency and releases
+// the content references held by the deleted entries.
 //
 // Returns:
 //   - error: An error if deletion fails.
@@ -337,36 +438,241 @@ func (c *Cache) DeleteRepository(repositoryID string) error {
 		return fmt.Errorf("%w: repository ID is empty", types.ErrInvalidConfig)
 	}
 
-	return c.db.Update(func(txn *badger.Txn) error {
-		// Delete repository entry
-		repoKey := fmt.Sprintf("repo:%s", repositoryID)
+	defer c.lockRepository(repositoryID)()
+
+	// Collect the entries to delete and the content they reference
+	repoKey := fmt.Sprintf("repo:%s", repositoryID)
+	refs, err := c.storedContentRefs(repoKey)
+	if err != nil {
+		return fmt.Errorf("failed to read repository entry\n>    %w", err)
+	}
+
+	filePrefix := fmt.Sprintf("file:%s:", repositoryID)
+	fileKeys, err := c.ListAllKeys(filePrefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range fileKeys {
+		fileRefs, err := c.storedContentRefs(key)
+		if err != nil {
+			return fmt.Errorf("failed to read file entry\n>    %w", err)
+		}
+		refs = append(refs, fileRefs...)
+	}
+
+	var parts chunkManifest
+	err = c.db.Update(func(txn *badger.Txn) error {
+		// Delete repository entry, its parts once no reader can reach them
+		var err error
+		if parts, err = storedManifest(txn, repositoryID); err != nil {
+			return fmt.Errorf("failed to read repository entry\n>    %w", err)
+		}
 		if err := txn.Delete([]byte(repoKey)); err != nil && err != badger.ErrKeyNotFound {
 			return fmt.Errorf("failed to delete repository entry\n>    %w", err)
 		}
 
 		// Delete all associated files
-		filePrefix := fmt.Sprintf("file:%s:", repositoryID)
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = false
-		it := txn.NewIterator(opts)
-		defer it.Close()
-
-		var keysToDelete [][]byte
-		for it.Seek([]byte(filePrefix)); it.ValidForPrefix([]byte(filePrefix)); it.Next() {
-			item := it.Item()
-			key := item.KeyCopy(nil)
-			keysToDelete = append(keysToDelete, key)
+		for _, key := range fileKeys {
+			if err := txn.Delete([]byte(key)); err != nil {
+				return fmt.Errorf("failed to delete file entry\n>    %w", err)
+			}
 		}
 
-		// Delete collected keys
-		for _, key := range keysToDelete {
-			if err := txn.Delete(key); err != nil {
-				return fmt.Errorf("failed to delete file entry\n>    %w", err)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(c.deleteRepositoryParts(repositoryID, parts), c.releaseBlobs(refs))
+}
+
+// ************************************************************************************************
+// RenameRepository moves a repository and its files to a new ID, so a renamed repository alias
+// keeps its index instead of being indexed again. The parts of a chunked repository entry and
+// the file entries are copied in as many transactions as needed, then the repository entry is
+// switched in a transaction of its own, so readers see the repository whole under one of the
+// IDs; the old file entries and parts are deleted last. Entries keep their content references
+// and expiry; the RepositoryID of every file is rewritten.
+//
+// Returns:
+//   - error: ErrRepositoryNotFound if the old ID is not cached, ErrRepositoryIDCollision if the
+//     new ID already is, or an error if the transaction fails.
+//
+// Example usage:
+//
+//	err := cache.RenameRepository("old-alias", "new-alias")
+//	if err != nil {
+//		return fmt.Errorf("failed to rename repository: %w", err)
+//	}
+func (c *Cache) RenameRepository(oldID, newID string) error {
+	if oldID == "" {
+		return fmt.Errorf("%w: repository ID is empty", types.ErrInvalidConfig)
+	}
+	if err := types.ValidateRepositoryID(newID); err != nil {
+		return err
+	}
+	if oldID == newID {
+		return fmt.Errorf("%w: %s is already the repository ID", types.ErrInvalidRepositoryID, newID)
+	}
+
+	oldRepoKey, newRepoKey := "repo:"+oldID, "repo:"+newID
+	oldFilePrefix, newFilePrefix := fmt.Sprintf("file:%s:", oldID), fmt.Sprintf("file:%s:", newID)
+
+	// Lock both IDs in a fixed order, so concurrent renames cannot deadlock
+	first, second := min(oldID, newID), max(oldID, newID)
+	defer c.lockRepository(first)()
+	defer c.lockRepository(second)()
+
+	// Rewrite the repository entry, reassembled and split again under the new ID
+	var data []byte
+	var expiresAt uint64
+	err := c.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(oldRepoKey))
+		if err == badger.ErrKeyNotFound {
+			return fmt.Errorf("%w: %s", types.ErrRepositoryNotFound, oldID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read repository entry\n>    %w", err)
+		}
+		if exists, err := keyPrefixExists(txn, newRepoKey, newFilePrefix); err != nil || exists {
+			if err != nil {
+				return err
 			}
+			return fmt.Errorf("%w: %s is already in the cache", types.ErrRepositoryIDCollision, newID)
 		}
 
+		expiresAt = item.ExpiresAt()
+		data, err = readRepositoryEntry(txn, oldID)
+		if err != nil {
+			return fmt.Errorf("failed to read repository entry\n>    %w", err)
+		}
+		var repo types.RepositoryIndex
+		if err := json.Unmarshal(data, &repo); err != nil {
+			return fmt.Errorf("failed to unmarshal repository data\n>    %w", err)
+		}
+		repo.ID = newID
+		if repo.Name == oldID {
+			repo.Name = newID
+		}
+		for path, file := range repo.Files {
+			file.RepositoryID = newID
+			repo.Files[path] = file
+		}
+		data, err = json.Marshal(&repo)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s\n>    %w", newRepoKey, err)
+		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	// Write the parts and copy the file entries first, the old entry still pointing at its own
+	head, parts, err := c.writeRepositoryParts(newID, data, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to store %s\n>    %w", newRepoKey, err)
+	}
+
+	oldFileKeys, err := c.ListAllKeys(oldFilePrefix)
+	if err != nil {
+		c.deleteRepositoryParts(newID, parts)
+		return err
+	}
+	newFileKeys := make([]string, len(oldFileKeys))
+	for index, key := range oldFileKeys {
+		newFileKeys[index] = newFilePrefix + strings.TrimPrefix(key, oldFilePrefix)
+	}
+	copied, err := c.updateBatched(oldFileKeys, func(txn *badger.Txn, key string) error {
+		fileItem, err := txn.Get([]byte(key))
+		if err != nil {
+			return fmt.Errorf("failed to read file entry\n>    %w", err)
+		}
+		data, err := fileItem.ValueCopy(nil)
+		if err != nil {
+			return fmt.Errorf("failed to read file entry\n>    %w", err)
+		}
+		var file types.IndexedFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("failed to unmarshal file data\n>    %w", err)
+		}
+		file.RepositoryID = newID
+		return copyEntry(txn, fileItem, newFilePrefix+strings.TrimPrefix(key, oldFilePrefix), &file)
+	})
+	if err != nil {
+		c.deleteEntries(newFileKeys[:copied])
+		c.deleteRepositoryParts(newID, parts)
+		return err
+	}
+
+	// Switch the repository entry to the new ID
+	var oldParts chunkManifest
+	err = c.update(func(txn *badger.Txn) error {
+		var err error
+		if oldParts, err = storedManifest(txn, oldID); err != nil {
+			return fmt.Errorf("failed to read repository entry\n>    %w", err)
+		}
+		if err := txn.SetEntry(c.repositoryEntry(newRepoKey, head, expiresAt)); err != nil {
+			return fmt.Errorf("failed to store %s\n>    %w", newRepoKey, err)
+		}
+		if err := txn.Delete([]byte(oldRepoKey)); err != nil {
+			return fmt.Errorf("failed to delete %s\n>    %w", oldRepoKey, err)
+		}
+		return nil
+	})
+	if err != nil {
+		c.deleteEntries(newFileKeys)
+		c.deleteRepositoryParts(newID, parts)
+		return err
+	}
+
+	return errors.Join(c.deleteEntries(oldFileKeys), c.deleteRepositoryParts(oldID, oldParts))
+}
+
+// ************************************************************************************************
+// keyPrefixExists reports whether a key or any key under a prefix is stored.
+func keyPrefixExists(txn *badger.Txn, key, prefix string) (bool, error) {
+	if _, err := txn.Get([]byte(key)); err == nil {
+		return true, nil
+	} else if err != badger.ErrKeyNotFound {
+		return false, err
+	}
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	it.Seek([]byte(prefix))
+	return it.ValidForPrefix([]byte(prefix)), nil
+}
+
+// ************************************************************************************************
+// copyEntry stores a value under a new key with the expiry of an existing entry.
+func copyEntry(txn *badger.Txn, item *badger.Item, newKey string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s\n>    %w", newKey, err)
+	}
+
+	entry := badger.NewEntry([]byte(newKey), data)
+	entry.ExpiresAt = item.ExpiresAt()
+	if err := txn.SetEntry(entry); err != nil {
+		return fmt.Errorf("failed to store %s\n>    %w", newKey, err)
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// deleteEntries deletes entries by key, in as many transactions as needed.
+func (c *Cache) deleteEntries(keys []string) error {
+	_, err := c.updateBatched(keys, func(txn *badger.Txn, key string) error {
+		return txn.Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete entries\n>    %w", err)
+	}
+	return nil
 }
 
 // ************************************************************************************************
@@ -422,6 +728,15 @@ func (c *Cache) GetCacheStats() (map[string]interface{}, error) {
 
 	stats["repository_count"] = repoCount
 	stats["file_count"] = fileCount
+
+	// Content-addressable store usage
+	dedup, err := c.dedupStats()
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect deduplication statistics\n>    %w", err)
+	}
+	for name, value := range dedup {
+		stats[name] = value
+	}
 	
 	return stats, nil
 }
@@ -443,6 +758,96 @@ func (c *Cache) RunGarbageCollection() error {
 	return c.db.RunValueLogGC(0.5)
 }
 
+// ************************************************************************************************
+// PruneExpired deletes the entries whose TTL has passed, then runs value log garbage
+// collection. BadgerDB only drops expired entries lazily during compaction; deleting them
+// explicitly lets the value log space be reclaimed right away. Reference counts held by pruned
+// entries are not released, the content they reference expires with the same TTL.
+//
+// Returns:
+//   - int: The number of entries pruned.
+//   - error: An error if the entries cannot be listed or deleted.
+//
+// Example usage:
+//
+//	pruned, err := cache.PruneExpired()
+//	if err != nil {
+//		return fmt.Errorf("failed to prune expired entries: %w", err)
+//	}
+func (c *Cache) PruneExpired() (int, error) {
+	type expiredEntry struct {
+		key     []byte
+		version uint64
+	}
+	now := uint64(mock_timeNow().Unix())
+
+	// Only the latest version of a key counts, iterated first among its versions
+	var expired []expiredEntry
+	err := c.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.AllVersions = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		var lastKey []byte
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			if lastKey != nil && string(item.Key()) == string(lastKey) {
+				continue
+			}
+			lastKey = item.KeyCopy(nil)
+			if expiresAt := item.ExpiresAt(); expiresAt != 0 && expiresAt <= now {
+				expired = append(expired, expiredEntry{key: lastKey, version: item.Version()})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired entries\n>    %w", err)
+	}
+
+	// Delete in batches, skipping keys written again since they were listed
+	const batchSize = 1000
+	pruned := 0
+	for start := 0; start < len(expired); start += batchSize {
+		batch := expired[start:min(start+batchSize, len(expired))]
+		count := 0
+		err := c.update(func(txn *badger.Txn) error {
+			count = 0
+			for _, entry := range batch {
+				item, err := txn.Get(entry.key)
+				if err == nil && item.Version() != entry.version {
+					continue
+				}
+				if err != nil && err != badger.ErrKeyNotFound {
+					return err
+				}
+				if err := txn.Delete(entry.key); err != nil {
+					return err
+				}
+				count++
+			}
+			return nil
+		})
+		if err != nil {
+			return pruned, fmt.Errorf("failed to delete expired entries\n>    %w", err)
+		}
+		pruned += count
+	}
+
+	// Rewrite value log files until no file is worth it
+	for pruned > 0 {
+		if err := c.db.RunValueLogGC(0.5); err != nil {
+			if err != badger.ErrNoRewrite && err != badger.ErrRejected {
+				return pruned, fmt.Errorf("failed to run value log garbage collection\n>    %w", err)
+			}
+			break
+		}
+	}
+	return pruned, nil
+}
+
 // ************************************************************************************************
 // InvalidateAll removes all entries from the cache.
 // This method is used by the refresh tool to force a complete cache rebuild.
@@ -636,6 +1041,48 @@ func (c *Cache) GetRawValue(key string) ([]byte, error) {
 	return value, nil
 }
 
+// ************************************************************************************************
+// GetDecodedValue returns the value of a key as the application reads it: a repository entry is
+// reassembled from its parts and returned as JSON with the contents of its files resolved, a
+// file entry returns the content of the file, and any other key its stored value.
+//
+// Returns:
+//   - []byte: The decoded value.
+//   - error: An error if the key is not found or its value cannot be decoded.
+//
+// Example usage:
+//
+//	data, err := cache.GetDecodedValue("repo:my-project")
+//	if err != nil {
+//		return fmt.Errorf("failed to decode value: %w", err)
+//	}
+func (c *Cache) GetDecodedValue(key string) ([]byte, error) {
+	if repositoryID, isRepo := strings.CutPrefix(key, "repo:"); isRepo {
+		repo, err := c.GetRepository(repositoryID)
+		if err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(repo, "", "  ")
+	}
+
+	value, err := c.GetRawValue(key)
+	if err != nil || !strings.HasPrefix(key, "file:") {
+		return value, err
+	}
+
+	var file types.IndexedFile
+	if err := json.Unmarshal(value, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal file data\n>    %w", err)
+	}
+	err = c.db.View(func(txn *badger.Txn) error {
+		return resolveContent(txn, &file)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve file content\n>    %w", err)
+	}
+	return []byte(file.Content), nil
+}
+
 // ************************************************************************************************
 // GetAllKeysWithValues returns all keys with their values, optionally filtered by prefix.
 // This method is useful for comprehensive cache inspection and the getcontent command without arguments.
@@ -757,6 +1204,12 @@ func (c *Cache) GetKeyInfo(key string) (map[string]interface{}, error) {
 				info["repository_id"] = parts[0]
 				info["file_path"] = parts[1]
 			}
+		} else if strings.HasPrefix(key, blobPrefix) {
+			info["type"] = "content"
+			info["content_hash"] = strings.TrimPrefix(key, blobPrefix)
+		} else if strings.HasPrefix(key, blobRefPrefix) {
+			info["type"] = "content_refcount"
+			info["content_hash"] = strings.TrimPrefix(key, blobRefPrefix)
 		} else {
 			info["type"] = "unknown"
 		}
@@ -779,7 +1232,11 @@ func (c *Cache) GetKeyInfo(key string) (map[string]interface{}, error) {
 }
 
 // ************************************************************************************************
-// FormatValuePreview formats a value for preview display (first 42 characters).
+// DefaultPreviewLength is the number of bytes shown by FormatValuePreview.
+const DefaultPreviewLength = 42
+
+// ************************************************************************************************
+// FormatValuePreview formats a value for preview display (first DefaultPreviewLength characters).
 // This utility function safely truncates values and handles special characters.
 //
 // Returns:
@@ -790,13 +1247,29 @@ func (c *Cache) GetKeyInfo(key string) (map[string]interface{}, error) {
 //	preview := cache.FormatValuePreview(rawValue)
 //	fmt.Printf("Value preview: %s\n", preview)
 func (c *Cache) FormatValuePreview(value []byte) string {
+	return c.FormatValuePreviewLength(value, DefaultPreviewLength)
+}
+
+// ************************************************************************************************
+// FormatValuePreviewLength formats a value for preview display, keeping at most maxLen bytes
+// without splitting a UTF-8 character. A maxLen of zero or less uses DefaultPreviewLength.
+//
+// Returns:
+//   - string: Formatted preview string.
+//
+// Example usage:
+//
+//	preview := cache.FormatValuePreviewLength(rawValue, 200)
+func (c *Cache) FormatValuePreviewLength(value []byte, maxLen int) string {
 	if len(value) == 0 {
 		return "(empty)"
 	}
+	if maxLen <= 0 {
+		maxLen = DefaultPreviewLength
+	}
 	
 	// Convert to string and limit length
 	str := string(value)
-	maxLen := 42
 	
 	if len(str) <= maxLen {
 		return str
@@ -0,0 +1,538 @@
+// ************************************************************************************************
+// Package cache - Unit tests for the BadgerDB cache.
+// This file covers content deduplication and reference counting.
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"repomix-mcp/pkg/types"
+)
+
+// newTestCache opens a cache in a temporary directory.
+func newTestCache(t *testing.T) *Cache {
+	t.Helper()
+
+	cache, err := NewCache(&types.CacheConfig{Path: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+// testRepository builds a repository index from path/content pairs.
+func testRepository(id string, files map[string]string) *types.RepositoryIndex {
+	repo := &types.RepositoryIndex{
+		ID:       id,
+		Name:     id,
+		Files:    make(map[string]types.IndexedFile),
+		Metadata: make(map[string]interface{}),
+	}
+	for path, content := range files {
+		repo.Files[path] = types.IndexedFile{Path: path, Content: content, RepositoryID: id}
+	}
+	return repo
+}
+
+// blobCount returns the number of stored content blobs.
+func blobCount(t *testing.T, cache *Cache) int {
+	t.Helper()
+
+	keys, err := cache.ListAllKeys(blobPrefix)
+	if err != nil {
+		t.Fatalf("Failed to list blobs: %v", err)
+	}
+	return len(keys)
+}
+
+// ************************************************************************************************
+// Test that identical content across repositories is stored once and resolved transparently
+func TestCache_DeduplicatesContent(t *testing.T) {
+	cache := newTestCache(t)
+
+	shared := "package vendored\n\nfunc Shared() {}\n"
+	if err := cache.StoreRepository(testRepository("repo-a", map[string]string{"vendor/shared.go": shared, "a.go": "package a"})); err != nil {
+		t.Fatalf("StoreRepository failed: %v", err)
+	}
+	if err := cache.StoreRepository(testRepository("repo-b", map[string]string{"third_party/shared.go": shared, "b.go": "package b"})); err != nil {
+		t.Fatalf("StoreRepository failed: %v", err)
+	}
+
+	if count := blobCount(t, cache); count != 3 {
+		t.Errorf("Expected 3 stored blobs, got %d", count)
+	}
+
+	repo, err := cache.GetRepository("repo-b")
+	if err != nil {
+		t.Fatalf("GetRepository failed: %v", err)
+	}
+	file := repo.Files["third_party/shared.go"]
+	if file.Content != shared || file.ContentRef != "" {
+		t.Errorf("Expected resolved content, got content %q ref %q", file.Content, file.ContentRef)
+	}
+
+	stats, err := cache.GetCacheStats()
+	if err != nil {
+		t.Fatalf("GetCacheStats failed: %v", err)
+	}
+	if ratio, _ := stats["dedup_ratio"].(float64); ratio <= 1.0 {
+		t.Errorf("Expected dedup ratio above 1, got %v", stats["dedup_ratio"])
+	}
+}
+
+// ************************************************************************************************
+// Test that shared content survives until its last reference is deleted
+func TestCache_DeleteReleasesReferences(t *testing.T) {
+	cache := newTestCache(t)
+
+	shared := "shared content"
+	cache.StoreRepository(testRepository("repo-a", map[string]string{"x.txt": shared}))
+	cache.StoreRepository(testRepository("repo-b", map[string]string{"y.txt": shared}))
+
+	if err := cache.DeleteRepository("repo-a"); err != nil {
+		t.Fatalf("DeleteRepository failed: %v", err)
+	}
+	repo, err := cache.GetRepository("repo-b")
+	if err != nil {
+		t.Fatalf("GetRepository after sibling delete failed: %v", err)
+	}
+	if repo.Files["y.txt"].Content != shared {
+		t.Errorf("Expected shared content to survive, got %q", repo.Files["y.txt"].Content)
+	}
+
+	if err := cache.DeleteRepository("repo-b"); err != nil {
+		t.Fatalf("DeleteRepository failed: %v", err)
+	}
+	if count := blobCount(t, cache); count != 0 {
+		t.Errorf("Expected no blobs after deleting every reference, got %d", count)
+	}
+}
+
+// ************************************************************************************************
+// Test that re-storing a repository releases content it no longer references
+func TestCache_RestoreReleasesReplacedContent(t *testing.T) {
+	cache := newTestCache(t)
+
+	cache.StoreRepository(testRepository("repo", map[string]string{"a.txt": "old", "b.txt": "kept"}))
+	cache.StoreRepository(testRepository("repo", map[string]string{"a.txt": "new", "b.txt": "kept"}))
+
+	if count := blobCount(t, cache); count != 2 {
+		t.Errorf("Expected 2 blobs after update, got %d", count)
+	}
+
+	if err := cache.DeleteRepository("repo"); err != nil {
+		t.Fatalf("DeleteRepository failed: %v", err)
+	}
+	if count := blobCount(t, cache); count != 0 {
+		t.Errorf("Expected no leaked blobs, got %d", count)
+	}
+}
+
+// ************************************************************************************************
+// Test that concurrent stores of a repository release its previous content once
+func TestCache_ConcurrentStoresKeepReferences(t *testing.T) {
+	cache := newTestCache(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for index := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			files := map[string]string{"a.txt": fmt.Sprintf("version %d", index%2), "shared.txt": "shared"}
+			errs <- cache.StoreRepository(testRepository("repo", files))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("StoreRepository failed: %v", err)
+		}
+	}
+
+	if _, err := cache.GetRepository("repo"); err != nil {
+		t.Fatalf("Expected the last stored index to resolve, got %v", err)
+	}
+	if err := cache.DeleteRepository("repo"); err != nil {
+		t.Fatalf("DeleteRepository failed: %v", err)
+	}
+	if count := blobCount(t, cache); count != 0 {
+		t.Errorf("Expected no leaked blobs, got %d", count)
+	}
+}
+
+// ************************************************************************************************
+// Test that the content of a repository too large for one transaction is stored in batches
+func TestCache_StoreRepositoryBatchesContent(t *testing.T) {
+	cache := newTestCache(t)
+
+	files := make(map[string]string)
+	for index := range 24 {
+		files[fmt.Sprintf("file%d.bin", index)] = fmt.Sprintf("%d", index) + strings.Repeat("x", 512<<10)
+	}
+	if err := cache.StoreRepository(testRepository("large", files)); err != nil {
+		t.Fatalf("StoreRepository failed: %v", err)
+	}
+	repo, err := cache.GetRepository("large")
+	if err != nil {
+		t.Fatalf("GetRepository failed: %v", err)
+	}
+	if repo.Files["file23.bin"].Content != files["file23.bin"] {
+		t.Error("Expected the content of every file to be stored")
+	}
+
+	if err := cache.DeleteRepository("large"); err != nil {
+		t.Fatalf("DeleteRepository failed: %v", err)
+	}
+	if count := blobCount(t, cache); count != 0 {
+		t.Errorf("Expected no leaked blobs, got %d", count)
+	}
+}
+
+// ************************************************************************************************
+// Test that single file entries reference the shared store and resolve on read
+func TestCache_StoreFileDeduplicates(t *testing.T) {
+	cache := newTestCache(t)
+
+	file := &types.IndexedFile{Path: "LICENSE", Content: "MIT"}
+	if err := cache.StoreFile("repo-a", file); err != nil {
+		t.Fatalf("StoreFile failed: %v", err)
+	}
+	if err := cache.StoreFile("repo-b", file); err != nil {
+		t.Fatalf("StoreFile failed: %v", err)
+	}
+	if file.ContentRef != "" || file.Content != "MIT" {
+		t.Error("Expected StoreFile not to modify the caller's file")
+	}
+	if count := blobCount(t, cache); count != 1 {
+		t.Errorf("Expected 1 blob, got %d", count)
+	}
+
+	got, err := cache.GetFile("repo-b", "LICENSE")
+	if err != nil {
+		t.Fatalf("GetFile failed: %v", err)
+	}
+	if got.Content != "MIT" {
+		t.Errorf("Expected resolved content, got %q", got.Content)
+	}
+
+	cache.DeleteRepository("repo-a")
+	cache.DeleteRepository("repo-b")
+	if count := blobCount(t, cache); count != 0 {
+		t.Errorf("Expected no blobs after deleting file entries, got %d", count)
+	}
+}
+
+// ************************************************************************************************
+// Test that renaming moves the repository and file entries, keeping their content references
+func TestCache_RenameRepository(t *testing.T) {
+	cache := newTestCache(t)
+
+	cache.StoreRepository(testRepository("old", map[string]string{"a.txt": "alpha"}))
+	cache.StoreFile("old", &types.IndexedFile{Path: "LICENSE", Content: "MIT", RepositoryID: "old"})
+	cache.StoreRepository(testRepository("taken", map[string]string{"b.txt": "beta"}))
+
+	if err := cache.RenameRepository("old", "taken"); !errors.Is(err, types.ErrRepositoryIDCollision) {
+		t.Errorf("Expected ErrRepositoryIDCollision, got %v", err)
+	}
+	if err := cache.RenameRepository("missing", "new"); !errors.Is(err, types.ErrRepositoryNotFound) {
+		t.Errorf("Expected ErrRepositoryNotFound, got %v", err)
+	}
+	if err := cache.RenameRepository("old", "new"); err != nil {
+		t.Fatalf("RenameRepository failed: %v", err)
+	}
+
+	repo, err := cache.GetRepository("new")
+	if err != nil {
+		t.Fatalf("GetRepository after rename failed: %v", err)
+	}
+	if repo.ID != "new" || repo.Name != "new" || repo.Files["a.txt"].Content != "alpha" || repo.Files["a.txt"].RepositoryID != "new" {
+		t.Errorf("Expected the renamed repository with its content, got %+v", repo)
+	}
+	file, err := cache.GetFile("new", "LICENSE")
+	if err != nil || file.Content != "MIT" || file.RepositoryID != "new" {
+		t.Errorf("Expected the renamed file entry, got %+v (%v)", file, err)
+	}
+	if keys, _ := cache.ListAllKeys("file:old:"); len(keys) != 0 {
+		t.Errorf("Expected no file entries left under the old ID, got %v", keys)
+	}
+	if _, err := cache.GetRepository("old"); !errors.Is(err, types.ErrRepositoryNotFound) {
+		t.Errorf("Expected the old ID to be gone, got %v", err)
+	}
+
+	// References moved with the entries, deleting releases every blob
+	cache.DeleteRepository("new")
+	cache.DeleteRepository("taken")
+	if count := blobCount(t, cache); count != 0 {
+		t.Errorf("Expected no leaked blobs, got %d", count)
+	}
+}
+
+// ************************************************************************************************
+// Test that a repository with more file entries than one transaction holds is renamed in batches
+func TestCache_RenameRepositoryBatchesFiles(t *testing.T) {
+	cache := newTestCache(t)
+
+	cache.StoreRepository(testRepository("old", map[string]string{"a.txt": "alpha"}))
+	for index := range 120 {
+		file := &types.IndexedFile{
+			Path:         fmt.Sprintf("file%d.txt", index),
+			Content:      fmt.Sprintf("content %d", index),
+			RepositoryID: "old",
+			Metadata:     map[string]string{"summary": strings.Repeat("x", 100<<10)},
+		}
+		if err := cache.StoreFile("old", file); err != nil {
+			t.Fatalf("StoreFile failed: %v", err)
+		}
+	}
+
+	if err := cache.RenameRepository("old", "new"); err != nil {
+		t.Fatalf("RenameRepository failed: %v", err)
+	}
+	if keys, _ := cache.ListAllKeys("file:new:"); len(keys) != 120 {
+		t.Errorf("Expected 120 file entries under the new ID, got %d", len(keys))
+	}
+	if keys, _ := cache.ListAllKeys("file:old:"); len(keys) != 0 {
+		t.Errorf("Expected no file entries left under the old ID, got %d", len(keys))
+	}
+	file, err := cache.GetFile("new", "file119.txt")
+	if err != nil || file.Content != "content 119" || file.RepositoryID != "new" {
+		t.Errorf("Expected the renamed file entry, got %+v (%v)", file, err)
+	}
+	if _, err := cache.GetRepository("new"); err != nil {
+		t.Errorf("GetRepository after rename failed: %v", err)
+	}
+
+	cache.DeleteRepository("new")
+	if count := blobCount(t, cache); count != 0 {
+		t.Errorf("Expected no leaked blobs, got %d", count)
+	}
+}
+
+// ************************************************************************************************
+// Test that repository entries above the maximum entry size are split and reassembled, and that
+// their parts follow the entry when it is replaced, renamed or deleted
+func TestCache_ChunkedRepository(t *testing.T) {
+	cache := newTestCache(t)
+	repo := testRepository("big", map[string]string{"a.txt": "alpha"})
+	repo.Metadata["api_summary"] = strings.Repeat("func Exported()\n", 200)
+
+	partCount := func() int {
+		keys, err := cache.ListAllKeys(repoPartPrefix)
+		if err != nil {
+			t.Fatalf("Failed to list parts: %v", err)
+		}
+		return len(keys)
+	}
+	assertRepository := func(id string) {
+		t.Helper()
+		stored, err := cache.GetRepository(id)
+		if err != nil {
+			t.Fatalf("GetRepository failed: %v", err)
+		}
+		if stored.Metadata["api_summary"] != repo.Metadata["api_summary"] || stored.Files["a.txt"].Content != "alpha" {
+			t.Errorf("Expected the repository to be reassembled intact, got %+v", stored)
+		}
+	}
+
+	// Measure the encoded entry without chunking
+	cache.config.MaxEntrySize = "0"
+	if err := cache.StoreRepository(repo); err != nil {
+		t.Fatalf("StoreRepository failed: %v", err)
+	}
+	raw, err := cache.GetRawValue("repo:big")
+	if err != nil {
+		t.Fatalf("GetRawValue failed: %v", err)
+	}
+	size := len(raw)
+
+	// An entry of exactly the maximum size stays whole
+	cache.config.MaxEntrySize = fmt.Sprintf("%d", size)
+	cache.StoreRepository(repo)
+	if count := partCount(); count != 0 {
+		t.Errorf("Expected no parts at the maximum size, got %d", count)
+	}
+	assertRepository("big")
+
+	// One byte more is split in two
+	cache.config.MaxEntrySize = fmt.Sprintf("%d", size-1)
+	cache.StoreRepository(repo)
+	if count := partCount(); count != 2 {
+		t.Errorf("Expected 2 parts one byte above the maximum size, got %d", count)
+	}
+	assertRepository("big")
+
+	cache.config.MaxEntrySize = fmt.Sprintf("%d", size/5)
+	cache.StoreRepository(repo)
+	if count := partCount(); count != 6 {
+		t.Errorf("Expected 6 parts, got %d", count)
+	}
+	assertRepository("big")
+	if ids, _ := cache.ListRepositories(); len(ids) != 1 || ids[0] != "big" {
+		t.Errorf("Expected parts not to be listed as repositories, got %v", ids)
+	}
+
+	// Fewer parts on replacement, the extra ones are deleted
+	cache.config.MaxEntrySize = fmt.Sprintf("%d", size/2)
+	cache.StoreRepository(repo)
+	if count := partCount(); count != 3 {
+		t.Errorf("Expected 3 parts after replacement, got %d", count)
+	}
+
+	if err := cache.RenameRepository("big", "huge"); err != nil {
+		t.Fatalf("RenameRepository failed: %v", err)
+	}
+	assertRepository("huge")
+	if keys, _ := cache.ListAllKeys(repoPartPrefix + "big:"); len(keys) != 0 {
+		t.Errorf("Expected no parts left under the old ID, got %v", keys)
+	}
+
+	if err := cache.DeleteRepository("huge"); err != nil {
+		t.Fatalf("DeleteRepository failed: %v", err)
+	}
+	if count := partCount(); count != 0 || blobCount(t, cache) != 0 {
+		t.Errorf("Expected parts and content to be deleted, got %d parts and %d blobs", count, blobCount(t, cache))
+	}
+}
+
+// ************************************************************************************************
+// Test that a repository entry with more parts than one transaction holds is stored in batches,
+// and that replacing it leaves only the parts of the new entry
+func TestCache_ChunkedRepositoryBatchesParts(t *testing.T) {
+	cache := newTestCache(t)
+	cache.config.MaxEntrySize = "1024"
+	repo := testRepository("huge", map[string]string{"a.txt": "alpha"})
+
+	for _, summary := range []string{strings.Repeat("func Exported()\n", 1<<20), strings.Repeat("func Replaced()\n", 1<<10)} {
+		repo.Metadata["api_summary"] = summary
+		if err := cache.StoreRepository(repo); err != nil {
+			t.Fatalf("StoreRepository failed: %v", err)
+		}
+		stored, err := cache.GetRepository("huge")
+		if err != nil {
+			t.Fatalf("GetRepository failed: %v", err)
+		}
+		if stored.Metadata["api_summary"] != summary {
+			t.Errorf("Expected the repository to be reassembled intact, got %.40q", stored.Metadata["api_summary"])
+		}
+	}
+
+	raw, err := cache.GetRawValue("repo:huge")
+	if err != nil {
+		t.Fatalf("GetRawValue failed: %v", err)
+	}
+	manifest, chunked := readManifest(raw)
+	keys, _ := cache.ListAllKeys(repoPartPrefix)
+	if !chunked || len(keys) != manifest.Chunks {
+		t.Errorf("Expected only the %d parts of the new entry, got %d", manifest.Chunks, len(keys))
+	}
+
+	if err := cache.DeleteRepository("huge"); err != nil {
+		t.Fatalf("DeleteRepository failed: %v", err)
+	}
+	if keys, _ := cache.ListAllKeys(repoPartPrefix); len(keys) != 0 {
+		t.Errorf("Expected the parts to be deleted, got %d", len(keys))
+	}
+}
+
+// ************************************************************************************************
+// Test that manifests are recognized by their tag byte only, not by the fields of the JSON value
+func TestReadManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		chunked bool
+	}{
+		{"tagged manifest", "\x00" + `{"chunks":3,"size":10,"first":2}`, true},
+		{"untagged manifest fields", `{"chunks":3,"size":10}`, false},
+		{"repository index", `{"id":"repo","chunks":3}`, false},
+		{"tagged manifest without parts", "\x00" + `{"chunks":0,"size":10}`, false},
+		{"tagged invalid manifest", "\x00not json", false},
+		{"empty value", "", false},
+	}
+	for _, test := range tests {
+		manifest, chunked := readManifest([]byte(test.value))
+		if chunked != test.chunked {
+			t.Errorf("%s: expected chunked %v, got %v", test.name, test.chunked, chunked)
+		}
+		if chunked && (manifest.Chunks != 3 || manifest.Size != 10 || manifest.First != 2) {
+			t.Errorf("%s: expected the decoded manifest, got %+v", test.name, manifest)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that entries past their TTL are pruned, and that entries written again are kept
+func TestCache_PruneExpired(t *testing.T) {
+	cache, err := NewCache(&types.CacheConfig{Path: t.TempDir(), TTL: "1h"})
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	cache.StoreRepository(testRepository("old", map[string]string{"a.txt": "alpha"}))
+	cache.StoreFile("old", &types.IndexedFile{Path: "LICENSE", Content: "MIT", RepositoryID: "old"})
+	stored, _ := cache.ListAllKeys("")
+
+	// Nothing has expired yet
+	if pruned, err := cache.PruneExpired(); err != nil || pruned != 0 {
+		t.Fatalf("Expected nothing to prune, got %d (%v)", pruned, err)
+	}
+
+	defer func() { mock_timeNow = time.Now }()
+	mock_timeNow = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	pruned, err := cache.PruneExpired()
+	if err != nil {
+		t.Fatalf("PruneExpired failed: %v", err)
+	}
+	if pruned != len(stored) {
+		t.Errorf("Expected %d entries pruned, got %d", len(stored), pruned)
+	}
+	if keys, _ := cache.ListAllKeys(""); len(keys) != 0 {
+		t.Errorf("Expected no entries left, got %v", keys)
+	}
+
+	// Entries without a TTL never expire
+	cache.config.TTL = ""
+	cache.StoreRepository(testRepository("kept", map[string]string{"b.txt": "beta"}))
+	if pruned, err := cache.PruneExpired(); err != nil || pruned != 0 {
+		t.Errorf("Expected entries without TTL to be kept, got %d pruned (%v)", pruned, err)
+	}
+}
+
+// ************************************************************************************************
+// Test that value previews honor the requested length without splitting UTF-8 characters
+func TestCache_FormatValuePreviewLength(t *testing.T) {
+	c := &Cache{}
+	value := []byte(strings.Repeat("é", 200))
+
+	for _, maxLen := range []int{0, 41, 42, 200} {
+		preview := c.FormatValuePreviewLength(value, maxLen)
+		limit := maxLen
+		if limit <= 0 {
+			limit = DefaultPreviewLength
+		}
+		if !strings.HasSuffix(preview, "...") {
+			t.Errorf("maxLen %d: expected a truncated preview, got %q", maxLen, preview)
+		}
+		if body := strings.TrimSuffix(preview, "..."); len(body) > limit || !utf8.ValidString(body) {
+			t.Errorf("maxLen %d: expected at most %d bytes of valid UTF-8, got %q", maxLen, limit, body)
+		}
+	}
+
+	if preview := c.FormatValuePreview(value); preview != c.FormatValuePreviewLength(value, DefaultPreviewLength) {
+		t.Errorf("Expected FormatValuePreview to use the default length, got %q", preview)
+	}
+	if preview := c.FormatValuePreviewLength([]byte("short"), 42); preview != "short" {
+		t.Errorf("Expected short values unchanged, got %q", preview)
+	}
+}
\ No newline at end of file
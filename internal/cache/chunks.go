@@ -0,0 +1,257 @@
+// ************************************************************************************************
+// Package cache provides the chunking of oversized repository entries.
+// A repository index whose encoded size exceeds the configured maximum entry size is split
+// into numbered "repopart:<id>:<n>" entries; "repo:<id>" then holds a small manifest giving
+// their range, tagged by a leading byte no JSON document starts with. Reads reassemble the
+// parts transparently, small indexes stay single entries.
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ************************************************************************************************
+// repoPartPrefix is the key prefix of the parts of a chunked repository entry.
+const repoPartPrefix = "repopart:"
+
+// ************************************************************************************************
+// defaultMaxEntrySize is the encoded size above which a repository index is chunked when
+// cache.maxEntrySize is not configured.
+const defaultMaxEntrySize = 1 << 20
+
+// ************************************************************************************************
+// chunkManifestTag is the first byte of the value of a chunked repository entry, followed by
+// the JSON manifest. Encoded repository indexes start with '{', so a manifest is told apart
+// whatever the fields of either type.
+const chunkManifestTag byte = 0x00
+
+// ************************************************************************************************
+// chunkManifest replaces the value of a chunked repository entry.
+type chunkManifest struct {
+	Chunks int `json:"chunks"`          // Number of parts
+	Size   int `json:"size"`            // Encoded size of the whole repository index
+	First  int `json:"first,omitempty"` // Number of the first part, after those of the previous index
+}
+
+// ************************************************************************************************
+// partKeys returns the keys of the parts of a chunked repository entry, in order.
+func (m chunkManifest) partKeys(repositoryID string) []string {
+	keys := make([]string, m.Chunks)
+	for index := range keys {
+		keys[index] = repoPartKey(repositoryID, m.First+index)
+	}
+	return keys
+}
+
+// ************************************************************************************************
+// maxEntrySize returns the encoded size above which a repository index is chunked, 0 when
+// chunking is disabled with "0".
+func (c *Cache) maxEntrySize() int {
+	if c.config == nil || c.config.MaxEntrySize == "" {
+		return defaultMaxEntrySize
+	}
+	if strings.TrimSpace(c.config.MaxEntrySize) == "0" {
+		return 0
+	}
+	size, err := types.ParseByteSize(c.config.MaxEntrySize)
+	if err != nil || size <= 0 {
+		return defaultMaxEntrySize
+	}
+	return int(size)
+}
+
+// ************************************************************************************************
+// repoPartKey returns the key of a part of a chunked repository entry.
+func repoPartKey(repositoryID string, index int) string {
+	return fmt.Sprintf("%s%s:%d", repoPartPrefix, repositoryID, index)
+}
+
+// ************************************************************************************************
+// readManifest decodes the manifest of a repository entry value.
+//
+// Returns:
+//   - chunkManifest: The manifest.
+//   - bool: False if the value is a whole repository index.
+func readManifest(data []byte) (chunkManifest, bool) {
+	var manifest chunkManifest
+	if len(data) == 0 || data[0] != chunkManifestTag {
+		return manifest, false
+	}
+	if err := json.Unmarshal(data[1:], &manifest); err != nil || manifest.Chunks <= 0 {
+		return manifest, false
+	}
+	return manifest, true
+}
+
+// ************************************************************************************************
+// readRepositoryEntry returns the encoded repository index stored under "repo:<id>",
+// reassembling its parts when it is chunked.
+//
+// Returns:
+//   - []byte: The encoded repository index.
+//   - error: badger.ErrKeyNotFound if the repository is not cached, or an error if a part is
+//     missing.
+func readRepositoryEntry(txn *badger.Txn, repositoryID string) ([]byte, error) {
+	item, err := txn.Get([]byte("repo:" + repositoryID))
+	if err != nil {
+		return nil, err
+	}
+	data, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, chunked := readManifest(data)
+	if !chunked {
+		return data, nil
+	}
+	assembled := make([]byte, 0, manifest.Size)
+	for index, key := range manifest.partKeys(repositoryID) {
+		part, err := txn.Get([]byte(key))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read part %d of %d of repository %s\n>    %w", index+1, manifest.Chunks, repositoryID, err)
+		}
+		err = part.Value(func(val []byte) error {
+			assembled = append(assembled, val...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(assembled) != manifest.Size {
+		return nil, fmt.Errorf("%w: repository %s reassembled to %d bytes, expected %d", types.ErrCacheCorrupted, repositoryID, len(assembled), manifest.Size)
+	}
+	return assembled, nil
+}
+
+// ************************************************************************************************
+// writeRepositoryEntry stores an encoded repository index under "repo:<id>". The parts of a
+// chunked index are written first, in as many transactions as needed, then the entry is
+// switched to them in a transaction of its own, so readers see either the previous index or the
+// new one whole. Entries expire at expiresAt, a Unix time, or after the cache TTL when it is 0.
+//
+// Returns:
+//   - chunkManifest: The manifest of the parts of the previous index, to delete with
+//     deleteRepositoryParts once nothing else can fail.
+//   - error: An error if an entry cannot be written, the new parts being deleted.
+func (c *Cache) writeRepositoryEntry(repositoryID string, data []byte, expiresAt uint64) (chunkManifest, error) {
+	head, manifest, err := c.writeRepositoryParts(repositoryID, data, expiresAt)
+	if err != nil {
+		return chunkManifest{}, err
+	}
+
+	var previous chunkManifest
+	err = c.update(func(txn *badger.Txn) error {
+		var err error
+		if previous, err = storedManifest(txn, repositoryID); err != nil {
+			return err
+		}
+		return txn.SetEntry(c.repositoryEntry("repo:"+repositoryID, head, expiresAt))
+	})
+	if err != nil {
+		c.deleteRepositoryParts(repositoryID, manifest)
+		return chunkManifest{}, err
+	}
+	return previous, nil
+}
+
+// ************************************************************************************************
+// writeRepositoryParts writes the parts of an encoded repository index larger than the maximum
+// entry size, in as many transactions as needed. They are numbered after the parts of the
+// stored index, which stay readable until the entry is switched to the new ones.
+//
+// Returns:
+//   - []byte: The value of the "repo:<id>" entry, the tagged manifest of the parts, or data
+//     itself when it is not chunked.
+//   - chunkManifest: The manifest of the parts, without parts when data is not chunked.
+//   - error: An error if a part cannot be written, the parts already written being deleted.
+func (c *Cache) writeRepositoryParts(repositoryID string, data []byte, expiresAt uint64) ([]byte, chunkManifest, error) {
+	maxSize := c.maxEntrySize()
+	if maxSize <= 0 || len(data) <= maxSize {
+		return data, chunkManifest{}, nil
+	}
+
+	var previous chunkManifest
+	err := c.db.View(func(txn *badger.Txn) error {
+		var err error
+		previous, err = storedManifest(txn, repositoryID)
+		return err
+	})
+	if err != nil {
+		return nil, chunkManifest{}, fmt.Errorf("failed to read repository entry\n>    %w", err)
+	}
+
+	manifest := chunkManifest{Chunks: (len(data) + maxSize - 1) / maxSize, Size: len(data), First: previous.First + previous.Chunks}
+	keys := manifest.partKeys(repositoryID)
+	parts := make(map[string][]byte, len(keys))
+	for index, key := range keys {
+		parts[key] = data[index*maxSize : min((index+1)*maxSize, len(data))]
+	}
+	written, err := c.updateBatched(keys, func(txn *badger.Txn, key string) error {
+		return txn.SetEntry(c.repositoryEntry(key, parts[key], expiresAt))
+	})
+	if err != nil {
+		c.deleteRepositoryParts(repositoryID, chunkManifest{Chunks: written, First: manifest.First})
+		return nil, chunkManifest{}, fmt.Errorf("failed to store part %d of repository %s\n>    %w", written+1, repositoryID, err)
+	}
+
+	encoded, err := json.Marshal(manifest)
+	if err != nil {
+		c.deleteRepositoryParts(repositoryID, manifest)
+		return nil, chunkManifest{}, err
+	}
+	return append([]byte{chunkManifestTag}, encoded...), manifest, nil
+}
+
+// ************************************************************************************************
+// repositoryEntry returns a repository entry expiring at expiresAt, a Unix time, or after the
+// cache TTL when it is 0.
+func (c *Cache) repositoryEntry(key string, value []byte, expiresAt uint64) *badger.Entry {
+	if expiresAt == 0 {
+		return c.newEntry(key, value)
+	}
+	entry := badger.NewEntry([]byte(key), value)
+	entry.ExpiresAt = expiresAt
+	return entry
+}
+
+// ************************************************************************************************
+// storedManifest returns the manifest of the stored entry of a repository, without parts when
+// it is a single entry or not stored.
+func storedManifest(txn *badger.Txn, repositoryID string) (chunkManifest, error) {
+	var manifest chunkManifest
+	item, err := txn.Get([]byte("repo:" + repositoryID))
+	if err == badger.ErrKeyNotFound {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, err
+	}
+
+	err = item.Value(func(val []byte) error {
+		if stored, chunked := readManifest(val); chunked {
+			manifest = stored
+		}
+		return nil
+	})
+	return manifest, err
+}
+
+// ************************************************************************************************
+// deleteRepositoryParts deletes the parts of a manifest, in as many transactions as needed.
+func (c *Cache) deleteRepositoryParts(repositoryID string, manifest chunkManifest) error {
+	_, err := c.updateBatched(manifest.partKeys(repositoryID), func(txn *badger.Txn, key string) error {
+		return txn.Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete the parts of repository %s\n>    %w", repositoryID, err)
+	}
+	return nil
+}
@@ -7,12 +7,20 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"repomix-mcp/pkg/types"
 )
 
+// ************************************************************************************************
+// minAPIKeyLength is the minimum length of the API keys accepted by the MCP endpoints.
+const minAPIKeyLength = 16
+
 // ************************************************************************************************
 // Manager handles configuration loading, validation, and management.
 // It provides centralized access to application configuration with validation
@@ -79,7 +87,7 @@ func (m *Manager) LoadConfig(configPath string) error {
 	}
 	
 	// Parse JSON
-	var config types.Config
+	config := newDefaultConfig()
 	if err := json.Unmarshal(data, &config); err != nil {
 		return fmt.Errorf("failed to parse config file\n>    %w", err)
 	}
@@ -106,7 +114,7 @@ func (m *Manager) LoadConfig(configPath string) error {
 //	jsonData := []byte(`{"repositories": {...}}`)
 //	err := manager.LoadConfigFromJSON(jsonData)
 func (m *Manager) LoadConfigFromJSON(jsonData []byte) error {
-	var config types.Config
+	config := newDefaultConfig()
 	if err := json.Unmarshal(jsonData, &config); err != nil {
 		return fmt.Errorf("failed to parse JSON config\n>    %w", err)
 	}
@@ -119,6 +127,20 @@ func (m *Manager) LoadConfigFromJSON(jsonData []byte) error {
 	return nil
 }
 
+// ************************************************************************************************
+// newDefaultConfig returns a configuration pre-populated with defaults that cannot be
+// expressed by Go zero values. JSON decoding overrides them only when keys are present.
+//
+// Returns:
+//   - types.Config: The configuration holding default values.
+func newDefaultConfig() types.Config {
+	return types.Config{
+		GoModule: types.GoModuleConfig{
+			VerifyChecksums: true,
+		},
+	}
+}
+
 // ************************************************************************************************
 // validateConfig validates the loaded configuration for consistency and completeness.
 // It checks repository configurations, cache settings, and server parameters.
@@ -146,10 +168,43 @@ func (m *Manager) validateConfig(config *types.Config) error {
 		return fmt.Errorf("invalid cache config\n>    %w", err)
 	}
 	
+	// Repositories are indexed one at a time unless configured otherwise
+	if config.Index.Parallelism < 0 {
+		return fmt.Errorf("%w: invalid index parallelism: %d", types.ErrInvalidConfig, config.Index.Parallelism)
+	}
+	if config.Index.Parallelism == 0 {
+		config.Index.Parallelism = 1
+	}
+	
+	// Repositories without their own hash algorithm use the cache-wide one
+	for alias, repo := range config.Repositories {
+		if repo.Indexing.HashAlgorithm == "" {
+			repo.Indexing.HashAlgorithm = config.Cache.HashAlgorithm
+			config.Repositories[alias] = repo
+		}
+	}
+	
 	// Validate server configuration
 	if err := m.validateServer(&config.Server); err != nil {
 		return fmt.Errorf("invalid server config\n>    %w", err)
 	}
+
+	// ACME certificates are stored with the cache unless configured otherwise
+	if config.Server.ACME.Enabled && config.Server.ACME.CacheDir == "" {
+		config.Server.ACME.CacheDir = filepath.Join(config.Cache.Path, "acme")
+	}
+	
+	// Validate Go module configuration
+	if err := m.validateGoModule(&config.GoModule); err != nil {
+		return fmt.Errorf("invalid go module config\n>    %w", err)
+	}
+	
+	// Validate workspaces
+	for name, workspace := range config.Workspaces {
+		if err := m.validateWorkspace(name, &workspace, config.Repositories); err != nil {
+			return fmt.Errorf("invalid workspace '%s'\n>    %w", name, err)
+		}
+	}
 	
 	return nil
 }
@@ -164,16 +219,34 @@ func (m *Manager) validateRepository(alias string, repo *types.RepositoryConfig)
 		return fmt.Errorf("%w: repository alias cannot be empty", types.ErrInvalidConfig)
 	}
 	
-	if repo.Type != types.RepositoryTypeLocal && repo.Type != types.RepositoryTypeRemote {
+	// Aliases share the repository ID namespace with synthetic Go module IDs
+	if err := types.ValidateRepositoryID(alias); err != nil {
+		return err
+	}
+	
+	if repo.Type != types.RepositoryTypeLocal && repo.Type != types.RepositoryTypeRemote && repo.Type != types.RepositoryTypeHTTP {
 		return fmt.Errorf("%w: %s", types.ErrInvalidRepositoryType, repo.Type)
 	}
 	
 	// Validate paths/URLs
-	if repo.Type == types.RepositoryTypeLocal {
+	switch repo.Type {
+	case types.RepositoryTypeLocal:
 		if repo.Path == "" {
 			return fmt.Errorf("%w: local repository path cannot be empty", types.ErrInvalidConfig)
 		}
-	} else {
+	case types.RepositoryTypeHTTP:
+		if err := validateDownloadURL(repo.URL); err != nil {
+			return err
+		}
+		if repo.Auth.Type == types.AuthTypeSSH {
+			return fmt.Errorf("%w: SSH auth is not supported for http repositories", types.ErrInvalidConfig)
+		}
+		if repo.MaxDownloadSize != "" {
+			if _, err := types.ParseByteSize(repo.MaxDownloadSize); err != nil {
+				return fmt.Errorf("invalid maxDownloadSize\n>    %w", err)
+			}
+		}
+	default:
 		if repo.URL == "" {
 			return fmt.Errorf("%w: remote repository URL cannot be empty", types.ErrInvalidConfig)
 		}
@@ -184,6 +257,54 @@ func (m *Manager) validateRepository(alias string, repo *types.RepositoryConfig)
 		return fmt.Errorf("invalid auth config\n>    %w", err)
 	}
 	
+	// Validate the indexing strategy override
+	switch repo.Indexing.Strategy {
+	case "", types.IndexingStrategyAuto, types.IndexingStrategyRepomix, types.IndexingStrategyGoNative, types.IndexingStrategyPythonNative, types.IndexingStrategyTypeScriptNative, types.IndexingStrategyRustNative, types.IndexingStrategyJavaNative, types.IndexingStrategyCSharpNative, types.IndexingStrategyTreeSitter:
+	default:
+		return fmt.Errorf("%w: unknown indexing strategy %q (expected auto, repomix, go_native, python_native, typescript_native, rust_native, java_native, csharp_native or tree_sitter)", types.ErrInvalidConfig, repo.Indexing.Strategy)
+	}
+	if err := types.ValidateHashAlgorithm(repo.Indexing.HashAlgorithm); err != nil {
+		return err
+	}
+	for _, name := range repo.Indexing.FallbackStrategies {
+		switch name {
+		case types.IndexingStrategyRepomix, types.IndexingStrategyGoNative, types.IndexingStrategyPythonNative, types.IndexingStrategyTypeScriptNative, types.IndexingStrategyRustNative, types.IndexingStrategyJavaNative, types.IndexingStrategyCSharpNative, types.IndexingStrategyTreeSitter:
+		case types.IndexingStrategyNone:
+			if len(repo.Indexing.FallbackStrategies) > 1 {
+				return fmt.Errorf("%w: fallback strategy \"none\" cannot be combined with other strategies", types.ErrInvalidConfig)
+			}
+		default:
+			return fmt.Errorf("%w: unknown fallback strategy %q (expected repomix, go_native, python_native, typescript_native, rust_native, java_native, csharp_native, tree_sitter or none)", types.ErrInvalidConfig, name)
+		}
+	}
+	for _, pattern := range repo.Indexing.APISpecFiles {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: invalid API specification pattern %q", types.ErrInvalidConfig, pattern)
+		}
+	}
+	if repo.Indexing.ChunkOverlap < 0 || (repo.Indexing.ChunkMaxLines > 0 && repo.Indexing.ChunkOverlap >= repo.Indexing.ChunkMaxLines) {
+		return fmt.Errorf("%w: invalid chunkOverlap: %d (expected a positive number of lines below chunkMaxLines)", types.ErrInvalidConfig, repo.Indexing.ChunkOverlap)
+	}
+	
+	// Validate the scheduled re-indexing interval
+	if repo.RefreshInterval != "" {
+		if d, err := time.ParseDuration(repo.RefreshInterval); err != nil || d <= 0 {
+			return fmt.Errorf("%w: invalid refreshInterval: %s", types.ErrInvalidConfig, repo.RefreshInterval)
+		}
+	}
+	
+	// Only local repositories have files to watch
+	if repo.Watch && repo.Type != types.RepositoryTypeLocal {
+		return fmt.Errorf("%w: watch is only supported for local repositories", types.ErrInvalidConfig)
+	}
+	
+	// Validate the access scopes, an empty scope would match tokens without scopes
+	for _, scope := range repo.Scopes {
+		if strings.TrimSpace(scope) == "" || strings.ContainsAny(scope, " \t") {
+			return fmt.Errorf("%w: invalid scope %q", types.ErrInvalidConfig, scope)
+		}
+	}
+	
 	// Set default branch if not specified
 	if repo.Branch == "" {
 		repo.Branch = "main"
@@ -192,6 +313,53 @@ func (m *Manager) validateRepository(alias string, repo *types.RepositoryConfig)
 	return nil
 }
 
+// ************************************************************************************************
+// validateDownloadURL checks that an http repository URL is an absolute http or https URL.
+//
+// Returns:
+//   - error: An error if the URL is empty, malformed or uses another scheme.
+func validateDownloadURL(rawURL string) error {
+	if rawURL == "" {
+		return fmt.Errorf("%w: http repository URL cannot be empty", types.ErrInvalidConfig)
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: invalid http repository URL %q\n>    %w", types.ErrInvalidConfig, rawURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("%w: http repository URL must use the http or https scheme: %s", types.ErrInvalidConfig, rawURL)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("%w: http repository URL has no host: %s", types.ErrInvalidConfig, rawURL)
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// validateWorkspace validates a workspace definition. Workspace names follow the repository
+// ID rules and every member must be a configured repository alias.
+//
+// Returns:
+//   - error: An error if the workspace definition is invalid.
+func (m *Manager) validateWorkspace(name string, workspace *types.WorkspaceConfig, repositories map[string]types.RepositoryConfig) error {
+	if err := types.ValidateRepositoryID(name); err != nil {
+		return err
+	}
+
+	if len(workspace.Repositories) == 0 {
+		return fmt.Errorf("%w: workspace has no repositories", types.ErrInvalidConfig)
+	}
+
+	for _, alias := range workspace.Repositories {
+		if _, exists := repositories[alias]; !exists {
+			return fmt.Errorf("%w: unknown repository alias %s", types.ErrInvalidConfig, alias)
+		}
+	}
+
+	return nil
+}
+
 // ************************************************************************************************
 // validateAuth validates authentication configuration.
 //
@@ -235,6 +403,29 @@ func (m *Manager) validateCache(cache *types.CacheConfig) error {
 		cache.Path = filepath.Join(homeDir, cache.Path[1:])
 	}
 	
+	if err := types.ValidateHashAlgorithm(cache.HashAlgorithm); err != nil {
+		return err
+	}
+	if cache.HashAlgorithm == "" {
+		cache.HashAlgorithm = types.DefaultHashAlgorithm
+	}
+	
+	// Set the repository entry chunking threshold default, "0" disables chunking
+	if cache.MaxEntrySize == "" {
+		cache.MaxEntrySize = "1MB"
+	}
+	if strings.TrimSpace(cache.MaxEntrySize) != "0" {
+		if size, err := types.ParseByteSize(cache.MaxEntrySize); err != nil || size <= 0 {
+			return fmt.Errorf("%w: invalid maxEntrySize: %s", types.ErrInvalidConfig, cache.MaxEntrySize)
+		}
+	}
+	
+	if cache.PruneInterval != "" {
+		if d, err := time.ParseDuration(cache.PruneInterval); err != nil || d <= 0 {
+			return fmt.Errorf("%w: invalid pruneInterval: %s", types.ErrInvalidConfig, cache.PruneInterval)
+		}
+	}
+	
 	return nil
 }
 
@@ -260,20 +451,199 @@ func (m *Manager) validateServer(server *types.ServerConfig) error {
 	if !isValidLevel {
 		return fmt.Errorf("%w: invalid log level: %s", types.ErrInvalidConfig, server.LogLevel)
 	}
+	if server.LogFormat != "" && server.LogFormat != "text" && server.LogFormat != "json" {
+		return fmt.Errorf("%w: invalid log format: %s, expected text or json", types.ErrInvalidConfig, server.LogFormat)
+	}
+	
+	// Validate the log file rotation options, used only when a log file is set
+	if strings.HasPrefix(server.LogFile, "~") {
+		homeDir, err := mock_osUserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory\n>    %w", err)
+		}
+		server.LogFile = filepath.Join(homeDir, server.LogFile[1:])
+	}
+	if server.LogMaxSize == "" {
+		server.LogMaxSize = "100MB"
+	}
+	if strings.TrimSpace(server.LogMaxSize) != "0" {
+		if size, err := types.ParseByteSize(server.LogMaxSize); err != nil || size <= 0 {
+			return fmt.Errorf("%w: invalid logMaxSize: %s", types.ErrInvalidConfig, server.LogMaxSize)
+		}
+	}
+	if server.LogMaxAge != "" {
+		if d, err := time.ParseDuration(server.LogMaxAge); err != nil || d < 0 {
+			return fmt.Errorf("%w: invalid logMaxAge: %s", types.ErrInvalidConfig, server.LogMaxAge)
+		}
+	}
+	if server.LogMaxBackups < 0 {
+		return fmt.Errorf("%w: invalid logMaxBackups: %d", types.ErrInvalidConfig, server.LogMaxBackups)
+	}
+	
+	for _, address := range server.ListenAddresses {
+		if err := ValidateListenAddress(address); err != nil {
+			return err
+		}
+	}
 	
 	// Set HTTPS defaults
 	if server.HTTPSPort == 0 {
 		server.HTTPSPort = 9443
 	}
 	
+	if server.CertValidityDays == 0 {
+		server.CertValidityDays = 365
+	}
+	if server.CertValidityDays < 0 {
+		return fmt.Errorf("%w: invalid certificate validity days: %d", types.ErrInvalidConfig, server.CertValidityDays)
+	}
+	
+	switch server.CertKeyAlgorithm {
+	case "":
+		server.CertKeyAlgorithm = "rsa"
+	case "rsa", "ecdsa":
+	default:
+		return fmt.Errorf("%w: invalid certificate key algorithm: %s", types.ErrInvalidConfig, server.CertKeyAlgorithm)
+	}
+	
+	// Set connection timeout defaults and validate them
+	timeouts := []struct {
+		name  string
+		value *string
+		def   string
+	}{
+		{"readHeaderTimeout", &server.ReadHeaderTimeout, "10s"},
+		{"readTimeout", &server.ReadTimeout, "30s"},
+		{"writeTimeout", &server.WriteTimeout, "5m"},
+		{"idleTimeout", &server.IdleTimeout, "120s"},
+		{"shutdownTimeout", &server.ShutdownTimeout, "30s"},
+		{"toolQueueTimeout", &server.ToolQueueTimeout, "30s"},
+		{"watchDebounce", &server.WatchDebounce, "2s"},
+	}
+	for _, timeout := range timeouts {
+		if *timeout.value == "" {
+			*timeout.value = timeout.def
+		}
+		if d, err := time.ParseDuration(*timeout.value); err != nil || d < 0 {
+			return fmt.Errorf("%w: invalid %s: %s", types.ErrInvalidConfig, timeout.name, *timeout.value)
+		}
+	}
+	
+	// Set the staleness note threshold default, "0" disables the note
+	if server.StalenessThreshold == "" {
+		server.StalenessThreshold = "168h"
+	}
+	if d, err := time.ParseDuration(server.StalenessThreshold); err != nil || d < 0 {
+		return fmt.Errorf("%w: invalid stalenessThreshold: %s", types.ErrInvalidConfig, server.StalenessThreshold)
+	}
+	
+	// Set the tokenizer default, the encoding is loaded once and shared with the server
+	if server.Tokenizer == "" {
+		server.Tokenizer = types.DefaultTokenizer
+	}
+	if _, err := types.NewTokenizer(server.Tokenizer); err != nil {
+		return err
+	}
+	
+	// Set the response size limit default, "0" disables the limit
+	if server.MaxResponseSize == "" {
+		server.MaxResponseSize = "4MB"
+	}
+	if strings.TrimSpace(server.MaxResponseSize) != "0" {
+		if _, err := types.ParseByteSize(server.MaxResponseSize); err != nil {
+			return fmt.Errorf("%w: invalid maxResponseSize: %s", types.ErrInvalidConfig, server.MaxResponseSize)
+		}
+	}
+	
+	// Set the response compression threshold default, "0" disables compression
+	if server.CompressionMinSize == "" {
+		server.CompressionMinSize = "1KB"
+	}
+	if strings.TrimSpace(server.CompressionMinSize) != "0" {
+		if _, err := types.ParseByteSize(server.CompressionMinSize); err != nil {
+			return fmt.Errorf("%w: invalid compressionMinSize: %s", types.ErrInvalidConfig, server.CompressionMinSize)
+		}
+	}
+	
+	// Validate the concurrency limit, 0 means unlimited
+	if server.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("%w: invalid maxConcurrentRequests: %d", types.ErrInvalidConfig, server.MaxConcurrentRequests)
+	}
+	if server.MaxConcurrentTools < 0 {
+		return fmt.Errorf("%w: invalid maxConcurrentTools: %d", types.ErrInvalidConfig, server.MaxConcurrentTools)
+	}
+	
+	// Validate the in-memory repository eviction policy, both limits are disabled by default
+	if server.MaxMemoryRepositories < 0 {
+		return fmt.Errorf("%w: invalid maxMemoryRepositories: %d", types.ErrInvalidConfig, server.MaxMemoryRepositories)
+	}
+	if server.MemoryIdleTimeout != "" {
+		if d, err := time.ParseDuration(server.MemoryIdleTimeout); err != nil || d < 0 {
+			return fmt.Errorf("%w: invalid memoryIdleTimeout: %s", types.ErrInvalidConfig, server.MemoryIdleTimeout)
+		}
+	}
+	
+	// Validate the API keys, short keys are easy to guess
+	for i, key := range server.Auth.APIKeys {
+		if len(strings.TrimSpace(key)) < minAPIKeyLength {
+			return fmt.Errorf("%w: auth.apiKeys[%d] must be at least %d characters long", types.ErrInvalidConfig, i, minAPIKeyLength)
+		}
+	}
+	
+	// Validate the OAuth2/OIDC token settings, keys are fetched over HTTP(S) from the issuer
+	for _, endpoint := range []struct {
+		name  string
+		value string
+	}{
+		{"auth.issuer", server.Auth.Issuer},
+		{"auth.jwksUrl", server.Auth.JWKSURL},
+	} {
+		if endpoint.value == "" {
+			continue
+		}
+		parsed, err := url.Parse(endpoint.value)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return fmt.Errorf("%w: invalid %s: %s", types.ErrInvalidConfig, endpoint.name, endpoint.value)
+		}
+	}
+	if server.Auth.ScopeClaim == "" {
+		server.Auth.ScopeClaim = "scope"
+	}
+	
+	// Validate the rate limits, 0 means unlimited
+	limits := []struct {
+		name  string
+		value int
+	}{
+		{"rateLimit.toolCallsPerMinute", server.RateLimit.ToolCallsPerMinute},
+		{"rateLimit.burst", server.RateLimit.Burst},
+		{"rateLimit.expensiveCallsPerMinute", server.RateLimit.ExpensiveCallsPerMinute},
+	}
+	for _, limit := range limits {
+		if limit.value < 0 {
+			return fmt.Errorf("%w: invalid %s: %d", types.ErrInvalidConfig, limit.name, limit.value)
+		}
+	}
+	
+	// Validate the tracing export, spans are posted to the collector over HTTP(S)
+	if server.Tracing.Endpoint != "" {
+		parsed, err := url.Parse(server.Tracing.Endpoint)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return fmt.Errorf("%w: invalid tracing.endpoint: %s", types.ErrInvalidConfig, server.Tracing.Endpoint)
+		}
+	}
+	if ratio := server.Tracing.SampleRatio; ratio != nil && (*ratio < 0 || *ratio > 1) {
+		return fmt.Errorf("%w: invalid tracing.sampleRatio: %g, expected a value from 0 to 1", types.ErrInvalidConfig, *ratio)
+	}
+	
 	// Validate HTTPS configuration
 	if server.HTTPSEnabled {
 		if server.HTTPSPort <= 0 || server.HTTPSPort > 65535 {
 			return fmt.Errorf("%w: invalid HTTPS port number: %d", types.ErrInvalidConfig, server.HTTPSPort)
 		}
 		
-		// If not auto-generating certificate, validate paths
-		if !server.AutoGenCert {
+		// If not auto-generating certificate nor obtaining it over ACME, validate paths
+		if !server.AutoGenCert && !server.ACME.Enabled {
 			if server.CertPath == "" {
 				return fmt.Errorf("%w: certificate path required when HTTPS is enabled and autoGenCert is false", types.ErrInvalidConfig)
 			}
@@ -304,6 +674,96 @@ func (m *Manager) validateServer(server *types.ServerConfig) error {
 			return fmt.Errorf("%w: HTTP and HTTPS ports must be different", types.ErrInvalidConfig)
 		}
 	}
+
+	// Validate mutual TLS configuration
+	if server.RequireClientCert && server.ClientCAPath == "" {
+		return fmt.Errorf("%w: clientCAPath required when requireClientCert is true", types.ErrInvalidConfig)
+	}
+	if server.ClientCAPath != "" {
+		if !server.HTTPSEnabled {
+			return fmt.Errorf("%w: clientCAPath requires httpsEnabled", types.ErrInvalidConfig)
+		}
+		if strings.HasPrefix(server.ClientCAPath, "~") {
+			homeDir, err := mock_osUserHomeDir()
+			if err != nil {
+				return fmt.Errorf("failed to get home directory for client CA path\n>    %w", err)
+			}
+			server.ClientCAPath = filepath.Join(homeDir, server.ClientCAPath[1:])
+		}
+	}
+
+	// Validate ACME configuration, certificates are requested for DNS names only
+	if acme := &server.ACME; acme.Enabled {
+		if !server.HTTPSEnabled {
+			return fmt.Errorf("%w: acme.enabled requires httpsEnabled", types.ErrInvalidConfig)
+		}
+		if len(acme.Hostnames) == 0 {
+			return fmt.Errorf("%w: acme.hostnames required when acme.enabled is true", types.ErrInvalidConfig)
+		}
+		for i, hostname := range acme.Hostnames {
+			hostname = strings.ToLower(strings.TrimSpace(hostname))
+			if hostname == "" || net.ParseIP(hostname) != nil || strings.ContainsAny(hostname, "*:/ ") || !strings.Contains(hostname, ".") {
+				return fmt.Errorf("%w: invalid acme.hostnames entry: %q, expected a DNS name", types.ErrInvalidConfig, acme.Hostnames[i])
+			}
+			acme.Hostnames[i] = hostname
+		}
+		if acme.DirectoryURL != "" {
+			parsed, err := url.Parse(acme.DirectoryURL)
+			if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+				return fmt.Errorf("%w: invalid acme.directoryUrl: %s, expected an https URL", types.ErrInvalidConfig, acme.DirectoryURL)
+			}
+		}
+		if strings.HasPrefix(acme.CacheDir, "~") {
+			homeDir, err := mock_osUserHomeDir()
+			if err != nil {
+				return fmt.Errorf("failed to get home directory for ACME cache directory\n>    %w", err)
+			}
+			acme.CacheDir = filepath.Join(homeDir, acme.CacheDir[1:])
+		}
+	}
+	
+	return nil
+}
+
+// ************************************************************************************************
+// ValidateListenAddress checks that an address is a valid host:port pair to listen on.
+// An empty host (":8080") binds all interfaces.
+//
+// Returns:
+//   - error: An error if the address is malformed or the port is out of range.
+//
+// Example usage:
+//
+//	if err := config.ValidateListenAddress("127.0.0.1:8080"); err != nil {
+//		return err
+//	}
+func ValidateListenAddress(address string) error {
+	_, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: invalid listen address %q: %v", types.ErrInvalidConfig, address, err)
+	}
+	
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("%w: invalid port in listen address %q", types.ErrInvalidConfig, address)
+	}
+	
+	return nil
+}
+
+// ************************************************************************************************
+// validateGoModule validates Go module fallback configuration.
+//
+// Returns:
+//   - error: An error if Go module configuration is invalid.
+func (m *Manager) validateGoModule(goModule *types.GoModuleConfig) error {
+	switch goModule.DocFormat {
+	case "":
+		goModule.DocFormat = "markdown"
+	case "markdown", "raw":
+	default:
+		return fmt.Errorf("%w: invalid doc format: %s", types.ErrInvalidConfig, goModule.DocFormat)
+	}
 	
 	return nil
 }
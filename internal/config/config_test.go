@@ -0,0 +1,467 @@
+// ************************************************************************************************
+// Package config - Unit tests for configuration loading and validation.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+// testConfigJSON builds a minimal valid configuration with one local repository alias.
+func testConfigJSON(alias string) []byte {
+	return []byte(fmt.Sprintf(`{
+		"repositories": {
+			%q: {"type": "local", "path": "/tmp/repo", "auth": {"type": "none"}, "indexing": {"enabled": true}}
+		},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info"}
+	}`, alias))
+}
+
+// ************************************************************************************************
+// Test that configured aliases cannot collide with synthetic Go module IDs
+func TestLoadConfigFromJSON_RepositoryIDCollision(t *testing.T) {
+	tests := []struct {
+		name        string
+		alias       string
+		expectError bool
+	}{
+		{"Regular alias", "gin", false},
+		{"Alias shadowing a Go module ID", "gomod:github.com/gin-gonic/gin", true},
+		{"Alias with cache key separator", "gin:v1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager()
+			err := manager.LoadConfigFromJSON(testConfigJSON(tt.alias))
+
+			if tt.expectError {
+				if !errors.Is(err, types.ErrInvalidRepositoryID) {
+					t.Errorf("Expected ErrInvalidRepositoryID for alias %q, got %v", tt.alias, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Unexpected error for alias %q: %v", tt.alias, err)
+			}
+		})
+	}
+}
+
+// ************************************************************************************************
+// Test that workspace members must be configured repositories
+func TestLoadConfigFromJSON_Workspaces(t *testing.T) {
+	withWorkspace := func(members string) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {
+			"api": {"type": "local", "path": "/tmp/api", "auth": {"type": "none"}, "indexing": {"enabled": true}}
+		},
+		"workspaces": {"platform": {"repositories": [%s]}},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info"}
+	}`, members))
+	}
+
+	manager := NewManager()
+	if err := manager.LoadConfigFromJSON(withWorkspace(`"api"`)); err != nil {
+		t.Errorf("Unexpected error for a valid workspace: %v", err)
+	}
+
+	for _, members := range []string{`"api", "web"`, ``} {
+		if err := NewManager().LoadConfigFromJSON(withWorkspace(members)); !errors.Is(err, types.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for members [%s], got %v", members, err)
+		}
+	}
+}
+
+func TestLoadConfigFromJSON_HashAlgorithm(t *testing.T) {
+	withAlgorithms := func(cacheAlgorithm, repoAlgorithm string) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {
+			"api": {"type": "local", "path": "/tmp/api", "auth": {"type": "none"}, "indexing": {"enabled": true, "hashAlgorithm": %q}},
+			"web": {"type": "local", "path": "/tmp/web", "auth": {"type": "none"}, "indexing": {"enabled": true}}
+		},
+		"cache": {"path": "/tmp/repomix-cache", "hashAlgorithm": %q},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info"}
+	}`, repoAlgorithm, cacheAlgorithm))
+	}
+
+	manager := NewManager()
+	if err := manager.LoadConfigFromJSON(withAlgorithms("xxhash", "blake3")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	config := manager.GetConfig()
+	if api := config.Repositories["api"].Indexing.HashAlgorithm; api != types.HashAlgorithmBLAKE3 {
+		t.Errorf("Expected the repository algorithm to be kept, got %q", api)
+	}
+	if web := config.Repositories["web"].Indexing.HashAlgorithm; web != types.HashAlgorithmXXHash {
+		t.Errorf("Expected the cache algorithm to be inherited, got %q", web)
+	}
+
+	manager = NewManager()
+	if err := manager.LoadConfigFromJSON(withAlgorithms("", "")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if web := manager.GetConfig().Repositories["web"].Indexing.HashAlgorithm; web != types.DefaultHashAlgorithm {
+		t.Errorf("Expected the default algorithm, got %q", web)
+	}
+
+	for _, algorithms := range [][2]string{{"blake2b", ""}, {"", "md5"}} {
+		if err := NewManager().LoadConfigFromJSON(withAlgorithms(algorithms[0], algorithms[1])); !errors.Is(err, types.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %v, got %v", algorithms, err)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that the tokenizer defaults to cl100k_base and accepts encodings, model names and chars
+func TestLoadConfigFromJSON_Tokenizer(t *testing.T) {
+	withTokenizer := func(tokenizer string) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {"api": {"type": "local", "path": "/tmp/api", "auth": {"type": "none"}, "indexing": {"enabled": true}}},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info", "tokenizer": %q}
+	}`, tokenizer))
+	}
+
+	manager := NewManager()
+	if err := manager.LoadConfigFromJSON(withTokenizer("")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tokenizer := manager.GetConfig().Server.Tokenizer; tokenizer != types.DefaultTokenizer {
+		t.Errorf("Expected the default tokenizer, got %q", tokenizer)
+	}
+
+	for _, tokenizer := range []string{"o200k_base", "gpt-4o", "chars"} {
+		if err := NewManager().LoadConfigFromJSON(withTokenizer(tokenizer)); err != nil {
+			t.Errorf("Unexpected error for %s: %v", tokenizer, err)
+		}
+	}
+	if err := NewManager().LoadConfigFromJSON(withTokenizer("words")); !errors.Is(err, types.ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for an unknown tokenizer, got %v", err)
+	}
+}
+
+// ************************************************************************************************
+// Test that chunk windows must share fewer lines than a chunk holds
+func TestLoadConfigFromJSON_ChunkOverlap(t *testing.T) {
+	withChunking := func(maxLines, overlap int) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {"api": {"type": "local", "path": "/tmp/api", "auth": {"type": "none"},
+			"indexing": {"enabled": true, "chunkMaxLines": %d, "chunkOverlap": %d}}},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info"}
+	}`, maxLines, overlap))
+	}
+
+	for _, limits := range [][2]int{{0, 0}, {100, 10}, {-1, 0}, {0, 50}} {
+		if err := NewManager().LoadConfigFromJSON(withChunking(limits[0], limits[1])); err != nil {
+			t.Errorf("Unexpected error for %v: %v", limits, err)
+		}
+	}
+	for _, limits := range [][2]int{{100, -1}, {100, 100}} {
+		if err := NewManager().LoadConfigFromJSON(withChunking(limits[0], limits[1])); !errors.Is(err, types.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %v, got %v", limits, err)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that API keys too short to resist guessing are refused
+func TestLoadConfigFromJSON_APIKeys(t *testing.T) {
+	withKeys := func(keys string) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {
+			"api": {"type": "local", "path": "/tmp/api", "auth": {"type": "none"}, "indexing": {"enabled": true}}
+		},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info", "auth": {"apiKeys": [%s]}}
+	}`, keys))
+	}
+
+	manager := NewManager()
+	if err := manager.LoadConfigFromJSON(withKeys(`"0123456789abcdef"`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if keys := manager.GetConfig().Server.Auth.APIKeys; len(keys) != 1 {
+		t.Errorf("Expected the API key to be loaded, got %v", keys)
+	}
+
+	for _, keys := range []string{`"secret"`, `"0123456789abcdef", ""`} {
+		if err := NewManager().LoadConfigFromJSON(withKeys(keys)); !errors.Is(err, types.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for keys [%s], got %v", keys, err)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that client certificates need a CA bundle and the HTTPS server
+func TestLoadConfigFromJSON_ClientCertificates(t *testing.T) {
+	withServer := func(server string) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {
+			"api": {"type": "local", "path": "/tmp/api", "auth": {"type": "none"}, "indexing": {"enabled": true}}
+		},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info", %s}
+	}`, server))
+	}
+
+	manager := NewManager()
+	if err := manager.LoadConfigFromJSON(withServer(`"httpsEnabled": true, "httpsPort": 9443, "autoGenCert": true, "clientCAPath": "/etc/clients-ca.pem", "requireClientCert": true`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if server := manager.GetConfig().Server; !server.RequireClientCert || server.ClientCAPath != "/etc/clients-ca.pem" {
+		t.Errorf("Expected the client certificate settings to be loaded, got %+v", server)
+	}
+
+	for _, server := range []string{
+		`"httpsEnabled": true, "httpsPort": 9443, "autoGenCert": true, "requireClientCert": true`,
+		`"clientCAPath": "/etc/clients-ca.pem"`,
+	} {
+		if err := NewManager().LoadConfigFromJSON(withServer(server)); !errors.Is(err, types.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %s, got %v", server, err)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that ACME certificates need the HTTPS server and DNS hostnames, and are stored with the
+// cache by default
+func TestLoadConfigFromJSON_ACME(t *testing.T) {
+	withServer := func(server string) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {
+			"api": {"type": "local", "path": "/tmp/api", "auth": {"type": "none"}, "indexing": {"enabled": true}}
+		},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "0.0.0.0", "logLevel": "info", %s}
+	}`, server))
+	}
+
+	manager := NewManager()
+	if err := manager.LoadConfigFromJSON(withServer(`"httpsEnabled": true, "httpsPort": 443, "acme": {"enabled": true, "hostnames": ["MCP.Example.com"], "email": "ops@example.com"}`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	acme := manager.GetConfig().Server.ACME
+	if acme.CacheDir != filepath.Join("/tmp/repomix-cache", "acme") {
+		t.Errorf("Expected certificates to be stored with the cache, got %s", acme.CacheDir)
+	}
+	if acme.Hostnames[0] != "mcp.example.com" {
+		t.Errorf("Expected hostnames to be lowercased, got %v", acme.Hostnames)
+	}
+
+	for _, server := range []string{
+		`"acme": {"enabled": true, "hostnames": ["mcp.example.com"]}`,
+		`"httpsEnabled": true, "httpsPort": 443, "acme": {"enabled": true}`,
+		`"httpsEnabled": true, "httpsPort": 443, "acme": {"enabled": true, "hostnames": ["10.0.0.12"]}`,
+		`"httpsEnabled": true, "httpsPort": 443, "acme": {"enabled": true, "hostnames": ["*.example.com"]}`,
+		`"httpsEnabled": true, "httpsPort": 443, "acme": {"enabled": true, "hostnames": ["mcp.example.com"], "directoryUrl": "http://ca.example.com/directory"}`,
+	} {
+		if err := NewManager().LoadConfigFromJSON(withServer(server)); !errors.Is(err, types.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %s, got %v", server, err)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that only local repositories can be watched, and that the watch debounce defaults to 2s
+func TestLoadConfigFromJSON_Watch(t *testing.T) {
+	withRepository := func(repository string) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {"api": %s},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info"}
+	}`, repository))
+	}
+
+	manager := NewManager()
+	if err := manager.LoadConfigFromJSON(withRepository(`{"type": "local", "path": "/tmp/api", "watch": true, "auth": {"type": "none"}, "indexing": {"enabled": true}}`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	config := manager.GetConfig()
+	if !config.Repositories["api"].Watch || config.Server.WatchDebounce != "2s" {
+		t.Errorf("Expected a watched repository with a 2s debounce, got %+v, %s", config.Repositories["api"], config.Server.WatchDebounce)
+	}
+
+	err := NewManager().LoadConfigFromJSON(withRepository(`{"type": "remote", "url": "https://github.com/example/api.git", "watch": true, "auth": {"type": "none"}, "indexing": {"enabled": true}}`))
+	if !errors.Is(err, types.ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for a watched remote repository, got %v", err)
+	}
+}
+
+// ************************************************************************************************
+// Test that the scheduled re-indexing interval must be a positive duration
+func TestLoadConfigFromJSON_RefreshInterval(t *testing.T) {
+	withInterval := func(interval string) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {
+			"api": {"type": "remote", "url": "https://github.com/example/api.git", "refreshInterval": %q, "auth": {"type": "none"}, "indexing": {"enabled": true}}
+		},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info"}
+	}`, interval))
+	}
+
+	manager := NewManager()
+	if err := manager.LoadConfigFromJSON(withInterval("1h")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if interval := manager.GetConfig().Repositories["api"].RefreshInterval; interval != "1h" {
+		t.Errorf("Expected refreshInterval 1h, got %q", interval)
+	}
+
+	for _, interval := range []string{"hourly", "0s", "-5m"} {
+		if err := NewManager().LoadConfigFromJSON(withInterval(interval)); !errors.Is(err, types.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %q, got %v", interval, err)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that repositories are indexed one at a time by default and that the parallelism cannot be
+// negative
+func TestLoadConfigFromJSON_IndexParallelism(t *testing.T) {
+	withIndex := func(index string) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {
+			"api": {"type": "local", "path": "/tmp/api", "auth": {"type": "none"}, "indexing": {"enabled": true}}
+		},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info"},
+		"index": %s
+	}`, index))
+	}
+
+	for index, expected := range map[string]int{`{}`: 1, `{"parallelism": 8}`: 8} {
+		manager := NewManager()
+		if err := manager.LoadConfigFromJSON(withIndex(index)); err != nil {
+			t.Fatalf("Unexpected error for %s: %v", index, err)
+		}
+		if parallelism := manager.GetConfig().Index.Parallelism; parallelism != expected {
+			t.Errorf("Expected parallelism %d for %s, got %d", expected, index, parallelism)
+		}
+	}
+
+	if err := NewManager().LoadConfigFromJSON(withIndex(`{"parallelism": -1}`)); !errors.Is(err, types.ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for a negative parallelism, got %v", err)
+	}
+}
+
+// ************************************************************************************************
+// Test that generated certificates default to a one-year RSA key and reject other settings
+func TestLoadConfigFromJSON_CertificateGeneration(t *testing.T) {
+	withServer := func(server string) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {
+			"api": {"type": "local", "path": "/tmp/api", "auth": {"type": "none"}, "indexing": {"enabled": true}}
+		},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info", "httpsEnabled": true, "httpsPort": 9443, "autoGenCert": true%s}
+	}`, server))
+	}
+
+	manager := NewManager()
+	if err := manager.LoadConfigFromJSON(withServer(``)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if server := manager.GetConfig().Server; server.CertValidityDays != 365 || server.CertKeyAlgorithm != "rsa" {
+		t.Errorf("Expected 365 days and rsa by default, got %d and %q", server.CertValidityDays, server.CertKeyAlgorithm)
+	}
+
+	manager = NewManager()
+	if err := manager.LoadConfigFromJSON(withServer(`, "certValidityDays": 90, "certKeyAlgorithm": "ecdsa", "certExtraSANs": ["mcp.internal"]`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if server := manager.GetConfig().Server; server.CertValidityDays != 90 || server.CertKeyAlgorithm != "ecdsa" || len(server.CertExtraSANs) != 1 {
+		t.Errorf("Expected the certificate settings to be loaded, got %+v", server)
+	}
+
+	for _, server := range []string{`, "certValidityDays": -1`, `, "certKeyAlgorithm": "ed25519"`, `, "certKeyAlgorithm": "RSA"`} {
+		if err := NewManager().LoadConfigFromJSON(withServer(server)); !errors.Is(err, types.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %s, got %v", server, err)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that the connection timeouts get defaults and must be non-negative durations
+func TestLoadConfigFromJSON_Timeouts(t *testing.T) {
+	withServer := func(server string) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {
+			"api": {"type": "local", "path": "/tmp/api", "auth": {"type": "none"}, "indexing": {"enabled": true}}
+		},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info"%s}
+	}`, server))
+	}
+
+	manager := NewManager()
+	if err := manager.LoadConfigFromJSON(withServer(`, "writeTimeout": "15m", "idleTimeout": "0s"`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	server := manager.GetConfig().Server
+	got := []string{server.ReadHeaderTimeout, server.ReadTimeout, server.WriteTimeout, server.IdleTimeout}
+	if expected := []string{"10s", "30s", "15m", "0s"}; fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Expected timeouts %v, got %v", expected, got)
+	}
+
+	for _, server := range []string{`, "readHeaderTimeout": "-1s"`, `, "readTimeout": "30"`, `, "writeTimeout": "forever"`, `, "idleTimeout": "-2m"`} {
+		if err := NewManager().LoadConfigFromJSON(withServer(server)); !errors.Is(err, types.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %s, got %v", server, err)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that listen addresses must be host:port pairs with a valid port
+func TestValidateListenAddress(t *testing.T) {
+	tests := []struct {
+		address     string
+		expectError bool
+	}{
+		{"127.0.0.1:8080", false},
+		{"[::1]:8080", false},
+		{":9080", false},
+		{"mcp.internal:443", false},
+		{"127.0.0.1", true},
+		{"::1:8080", true},
+		{"127.0.0.1:0", true},
+		{"127.0.0.1:65536", true},
+		{"127.0.0.1:http", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateListenAddress(tt.address)
+		if tt.expectError && !errors.Is(err, types.ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %q, got %v", tt.address, err)
+		}
+		if !tt.expectError && err != nil {
+			t.Errorf("Unexpected error for %q: %v", tt.address, err)
+		}
+	}
+
+	withAddresses := func(addresses string) []byte {
+		return []byte(fmt.Sprintf(`{
+		"repositories": {
+			"api": {"type": "local", "path": "/tmp/api", "auth": {"type": "none"}, "indexing": {"enabled": true}}
+		},
+		"cache": {"path": "/tmp/repomix-cache"},
+		"server": {"port": 8080, "host": "localhost", "logLevel": "info", "listenAddresses": [%s]}
+	}`, addresses))
+	}
+	if err := NewManager().LoadConfigFromJSON(withAddresses(`"127.0.0.1:8080", "[::1]:8080"`)); err != nil {
+		t.Errorf("Unexpected error for valid listen addresses: %v", err)
+	}
+	if err := NewManager().LoadConfigFromJSON(withAddresses(`"127.0.0.1:8080", "localhost"`)); !errors.Is(err, types.ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for a listen address without a port, got %v", err)
+	}
+}
@@ -5,23 +5,34 @@
 package godoc
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
+
+	"repomix-mcp/internal/tracing"
+	"repomix-mcp/pkg/types"
 )
 
+// ************************************************************************************************
+// commandStderrTailLines is the number of trailing standard error lines kept in command errors.
+const commandStderrTailLines = 10
+
 // ************************************************************************************************
 // executeCommandWithLogging wraps command execution with verbose logging.
-// It logs the command being executed and its stdout/stderr output when verbose mode is enabled.
+// Standard output and standard error are captured separately, so the documentation read
+// from stdout is not polluted by progress messages such as "go: downloading ...", and a
+// failure is returned with the tail of stderr instead of a bare "exit status 1".
 //
 // Returns:
 //   - stdout: Standard output from the command
-//   - stderr: Standard error from the command (if available separately)
-//   - error: Command execution error
+//   - stderr: Standard error from the command
+//   - error: Command execution error, with the tail of stderr when the command ran
 func (g *GoDocRetriever) executeCommandWithLogging(cmd *exec.Cmd, operation string) (stdout []byte, stderr []byte, err error) {
 	// Build command string for logging
 	cmdStr := cmd.Path
@@ -30,65 +41,82 @@ func (g *GoDocRetriever) executeCommandWithLogging(cmd *exec.Cmd, operation stri
 	}
 
 	if g.verbose {
-		log.Printf("[CMD] %s", cmdStr)
+		slog.Info("Running command", "operation", operation, "command", cmdStr)
 	}
 
-	// Execute command and capture output
+	var stdoutBuffer, stderrBuffer bytes.Buffer
+	if cmd.Stdout == nil {
+		cmd.Stdout = &stdoutBuffer
+	}
 	if cmd.Stderr == nil {
-		// Use CombinedOutput when stderr is not set separately
-		combined, err := cmd.CombinedOutput()
+		cmd.Stderr = &stderrBuffer
+	}
+	err = cmd.Run()
+	stdout, stderr = stdoutBuffer.Bytes(), stderrBuffer.Bytes()
 
-		if g.verbose {
-			if err != nil {
-				// Command failed - log the combined output as stderr
-				log.Printf("[CMD STDERR] %s", strings.TrimSpace(string(combined)))
-			} else {
-				// Command succeeded - log as stdout
-				if len(combined) > 0 {
-					log.Printf("[CMD STDOUT] %s", strings.TrimSpace(string(combined)))
-				} else {
-					log.Printf("[CMD STDOUT] (no output)")
-				}
-			}
+	if g.verbose {
+		attributes := []any{"operation", operation, "stdout", strings.TrimSpace(string(stdout))}
+		if len(stderr) > 0 {
+			attributes = append(attributes, "stderr", strings.TrimSpace(string(stderr)))
 		}
+		if err != nil {
+			attributes = append(attributes, "error", err)
+		}
+		slog.Info("Command finished", attributes...)
+	}
 
-		return combined, nil, err
-	} else {
-		// Use separate stdout/stderr when possible
-		stdout, err := cmd.Output()
+	if err != nil {
+		return stdout, stderr, commandError(operation, err, stderr)
+	}
+	return stdout, stderr, nil
+}
 
-		if g.verbose {
-			if err != nil {
-				// Try to get stderr from ExitError
-				if exitError, ok := err.(*exec.ExitError); ok {
-					stderr = exitError.Stderr
-					log.Printf("[CMD STDERR] %s", strings.TrimSpace(string(stderr)))
-				} else {
-					log.Printf("[CMD STDERR] %s", err.Error())
-				}
-			}
+// ************************************************************************************************
+// commandError wraps the error of a failed command with the last lines of its standard error,
+// which carry the actionable cause ("module not found", "proxy unreachable"...).
+func commandError(operation string, err error, stderr []byte) error {
+	if tail := stderrTail(stderr, commandStderrTailLines); tail != "" {
+		return fmt.Errorf("%s failed: %s\n>    %w", operation, tail, err)
+	}
+	return fmt.Errorf("%s failed\n>    %w", operation, err)
+}
 
-			if len(stdout) > 0 {
-				log.Printf("[CMD STDOUT] %s", strings.TrimSpace(string(stdout)))
-			} else {
-				log.Printf("[CMD STDOUT] (no output)")
-			}
+// ************************************************************************************************
+// stderrTail returns the last non-empty lines of a command's standard error, joined with "; ".
+func stderrTail(stderr []byte, maxLines int) string {
+	var lines []string
+	for _, line := range strings.Split(string(stderr), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
 		}
-
-		return stdout, stderr, err
 	}
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	return strings.Join(lines, "; ")
 }
 
+// ************************************************************************************************
+// goCommandSteps is the number of progress steps of executeGoCommands.
+const goCommandSteps = 5
+
 // ************************************************************************************************
 // executeGoCommands runs the complete sequence of Go commands to fetch module documentation.
 // This includes module initialization, getting the target module, and documentation extraction.
+// Each step is reported to progress when not nil.
 //
 // Returns:
 //   - *GoModuleInfo: Complete module information with documentation.
 //   - error: An error if any command fails.
-func (g *GoDocRetriever) executeGoCommands(modulePath, tempDir string) (*GoModuleInfo, error) {
+func (g *GoDocRetriever) executeGoCommands(ctx context.Context, modulePath, tempDir string, progress types.ProgressFunc) (*GoModuleInfo, error) {
 	if g.verbose {
-		log.Printf("Executing Go commands for module %s in directory %s", modulePath, tempDir)
+		slog.InfoContext(ctx, "Executing Go commands", "module", modulePath, "dir", tempDir)
+	}
+
+	report := func(step int, message string) {
+		if progress != nil {
+			progress(step, goCommandSteps, message)
+		}
 	}
 
 	// Initialize the result structure
@@ -100,82 +128,105 @@ func (g *GoDocRetriever) executeGoCommands(modulePath, tempDir string) (*GoModul
 	}
 
 	// Step 1: Initialize Go module
-	if err := g.initGoModule(tempDir); err != nil {
+	report(0, "Initializing a temporary Go module")
+	_, span := tracing.Start(ctx, "go mod init")
+	err := g.initGoModule(ctx, tempDir)
+	span.RecordError(err)
+	span.End()
+	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Go module: %w", err)
 	}
 
 	// Step 2: Get the target module
-	version, err := g.getModule(modulePath, tempDir)
+	report(1, fmt.Sprintf("Fetching module %s", modulePath))
+	_, span = tracing.Start(ctx, "go get", tracing.String("go.module", modulePath))
+	version, err := g.getModule(ctx, modulePath, tempDir)
+	span.SetAttributes(tracing.String("go.module.version", version))
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get module %s: %w", modulePath, err)
 	}
 	moduleInfo.Version = version
 
-	// Step 3: Get Go version
-	goVersion, err := g.getGoVersion()
-	if err != nil {
-		if g.verbose {
-			log.Printf("Warning: failed to get Go version: %v", err)
-		}
-	} else {
-		moduleInfo.GoVersion = goVersion
-	}
+	// Step 3: Record the Go version captured when the toolchain was validated
+	moduleInfo.GoVersion = g.cachedGoVersion()
 
 	// Step 4: Extract basic documentation
-	basicDocs, err := g.runGoDoc(modulePath, tempDir, false)
+	report(2, fmt.Sprintf("Extracting the documentation of %s@%s", modulePath, version))
+	_, span = tracing.Start(ctx, "go doc", tracing.Bool("go.doc.all", false))
+	basicDocs, err := g.runGoDoc(ctx, modulePath, tempDir, false)
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get basic documentation: %w", err)
 	}
 	moduleInfo.Documentation = basicDocs
 
 	// Step 5: Extract comprehensive documentation
-	allDocs, err := g.runGoDoc(modulePath, tempDir, true)
+	report(3, fmt.Sprintf("Extracting the full documentation of %s@%s", modulePath, version))
+	_, span = tracing.Start(ctx, "go doc", tracing.Bool("go.doc.all", true))
+	allDocs, err := g.runGoDoc(ctx, modulePath, tempDir, true)
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		// Don't fail if comprehensive docs fail, just log it
 		if g.verbose {
-			log.Printf("Warning: failed to get comprehensive documentation for %s: %v", modulePath, err)
+			slog.WarnContext(ctx, "Failed to get comprehensive documentation", "module", modulePath, "error", err)
 		}
-		moduleInfo.ErrorInfo = fmt.Sprintf("Failed to get comprehensive docs: %v", err)
+		moduleInfo.ErrorInfo = joinErrorInfo(moduleInfo.ErrorInfo, fmt.Sprintf("Failed to get comprehensive docs: %v", err))
 	} else {
 		moduleInfo.AllDocs = allDocs
 	}
 
 	// Step 6: Try to get package list
-	packages, err := g.listPackages(modulePath, tempDir)
+	report(4, fmt.Sprintf("Listing the packages of %s@%s", modulePath, version))
+	_, span = tracing.Start(ctx, "go list")
+	packages, err := g.listPackages(ctx, modulePath, tempDir)
+	span.SetAttributes(tracing.Int("go.packages", len(packages)))
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		if g.verbose {
-			log.Printf("Warning: failed to list packages for %s: %v", modulePath, err)
+			slog.WarnContext(ctx, "Failed to list packages", "module", modulePath, "error", err)
 		}
+		moduleInfo.ErrorInfo = joinErrorInfo(moduleInfo.ErrorInfo, fmt.Sprintf("Failed to list packages: %v", err))
 	} else {
 		moduleInfo.PackageList = packages
 	}
+	report(goCommandSteps, fmt.Sprintf("Fetched %d packages of %s@%s", len(moduleInfo.PackageList), modulePath, version))
 
 	if g.verbose {
-		log.Printf("Successfully executed Go commands for module %s", modulePath)
+		slog.InfoContext(ctx, "Executed Go commands", "module", modulePath)
 	}
 
 	return moduleInfo, nil
 }
 
+// ************************************************************************************************
+// joinErrorInfo appends a problem to the ErrorInfo of a module, one per line.
+func joinErrorInfo(errorInfo, problem string) string {
+	if errorInfo == "" {
+		return problem
+	}
+	return errorInfo + "\n" + problem
+}
+
 // ************************************************************************************************
 // initGoModule initializes a new Go module in the temporary directory.
-func (g *GoDocRetriever) initGoModule(tempDir string) error {
-	ctx, cancel := g.createCommandContext()
+func (g *GoDocRetriever) initGoModule(parent context.Context, tempDir string) error {
+	ctx, cancel := g.createCommandContext(parent)
 	defer cancel()
 
-	cmd := mock_execCommandContext(ctx, "go", "mod", "init", "temp-docs")
+	cmd := g.newGoCommand(ctx, "mod", "init", "temp-docs")
 	cmd.Dir = tempDir
 
 	if g.verbose {
-		log.Printf("Initializing Go module in %s", tempDir)
+		slog.InfoContext(parent, "Initializing Go module", "dir", tempDir)
 	}
 
-	stdout, stderr, err := g.executeCommandWithLogging(cmd, "go mod init")
-	if err != nil {
-		if len(stderr) > 0 {
-			return fmt.Errorf("go mod init failed: %s", string(stderr))
-		}
-		return fmt.Errorf("go mod init failed: %s", string(stdout))
+	if _, _, err := g.executeCommandWithLogging(cmd, "go mod init"); err != nil {
+		return err
 	}
 
 	return nil
@@ -183,27 +234,24 @@ func (g *GoDocRetriever) initGoModule(tempDir string) error {
 
 // ************************************************************************************************
 // getModule fetches the specified Go module using `go get`.
-func (g *GoDocRetriever) getModule(modulePath, tempDir string) (string, error) {
-	ctx, cancel := g.createCommandContext()
+func (g *GoDocRetriever) getModule(parent context.Context, modulePath, tempDir string) (string, error) {
+	ctx, cancel := g.createCommandContext(parent)
 	defer cancel()
 
-	cmd := mock_execCommandContext(ctx, "go", "get", modulePath)
+	cmd := g.newGoCommand(ctx, "get", modulePath)
 	cmd.Dir = tempDir
 
 	if g.verbose {
-		log.Printf("Getting module: %s", modulePath)
+		slog.InfoContext(parent, "Getting module", "module", modulePath)
 	}
 
-	stdout, stderr, err := g.executeCommandWithLogging(cmd, "go get")
+	stdout, stderr, err := g.executeCommandWithLogging(cmd, "go get "+modulePath)
 	if err != nil {
-		if len(stderr) > 0 {
-			return "", fmt.Errorf("go get %s failed: %s", modulePath, string(stderr))
-		}
-		return "", fmt.Errorf("go get %s failed: %s", modulePath, string(stdout))
+		return "", err
 	}
 
-	// Try to extract version from output
-	outputStr := string(stdout)
+	// Try to extract version from output, go get reports on stderr
+	outputStr := string(stdout) + string(stderr)
 	if strings.Contains(outputStr, "@") {
 		// Look for version information in the output
 		lines := strings.Split(outputStr, "\n")
@@ -222,8 +270,8 @@ func (g *GoDocRetriever) getModule(modulePath, tempDir string) (string, error) {
 
 // ************************************************************************************************
 // runGoDoc executes `go doc` command to extract documentation.
-func (g *GoDocRetriever) runGoDoc(modulePath, tempDir string, allDocs bool) (string, error) {
-	ctx, cancel := g.createCommandContext()
+func (g *GoDocRetriever) runGoDoc(parent context.Context, modulePath, tempDir string, allDocs bool) (string, error) {
+	ctx, cancel := g.createCommandContext(parent)
 	defer cancel()
 
 	args := []string{"go", "doc"}
@@ -232,7 +280,7 @@ func (g *GoDocRetriever) runGoDoc(modulePath, tempDir string, allDocs bool) (str
 	}
 	args = append(args, modulePath)
 
-	cmd := mock_execCommandContext(ctx, args[0], args[1:]...)
+	cmd := g.newGoCommand(ctx, args[1:]...)
 	cmd.Dir = tempDir
 
 	command := "go doc"
@@ -241,24 +289,24 @@ func (g *GoDocRetriever) runGoDoc(modulePath, tempDir string, allDocs bool) (str
 	}
 
 	if g.verbose {
-		log.Printf("Running: %s %s", command, modulePath)
+		slog.InfoContext(parent, "Running go doc", "command", command, "module", modulePath)
 	}
 
-	stdout, _, err := g.executeCommandWithLogging(cmd, "go doc")
+	stdout, _, err := g.executeCommandWithLogging(cmd, command)
 	if err != nil {
 		// Log the failure and try alternative approaches
 		if g.verbose {
-			log.Printf("Direct go doc approach failed, trying alternatives...")
+			slog.InfoContext(parent, "Direct go doc approach failed, trying alternatives", "module", modulePath, "error", err)
 		}
-		return g.tryAlternativeDocApproaches(modulePath, tempDir, allDocs)
+		return g.tryAlternativeDocApproaches(parent, modulePath, tempDir, allDocs, err)
 	}
 
 	result := strings.TrimSpace(string(stdout))
 	if result == "" {
 		if g.verbose {
-			log.Printf("go doc returned empty output, trying alternatives...")
+			slog.InfoContext(parent, "go doc returned empty output, trying alternatives", "module", modulePath)
 		}
-		return g.tryAlternativeDocApproaches(modulePath, tempDir, allDocs)
+		return g.tryAlternativeDocApproaches(parent, modulePath, tempDir, allDocs, fmt.Errorf("%s returned no documentation", command))
 	}
 
 	return result, nil
@@ -266,25 +314,26 @@ func (g *GoDocRetriever) runGoDoc(modulePath, tempDir string, allDocs bool) (str
 
 // ************************************************************************************************
 // tryAlternativeDocApproaches tries different ways to get documentation when direct approach fails.
-func (g *GoDocRetriever) tryAlternativeDocApproaches(modulePath, tempDir string, allDocs bool) (string, error) {
+// When every approach fails, the error carries the cause of the direct approach failure.
+func (g *GoDocRetriever) tryAlternativeDocApproaches(parent context.Context, modulePath, tempDir string, allDocs bool, cause error) (string, error) {
 	alternatives := []string{
 		modulePath,
 		filepath.Base(modulePath), // Just the package name
 	}
 
 	for _, alt := range alternatives {
-		if result, err := g.runGoDocDirect(alt, tempDir, allDocs); err == nil && result != "" {
+		if result, err := g.runGoDocDirect(parent, alt, tempDir, allDocs); err == nil && result != "" {
 			return result, nil
 		}
 	}
 
-	return "", fmt.Errorf("all documentation extraction approaches failed for %s", modulePath)
+	return "", fmt.Errorf("all documentation extraction approaches failed for %s\n>    %w", modulePath, cause)
 }
 
 // ************************************************************************************************
 // runGoDocDirect runs go doc with a specific path.
-func (g *GoDocRetriever) runGoDocDirect(path, tempDir string, allDocs bool) (string, error) {
-	ctx, cancel := g.createCommandContext()
+func (g *GoDocRetriever) runGoDocDirect(parent context.Context, path, tempDir string, allDocs bool) (string, error) {
+	ctx, cancel := g.createCommandContext(parent)
 	defer cancel()
 
 	args := []string{"doc"}
@@ -293,10 +342,10 @@ func (g *GoDocRetriever) runGoDocDirect(path, tempDir string, allDocs bool) (str
 	}
 	args = append(args, path)
 
-	cmd := mock_execCommandContext(ctx, args[0], args[1:]...)
+	cmd := g.newGoCommand(ctx, args...)
 	cmd.Dir = tempDir
 
-	stdout, _, err := g.executeCommandWithLogging(cmd, "go doc direct")
+	stdout, _, err := g.executeCommandWithLogging(cmd, "go doc "+path)
 	if err != nil {
 		return "", err
 	}
@@ -306,24 +355,24 @@ func (g *GoDocRetriever) runGoDocDirect(path, tempDir string, allDocs bool) (str
 
 // ************************************************************************************************
 // listPackages attempts to list packages in the module.
-func (g *GoDocRetriever) listPackages(modulePath, tempDir string) ([]string, error) {
-	ctx, cancel := g.createCommandContext()
+func (g *GoDocRetriever) listPackages(parent context.Context, modulePath, tempDir string) ([]string, error) {
+	ctx, cancel := g.createCommandContext(parent)
 	defer cancel()
 
-	cmd := mock_execCommandContext(ctx, "go", "list", "-f", "{{.ImportPath}}", modulePath+"/...")
+	cmd := g.newGoCommand(ctx, "list", "-f", "{{.ImportPath}}", modulePath+"/...")
 	cmd.Dir = tempDir
 
 	if g.verbose {
-		log.Printf("Listing packages for: %s", modulePath)
+		slog.InfoContext(parent, "Listing packages", "module", modulePath)
 	}
 
-	stdout, _, err := g.executeCommandWithLogging(cmd, "go list")
+	stdout, _, err := g.executeCommandWithLogging(cmd, "go list "+modulePath+"/...")
 	if err != nil {
 		// Try simpler approach
 		if g.verbose {
-			log.Printf("go list with template failed, trying simple approach...")
+			slog.InfoContext(parent, "go list with template failed, trying simple approach", "module", modulePath, "error", err)
 		}
-		return g.listPackagesSimple(modulePath, tempDir)
+		return g.listPackagesSimple(parent, modulePath, tempDir)
 	}
 
 	outputStr := strings.TrimSpace(string(stdout))
@@ -345,14 +394,14 @@ func (g *GoDocRetriever) listPackages(modulePath, tempDir string) ([]string, err
 
 // ************************************************************************************************
 // listPackagesSimple tries a simpler approach to list packages.
-func (g *GoDocRetriever) listPackagesSimple(modulePath, tempDir string) ([]string, error) {
-	ctx, cancel := g.createCommandContext()
+func (g *GoDocRetriever) listPackagesSimple(parent context.Context, modulePath, tempDir string) ([]string, error) {
+	ctx, cancel := g.createCommandContext(parent)
 	defer cancel()
 
-	cmd := mock_execCommandContext(ctx, "go", "list", modulePath)
+	cmd := g.newGoCommand(ctx, "list", modulePath)
 	cmd.Dir = tempDir
 
-	stdout, _, err := g.executeCommandWithLogging(cmd, "go list simple")
+	stdout, _, err := g.executeCommandWithLogging(cmd, "go list "+modulePath)
 	if err != nil {
 		return []string{}, err
 	}
@@ -366,24 +415,60 @@ func (g *GoDocRetriever) listPackagesSimple(modulePath, tempDir string) ([]strin
 }
 
 // ************************************************************************************************
-// getGoVersion gets the Go version being used.
-func (g *GoDocRetriever) getGoVersion() (string, error) {
-	ctx, cancel := g.createCommandContext()
-	defer cancel()
+// newGoCommand builds a go subcommand bound to ctx with the retriever's environment applied.
+func (g *GoDocRetriever) newGoCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := mock_execCommandContext(ctx, "go", args...)
+	cmd.Env = g.goCommandEnv()
+	return cmd
+}
 
-	cmd := mock_execCommandContext(ctx, "go", "version")
+// ************************************************************************************************
+// checksumBypassVariables are the go environment variables that exempt modules from checksum
+// verification, dropped when verification is enabled.
+var checksumBypassVariables = []string{"GONOSUMDB", "GOINSECURE"}
 
-	stdout, _, err := g.executeCommandWithLogging(cmd, "go version")
-	if err != nil {
-		return "", err
+// ************************************************************************************************
+// checksumBypassFlags are the GOFLAGS flags that weaken checksum verification, dropped when
+// verification is enabled.
+var checksumBypassFlags = []string{"insecure", "mod"}
+
+// ************************************************************************************************
+// goCommandEnv returns the environment for go subprocesses. When checksum verification is
+// enabled, public modules are checked against sum.golang.org: inherited variables and GOFLAGS
+// flags exempting modules are dropped. GOPRIVATE, GONOPROXY and GOPROXY are kept, so private
+// modules are still fetched directly and skip the public checksum database, as the go command
+// does by default. When verification is disabled, the checksum database is turned off.
+func (g *GoDocRetriever) goCommandEnv() []string {
+	inherited := mock_osEnviron()
+	env := make([]string, 0, len(inherited)+1)
+	for _, kv := range inherited {
+		name, value, _ := strings.Cut(kv, "=")
+		if name == "GOSUMDB" || (g.config.VerifyChecksums && slices.Contains(checksumBypassVariables, name)) {
+			continue
+		}
+		if g.config.VerifyChecksums && name == "GOFLAGS" {
+			flags := slices.DeleteFunc(strings.Fields(value), func(flag string) bool {
+				flagName, _, _ := strings.Cut(strings.TrimLeft(flag, "-"), "=")
+				return slices.Contains(checksumBypassFlags, flagName)
+			})
+			if len(flags) == 0 {
+				continue
+			}
+			kv = "GOFLAGS=" + strings.Join(flags, " ")
+		}
+		env = append(env, kv)
 	}
 
-	return strings.TrimSpace(string(stdout)), nil
+	if g.config.VerifyChecksums {
+		return append(env, "GOSUMDB=sum.golang.org")
+	}
+	return append(env, "GOSUMDB=off")
 }
 
 // ************************************************************************************************
-// createCommandContext creates a context with timeout for command execution.
-func (g *GoDocRetriever) createCommandContext() (context.Context, context.CancelFunc) {
+// createCommandContext creates a context with timeout for command execution, cancelled with
+// its parent.
+func (g *GoDocRetriever) createCommandContext(parent context.Context) (context.Context, context.CancelFunc) {
 	timeout := 60 * time.Second // Default timeout
 
 	if g.config.CommandTimeout != "" {
@@ -392,5 +477,5 @@ func (g *GoDocRetriever) createCommandContext() (context.Context, context.Cancel
 		}
 	}
 
-	return context.WithTimeout(context.Background(), timeout)
+	return context.WithTimeout(parent, timeout)
 }
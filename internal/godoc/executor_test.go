@@ -0,0 +1,40 @@
+// ************************************************************************************************
+// Package godoc environment tests for the go subprocesses of Go module documentation retrieval.
+// This file contains tests to verify that checksum verification cannot be bypassed.
+package godoc
+
+import (
+	"slices"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+// TestGoCommandEnv tests the environment of go subprocesses with and without checksum verification,
+// private module settings being kept in both cases
+func TestGoCommandEnv(t *testing.T) {
+	original := mock_osEnviron
+	defer func() { mock_osEnviron = original }()
+	mock_osEnviron = func() []string {
+		return []string{"HOME=/home/dev", "GOPROXY=https://proxy.example.com", "GOSUMDB=off", "GONOSUMDB=example.com",
+			"GOPRIVATE=example.com", "GONOPROXY=example.com", "GOINSECURE=example.com", "GOFLAGS=-mod=mod -trimpath --insecure",
+			"GOENV=/home/dev/go.env"}
+	}
+
+	tests := []struct {
+		verify   bool
+		expected []string
+	}{
+		{true, []string{"HOME=/home/dev", "GOPROXY=https://proxy.example.com", "GOPRIVATE=example.com", "GONOPROXY=example.com",
+			"GOFLAGS=-trimpath", "GOENV=/home/dev/go.env", "GOSUMDB=sum.golang.org"}},
+		{false, []string{"HOME=/home/dev", "GOPROXY=https://proxy.example.com", "GONOSUMDB=example.com", "GOPRIVATE=example.com",
+			"GONOPROXY=example.com", "GOINSECURE=example.com", "GOFLAGS=-mod=mod -trimpath --insecure", "GOENV=/home/dev/go.env",
+			"GOSUMDB=off"}},
+	}
+	for _, test := range tests {
+		retriever := &GoDocRetriever{config: &types.GoModuleConfig{VerifyChecksums: test.verify}}
+		if env := retriever.goCommandEnv(); !slices.Equal(env, test.expected) {
+			t.Errorf("VerifyChecksums %v: expected %v, got %v", test.verify, test.expected, env)
+		}
+	}
+}
@@ -5,13 +5,16 @@
 package godoc
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"repomix-mcp/internal/tracing"
 	"repomix-mcp/pkg/types"
 )
 
@@ -24,6 +27,12 @@ type GoDocRetriever struct {
 	tempDirBase string
 	cache       CacheInterface
 	verbose     bool
+
+	hashAlgorithm string // Content hash algorithm, empty for types.DefaultHashAlgorithm
+
+	// Cached `go version` output, captured once the toolchain has been validated
+	goVersion   string
+	goVersionMu sync.Mutex
 }
 
 // ************************************************************************************************
@@ -34,6 +43,14 @@ type CacheInterface interface {
 	ListRepositories() ([]string, error)
 }
 
+// ************************************************************************************************
+// ContextCacheInterface is implemented by caches recording their accesses in the trace of a
+// context, such as cache.Cache.
+type ContextCacheInterface interface {
+	GetRepositoryContext(ctx context.Context, id string) (*types.RepositoryIndex, error)
+	StoreRepositoryContext(ctx context.Context, repo *types.RepositoryIndex) error
+}
+
 // ************************************************************************************************
 // GoModuleInfo represents comprehensive information about a Go module's documentation.
 // It contains all extracted documentation, metadata, and package information.
@@ -92,6 +109,10 @@ func NewGoDocRetriever(config *types.GoModuleConfig, cache CacheInterface) (*GoD
 		return nil, fmt.Errorf("failed to create temp directory base %s: %w", tempDirBase, err)
 	}
 
+	if !config.VerifyChecksums {
+		slog.Warn("Go module checksum verification is disabled, fetched modules are not checked against the checksum database")
+	}
+
 	return &GoDocRetriever{
 		config:      config,
 		tempDirBase: tempDirBase,
@@ -106,6 +127,13 @@ func (g *GoDocRetriever) SetVerbose(verbose bool) {
 	g.verbose = verbose
 }
 
+// ************************************************************************************************
+// SetHashAlgorithm sets the algorithm of the content hashes of the indexed documentation,
+// empty meaning types.DefaultHashAlgorithm.
+func (g *GoDocRetriever) SetHashAlgorithm(algorithm string) {
+	g.hashAlgorithm = algorithm
+}
+
 // ************************************************************************************************
 // IsGoModulePath checks if a given string looks like a valid Go module path.
 // It uses pattern matching to identify potential Go module imports.
@@ -168,6 +196,13 @@ func IsGoModulePath(libraryName string) bool {
 //		return fmt.Errorf("failed to retrieve docs: %w", err)
 //	}
 func (g *GoDocRetriever) RetrieveDocumentation(modulePath string) (*GoModuleInfo, error) {
+	return g.retrieveDocumentation(context.Background(), modulePath, nil)
+}
+
+// ************************************************************************************************
+// retrieveDocumentation fetches the documentation of a module, reporting each Go command to
+// progress when not nil. The running command is killed when ctx is cancelled.
+func (g *GoDocRetriever) retrieveDocumentation(ctx context.Context, modulePath string, progress types.ProgressFunc) (*GoModuleInfo, error) {
 	if modulePath == "" {
 		return nil, fmt.Errorf("module path cannot be empty")
 	}
@@ -178,7 +213,7 @@ func (g *GoDocRetriever) RetrieveDocumentation(modulePath string) (*GoModuleInfo
 	}
 
 	if g.verbose {
-		log.Printf("Starting Go module documentation retrieval for: %s", modulePath)
+		slog.InfoContext(ctx, "Starting Go module documentation retrieval", "module", modulePath)
 	}
 
 	// Check if Go command is available
@@ -190,7 +225,7 @@ func (g *GoDocRetriever) RetrieveDocumentation(modulePath string) (*GoModuleInfo
 	var moduleInfo *GoModuleInfo
 	err := g.withTempDir(func(tempDir string) error {
 		var err error
-		moduleInfo, err = g.executeGoCommands(modulePath, tempDir)
+		moduleInfo, err = g.executeGoCommands(ctx, modulePath, tempDir, progress)
 		return err
 	})
 
@@ -199,7 +234,7 @@ func (g *GoDocRetriever) RetrieveDocumentation(modulePath string) (*GoModuleInfo
 	}
 
 	if g.verbose {
-		log.Printf("Successfully retrieved documentation for module: %s", modulePath)
+		slog.InfoContext(ctx, "Retrieved Go module documentation", "module", modulePath)
 	}
 
 	return moduleInfo, nil
@@ -220,37 +255,69 @@ func (g *GoDocRetriever) RetrieveDocumentation(modulePath string) (*GoModuleInfo
 //		return fmt.Errorf("failed to get docs: %w", err)
 //	}
 func (g *GoDocRetriever) GetOrRetrieveDocumentation(modulePath string) (*GoModuleInfo, error) {
+	return g.GetOrRetrieveDocumentationContext(context.Background(), modulePath, nil)
+}
+
+// ************************************************************************************************
+// GetOrRetrieveDocumentationContext is GetOrRetrieveDocumentation reporting the steps of a
+// live retrieval to progress, when not nil: fetching the module, extracting its documentation
+// and listing its packages. Nothing is reported when the documentation is served from the
+// cache. The running go command is killed when ctx is cancelled.
+//
+// Returns:
+//   - *GoModuleInfo: Module documentation information.
+//   - error: An error if retrieval fails or ctx is cancelled.
+//
+// Example usage:
+//
+//	info, err := retriever.GetOrRetrieveDocumentationContext(ctx, "github.com/gin-gonic/gin", func(progress, total int, message string) {
+//		slog.Info(message, "progress", progress, "total", total)
+//	})
+func (g *GoDocRetriever) GetOrRetrieveDocumentationContext(ctx context.Context, modulePath string, progress types.ProgressFunc) (*GoModuleInfo, error) {
+	ctx, span := tracing.Start(ctx, "godoc.GetOrRetrieveDocumentation", tracing.String("go.module", modulePath))
+	defer span.End()
+
+	// Denied hosts are not served from the cache either
+	if err := g.validateModuleHost(modulePath); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("invalid module path: %w", err)
+	}
+
 	// Generate cache key
 	cacheKey := g.getCacheKey(modulePath)
 
 	// Try to get from cache first
-	if cached, err := g.cache.GetRepository(cacheKey); err == nil {
+	if cached, err := g.getCachedRepository(ctx, cacheKey); err == nil {
 		if g.verbose {
-			log.Printf("Found cached documentation for module: %s", modulePath)
+			slog.InfoContext(ctx, "Found cached Go module documentation", "module", modulePath)
 		}
 
 		// Check if cache is still valid
 		if moduleInfo := g.parseRepositoryToModuleInfo(cached); moduleInfo != nil {
 			if g.isCacheValid(moduleInfo) {
+				span.SetAttributes(tracing.Bool("cache.hit", true))
 				return moduleInfo, nil
 			}
 		}
 
 		if g.verbose {
-			log.Printf("Cached documentation for %s is expired, retrieving fresh", modulePath)
+			slog.InfoContext(ctx, "Cached Go module documentation is expired, retrieving fresh", "module", modulePath)
 		}
 	}
 
 	// Cache miss or expired, retrieve fresh documentation
-	moduleInfo, err := g.RetrieveDocumentation(modulePath)
+	span.SetAttributes(tracing.Bool("cache.hit", false))
+	moduleInfo, err := g.retrieveDocumentation(ctx, modulePath, progress)
 	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
+	span.SetAttributes(tracing.String("go.module.version", moduleInfo.Version))
 
 	// Cache the results
-	if err := g.cacheModuleInfo(modulePath, moduleInfo); err != nil {
+	if err := g.cacheModuleInfo(ctx, modulePath, moduleInfo); err != nil {
 		// Log error but don't fail the request
-		log.Printf("Warning: failed to cache module documentation for %s: %v", modulePath, err)
+		slog.WarnContext(ctx, "Failed to cache Go module documentation", "module", modulePath, "error", err)
 	}
 
 	return moduleInfo, nil
@@ -283,9 +350,10 @@ func (g *GoDocRetriever) CreateSyntheticRepository(modulePath string, info *GoMo
 			Language:     "markdown",
 			RepositoryID: repoID,
 			Metadata: map[string]string{
-				"source":      "go_doc",
-				"type":        "documentation",
-				"module_path": modulePath,
+				"source":       "go_doc",
+				"type":         "documentation",
+				"module_path":  modulePath,
+				"retrieved_at": info.CachedAt.Format(time.RFC3339),
 			},
 		}
 	}
@@ -301,9 +369,10 @@ func (g *GoDocRetriever) CreateSyntheticRepository(modulePath string, info *GoMo
 			Language:     "markdown",
 			RepositoryID: repoID,
 			Metadata: map[string]string{
-				"source":      "go_doc_all",
-				"type":        "comprehensive_documentation",
-				"module_path": modulePath,
+				"source":       "go_doc_all",
+				"type":         "comprehensive_documentation",
+				"module_path":  modulePath,
+				"retrieved_at": info.CachedAt.Format(time.RFC3339),
 			},
 		}
 	}
@@ -372,7 +441,7 @@ func (g *GoDocRetriever) CreateSyntheticRepository(modulePath string, info *GoMo
 
 // getCacheKey generates a consistent cache key for a Go module.
 func (g *GoDocRetriever) getCacheKey(modulePath string) string {
-	return fmt.Sprintf("gomod:%s", modulePath)
+	return types.GoModuleRepositoryID(modulePath)
 }
 
 // validateModulePath validates that a module path is safe and properly formatted.
@@ -395,34 +464,97 @@ func (g *GoDocRetriever) validateModulePath(modulePath string) error {
 		return fmt.Errorf("invalid Go module path format")
 	}
 
+	// Enforce host restrictions before anything is fetched
+	if err := g.validateModuleHost(modulePath); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// validateModuleHost checks the host of a requested module path against the configured deny
+// and allow lists. Deny entries win over allow entries, and an empty allow list permits every
+// host. Standard library paths have no host and are never restricted. The dependencies of the
+// module and the hosts its import path redirects to are resolved by the go command and are not
+// checked.
+func (g *GoDocRetriever) validateModuleHost(modulePath string) error {
+	host := strings.ToLower(strings.SplitN(strings.TrimSpace(modulePath), "/", 2)[0])
+	if !strings.Contains(host, ".") {
+		return nil
+	}
+
+	for _, denied := range g.config.DeniedHosts {
+		if matchModuleHost(host, denied) {
+			return fmt.Errorf("module host %s is denied by configuration", host)
+		}
+	}
+
+	if len(g.config.AllowedHosts) == 0 {
+		return nil
+	}
+
+	for _, allowed := range g.config.AllowedHosts {
+		if matchModuleHost(host, allowed) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("module host %s is not in the allowed hosts list", host)
+}
+
+// matchModuleHost reports whether host equals pattern or is a subdomain of it.
+// A leading "*." in the pattern is accepted and treated the same way.
+func matchModuleHost(host, pattern string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	pattern = strings.TrimPrefix(pattern, "*.")
+	if pattern == "" {
+		return false
+	}
+
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
 // validateGoCommand checks if the go command is available and working.
+// The reported toolchain version is cached so later retrievals can record it
+// without running the command again.
 func (g *GoDocRetriever) validateGoCommand() error {
+	g.goVersionMu.Lock()
+	defer g.goVersionMu.Unlock()
+
+	if g.goVersion != "" {
+		return nil
+	}
+
 	cmd := mock_execCommand("go", "version")
 	
 	if g.verbose {
-		log.Printf("[CMD] go version")
+		slog.Info("Running command", "command", "go version")
 	}
 	
 	output, err := cmd.Output()
 	if err != nil {
 		if g.verbose {
-			log.Printf("[CMD STDERR] %s", err.Error())
+			slog.Info("Command finished", "command", "go version", "error", err)
 		}
 		return fmt.Errorf("go command not available: %w", err)
 	}
 
+	versionStr := strings.TrimSpace(string(output))
 	if g.verbose {
-		versionStr := strings.TrimSpace(string(output))
-		log.Printf("[CMD STDOUT] %s", versionStr)
-		log.Printf("Go version: %s", versionStr)
+		slog.Info("Command finished", "command", "go version", "stdout", versionStr)
 	}
 
+	g.goVersion = versionStr
 	return nil
 }
 
+// cachedGoVersion returns the toolchain version captured by validateGoCommand.
+func (g *GoDocRetriever) cachedGoVersion() string {
+	g.goVersionMu.Lock()
+	defer g.goVersionMu.Unlock()
+	return g.goVersion
+}
+
 // withTempDir creates a temporary directory, executes a function, and cleans up.
 func (g *GoDocRetriever) withTempDir(fn func(string) error) error {
 	tempDir, err := mock_osMkdirTemp(g.tempDirBase, "gomod-*")
@@ -432,37 +564,34 @@ func (g *GoDocRetriever) withTempDir(fn func(string) error) error {
 
 	defer func() {
 		if removeErr := mock_osRemoveAll(tempDir); removeErr != nil {
-			log.Printf("Warning: failed to cleanup temp directory %s: %v", tempDir, removeErr)
+			slog.Warn("Failed to clean up temp directory", "dir", tempDir, "error", removeErr)
 		}
 	}()
 
 	if g.verbose {
-		log.Printf("Created temp directory: %s", tempDir)
+		slog.Info("Created temp directory", "dir", tempDir)
 	}
 
 	return fn(tempDir)
 }
 
-// calculateContentHash generates a simple hash for content change detection.
+// calculateContentHash hashes content for change detection with the configured algorithm.
 func (g *GoDocRetriever) calculateContentHash(content string) string {
-	if len(content) == 0 {
-		return "empty"
-	}
-
-	// Simple hash based on content length and first/last characters
-	first := content[0]
-	last := content[len(content)-1]
-
-	return fmt.Sprintf("godoc_%d_%c_%c", len(content), first, last)
+	return types.HashContent(g.hashAlgorithm, content)
 }
 
-// formatDocumentation formats raw go doc output into markdown.
+// formatDocumentation formats raw go doc output according to the configured DocFormat.
+// The output only depends on its inputs so identical docs render byte-identically;
+// volatile fields such as the retrieval time live in file metadata instead.
 func (g *GoDocRetriever) formatDocumentation(command, content string) string {
+	if g.config.DocFormat == "raw" {
+		return content
+	}
+
 	var formatted strings.Builder
 
-	formatted.WriteString(fmt.Sprintf("# Go Documentation\n\n"))
-	formatted.WriteString(fmt.Sprintf("**Generated with:** `%s`\n", command))
-	formatted.WriteString(fmt.Sprintf("**Retrieved at:** %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
+	formatted.WriteString("# Go Documentation\n\n")
+	formatted.WriteString(fmt.Sprintf("**Generated with:** `%s`\n\n", command))
 	formatted.WriteString("---\n\n")
 	
 	// Add the raw documentation content in a code block
@@ -474,19 +603,31 @@ func (g *GoDocRetriever) formatDocumentation(command, content string) string {
 }
 
 // cacheModuleInfo stores module information in the cache.
-func (g *GoDocRetriever) cacheModuleInfo(modulePath string, info *GoModuleInfo) error {
+func (g *GoDocRetriever) cacheModuleInfo(ctx context.Context, modulePath string, info *GoModuleInfo) error {
 	repo := g.CreateSyntheticRepository(modulePath, info)
+	if tracedCache, ok := g.cache.(ContextCacheInterface); ok {
+		return tracedCache.StoreRepositoryContext(ctx, repo)
+	}
 	return g.cache.StoreRepository(repo)
 }
 
+// getCachedRepository reads a repository from the cache, in the trace of ctx when the cache
+// records its accesses.
+func (g *GoDocRetriever) getCachedRepository(ctx context.Context, key string) (*types.RepositoryIndex, error) {
+	if tracedCache, ok := g.cache.(ContextCacheInterface); ok {
+		return tracedCache.GetRepositoryContext(ctx, key)
+	}
+	return g.cache.GetRepository(key)
+}
+
 // parseRepositoryToModuleInfo converts a cached repository back to module info.
 func (g *GoDocRetriever) parseRepositoryToModuleInfo(repo *types.RepositoryIndex) *GoModuleInfo {
-	if repo == nil || !strings.HasPrefix(repo.ID, "gomod:") {
+	if repo == nil || !types.IsGoModuleRepositoryID(repo.ID) {
 		return nil
 	}
 
 	info := &GoModuleInfo{
-		ModulePath:  strings.TrimPrefix(repo.ID, "gomod:"),
+		ModulePath:  strings.TrimPrefix(repo.ID, types.GoModuleRepositoryPrefix),
 		CachedAt:    repo.LastUpdated,
 		PackageList: []string{},
 		Examples:    make(map[string]string),
@@ -531,7 +672,7 @@ func (g *GoDocRetriever) isCacheValid(info *GoModuleInfo) bool {
 
 	timeout, err := mock_timeParseDuration(g.config.CacheTimeout)
 	if err != nil {
-		log.Printf("Warning: invalid cache timeout format %s, assuming valid", g.config.CacheTimeout)
+		slog.Warn("Invalid cache timeout format, assuming valid", "cacheTimeout", g.config.CacheTimeout, "error", err)
 		return true
 	}
 
@@ -0,0 +1,80 @@
+// ************************************************************************************************
+// Package godoc host restriction tests for the Go module fallback.
+// This file verifies how module hosts are matched against the allowed and denied hosts lists.
+package godoc
+
+import (
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+// TestMatchModuleHost tests exact host, subdomain and wildcard pattern matching
+func TestMatchModuleHost(t *testing.T) {
+	tests := []struct {
+		host     string
+		pattern  string
+		expected bool
+	}{
+		{"github.com", "github.com", true},
+		{"github.com", " GitHub.com ", true},
+		{"api.github.com", "github.com", true},
+		{"api.github.com", "*.github.com", true},
+		{"github.com", "*.github.com", true},
+		{"evilgithub.com", "github.com", false},
+		{"github.com.evil.com", "github.com", false},
+		{"github.com", "api.github.com", false},
+		{"github.com", "", false},
+		{"github.com", "*.", false},
+	}
+	for _, test := range tests {
+		if matched := matchModuleHost(test.host, test.pattern); matched != test.expected {
+			t.Errorf("matchModuleHost(%q, %q): expected %v, got %v", test.host, test.pattern, test.expected, matched)
+		}
+	}
+}
+
+// TestValidateModuleHost tests that denied hosts win over allowed ones and that an empty allow
+// list permits every host
+func TestValidateModuleHost(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowed    []string
+		denied     []string
+		modulePath string
+		valid      bool
+	}{
+		{"empty lists allow every host", nil, nil, "example.org/mod", true},
+		{"empty allow list with a deny list", nil, []string{"example.org"}, "github.com/gin-gonic/gin", true},
+		{"allowed host", []string{"github.com"}, nil, "github.com/gin-gonic/gin", true},
+		{"host case is ignored", []string{"github.com"}, nil, "GitHub.com/gin-gonic/gin", true},
+		{"allowed subdomain", []string{"*.example.com"}, nil, "go.example.com/mod", true},
+		{"host outside of the allow list", []string{"github.com"}, nil, "gitlab.com/group/mod", false},
+		{"deny wins over allow", []string{"example.com"}, []string{"internal.example.com"}, "internal.example.com/mod", false},
+		{"denied subdomain", nil, []string{"example.com"}, "go.example.com/mod", false},
+		{"standard library is never restricted", []string{"github.com"}, []string{"github.com"}, "net/http", true},
+	}
+	for _, test := range tests {
+		retriever := &GoDocRetriever{config: &types.GoModuleConfig{AllowedHosts: test.allowed, DeniedHosts: test.denied}}
+		if err := retriever.validateModuleHost(test.modulePath); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v, got error %v", test.name, test.valid, err)
+		}
+	}
+}
+
+// TestGetOrRetrieveDocumentation_DeniedCachedModule tests that a denied module is not served
+// from the cache
+func TestGetOrRetrieveDocumentation_DeniedCachedModule(t *testing.T) {
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"gomod:internal.example.com/mod": {ID: "gomod:internal.example.com/mod", Metadata: map[string]interface{}{}},
+	}}
+	config := &types.GoModuleConfig{TempDirBase: t.TempDir(), CacheTimeout: "1h", DeniedHosts: []string{"example.com"}}
+	retriever, err := NewGoDocRetriever(config, cache)
+	if err != nil {
+		t.Fatalf("Failed to create retriever: %v", err)
+	}
+
+	if _, err := retriever.GetOrRetrieveDocumentation("internal.example.com/mod"); err == nil {
+		t.Error("Expected the denied module to be refused")
+	}
+}
@@ -5,8 +5,10 @@ package godoc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -174,6 +176,44 @@ func TestCommandLoggingFormat(t *testing.T) {
 	})
 }
 
+// TestCommandErrorIncludesStderr tests that a failed command reports the tail of its stderr
+// and keeps progress messages out of stdout
+func TestCommandErrorIncludesStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+
+	retriever, err := NewGoDocRetriever(&types.GoModuleConfig{Enabled: true, CommandTimeout: "30s"}, &mockCache{})
+	if err != nil {
+		t.Fatalf("Failed to create GoDocRetriever: %v", err)
+	}
+
+	cmd := exec.Command("sh", "-c", "echo 'go: downloading example.com/mod v1.0.0' >&2; echo 'go: module example.com/mod: not found' >&2; exit 1")
+	_, stderr, err := retriever.executeCommandWithLogging(cmd, "go get example.com/mod")
+	if err == nil {
+		t.Fatal("Expected an error for a failing command")
+	}
+	if !strings.Contains(err.Error(), "go get example.com/mod failed: go: downloading example.com/mod v1.0.0; go: module example.com/mod: not found") {
+		t.Errorf("Expected the stderr tail in the error, got: %v", err)
+	}
+	var exitError *exec.ExitError
+	if !errors.As(err, &exitError) {
+		t.Errorf("Expected the exit error to be wrapped, got: %v", err)
+	}
+	if len(stderr) == 0 {
+		t.Error("Expected stderr to be returned")
+	}
+
+	cmd = exec.Command("sh", "-c", "echo 'go: downloading x' >&2; echo documentation")
+	stdout, _, err := retriever.executeCommandWithLogging(cmd, "go doc")
+	if err != nil || strings.TrimSpace(string(stdout)) != "documentation" {
+		t.Errorf("Expected only the documentation on stdout, got %q (%v)", stdout, err)
+	}
+	if tail := stderrTail([]byte("a\n\nb\nc\n"), 2); tail != "b; c" {
+		t.Errorf("Expected the last two non-empty lines, got %q", tail)
+	}
+}
+
 // TestProblematicGoModule tests with the specific module mentioned by the user
 func TestProblematicGoModule(t *testing.T) {
 	// Skip this test if we're not in a proper Go environment
@@ -219,6 +259,31 @@ func TestProblematicGoModule(t *testing.T) {
 	})
 }
 
+// TestRetrieveDocumentationCancelled tests that a cancelled retrieval runs no go command
+func TestRetrieveDocumentationCancelled(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("Go command not available, skipping integration test")
+	}
+
+	retriever, err := NewGoDocRetriever(&types.GoModuleConfig{Enabled: true, CacheTimeout: "1h", CommandTimeout: "60s"}, &mockCache{})
+	if err != nil {
+		t.Fatalf("Failed to create GoDocRetriever: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var messages []string
+	_, err = retriever.GetOrRetrieveDocumentationContext(ctx, "example.com/cancelled", func(progress, total int, message string) {
+		messages = append(messages, message)
+	})
+	if err == nil {
+		t.Fatal("Expected a cancelled retrieval to fail")
+	}
+	if len(messages) != 1 || !strings.Contains(messages[0], "Initializing") {
+		t.Errorf("Expected the retrieval to stop at its first step, got %v", messages)
+	}
+}
+
 // Example of how the logging output should look:
 // 
 // [CMD] go version
@@ -48,6 +48,12 @@ var mock_osReadFile = os.ReadFile
 // mock_osWriteFile writes data to a file
 var mock_osWriteFile = os.WriteFile
 
+// mock_osRename renames a file, replacing the destination
+var mock_osRename = os.Rename
+
+// mock_osEnviron returns the current process environment
+var mock_osEnviron = os.Environ
+
 // ************************************************************************************************
 // Mock functions for command execution
 
@@ -0,0 +1,216 @@
+// ************************************************************************************************
+// Package godoc provides bulk warming of Go module documentation for the repomix-mcp application.
+// Warming fetches the documentation of many modules into the cache ahead of time; a manifest
+// checkpoints every outcome so an interrupted run resumes where it stopped.
+package godoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ************************************************************************************************
+// WarmManifest records which modules were warmed and which failed. Failed modules are known-bad
+// and skipped by later runs unless retried explicitly.
+type WarmManifest struct {
+	Succeeded map[string]time.Time `json:"succeeded"` // Completion time by module path
+	Failed    map[string]string    `json:"failed"`    // Last error by module path
+
+	path string
+	mu   sync.Mutex
+}
+
+// ************************************************************************************************
+// WarmSummary counts the outcomes of a warm run.
+type WarmSummary struct {
+	Warmed  int // Modules fetched or found valid in the cache
+	Skipped int // Modules already recorded in the manifest
+	Failed  int // Modules whose retrieval failed
+}
+
+// ************************************************************************************************
+// LoadWarmManifest reads a warm manifest, starting an empty one when the file does not exist.
+//
+// Returns:
+//   - *WarmManifest: The manifest, saved back to path as modules complete.
+//   - error: An error if the file exists but cannot be read or decoded.
+//
+// Example usage:
+//
+//	manifest, err := godoc.LoadWarmManifest("/var/cache/repomix-mcp/warm-manifest.json")
+//	if err != nil {
+//		return err
+//	}
+func LoadWarmManifest(path string) (*WarmManifest, error) {
+	manifest := &WarmManifest{
+		Succeeded: make(map[string]time.Time),
+		Failed:    make(map[string]string),
+		path:      path,
+	}
+
+	data, err := mock_osReadFile(path)
+	if mock_osIsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read warm manifest %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid warm manifest %s: %w", path, err)
+	}
+	if manifest.Succeeded == nil {
+		manifest.Succeeded = make(map[string]time.Time)
+	}
+	if manifest.Failed == nil {
+		manifest.Failed = make(map[string]string)
+	}
+	return manifest, nil
+}
+
+// ************************************************************************************************
+// record stores the outcome of a module and checkpoints the manifest. The file is replaced
+// atomically so an interruption never leaves it truncated.
+func (m *WarmManifest) record(modulePath string, retrievalErr error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if retrievalErr != nil {
+		m.Failed[modulePath] = retrievalErr.Error()
+	} else {
+		delete(m.Failed, modulePath)
+		m.Succeeded[modulePath] = mock_timeNow()
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode warm manifest: %w", err)
+	}
+	if err := mock_osMkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to create warm manifest directory: %w", err)
+	}
+	tempPath := m.path + ".tmp"
+	if err := mock_osWriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write warm manifest: %w", err)
+	}
+	if err := mock_osRename(tempPath, m.path); err != nil {
+		return fmt.Errorf("failed to replace warm manifest: %w", err)
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// ParseModuleList reads module paths from a list file: one module per line, blank lines and
+// lines starting with '#' are ignored.
+//
+// Returns:
+//   - []string: The module paths in file order.
+func ParseModuleList(content string) []string {
+	var modules []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		modules = append(modules, line)
+	}
+	return modules
+}
+
+// ************************************************************************************************
+// WarmModules fetches the documentation of modules into the cache, at most MaxConcurrent at
+// a time; every go command runs under the configured CommandTimeout. Modules recorded as
+// succeeded in the manifest are skipped, as are modules recorded as failed unless retryFailed
+// is set. Each outcome is checkpointed in the manifest as soon as it is known.
+//
+// Returns:
+//   - WarmSummary: The outcome counts.
+//   - error: An error if the manifest could not be saved; the run stops at the first such error.
+//
+// Example usage:
+//
+//	summary, err := retriever.WarmModules(modules, manifest, false)
+func (g *GoDocRetriever) WarmModules(modules []string, manifest *WarmManifest, retryFailed bool) (WarmSummary, error) {
+	var summary WarmSummary
+
+	// Deduplicate and drop the modules completed or known-bad in an earlier run
+	seen := make(map[string]bool)
+	var pending []string
+	for _, modulePath := range modules {
+		if seen[modulePath] {
+			continue
+		}
+		seen[modulePath] = true
+
+		_, succeeded := manifest.Succeeded[modulePath]
+		_, failed := manifest.Failed[modulePath]
+		if succeeded || (failed && !retryFailed) {
+			summary.Skipped++
+			continue
+		}
+		pending = append(pending, modulePath)
+	}
+	sort.Strings(pending)
+
+	workers := g.config.MaxConcurrent
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	slog.Info("Warming modules", "modules", len(pending), "skipped", summary.Skipped, "concurrent", workers)
+
+	var (
+		mu       sync.Mutex
+		saveErr  error
+		finished int
+		wg       sync.WaitGroup
+	)
+	queue := make(chan string)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for modulePath := range queue {
+				_, err := g.GetOrRetrieveDocumentation(modulePath)
+				recordErr := manifest.record(modulePath, err)
+
+				mu.Lock()
+				finished++
+				if err != nil {
+					summary.Failed++
+					slog.Warn("Failed to warm module", "module", modulePath, "progress", finished, "total", len(pending), "error", err)
+				} else {
+					summary.Warmed++
+					slog.Info("Warmed module", "module", modulePath, "progress", finished, "total", len(pending))
+				}
+				if recordErr != nil && saveErr == nil {
+					saveErr = recordErr
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, modulePath := range pending {
+		mu.Lock()
+		stop := saveErr != nil
+		mu.Unlock()
+		if stop {
+			break
+		}
+		queue <- modulePath
+	}
+	close(queue)
+	wg.Wait()
+
+	return summary, saveErr
+}
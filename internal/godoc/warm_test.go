@@ -0,0 +1,62 @@
+// ************************************************************************************************
+// Package godoc bulk warming tests.
+// This file verifies that warm runs checkpoint their outcomes and resume from the manifest.
+package godoc
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"repomix-mcp/pkg/types"
+)
+
+// TestWarmModules_Resume tests that completed and known-bad modules are skipped on the next run
+func TestWarmModules_Resume(t *testing.T) {
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		// Valid cached documentation, warmed without running go
+		"gomod:github.com/cached/mod": {ID: "gomod:github.com/cached/mod", LastUpdated: time.Now(), Metadata: map[string]interface{}{}},
+	}}
+	config := &types.GoModuleConfig{TempDirBase: t.TempDir(), CacheTimeout: "1h", MaxConcurrent: 2}
+	retriever, err := NewGoDocRetriever(config, cache)
+	if err != nil {
+		t.Fatalf("NewGoDocRetriever failed: %v", err)
+	}
+
+	manifestPath := filepath.Join(t.TempDir(), "warm-manifest.json")
+	manifest, err := LoadWarmManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("LoadWarmManifest failed: %v", err)
+	}
+
+	modules := ParseModuleList("# seed list\ngithub.com/cached/mod\n\nnot a module\ngithub.com/cached/mod\n")
+	summary, err := retriever.WarmModules(modules, manifest, false)
+	if err != nil {
+		t.Fatalf("WarmModules failed: %v", err)
+	}
+	if summary.Warmed != 1 || summary.Failed != 1 || summary.Skipped != 0 {
+		t.Errorf("Expected 1 warmed and 1 failed, got %+v", summary)
+	}
+
+	// A new run reads the checkpoint back and skips both modules
+	resumed, err := LoadWarmManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("LoadWarmManifest failed: %v", err)
+	}
+	if _, ok := resumed.Succeeded["github.com/cached/mod"]; !ok {
+		t.Errorf("Expected the warmed module in the manifest, got %+v", resumed.Succeeded)
+	}
+	summary, err = retriever.WarmModules(modules, resumed, false)
+	if err != nil {
+		t.Fatalf("WarmModules failed: %v", err)
+	}
+	if summary.Skipped != 2 || summary.Warmed != 0 || summary.Failed != 0 {
+		t.Errorf("Expected both modules skipped, got %+v", summary)
+	}
+
+	// Known-bad modules are retried on request
+	summary, _ = retriever.WarmModules(modules, resumed, true)
+	if summary.Skipped != 1 || summary.Failed != 1 {
+		t.Errorf("Expected the failed module to be retried, got %+v", summary)
+	}
+}
@@ -0,0 +1,239 @@
+// ************************************************************************************************
+// Package indexer provides the incremental re-indexing of git repositories.
+// A repository indexed with repomix at a commit is re-indexed by running repomix on the files
+// changed since, committed or not, and merging them into the previous index, which makes
+// re-indexing large monorepos fast. The whole repository is indexed again when there is no
+// usable previous index: first indexing, another strategy, a changed indexing configuration, a
+// commit missing from the history, or too many changed files.
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"repomix-mcp/internal/repository"
+	"repomix-mcp/internal/tracing"
+	"repomix-mcp/pkg/types"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ************************************************************************************************
+// maxIncrementalFiles is the number of changed files above which the whole repository is
+// indexed again, a single repomix run being faster than merging that many changes.
+const maxIncrementalFiles = 1000
+
+// ************************************************************************************************
+// IndexRepositoryIncremental indexes a repository like IndexRepositoryContext, re-indexing only
+// the files changed since previous, its last index, when the repository is a git repository
+// indexed with repomix. The commit, working tree state and configuration of the new index are
+// recorded in its metadata for the next run.
+//
+// Returns:
+//   - *types.RepositoryIndex: The indexed repository content.
+//   - error: An error if indexing fails or ctx is cancelled.
+//
+// Example usage:
+//
+//	previous, _ := cache.GetRepository("my-repo")
+//	index, err := indexer.IndexRepositoryIncremental(ctx, previous, "my-repo", "/path/to/repo", config)
+//	if err != nil {
+//		return fmt.Errorf("failed to index repository: %w", err)
+//	}
+func (i *Indexer) IndexRepositoryIncremental(ctx context.Context, previous *types.RepositoryIndex, repositoryID, localPath string, config types.IndexingConfig) (*types.RepositoryIndex, error) {
+	sinceCommit := ""
+	if previous != nil {
+		sinceCommit = previous.CommitHash
+	}
+
+	changes, err := repository.ChangesSince(localPath, sinceCommit)
+	if err != nil {
+		if sinceCommit != "" {
+			slog.InfoContext(ctx, "Indexing the whole repository", "repository", repositoryID, "reason", err)
+		}
+		return i.IndexRepositoryContext(ctx, repositoryID, localPath, config)
+	}
+
+	if reason := i.incrementalBlocker(previous, changes, localPath, config); reason != "" {
+		slog.InfoContext(ctx, "Indexing the whole repository", "repository", repositoryID, "reason", reason)
+	} else {
+		repoIndex, err := i.indexChangedFiles(ctx, previous, changes, repositoryID, localPath, config)
+		if err == nil {
+			recordIndexedState(repoIndex, changes, config)
+			return repoIndex, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		slog.WarnContext(ctx, "Incremental indexing failed, indexing the whole repository", "repository", repositoryID, "error", err)
+	}
+
+	repoIndex, err := i.IndexRepositoryContext(ctx, repositoryID, localPath, config)
+	if err != nil {
+		return nil, err
+	}
+	recordIndexedState(repoIndex, changes, config)
+	return repoIndex, nil
+}
+
+// ************************************************************************************************
+// incrementalBlocker tells why a repository cannot be re-indexed incrementally from its
+// previous index.
+//
+// Returns:
+//   - string: The reason, empty if the repository can be re-indexed incrementally.
+func (i *Indexer) incrementalBlocker(previous *types.RepositoryIndex, changes *repository.Changes, localPath string, config types.IndexingConfig) string {
+	if previous == nil || previous.CommitHash == "" {
+		return "no previous index at a known commit"
+	}
+	if strategy, _ := previous.Metadata[types.IndexingStrategyMetadataKey].(string); strategy != StrategyRepomix.String() {
+		return fmt.Sprintf("previous index built with the %s strategy", strategy)
+	}
+	if strategy, err := i.selectIndexingStrategy(localPath, config.Strategy); err != nil || strategy != StrategyRepomix {
+		return "repository not indexed with repomix"
+	}
+	if hash, _ := previous.Metadata[types.IndexingConfigMetadataKey].(string); hash != indexingConfigHash(config) {
+		return "indexing configuration changed"
+	}
+
+	changed := changedPaths(previous, changes)
+	if len(changed) > maxIncrementalFiles {
+		return fmt.Sprintf("%d files changed", len(changed))
+	}
+	for _, path := range changed {
+		// Paths are passed to repomix as a comma-separated list of glob patterns
+		if strings.ContainsAny(path, ",*?[]{}") {
+			return fmt.Sprintf("changed file %q cannot be passed to repomix", path)
+		}
+	}
+	return ""
+}
+
+// ************************************************************************************************
+// indexChangedFiles re-indexes the files changed since the previous index with repomix and
+// merges them into a copy of it, then recomputes the content added on top of the indexed files.
+//
+// Returns:
+//   - *types.RepositoryIndex: The merged repository index.
+//   - error: An error if repomix fails or ctx is cancelled.
+func (i *Indexer) indexChangedFiles(ctx context.Context, previous *types.RepositoryIndex, changes *repository.Changes, repositoryID, localPath string, config types.IndexingConfig) (repoIndex *types.RepositoryIndex, err error) {
+	changed := changedPaths(previous, changes)
+	ctx, span := tracing.Start(ctx, "indexer.indexChangedFiles", tracing.Int("indexer.changed_files", len(changed)))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	repoIndex = &types.RepositoryIndex{
+		ID:          repositoryID,
+		Name:        repositoryID,
+		Path:        localPath,
+		LastUpdated: mock_timeNow(),
+		Files:       make(map[string]types.IndexedFile, len(previous.Files)),
+		Metadata:    make(map[string]interface{}),
+	}
+	for path, file := range previous.Files {
+		repoIndex.Files[path] = file
+	}
+
+	// Changed files are dropped, then indexed again unless deleted or no longer included
+	var include []string
+	for _, path := range changed {
+		relPath := filepath.FromSlash(path)
+		delete(repoIndex.Files, relPath)
+
+		info, err := mock_osStat(filepath.Join(localPath, relPath))
+		if err != nil || info.IsDir() || !includedPath(path, config) {
+			continue
+		}
+		include = append(include, path)
+	}
+
+	if len(include) > 0 {
+		changedIndex, err := i.runRepomix(ctx, repositoryID, localPath, include, config)
+		if err != nil {
+			return nil, err
+		}
+		for path, file := range changedIndex.Files {
+			repoIndex.Files[path] = file
+		}
+	}
+
+	repoIndex.Metadata["file_count"] = len(repoIndex.Files)
+	repoIndex.Metadata["indexed_at"] = mock_timeNow().Format(time.RFC3339)
+	repoIndex.Metadata["indexer_version"] = "repomix-mcp-v1.0.0"
+	i.addRepositoryContent(repoIndex, localPath, config)
+
+	repoIndex.Metadata[types.IndexingStrategyMetadataKey] = StrategyRepomix.String()
+	repoIndex.Metadata[types.IncrementalFilesMetadataKey] = len(changed)
+	slog.InfoContext(ctx, "Indexed changed files", "repository", repositoryID, "changed", len(changed), "reindexed", len(include), "since", previous.CommitHash)
+	return repoIndex, nil
+}
+
+// ************************************************************************************************
+// changedPaths returns the files to re-index since the previous index: those changed by the
+// commits since, and those modified or untracked in the working tree now or when it was indexed.
+func changedPaths(previous *types.RepositoryIndex, changes *repository.Changes) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, list := range [][]string{changes.Committed, changes.Dirty, decodePaths(previous.Metadata[types.DirtyFilesMetadataKey])} {
+		for _, path := range list {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// ************************************************************************************************
+// includedPath reports whether a file matches the include patterns of the indexing
+// configuration, always-included files included. Every file matches without include pattern;
+// exclude patterns are left to repomix.
+func includedPath(path string, config types.IndexingConfig) bool {
+	if len(config.IncludePatterns) == 0 {
+		return true
+	}
+	for _, pattern := range append(append([]string(nil), config.IncludePatterns...), config.AlwaysInclude...) {
+		if matched, _ := doublestar.Match(pattern, path); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ************************************************************************************************
+// recordIndexedState records the commit, the dirty files and the configuration a repository
+// was indexed with, read by the next incremental run.
+func recordIndexedState(repoIndex *types.RepositoryIndex, changes *repository.Changes, config types.IndexingConfig) {
+	repoIndex.CommitHash = changes.Commit
+	repoIndex.Metadata[types.IndexingConfigMetadataKey] = indexingConfigHash(config)
+	repoIndex.Metadata[types.DirtyFilesMetadataKey] = append([]string{}, changes.Dirty...)
+}
+
+// ************************************************************************************************
+// indexingConfigHash returns the hash identifying an indexing configuration.
+func indexingConfigHash(config types.IndexingConfig) string {
+	data, _ := json.Marshal(config)
+	return types.ContentHash(string(data))
+}
+
+// ************************************************************************************************
+// decodePaths reads a list of paths from repository metadata, a typed slice right after
+// indexing and a generic JSON array once loaded from the cache.
+func decodePaths(value interface{}) []string {
+	if paths, ok := value.([]string); ok {
+		return paths
+	}
+	var paths []string
+	if data, err := json.Marshal(value); err == nil {
+		_ = json.Unmarshal(data, &paths)
+	}
+	return paths
+}
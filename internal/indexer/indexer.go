@@ -5,13 +5,20 @@
 package indexer
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"repomix-mcp/pkg/types"
+	"repomix-mcp/internal/logging"
 	"repomix-mcp/internal/parser"
+	"repomix-mcp/internal/repository"
+	"repomix-mcp/internal/tracing"
 )
 
 // ************************************************************************************************
@@ -24,6 +31,24 @@ const (
 	
 	// StrategyGoNative uses Go AST parsing for Go projects.
 	StrategyGoNative
+
+	// StrategyPythonNative uses Python source parsing for Python projects.
+	StrategyPythonNative
+
+	// StrategyTypeScriptNative uses TypeScript/JavaScript source parsing for Node projects.
+	StrategyTypeScriptNative
+
+	// StrategyRustNative uses Rust source parsing for Cargo crates and workspaces.
+	StrategyRustNative
+
+	// StrategyJavaNative uses Java source parsing for Maven and Gradle projects.
+	StrategyJavaNative
+
+	// StrategyCSharpNative uses C# source parsing for .NET solutions and projects.
+	StrategyCSharpNative
+
+	// StrategyTreeSitter uses tree-sitter grammars for the languages without a native parser.
+	StrategyTreeSitter
 )
 
 // String returns a string representation of the indexing strategy.
@@ -33,6 +58,18 @@ func (s IndexingStrategy) String() string {
 		return "repomix"
 	case StrategyGoNative:
 		return "go_native"
+	case StrategyPythonNative:
+		return "python_native"
+	case StrategyTypeScriptNative:
+		return "typescript_native"
+	case StrategyRustNative:
+		return "rust_native"
+	case StrategyJavaNative:
+		return "java_native"
+	case StrategyCSharpNative:
+		return "csharp_native"
+	case StrategyTreeSitter:
+		return "tree_sitter"
 	default:
 		return "unknown"
 	}
@@ -103,7 +140,11 @@ func (i *Indexer) Close() error {
 
 // ************************************************************************************************
 // DetermineIndexingStrategy determines the best indexing strategy for a repository.
-// It checks for Go projects and returns the appropriate strategy.
+// It checks for Go projects (go.mod or go.work), then Python projects (pyproject.toml or setup.py),
+// then Rust projects (Cargo.toml), then Java projects (pom.xml or build.gradle), then .NET projects
+// (*.sln or *.csproj), then Node projects (package.json or tsconfig.json), then for several Go files,
+// then for several files parsed by tree-sitter grammars when they are built in, and returns the
+// appropriate strategy.
 //
 // Returns:
 //   - IndexingStrategy: The recommended indexing strategy.
@@ -118,8 +159,48 @@ func (i *Indexer) DetermineIndexingStrategy(localPath string) IndexingStrategy {
 		return StrategyGoNative
 	}
 
-	// Fallback: check for significant number of Go files
-	goFileCount := 0
+	// Multi-module workspaces may have no go.mod at the root
+	if _, err := mock_osStat(filepath.Join(localPath, "go.work")); err == nil {
+		return StrategyGoNative
+	}
+
+	// Check if this is a Python project by looking for its packaging files
+	for _, name := range parser.PythonProjectFiles {
+		if _, err := mock_osStat(filepath.Join(localPath, name)); err == nil {
+			return StrategyPythonNative
+		}
+	}
+
+	// Check if this is a Rust crate or workspace, before Node as WebAssembly crates ship a package.json
+	for _, name := range parser.RustProjectFiles {
+		if _, err := mock_osStat(filepath.Join(localPath, name)); err == nil {
+			return StrategyRustNative
+		}
+	}
+
+	// Check if this is a Maven or Gradle project, before Node as web applications ship a package.json
+	for _, name := range parser.JavaProjectFiles {
+		if _, err := mock_osStat(filepath.Join(localPath, name)); err == nil {
+			return StrategyJavaNative
+		}
+	}
+
+	// Check if this is a .NET solution or project, before Node as ASP.NET projects ship a package.json
+	for _, pattern := range parser.CSharpProjectPatterns {
+		if matches, _ := filepath.Glob(filepath.Join(localPath, pattern)); len(matches) > 0 {
+			return StrategyCSharpNative
+		}
+	}
+
+	// Check if this is a Node project, TypeScript or JavaScript
+	for _, name := range parser.NodeProjectFiles {
+		if _, err := mock_osStat(filepath.Join(localPath, name)); err == nil {
+			return StrategyTypeScriptNative
+		}
+	}
+
+	// Fallback: check for significant number of Go files, or of files parsed by tree-sitter
+	goFileCount, treeSitterFileCount := 0, 0
 	filepath.Walk(localPath, func(path string, info mock_osFileInfo, err error) error {
 		if err != nil {
 			return nil // Skip errors
@@ -127,6 +208,9 @@ func (i *Indexer) DetermineIndexingStrategy(localPath string) IndexingStrategy {
 		if !info.IsDir() && strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
 			goFileCount++
 		}
+		if !info.IsDir() && slices.Contains(parser.TreeSitterExtensions, strings.ToLower(filepath.Ext(path))) {
+			treeSitterFileCount++
+		}
 		return nil
 	})
 
@@ -135,12 +219,104 @@ func (i *Indexer) DetermineIndexingStrategy(localPath string) IndexingStrategy {
 		return StrategyGoNative
 	}
 
+	// Extract the constructs of other languages rather than dumping their files
+	if parser.TreeSitterAvailable && treeSitterFileCount >= 3 {
+		return StrategyTreeSitter
+	}
+
 	// Default to repomix strategy
 	return StrategyRepomix
 }
 
+// ************************************************************************************************
+// selectIndexingStrategy returns the strategy configured by IndexingConfig.Strategy, falling
+// back to DetermineIndexingStrategy when it is empty or "auto".
+//
+// Returns:
+//   - IndexingStrategy: The strategy to index with.
+//   - error: ErrInvalidConfig if the strategy name is unknown.
+func (i *Indexer) selectIndexingStrategy(localPath, name string) (IndexingStrategy, error) {
+	switch name {
+	case types.IndexingStrategyRepomix:
+		return StrategyRepomix, nil
+	case types.IndexingStrategyGoNative:
+		return StrategyGoNative, nil
+	case types.IndexingStrategyPythonNative:
+		return StrategyPythonNative, nil
+	case types.IndexingStrategyTypeScriptNative:
+		return StrategyTypeScriptNative, nil
+	case types.IndexingStrategyRustNative:
+		return StrategyRustNative, nil
+	case types.IndexingStrategyJavaNative:
+		return StrategyJavaNative, nil
+	case types.IndexingStrategyCSharpNative:
+		return StrategyCSharpNative, nil
+	case types.IndexingStrategyTreeSitter:
+		return StrategyTreeSitter, nil
+	case "", types.IndexingStrategyAuto:
+		return i.DetermineIndexingStrategy(localPath), nil
+	default:
+		return StrategyRepomix, fmt.Errorf("%w: unknown indexing strategy %q", types.ErrInvalidConfig, name)
+	}
+}
+
+// ************************************************************************************************
+// fallbackStrategies returns the strategies tried in order when the primary strategy fails.
+// Without IndexingConfig.FallbackStrategies, native parsing falls back to repomix and
+// repomix has no fallback; "none" disables fallbacks. The primary strategy and duplicates
+// are left out.
+//
+// Returns:
+//   - []IndexingStrategy: The fallback chain, possibly empty.
+//   - error: ErrInvalidConfig if a strategy name is unknown.
+func fallbackStrategies(primary IndexingStrategy, names []string) ([]IndexingStrategy, error) {
+	if len(names) == 0 {
+		if primary != StrategyRepomix {
+			return []IndexingStrategy{StrategyRepomix}, nil
+		}
+		return nil, nil
+	}
+
+	var chain []IndexingStrategy
+	seen := map[IndexingStrategy]bool{primary: true}
+	for _, name := range names {
+		var strategy IndexingStrategy
+		switch name {
+		case types.IndexingStrategyRepomix:
+			strategy = StrategyRepomix
+		case types.IndexingStrategyGoNative:
+			strategy = StrategyGoNative
+		case types.IndexingStrategyPythonNative:
+			strategy = StrategyPythonNative
+		case types.IndexingStrategyTypeScriptNative:
+			strategy = StrategyTypeScriptNative
+		case types.IndexingStrategyRustNative:
+			strategy = StrategyRustNative
+		case types.IndexingStrategyJavaNative:
+			strategy = StrategyJavaNative
+		case types.IndexingStrategyCSharpNative:
+			strategy = StrategyCSharpNative
+		case types.IndexingStrategyTreeSitter:
+			strategy = StrategyTreeSitter
+		case types.IndexingStrategyNone:
+			continue
+		default:
+			return nil, fmt.Errorf("%w: unknown fallback strategy %q", types.ErrInvalidConfig, name)
+		}
+		if !seen[strategy] {
+			seen[strategy] = true
+			chain = append(chain, strategy)
+		}
+	}
+	return chain, nil
+}
+
+// ************************************************************************************************
 // IndexRepository indexes a repository using the appropriate strategy.
-// It automatically detects whether to use repomix or Go-native parsing.
+// It automatically detects whether to use repomix or Go-native parsing,
+// unless IndexingConfig.Strategy forces one. When the strategy fails, the
+// IndexingConfig.FallbackStrategies are tried in order and each failure is logged
+// as a warning; the strategy that succeeded is recorded in the repository metadata.
 //
 // Returns:
 //   - *types.RepositoryIndex: The indexed repository content.
@@ -153,35 +329,130 @@ func (i *Indexer) DetermineIndexingStrategy(localPath string) IndexingStrategy {
 //		return fmt.Errorf("failed to index repository: %w", err)
 //	}
 func (i *Indexer) IndexRepository(repositoryID, localPath string, config types.IndexingConfig) (*types.RepositoryIndex, error) {
+	return i.IndexRepositoryContext(context.Background(), repositoryID, localPath, config)
+}
+
+// ************************************************************************************************
+// IndexRepositoryContext indexes a repository like IndexRepository, killing the repomix
+// process and trying no fallback strategy once ctx is cancelled.
+//
+// Returns:
+//   - *types.RepositoryIndex: The indexed repository content.
+//   - error: An error if indexing fails or ctx is cancelled.
+//
+// Example usage:
+//
+//	index, err := indexer.IndexRepositoryContext(ctx, "my-repo", "/path/to/repo", config)
+//	if err != nil {
+//		return fmt.Errorf("failed to index repository: %w", err)
+//	}
+func (i *Indexer) IndexRepositoryContext(ctx context.Context, repositoryID, localPath string, config types.IndexingConfig) (*types.RepositoryIndex, error) {
+	ctx = logging.ContextWith(ctx, "repository", repositoryID)
+	ctx, span := tracing.Start(ctx, "indexer.IndexRepository", tracing.String("repository.id", repositoryID))
+	defer span.End()
+
+	repoIndex, err := i.indexRepository(ctx, repositoryID, localPath, config)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(
+		tracing.String("indexer.strategy", fmt.Sprint(repoIndex.Metadata[types.IndexingStrategyMetadataKey])),
+		tracing.Int("repository.files", len(repoIndex.Files)),
+	)
+	return repoIndex, nil
+}
+
+// indexRepository indexes a repository with its strategy, then its fallback strategies until
+// one succeeds.
+func (i *Indexer) indexRepository(ctx context.Context, repositoryID, localPath string, config types.IndexingConfig) (*types.RepositoryIndex, error) {
 	if repositoryID == "" || localPath == "" {
 		return nil, fmt.Errorf("%w: invalid parameters", types.ErrInvalidConfig)
 	}
 
 	if !config.Enabled {
-		return nil, fmt.Errorf("%w: indexing is disabled", types.ErrIndexingFailed)
+		return nil, fmt.Errorf("%w: %s", types.ErrIndexingDisabled, repositoryID)
 	}
 
 	// Determine indexing strategy
-	strategy := i.DetermineIndexingStrategy(localPath)
+	strategy, err := i.selectIndexingStrategy(localPath, config.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	fallbacks, err := fallbackStrategies(strategy, config.FallbackStrategies)
+	if err != nil {
+		return nil, err
+	}
+
+	var failures []string
+	for index, current := range append([]IndexingStrategy{strategy}, fallbacks...) {
+		repoIndex, err := i.indexWithStrategy(ctx, current, repositoryID, localPath, config)
+		if err == nil {
+			repoIndex.Metadata[types.IndexingStrategyMetadataKey] = current.String()
+			if len(failures) > 0 {
+				repoIndex.Metadata[types.IndexingFallbacksMetadataKey] = failures
+			}
+			return repoIndex, nil
+		}
+
+		if index == len(fallbacks) || ctx.Err() != nil {
+			return nil, err
+		}
+		next := fallbacks[index]
+		slog.WarnContext(ctx, "Indexing failed, falling back", "strategy", current.String(), "fallback", next.String(), "error", err)
+		failures = append(failures, fmt.Sprintf("%s: %v", current, err))
+	}
+	return nil, fmt.Errorf("no indexing strategy available for %s", repositoryID)
+}
+
+// indexWithStrategy indexes a repository with a single strategy, without fallback.
+func (i *Indexer) indexWithStrategy(ctx context.Context, strategy IndexingStrategy, repositoryID, localPath string, config types.IndexingConfig) (repoIndex *types.RepositoryIndex, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("indexing of %s cancelled\n>    %w", repositoryID, err)
+	}
+
+	ctx, span := tracing.Start(ctx, "indexer.indexWithStrategy", tracing.String("indexer.strategy", strategy.String()))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
 
 	switch strategy {
 	case StrategyGoNative:
-		return i.indexRepositoryWithGo(repositoryID, localPath, config)
+		return i.indexRepositoryWithParser("go", i.goParser, repositoryID, localPath, config)
+	case StrategyPythonNative:
+		return i.indexRepositoryWithParser("python", parser.NewPythonParser(), repositoryID, localPath, config)
+	case StrategyTypeScriptNative:
+		return i.indexRepositoryWithParser("typescript", parser.NewTypeScriptParser(), repositoryID, localPath, config)
+	case StrategyRustNative:
+		return i.indexRepositoryWithParser("rust", parser.NewRustParser(), repositoryID, localPath, config)
+	case StrategyJavaNative:
+		return i.indexRepositoryWithParser("java", parser.NewJavaParser(), repositoryID, localPath, config)
+	case StrategyCSharpNative:
+		return i.indexRepositoryWithParser("csharp", parser.NewCSharpParser(), repositoryID, localPath, config)
+	case StrategyTreeSitter:
+		if !parser.TreeSitterAvailable {
+			return nil, fmt.Errorf("%w: tree-sitter grammars need a build with cgo enabled", types.ErrParserUnavailable)
+		}
+		return i.indexRepositoryWithParser("tree-sitter", parser.NewTreeSitterParser(), repositoryID, localPath, config)
 	case StrategyRepomix:
-		return i.indexRepositoryWithRepomix(repositoryID, localPath, config)
+		return i.indexRepositoryWithRepomix(ctx, repositoryID, localPath, config)
 	default:
 		return nil, fmt.Errorf("unknown indexing strategy: %s", strategy.String())
 	}
 }
 
-// indexRepositoryWithGo indexes a Go repository using Go AST parsing.
-func (i *Indexer) indexRepositoryWithGo(repositoryID, localPath string, config types.IndexingConfig) (*types.RepositoryIndex, error) {
-	// Use Go parser for indexing
-	repoIndex, err := i.goParser.ParseRepository(repositoryID, localPath, config)
+// repositoryParser is a native parser producing the repomix-style index of a repository.
+type repositoryParser interface {
+	ParseRepository(repositoryID, localPath string, config types.IndexingConfig) (*types.RepositoryIndex, error)
+}
+
+// indexRepositoryWithParser indexes a repository using a native source parser.
+func (i *Indexer) indexRepositoryWithParser(language string, sourceParser repositoryParser, repositoryID, localPath string, config types.IndexingConfig) (*types.RepositoryIndex, error) {
+	repoIndex, err := sourceParser.ParseRepository(repositoryID, localPath, config)
 	if err != nil {
-		// Fallback to repomix if Go parsing fails
-		fmt.Printf("Go parsing failed for %s, falling back to repomix: %v\n", repositoryID, err)
-		return i.indexRepositoryWithRepomix(repositoryID, localPath, config)
+		return nil, fmt.Errorf("%s parsing failed\n>    %w", language, err)
 	}
 
 	// Write .repomix.xml file to repository directory
@@ -189,31 +460,44 @@ func (i *Indexer) indexRepositoryWithGo(repositoryID, localPath string, config t
 	if xmlFile, exists := repoIndex.Files[".repomix.xml"]; exists {
 		if err := mock_osWriteFile(xmlFilePath, []byte(xmlFile.Content), 0644); err != nil {
 			// Log error but don't fail indexing
-			fmt.Printf("Warning: failed to write .repomix.xml to %s: %v\n", xmlFilePath, err)
+			slog.Warn("Failed to write .repomix.xml", "repository", repositoryID, "path", xmlFilePath, "error", err)
 		}
 	}
 
-	// Discover and add README files from all subfolders
-	readmeFiles, err := i.findReadmeFiles(localPath, repositoryID)
+	// Native parsers only emit constructs, add always-included files verbatim
+	i.addRepositoryContent(repoIndex, localPath, config)
+
+	return repoIndex, nil
+}
+
+// indexRepositoryWithRepomix indexes a repository using the repomix CLI tool, killed when ctx
+// is cancelled.
+func (i *Indexer) indexRepositoryWithRepomix(ctx context.Context, repositoryID, localPath string, config types.IndexingConfig) (*types.RepositoryIndex, error) {
+	// Add include patterns, always-included files must not be filtered out by them
+	var includePatterns []string
+	if len(config.IncludePatterns) > 0 {
+		includePatterns = append(append([]string(nil), config.IncludePatterns...), config.AlwaysInclude...)
+	}
+
+	repoIndex, err := i.runRepomix(ctx, repositoryID, localPath, includePatterns, config)
 	if err != nil {
-		// Log error but don't fail indexing
-		fmt.Printf("Warning: failed to discover README files: %v\n", err)
-	} else {
-		// Add README files to repository index
-		for _, readmeFile := range readmeFiles {
-			repoIndex.Files[readmeFile.Path] = readmeFile
-		}
-		
-		// Update metadata
-		repoIndex.Metadata["readme_count"] = len(readmeFiles)
-		fmt.Printf("Added %d README files to repository index\n", len(readmeFiles))
+		return nil, err
 	}
 
+	// Repomix ignore patterns win over includes, add always-included files it dropped
+	i.addRepositoryContent(repoIndex, localPath, config)
+
 	return repoIndex, nil
 }
 
-// indexRepositoryWithRepomix indexes a repository using the repomix CLI tool.
-func (i *Indexer) indexRepositoryWithRepomix(repositoryID, localPath string, config types.IndexingConfig) (*types.RepositoryIndex, error) {
+// ************************************************************************************************
+// runRepomix runs the repomix CLI on the files of a repository matching include patterns, all
+// files when there is none, and parses its output. The process is killed when ctx is cancelled.
+//
+// Returns:
+//   - *types.RepositoryIndex: The files output by repomix.
+//   - error: An error if repomix fails or its output cannot be parsed.
+func (i *Indexer) runRepomix(ctx context.Context, repositoryID, localPath string, includePatterns []string, config types.IndexingConfig) (*types.RepositoryIndex, error) {
 	// Create output file path
 	outputFile := filepath.Join(i.tempDir, fmt.Sprintf("%s-output.xml", repositoryID))
 
@@ -231,14 +515,17 @@ func (i *Indexer) indexRepositoryWithRepomix(repositoryID, localPath string, con
 		args = append(args, "--compress")
 	}
 
-	// Add include patterns
-	if len(config.IncludePatterns) > 0 {
-		args = append(args, "--include", strings.Join(config.IncludePatterns, ","))
+	if len(includePatterns) > 0 {
+		args = append(args, "--include", strings.Join(includePatterns, ","))
 	}
 
-	// Add exclude patterns
-	if len(config.ExcludePatterns) > 0 {
-		excludePatterns := append(config.ExcludePatterns, ".git", ".git/**")
+	// Add exclude patterns, plus everything untracked when only git-tracked files are wanted
+	excludePatterns := append([]string(nil), config.ExcludePatterns...)
+	if config.GitTrackedOnly {
+		excludePatterns = append(excludePatterns, i.untrackedPatterns(localPath)...)
+	}
+	if len(excludePatterns) > 0 {
+		excludePatterns = append(excludePatterns, ".git", ".git/**")
 		args = append(args, "--ignore", strings.Join(excludePatterns, ","))
 	}
 
@@ -246,10 +533,13 @@ func (i *Indexer) indexRepositoryWithRepomix(repositoryID, localPath string, con
 	args = append(args, localPath)
 
 	// Execute repomix
-	cmd := mock_execCommand(i.repomixPath, args...)
+	cmd := mock_execCommandContext(ctx, i.repomixPath, args...)
 	cmd.Dir = localPath
 
+	_, span := tracing.Start(ctx, "repomix", tracing.String("process.command", i.repomixPath))
 	output, err := cmd.CombinedOutput()
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		return nil, fmt.Errorf("%w: repomix execution failed: %s\n>    %w", types.ErrRepomixExecFailed, string(output), err)
 	}
@@ -261,7 +551,7 @@ func (i *Indexer) indexRepositoryWithRepomix(repositoryID, localPath string, con
 	}
 
 	// Parse repomix output into structured data
-	repoIndex, err := i.parseRepomixOutput(repositoryID, localPath, string(content))
+	repoIndex, err := i.parseRepomixOutput(repositoryID, localPath, string(content), config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse repomix output\n>    %w", err)
 	}
@@ -269,23 +559,257 @@ func (i *Indexer) indexRepositoryWithRepomix(repositoryID, localPath string, con
 	// Clean up output file
 	mock_osRemove(outputFile)
 
+	return repoIndex, nil
+}
+
+// ************************************************************************************************
+// addRepositoryContent adds the content every strategy serves on top of the files it indexed:
+// always-included files, README files from all subfolders, API specifications and Protocol
+// Buffers definitions, then the Markdown sections, directory statistics and work items of the
+// resulting index.
+func (i *Indexer) addRepositoryContent(repoIndex *types.RepositoryIndex, localPath string, config types.IndexingConfig) {
+	i.addAlwaysIncluded(repoIndex, localPath, config)
+
 	// Discover and add README files from all subfolders
-	readmeFiles, err := i.findReadmeFiles(localPath, repositoryID)
+	readmeFiles, err := i.findReadmeFiles(localPath, repoIndex.ID, config.HashAlgorithm)
 	if err != nil {
 		// Log error but don't fail indexing
-		fmt.Printf("Warning: failed to discover README files: %v\n", err)
+		slog.Warn("Failed to discover README files", "repository", repoIndex.ID, "error", err)
 	} else {
 		// Add README files to repository index
 		for _, readmeFile := range readmeFiles {
 			repoIndex.Files[readmeFile.Path] = readmeFile
 		}
-		
+
 		// Update metadata
 		repoIndex.Metadata["readme_count"] = len(readmeFiles)
-		fmt.Printf("Added %d README files to repository index\n", len(readmeFiles))
+		slog.Info("Added README files to repository index", "repository", repoIndex.ID, "count", len(readmeFiles))
 	}
 
-	return repoIndex, nil
+	i.addAPISpecs(repoIndex, localPath, config)
+	i.addProtobufDefinitions(repoIndex, localPath, config)
+	i.addMarkdownSections(repoIndex)
+	i.addChunks(repoIndex, config)
+	i.addDirectoryStats(repoIndex, config)
+	i.addTodos(repoIndex, localPath, config)
+}
+
+// ************************************************************************************************
+// addAlwaysIncluded adds the files matching IndexingConfig.AlwaysInclude that are not
+// already part of the repository index, ignoring exclude patterns and size limits.
+func (i *Indexer) addAlwaysIncluded(repoIndex *types.RepositoryIndex, localPath string, config types.IndexingConfig) {
+	if len(config.AlwaysInclude) == 0 {
+		return
+	}
+
+	added := 0
+	err := filepath.Walk(localPath, func(path string, info mock_osFileInfo, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(localPath, path)
+		if err != nil {
+			return nil
+		}
+		if _, exists := repoIndex.Files[relPath]; exists || !repository.AlwaysIncluded(relPath, info.Size(), config) {
+			return nil
+		}
+
+		content, err := mock_osReadFile(path)
+		if err != nil {
+			slog.Warn("Failed to read always-included file", "repository", repoIndex.ID, "path", path, "error", err)
+			return nil
+		}
+
+		repoIndex.Files[relPath] = types.IndexedFile{
+			Path:         relPath,
+			Content:      string(content),
+			Hash:         i.calculateContentHash(config.HashAlgorithm, string(content)),
+			Size:         info.Size(),
+			ModTime:      info.ModTime(),
+			Language:     i.detectLanguage(relPath),
+			RepositoryID: repoIndex.ID,
+			Metadata: map[string]string{
+				"always_include": "true",
+			},
+		}
+		added++
+		return nil
+	})
+	if err != nil {
+		slog.Warn("Failed to discover always-included files", "repository", repoIndex.ID, "error", err)
+	}
+
+	if added > 0 {
+		slog.Info("Added always-included files to repository index", "repository", repoIndex.ID, "count", added)
+	}
+}
+
+// ************************************************************************************************
+// addAPISpecs tags the OpenAPI/Swagger specifications matching IndexingConfig.APISpecFiles and
+// records their paths in the repository metadata. Specifications the indexing strategy left out,
+// such as the YAML files of a Go-native index, are read from disk up to the always-include size
+// limit.
+func (i *Indexer) addAPISpecs(repoIndex *types.RepositoryIndex, localPath string, config types.IndexingConfig) {
+	var specs []string
+	err := filepath.Walk(localPath, func(path string, info mock_osFileInfo, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != localPath && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(localPath, path)
+		if err != nil || !types.IsAPISpecFile(relPath, config.APISpecFiles) {
+			return nil
+		}
+
+		file, exists := repoIndex.Files[relPath]
+		if !exists {
+			if info.Size() > repository.AlwaysIncludeMaxSize {
+				slog.Warn("Skipping large API specification", "repository", repoIndex.ID, "path", path, "size", info.Size())
+				return nil
+			}
+			content, err := mock_osReadFile(path)
+			if err != nil {
+				slog.Warn("Failed to read API specification", "repository", repoIndex.ID, "path", path, "error", err)
+				return nil
+			}
+			file = types.IndexedFile{
+				Path:         relPath,
+				Content:      string(content),
+				Hash:         i.calculateContentHash(config.HashAlgorithm, string(content)),
+				Size:         info.Size(),
+				ModTime:      info.ModTime(),
+				Language:     i.detectLanguage(relPath),
+				RepositoryID: repoIndex.ID,
+			}
+		}
+		if file.Metadata == nil {
+			file.Metadata = make(map[string]string)
+		}
+		file.Metadata["file_type"] = types.APISpecFileType
+		repoIndex.Files[relPath] = file
+
+		specs = append(specs, relPath)
+		return nil
+	})
+	if err != nil {
+		slog.Warn("Failed to discover API specifications", "repository", repoIndex.ID, "error", err)
+	}
+
+	if len(specs) > 0 {
+		sort.Strings(specs)
+		repoIndex.Metadata[types.APISpecsMetadataKey] = specs
+		slog.Info("Found API specifications", "repository", repoIndex.ID, "count", len(specs))
+	}
+}
+
+// ************************************************************************************************
+// addProtobufDefinitions renders the services, rpcs, messages and enums of the .proto files of a
+// repository as the parser.ProtobufDefinitionsFile file and adds them to its symbol index,
+// whatever the indexing strategy.
+func (i *Indexer) addProtobufDefinitions(repoIndex *types.RepositoryIndex, localPath string, config types.IndexingConfig) {
+	protoIndex, err := parser.NewProtobufParser().ParseRepository(repoIndex.ID, localPath, config)
+	if err != nil {
+		slog.Debug("No Protocol Buffers definitions indexed", "repository", repoIndex.ID, "error", err)
+		return
+	}
+
+	file := protoIndex.Files[".repomix.xml"]
+	file.Path = parser.ProtobufDefinitionsFile
+	repoIndex.Files[parser.ProtobufDefinitionsFile] = file
+	repoIndex.Metadata["protobuf_files_count"] = protoIndex.Metadata["file_count"]
+
+	protoSymbols, _ := protoIndex.Metadata[types.SymbolsMetadataKey].([]types.Symbol)
+	var symbols []types.Symbol
+	if value, exists := repoIndex.Metadata[types.SymbolsMetadataKey]; exists {
+		if symbols, err = types.DecodeSymbols(value); err != nil {
+			slog.Warn("Failed to merge Protocol Buffers symbols", "repository", repoIndex.ID, "error", err)
+			return
+		}
+	}
+	symbols = append(slices.Clip(symbols), protoSymbols...)
+	sort.SliceStable(symbols, func(a, b int) bool {
+		return symbols[a].QualifiedName() < symbols[b].QualifiedName()
+	})
+	repoIndex.Metadata[types.SymbolsMetadataKey] = symbols
+	slog.Info("Indexed Protocol Buffers definitions", "repository", repoIndex.ID, "files", protoIndex.Metadata["file_count"], "symbols", len(protoSymbols))
+}
+
+// ************************************************************************************************
+// addMarkdownSections records the headings, code blocks and links of every indexed Markdown file
+// in its metadata, so documentation can be served by section.
+func (i *Indexer) addMarkdownSections(repoIndex *types.RepositoryIndex) {
+	for path, file := range repoIndex.Files {
+		if !slices.Contains(parser.MarkdownExtensions, strings.ToLower(filepath.Ext(path))) {
+			continue
+		}
+		sections := parser.ParseMarkdown(file.Content)
+		if len(sections) == 0 {
+			continue
+		}
+		if file.Metadata == nil {
+			file.Metadata = make(map[string]string)
+		}
+		file.Metadata[types.MarkdownSectionsMetadataKey] = types.FormatMarkdownSections(sections)
+		repoIndex.Files[path] = file
+	}
+}
+
+// ************************************************************************************************
+// addChunks splits the indexed files longer than IndexingConfig.ChunkMaxLines into chunks
+// following their constructs or sections, recorded in the file metadata. Chunks of a file
+// indexed before are replaced, its content having changed.
+func (i *Indexer) addChunks(repoIndex *types.RepositoryIndex, config types.IndexingConfig) {
+	chunkedFiles := 0
+	for path, file := range repoIndex.Files {
+		delete(file.Metadata, types.ChunksMetadataKey)
+		chunks := parser.ChunkFile(path, file.Content, config.ChunkMaxLines, config.ChunkOverlap)
+		if len(chunks) == 0 {
+			continue
+		}
+		if file.Metadata == nil {
+			file.Metadata = make(map[string]string)
+		}
+		file.Metadata[types.ChunksMetadataKey] = types.FormatChunks(chunks)
+		repoIndex.Files[path] = file
+		chunkedFiles++
+	}
+	repoIndex.Metadata["chunked_files_count"] = chunkedFiles
+}
+
+// ************************************************************************************************
+// untrackedPatterns returns ignore patterns for the untracked content of a git repository.
+// Non-git directories and failures yield no patterns, so every file is indexed.
+//
+// Returns:
+//   - []string: The ignore patterns.
+func (i *Indexer) untrackedPatterns(localPath string) []string {
+	tracked, err := repository.TrackedFiles(localPath)
+	if err != nil {
+		slog.Warn("Ignoring gitTrackedOnly", "path", localPath, "error", err)
+		return nil
+	}
+
+	patterns, err := repository.UntrackedPatterns(localPath, tracked)
+	if err != nil {
+		slog.Warn("Failed to list untracked files", "path", localPath, "error", err)
+		return nil
+	}
+	return patterns
 }
 
 // ************************************************************************************************
@@ -295,7 +819,7 @@ func (i *Indexer) indexRepositoryWithRepomix(repositoryID, localPath string, con
 // Returns:
 //   - *types.RepositoryIndex: The parsed repository index.
 //   - error: An error if parsing fails.
-func (i *Indexer) parseRepomixOutput(repositoryID, localPath, content string) (*types.RepositoryIndex, error) {
+func (i *Indexer) parseRepomixOutput(repositoryID, localPath, content string, config types.IndexingConfig) (*types.RepositoryIndex, error) {
 	repoIndex := &types.RepositoryIndex{
 		ID:          repositoryID,
 		Name:        repositoryID,
@@ -318,14 +842,19 @@ func (i *Indexer) parseRepomixOutput(repositoryID, localPath, content string) (*
 		indexedFile := types.IndexedFile{
 			Path:         file.Path,
 			Content:      file.Content,
-			Hash:         i.calculateContentHash(file.Content),
+			Hash:         fileHash(config.HashAlgorithm, localPath, file.Path, file.Content),
 			Size:         int64(len(file.Content)),
-			ModTime:      mock_timeNow(),
+			ModTime:      fileModTime(localPath, file.Path),
 			Language:     i.detectLanguage(file.Path),
 			RepositoryID: repositoryID,
 			Metadata:     make(map[string]string),
 		}
 
+		// Tag minified files so search and docs can push them down
+		if i.isMinified(file.Content, config.MinifiedAvgLine, config.MinifiedMaxLine) {
+			indexedFile.Metadata["minified"] = "true"
+		}
+
 		repoIndex.Files[file.Path] = indexedFile
 	}
 
@@ -337,6 +866,119 @@ func (i *Indexer) parseRepomixOutput(repositoryID, localPath, content string) (*
 	return repoIndex, nil
 }
 
+// ************************************************************************************************
+// addDirectoryStats records per-directory file counts, sizes and languages in the repository
+// metadata when IndexingConfig.DirectoryStats is set. The Go parser already rolled up the Go
+// sources it parsed, so only the files served verbatim are added on top of its statistics.
+func (i *Indexer) addDirectoryStats(repoIndex *types.RepositoryIndex, config types.IndexingConfig) {
+	if !config.DirectoryStats {
+		return
+	}
+
+	stats, parsedGo := repoIndex.Metadata[types.DirectoryStatsMetadataKey].(map[string]*types.DirectoryStats)
+	if !parsedGo {
+		stats = make(map[string]*types.DirectoryStats)
+	}
+
+	for path, file := range repoIndex.Files {
+		// Skip the synthetic Go construct summary and sources the parser already counted
+		if file.Metadata["indexer_type"] == "go_native" || (parsedGo && file.Language == "go") {
+			continue
+		}
+		types.AddDirectoryStat(stats, path, file.Language, file.Size)
+	}
+
+	repoIndex.Metadata[types.DirectoryStatsMetadataKey] = stats
+}
+
+// ************************************************************************************************
+// addTodos collects the work item markers (TODO, FIXME...) of the indexed files into the
+// repository metadata. Files are read from disk because repomix output has comments removed;
+// Go sources already scanned by the Go parser are skipped.
+func (i *Indexer) addTodos(repoIndex *types.RepositoryIndex, localPath string, config types.IndexingConfig) {
+	todos, parsedGo := repoIndex.Metadata[types.TodosMetadataKey].([]types.TodoItem)
+
+	for path, file := range repoIndex.Files {
+		if file.Metadata["indexer_type"] == "go_native" || (parsedGo && file.Language == "go") {
+			continue
+		}
+
+		content := file.Content
+		if data, err := mock_osReadFile(filepath.Join(localPath, filepath.FromSlash(path))); err == nil {
+			content = string(data)
+		}
+		todos = append(todos, types.ScanTodos(path, content, config.TodoMarkers)...)
+	}
+
+	sort.Slice(todos, func(a, b int) bool {
+		if todos[a].File != todos[b].File {
+			return todos[a].File < todos[b].File
+		}
+		return todos[a].Line < todos[b].Line
+	})
+	repoIndex.Metadata[types.TodosMetadataKey] = todos
+}
+
+// ************************************************************************************************
+// fileModTime returns the modification time of a repository file on disk. Repomix output
+// carries no timestamps, so files that cannot be stat'ed fall back to the indexing time.
+func fileModTime(localPath, relPath string) time.Time {
+	info, err := mock_osStat(filepath.Join(localPath, filepath.FromSlash(relPath)))
+	if err != nil {
+		return mock_timeNow()
+	}
+	return info.ModTime()
+}
+
+// ************************************************************************************************
+// fileHash returns the content hash of a repository file on disk, so cached hashes can be
+// compared with the working tree. Repomix may compress or reformat the content it outputs;
+// files that cannot be read fall back to the hash of that content.
+func fileHash(algorithm, localPath, relPath, content string) string {
+	data, err := mock_osReadFile(filepath.Join(localPath, filepath.FromSlash(relPath)))
+	if err != nil {
+		return types.HashContent(algorithm, content)
+	}
+	return types.HashContent(algorithm, string(data))
+}
+
+// ************************************************************************************************
+// Default minified detection thresholds, used when the IndexingConfig values are zero.
+const (
+	defaultMinifiedAvgLine = 300
+	defaultMinifiedMaxLine = 5000
+)
+
+// ************************************************************************************************
+// isMinified reports whether content looks minified: its average line length exceeds
+// avgThreshold or its longest line exceeds maxThreshold. A zero threshold uses the
+// default and a negative threshold disables that check.
+//
+// Returns:
+//   - bool: True if the content should be treated as minified.
+func (i *Indexer) isMinified(content string, avgThreshold, maxThreshold int) bool {
+	if content == "" {
+		return false
+	}
+	if avgThreshold == 0 {
+		avgThreshold = defaultMinifiedAvgLine
+	}
+	if maxThreshold == 0 {
+		maxThreshold = defaultMinifiedMaxLine
+	}
+
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	maxLength := 0
+	for _, line := range lines {
+		if len(line) > maxLength {
+			maxLength = len(line)
+		}
+	}
+	average := len(content) / len(lines)
+
+	return (avgThreshold > 0 && average > avgThreshold) || (maxThreshold > 0 && maxLength > maxThreshold)
+}
+
 // ************************************************************************************************
 // FileContent represents a file extracted from repomix output.
 type FileContent struct {
@@ -409,21 +1051,12 @@ func (i *Indexer) extractFilesFromXML(content string) ([]FileContent, error) {
 }
 
 // ************************************************************************************************
-// calculateContentHash generates a simple hash for content change detection.
+// calculateContentHash hashes content for change detection with the configured algorithm.
 //
 // Returns:
-//   - string: The content hash.
-func (i *Indexer) calculateContentHash(content string) string {
-	// Simple hash based on content length and first/last characters
-	// In production, you might want to use a proper hash function like SHA256
-	if len(content) == 0 {
-		return "empty"
-	}
-	
-	first := content[0]
-	last := content[len(content)-1]
-	
-	return fmt.Sprintf("%d_%c_%c", len(content), first, last)
+//   - string: The content hash, prefixed with the algorithm name.
+func (i *Indexer) calculateContentHash(algorithm, content string) string {
+	return types.HashContent(algorithm, content)
 }
 
 // ************************************************************************************************
@@ -529,11 +1162,11 @@ func (i *Indexer) ValidateRepomix() error {
 //
 // Example usage:
 //
-//	file, err := indexer.IndexSingleFile("/path/to/repo", "src/main.go")
+//	file, err := indexer.IndexSingleFile("/path/to/repo", "src/main.go", config.HashAlgorithm)
 //	if err != nil {
 //		return fmt.Errorf("failed to index file: %w", err)
 //	}
-func (i *Indexer) IndexSingleFile(repositoryPath, filePath string) (*types.IndexedFile, error) {
+func (i *Indexer) IndexSingleFile(repositoryPath, filePath, hashAlgorithm string) (*types.IndexedFile, error) {
 	if repositoryPath == "" || filePath == "" {
 		return nil, fmt.Errorf("%w: invalid parameters", types.ErrInvalidConfig)
 	}
@@ -561,7 +1194,7 @@ func (i *Indexer) IndexSingleFile(repositoryPath, filePath string) (*types.Index
 	indexedFile := &types.IndexedFile{
 		Path:         filePath,
 		Content:      string(content),
-		Hash:         i.calculateContentHash(string(content)),
+		Hash:         i.calculateContentHash(hashAlgorithm, string(content)),
 		Size:         fileInfo.Size(),
 		ModTime:      fileInfo.ModTime(),
 		Language:     i.detectLanguage(filePath),
@@ -582,11 +1215,11 @@ func (i *Indexer) IndexSingleFile(repositoryPath, filePath string) (*types.Index
 //
 // Example usage:
 //
-//	readmeFiles, err := indexer.findReadmeFiles("/path/to/repo", "repo-id")
+//	readmeFiles, err := indexer.findReadmeFiles("/path/to/repo", "repo-id", config.HashAlgorithm)
 //	if err != nil {
 //		return fmt.Errorf("failed to find README files: %w", err)
 //	}
-func (i *Indexer) findReadmeFiles(localPath, repositoryID string) ([]types.IndexedFile, error) {
+func (i *Indexer) findReadmeFiles(localPath, repositoryID, hashAlgorithm string) ([]types.IndexedFile, error) {
 	if localPath == "" || repositoryID == "" {
 		return nil, fmt.Errorf("%w: invalid parameters", types.ErrInvalidConfig)
 	}
@@ -609,7 +1242,7 @@ func (i *Indexer) findReadmeFiles(localPath, repositoryID string) ([]types.Index
 	err := filepath.Walk(localPath, func(path string, info mock_osFileInfo, err error) error {
 		if err != nil {
 			// Log error but continue processing
-			fmt.Printf("Warning: error accessing %s: %v\n", path, err)
+			slog.Warn("Failed to access path", "repository", repositoryID, "path", path, "error", err)
 			return nil
 		}
 
@@ -656,21 +1289,21 @@ func (i *Indexer) findReadmeFiles(localPath, repositoryID string) ([]types.Index
 
 		// Check file size
 		if info.Size() > maxFileSize {
-			fmt.Printf("Warning: skipping large README file %s (%d bytes)\n", path, info.Size())
+			slog.Warn("Skipping large README file", "repository", repositoryID, "path", path, "size", info.Size())
 			return nil
 		}
 
 		// Calculate relative path from repository root
 		relPath, err := filepath.Rel(localPath, path)
 		if err != nil {
-			fmt.Printf("Warning: failed to calculate relative path for %s: %v\n", path, err)
+			slog.Warn("Failed to calculate relative path", "repository", repositoryID, "path", path, "error", err)
 			return nil
 		}
 
 		// Read file content
 		content, err := mock_osReadFile(path)
 		if err != nil {
-			fmt.Printf("Warning: failed to read README file %s: %v\n", path, err)
+			slog.Warn("Failed to read README file", "repository", repositoryID, "path", path, "error", err)
 			return nil
 		}
 
@@ -678,7 +1311,7 @@ func (i *Indexer) findReadmeFiles(localPath, repositoryID string) ([]types.Index
 		indexedFile := types.IndexedFile{
 			Path:         relPath,
 			Content:      string(content),
-			Hash:         i.calculateContentHash(string(content)),
+			Hash:         i.calculateContentHash(hashAlgorithm, string(content)),
 			Size:         info.Size(),
 			ModTime:      info.ModTime(),
 			Language:     i.detectLanguage(relPath),
@@ -696,7 +1329,7 @@ func (i *Indexer) findReadmeFiles(localPath, repositoryID string) ([]types.Index
 
 		readmeFiles = append(readmeFiles, indexedFile)
 		
-		fmt.Printf("Discovered README file: %s (size: %d bytes)\n", relPath, info.Size())
+		slog.Debug("Discovered README file", "repository", repositoryID, "path", relPath, "size", info.Size())
 		return nil
 	})
 
@@ -704,6 +1337,6 @@ func (i *Indexer) findReadmeFiles(localPath, repositoryID string) ([]types.Index
 		return nil, fmt.Errorf("failed to walk directory tree: %w", err)
 	}
 
-	fmt.Printf("Found %d README files in repository %s\n", len(readmeFiles), repositoryID)
+	slog.Info("Found README files", "repository", repositoryID, "count", len(readmeFiles))
 	return readmeFiles, nil
 }
\ No newline at end of file
@@ -0,0 +1,580 @@
+// ************************************************************************************************
+// Package indexer - Unit tests for repository indexing.
+// This file covers minified file detection, always-included files, disabled indexing,
+// work item collection, strategy selection, fallback and incremental re-indexing.
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"repomix-mcp/internal/parser"
+	"repomix-mcp/pkg/types"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ************************************************************************************************
+// Test that minified content is detected by average and longest line length
+func TestIndexer_isMinified(t *testing.T) {
+	indexer := &Indexer{}
+
+	normalSource := strings.Repeat("function add(a, b) {\n  return a + b;\n}\n", 50)
+	minifiedSource := strings.Repeat("var a=function(b,c){return b+c};", 400)
+	longLineSource := strings.Repeat("short line\n", 200) + strings.Repeat("x", 6000) + "\n"
+
+	tests := []struct {
+		name     string
+		content  string
+		avg, max int
+		expected bool
+	}{
+		{"normal source", normalSource, 0, 0, false},
+		{"minified source", minifiedSource, 0, 0, true},
+		{"single huge line among short ones", longLineSource, 0, 0, true},
+		{"custom average threshold", normalSource, 10, 0, true},
+		{"both checks disabled", minifiedSource, -1, -1, false},
+		{"empty content", "", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := indexer.isMinified(tt.content, tt.avg, tt.max); result != tt.expected {
+				t.Errorf("isMinified() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+// ************************************************************************************************
+// Test that repomix output parsing tags minified files
+func TestIndexer_parseRepomixOutput_TagsMinified(t *testing.T) {
+	indexer := &Indexer{}
+
+	output := "<file path=\"dist/app.min.js\">\n" +
+		strings.Repeat("var a=function(b,c){return b+c};", 400) + "\n" +
+		"</file>\n" +
+		"<file path=\"src/app.js\">\n" +
+		"function add(a, b) {\n  return a + b;\n}\n" +
+		"</file>\n"
+
+	repoIndex, err := indexer.parseRepomixOutput("test-repo", "/tmp/test-repo", output, types.IndexingConfig{})
+	if err != nil {
+		t.Fatalf("parseRepomixOutput failed: %v", err)
+	}
+
+	if repoIndex.Files["dist/app.min.js"].Metadata["minified"] != "true" {
+		t.Error("Expected dist/app.min.js to be tagged minified")
+	}
+	if _, tagged := repoIndex.Files["src/app.js"].Metadata["minified"]; tagged {
+		t.Error("Expected src/app.js not to be tagged minified")
+	}
+}
+
+// ************************************************************************************************
+// Test that repomix-indexed files carry their modification time from disk
+func TestIndexer_parseRepomixOutput_ModTime(t *testing.T) {
+	indexer := &Indexer{}
+	localPath := t.TempDir()
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.MkdirAll(filepath.Join(localPath, "src"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(localPath, "src", "app.js"), []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Chtimes(filepath.Join(localPath, "src", "app.js"), modTime, modTime); err != nil {
+		t.Fatalf("Failed to set file time: %v", err)
+	}
+
+	output := "<file path=\"src/app.js\">\nx\n</file>\n" +
+		"<file path=\"src/gone.js\">\ny\n</file>\n"
+
+	before := time.Now()
+	repoIndex, err := indexer.parseRepomixOutput("test-repo", localPath, output, types.IndexingConfig{})
+	if err != nil {
+		t.Fatalf("parseRepomixOutput failed: %v", err)
+	}
+
+	if got := repoIndex.Files["src/app.js"].ModTime; !got.Equal(modTime) {
+		t.Errorf("Expected ModTime %v from disk, got %v", modTime, got)
+	}
+	if got := repoIndex.Files["src/gone.js"].ModTime; got.Before(before) {
+		t.Errorf("Expected a missing file to fall back to the indexing time, got %v", got)
+	}
+}
+
+// ************************************************************************************************
+// Test that always-included files are added even when indexing dropped them
+func TestIndexer_addAlwaysIncluded(t *testing.T) {
+	tempDir := t.TempDir()
+	for name, content := range map[string]string{"LICENSE": "MIT", "main.go": "package main", "notes.txt": "todo"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	indexer := &Indexer{}
+	repoIndex := &types.RepositoryIndex{
+		ID:    "test-repo",
+		Files: map[string]types.IndexedFile{"main.go": {Path: "main.go", Content: "package main"}},
+	}
+	config := types.IndexingConfig{ExcludePatterns: []string{"LICENSE"}, AlwaysInclude: []string{"LICENSE"}}
+
+	indexer.addAlwaysIncluded(repoIndex, tempDir, config)
+
+	license, exists := repoIndex.Files["LICENSE"]
+	if !exists || license.Content != "MIT" {
+		t.Fatalf("Expected LICENSE to be added, got %+v", repoIndex.Files)
+	}
+	if license.Metadata["always_include"] != "true" {
+		t.Error("Expected always_include metadata on LICENSE")
+	}
+	if _, exists := repoIndex.Files["notes.txt"]; exists {
+		t.Error("Expected notes.txt not to be added")
+	}
+}
+
+// ************************************************************************************************
+// Test that a disabled indexing configuration reports ErrIndexingDisabled rather than a failure
+func TestIndexer_IndexRepository_Disabled(t *testing.T) {
+	indexer := &Indexer{}
+
+	_, err := indexer.IndexRepository("my-repo", t.TempDir(), types.IndexingConfig{Enabled: false})
+	if !errors.Is(err, types.ErrIndexingDisabled) {
+		t.Errorf("Expected ErrIndexingDisabled, got %v", err)
+	}
+	if errors.Is(err, types.ErrIndexingFailed) {
+		t.Error("Expected a disabled repository not to be reported as an indexing failure")
+	}
+}
+
+// ************************************************************************************************
+// Test that work items are read from disk, since repomix output has comments removed
+func TestIndexer_addTodos(t *testing.T) {
+	indexer := &Indexer{}
+	localPath := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(localPath, "app.js"), []byte("// FIXME: leak\nrun();\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	repoIndex := &types.RepositoryIndex{
+		Files: map[string]types.IndexedFile{
+			"app.js":    {Path: "app.js", Content: "run();\n", Language: "javascript"},
+			"README.md": {Path: "README.md", Content: "XXX: document flags\n", Language: "markdown"},
+		},
+		Metadata: make(map[string]interface{}),
+	}
+	indexer.addTodos(repoIndex, localPath, types.IndexingConfig{})
+
+	todos, ok := repoIndex.Metadata[types.TodosMetadataKey].([]types.TodoItem)
+	if !ok || len(todos) != 2 {
+		t.Fatalf("Expected 2 work items, got %v", repoIndex.Metadata[types.TodosMetadataKey])
+	}
+	if todos[0].File != "README.md" || todos[0].Marker != "XXX" {
+		t.Errorf("Expected the in-memory README item first, got %+v", todos[0])
+	}
+	if todos[1].File != "app.js" || todos[1].Marker != "FIXME" || todos[1].Line != 1 {
+		t.Errorf("Expected the on-disk FIXME in app.js, got %+v", todos[1])
+	}
+}
+
+// ************************************************************************************************
+// Test that a configured strategy overrides detection and unknown names are rejected
+func TestIndexer_selectIndexingStrategy(t *testing.T) {
+	indexer := &Indexer{}
+	goRepo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(goRepo, "go.mod"), []byte("module example.com/app\n"), 0644); err != nil {
+		t.Fatalf("Failed to write go.mod: %v", err)
+	}
+	pythonRepo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(pythonRepo, "pyproject.toml"), []byte("[project]\nname = \"app\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write pyproject.toml: %v", err)
+	}
+	nodeRepo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(nodeRepo, "package.json"), []byte(`{"name": "web"}`), 0644); err != nil {
+		t.Fatalf("Failed to write package.json: %v", err)
+	}
+	rustRepo := t.TempDir()
+	for _, name := range []string{"Cargo.toml", "package.json"} {
+		if err := os.WriteFile(filepath.Join(rustRepo, name), []byte("\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	javaRepo := t.TempDir()
+	for _, name := range []string{"build.gradle.kts", "package.json"} {
+		if err := os.WriteFile(filepath.Join(javaRepo, name), []byte("\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	csharpRepo := t.TempDir()
+	for _, name := range []string{"Billing.sln", "package.json"} {
+		if err := os.WriteFile(filepath.Join(csharpRepo, name), []byte("\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	cRepo := t.TempDir()
+	for _, name := range []string{"main.c", "util.c", "util.h"} {
+		if err := os.WriteFile(filepath.Join(cRepo, name), []byte("\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	cStrategy := StrategyRepomix
+	if parser.TreeSitterAvailable {
+		cStrategy = StrategyTreeSitter
+	}
+	plainRepo := t.TempDir()
+
+	tests := []struct {
+		localPath string
+		name      string
+		expected  IndexingStrategy
+	}{
+		{goRepo, "", StrategyGoNative},
+		{goRepo, types.IndexingStrategyAuto, StrategyGoNative},
+		{goRepo, types.IndexingStrategyRepomix, StrategyRepomix},
+		{plainRepo, "", StrategyRepomix},
+		{plainRepo, types.IndexingStrategyGoNative, StrategyGoNative},
+		{pythonRepo, "", StrategyPythonNative},
+		{plainRepo, types.IndexingStrategyPythonNative, StrategyPythonNative},
+		{nodeRepo, "", StrategyTypeScriptNative},
+		{nodeRepo, types.IndexingStrategyRepomix, StrategyRepomix},
+		{rustRepo, "", StrategyRustNative},
+		{plainRepo, types.IndexingStrategyRustNative, StrategyRustNative},
+		{javaRepo, "", StrategyJavaNative},
+		{plainRepo, types.IndexingStrategyJavaNative, StrategyJavaNative},
+		{csharpRepo, "", StrategyCSharpNative},
+		{plainRepo, types.IndexingStrategyCSharpNative, StrategyCSharpNative},
+		{cRepo, "", cStrategy},
+		{cRepo, types.IndexingStrategyRepomix, StrategyRepomix},
+		{plainRepo, types.IndexingStrategyTreeSitter, StrategyTreeSitter},
+	}
+	for _, tt := range tests {
+		strategy, err := indexer.selectIndexingStrategy(tt.localPath, tt.name)
+		if err != nil || strategy != tt.expected {
+			t.Errorf("selectIndexingStrategy(%q) = %s, %v; expected %s", tt.name, strategy, err, tt.expected)
+		}
+	}
+
+	if _, err := indexer.selectIndexingStrategy(goRepo, "tree-sitter"); !errors.Is(err, types.ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for an unknown strategy, got %v", err)
+	}
+}
+
+// ************************************************************************************************
+// Test the default and configured fallback chains
+func TestFallbackStrategies(t *testing.T) {
+	tests := []struct {
+		primary  IndexingStrategy
+		names    []string
+		expected []IndexingStrategy
+	}{
+		{StrategyGoNative, nil, []IndexingStrategy{StrategyRepomix}},
+		{StrategyRepomix, nil, nil},
+		{StrategyPythonNative, nil, []IndexingStrategy{StrategyRepomix}},
+		{StrategyTypeScriptNative, nil, []IndexingStrategy{StrategyRepomix}},
+		{StrategyRustNative, nil, []IndexingStrategy{StrategyRepomix}},
+		{StrategyJavaNative, nil, []IndexingStrategy{StrategyRepomix}},
+		{StrategyCSharpNative, nil, []IndexingStrategy{StrategyRepomix}},
+		{StrategyTreeSitter, nil, []IndexingStrategy{StrategyRepomix}},
+		{StrategyPythonNative, []string{types.IndexingStrategyGoNative}, []IndexingStrategy{StrategyGoNative}},
+		{StrategyGoNative, []string{types.IndexingStrategyNone}, nil},
+		{StrategyRepomix, []string{types.IndexingStrategyGoNative}, []IndexingStrategy{StrategyGoNative}},
+		{StrategyGoNative, []string{types.IndexingStrategyGoNative, types.IndexingStrategyRepomix, types.IndexingStrategyRepomix}, []IndexingStrategy{StrategyRepomix}},
+	}
+	for _, tt := range tests {
+		chain, err := fallbackStrategies(tt.primary, tt.names)
+		if err != nil || fmt.Sprint(chain) != fmt.Sprint(tt.expected) {
+			t.Errorf("fallbackStrategies(%s, %v) = %v, %v; expected %v", tt.primary, tt.names, chain, err, tt.expected)
+		}
+	}
+
+	if _, err := fallbackStrategies(StrategyRepomix, []string{"auto"}); !errors.Is(err, types.ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for an unknown fallback strategy, got %v", err)
+	}
+}
+
+// ************************************************************************************************
+// Test that a failed strategy falls back to the configured one and records the outcome
+func TestIndexer_IndexRepositoryFallback(t *testing.T) {
+	indexer := &Indexer{
+		repomixPath: filepath.Join(t.TempDir(), "missing-repomix"),
+		tempDir:     t.TempDir(),
+		goParser:    parser.NewGoParser(),
+	}
+	repoPath := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.21\n",
+		"main.go": "package main\n\n// Run starts the application.\nfunc Run() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	config := types.IndexingConfig{
+		Enabled:            true,
+		Strategy:           types.IndexingStrategyRepomix,
+		FallbackStrategies: []string{types.IndexingStrategyGoNative},
+	}
+	repoIndex, err := indexer.IndexRepository("app", repoPath, config)
+	if err != nil {
+		t.Fatalf("Expected the Go fallback to succeed, got %v", err)
+	}
+	if repoIndex.Metadata[types.IndexingStrategyMetadataKey] != "go_native" {
+		t.Errorf("Expected indexing_strategy go_native, got %v", repoIndex.Metadata[types.IndexingStrategyMetadataKey])
+	}
+	failures, _ := repoIndex.Metadata[types.IndexingFallbacksMetadataKey].([]string)
+	if len(failures) != 1 || !strings.HasPrefix(failures[0], "repomix: ") {
+		t.Errorf("Expected the repomix failure to be recorded, got %v", failures)
+	}
+
+	config.FallbackStrategies = []string{types.IndexingStrategyNone}
+	if _, err := indexer.IndexRepository("app", repoPath, config); err == nil {
+		t.Error("Expected an error with fallbacks disabled")
+	}
+}
+
+// ************************************************************************************************
+// Test that specifications are tagged, added when missing from the index and listed in metadata
+func TestIndexer_addAPISpecs(t *testing.T) {
+	localPath := t.TempDir()
+	files := map[string]string{
+		"openapi.yaml":                "openapi: 3.0.0",
+		"api/v2/swagger.json":         "{}",
+		"node_modules/x/openapi.yaml": "openapi: 3.0.0",
+		"main.go":                     "package main",
+	}
+	for name, content := range files {
+		path := filepath.Join(localPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	indexer := &Indexer{}
+	repoIndex := &types.RepositoryIndex{
+		ID:       "test-repo",
+		Files:    map[string]types.IndexedFile{"openapi.yaml": {Path: "openapi.yaml", Content: "openapi: 3.0.0"}},
+		Metadata: make(map[string]interface{}),
+	}
+
+	indexer.addAPISpecs(repoIndex, localPath, types.IndexingConfig{})
+
+	swaggerPath := filepath.Join("api", "v2", "swagger.json")
+	specs, _ := repoIndex.Metadata[types.APISpecsMetadataKey].([]string)
+	if len(specs) != 2 || specs[0] != swaggerPath || specs[1] != "openapi.yaml" {
+		t.Fatalf("Expected the two specifications outside node_modules, got %v", specs)
+	}
+	for _, spec := range specs {
+		if repoIndex.Files[spec].Metadata["file_type"] != types.APISpecFileType {
+			t.Errorf("Expected %s to be tagged as an API specification, got %+v", spec, repoIndex.Files[spec])
+		}
+	}
+	if repoIndex.Files[swaggerPath].Content != "{}" {
+		t.Errorf("Expected the missing specification to be read from disk, got %+v", repoIndex.Files[swaggerPath])
+	}
+}
+
+// ************************************************************************************************
+// Test that proto definitions are rendered and merged into the symbols of the strategy
+func TestIndexer_addProtobufDefinitions(t *testing.T) {
+	localPath := t.TempDir()
+	proto := "package billing;\n\n// Bills customers.\nservice Billing {\n  rpc Pay(PayRequest) returns (PayResponse);\n}\n"
+	if err := os.WriteFile(filepath.Join(localPath, "billing.proto"), []byte(proto), 0644); err != nil {
+		t.Fatalf("Failed to write billing.proto: %v", err)
+	}
+
+	indexer := &Indexer{}
+	repoIndex := &types.RepositoryIndex{
+		ID:    "test-repo",
+		Files: make(map[string]types.IndexedFile),
+		Metadata: map[string]interface{}{
+			types.SymbolsMetadataKey: []types.Symbol{{Name: "Client", Kind: "struct", Package: "example.com/billing"}},
+		},
+	}
+
+	indexer.addProtobufDefinitions(repoIndex, localPath, types.IndexingConfig{})
+
+	file, exists := repoIndex.Files[parser.ProtobufDefinitionsFile]
+	if !exists || file.Path != parser.ProtobufDefinitionsFile || !strings.Contains(file.Content, "rpc Pay(PayRequest) returns (PayResponse)") {
+		t.Fatalf("Expected the rendered definitions in %s, got %+v", parser.ProtobufDefinitionsFile, file)
+	}
+	if repoIndex.Metadata["protobuf_files_count"] != 1 {
+		t.Errorf("Expected protobuf_files_count 1, got %v", repoIndex.Metadata["protobuf_files_count"])
+	}
+
+	symbols, _ := repoIndex.Metadata[types.SymbolsMetadataKey].([]types.Symbol)
+	var names []string
+	for _, symbol := range symbols {
+		names = append(names, symbol.QualifiedName())
+	}
+	if got := strings.Join(names, ","); got != "billing.Billing,billing.Billing.Pay,example.com/billing.Client" {
+		t.Errorf("Expected the proto symbols merged with the strategy ones, got %s", got)
+	}
+
+	// Repositories without proto files are left unchanged
+	empty := &types.RepositoryIndex{ID: "empty", Files: make(map[string]types.IndexedFile), Metadata: make(map[string]interface{})}
+	indexer.addProtobufDefinitions(empty, t.TempDir(), types.IndexingConfig{})
+	if len(empty.Files) != 0 || len(empty.Metadata) != 0 {
+		t.Errorf("Expected no change without proto files, got %+v", empty)
+	}
+}
+
+// ************************************************************************************************
+// Test that files longer than a chunk are chunked and stale chunks are dropped
+func TestIndexer_addChunks(t *testing.T) {
+	indexer := &Indexer{}
+	repoIndex := &types.RepositoryIndex{
+		ID: "test-repo",
+		Files: map[string]types.IndexedFile{
+			"main.go":   {Path: "main.go", Content: "package main\n\nfunc main() {\n" + strings.Repeat("\trun()\n", 10) + "}\n"},
+			"short.go":  {Path: "short.go", Content: "package main\n", Metadata: map[string]string{types.ChunksMetadataKey: "[]"}},
+			"notes.txt": {Path: "notes.txt", Content: strings.Repeat("note\n", 12)},
+		},
+		Metadata: make(map[string]interface{}),
+	}
+
+	indexer.addChunks(repoIndex, types.IndexingConfig{ChunkMaxLines: 10, ChunkOverlap: 2})
+
+	chunks, err := types.ParseChunks(repoIndex.Files["main.go"].Metadata[types.ChunksMetadataKey])
+	if err != nil || len(chunks) != 3 || chunks[1].Start != 3 || chunks[1].Symbols[0] != "main" {
+		t.Errorf("Expected main.go chunked at its main function, got %+v (%v)", chunks, err)
+	}
+	if _, exists := repoIndex.Files["short.go"].Metadata[types.ChunksMetadataKey]; exists {
+		t.Error("Expected the stale chunks of short.go to be dropped")
+	}
+	if _, exists := repoIndex.Files["notes.txt"].Metadata[types.ChunksMetadataKey]; !exists {
+		t.Error("Expected notes.txt to be chunked")
+	}
+	if repoIndex.Metadata["chunked_files_count"] != 2 {
+		t.Errorf("Expected chunked_files_count 2, got %v", repoIndex.Metadata["chunked_files_count"])
+	}
+
+	indexer.addChunks(repoIndex, types.IndexingConfig{ChunkMaxLines: -1})
+	if repoIndex.Metadata["chunked_files_count"] != 0 {
+		t.Errorf("Expected no chunks when chunking is disabled, got %v", repoIndex.Metadata["chunked_files_count"])
+	}
+}
+
+// ************************************************************************************************
+// Test that a git repository indexed with repomix is re-indexed from the files changed since its
+// previous index, committed or not, and indexed whole again when the configuration changes
+func TestIndexer_IndexRepositoryIncremental(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake repomix script requires a POSIX shell")
+	}
+
+	// The fake repomix outputs the included files, all files without include pattern
+	binDir := t.TempDir()
+	logPath := filepath.Join(binDir, "calls.log")
+	script := `#!/bin/sh
+out=""; include=""; path=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+  --output|--include|--ignore|--style) [ "$1" = --output ] && out="$2"; [ "$1" = --include ] && include="$2"; shift 2 ;;
+  --*) shift ;;
+  *) path="$1"; shift ;;
+  esac
+done
+echo "include=$include" >> "` + logPath + `"
+cd "$path"
+if [ -n "$include" ]; then files=$(echo "$include" | tr ',' ' '); else files=$(find . -type f -not -path './.git/*' | sed 's|^\./||'); fi
+: > "$out"
+for f in $files; do printf '<file path="%s">\n%s\n</file>\n' "$f" "$(cat "$f")" >> "$out"; done
+`
+	if err := os.WriteFile(filepath.Join(binDir, "repomix"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake repomix: %v", err)
+	}
+	indexer := &Indexer{repomixPath: filepath.Join(binDir, "repomix"), tempDir: t.TempDir(), goParser: parser.NewGoParser()}
+
+	repoPath := t.TempDir()
+	repo, err := git.PlainInit(repoPath, false)
+	if err != nil {
+		t.Fatalf("Failed to init repository: %v", err)
+	}
+	worktree, _ := repo.Worktree()
+	commit := func(files map[string]string, removed ...string) {
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", name, err)
+			}
+			if _, err := worktree.Add(name); err != nil {
+				t.Fatalf("Failed to add %s: %v", name, err)
+			}
+		}
+		for _, name := range removed {
+			if _, err := worktree.Remove(name); err != nil {
+				t.Fatalf("Failed to remove %s: %v", name, err)
+			}
+		}
+		signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+		if _, err := worktree.Commit("update", &git.CommitOptions{Author: signature}); err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+	}
+	calls := func() []string {
+		data, _ := os.ReadFile(logPath)
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	commit(map[string]string{"a.txt": "alpha", "b.txt": "bravo", "c.txt": "charlie"})
+	config := types.IndexingConfig{Enabled: true, Strategy: types.IndexingStrategyRepomix}
+	ctx := context.Background()
+
+	first, err := indexer.IndexRepositoryIncremental(ctx, nil, "app", repoPath, config)
+	if err != nil {
+		t.Fatalf("Initial indexing failed: %v", err)
+	}
+	if len(first.Files) != 3 || first.CommitHash == "" || first.Metadata[types.IncrementalFilesMetadataKey] != nil {
+		t.Fatalf("Expected the whole repository to be indexed at its commit, got %d files at %q", len(first.Files), first.CommitHash)
+	}
+
+	commit(map[string]string{"b.txt": "bravo v2"}, "c.txt")
+	if err := os.WriteFile(filepath.Join(repoPath, "d.txt"), []byte("delta"), 0644); err != nil {
+		t.Fatalf("Failed to write d.txt: %v", err)
+	}
+
+	second, err := indexer.IndexRepositoryIncremental(ctx, first, "app", repoPath, config)
+	if err != nil {
+		t.Fatalf("Incremental indexing failed: %v", err)
+	}
+	if got := calls(); got[len(got)-1] != "include=b.txt,d.txt" {
+		t.Errorf("Expected repomix to run on the changed files only, got %v", got)
+	}
+	if second.Metadata[types.IncrementalFilesMetadataKey] != 3 {
+		t.Errorf("Expected 3 changed files, got %v", second.Metadata[types.IncrementalFilesMetadataKey])
+	}
+	if _, exists := second.Files["c.txt"]; exists {
+		t.Error("Expected the deleted file to be dropped")
+	}
+	if second.Files["a.txt"].Content != "alpha" || second.Files["b.txt"].Content != "bravo v2" || second.Files["d.txt"].Content != "delta" {
+		t.Errorf("Expected unchanged files kept and changed files re-indexed, got %v", second.Files)
+	}
+	if dirty := decodePaths(second.Metadata[types.DirtyFilesMetadataKey]); len(dirty) != 1 || dirty[0] != "d.txt" {
+		t.Errorf("Expected the untracked file to be recorded, got %v", dirty)
+	}
+
+	config.ExcludePatterns = []string{"*.log"}
+	third, err := indexer.IndexRepositoryIncremental(ctx, second, "app", repoPath, config)
+	if err != nil {
+		t.Fatalf("Indexing with a new configuration failed: %v", err)
+	}
+	if got := calls(); got[len(got)-1] != "include=" || third.Metadata[types.IncrementalFilesMetadataKey] != nil {
+		t.Errorf("Expected the whole repository to be indexed after a configuration change, got %v", got)
+	}
+}
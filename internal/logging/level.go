@@ -0,0 +1,153 @@
+// ************************************************************************************************
+// Package logging provides the log levels and the log output setup for the repomix-mcp
+// application. Messages are logged with log/slog at an explicit level; the lines still written
+// with the standard logger carry none, so it is inferred from their wording, "Warning: ..." or
+// "failed to ...", and they honour server.logLevel too.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// Level is a log verbosity level, ordered from the most to the least verbose.
+type Level int
+
+const (
+	LevelTrace Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarning
+	LevelError
+	LevelCritical
+)
+
+// ************************************************************************************************
+// levelNames maps the server.logLevel values to levels.
+var levelNames = map[string]Level{
+	"trace":    LevelTrace,
+	"debug":    LevelDebug,
+	"info":     LevelInfo,
+	"warning":  LevelWarning,
+	"error":    LevelError,
+	"critical": LevelCritical,
+}
+
+// ************************************************************************************************
+// ParseLevel returns the level named by a server.logLevel value.
+//
+// Returns:
+//   - Level: The level.
+//   - error: An error if the name is not a level.
+func ParseLevel(name string) (Level, error) {
+	level, exists := levelNames[strings.ToLower(strings.TrimSpace(name))]
+	if !exists {
+		return LevelInfo, fmt.Errorf("%w: invalid log level: %s", types.ErrInvalidConfig, name)
+	}
+	return level, nil
+}
+
+// ************************************************************************************************
+// MessageLevel infers the level of a log line from its message, after the date and time the
+// standard logger prefixes: fatal and panic messages are critical, errors and failures are
+// errors, warnings are warnings and anything else is informational.
+func MessageLevel(line string) Level {
+	message := strings.ToLower(strings.TrimSpace(StripLogPrefix(line)))
+	switch {
+	case strings.HasPrefix(message, "fatal") || strings.HasPrefix(message, "panic"):
+		return LevelCritical
+	case strings.HasPrefix(message, "error") || strings.HasPrefix(message, "failed") || strings.Contains(message, " error:"):
+		return LevelError
+	case strings.HasPrefix(message, "warning"):
+		return LevelWarning
+	}
+	return LevelInfo
+}
+
+// ************************************************************************************************
+// StripLogPrefix removes the "2006/01/02 15:04:05 " prefix of the standard logger, when present.
+func StripLogPrefix(line string) string {
+	const prefixLength = len("2006/01/02 15:04:05 ")
+	if len(line) >= prefixLength {
+		if _, err := time.Parse("2006/01/02 15:04:05", line[:prefixLength-1]); err == nil {
+			return line[prefixLength:]
+		}
+	}
+	return line
+}
+
+// ************************************************************************************************
+// Setup makes log/slog and the standard logger write the messages at or above the configured
+// level, as text or JSON lines, to stderr and to the configured log file, or to the log file
+// only when server.logFileOnly is set.
+//
+// Returns:
+//   - io.Closer: Closes the log file and logs to stderr again; nil without a log file.
+//   - error: An error if the log options are invalid or the file cannot be opened.
+//
+// Example usage:
+//
+//	logFile, err := logging.Setup(&config.Server)
+//	if err != nil {
+//		return err
+//	}
+func Setup(server *types.ServerConfig) (io.Closer, error) {
+	level, err := ParseLevel(server.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	format, err := ParseFormat(server.LogFormat)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", types.ErrInvalidConfig, err)
+	}
+	if server.LogFile == "" {
+		setOutputHandler(NewHandler(os.Stderr, format, level))
+		return nil, nil
+	}
+
+	var maxSize int64
+	if strings.TrimSpace(server.LogMaxSize) != "0" && server.LogMaxSize != "" {
+		if maxSize, err = types.ParseByteSize(server.LogMaxSize); err != nil {
+			return nil, fmt.Errorf("%w: invalid logMaxSize: %s", types.ErrInvalidConfig, server.LogMaxSize)
+		}
+	}
+	var maxAge time.Duration
+	if server.LogMaxAge != "" {
+		if maxAge, err = time.ParseDuration(server.LogMaxAge); err != nil {
+			return nil, fmt.Errorf("%w: invalid logMaxAge: %s", types.ErrInvalidConfig, server.LogMaxAge)
+		}
+	}
+
+	file, err := NewRotatingFile(server.LogFile, maxSize, maxAge, server.LogMaxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	var out io.Writer = file
+	if !server.LogFileOnly {
+		out = io.MultiWriter(os.Stderr, file)
+	}
+	setOutputHandler(NewHandler(out, format, level))
+	return &logFileCloser{file: file, stderr: NewHandler(os.Stderr, format, level)}, nil
+}
+
+// ************************************************************************************************
+// logFileCloser closes the log file set up by Setup, logging to stderr from then on.
+type logFileCloser struct {
+	file   io.Closer
+	stderr slog.Handler
+}
+
+// ************************************************************************************************
+// Close logs to stderr and closes the log file.
+func (c *logFileCloser) Close() error {
+	setOutputHandler(c.stderr)
+	return c.file.Close()
+}
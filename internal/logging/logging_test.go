@@ -0,0 +1,160 @@
+// ************************************************************************************************
+// Package logging - Unit tests for log file rotation and level filtering.
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ************************************************************************************************
+// Test that the log file is rotated by size and old rotated files are deleted
+func TestRotatingFile_Rotation(t *testing.T) {
+	now := time.Date(2025, 3, 2, 14, 10, 0, 0, time.Local)
+	mock_timeNow = func() time.Time { return now }
+	defer func() { mock_timeNow = time.Now }()
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "server.log")
+	file, err := NewRotatingFile(logPath, 10, 0, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	defer file.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		now = now.Add(time.Second)
+	}
+
+	content, _ := os.ReadFile(logPath)
+	if string(content) != "fourth\n" {
+		t.Errorf("Expected the current file to hold the last line, got %q", content)
+	}
+	backups := file.backups()
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 rotated files kept, got %v", backups)
+	}
+	newest, _ := os.ReadFile(filepath.Join(dir, backups[0]))
+	if backups[0] != "server-2025-03-02T14-10-03.000.log" || string(newest) != "third\n" {
+		t.Errorf("Expected the newest rotated file first, got %s with %q", backups[0], newest)
+	}
+
+	// Rotated files older than the maximum age are deleted on the next rotation
+	file.maxBackups = 0
+	file.maxAge = 90 * time.Minute
+	now = now.Add(time.Hour)
+	file.Write([]byte("fifth line\n"))
+	if backups := file.backups(); len(backups) != 3 {
+		t.Errorf("Expected rotated files within the maximum age to be kept, got %v", backups)
+	}
+	now = now.Add(time.Hour)
+	file.Write([]byte("sixth line\n"))
+	if backups := file.backups(); len(backups) != 2 || backups[1] != "server-2025-03-02T15-10-04.000.log" {
+		t.Errorf("Expected expired rotated files to be deleted, got %v", backups)
+	}
+}
+
+// ************************************************************************************************
+// Test that an existing log file is appended to across restarts
+func TestRotatingFile_Append(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "logs", "server.log")
+	for _, line := range []string{"before restart\n", "after\n"} {
+		file, err := NewRotatingFile(logPath, 1<<10, 0, 0)
+		if err != nil {
+			t.Fatalf("NewRotatingFile failed: %v", err)
+		}
+		file.Write([]byte(line))
+		file.Close()
+	}
+
+	content, _ := os.ReadFile(logPath)
+	if string(content) != "before restart\nafter\n" {
+		t.Errorf("Expected both lines in the log file, got %q", content)
+	}
+}
+
+// ************************************************************************************************
+// Test that message levels are inferred from their wording
+func TestMessageLevel(t *testing.T) {
+	tests := []struct {
+		line string
+		want Level
+	}{
+		{"2025/03/02 14:10:00 Starting MCP server...\n", LevelInfo},
+		{"2025/03/02 14:10:00 Warning: failed to close cache: closed\n", LevelWarning},
+		{"2025/03/02 14:10:00 Failed to create application: boom\n", LevelError},
+		{"2025/03/02 14:10:00 HTTP server error: bind\n", LevelError},
+		{"2025/03/02 14:10:00 Error encoding JSON-RPC response\n", LevelError},
+		{"Fatal: out of memory\n", LevelCritical},
+	}
+
+	for _, tt := range tests {
+		if got := MessageLevel(tt.line); got != tt.want {
+			t.Errorf("MessageLevel(%q) = %d, want %d", tt.line, got, tt.want)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that the lines of the standard logger are logged at the level of their wording, and
+// dropped below the minimum level
+func TestStandardLogWriter(t *testing.T) {
+	var out bytes.Buffer
+	writer := &standardLogWriter{handler: NewHandler(&out, FormatText, LevelWarning)}
+
+	input := "2025/03/02 14:10:00 Indexed repository\n2025/03/02 14:10:01 Warning: slow disk\n"
+	written, err := writer.Write([]byte(input))
+	if err != nil || written != len(input) {
+		t.Fatalf("Expected the whole input to be consumed, got %d (%v)", written, err)
+	}
+	if line := out.String(); strings.Contains(line, "Indexed") || !strings.Contains(line, `level=WARN msg="Warning: slow disk"`) {
+		t.Errorf("Expected only the warning, got %q", line)
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+}
+
+// ************************************************************************************************
+// Test that JSON lines carry the attributes of the message and of its context
+func TestNewHandler_JSON(t *testing.T) {
+	var out bytes.Buffer
+	var extra bytes.Buffer
+	logger := slog.New(fanoutHandler{NewHandler(&out, FormatJSON, LevelTrace), NewHandler(&extra, FormatText, LevelError)})
+
+	ctx := ContextWith(context.Background(), "tool", "get-library-docs")
+	ctx = ContextWith(ctx, "repository", "app")
+	logger.Log(ctx, SlogLevelTrace, "Processing file", "path", "main.go")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", out.String(), err)
+	}
+	want := map[string]interface{}{"level": "TRACE", "msg": "Processing file", "path": "main.go", "tool": "get-library-docs", "repository": "app"}
+	for key, value := range want {
+		if line[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, line[key])
+		}
+	}
+	if extra.Len() != 0 {
+		t.Errorf("Expected the error handler to drop the trace message, got %q", extra.String())
+	}
+
+	if level := FromSlogLevel(slog.LevelWarn + 1); level != LevelWarning {
+		t.Errorf("Expected levels between warning and error to be warnings, got %d", level)
+	}
+}
@@ -0,0 +1,14 @@
+package logging
+
+import (
+	"os"
+	"time"
+)
+
+// ************************************************************************************************
+// Mock functions to allow easy and in depth unit test
+var (
+	// Mock for external package
+	mock_osMkdirAll = os.MkdirAll
+	mock_timeNow    = time.Now
+)
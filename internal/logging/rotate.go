@@ -0,0 +1,202 @@
+// ************************************************************************************************
+// Package logging provides persistent log files for the repomix-mcp application.
+// Logs are appended to a file rotated once it reaches a maximum size; rotated files are renamed
+// with their rotation time and deleted once older than a maximum age or beyond a maximum count.
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ************************************************************************************************
+// backupTimeFormat is the rotation time inserted in the name of rotated files, sortable and
+// valid on every file system.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// ************************************************************************************************
+// RotatingFile is an io.WriteCloser appending to a log file and rotating it by size. It is safe
+// for concurrent use.
+type RotatingFile struct {
+	path       string
+	maxSize    int64         // Size at which the file is rotated, 0 for no rotation
+	maxAge     time.Duration // Age after which rotated files are deleted, 0 to keep them
+	maxBackups int           // Rotated files kept, 0 to keep them all
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// ************************************************************************************************
+// NewRotatingFile opens, or creates, a log file rotated by size. Its directory is created when
+// missing.
+//
+// Returns:
+//   - *RotatingFile: The log file, appended to.
+//   - error: An error if the file cannot be opened.
+//
+// Example usage:
+//
+//	logFile, err := logging.NewRotatingFile("/var/log/repomix-mcp.log", 100<<20, 7*24*time.Hour, 10)
+//	if err != nil {
+//		return err
+//	}
+//	defer logFile.Close()
+func NewRotatingFile(path string, maxSize int64, maxAge time.Duration, maxBackups int) (*RotatingFile, error) {
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.removeOldBackups()
+	return r, nil
+}
+
+// ************************************************************************************************
+// Write appends to the log file, rotating it first when the data would take it past the
+// maximum size. A single write larger than the maximum size is written whole to a new file.
+//
+// Returns:
+//   - int: The number of bytes written.
+//   - error: An error if the file cannot be written or rotated.
+func (r *RotatingFile) Write(data []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, fmt.Errorf("log file %s is closed", r.path)
+	}
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(data)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	written, err := r.file.Write(data)
+	r.size += int64(written)
+	return written, err
+}
+
+// ************************************************************************************************
+// Close closes the log file. Further writes fail.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// ************************************************************************************************
+// open opens the log file for appending and records its current size.
+func (r *RotatingFile) open() error {
+	if err := mock_osMkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory\n>    %w", err)
+	}
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s\n>    %w", r.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file %s\n>    %w", r.path, err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// ************************************************************************************************
+// rotate renames the current log file with the rotation time, opens a new one and deletes the
+// rotated files no longer kept. The caller holds r.mu.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file %s\n>    %w", r.path, err)
+	}
+	r.file = nil
+
+	if err := os.Rename(r.path, r.backupName(mock_timeNow())); err != nil {
+		return fmt.Errorf("failed to rotate log file %s\n>    %w", r.path, err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.removeOldBackups()
+	return nil
+}
+
+// ************************************************************************************************
+// backupName returns the name of the log file rotated at the given time: "app.log" becomes
+// "app-2025-03-02T14-10-00.000.log".
+func (r *RotatingFile) backupName(rotatedAt time.Time) string {
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(r.path, ext)
+	return fmt.Sprintf("%s-%s%s", base, rotatedAt.Format(backupTimeFormat), ext)
+}
+
+// ************************************************************************************************
+// backups returns the rotated files of the log file, newest first.
+func (r *RotatingFile) backups() []string {
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext) + "-"
+
+	entries, err := os.ReadDir(filepath.Dir(r.path))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err == nil {
+			names = append(names, name)
+		}
+	}
+	// The rotation time sorts chronologically
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names
+}
+
+// ************************************************************************************************
+// removeOldBackups deletes the rotated files beyond the maximum count and those older than the
+// maximum age. Failures are reported on stderr, since the log itself may be the culprit.
+func (r *RotatingFile) removeOldBackups() {
+	if r.maxAge <= 0 && r.maxBackups <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext) + "-"
+	cutoff := mock_timeNow().Add(-r.maxAge)
+	for index, name := range r.backups() {
+		expired := false
+		if r.maxAge > 0 {
+			rotatedAt, _ := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext), time.Local)
+			expired = rotatedAt.Before(cutoff)
+		}
+		if !expired && (r.maxBackups <= 0 || index < r.maxBackups) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(filepath.Dir(r.path), name)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove rotated log file %s: %v\n", name, err)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// ************************************************************************************************
+// Package logging provides the structured logger of the repomix-mcp application.
+// Messages are logged with log/slog at an explicit level, with attributes naming the repository,
+// tool or file they are about, and written as text or JSON lines at or above server.logLevel.
+// Attributes stored in a context with ContextWith are added to every message logged with it.
+// Lines still written with the standard logger go through the same handlers, at the level
+// inferred from their wording.
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// ************************************************************************************************
+// Log line formats of server.logFormat.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ************************************************************************************************
+// Levels of log/slog matching the trace and critical levels, beyond its four levels.
+const (
+	SlogLevelTrace    = slog.Level(-8)
+	SlogLevelCritical = slog.Level(12)
+)
+
+// ************************************************************************************************
+// slogLevels maps the levels to the levels of log/slog.
+var slogLevels = map[Level]slog.Level{
+	LevelTrace:    SlogLevelTrace,
+	LevelDebug:    slog.LevelDebug,
+	LevelInfo:     slog.LevelInfo,
+	LevelWarning:  slog.LevelWarn,
+	LevelError:    slog.LevelError,
+	LevelCritical: SlogLevelCritical,
+}
+
+// ************************************************************************************************
+// The handlers of the default logger: the configured output, and the handlers added by
+// AddHandler such as the one sending the log to MCP clients.
+var (
+	handlersMu    sync.Mutex
+	outputHandler slog.Handler
+	extraHandlers []slog.Handler
+)
+
+// ************************************************************************************************
+// SlogLevel returns the level of log/slog matching a level.
+func (l Level) SlogLevel() slog.Level {
+	return slogLevels[l]
+}
+
+// ************************************************************************************************
+// FromSlogLevel returns the level matching a level of log/slog, rounded down.
+func FromSlogLevel(level slog.Level) Level {
+	result := LevelTrace
+	for candidate, slogLevel := range slogLevels {
+		if slogLevel <= level && candidate > result {
+			result = candidate
+		}
+	}
+	return result
+}
+
+// ************************************************************************************************
+// ParseFormat checks a server.logFormat value.
+//
+// Returns:
+//   - string: The format, FormatText when the value is empty.
+//   - error: An error if the value is not a format.
+func ParseFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("invalid log format: %s, expected %s or %s", format, FormatText, FormatJSON)
+}
+
+// ************************************************************************************************
+// NewHandler returns a handler writing the messages at or above a minimum level to out, as
+// text or JSON lines. The trace and critical levels are named TRACE and CRITICAL.
+//
+// Example usage:
+//
+//	logger := slog.New(logging.NewHandler(os.Stderr, logging.FormatJSON, logging.LevelInfo))
+func NewHandler(out io.Writer, format string, minLevel Level) slog.Handler {
+	options := &slog.HandlerOptions{
+		Level: minLevel.SlogLevel(),
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.LevelKey {
+				switch level, _ := attr.Value.Any().(slog.Level); level {
+				case SlogLevelTrace:
+					attr.Value = slog.StringValue("TRACE")
+				case SlogLevelCritical:
+					attr.Value = slog.StringValue("CRITICAL")
+				}
+			}
+			return attr
+		},
+	}
+	if format == FormatJSON {
+		return slog.NewJSONHandler(out, options)
+	}
+	return slog.NewTextHandler(out, options)
+}
+
+// ************************************************************************************************
+// AddHandler sends the messages of the default logger to another handler as well, which
+// filters them by its own level.
+//
+// Example usage:
+//
+//	logging.AddHandler(server.LogHandler())
+func AddHandler(handler slog.Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	extraHandlers = append(extraHandlers, handler)
+	installLocked()
+}
+
+// ************************************************************************************************
+// setOutputHandler replaces the handler of the configured output.
+func setOutputHandler(handler slog.Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	outputHandler = handler
+	installLocked()
+}
+
+// ************************************************************************************************
+// installLocked makes the handlers those of the default logger and of the standard logger.
+// The caller holds handlersMu.
+func installLocked() {
+	handlers := make(fanoutHandler, 0, len(extraHandlers)+1)
+	if outputHandler != nil {
+		handlers = append(handlers, outputHandler)
+	}
+	handlers = append(handlers, extraHandlers...)
+
+	// slog.SetDefault redirects the standard logger to the handlers at the info level; it is
+	// redirected again so its lines keep the level of their wording
+	slog.SetDefault(slog.New(handlers))
+	log.SetFlags(0)
+	log.SetOutput(&standardLogWriter{handler: handlers})
+}
+
+// ************************************************************************************************
+// contextAttrsKey is the context key of the attributes added to the messages logged with it.
+type contextAttrsKey struct{}
+
+// ************************************************************************************************
+// ContextWith returns a context whose messages carry attributes, given as alternating keys and
+// values like slog.Logger.With, in addition to those of ctx.
+//
+// Example usage:
+//
+//	ctx = logging.ContextWith(ctx, "tool", "get-library-docs", "repository", libraryID)
+//	slog.InfoContext(ctx, "Getting library docs", "tokens", tokens)
+func ContextWith(ctx context.Context, args ...any) context.Context {
+	var record slog.Record
+	record.Add(args...)
+	attrs := append([]slog.Attr(nil), contextAttrs(ctx)...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	return context.WithValue(ctx, contextAttrsKey{}, attrs)
+}
+
+// ************************************************************************************************
+// contextAttrs returns the attributes stored in a context by ContextWith.
+func contextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextAttrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// ************************************************************************************************
+// fanoutHandler sends the messages to several handlers, with the attributes of their context.
+type fanoutHandler []slog.Handler
+
+// ************************************************************************************************
+// Enabled reports whether any handler takes messages of a level.
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range f {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// ************************************************************************************************
+// Handle sends a message to the handlers taking its level.
+func (f fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs := contextAttrs(ctx); len(attrs) > 0 {
+		record = record.Clone()
+		record.AddAttrs(attrs...)
+	}
+	var errs []error
+	for _, handler := range f {
+		if handler.Enabled(ctx, record.Level) {
+			if err := handler.Handle(ctx, record.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// WithAttrs returns the handlers with attributes added to their messages.
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, handler := range f {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+// ************************************************************************************************
+// WithGroup returns the handlers with the attributes of their messages in a group.
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, handler := range f {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
+
+// ************************************************************************************************
+// standardLogWriter logs the lines of the standard logger to a handler, at the level inferred
+// from their wording. The standard logger writes one whole line per call.
+type standardLogWriter struct {
+	handler slog.Handler
+}
+
+// ************************************************************************************************
+// Write logs the lines of data.
+func (w *standardLogWriter) Write(data []byte) (int, error) {
+	ctx := context.Background()
+	for _, line := range bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n")) {
+		message := StripLogPrefix(string(line))
+		level := MessageLevel(message).SlogLevel()
+		if message == "" || !w.handler.Enabled(ctx, level) {
+			continue
+		}
+		if err := w.handler.Handle(ctx, slog.NewRecord(mock_timeNow(), level, message, 0)); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
@@ -0,0 +1,113 @@
+// ************************************************************************************************
+// Package mcp provides the per-repository access control of the MCP server.
+// Clients authenticated with an OAuth2/OIDC token only see the repositories whose configured
+// scopes they were granted, so teams sharing a server cannot resolve or fetch each other's
+// repositories. Repositories without scopes, Go modules and clients authenticated with an API
+// key, or not authenticated at all, are not restricted.
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// principal is an authenticated client.
+type principal struct {
+	subject string
+	scopes  map[string]bool // Granted scopes, matched against the scopes of the repositories
+}
+
+// ************************************************************************************************
+// principalContextKey is the context key of the client of a request.
+type principalContextKey struct{}
+
+// ************************************************************************************************
+// withPrincipal returns a context carrying the client of a request.
+func withPrincipal(ctx context.Context, client *principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, client)
+}
+
+// ************************************************************************************************
+// principalFromContext returns the token-authenticated client of a request, nil when its
+// access is not restricted.
+func principalFromContext(ctx context.Context) *principal {
+	client, _ := ctx.Value(principalContextKey{}).(*principal)
+	return client
+}
+
+// ************************************************************************************************
+// canAccessRepository reports whether the client of a request may see a repository: a
+// configured repository, a glob expansion of one or a workspace, whose members must all be
+// accessible.
+func (s *Server) canAccessRepository(ctx context.Context, repositoryID string) bool {
+	client := principalFromContext(ctx)
+	if client == nil || types.IsGoModuleRepositoryID(repositoryID) {
+		return true
+	}
+
+	if strings.HasPrefix(repositoryID, types.WorkspaceRepositoryPrefix) {
+		workspace, exists := s.config.Workspaces[strings.TrimPrefix(repositoryID, types.WorkspaceRepositoryPrefix)]
+		if !exists {
+			return true
+		}
+		for _, alias := range workspace.Repositories {
+			if !s.canAccessRepository(ctx, alias) {
+				return false
+			}
+		}
+		return true
+	}
+
+	repoConfig, exists := s.repositoryConfig(repositoryID)
+	if !exists || len(repoConfig.Scopes) == 0 {
+		return true
+	}
+	for _, scope := range repoConfig.Scopes {
+		if client.scopes[scope] {
+			return true
+		}
+	}
+	return false
+}
+
+// ************************************************************************************************
+// filterAccessible returns the repository IDs the client of a request may see.
+func (s *Server) filterAccessible(ctx context.Context, repositoryIDs []string) []string {
+	if principalFromContext(ctx) == nil {
+		return repositoryIDs
+	}
+	accessible := make([]string, 0, len(repositoryIDs))
+	for _, repositoryID := range repositoryIDs {
+		if s.canAccessRepository(ctx, repositoryID) {
+			accessible = append(accessible, repositoryID)
+		}
+	}
+	return accessible
+}
+
+// ************************************************************************************************
+// checkToolAccess checks that the client of a tool call may see the repository the call names.
+// Inaccessible repositories are reported as not found, so their names are not confirmed.
+//
+// Returns:
+//   - error: An error if the call names a repository the client may not see, or refreshes
+//     every repository while the client is restricted.
+func (s *Server) checkToolAccess(ctx context.Context, toolName string, arguments map[string]interface{}) error {
+	if principalFromContext(ctx) == nil {
+		return nil
+	}
+	for _, name := range []string{"library-id", "repositoryID", "repository"} {
+		repositoryID, _ := arguments[name].(string)
+		if repositoryID != "" && !s.canAccessRepository(ctx, repositoryID) {
+			return fmt.Errorf("repository not found: %s", repositoryID)
+		}
+	}
+	if repositoryID, _ := arguments["repositoryID"].(string); toolName == "refresh" && repositoryID == "" {
+		return fmt.Errorf("refreshing every repository requires unrestricted access, name a repositoryID")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// ************************************************************************************************
+// Package mcp provides the HTTPS certificates obtained from an ACME certificate authority such
+// as Let's Encrypt. Certificates are requested on the first handshake for a configured hostname,
+// stored under server.acme.cacheDir so restarts reuse them, and renewed before they expire. The
+// CA challenges are answered by the HTTPS server (TLS-ALPN-01) and the HTTP listeners (HTTP-01).
+package mcp
+
+import (
+	"crypto/tls"
+
+	"repomix-mcp/pkg/types"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ************************************************************************************************
+// NewACMEManager returns the manager obtaining and renewing the certificates of the configured
+// hostnames, accepting the terms of service of the CA. Certificates for other names are refused.
+//
+// Example usage:
+//
+//	manager := NewACMEManager(config.Server.ACME)
+//	httpsServer.TLSConfig = ACMETLSConfig(manager)
+func NewACMEManager(config types.ACMEConfig) *autocert.Manager {
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(config.CacheDir),
+		HostPolicy: autocert.HostWhitelist(config.Hostnames...),
+		Email:      config.Email,
+	}
+	if config.DirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: config.DirectoryURL}
+	}
+	return manager
+}
+
+// ************************************************************************************************
+// ACMETLSConfig returns the TLS configuration of the HTTPS server serving the certificates of an
+// ACME manager, which also answers the TLS-ALPN-01 challenges of the CA.
+func ACMETLSConfig(manager *autocert.Manager) *tls.Config {
+	tlsConfig := manager.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+	return tlsConfig
+}
@@ -0,0 +1,347 @@
+// ************************************************************************************************
+// Package mcp provides the admin REST API of the MCP server.
+// Operations tooling and dashboards manage the server under /api/v1 with plain JSON requests:
+// they list and inspect the served repositories, trigger their reindexing, delete them from the
+// cache and read the cache statistics without speaking MCP. The endpoints share the
+// authentication of /mcp; token-authenticated clients only see the repositories of their scopes
+// and need the admin scope to delete or reindex them. Without authentication, deletions and
+// reindexing requested by the pages of another origin are refused.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// defaultAdminScope is the scope access tokens need to delete or reindex repositories when
+// auth.adminScope is not set.
+const defaultAdminScope = "repomix:admin"
+
+// ************************************************************************************************
+// CacheStatsProvider is implemented by caches reporting their usage, such as cache.Cache.
+type CacheStatsProvider interface {
+	GetCacheStats() (map[string]interface{}, error)
+}
+
+// ************************************************************************************************
+// adminRepository is a served repository as listed by the admin API.
+type adminRepository struct {
+	ID          string    `json:"id"`
+	GoModule    bool      `json:"goModule"`
+	Files       int       `json:"files"`
+	LastUpdated time.Time `json:"lastUpdated"`
+	CommitHash  string    `json:"commitHash,omitempty"`
+}
+
+// ************************************************************************************************
+// adminRepositoryDetail is a served repository as described by the admin API.
+type adminRepositoryDetail struct {
+	adminRepository
+	Name      string                 `json:"name"`
+	Path      string                 `json:"path"`
+	Alias     string                 `json:"alias,omitempty"` // Configured alias the repository comes from
+	Languages map[string]int         `json:"languages"`       // Number of files by language
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ************************************************************************************************
+// adminIndexJob is an indexing job as reported by the admin API.
+type adminIndexJob struct {
+	ID         string     `json:"id"`
+	Repository string     `json:"repository"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	Progress   int        `json:"progress"`
+	Total      int        `json:"total"`
+	Message    string     `json:"message,omitempty"`
+	QueuedAt   time.Time  `json:"queuedAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// ************************************************************************************************
+// registerAdminRoutes adds the admin API endpoints to a mux, behind the authentication of the
+// MCP endpoints.
+//
+// Example usage:
+//
+//	mux := http.NewServeMux()
+//	s.registerAdminRoutes(mux)
+func (s *Server) registerAdminRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/repositories", s.withAuthentication(s.handleAdminListRepositories))
+	mux.HandleFunc("GET /api/v1/repositories/{id}", s.withAuthentication(s.handleAdminGetRepository))
+	mux.HandleFunc("DELETE /api/v1/repositories/{id}", s.withAuthentication(s.withAdminWrite(s.handleAdminDeleteRepository)))
+	mux.HandleFunc("POST /api/v1/repositories/{id}/reindex", s.withAuthentication(s.withAdminWrite(s.handleAdminReindex)))
+	mux.HandleFunc("GET /api/v1/jobs/{id}", s.withAuthentication(s.handleAdminGetJob))
+	mux.HandleFunc("GET /api/v1/cache/stats", s.withAuthentication(s.handleAdminCacheStats))
+}
+
+// ************************************************************************************************
+// withAdminWrite wraps an admin API handler changing the server state. Token-authenticated
+// clients must have been granted the admin scope; when the endpoints are not authenticated,
+// requests sent by a browser from another origin are refused so a web page cannot delete or
+// reindex repositories of a server listening on the local machine.
+//
+// Example usage:
+//
+//	mux.HandleFunc("DELETE /api/v1/repositories/{id}", s.withAuthentication(s.withAdminWrite(s.handleAdminDeleteRepository)))
+func (s *Server) withAdminWrite(next http.HandlerFunc) http.HandlerFunc {
+	authenticated := len(s.apiKeyDigests()) > 0 || s.tokenVerifier != nil
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if client := principalFromContext(r.Context()); client != nil {
+			if scope := s.adminScope(); !client.scopes[scope] {
+				slog.WarnContext(r.Context(), "Rejecting admin API request: missing admin scope", "subject", client.subject, "scope", scope)
+				writeAdminError(w, http.StatusForbidden, fmt.Sprintf("the %s scope is required", scope))
+				return
+			}
+		} else if !authenticated && isCrossOriginRequest(r) {
+			slog.WarnContext(r.Context(), "Rejecting cross-origin admin API request", "origin", r.Header.Get("Origin"), "remote", r.RemoteAddr)
+			writeAdminError(w, http.StatusForbidden, "cross-origin requests are not allowed")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// ************************************************************************************************
+// adminScope returns the scope access tokens need to delete or reindex repositories.
+func (s *Server) adminScope() string {
+	if s.config != nil && s.config.Server.Auth.AdminScope != "" {
+		return s.config.Server.Auth.AdminScope
+	}
+	return defaultAdminScope
+}
+
+// ************************************************************************************************
+// isCrossOriginRequest reports whether a browser sent a request from a page of another origin,
+// from its Sec-Fetch-Site header or, for older browsers, its Origin header compared with the
+// Host. Requests of non-browser clients carry neither and are not cross-origin.
+func isCrossOriginRequest(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return true
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return true
+	}
+	return !strings.EqualFold(parsed.Host, r.Host)
+}
+
+// ************************************************************************************************
+// handleAdminListRepositories lists the served repositories the client may see, Go modules
+// included unless ?goModules=false.
+func (s *Server) handleAdminListRepositories(w http.ResponseWriter, r *http.Request) {
+	includeGoModules := r.URL.Query().Get("goModules") != "false"
+
+	repositories := []adminRepository{}
+	for _, library := range s.listLibraries(includeGoModules) {
+		if s.canAccessRepository(r.Context(), library.ID) {
+			repositories = append(repositories, adminRepository{
+				ID:          library.ID,
+				GoModule:    library.GoModule,
+				Files:       library.FileCount,
+				LastUpdated: library.LastUpdated,
+				CommitHash:  library.CommitHash,
+			})
+		}
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]interface{}{"repositories": repositories})
+}
+
+// ************************************************************************************************
+// handleAdminGetRepository describes a served repository.
+func (s *Server) handleAdminGetRepository(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.adminRepository(w, r)
+	if !ok {
+		return
+	}
+
+	detail := adminRepositoryDetail{
+		adminRepository: adminRepository{
+			ID:          repo.ID,
+			GoModule:    types.IsGoModuleRepositoryID(repo.ID),
+			Files:       len(repo.Files),
+			LastUpdated: repo.LastUpdated,
+			CommitHash:  repo.CommitHash,
+		},
+		Name:      repo.Name,
+		Path:      repo.Path,
+		Languages: make(map[string]int),
+		Metadata:  repo.Metadata,
+	}
+	if alias, exists := s.repositoryAlias(repo.ID); exists {
+		detail.Alias = alias
+	}
+	for _, file := range repo.Files {
+		if file.Language != "" {
+			detail.Languages[file.Language]++
+		}
+	}
+	writeAdminJSON(w, http.StatusOK, detail)
+}
+
+// ************************************************************************************************
+// handleAdminDeleteRepository deletes a repository from the cache, the memory and the search
+// index. A configured repository is served again once reindexed.
+func (s *Server) handleAdminDeleteRepository(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.adminRepository(w, r)
+	if !ok {
+		return
+	}
+
+	if s.cache != nil {
+		if err := s.cache.InvalidateRepository(repo.ID); err != nil {
+			writeAdminError(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete repository %s: %v", repo.ID, err))
+			return
+		}
+	}
+	s.removeMemoryRepository(repo.ID)
+	s.removeFromSearchIndex(repo.ID)
+
+	slog.InfoContext(r.Context(), "Deleted repository through the admin API", "repository", repo.ID)
+	s.broadcastMessage("info", fmt.Sprintf("Repository %s deleted", repo.ID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ************************************************************************************************
+// handleAdminReindex queues the reindexing of a configured repository, or of the configured
+// repository a glob expansion comes from, and answers with its job.
+func (s *Server) handleAdminReindex(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !s.canAccessRepository(r.Context(), id) {
+		writeAdminError(w, http.StatusNotFound, fmt.Sprintf("repository not found: %s", id))
+		return
+	}
+	alias, exists := s.repositoryAlias(id)
+	if !exists {
+		writeAdminError(w, http.StatusNotFound, fmt.Sprintf("no repository %s in the configuration", id))
+		return
+	}
+	if s.repositoryIndexer == nil {
+		writeAdminError(w, http.StatusServiceUnavailable, "indexing is not available on this server")
+		return
+	}
+
+	job, queued, err := s.startIndexJob(alias)
+	if err != nil {
+		writeAdminError(w, http.StatusConflict, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if queued {
+		status = http.StatusAccepted
+	}
+	w.Header().Set("Location", "/api/v1/jobs/"+job.ID)
+	writeAdminJSON(w, status, newAdminIndexJob(job))
+}
+
+// ************************************************************************************************
+// handleAdminGetJob reports the status of an indexing job.
+func (s *Server) handleAdminGetJob(w http.ResponseWriter, r *http.Request) {
+	job, exists := s.indexJobs.get(r.PathValue("id"))
+	if !exists || !s.canAccessRepository(r.Context(), job.Alias) {
+		writeAdminError(w, http.StatusNotFound, fmt.Sprintf("no indexing job %s", r.PathValue("id")))
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, newAdminIndexJob(job))
+}
+
+// ************************************************************************************************
+// handleAdminCacheStats reports the cache usage and the repositories held in memory. The
+// statistics cover every repository, so token-authenticated clients are refused.
+func (s *Server) handleAdminCacheStats(w http.ResponseWriter, r *http.Request) {
+	if principalFromContext(r.Context()) != nil {
+		writeAdminError(w, http.StatusForbidden, "cache statistics require unrestricted access")
+		return
+	}
+
+	stats := map[string]interface{}{
+		"memoryRepositories": s.memoryRepositoryCount(),
+	}
+	if provider, ok := s.cache.(CacheStatsProvider); ok {
+		cacheStats, err := provider.GetCacheStats()
+		if err != nil {
+			writeAdminError(w, http.StatusInternalServerError, fmt.Sprintf("failed to read cache statistics: %v", err))
+			return
+		}
+		stats["cache"] = cacheStats
+	}
+	writeAdminJSON(w, http.StatusOK, stats)
+}
+
+// ************************************************************************************************
+// adminRepository returns the repository named by the {id} path value, answering 404 Not Found
+// when it is not served or the client may not see it.
+//
+// Returns:
+//   - *types.RepositoryIndex: The repository.
+//   - bool: False if the response has been sent.
+func (s *Server) adminRepository(w http.ResponseWriter, r *http.Request) (*types.RepositoryIndex, bool) {
+	id := r.PathValue("id")
+	if s.isRepositoryDisabled(id) || !s.canAccessRepository(r.Context(), id) {
+		writeAdminError(w, http.StatusNotFound, fmt.Sprintf("repository not found: %s", id))
+		return nil, false
+	}
+	repo, err := s.lookupRepository(id)
+	if err != nil {
+		writeAdminError(w, http.StatusNotFound, err.Error())
+		return nil, false
+	}
+	return repo, true
+}
+
+// ************************************************************************************************
+// newAdminIndexJob returns the admin API view of an indexing job.
+func newAdminIndexJob(job indexJob) adminIndexJob {
+	view := adminIndexJob{
+		ID:         job.ID,
+		Repository: job.Alias,
+		Status:     job.Status,
+		Error:      job.Error,
+		Progress:   job.Progress,
+		Total:      job.Total,
+		Message:    job.Message,
+		QueuedAt:   job.QueuedAt,
+	}
+	if !job.StartedAt.IsZero() {
+		view.StartedAt = &job.StartedAt
+	}
+	if !job.FinishedAt.IsZero() {
+		view.FinishedAt = &job.FinishedAt
+	}
+	return view
+}
+
+// ************************************************************************************************
+// writeAdminJSON sends a JSON response.
+func writeAdminJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(body); err != nil {
+		slog.Warn("Failed to write admin API response", "error", err)
+	}
+}
+
+// ************************************************************************************************
+// writeAdminError sends a JSON error response: {"error": "<message>"}.
+func writeAdminError(w http.ResponseWriter, status int, message string) {
+	writeAdminJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,141 @@
+// ************************************************************************************************
+// Package mcp - Unit tests for the admin REST API.
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// Test that access tokens need the admin scope to delete or reindex repositories, whatever their
+// repository scopes
+func TestWithAdminWrite_AdminScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminScope string
+		client     *principal
+		expected   int
+	}{
+		{"API key", "", nil, http.StatusNoContent},
+		{"Default admin scope", "", &principal{subject: "alice", scopes: map[string]bool{"repomix:admin": true}}, http.StatusNoContent},
+		{"Repository scope only", "", &principal{subject: "bob", scopes: map[string]bool{"repomix:finance": true}}, http.StatusForbidden},
+		{"No scope", "", &principal{subject: "carol", scopes: map[string]bool{}}, http.StatusForbidden},
+		{"Configured admin scope", "ops", &principal{subject: "dave", scopes: map[string]bool{"ops": true}}, http.StatusNoContent},
+		{"Default scope when configured", "ops", &principal{subject: "erin", scopes: map[string]bool{"repomix:admin": true}}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &types.Config{Server: types.ServerConfig{Auth: types.ServerAuth{
+				APIKeys:    []string{"static-key-0123456789"},
+				AdminScope: tt.adminScope,
+			}}}
+			server := &Server{config: config}
+			handler := server.withAdminWrite(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/repositories/billing", nil)
+			if tt.client != nil {
+				req = req.WithContext(withPrincipal(req.Context(), tt.client))
+			}
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			if recorder.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d %s", tt.expected, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
+
+// ************************************************************************************************
+// Test that unauthenticated admin changes are refused when a browser sends them from another
+// origin, and allowed from the same origin or from non-browser clients
+func TestWithAdminWrite_CrossOrigin(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected int
+	}{
+		{"No browser headers", nil, http.StatusAccepted},
+		{"Same origin", map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://127.0.0.1:8080"}, http.StatusAccepted},
+		{"Typed URL", map[string]string{"Sec-Fetch-Site": "none"}, http.StatusAccepted},
+		{"Cross site", map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example.com"}, http.StatusForbidden},
+		{"Same site", map[string]string{"Sec-Fetch-Site": "same-site", "Origin": "http://127.0.0.1:3000"}, http.StatusForbidden},
+		{"Other origin without Sec-Fetch-Site", map[string]string{"Origin": "https://evil.example.com"}, http.StatusForbidden},
+		{"Other port without Sec-Fetch-Site", map[string]string{"Origin": "http://127.0.0.1:3000"}, http.StatusForbidden},
+		{"Null origin", map[string]string{"Origin": "null"}, http.StatusForbidden},
+		{"Matching origin without Sec-Fetch-Site", map[string]string{"Origin": "http://127.0.0.1:8080"}, http.StatusAccepted},
+	}
+
+	for _, authenticated := range []bool{false, true} {
+		config := &types.Config{}
+		if authenticated {
+			config.Server.Auth.APIKeys = []string{"static-key-0123456789"}
+		}
+		server := &Server{config: config}
+		handler := server.withAdminWrite(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+		})
+
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodPost, "http://127.0.0.1:8080/api/v1/repositories/app/reindex", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+
+			expected := tt.expected
+			if authenticated {
+				// Browsers cannot attach the API key to a cross-origin request on their own
+				expected = http.StatusAccepted
+			}
+			if recorder.Code != expected {
+				t.Errorf("%s (authenticated: %v): expected status %d, got %d", tt.name, authenticated, expected, recorder.Code)
+			}
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that the admin routes reading the server are not subject to the cross-origin check, and
+// that the mutating ones are
+func TestAdminAPI_CrossOriginRoutes(t *testing.T) {
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"app": {ID: "app", Name: "app", Files: map[string]types.IndexedFile{}},
+	}}
+	server, err := NewServer(&types.Config{}, cache, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	mux := http.NewServeMux()
+	server.registerAdminRoutes(mux)
+
+	request := func(method, path string) int {
+		req := httptest.NewRequest(method, "http://127.0.0.1:8080"+path, nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		req.Header.Set("Sec-Fetch-Site", "cross-site")
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	if status := request(http.MethodGet, "/api/v1/repositories/app"); status != http.StatusOK {
+		t.Errorf("Expected the repository to be readable, got %d", status)
+	}
+	if status := request(http.MethodDelete, "/api/v1/repositories/app"); status != http.StatusForbidden {
+		t.Errorf("Expected the deletion to be refused, got %d", status)
+	}
+	if status := request(http.MethodPost, "/api/v1/repositories/app/reindex"); status != http.StatusForbidden {
+		t.Errorf("Expected the reindexing to be refused, got %d", status)
+	}
+	if _, exists := cache.repos["app"]; !exists {
+		t.Error("Expected the repository to stay in the cache")
+	}
+}
@@ -0,0 +1,132 @@
+// ************************************************************************************************
+// Package mcp provides the rendering of OpenAPI/Swagger specifications for the MCP server.
+// The specifications tagged at indexing time are served verbatim or as a list of endpoints.
+package mcp
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// selectAPISpec picks the specification to serve: the one matching the requested path, by full
+// path or by file name, or the first one when no path is requested.
+//
+// Returns:
+//   - string: The path of the selected specification.
+//   - bool: Whether a specification matched.
+func selectAPISpec(specs []string, requested string) (string, bool) {
+	if len(specs) == 0 {
+		return "", false
+	}
+	if requested == "" {
+		return specs[0], true
+	}
+
+	requested = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(requested)), "/")
+	for _, spec := range specs {
+		if filepath.ToSlash(spec) == requested {
+			return spec, true
+		}
+	}
+	for _, spec := range specs {
+		if path.Base(filepath.ToSlash(spec)) == requested {
+			return spec, true
+		}
+	}
+	return "", false
+}
+
+// ************************************************************************************************
+// formatAPISpec renders an API specification as Markdown: its endpoints and schemas when
+// summarize is set, its content otherwise. The other specifications of the repository are listed so they can be
+// requested by path.
+//
+// Returns:
+//   - string: The Markdown document.
+//   - error: An error if the specification cannot be summarized.
+func formatAPISpec(repositoryID string, file types.IndexedFile, specs []string, summarize bool) (string, error) {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("# API specification: %s\n\n", repositoryID))
+	text.WriteString(fmt.Sprintf("**File:** %s\n", filepath.ToSlash(file.Path)))
+	if len(specs) > 1 {
+		var others []string
+		for _, spec := range specs {
+			if spec != file.Path {
+				others = append(others, filepath.ToSlash(spec))
+			}
+		}
+		text.WriteString(fmt.Sprintf("**Other specifications:** %s\n", strings.Join(others, ", ")))
+	}
+	text.WriteString("\n")
+
+	if !summarize {
+		language := "yaml"
+		if strings.HasSuffix(strings.ToLower(file.Path), ".json") {
+			language = "json"
+		}
+		text.WriteString(fmt.Sprintf("```%s\n%s\n```\n", language, strings.TrimRight(file.Content, "\n")))
+		return text.String(), nil
+	}
+
+	summary, err := types.SummarizeAPISpec(file.Content)
+	if err != nil {
+		return "", err
+	}
+	text.WriteString(formatAPISpecSummary(summary))
+	return text.String(), nil
+}
+
+// ************************************************************************************************
+// formatAPISpecSummary renders the outline of an API specification as Markdown: its title and
+// version, its endpoints and its schemas with their properties, required ones marked.
+func formatAPISpecSummary(summary types.APISpecSummary) string {
+	var text strings.Builder
+	if summary.Title != "" {
+		text.WriteString(fmt.Sprintf("**Title:** %s\n", summary.Title))
+	}
+	if summary.Version != "" {
+		text.WriteString(fmt.Sprintf("**Version:** %s\n", summary.Version))
+	}
+	text.WriteString(fmt.Sprintf("\n## Endpoints (%d)\n\n", len(summary.Endpoints)))
+	for _, endpoint := range summary.Endpoints {
+		line := fmt.Sprintf("- %s %s", endpoint.Method, endpoint.Path)
+		if endpoint.OperationID != "" {
+			line += fmt.Sprintf(" (%s)", endpoint.OperationID)
+		}
+		if endpoint.Summary != "" {
+			line += ": " + endpoint.Summary
+		}
+		text.WriteString(line + "\n")
+	}
+
+	if len(summary.Schemas) > 0 {
+		text.WriteString(fmt.Sprintf("\n## Schemas (%d)\n\n", len(summary.Schemas)))
+	}
+	for _, schema := range summary.Schemas {
+		line := "- " + schema.Name
+		if schema.Type != "" {
+			line += fmt.Sprintf(" (%s)", schema.Type)
+		}
+		properties := make([]string, 0, len(schema.Properties))
+		for _, property := range schema.Properties {
+			field := property.Name
+			if property.Type != "" {
+				field += " " + property.Type
+			}
+			if property.Required {
+				field += " (required)"
+			}
+			properties = append(properties, field)
+		}
+		if len(properties) > 0 {
+			line += ": " + strings.Join(properties, ", ")
+		}
+		text.WriteString(line + "\n")
+	}
+	return text.String()
+}
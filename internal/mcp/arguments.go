@@ -0,0 +1,97 @@
+// ************************************************************************************************
+// Package mcp provides the coercion of numeric tool-call arguments.
+// JSON numbers decode as float64, clients sending arguments from a command line send strings,
+// and handlers called directly may pass Go integers; every handler reads integer arguments
+// through the same helper so they accept the same inputs and bounds.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ************************************************************************************************
+// maxTokensArg bounds the tokens arguments, far above any useful response.
+const maxTokensArg = 1000000
+
+// ************************************************************************************************
+// parseIntArg reads an integer argument from a JSON number, a Go integer or a decimal string,
+// between minimum and maximum inclusive. Numbers with a fractional part are rejected.
+//
+// Returns:
+//   - int: The argument value, def when the argument is absent or null.
+//   - error: An error naming the argument if it is not an integer or is out of bounds.
+//
+// Example usage:
+//
+//	limit, err := parseIntArg(arguments, "limit", 100, 1, 10000)
+//	if err != nil {
+//		s.sendJSONRPCError(w, id, -32602, "Invalid params", err.Error())
+//		return
+//	}
+func parseIntArg(arguments map[string]interface{}, key string, def, minimum, maximum int) (int, error) {
+	value, exists := arguments[key]
+	if !exists || value == nil {
+		return def, nil
+	}
+
+	number, err := intArgValue(key, value)
+	if err != nil {
+		return 0, err
+	}
+	if number < minimum || number > maximum {
+		return 0, fmt.Errorf("argument %q must be between %d and %d, got %d", key, minimum, maximum, number)
+	}
+	return number, nil
+}
+
+// ************************************************************************************************
+// intArgValue converts the value of an integer argument, rejecting numbers with a fractional
+// part and those not fitting an int.
+func intArgValue(key string, value interface{}) (int, error) {
+	// Decoders using UseNumber produce json.Number, parsed like a string
+	var number float64
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int32:
+		return int(v), nil
+	case int64:
+		if v < math.MinInt || v > math.MaxInt {
+			return 0, fmt.Errorf("argument %q is out of range: %d", key, v)
+		}
+		return int(v), nil
+	case float64:
+		number = v
+	case float32:
+		number = float64(v)
+	case json.Number, string:
+		text := fmt.Sprint(v)
+		trimmed := strings.TrimSpace(text)
+		if parsed, err := strconv.Atoi(trimmed); err == nil {
+			return parsed, nil
+		}
+		parsed, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil {
+			return 0, fmt.Errorf("argument %q must be an integer, got %q", key, text)
+		}
+		number = parsed
+	default:
+		return 0, fmt.Errorf("argument %q must be an integer, got %s", key, jsonTypeName(value))
+	}
+
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("argument %q must be a finite number", key)
+	}
+	if number != math.Trunc(number) {
+		return 0, fmt.Errorf("argument %q must be an integer, got %g", key, number)
+	}
+	// float64(math.MaxInt) rounds up to 2^63, which no longer fits
+	if number < math.MinInt || number >= math.MaxInt {
+		return 0, fmt.Errorf("argument %q is out of range: %g", key, number)
+	}
+	return int(number), nil
+}
@@ -0,0 +1,143 @@
+// ************************************************************************************************
+// Package mcp provides the authentication of the MCP endpoints.
+// When API keys or an identity provider are configured, requests to /mcp, /mcp/ws and the admin
+// API must carry an API key or a valid access token in an "Authorization: Bearer <token>" header
+// and are otherwise answered with 401 Unauthorized. Keys are compared in constant time;
+// token-authenticated clients are restricted to the repositories of their scopes. /health stays
+// open for probes.
+package mcp
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// apiKeyDigests returns the SHA-256 digests of the configured API keys, so keys of any length
+// are compared in constant time.
+func (s *Server) apiKeyDigests() [][sha256.Size]byte {
+	if s.config == nil {
+		return nil
+	}
+	digests := make([][sha256.Size]byte, 0, len(s.config.Server.Auth.APIKeys))
+	for _, key := range s.config.Server.Auth.APIKeys {
+		digests = append(digests, sha256.Sum256([]byte(strings.TrimSpace(key))))
+	}
+	return digests
+}
+
+// ************************************************************************************************
+// withAuthentication wraps an HTTP handler so only requests carrying a configured API key or a
+// valid access token run it, with the client of a token in their context. The handler is
+// returned unchanged when no credential is configured.
+//
+// Example usage:
+//
+//	mux.HandleFunc("/mcp", s.withAuthentication(s.handleMCPEndpoint))
+func (s *Server) withAuthentication(next http.HandlerFunc) http.HandlerFunc {
+	digests := s.apiKeyDigests()
+	verifier := s.tokenVerifier
+	if len(digests) == 0 && verifier == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// CORS preflight requests never carry credentials
+		if r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+
+		token, found := bearerToken(r)
+		if !found {
+			slog.Warn("Rejecting request: missing bearer token", "remote", r.RemoteAddr)
+			sendUnauthorized(w, "", "missing Authorization: Bearer header")
+			return
+		}
+		if index := matchingDigest(digests, token); index >= 0 {
+			next(w, r.WithContext(withClientKey(r.Context(), fmt.Sprintf("api-key:%d", index))))
+			return
+		}
+		if verifier == nil || !looksLikeJWT(token) {
+			slog.Warn("Rejecting request: invalid API key", "remote", r.RemoteAddr)
+			sendUnauthorized(w, "invalid_token", "invalid API key")
+			return
+		}
+		client, err := verifier.verify(r.Context(), token)
+		if err != nil {
+			slog.Warn("Rejecting request: invalid access token", "remote", r.RemoteAddr, "error", err)
+			sendUnauthorized(w, "invalid_token", err.Error())
+			return
+		}
+		ctx := withPrincipal(r.Context(), client)
+		if client.subject != "" {
+			ctx = withClientKey(ctx, "subject:"+client.subject)
+		}
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// ************************************************************************************************
+// bearerToken returns the token of the Authorization header of a request.
+//
+// Returns:
+//   - string: The token.
+//   - bool: False if the request has no bearer token.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// ************************************************************************************************
+// matchingDigest returns the index of the key of the digests a token is. Every digest is
+// compared, so the time taken does not tell which key almost matched.
+//
+// Returns:
+//   - int: The index of the key, -1 if the token is none of them.
+func matchingDigest(digests [][sha256.Size]byte, token string) int {
+	digest := sha256.Sum256([]byte(token))
+	index := -1
+	for i := range digests {
+		index = subtle.ConstantTimeSelect(subtle.ConstantTimeCompare(digests[i][:], digest[:]), i, index)
+	}
+	return index
+}
+
+// ************************************************************************************************
+// sendUnauthorized answers an unauthenticated request with a 401 status, a WWW-Authenticate
+// challenge and a JSON-RPC error. errorCode is the RFC 6750 error code, empty when the request
+// carried no credentials.
+func sendUnauthorized(w http.ResponseWriter, errorCode, description string) {
+	challenge := `Bearer realm="repomix-mcp"`
+	if errorCode != "" {
+		challenge += `, error="` + errorCode + `"`
+	}
+
+	response := types.JSONRPCResponse{
+		JsonRPC: "2.0",
+		Error: &types.JSONRPCError{
+			Code:    -32000,
+			Message: "Unauthorized",
+			Data:    description,
+		},
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", challenge)
+	w.WriteHeader(http.StatusUnauthorized)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Failed to encode JSON-RPC error response", "error", err)
+	}
+}
@@ -0,0 +1,75 @@
+// ************************************************************************************************
+// Package mcp provides the cancellation of the requests handled by the MCP server.
+// Every request has a context, cancelled when its HTTP client disconnects, when its WebSocket
+// session ends or when the client sends notifications/cancelled for it. Tools pass the context
+// to the Go module retrieval and to the indexing jobs they wait for, so the go, git and repomix
+// processes of a cancelled request stop.
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// inFlightKey identifies a request being handled: its JSON-RPC ID within the client session it
+// belongs to, or among the requests without session.
+type inFlightKey struct {
+	scope *session // Client session, nil for requests without session
+	id    string
+}
+
+// ************************************************************************************************
+// requestKey returns the key of a request ID received with the session of ctx.
+func requestKey(ctx context.Context, id interface{}) inFlightKey {
+	return inFlightKey{scope: sessionFromContext(ctx), id: fmt.Sprint(id)}
+}
+
+// ************************************************************************************************
+// trackRequest registers a request being handled so notifications/cancelled can cancel it.
+//
+// Returns:
+//   - context.Context: The context of the request, cancelled with ctx or by the client.
+//   - func(): The function to call once the request is answered.
+func (s *Server) trackRequest(ctx context.Context, id interface{}) (context.Context, func()) {
+	key := requestKey(ctx, id)
+	ctx, cancel := context.WithCancel(ctx)
+
+	s.inFlightMu.Lock()
+	s.inFlight[key] = cancel
+	s.inFlightMu.Unlock()
+
+	return ctx, func() {
+		s.inFlightMu.Lock()
+		delete(s.inFlight, key)
+		s.inFlightMu.Unlock()
+		cancel()
+	}
+}
+
+// ************************************************************************************************
+// handleCancelled handles the notifications/cancelled notification, cancelling the request it
+// names when it is still being handled in the same session.
+func (s *Server) handleCancelled(ctx context.Context, w http.ResponseWriter, req types.JSONRPCRequest) {
+	var params struct {
+		RequestID interface{} `json:"requestId"`
+		Reason    string      `json:"reason"`
+	}
+	if err := s.parseParams(req.Params, &params); err == nil && params.RequestID != nil {
+		key := requestKey(ctx, params.RequestID)
+		s.inFlightMu.Lock()
+		cancel, exists := s.inFlight[key]
+		s.inFlightMu.Unlock()
+		if exists {
+			slog.InfoContext(ctx, "Cancelling request", "id", key.id, "reason", params.Reason)
+			cancel()
+		}
+	}
+
+	// Notifications have no JSON-RPC response
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,221 @@
+// ************************************************************************************************
+// Package mcp provides the logging capability of the MCP server.
+// Server log lines and the progress messages of tool calls are sent to clients as
+// notifications/message at or above a minimum level, server.logLevel until a client changes
+// it with logging/setLevel. The level set by a client with a session applies to that session
+// only. Levels are the syslog severities of the MCP specification.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"repomix-mcp/internal/logging"
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// clientLogLevels are the MCP log levels, from the least to the most severe.
+var clientLogLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// ************************************************************************************************
+// clientLogQueueSize is the number of server log lines waiting to be sent to clients; lines
+// logged while the queue is full are not sent.
+const clientLogQueueSize = 256
+
+// ************************************************************************************************
+// clientLogSeverity returns the rank of an MCP log level in clientLogLevels.
+//
+// Returns:
+//   - int: The rank, higher for more severe levels.
+//   - bool: False if the name is not an MCP log level.
+func clientLogSeverity(level string) (int, bool) {
+	for severity, name := range clientLogLevels {
+		if name == level {
+			return severity, true
+		}
+	}
+	return 0, false
+}
+
+// ************************************************************************************************
+// clientLogLevel returns the MCP log level matching a server log level.
+func clientLogLevel(level logging.Level) string {
+	switch level {
+	case logging.LevelTrace, logging.LevelDebug:
+		return "debug"
+	case logging.LevelWarning:
+		return "warning"
+	case logging.LevelError:
+		return "error"
+	case logging.LevelCritical:
+		return "critical"
+	}
+	return "info"
+}
+
+// ************************************************************************************************
+// clientLogEnabled reports whether messages of an MCP log level are sent to the clients without
+// session.
+func (s *Server) clientLogEnabled(level string) bool {
+	severity, ok := clientLogSeverity(level)
+	return ok && int32(severity) >= s.clientLogMin.Load()
+}
+
+// ************************************************************************************************
+// sessionLogEnabled reports whether messages of an MCP log level are sent to the client of a
+// session, or to the clients without session when sess is nil.
+func (s *Server) sessionLogEnabled(sess *session, level string) bool {
+	if sess == nil {
+		return s.clientLogEnabled(level)
+	}
+	severity, ok := clientLogSeverity(level)
+	return ok && int32(severity) >= sess.logMin.Load()
+}
+
+// ************************************************************************************************
+// handleLoggingSetLevel handles the logging/setLevel request, for the session of the client or
+// for every client without session.
+func (s *Server) handleLoggingSetLevel(ctx context.Context, w http.ResponseWriter, req types.JSONRPCRequest) {
+	var params struct {
+		Level string `json:"level"`
+	}
+	if err := s.parseParams(req.Params, &params); err != nil {
+		s.sendJSONRPCError(w, req.ID, -32602, "Invalid params", fmt.Sprintf("Failed to parse parameters: %v", err))
+		return
+	}
+	severity, ok := clientLogSeverity(params.Level)
+	if !ok {
+		s.sendJSONRPCError(w, req.ID, -32602, "Invalid params", fmt.Sprintf("Invalid log level: %s, expected one of %s", params.Level, strings.Join(clientLogLevels, ", ")))
+		return
+	}
+
+	if sess := sessionFromContext(ctx); sess != nil {
+		sess.logMin.Store(int32(severity))
+		slog.Info("Client log level set", "session", sess.id, "level", params.Level)
+	} else {
+		s.clientLogMin.Store(int32(severity))
+		slog.Info("Client log level set", "level", params.Level)
+	}
+	s.sendJSONRPCResult(w, req.ID, map[string]interface{}{})
+}
+
+// ************************************************************************************************
+// LogHandler returns a handler sending the server log messages to the open GET event streams
+// and WebSocket sessions, for the default logger. Messages are queued and sent in the
+// background by the running server, so logging never waits for clients.
+//
+// Example usage:
+//
+//	logging.AddHandler(server.LogHandler())
+func (s *Server) LogHandler() slog.Handler {
+	return &clientLogHandler{server: s}
+}
+
+// ************************************************************************************************
+// clientLogHandler queues the log messages clients receive at their level, as text followed by
+// their attributes.
+type clientLogHandler struct {
+	server *Server
+	attrs  string // Attributes added by WithAttrs, formatted
+	group  string // Prefix of the attribute keys, from WithGroup
+}
+
+// ************************************************************************************************
+// Enabled reports whether a client listens to messages of a level.
+func (c *clientLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	streams := c.server.openStreams()
+	return len(streams) > 0 && c.server.anyLogEnabled(streams, clientLogLevel(logging.FromSlogLevel(level)))
+}
+
+// ************************************************************************************************
+// Handle queues a message, dropping it when the queue is full.
+func (c *clientLogHandler) Handle(_ context.Context, record slog.Record) error {
+	var text strings.Builder
+	text.WriteString(logging.StripLogPrefix(record.Message))
+	text.WriteString(c.attrs)
+	record.Attrs(func(attr slog.Attr) bool {
+		writeClientLogAttr(&text, c.group, attr)
+		return true
+	})
+
+	select {
+	case c.server.clientLogs <- messageParams(clientLogLevel(logging.FromSlogLevel(record.Level)), text.String()):
+	default:
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// WithAttrs returns a handler adding attributes to the messages.
+func (c *clientLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var text strings.Builder
+	text.WriteString(c.attrs)
+	for _, attr := range attrs {
+		writeClientLogAttr(&text, c.group, attr)
+	}
+	return &clientLogHandler{server: c.server, attrs: text.String(), group: c.group}
+}
+
+// ************************************************************************************************
+// WithGroup returns a handler prefixing the keys of the attributes with a group name.
+func (c *clientLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return c
+	}
+	return &clientLogHandler{server: c.server, attrs: c.attrs, group: c.group + name + "."}
+}
+
+// ************************************************************************************************
+// writeClientLogAttr appends " key=value" to the text of a message, groups included.
+func writeClientLogAttr(text *strings.Builder, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		for _, member := range attr.Value.Group() {
+			writeClientLogAttr(text, prefix+attr.Key+".", member)
+		}
+		return
+	}
+	fmt.Fprintf(text, " %s%s=%v", prefix, attr.Key, attr.Value)
+}
+
+// ************************************************************************************************
+// anyLogEnabled reports whether messages of an MCP log level are sent to any of the streams.
+func (s *Server) anyLogEnabled(streams map[messageSink]*session, level string) bool {
+	for _, sess := range streams {
+		if s.sessionLogEnabled(sess, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// ************************************************************************************************
+// forwardClientLogs sends the queued log lines to the open streams until the server stops.
+// Send failures are not logged, which would queue another line to send.
+func (s *Server) forwardClientLogs() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case params := <-s.clientLogs:
+			data, err := json.Marshal(types.JSONRPCRequest{JsonRPC: "2.0", Method: "notifications/message", Params: params})
+			if err != nil {
+				continue
+			}
+			level, _ := params["level"].(string)
+			for stream, sess := range s.openStreams() {
+				if s.sessionLogEnabled(sess, level) {
+					stream.send(data)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// ************************************************************************************************
+// Package mcp provides the response compression of the MCP HTTP endpoint.
+// Responses are buffered, which JSON-RPC responses already are, and compressed with gzip or
+// deflate when the client accepts it and the body is large enough to benefit.
+package mcp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// defaultCompressionMinSize is the body size from which responses are compressed when
+// server.compressionMinSize is not configured.
+const defaultCompressionMinSize = 1 << 10
+
+// ************************************************************************************************
+// compressionMinSize returns the body size from which responses are compressed, 0 when
+// compression is disabled with "0".
+func (s *Server) compressionMinSize() int {
+	if s.config == nil || s.config.Server.CompressionMinSize == "" {
+		return defaultCompressionMinSize
+	}
+	if strings.TrimSpace(s.config.Server.CompressionMinSize) == "0" {
+		return 0
+	}
+	size, err := types.ParseByteSize(s.config.Server.CompressionMinSize)
+	if err != nil {
+		return defaultCompressionMinSize
+	}
+	return int(size)
+}
+
+// ************************************************************************************************
+// withCompression wraps an HTTP handler so its response is compressed when the client accepts
+// gzip or deflate and the body reaches the minimum size.
+//
+// Example usage:
+//
+//	mux.HandleFunc("/mcp", s.withCompression(s.handleMCPEndpoint))
+func (s *Server) withCompression(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		minSize := s.compressionMinSize()
+		if minSize == 0 {
+			next(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			next(w, r)
+			return
+		}
+
+		buffered := &bufferedResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next(buffered, r)
+		buffered.finish(encoding, minSize)
+	}
+}
+
+// ************************************************************************************************
+// negotiateEncoding picks the response encoding from an Accept-Encoding header: gzip, then
+// deflate, among the codings the client accepts with a non-zero quality.
+//
+// Returns:
+//   - string: "gzip", "deflate" or an empty string for an uncompressed response.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.EqualFold(strings.TrimSpace(name), "q") {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if coding != "" {
+			accepted[coding] = quality > 0
+		}
+	}
+
+	for _, coding := range []string{"gzip", "deflate"} {
+		if allowed, listed := accepted[coding]; listed {
+			if allowed {
+				return coding
+			}
+			continue
+		}
+		if accepted["*"] {
+			return coding
+		}
+	}
+	return ""
+}
+
+// ************************************************************************************************
+// bufferedResponseWriter holds a response until the handler returns, so its size is known
+// before choosing whether to compress it.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	streaming bool // Flushed by the handler, e.g. an event stream: written through uncompressed
+}
+
+// ************************************************************************************************
+// WriteHeader records the status code, sent once the response is complete.
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+// ************************************************************************************************
+// Write buffers part of the response body.
+func (b *bufferedResponseWriter) Write(data []byte) (int, error) {
+	if b.streaming {
+		return b.ResponseWriter.Write(data)
+	}
+	return b.body.Write(data)
+}
+
+// ************************************************************************************************
+// FlushError sends what is buffered uncompressed and writes the rest of the response through,
+// since a handler flushing its response streams it.
+func (b *bufferedResponseWriter) FlushError() error {
+	if !b.streaming {
+		b.streaming = true
+		b.ResponseWriter.WriteHeader(b.status)
+		if _, err := b.ResponseWriter.Write(b.body.Bytes()); err != nil {
+			return err
+		}
+		b.body.Reset()
+	}
+	return http.NewResponseController(b.ResponseWriter).Flush()
+}
+
+// ************************************************************************************************
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (b *bufferedResponseWriter) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
+
+// ************************************************************************************************
+// finish sends the buffered response, compressed with the given encoding when the body
+// reaches minSize bytes.
+func (b *bufferedResponseWriter) finish(encoding string, minSize int) {
+	if b.streaming {
+		return
+	}
+
+	w := b.ResponseWriter
+	if b.body.Len() < minSize || w.Header().Get("Content-Encoding") != "" {
+		w.WriteHeader(b.status)
+		w.Write(b.body.Bytes())
+		return
+	}
+
+	w.Header().Set("Content-Encoding", encoding)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(b.status)
+
+	var compressor io.WriteCloser
+	if encoding == "gzip" {
+		compressor = gzip.NewWriter(w)
+	} else {
+		compressor = zlib.NewWriter(w)
+	}
+	compressor.Write(b.body.Bytes())
+	compressor.Close()
+}
@@ -0,0 +1,134 @@
+// ************************************************************************************************
+// Package mcp provides the eviction of in-memory repositories of the MCP server.
+// Repositories held in memory are dropped once idle for too long or when more of them are held
+// than configured, least recently used first. The cache remains authoritative: an evicted
+// repository is reloaded from it the next time it is requested.
+package mcp
+
+import (
+	"log/slog"
+	"sort"
+	"time"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// memoryIdleTimeout returns the time after which an unused in-memory repository is evicted,
+// 0 when idle eviction is disabled.
+func (s *Server) memoryIdleTimeout() time.Duration {
+	if s.config == nil {
+		return 0
+	}
+	return parseTimeout(s.config.Server.MemoryIdleTimeout, 0)
+}
+
+// ************************************************************************************************
+// maxMemoryRepositories returns the number of repositories held in memory before the least
+// recently used ones are evicted, 0 when the count is unlimited.
+func (s *Server) maxMemoryRepositories() int {
+	if s.config == nil || s.config.Server.MaxMemoryRepositories < 0 {
+		return 0
+	}
+	return s.config.Server.MaxMemoryRepositories
+}
+
+// ************************************************************************************************
+// storeMemoryRepositoryLocked holds a repository in memory as just used, then applies the
+// eviction policy. The caller holds s.reposMu for writing.
+func (s *Server) storeMemoryRepositoryLocked(repositoryID string, now time.Time) {
+	s.repoAccess[repositoryID] = now
+	delete(s.evicted, repositoryID)
+	s.evictRepositoriesLocked(now)
+}
+
+// ************************************************************************************************
+// evictRepositoriesLocked drops the in-memory repositories idle for longer than the idle
+// timeout, then the least recently used ones above the maximum count. Nothing is evicted
+// without a cache to reload from. The caller holds s.reposMu for writing.
+//
+// Returns:
+//   - []string: The IDs of the evicted repositories.
+func (s *Server) evictRepositoriesLocked(now time.Time) []string {
+	if s.cache == nil {
+		return nil
+	}
+
+	var evicted []string
+	if idleTimeout := s.memoryIdleTimeout(); idleTimeout > 0 {
+		for repoID := range s.repositories {
+			if now.Sub(s.repoAccess[repoID]) > idleTimeout {
+				evicted = append(evicted, repoID)
+			}
+		}
+		for _, repoID := range evicted {
+			s.evictRepositoryLocked(repoID)
+		}
+	}
+
+	if maxCount := s.maxMemoryRepositories(); maxCount > 0 && len(s.repositories) > maxCount {
+		repoIDs := make([]string, 0, len(s.repositories))
+		for repoID := range s.repositories {
+			repoIDs = append(repoIDs, repoID)
+		}
+		sort.Slice(repoIDs, func(i, j int) bool {
+			return s.repoAccess[repoIDs[i]].Before(s.repoAccess[repoIDs[j]])
+		})
+		for _, repoID := range repoIDs[:len(repoIDs)-maxCount] {
+			s.evictRepositoryLocked(repoID)
+			evicted = append(evicted, repoID)
+		}
+	}
+
+	if len(evicted) > 0 && s.verbose {
+		slog.Info("Evicted repositories from memory", "repositories", evicted)
+	}
+	return evicted
+}
+
+// ************************************************************************************************
+// evictRepositoryLocked drops a repository from memory, remembering it so it is reloaded from
+// the cache on demand. The caller holds s.reposMu for writing.
+func (s *Server) evictRepositoryLocked(repositoryID string) {
+	delete(s.repositories, repositoryID)
+	delete(s.repoAccess, repositoryID)
+	s.evicted[repositoryID] = true
+}
+
+// ************************************************************************************************
+// reloadEvictedRepository reads an evicted repository back from the cache into memory.
+//
+// Returns:
+//   - *types.RepositoryIndex: The repository, nil if it was not evicted or is no longer cached.
+func (s *Server) reloadEvictedRepository(repositoryID string) *types.RepositoryIndex {
+	s.reposMu.RLock()
+	wasEvicted := s.evicted[repositoryID]
+	s.reposMu.RUnlock()
+	if !wasEvicted || s.cache == nil {
+		return nil
+	}
+
+	// The cache is read without holding the lock, another request may reload it meanwhile
+	repo, err := s.cache.GetRepository(repositoryID)
+	if err != nil {
+		slog.Warn("Failed to reload evicted repository", "repository", repositoryID, "error", err)
+		return nil
+	}
+
+	s.reposMu.Lock()
+	defer s.reposMu.Unlock()
+	if current, exists := s.repositories[repositoryID]; exists {
+		s.repoAccess[repositoryID] = time.Now()
+		return current
+	}
+	if !s.evicted[repositoryID] {
+		// Dropped by a reload of the cache in the meantime
+		return nil
+	}
+	s.repositories[repositoryID] = repo
+	s.storeMemoryRepositoryLocked(repositoryID, time.Now())
+	if s.verbose {
+		slog.Info("Reloaded evicted repository from cache", "repository", repositoryID)
+	}
+	return repo
+}
@@ -0,0 +1,233 @@
+// ************************************************************************************************
+// Package mcp provides the lookup of repositories by file path for the MCP server.
+// Agents often know a file path, from a stack trace or an editor, but not the repository it
+// belongs to; the indexed file paths of every served repository are searched for it and the
+// owning repositories are ranked by how specific the match is.
+package mcp
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// File path match kinds, from the most to the least specific.
+const (
+	fileMatchExact     = 3 // The query is the whole relative path
+	fileMatchSuffix    = 2 // The query is a trailing run of path components
+	fileMatchSubstring = 1 // The query appears anywhere in the path
+)
+
+// ************************************************************************************************
+// fileMatch is an indexed file whose path matches a queried path.
+type fileMatch struct {
+	RepositoryID string
+	Path         string // Path relative to the repository root
+	Kind         int    // One of the fileMatch constants
+}
+
+// ************************************************************************************************
+// normalizeFilePathQuery turns a queried path into the form of indexed file paths: forward
+// slashes, no leading "./" or "/".
+func normalizeFilePathQuery(query string) string {
+	query = strings.TrimSpace(strings.ReplaceAll(query, "\\", "/"))
+	for strings.HasPrefix(query, "./") {
+		query = query[2:]
+	}
+	return strings.TrimLeft(query, "/")
+}
+
+// ************************************************************************************************
+// matchFilePath reports how a relative file path matches a normalized query, 0 when it does not.
+// Paths are compared case-sensitively, as on most file systems.
+func matchFilePath(filePath, query string) int {
+	switch {
+	case filePath == query:
+		return fileMatchExact
+	case strings.HasSuffix(filePath, "/"+query):
+		return fileMatchSuffix
+	case strings.Contains(filePath, query):
+		return fileMatchSubstring
+	}
+	return 0
+}
+
+// ************************************************************************************************
+// relativeToRepository returns an absolute query path relative to a repository root, so a path
+// copied from the machine running the server matches exactly.
+//
+// Returns:
+//   - string: The path relative to the root.
+//   - bool: False if the query is not under the root.
+func relativeToRepository(query, root string) (string, bool) {
+	if root == "" || !filepath.IsAbs(query) {
+		return "", false
+	}
+	relative, err := filepath.Rel(root, query)
+	if err != nil || relative == "." || strings.HasPrefix(relative, "..") {
+		return "", false
+	}
+	return filepath.ToSlash(relative), true
+}
+
+// ************************************************************************************************
+// maxFileSuggestions is the number of similar paths suggested when a requested file is missing.
+const maxFileSuggestions = 5
+
+// ************************************************************************************************
+// findRepositoryFile returns the indexed file of a repository at a path, relative to its root or
+// absolute on the server.
+//
+// Returns:
+//   - types.IndexedFile: The file.
+//   - bool: False if the repository has no file at this path.
+//   - []string: When the file is missing, the most similar indexed paths.
+func findRepositoryFile(repo *types.RepositoryIndex, query string) (types.IndexedFile, bool, []string) {
+	normalized := normalizeFilePathQuery(query)
+	if relative, ok := relativeToRepository(query, repo.Path); ok {
+		normalized = relative
+	}
+	if file, exists := repo.Files[normalized]; exists {
+		return file, true, nil
+	}
+	if normalized == "" {
+		return types.IndexedFile{}, false, nil
+	}
+
+	// Suggest the paths ending with the same components, or else containing the file name
+	var suffixes, names []string
+	name := normalized[strings.LastIndex(normalized, "/")+1:]
+	for filePath := range repo.Files {
+		switch {
+		case matchFilePath(filePath, normalized) == fileMatchSuffix:
+			suffixes = append(suffixes, filePath)
+		case strings.Contains(filePath, name):
+			names = append(names, filePath)
+		}
+	}
+	sort.Strings(suffixes)
+	sort.Strings(names)
+	suggestions := append(suffixes, names...)
+	if len(suggestions) > maxFileSuggestions {
+		suggestions = suggestions[:maxFileSuggestions]
+	}
+	return types.IndexedFile{}, false, suggestions
+}
+
+// ************************************************************************************************
+// findFileMatches searches the indexed file paths of every enabled repository for a path.
+//
+// Returns:
+//   - []fileMatch: The matching files, most specific first: exact paths, then trailing path
+//     components, then substrings; within a kind, paths the query covers more of come first.
+func (s *Server) findFileMatches(query string) []fileMatch {
+	normalized := normalizeFilePathQuery(query)
+	if normalized == "" {
+		return nil
+	}
+
+	var matches []fileMatch
+	for _, repoID := range s.servedRepositoryIDs() {
+		repo, err := s.lookupRepository(repoID)
+		if err != nil {
+			continue
+		}
+		repoQuery := normalized
+		if relative, ok := relativeToRepository(query, repo.Path); ok {
+			repoQuery = relative
+		}
+		for filePath := range repo.Files {
+			if kind := matchFilePath(filePath, repoQuery); kind > 0 {
+				matches = append(matches, fileMatch{RepositoryID: repoID, Path: filePath, Kind: kind})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Kind != b.Kind {
+			return a.Kind > b.Kind
+		}
+		if len(a.Path) != len(b.Path) {
+			return len(a.Path) < len(b.Path) // The query covers more of a shorter path
+		}
+		if a.RepositoryID != b.RepositoryID {
+			return a.RepositoryID < b.RepositoryID
+		}
+		return a.Path < b.Path
+	})
+	return matches
+}
+
+// ************************************************************************************************
+// servedRepositoryIDs returns the sorted IDs of the enabled repositories, cached or in memory.
+func (s *Server) servedRepositoryIDs() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(repoID string) {
+		if !seen[repoID] && !s.isRepositoryDisabled(repoID) {
+			seen[repoID] = true
+			ids = append(ids, repoID)
+		}
+	}
+
+	if s.cache != nil {
+		if repoIDs, err := s.cache.ListRepositories(); err == nil {
+			for _, repoID := range repoIDs {
+				add(repoID)
+			}
+		}
+	}
+	for _, repoID := range s.memoryRepositoryIDs() {
+		add(repoID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ************************************************************************************************
+// formatFileMatches renders file matches as Markdown, grouped by repository in rank order.
+//
+// Returns:
+//   - string: The Markdown document, listing at most limit files.
+func formatFileMatches(query string, matches []fileMatch, limit int) string {
+	kindLabels := map[int]string{
+		fileMatchExact:     "exact path",
+		fileMatchSuffix:    "path suffix",
+		fileMatchSubstring: "partial path",
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("# Repositories containing %s\n\n", query))
+
+	shown := matches
+	if limit > 0 && len(shown) > limit {
+		shown = shown[:limit]
+	}
+
+	var order []string
+	byRepository := make(map[string][]fileMatch)
+	for _, match := range shown {
+		if _, exists := byRepository[match.RepositoryID]; !exists {
+			order = append(order, match.RepositoryID)
+		}
+		byRepository[match.RepositoryID] = append(byRepository[match.RepositoryID], match)
+	}
+
+	for _, repoID := range order {
+		text.WriteString(fmt.Sprintf("## %s\n\n", repoID))
+		for _, match := range byRepository[repoID] {
+			text.WriteString(fmt.Sprintf("- %s (%s)\n", match.Path, kindLabels[match.Kind]))
+		}
+		text.WriteString("\n")
+	}
+
+	if len(shown) < len(matches) {
+		text.WriteString(fmt.Sprintf("%d more matching files not shown, use a more specific path or a higher limit.\n", len(matches)-len(shown)))
+	}
+	return text.String()
+}
@@ -0,0 +1,69 @@
+// ************************************************************************************************
+// Package mcp provides the construction of the HTTP and HTTPS servers of the MCP server.
+// Every server applies the connection timeouts of the configuration, falling back to defaults
+// that keep slow or idle clients from holding connections open indefinitely, and the HTTP
+// listen addresses are all bound before any of them serves.
+package mcp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ************************************************************************************************
+// listenAll binds every address, closing the ones already bound when one of them fails so a bad
+// address leaves no listener behind.
+//
+// Returns:
+//   - []net.Listener: One listener per address, in order.
+//   - error: An error naming the first address that cannot be bound.
+//
+// Example usage:
+//
+//	listeners, err := listenAll([]string{"127.0.0.1:8080", "[::1]:8080"})
+//	if err != nil {
+//		return err
+//	}
+func listenAll(addresses []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addresses))
+	for _, address := range addresses {
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// ************************************************************************************************
+// newHTTPServer creates an http.Server with the configured connection timeouts applied.
+// Unset or invalid timeouts fall back to safe defaults so slow or idle clients
+// cannot hold connections open indefinitely.
+func (s *Server) newHTTPServer(address string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: parseTimeout(s.config.Server.ReadHeaderTimeout, 10*time.Second),
+		ReadTimeout:       parseTimeout(s.config.Server.ReadTimeout, 30*time.Second),
+		WriteTimeout:      parseTimeout(s.config.Server.WriteTimeout, 5*time.Minute),
+		IdleTimeout:       parseTimeout(s.config.Server.IdleTimeout, 120*time.Second),
+	}
+}
+
+// parseTimeout parses a duration string, returning def when it is empty or invalid.
+func parseTimeout(value string, def time.Duration) time.Duration {
+	if value == "" {
+		return def
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		return def
+	}
+	return parsed
+}
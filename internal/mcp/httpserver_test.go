@@ -0,0 +1,126 @@
+// ************************************************************************************************
+// Package mcp - Unit tests for the construction of the HTTP servers.
+package mcp
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// Test that parseTimeout falls back to the default for empty, invalid and negative durations
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 7 * time.Second},
+		{"45s", 45 * time.Second},
+		{"2m30s", 150 * time.Second},
+		{"0s", 0},
+		{"-1s", 7 * time.Second},
+		{"soon", 7 * time.Second},
+		{"30", 7 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := parseTimeout(tt.value, 7*time.Second); got != tt.expected {
+			t.Errorf("parseTimeout(%q): expected %v, got %v", tt.value, tt.expected, got)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that the HTTP servers apply the configured connection timeouts, or the defaults
+func TestServer_newHTTPServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   types.ServerConfig
+		expected [4]time.Duration // ReadHeader, Read, Write, Idle
+	}{
+		{
+			name:     "Defaults",
+			config:   types.ServerConfig{},
+			expected: [4]time.Duration{10 * time.Second, 30 * time.Second, 5 * time.Minute, 120 * time.Second},
+		},
+		{
+			name: "Configured",
+			config: types.ServerConfig{
+				ReadHeaderTimeout: "2s",
+				ReadTimeout:       "1m",
+				WriteTimeout:      "10m",
+				IdleTimeout:       "0s",
+			},
+			expected: [4]time.Duration{2 * time.Second, time.Minute, 10 * time.Minute, 0},
+		},
+		{
+			name:     "Invalid",
+			config:   types.ServerConfig{ReadHeaderTimeout: "fast", WriteTimeout: "-5m"},
+			expected: [4]time.Duration{10 * time.Second, 30 * time.Second, 5 * time.Minute, 120 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &Server{config: &types.Config{Server: tt.config}}
+			handler := http.NotFoundHandler()
+
+			httpServer := server.newHTTPServer("127.0.0.1:8080", handler)
+			if httpServer.Addr != "127.0.0.1:8080" || httpServer.Handler == nil {
+				t.Errorf("Expected the address and handler to be set, got %q and %v", httpServer.Addr, httpServer.Handler)
+			}
+
+			got := [4]time.Duration{httpServer.ReadHeaderTimeout, httpServer.ReadTimeout, httpServer.WriteTimeout, httpServer.IdleTimeout}
+			if got != tt.expected {
+				t.Errorf("Expected timeouts %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// ************************************************************************************************
+// Test that listenAll binds every address, and closes them all when one cannot be bound
+func TestListenAll(t *testing.T) {
+	listeners, err := listenAll([]string{"127.0.0.1:0", "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	if len(listeners) != 2 || listeners[0].Addr().String() == listeners[1].Addr().String() {
+		t.Fatalf("Expected two distinct listeners, got %v", listeners)
+	}
+	for _, listener := range listeners {
+		listener.Close()
+	}
+
+	// The second address is already taken, so the first one must be released
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer busy.Close()
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	freeAddress := free.Addr().String()
+	free.Close()
+
+	if _, err := listenAll([]string{freeAddress, busy.Addr().String()}); err == nil {
+		t.Fatalf("Expected an error for an address already in use")
+	}
+	released, err := net.Listen("tcp", freeAddress)
+	if err != nil {
+		t.Errorf("Expected %s to be released after the failure: %v", freeAddress, err)
+	} else {
+		released.Close()
+	}
+
+	if _, err := listenAll([]string{"127.0.0.1"}); err == nil {
+		t.Errorf("Expected an error for an address without a port")
+	}
+}
@@ -0,0 +1,85 @@
+// ************************************************************************************************
+// Package mcp provides the lookup of interface implementations for the MCP server.
+// The implementation index recorded by the Go parser is queried by interface name, listing
+// its implementations, or by type name, listing the interfaces the type implements.
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// qualifiedNameMatches reports whether a name qualified by import path matches a query: the
+// qualified name itself, "pkg.Name" for the last import path element, or the bare name.
+func qualifiedNameMatches(qualified, query string) bool {
+	if qualified == query || strings.HasSuffix(qualified, "/"+query) {
+		return true
+	}
+	return !strings.Contains(query, ".") && strings.HasSuffix(qualified, "."+query)
+}
+
+// ************************************************************************************************
+// formatImplementations renders the entries of an implementation index matching a name as
+// Markdown. Matching interfaces are listed with their implementations; when no interface
+// matches, the interfaces implemented by the matching types are listed instead.
+//
+// Returns:
+//   - string: The Markdown document.
+//   - bool: Whether any interface or type matched.
+func formatImplementations(repositoryID, name string, index []types.InterfaceImplementations) (string, bool) {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("# Implementations: %s in %s\n\n", name, repositoryID))
+
+	matched := false
+	for _, entry := range index {
+		if !qualifiedNameMatches(entry.Interface, name) {
+			continue
+		}
+		matched = true
+		text.WriteString(fmt.Sprintf("## interface %s\n\nDeclared in %s:%d\n\n", entry.Interface, entry.File, entry.Line))
+		if len(entry.Implementations) == 0 {
+			text.WriteString("No implementations in this repository.\n\n")
+			continue
+		}
+		for _, implementation := range entry.Implementations {
+			text.WriteString(fmt.Sprintf("- %s (%s:%d)\n", implementationTypeName(implementation), implementation.File, implementation.Line))
+		}
+		text.WriteString("\n")
+	}
+	if matched {
+		return text.String(), true
+	}
+
+	// No interface of that name, list what the types of that name implement
+	implemented := make(map[string][]string)
+	var typeNames []string
+	for _, entry := range index {
+		for _, implementation := range entry.Implementations {
+			if !qualifiedNameMatches(implementation.Type, name) {
+				continue
+			}
+			if _, exists := implemented[implementation.Type]; !exists {
+				typeNames = append(typeNames, implementation.Type)
+			}
+			implemented[implementation.Type] = append(implemented[implementation.Type],
+				fmt.Sprintf("- %s as %s (%s:%d)\n", entry.Interface, implementationTypeName(implementation), entry.File, entry.Line))
+		}
+	}
+	for _, typeName := range typeNames {
+		text.WriteString(fmt.Sprintf("## type %s implements\n\n%s\n", typeName, strings.Join(implemented[typeName], "")))
+	}
+	return text.String(), len(typeNames) > 0
+}
+
+// ************************************************************************************************
+// implementationTypeName returns the implementing type as written in Go, "*T" when only the
+// pointer type implements the interface.
+func implementationTypeName(implementation types.Implementation) string {
+	if implementation.Pointer {
+		return "*" + implementation.Type
+	}
+	return implementation.Type
+}
\ No newline at end of file
@@ -0,0 +1,342 @@
+// ************************************************************************************************
+// Package mcp provides background indexing jobs for the MCP server.
+// Clients start the indexing of a configured repository with the index-repository tool and
+// poll its job with get-index-job, so they need no shell access to the server. Jobs run one at
+// a time in the server process; open event streams are notified when a job ends, and clients
+// waiting for a job receive its progress and cancel it when they cancel the call that queued it.
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// RepositoryIndexer defines the interface for indexing a configured repository alias, glob
+// expansions included, and updating the server with the result.
+type RepositoryIndexer interface {
+	IndexRepository(alias string) error
+}
+
+// ************************************************************************************************
+// ContextRepositoryIndexer is implemented by repository indexers reporting the progress of
+// their runs, such as the repositories and files indexed so far, and stopping them when their
+// context is cancelled.
+type ContextRepositoryIndexer interface {
+	IndexRepositoryContext(ctx context.Context, alias string, progress types.ProgressFunc) error
+}
+
+// ************************************************************************************************
+// Indexing job states.
+const (
+	indexJobQueued    = "queued"
+	indexJobRunning   = "running"
+	indexJobSucceeded = "succeeded"
+	indexJobFailed    = "failed"
+)
+
+// ************************************************************************************************
+// maxIndexJobs is the number of jobs kept for status queries; the oldest finished jobs are
+// forgotten first.
+const maxIndexJobs = 100
+
+// ************************************************************************************************
+// indexJob is the indexing of a repository alias started by a client.
+type indexJob struct {
+	ID         string
+	Alias      string
+	Status     string // One of the indexJob states
+	Error      string // Failure cause, for failed jobs
+	Progress   int    // Steps done, as reported by the indexer
+	Total      int    // Steps to do, 0 when unknown
+	Message    string // Last progress message
+	QueuedAt   time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	cancel context.CancelFunc // Cancels the context of the run
+}
+
+// ************************************************************************************************
+// indexJobs tracks the indexing jobs of the server. It is safe for concurrent use.
+type indexJobs struct {
+	mu      sync.Mutex
+	nextID  int
+	jobs    map[string]*indexJob
+	pending  map[string]string          // ID of the queued or running job by alias
+	watchers map[string][]chan indexJob // Channels of the clients waiting for a job, by ID
+	run      sync.Mutex                 // Held by the running job, so jobs run one at a time
+}
+
+// ************************************************************************************************
+// newIndexJobs creates an empty job list.
+func newIndexJobs() *indexJobs {
+	return &indexJobs{
+		jobs:     make(map[string]*indexJob),
+		pending:  make(map[string]string),
+		watchers: make(map[string][]chan indexJob),
+	}
+}
+
+// ************************************************************************************************
+// get returns a copy of a job.
+//
+// Returns:
+//   - indexJob: The job.
+//   - bool: False if no job has this ID.
+func (j *indexJobs) get(id string) (indexJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, exists := j.jobs[id]
+	if !exists {
+		return indexJob{}, false
+	}
+	return *job, true
+}
+
+// ************************************************************************************************
+// watch follows a job: the returned channel receives a copy of the job whenever it progresses
+// and is closed once the job has ended. Copies are dropped while the channel is full. The
+// returned function stops watching and must be called.
+//
+// Returns:
+//   - <-chan indexJob: The job updates, already closed when the job has ended or is unknown.
+//   - func(): The function to stop watching.
+func (j *indexJobs) watch(id string) (<-chan indexJob, func()) {
+	updates := make(chan indexJob, 16)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if job, exists := j.jobs[id]; !exists || job.Status == indexJobSucceeded || job.Status == indexJobFailed {
+		close(updates)
+		return updates, func() {}
+	}
+	j.watchers[id] = append(j.watchers[id], updates)
+
+	return updates, func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		watchers := j.watchers[id]
+		for index, watcher := range watchers {
+			if watcher == updates {
+				j.watchers[id] = append(watchers[:index], watchers[index+1:]...)
+				close(updates)
+				break
+			}
+		}
+		if len(j.watchers[id]) == 0 {
+			delete(j.watchers, id)
+		}
+	}
+}
+
+// ************************************************************************************************
+// cancel cancels a job: a queued job fails as soon as it runs, a running job once the indexer
+// notices. Ended jobs are left unchanged.
+func (j *indexJobs) cancel(id string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if job, exists := j.jobs[id]; exists {
+		job.cancel()
+	}
+}
+
+// ************************************************************************************************
+// list returns copies of the jobs, most recently queued first.
+func (j *indexJobs) list() []indexJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	jobs := make([]indexJob, 0, len(j.jobs))
+	for _, job := range j.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(a, b int) bool {
+		return jobs[a].QueuedAt.After(jobs[b].QueuedAt) || (jobs[a].QueuedAt.Equal(jobs[b].QueuedAt) && jobs[a].ID > jobs[b].ID)
+	})
+	return jobs
+}
+
+// ************************************************************************************************
+// forgetOldJobsLocked drops the oldest finished jobs beyond maxIndexJobs. The caller holds j.mu.
+func (j *indexJobs) forgetOldJobsLocked() {
+	if len(j.jobs) <= maxIndexJobs {
+		return
+	}
+	var finished []*indexJob
+	for _, job := range j.jobs {
+		if job.Status == indexJobSucceeded || job.Status == indexJobFailed {
+			finished = append(finished, job)
+		}
+	}
+	sort.Slice(finished, func(a, b int) bool {
+		return finished[a].FinishedAt.Before(finished[b].FinishedAt)
+	})
+	for _, job := range finished {
+		if len(j.jobs) <= maxIndexJobs {
+			return
+		}
+		delete(j.jobs, job.ID)
+	}
+}
+
+// ************************************************************************************************
+// startIndexJob queues the indexing of a configured repository alias. A job already queued or
+// running for the alias is returned instead of starting another one.
+//
+// Returns:
+//   - indexJob: The job.
+//   - bool: True if a new job was queued.
+//   - error: An error if the alias cannot be indexed from the server.
+func (s *Server) startIndexJob(alias string) (indexJob, bool, error) {
+	if s.repositoryIndexer == nil {
+		return indexJob{}, false, fmt.Errorf("indexing is not available on this server")
+	}
+	repoConfig, exists := s.config.Repositories[alias]
+	if !exists {
+		return indexJob{}, false, fmt.Errorf("no repository %s in the configuration", alias)
+	}
+	if repoConfig.Disabled || !repoConfig.Indexing.Enabled {
+		return indexJob{}, false, fmt.Errorf("repository %s is disabled", alias)
+	}
+
+	jobs := s.indexJobs
+	jobs.mu.Lock()
+	if id, exists := jobs.pending[alias]; exists {
+		job := *jobs.jobs[id]
+		jobs.mu.Unlock()
+		return job, false, nil
+	}
+	jobs.nextID++
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &indexJob{
+		ID:       fmt.Sprintf("index-%d", jobs.nextID),
+		Alias:    alias,
+		Status:   indexJobQueued,
+		QueuedAt: time.Now(),
+		cancel:   cancel,
+	}
+	jobs.jobs[job.ID] = job
+	jobs.pending[alias] = job.ID
+	jobs.forgetOldJobsLocked()
+	queued := *job
+	jobs.mu.Unlock()
+
+	slog.Info("Queued indexing job", "job", job.ID, "repository", alias)
+	go s.runIndexJob(ctx, job)
+	return queued, true, nil
+}
+
+// ************************************************************************************************
+// runIndexJob indexes the repository of a queued job once no other job runs, and notifies the
+// open event streams of the outcome. Indexers implementing ContextRepositoryIndexer stop when
+// ctx is cancelled.
+func (s *Server) runIndexJob(ctx context.Context, job *indexJob) {
+	jobs := s.indexJobs
+	jobs.run.Lock()
+	defer jobs.run.Unlock()
+	defer job.cancel()
+
+	jobs.mu.Lock()
+	job.Status = indexJobRunning
+	job.StartedAt = time.Now()
+	jobs.mu.Unlock()
+	slog.Info("Running indexing job", "job", job.ID, "repository", job.Alias)
+
+	var err error
+	if ctx.Err() != nil {
+		err = fmt.Errorf("cancelled before it started")
+	} else if indexer, ok := s.repositoryIndexer.(ContextRepositoryIndexer); ok {
+		err = indexer.IndexRepositoryContext(ctx, job.Alias, func(progress, total int, message string) {
+			jobs.mu.Lock()
+			defer jobs.mu.Unlock()
+			job.Progress = progress
+			job.Total = total
+			job.Message = message
+			jobs.notifyWatchersLocked(job)
+		})
+	} else {
+		err = s.repositoryIndexer.IndexRepository(job.Alias)
+	}
+
+	jobs.mu.Lock()
+	job.FinishedAt = time.Now()
+	job.Status = indexJobSucceeded
+	if err != nil {
+		job.Status = indexJobFailed
+		job.Error = err.Error()
+	}
+	delete(jobs.pending, job.Alias)
+	jobs.notifyWatchersLocked(job)
+	for _, watcher := range jobs.watchers[job.ID] {
+		close(watcher)
+	}
+	delete(jobs.watchers, job.ID)
+	duration := job.FinishedAt.Sub(job.StartedAt).Round(time.Millisecond)
+	jobs.mu.Unlock()
+
+	if err != nil {
+		slog.Error("Indexing job failed", "job", job.ID, "repository", job.Alias, "error", err)
+		s.broadcastMessage("error", fmt.Sprintf("Indexing job %s for repository %s failed: %v", job.ID, job.Alias, err))
+		return
+	}
+	slog.Info("Indexing job succeeded", "job", job.ID, "repository", job.Alias, "duration", duration)
+	s.broadcastMessage("info", fmt.Sprintf("Indexing job %s for repository %s succeeded in %s", job.ID, job.Alias, duration))
+}
+
+// ************************************************************************************************
+// notifyWatchersLocked sends a copy of a job to the clients waiting for it, without blocking.
+// The caller holds j.mu.
+func (j *indexJobs) notifyWatchersLocked(job *indexJob) {
+	for _, watcher := range j.watchers[job.ID] {
+		select {
+		case watcher <- *job:
+		default:
+		}
+	}
+}
+
+// ************************************************************************************************
+// formatIndexJobs renders indexing jobs as a Markdown table.
+//
+// Returns:
+//   - string: The Markdown document.
+func formatIndexJobs(jobs []indexJob) string {
+	var text strings.Builder
+	text.WriteString("| Job | Repository | Status | Queued | Duration | Progress | Error |\n")
+	text.WriteString("|-----|------------|--------|--------|----------|----------|-------|\n")
+	for _, job := range jobs {
+		duration := "-"
+		switch {
+		case !job.FinishedAt.IsZero():
+			duration = job.FinishedAt.Sub(job.StartedAt).Round(time.Millisecond).String()
+		case !job.StartedAt.IsZero():
+			duration = time.Since(job.StartedAt).Round(time.Second).String() + " so far"
+		}
+		progress := "-"
+		if job.Total > 0 {
+			progress = fmt.Sprintf("%d/%d", job.Progress, job.Total)
+		}
+		if job.Message != "" {
+			progress = strings.TrimPrefix(progress+" "+tableCell(job.Message), "- ")
+		}
+		errorText := "-"
+		if job.Error != "" {
+			errorText = tableCell(job.Error)
+		}
+		text.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s | %s |\n",
+			job.ID, job.Alias, job.Status, job.QueuedAt.UTC().Format(time.RFC3339), duration, progress, errorText))
+	}
+	return text.String()
+}
+
+// ************************************************************************************************
+// tableCell flattens a text to a single line usable in a Markdown table cell.
+func tableCell(text string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(text), " "), "|", "\\|")
+}
@@ -0,0 +1,492 @@
+// ************************************************************************************************
+// Package mcp provides the validation of OAuth2/OIDC bearer tokens.
+// Access tokens are JSON Web Tokens signed by the identity provider with a key of its JSON Web
+// Key Set, fetched from the configured URL or from the discovery document of the issuer. The
+// key set is cached and fetched again hourly, or when a token names an unknown key. Tokens must
+// be unexpired and match the configured issuer and audience; their scopes decide which
+// repositories the client sees.
+package mcp
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha512" // Registers SHA-384 and SHA-512 for crypto.Hash
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// JWKS fetching: the key set is fetched again after jwksRefreshInterval, and at most once per
+// jwksMinRefreshInterval when tokens name unknown keys, so forged key IDs cannot flood the
+// identity provider.
+const (
+	jwksRefreshInterval    = time.Hour
+	jwksMinRefreshInterval = time.Minute
+	jwksFetchTimeout       = 10 * time.Second
+	jwksMaxSize            = 1 << 20
+)
+
+// ************************************************************************************************
+// jwtClockSkew is the tolerance applied to the exp and nbf claims.
+const jwtClockSkew = time.Minute
+
+// ************************************************************************************************
+// jwtAlgorithms maps the supported JWS algorithms to their hash; "none" and the HMAC algorithms,
+// which would need a shared secret, are refused.
+var jwtAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+	"EdDSA": 0,
+}
+
+// ************************************************************************************************
+// jwtVerifier validates the access tokens of an identity provider.
+type jwtVerifier struct {
+	issuer     string
+	audience   string
+	scopeClaim string
+	jwksURL    string // Configured, or discovered from the issuer on the first fetch
+	client     *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey // Signing keys by key ID, "" for keys without ID
+	fetched     time.Time                   // Time of the last successful fetch
+	lastAttempt time.Time                   // Time of the last fetch, successful or not
+	fetchErr    error                       // Error of the last fetch, nil when it succeeded
+	refreshing  chan struct{}               // Closed when the running fetch ends, nil when none runs
+}
+
+// ************************************************************************************************
+// newJWTVerifier returns the verifier of the configured identity provider.
+//
+// Returns:
+//   - *jwtVerifier: The verifier, nil when neither an issuer nor a JWKS URL is configured.
+func newJWTVerifier(auth types.ServerAuth) *jwtVerifier {
+	if auth.Issuer == "" && auth.JWKSURL == "" {
+		return nil
+	}
+	scopeClaim := auth.ScopeClaim
+	if scopeClaim == "" {
+		scopeClaim = "scope"
+	}
+	return &jwtVerifier{
+		issuer:     auth.Issuer,
+		audience:   auth.Audience,
+		scopeClaim: scopeClaim,
+		jwksURL:    auth.JWKSURL,
+		client:     &http.Client{Timeout: jwksFetchTimeout},
+	}
+}
+
+// ************************************************************************************************
+// looksLikeJWT reports whether a bearer token has the three dot-separated parts of a JWT.
+func looksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// ************************************************************************************************
+// verify validates a token and returns the client it identifies.
+//
+// Returns:
+//   - *principal: The client, with the scopes it was granted.
+//   - error: An error if the token is malformed, badly signed, expired or issued for another
+//     issuer or audience.
+//
+// Example usage:
+//
+//	client, err := verifier.verify(r.Context(), token)
+//	if err != nil {
+//		sendUnauthorized(w, "invalid_token", err.Error())
+//	}
+func (v *jwtVerifier) verify(ctx context.Context, token string) (*principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+	hash, supported := jwtAlgorithms[header.Algorithm]
+	if !supported {
+		return nil, fmt.Errorf("unsupported signing algorithm: %q", header.Algorithm)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature: %w", err)
+	}
+
+	key, err := v.signingKey(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(header.Algorithm, hash, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	if err := v.checkClaims(claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	subject, _ := claims["sub"].(string)
+	scopes := make(map[string]bool)
+	for _, scope := range claimStrings(claims[v.scopeClaim]) {
+		scopes[scope] = true
+	}
+	return &principal{subject: subject, scopes: scopes}, nil
+}
+
+// ************************************************************************************************
+// checkClaims checks the validity period, issuer and audience of a token.
+//
+// Returns:
+//   - error: An error naming the first claim that does not match.
+func (v *jwtVerifier) checkClaims(claims map[string]interface{}, now time.Time) error {
+	expiry, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("token has no expiry")
+	}
+	if now.Add(-jwtClockSkew).After(time.Unix(int64(expiry), 0)) {
+		return fmt.Errorf("token expired")
+	}
+	if notBefore, ok := claims["nbf"].(float64); ok && now.Add(jwtClockSkew).Before(time.Unix(int64(notBefore), 0)) {
+		return fmt.Errorf("token not valid yet")
+	}
+	if v.issuer != "" {
+		if issuer, _ := claims["iss"].(string); strings.TrimSuffix(issuer, "/") != strings.TrimSuffix(v.issuer, "/") {
+			return fmt.Errorf("token issued by %q", issuer)
+		}
+	}
+	if v.audience != "" {
+		for _, audience := range claimStrings(claims["aud"]) {
+			if audience == v.audience {
+				return nil
+			}
+		}
+		return fmt.Errorf("token not issued for audience %q", v.audience)
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// claimStrings returns the values of a claim holding a space-separated string or an array of
+// strings, as the scope, scp and aud claims do.
+func claimStrings(value interface{}) []string {
+	switch typed := value.(type) {
+	case string:
+		return strings.Fields(typed)
+	case []interface{}:
+		values := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// decodeJWTPart decodes a base64url-encoded JSON part of a token.
+func decodeJWTPart(part string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// ************************************************************************************************
+// verifyJWTSignature checks the signature of a token with a public key.
+//
+// Returns:
+//   - error: An error if the key does not fit the algorithm or the signature does not match.
+func verifyJWTSignature(algorithm string, hash crypto.Hash, key crypto.PublicKey, signingInput, signature []byte) error {
+	var digest []byte
+	if hash != 0 {
+		hasher := hash.New()
+		hasher.Write(signingInput)
+		digest = hasher.Sum(nil)
+	}
+
+	invalid := fmt.Errorf("invalid token signature")
+	switch publicKey := key.(type) {
+	case *rsa.PublicKey:
+		switch algorithm[:2] {
+		case "RS":
+			if rsa.VerifyPKCS1v15(publicKey, hash, digest, signature) != nil {
+				return invalid
+			}
+			return nil
+		case "PS":
+			if rsa.VerifyPSS(publicKey, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) != nil {
+				return invalid
+			}
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		curves := map[string]elliptic.Curve{"ES256": elliptic.P256(), "ES384": elliptic.P384(), "ES512": elliptic.P521()}
+		if curve, ok := curves[algorithm]; ok && publicKey.Curve == curve {
+			size := (curve.Params().BitSize + 7) / 8
+			if len(signature) != 2*size {
+				return invalid
+			}
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if !ecdsa.Verify(publicKey, digest, r, s) {
+				return invalid
+			}
+			return nil
+		}
+	case ed25519.PublicKey:
+		if algorithm == "EdDSA" {
+			if !ed25519.Verify(publicKey, signingInput, signature) {
+				return invalid
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("signing key does not match algorithm %s", algorithm)
+}
+
+// ************************************************************************************************
+// signingKey returns the key of the key set with an ID, refreshing the key set when it is stale
+// or does not hold the key. The key set is fetched in the background, once for all callers: a
+// key of the cached set is returned at once, and callers missing their key wait for the fetch.
+//
+// Returns:
+//   - crypto.PublicKey: The key.
+//   - error: An error if the key set cannot be fetched or has no such key, or ctx.Err() if ctx
+//     ends while waiting for the fetch.
+func (v *jwtVerifier) signingKey(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	now := time.Now()
+	key, found := v.keys[keyID]
+	stale := now.Sub(v.fetched) > jwksRefreshInterval
+	if (stale || !found) && v.refreshing == nil && now.Sub(v.lastAttempt) >= jwksMinRefreshInterval {
+		v.lastAttempt = now
+		v.refreshing = make(chan struct{})
+		go v.refreshKeys(v.refreshing)
+	}
+	refreshing := v.refreshing
+	v.mu.Unlock()
+
+	if !found && refreshing != nil {
+		select {
+		case <-refreshing:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		v.mu.Lock()
+		key, found = v.keys[keyID]
+		loaded, fetchErr := v.keys != nil, v.fetchErr
+		v.mu.Unlock()
+		if !loaded {
+			return nil, fmt.Errorf("failed to fetch the signing keys: %w", fetchErr)
+		}
+	}
+	if !found {
+		if keyID == "" {
+			return nil, fmt.Errorf("token names no signing key and the key set has several")
+		}
+		return nil, fmt.Errorf("unknown signing key %q", keyID)
+	}
+	return key, nil
+}
+
+// ************************************************************************************************
+// refreshKeys fetches the key set and replaces the cached one, which is kept when the fetch
+// fails, then closes done. The fetch is not tied to the request that started it, the client
+// timeout bounds it.
+func (v *jwtVerifier) refreshKeys(done chan struct{}) {
+	keys, err := v.fetchKeys(context.Background())
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.fetchErr = err
+	if err == nil {
+		v.keys = keys
+		v.fetched = time.Now()
+	} else if v.keys != nil {
+		slog.Warn("Failed to refresh the token signing keys, keeping the previous ones", "error", err)
+	}
+	v.refreshing = nil
+	close(done)
+}
+
+// ************************************************************************************************
+// fetchKeys fetches the key set, discovering its URL from the issuer first when needed. Fetches
+// run one at a time, outside of v.mu.
+//
+// Returns:
+//   - map[string]crypto.PublicKey: The signing keys by key ID; a single key without ID is also
+//     stored under "".
+//   - error: An error if the key set cannot be fetched or holds no usable key.
+func (v *jwtVerifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	if v.jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.fetchJSON(ctx, strings.TrimSuffix(v.issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, fmt.Errorf("failed to discover the issuer\n>    %w", err)
+		}
+		if discovery.JWKSURI == "" {
+			return nil, fmt.Errorf("the discovery document of %s has no jwks_uri", v.issuer)
+		}
+		v.jwksURL = discovery.JWKSURI
+	}
+
+	var keySet struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := v.fetchJSON(ctx, v.jwksURL, &keySet); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, raw := range keySet.Keys {
+		keyID, key, err := parseJWK(raw)
+		if err != nil {
+			slog.Warn("Skipping a token signing key", "jwksUrl", v.jwksURL, "error", err)
+			continue
+		}
+		if key != nil {
+			keys[keyID] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no usable signing key at %s", v.jwksURL)
+	}
+	if len(keys) == 1 {
+		for _, key := range keys {
+			keys[""] = key
+		}
+	}
+	return keys, nil
+}
+
+// ************************************************************************************************
+// fetchJSON fetches and decodes a JSON document of the identity provider.
+//
+// Returns:
+//   - error: An error if the request fails, is not answered with 200 OK or is not valid JSON.
+func (v *jwtVerifier) fetchJSON(ctx context.Context, url string, target interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s\n>    %w", url, err)
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := v.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s\n>    %w", url, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: %s", url, response.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, jwksMaxSize)).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode %s\n>    %w", url, err)
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// parseJWK parses a JSON Web Key.
+//
+// Returns:
+//   - string: The key ID.
+//   - crypto.PublicKey: The public key, nil for keys not used for signatures.
+//   - error: An error if the key is malformed or of an unsupported type.
+func parseJWK(raw json.RawMessage) (string, crypto.PublicKey, error) {
+	var jwk struct {
+		KeyType string `json:"kty"`
+		KeyID   string `json:"kid"`
+		Use     string `json:"use"`
+		N       string `json:"n"`
+		E       string `json:"e"`
+		Curve   string `json:"crv"`
+		X       string `json:"x"`
+		Y       string `json:"y"`
+	}
+	if err := json.Unmarshal(raw, &jwk); err != nil {
+		return "", nil, err
+	}
+	if jwk.Use != "" && jwk.Use != "sig" {
+		return jwk.KeyID, nil, nil
+	}
+
+	decode := func(name, value string) (*big.Int, error) {
+		data, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil || len(data) == 0 {
+			return nil, fmt.Errorf("key %q has an invalid %s", jwk.KeyID, name)
+		}
+		return new(big.Int).SetBytes(data), nil
+	}
+
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decode("n", jwk.N)
+		if err != nil {
+			return "", nil, err
+		}
+		e, err := decode("e", jwk.E)
+		if err != nil {
+			return "", nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 || n.BitLen() < 2048 {
+			return "", nil, fmt.Errorf("RSA key %q is too weak", jwk.KeyID)
+		}
+		return jwk.KeyID, &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[jwk.Curve]
+		if !ok {
+			return "", nil, fmt.Errorf("key %q uses the unsupported curve %q", jwk.KeyID, jwk.Curve)
+		}
+		x, err := decode("x", jwk.X)
+		if err != nil {
+			return "", nil, err
+		}
+		y, err := decode("y", jwk.Y)
+		if err != nil {
+			return "", nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return "", nil, fmt.Errorf("key %q is not on curve %s", jwk.KeyID, jwk.Curve)
+		}
+		return jwk.KeyID, &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		data, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if jwk.Curve != "Ed25519" || err != nil || len(data) != ed25519.PublicKeySize {
+			return "", nil, fmt.Errorf("key %q is not a valid Ed25519 key", jwk.KeyID)
+		}
+		return jwk.KeyID, ed25519.PublicKey(data), nil
+	}
+	return "", nil, fmt.Errorf("key %q has the unsupported type %q", jwk.KeyID, jwk.KeyType)
+}
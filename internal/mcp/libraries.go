@@ -0,0 +1,83 @@
+// ************************************************************************************************
+// Package mcp provides the listing of the libraries served by the MCP server.
+// Every enabled repository, configured or synthesized from a Go module, is listed with the
+// metadata agents need to pick one: when it was indexed, its size and its commit.
+package mcp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// libraryInfo describes a served repository.
+type libraryInfo struct {
+	ID          string
+	GoModule    bool
+	FileCount   int
+	LastUpdated time.Time
+	CommitHash  string
+}
+
+// ************************************************************************************************
+// listLibraries returns the served repositories sorted by ID, Go modules included when asked.
+//
+// Returns:
+//   - []libraryInfo: The repositories, without those that cannot be loaded.
+func (s *Server) listLibraries(includeGoModules bool) []libraryInfo {
+	var libraries []libraryInfo
+	for _, repoID := range s.servedRepositoryIDs() {
+		goModule := types.IsGoModuleRepositoryID(repoID)
+		if goModule && !includeGoModules {
+			continue
+		}
+		repo, err := s.lookupRepository(repoID)
+		if err != nil {
+			continue
+		}
+		libraries = append(libraries, libraryInfo{
+			ID:          repoID,
+			GoModule:    goModule,
+			FileCount:   len(repo.Files),
+			LastUpdated: repo.LastUpdated,
+			CommitHash:  repo.CommitHash,
+		})
+	}
+	return libraries
+}
+
+// ************************************************************************************************
+// formatLibraryList renders the served repositories as a Markdown table.
+//
+// Returns:
+//   - string: The Markdown document.
+func formatLibraryList(libraries []libraryInfo) string {
+	var text strings.Builder
+	text.WriteString("# Available libraries\n\n")
+	text.WriteString(fmt.Sprintf("%d libraries, usable as library-id in the other tools.\n\n", len(libraries)))
+	text.WriteString("| Library ID | Source | Files | Last updated | Commit |\n")
+	text.WriteString("|------------|--------|-------|--------------|--------|\n")
+
+	for _, library := range libraries {
+		source := "repository"
+		if library.GoModule {
+			source = "Go module"
+		}
+		lastUpdated := "-"
+		if !library.LastUpdated.IsZero() {
+			lastUpdated = library.LastUpdated.UTC().Format(time.RFC3339)
+		}
+		commit := "-"
+		if library.CommitHash != "" {
+			commit = library.CommitHash
+			if len(commit) > 12 {
+				commit = commit[:12]
+			}
+		}
+		text.WriteString(fmt.Sprintf("| %s | %s | %d | %s | %s |\n", library.ID, source, library.FileCount, lastUpdated, commit))
+	}
+	return text.String()
+}
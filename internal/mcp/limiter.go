@@ -0,0 +1,105 @@
+// ************************************************************************************************
+// Package mcp provides the concurrency limit of the MCP HTTP endpoint.
+// Every MCP request may assemble large documentation, so the number of requests handled at once
+// is bounded across all listeners and WebSocket sessions; requests arriving while the server is
+// saturated are rejected with 503 Service Unavailable, or a "Server busy" JSON-RPC error on a
+// WebSocket, instead of queueing.
+package mcp
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// maxConcurrentRequests returns the number of MCP requests handled at once, 0 when unlimited.
+func (s *Server) maxConcurrentRequests() int {
+	if s.config == nil || s.config.Server.MaxConcurrentRequests < 0 {
+		return 0
+	}
+	return s.config.Server.MaxConcurrentRequests
+}
+
+// ************************************************************************************************
+// acquireRequestSlot takes one of the maxConcurrentRequests slots without waiting.
+//
+// Returns:
+//   - func(): Frees the slot.
+//   - bool: False if every slot is taken.
+//
+// Example usage:
+//
+//	release, acquired := s.acquireRequestSlot()
+//	if !acquired {
+//		return
+//	}
+//	defer release()
+func (s *Server) acquireRequestSlot() (func(), bool) {
+	if s.requestSlots == nil {
+		return func() {}, true
+	}
+	select {
+	case s.requestSlots <- struct{}{}:
+		return func() { <-s.requestSlots }, true
+	default:
+		return nil, false
+	}
+}
+
+// ************************************************************************************************
+// withConcurrencyLimit wraps an HTTP handler so at most maxConcurrentRequests requests run at
+// once. The limit is shared by every listener and WebSocket session.
+//
+// Example usage:
+//
+//	mux.HandleFunc("/mcp", s.withConcurrencyLimit(s.handleMCPEndpoint))
+func (s *Server) withConcurrencyLimit(next http.HandlerFunc) http.HandlerFunc {
+	if s.requestSlots == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Event streams stay open without doing work, they do not hold a slot
+		if r.Method == http.MethodGet && acceptsEventStream(r) {
+			next(w, r)
+			return
+		}
+
+		release, acquired := s.acquireRequestSlot()
+		if !acquired {
+			slog.Warn("Rejecting request: too many requests in progress", "remote", r.RemoteAddr, "limit", cap(s.requestSlots))
+			sendServerBusy(w)
+			return
+		}
+		defer release()
+		next(w, r)
+	}
+}
+
+// ************************************************************************************************
+// serverBusyError is the JSON-RPC error of a request rejected by the concurrency limit.
+var serverBusyError = &types.JSONRPCError{
+	Code:    -32000,
+	Message: "Server busy",
+	Data:    "too many concurrent requests, retry later",
+}
+
+// ************************************************************************************************
+// sendServerBusy answers a request rejected by the concurrency limit with a 503 status and a
+// JSON-RPC error, asking the client to retry shortly.
+func sendServerBusy(w http.ResponseWriter) {
+	response := types.JSONRPCResponse{
+		JsonRPC: "2.0",
+		Error:   serverBusyError,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", "1")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Failed to encode JSON-RPC error response", "error", err)
+	}
+}
@@ -0,0 +1,99 @@
+// ************************************************************************************************
+// Package mcp provides the progress notifications of long-running tool calls.
+// A tools/call request carrying a progressToken in its _meta parameter receives
+// notifications/progress while the tool runs, when its transport delivers notifications: Go
+// module retrieval reports the go commands it runs and the packages fetched, and waited
+// indexing jobs report the repositories and files indexed.
+package mcp
+
+import (
+	"net/http"
+	"sync"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// progressResponseWriter is handed to the tool handlers of a request with a progress token. It
+// keeps the token and the last progress sent, which must increase with every notification.
+type progressResponseWriter struct {
+	http.ResponseWriter
+	token    interface{}
+	mu       sync.Mutex
+	reported bool
+	last     int
+}
+
+// ************************************************************************************************
+// withProgressToken returns the response writer of a tool call, tracking its progress token
+// when the request has one.
+func withProgressToken(w http.ResponseWriter, meta *types.MCPRequestMeta) http.ResponseWriter {
+	if meta == nil || meta.ProgressToken == nil {
+		return w
+	}
+	return &progressResponseWriter{ResponseWriter: w, token: meta.ProgressToken}
+}
+
+// ************************************************************************************************
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (p *progressResponseWriter) Unwrap() http.ResponseWriter {
+	return p.ResponseWriter
+}
+
+// ************************************************************************************************
+// notify sends a notification through the underlying response writer, dropping it when the
+// transport cannot deliver notifications.
+func (p *progressResponseWriter) notify(data []byte) error {
+	if streaming, ok := p.ResponseWriter.(notifier); ok {
+		return streaming.notify(data)
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// notifyProgress sends a progress notification to the client of a tool call with a progress
+// token; total is omitted when 0. Progress not above the last one sent is dropped.
+//
+// Example usage:
+//
+//	s.notifyProgress(w, 2, 5, "Extracting documentation")
+func (s *Server) notifyProgress(w http.ResponseWriter, progress, total int, message string) {
+	tracker, ok := w.(*progressResponseWriter)
+	if !ok {
+		return
+	}
+	tracker.mu.Lock()
+	if tracker.reported && progress <= tracker.last {
+		tracker.mu.Unlock()
+		return
+	}
+	tracker.reported = true
+	tracker.last = progress
+	tracker.mu.Unlock()
+
+	params := map[string]interface{}{
+		"progressToken": tracker.token,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	s.notify(w, "notifications/progress", params)
+}
+
+// ************************************************************************************************
+// progressFunc returns the function reporting progress to the client of a tool call.
+//
+// Returns:
+//   - types.ProgressFunc: The function, nil when the request has no progress token.
+func (s *Server) progressFunc(w http.ResponseWriter) types.ProgressFunc {
+	if _, ok := w.(*progressResponseWriter); !ok {
+		return nil
+	}
+	return func(progress, total int, message string) {
+		s.notifyProgress(w, progress, total, message)
+	}
+}
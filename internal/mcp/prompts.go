@@ -0,0 +1,200 @@
+// ************************************************************************************************
+// Package mcp provides the prompts of the MCP server.
+// Prompts are reusable documentation requests a client offers its user, such as summarizing a
+// repository or explaining one of its APIs; the server fills them with the indexed
+// documentation so the model answers from the cache rather than from memory.
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// promptContextTokens is the budget of indexed documentation embedded in a prompt.
+const promptContextTokens = 8000
+
+// ************************************************************************************************
+// promptDefinitions returns the prompts offered by the server.
+func promptDefinitions() []types.MCPPrompt {
+	libraryArgument := types.MCPPromptArgument{
+		Name:        "library-id",
+		Description: "Repository ID from resolve-library-id or list-libraries",
+		Required:    true,
+	}
+	return []types.MCPPrompt{
+		{
+			Name:        "summarize-repository",
+			Description: "Summarize a repository: its purpose, structure, entry points and usage, from its indexed documentation",
+			Arguments:   []types.MCPPromptArgument{libraryArgument},
+		},
+		{
+			Name:        "explain-api",
+			Description: "Explain a function, method or type of a repository with an example, from its indexed documentation",
+			Arguments: []types.MCPPromptArgument{
+				libraryArgument,
+				{
+					Name:        "symbol",
+					Description: "API name, e.g. Open, Store.Get or storage.Store.Get",
+					Required:    true,
+				},
+			},
+		},
+	}
+}
+
+// ************************************************************************************************
+// handlePromptsList handles the prompts/list request.
+func (s *Server) handlePromptsList(w http.ResponseWriter, req types.JSONRPCRequest) {
+	slog.Debug("Handling prompts/list request")
+	s.sendJSONRPCResult(w, req.ID, types.MCPPromptsListResult{Prompts: promptDefinitions()})
+}
+
+// ************************************************************************************************
+// handlePromptsGet handles the prompts/get request.
+func (s *Server) handlePromptsGet(ctx context.Context, w http.ResponseWriter, req types.JSONRPCRequest) {
+	slog.DebugContext(ctx, "Handling prompts/get request")
+
+	var params types.MCPPromptGetParams
+	if err := s.parseParams(req.Params, &params); err != nil {
+		s.sendJSONRPCError(w, req.ID, -32602, "Invalid params", fmt.Sprintf("Failed to parse parameters: %v", err))
+		return
+	}
+
+	slog.InfoContext(ctx, "Getting prompt", "prompt", params.Name, "arguments", params.Arguments)
+
+	if libraryID := strings.TrimSpace(params.Arguments["library-id"]); libraryID != "" && !s.canAccessRepository(ctx, libraryID) {
+		s.sendJSONRPCError(w, req.ID, -32602, "Invalid params", fmt.Sprintf("repository not found: %s", libraryID))
+		return
+	}
+
+	result, err := s.buildPrompt(ctx, params.Name, params.Arguments)
+	if err != nil {
+		s.sendJSONRPCError(w, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	s.sendJSONRPCResult(w, req.ID, result)
+}
+
+// ************************************************************************************************
+// buildPrompt fills a prompt with its arguments and the indexed documentation it refers to.
+//
+// Returns:
+//   - types.MCPPromptGetResult: The prompt messages.
+//   - error: An error if the prompt is unknown, an argument is missing or the repository cannot
+//     be served.
+func (s *Server) buildPrompt(ctx context.Context, name string, arguments map[string]string) (types.MCPPromptGetResult, error) {
+	var prompt *types.MCPPrompt
+	for _, definition := range promptDefinitions() {
+		if definition.Name == name {
+			prompt = &definition
+			break
+		}
+	}
+	if prompt == nil {
+		return types.MCPPromptGetResult{}, fmt.Errorf("unknown prompt: %s", name)
+	}
+	for _, argument := range prompt.Arguments {
+		if argument.Required && strings.TrimSpace(arguments[argument.Name]) == "" {
+			return types.MCPPromptGetResult{}, fmt.Errorf("missing required argument: %s", argument.Name)
+		}
+	}
+
+	libraryID := strings.TrimSpace(arguments["library-id"])
+	if s.isRepositoryDisabled(libraryID) {
+		return types.MCPPromptGetResult{}, fmt.Errorf("repository %s is disabled", libraryID)
+	}
+
+	var description, instructions, context string
+	var err error
+	switch name {
+	case "summarize-repository":
+		description = fmt.Sprintf("Summary of %s", libraryID)
+		instructions = fmt.Sprintf("Summarize the repository %s for a developer new to it: its purpose, its main "+
+			"packages or modules and how they fit together, its entry points and how to use it.", libraryID)
+		context, err = s.repositoryOverview(ctx, libraryID)
+
+	case "explain-api":
+		symbol := strings.TrimSpace(arguments["symbol"])
+		description = fmt.Sprintf("Explanation of %s in %s", symbol, libraryID)
+		instructions = fmt.Sprintf("Explain the API %s of the repository %s: what it does, its parameters and results, "+
+			"the errors it reports and how to call it, with a short example.", symbol, libraryID)
+		context, err = s.symbolOverview(ctx, libraryID, symbol)
+	}
+	if err != nil {
+		return types.MCPPromptGetResult{}, err
+	}
+
+	text := instructions + " Base your answer on the indexed documentation below only, and say so when it does not cover something.\n\n" + context
+	return types.MCPPromptGetResult{
+		Description: description,
+		Messages: []types.MCPPromptMessage{
+			{Role: "user", Content: types.MCPContent{Type: "text", Text: text}},
+		},
+	}, nil
+}
+
+// ************************************************************************************************
+// repositoryOverview returns the README and the API summary of a repository, or its
+// documentation when it has no API summary, within the prompt budget.
+//
+// Returns:
+//   - string: The Markdown overview.
+//   - error: An error if the repository is not found.
+func (s *Server) repositoryOverview(ctx context.Context, libraryID string) (string, error) {
+	var sections []string
+	if repo, err := s.lookupRepository(libraryID); err == nil {
+		if readmes := s.findAllReadmeFiles(repo); len(readmes) > 0 {
+			sections = append(sections, fmt.Sprintf("## %s\n\n%s", readmes[0].Path, truncateText(readmes[0].Content, promptContextTokens/2)))
+		}
+	}
+
+	budget := promptContextTokens
+	if len(sections) > 0 {
+		budget = promptContextTokens / 2
+	}
+	if summary, err := s.getAPISummary(libraryID, budget); err == nil {
+		sections = append(sections, summary)
+	} else {
+		docs, err := s.getRepositoryDocs(ctx, libraryID, "", budget, false)
+		if err != nil {
+			return "", err
+		}
+		sections = append(sections, docs)
+	}
+	return strings.Join(sections, "\n\n"), nil
+}
+
+// ************************************************************************************************
+// symbolOverview returns the declarations of a symbol with their doc comments when the
+// repository has a symbol index, and the documentation about it otherwise.
+//
+// Returns:
+//   - string: The Markdown overview.
+//   - error: An error if the repository is not found.
+func (s *Server) symbolOverview(ctx context.Context, libraryID, symbol string) (string, error) {
+	if repo, err := s.lookupRepository(libraryID); err == nil {
+		if value, exists := repo.Metadata[types.SymbolsMetadataKey]; exists {
+			if index, err := types.DecodeSymbols(value); err == nil {
+				if text, found := formatSymbols(libraryID, symbol, index); found {
+					return truncateText(text, promptContextTokens), nil
+				}
+			}
+		}
+	}
+	return s.getRepositoryDocs(ctx, libraryID, symbol, promptContextTokens, false)
+}
+
+// ************************************************************************************************
+// truncateText cuts a text at a maximum length, noting the truncation.
+func truncateText(text string, maxLength int) string {
+	if len(text) <= maxLength {
+		return text
+	}
+	return text[:maxLength] + "\n\n[Content truncated...]"
+}
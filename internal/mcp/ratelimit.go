@@ -0,0 +1,242 @@
+// ************************************************************************************************
+// Package mcp provides the per-client rate limits of the MCP server.
+// Every client has a token bucket of tool calls, and a second one for the calls running
+// subprocesses: refresh, index-repository and the retrieval of uncached Go modules. Clients are
+// identified by their API key or token subject when authenticated, by their IP address
+// otherwise. Calls beyond the limit are refused with 429 Too Many Requests and a Retry-After
+// header, so one misbehaving client cannot hammer the indexer.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// maxRateLimitClients is the number of client buckets kept; beyond it the buckets of idle
+// clients, which are full again, are forgotten.
+const maxRateLimitClients = 10000
+
+// ************************************************************************************************
+// expensiveTools are the tools running subprocesses for every call.
+var expensiveTools = map[string]bool{
+	"refresh":          true,
+	"index-repository": true,
+}
+
+// ************************************************************************************************
+// errRateLimited reports a call refused by a rate limit, with the time until it is allowed.
+type errRateLimited struct {
+	retryAfter time.Duration
+}
+
+// ************************************************************************************************
+// Error describes the refused call.
+func (e errRateLimited) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry in %s", e.retryAfter.Round(time.Second))
+}
+
+// ************************************************************************************************
+// rateLimiter holds the token buckets of the clients.
+type rateLimiter struct {
+	rate  float64 // Tokens added per second
+	burst float64 // Bucket capacity
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// ************************************************************************************************
+// tokenBucket is the bucket of a client: its tokens when last updated.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// ************************************************************************************************
+// newRateLimiter creates a limiter allowing perMinute calls per client and minute, up to burst
+// at once.
+//
+// Returns:
+//   - *rateLimiter: The limiter, nil when perMinute is 0 and calls are unlimited.
+func newRateLimiter(perMinute, burst int) *rateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = perMinute
+	}
+	return &rateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// ************************************************************************************************
+// allow takes a token from the bucket of a client. A nil limiter allows every call.
+//
+// Returns:
+//   - time.Duration: 0 if the call is allowed, otherwise the time until the next token.
+func (l *rateLimiter) allow(client string, now time.Time) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, exists := l.buckets[client]
+	if !exists {
+		if len(l.buckets) >= maxRateLimitClients {
+			l.forgetFullBucketsLocked(now)
+		}
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[client] = bucket
+	}
+
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate)
+	bucket.updated = now
+	if bucket.tokens < 1 {
+		return time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return 0
+}
+
+// ************************************************************************************************
+// refund gives back the token taken by allow for a call refused afterwards. A nil limiter
+// ignores it.
+func (l *rateLimiter) refund(client string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if bucket, exists := l.buckets[client]; exists {
+		bucket.tokens = math.Min(l.burst, bucket.tokens+1)
+	}
+}
+
+// ************************************************************************************************
+// forgetFullBucketsLocked drops the buckets refilled since their last call, which a new bucket
+// recreates as is. The caller holds l.mu.
+func (l *rateLimiter) forgetFullBucketsLocked(now time.Time) {
+	for client, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// ************************************************************************************************
+// clientKeyContextKey is the context key of the rate-limited identity of a request.
+type clientKeyContextKey struct{}
+
+// ************************************************************************************************
+// withClientKey returns a context carrying the rate-limited identity of a request.
+func withClientKey(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKeyContextKey{}, client)
+}
+
+// ************************************************************************************************
+// requestClientKey returns the rate-limited identity of an HTTP request: the one set by the
+// authentication, the name of its client certificate, or its IP address.
+func requestClientKey(r *http.Request) string {
+	if client, ok := r.Context().Value(clientKeyContextKey{}).(string); ok {
+		return client
+	}
+	if name, ok := clientCertificateName(r); ok {
+		return "cert:" + name
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ************************************************************************************************
+// clientKeyFromContext returns the rate-limited identity of a request, empty for requests not
+// received over HTTP.
+func clientKeyFromContext(ctx context.Context) string {
+	client, _ := ctx.Value(clientKeyContextKey{}).(string)
+	return client
+}
+
+// ************************************************************************************************
+// allowToolCall takes a call from the limit of the client of a request, and one from its limit
+// of expensive calls for refresh and index-repository. A call refused by the expensive limit
+// gives its tool call back, so refused calls do not drain the general limit.
+//
+// Returns:
+//   - time.Duration: 0 if the call is allowed, otherwise the time until it would be.
+func (s *Server) allowToolCall(ctx context.Context, toolName string) time.Duration {
+	client := clientKeyFromContext(ctx)
+	if client == "" {
+		return 0
+	}
+	if wait := s.toolCallLimiter.allow(client, time.Now()); wait > 0 {
+		slog.WarnContext(ctx, "Rate limiting client: tool call refused", "client", client)
+		return wait
+	}
+	if expensiveTools[toolName] {
+		var limited errRateLimited
+		if errors.As(s.allowExpensiveCall(ctx, toolName), &limited) {
+			s.toolCallLimiter.refund(client)
+			return limited.retryAfter
+		}
+	}
+	return 0
+}
+
+// ************************************************************************************************
+// allowExpensiveCall takes a call from the limit of expensive calls of the client of a request.
+//
+// Returns:
+//   - error: An errRateLimited if the limit is exhausted.
+func (s *Server) allowExpensiveCall(ctx context.Context, what string) error {
+	client := clientKeyFromContext(ctx)
+	if client == "" {
+		return nil
+	}
+	if wait := s.expensiveLimiter.allow(client, time.Now()); wait > 0 {
+		slog.WarnContext(ctx, "Rate limiting client: expensive call refused", "client", client, "call", what)
+		return errRateLimited{retryAfter: wait}
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// sendRateLimited answers a request refused by a rate limit with a 429 status, a Retry-After
+// header and a JSON-RPC error.
+func sendRateLimited(w http.ResponseWriter, id interface{}, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	response := types.JSONRPCResponse{
+		JsonRPC: "2.0",
+		ID:      id,
+		Error: &types.JSONRPCError{
+			Code:    -32000,
+			Message: "Rate limit exceeded",
+			Data:    fmt.Sprintf("too many tool calls, retry in %d seconds", seconds),
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(http.StatusTooManyRequests)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Failed to encode JSON-RPC error response", "error", err)
+	}
+}
@@ -0,0 +1,49 @@
+// ************************************************************************************************
+// Package mcp provides the lookup of symbol references for the MCP server.
+// The reference index recorded by the Go parser is queried by function or method name,
+// listing the call sites of every matching symbol.
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// symbolNameMatches reports whether a qualified function or method name matches a query: any
+// name qualifiedNameMatches accepts, or a method named with its receiver type, "Store.Get".
+func symbolNameMatches(qualified, query string) bool {
+	return qualifiedNameMatches(qualified, query) || strings.HasSuffix(qualified, "."+query)
+}
+
+// ************************************************************************************************
+// formatReferences renders the entries of a reference index matching a name as Markdown, each
+// symbol with its call sites.
+//
+// Returns:
+//   - string: The Markdown document.
+//   - bool: Whether any symbol matched.
+func formatReferences(repositoryID, name string, index []types.SymbolReferences) (string, bool) {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("# References: %s in %s\n\n", name, repositoryID))
+
+	matched := false
+	for _, entry := range index {
+		if !symbolNameMatches(entry.Symbol, name) {
+			continue
+		}
+		matched = true
+		text.WriteString(fmt.Sprintf("## %s\n\nDeclared in %s:%d\n\n", entry.Symbol, entry.File, entry.Line))
+		if len(entry.References) == 0 {
+			text.WriteString("No call sites in this repository.\n\n")
+			continue
+		}
+		for _, reference := range entry.References {
+			text.WriteString(fmt.Sprintf("- %s:%d:%d in %s\n", reference.File, reference.Line, reference.Column, reference.Caller))
+		}
+		text.WriteString("\n")
+	}
+	return text.String(), matched
+}
@@ -0,0 +1,99 @@
+// ************************************************************************************************
+// Package mcp provides the response size limit of the MCP server.
+// Token budgets are estimates, so every tool response is checked against a hard byte limit
+// once encoded and its text is truncated with a note when it does not fit.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// defaultMaxResponseSize is the encoded size limit of tool responses when server.maxResponseSize
+// is not configured.
+const defaultMaxResponseSize = 4 << 20
+
+// ************************************************************************************************
+// maxResponseSize returns the encoded size limit of tool responses in bytes, 0 when the limit
+// is disabled with "0".
+func (s *Server) maxResponseSize() int {
+	if s.config == nil || s.config.Server.MaxResponseSize == "" {
+		return defaultMaxResponseSize
+	}
+	if strings.TrimSpace(s.config.Server.MaxResponseSize) == "0" {
+		return 0
+	}
+	limit, err := types.ParseByteSize(s.config.Server.MaxResponseSize)
+	if err != nil {
+		return defaultMaxResponseSize
+	}
+	return int(limit)
+}
+
+// ************************************************************************************************
+// encodeResponse encodes a JSON-RPC response the way json.Encoder writes it, newline included.
+func encodeResponse(response types.JSONRPCResponse) ([]byte, error) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ************************************************************************************************
+// truncationNote returns the note appended to the text of a truncated tool response.
+func truncationNote(limit int) string {
+	return fmt.Sprintf("\n\n[Response truncated: it exceeded the maximum response size of %d bytes]", limit)
+}
+
+// ************************************************************************************************
+// limitToolResult encodes a tool result response, truncating its largest text content until the
+// encoded response fits in limit bytes. JSON escaping makes the encoded text longer than the raw
+// text, so the truncation is repeated until it fits.
+//
+// Returns:
+//   - []byte: The encoded response.
+//   - bool: Whether the content was truncated.
+//   - error: An error if the response cannot be encoded or made to fit.
+func limitToolResult(id interface{}, result types.MCPToolCallResult, limit int) ([]byte, bool, error) {
+	note := truncationNote(limit)
+	result.Content = append([]types.MCPContent(nil), result.Content...)
+
+	truncated := false
+	for attempt := 0; attempt < 8; attempt++ {
+		data, err := encodeResponse(types.JSONRPCResponse{JsonRPC: "2.0", ID: id, Result: result})
+		if err != nil || len(data) <= limit {
+			return data, truncated, err
+		}
+
+		largest := -1
+		for index, content := range result.Content {
+			if largest < 0 || len(content.Text) > len(result.Content[largest].Text) {
+				largest = index
+			}
+		}
+		if largest < 0 {
+			break
+		}
+
+		text := strings.TrimSuffix(result.Content[largest].Text, note)
+		keep := len(text) - (len(data) - limit) - len(note)
+		if keep <= 0 {
+			if text == "" {
+				break
+			}
+			keep = 0
+		}
+		for keep > 0 && !utf8.RuneStart(text[keep]) {
+			keep--
+		}
+		result.Content[largest].Text = text[:keep] + note
+		truncated = true
+	}
+	return nil, truncated, fmt.Errorf("response does not fit in the maximum response size of %d bytes", limit)
+}
@@ -0,0 +1,70 @@
+// ************************************************************************************************
+// Package mcp provides the scheduled re-indexing of repositories while serving.
+// Repositories configured with a refreshInterval are re-indexed by an indexing job at every
+// interval, remote repositories being pulled first, so the served content follows upstream
+// without running the index command. The first run happens one interval after the server starts,
+// the cached index being served meanwhile.
+package mcp
+
+import (
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// ************************************************************************************************
+// startScheduler starts re-indexing the repositories configured with a refresh interval, until
+// the server stops.
+//
+// Example usage:
+//
+//	s.startScheduler()
+func (s *Server) startScheduler() {
+	aliases := make([]string, 0, len(s.config.Repositories))
+	for alias := range s.config.Repositories {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		repoConfig := s.config.Repositories[alias]
+		if repoConfig.RefreshInterval == "" || repoConfig.Disabled || !repoConfig.Indexing.Enabled {
+			continue
+		}
+		interval, err := time.ParseDuration(repoConfig.RefreshInterval)
+		if err != nil || interval <= 0 {
+			continue
+		}
+		slog.Info("Scheduled repository re-indexing", "repository", alias, "interval", interval)
+		go s.refreshPeriodically(alias, interval)
+	}
+}
+
+// ************************************************************************************************
+// refreshPeriodically queues the indexing job of a repository at every interval until the
+// server stops. A tick finding the previous job still queued or running is skipped.
+func (s *Server) refreshPeriodically(alias string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+		if s.stopping() {
+			return
+		}
+
+		job, queued, err := s.startIndexJob(alias)
+		switch {
+		case err != nil:
+			slog.Warn("Scheduled re-indexing failed to start", "repository", alias, "error", err)
+		case queued:
+			slog.Info("Scheduled re-indexing of repository", "repository", alias, "job", job.ID)
+		default:
+			slog.Info("Skipping scheduled re-indexing, a job is in progress", "repository", alias, "job", job.ID)
+		}
+	}
+}
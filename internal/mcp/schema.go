@@ -0,0 +1,160 @@
+// ************************************************************************************************
+// Package mcp provides validation of tool-call arguments against the tool input schemas.
+// Only the JSON Schema subset used by the tool definitions is supported: object properties,
+// required fields, primitive types and string enums. Numbers and integers are also accepted as
+// numeric strings, which clients sent before arguments were validated.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ************************************************************************************************
+// validateArguments checks tool-call arguments against a tool input schema.
+// Arguments not declared in the schema are accepted and ignored by the handlers.
+//
+// Returns:
+//   - error: An error naming the first offending field, nil if the arguments are valid.
+//
+// Example usage:
+//
+//	if err := validateArguments(tool.InputSchema, params.Arguments); err != nil {
+//		// Reply with a -32602 Invalid params error
+//	}
+func validateArguments(schema map[string]interface{}, arguments map[string]interface{}) error {
+	for _, name := range schemaStrings(schema["required"]) {
+		if value, exists := arguments[name]; !exists || value == nil {
+			return fmt.Errorf("missing required argument %q", name)
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	// Validate in a stable order so the reported field does not depend on map iteration
+	names := make([]string, 0, len(arguments))
+	for name := range arguments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, declared := properties[name].(map[string]interface{})
+		if !declared {
+			continue
+		}
+		if err := validateValue(name, property, arguments[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ************************************************************************************************
+// validateValue checks a single argument against its property schema.
+//
+// Returns:
+//   - error: An error naming the field, the expected type or the allowed values.
+func validateValue(name string, property map[string]interface{}, value interface{}) error {
+	expected, _ := property["type"].(string)
+	if expected != "" && !matchesType(expected, value) {
+		return fmt.Errorf("argument %q must be of type %s, got %s", name, expected, jsonTypeName(value))
+	}
+
+	allowed := schemaStrings(property["enum"])
+	if len(allowed) == 0 {
+		return nil
+	}
+	str, _ := value.(string)
+	for _, candidate := range allowed {
+		if str == candidate {
+			return nil
+		}
+	}
+	return fmt.Errorf("argument %q must be one of [%s], got %q", name, strings.Join(allowed, ", "), str)
+}
+
+// ************************************************************************************************
+// matchesType reports whether a decoded JSON value has the given JSON Schema type.
+func matchesType(expected string, value interface{}) bool {
+	switch expected {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := schemaNumber(value)
+		return ok
+	case "integer":
+		number, ok := schemaNumber(value)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// ************************************************************************************************
+// schemaNumber returns the value of a number argument: a JSON number, a json.Number or a string
+// holding a finite number.
+func schemaNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case json.Number, string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(v)), 64)
+		return number, err == nil && !math.IsInf(number, 0) && !math.IsNaN(number)
+	}
+	return 0, false
+}
+
+// ************************************************************************************************
+// jsonTypeName returns the JSON type name of a decoded value, for error messages.
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// ************************************************************************************************
+// schemaStrings returns a schema keyword holding a list of strings, such as "required" or "enum".
+func schemaStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		strs := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				strs = append(strs, str)
+			}
+		}
+		return strs
+	}
+	return nil
+}
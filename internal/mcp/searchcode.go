@@ -0,0 +1,89 @@
+// ************************************************************************************************
+// Package mcp provides the rendering of code search results for the MCP server.
+// Results of the search engine are listed in rank order, each with its location and the lines
+// around its best match, numbered so agents can cite or open them.
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// snippetContextLines is the number of lines the search engine keeps around a match.
+const snippetContextLines = 2
+
+// ************************************************************************************************
+// formatSearchResults renders search results as Markdown: the file and line of each best match
+// with its number of matches, then the numbered snippet around it.
+//
+// Returns:
+//   - string: The Markdown document.
+func formatSearchResults(query string, results []types.SearchResult) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("# Search results for %s\n\n", query))
+	text.WriteString(fmt.Sprintf("%d files matched, best match first.\n\n", len(results)))
+
+	for index, result := range results {
+		text.WriteString(fmt.Sprintf("## %d. %s: %s:%d\n\n", index+1, result.File.RepositoryID, result.File.Path, result.LineNumber))
+		text.WriteString(fmt.Sprintf("%d matches in this file, score %.2f\n\n", result.MatchCount, result.Score))
+
+		first := result.LineNumber - snippetContextLines
+		if first < 1 {
+			first = 1
+		}
+		lines := strings.Split(result.Snippet, "\n")
+		width := len(fmt.Sprint(first + len(lines) - 1))
+		text.WriteString("```" + result.File.Language + "\n")
+		for offset, line := range lines {
+			marker := " "
+			if first+offset == result.LineNumber {
+				marker = ">"
+			}
+			text.WriteString(fmt.Sprintf("%s%*d | %s\n", marker, width, first+offset, line))
+		}
+		text.WriteString("```\n\n")
+	}
+	return text.String()
+}
+
+// ************************************************************************************************
+// formatGrepResults renders grep results as Markdown, one numbered block per file like the grep
+// command: matching lines marked with ':', context lines with '-', and '--' between
+// non-adjacent groups of lines.
+//
+// Returns:
+//   - string: The Markdown document.
+func formatGrepResults(libraryID, pattern string, results []types.GrepResult, truncated bool) string {
+	matchCount := 0
+	for _, result := range results {
+		matchCount += result.MatchCount
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("# Lines of %s matching %s\n\n", libraryID, pattern))
+	text.WriteString(fmt.Sprintf("%d matching lines in %d files.\n\n", matchCount, len(results)))
+
+	for _, result := range results {
+		text.WriteString(fmt.Sprintf("## %s (%d matches)\n\n", result.Path, result.MatchCount))
+		text.WriteString("```" + result.Language + "\n")
+		for index, line := range result.Lines {
+			if index > 0 && line.Number != result.Lines[index-1].Number+1 {
+				text.WriteString("--\n")
+			}
+			separator := "-"
+			if line.Match {
+				separator = ":"
+			}
+			text.WriteString(fmt.Sprintf("%d%s%s\n", line.Number, separator, line.Text))
+		}
+		text.WriteString("```\n\n")
+	}
+
+	if truncated {
+		text.WriteString("More lines match, use a more specific pattern, a file filter or a higher maxMatches.\n")
+	}
+	return text.String()
+}
@@ -6,19 +6,26 @@ package mcp
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"path"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"repomix-mcp/internal/godoc"
+	"repomix-mcp/internal/logging"
+	"repomix-mcp/internal/tracing"
 	"repomix-mcp/pkg/types"
+
+	"golang.org/x/crypto/acme/autocert"
 )
 
 // ************************************************************************************************
@@ -29,15 +36,66 @@ type Server struct {
 	cache        CacheInterface
 	searchEngine SearchInterface
 	repositories map[string]*types.RepositoryIndex
+	repoAccess   map[string]time.Time // Last use of each in-memory repository
+	evicted      map[string]bool      // Repositories evicted from memory, reloaded from the cache on demand
+	reposMu      sync.RWMutex
 	verbose      bool
 
 	// Go module documentation retriever
 	goDocRetriever *godoc.GoDocRetriever
 
+	// Working tree listing for get-working-diff
+	fileLister FileLister
+
+	// Counting of the tokens budgets of get-library-docs
+	tokenizer *types.Tokenizer
+
+	// Validation of OAuth2/OIDC access tokens, nil when no identity provider is configured
+	tokenVerifier *jwtVerifier
+
+	// Per-client limits of tool calls and of the calls running subprocesses, nil when unlimited
+	toolCallLimiter  *rateLimiter
+	expensiveLimiter *rateLimiter
+
+	// Workers running the expensive tool executions, nil when unlimited
+	workers *workerPool
+
+	// Slots of the MCP requests handled at once, over HTTP and WebSocket, nil when unlimited
+	requestSlots chan struct{}
+
+	// Watcher re-indexing the local repositories when their files change, nil when none is watched
+	watcher *repositoryWatcher
+
+	// Indexing of configured repositories for index-repository, and its jobs
+	repositoryIndexer RepositoryIndexer
+	indexJobs         *indexJobs
+
 	// Server management
-	httpServer  *http.Server
+	httpServers []*http.Server
 	httpsServer *http.Server
 	wg          sync.WaitGroup
+	done        chan struct{} // Closed when the server stops, ending the event streams
+	stopped     chan struct{} // Closed once Stop has drained the requests and stopped the servers
+	stopOnce    sync.Once
+	draining    bool           // Set by Stop to refuse new requests, guarded by inFlightMu
+	requests    sync.WaitGroup // JSON-RPC requests being handled, waited for by Stop
+
+	// Open GET event streams and WebSocket sessions receiving server-initiated notifications,
+	// with their client session, nil for streams opened without session
+	streams   map[messageSink]*session
+	streamsMu sync.Mutex
+
+	// Client sessions by Mcp-Session-Id
+	sessions   map[string]*session
+	sessionsMu sync.Mutex
+
+	// Minimum severity of the log messages sent to clients, and the server log lines to send
+	clientLogMin atomic.Int32
+	clientLogs   chan map[string]interface{}
+
+	// Cancellation of the requests being handled, for notifications/cancelled
+	inFlight   map[inFlightKey]context.CancelFunc
+	inFlightMu sync.Mutex
 }
 
 // ************************************************************************************************
@@ -50,12 +108,34 @@ type CacheInterface interface {
 	InvalidateRepository(repositoryID string) error
 }
 
+// ************************************************************************************************
+// ContextCacheInterface is implemented by caches recording their accesses in the trace of a
+// request, such as cache.Cache.
+type ContextCacheInterface interface {
+	GetRepositoryContext(ctx context.Context, id string) (*types.RepositoryIndex, error)
+}
+
 // ************************************************************************************************
 // SearchInterface defines the interface for search operations.
 type SearchInterface interface {
 	Search(query types.SearchQuery) ([]types.SearchResult, error)
 }
 
+// ************************************************************************************************
+// SearchIndexUpdater is implemented by search engines keeping their own index of the served
+// repositories. The server updates it one repository at a time as repositories change.
+type SearchIndexUpdater interface {
+	IndexRepository(repo *types.RepositoryIndex)
+	RemoveRepository(repositoryID string)
+}
+
+// ************************************************************************************************
+// RepositoryGrepper is implemented by search engines able to return every line of a repository
+// matching a regular expression, for the grep-repository tool.
+type RepositoryGrepper interface {
+	Grep(query types.GrepQuery, repo *types.RepositoryIndex) ([]types.GrepResult, bool, error)
+}
+
 // ************************************************************************************************
 // NewServer creates a new MCP server instance.
 //
@@ -79,17 +159,47 @@ func NewServer(config *types.Config, cache CacheInterface, searchEngine SearchIn
 		cache:        cache,
 		searchEngine: searchEngine,
 		repositories: make(map[string]*types.RepositoryIndex),
+		repoAccess:   make(map[string]time.Time),
+		evicted:      make(map[string]bool),
+		done:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+		streams:      make(map[messageSink]*session),
+		sessions:     make(map[string]*session),
+		indexJobs:    newIndexJobs(),
+		clientLogs:   make(chan map[string]interface{}, clientLogQueueSize),
+		inFlight:     make(map[inFlightKey]context.CancelFunc),
+	}
+	server.tokenVerifier = newJWTVerifier(config.Server.Auth)
+	server.toolCallLimiter = newRateLimiter(config.Server.RateLimit.ToolCallsPerMinute, config.Server.RateLimit.Burst)
+	server.expensiveLimiter = newRateLimiter(config.Server.RateLimit.ExpensiveCallsPerMinute, 0)
+	server.workers = newWorkerPool(config.Server.MaxConcurrentTools, parseTimeout(config.Server.ToolQueueTimeout, 30*time.Second))
+	if limit := server.maxConcurrentRequests(); limit > 0 {
+		server.requestSlots = make(chan struct{}, limit)
+	}
+
+	tokenizer, err := types.NewTokenizer(config.Server.Tokenizer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer\n>    %w", err)
+	}
+	server.tokenizer = tokenizer
+
+	// Clients receive log messages at the server log level until they set their own
+	level, err := logging.ParseLevel(config.Server.LogLevel)
+	if err != nil {
+		level = logging.LevelInfo
 	}
+	severity, _ := clientLogSeverity(clientLogLevel(level))
+	server.clientLogMin.Store(int32(severity))
 
 	// Initialize Go module retriever if enabled
 	if config.GoModule.Enabled {
 		goDocRetriever, err := godoc.NewGoDocRetriever(&config.GoModule, cache)
 		if err != nil {
-			log.Printf("Warning: failed to initialize Go module retriever: %v", err)
-			log.Printf("Go module fallback will be disabled")
+			slog.Warn("Failed to initialize Go module retriever, Go module fallback disabled", "error", err)
 		} else {
+			goDocRetriever.SetHashAlgorithm(config.Cache.HashAlgorithm)
 			server.goDocRetriever = goDocRetriever
-			log.Printf("Go module documentation fallback enabled")
+			slog.Info("Go module documentation fallback enabled")
 		}
 	}
 
@@ -112,26 +222,74 @@ func NewServer(config *types.Config, cache CacheInterface, searchEngine SearchIn
 func (s *Server) Start() error {
 	// Create HTTP mux for handlers
 	mux := http.NewServeMux()
-	mux.HandleFunc("/mcp", s.handleMCPEndpoint)
+	mux.HandleFunc("/mcp", s.withAuthentication(s.withConcurrencyLimit(s.withCompression(s.handleMCPEndpoint))))
+	mux.HandleFunc("/mcp/ws", s.withAuthentication(s.handleWebSocket))
 	mux.HandleFunc("/health", s.handleHealth)
+	s.registerAdminRoutes(mux)
 
-	// Start HTTP server
-	httpAddress := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
-	s.httpServer = &http.Server{
-		Addr:    httpAddress,
-		Handler: mux,
+	// Client certificates are only checked by the HTTPS server, so when they are required the
+	// HTTP listeners keep serving /health only
+	httpMux := mux
+	if s.config.Server.HTTPSEnabled && s.config.Server.RequireClientCert {
+		httpMux = http.NewServeMux()
+		httpMux.HandleFunc("/health", s.handleHealth)
+		slog.Info("MCP endpoints require a client certificate, HTTP listeners only serve /health", "clientCA", s.config.Server.ClientCAPath)
 	}
 
-	log.Printf("Starting HTTP MCP server on %s", httpAddress)
-	log.Printf("HTTP MCP endpoint available at: http://%s/mcp", httpAddress)
+	// ACME certificates are obtained on the first handshake, the HTTP listeners answer the
+	// HTTP-01 challenges of the CA
+	var httpHandler http.Handler = httpMux
+	var certManager *autocert.Manager
+	if s.config.Server.HTTPSEnabled && s.config.Server.ACME.Enabled {
+		certManager = NewACMEManager(s.config.Server.ACME)
+		httpHandler = certManager.HTTPHandler(httpMux)
+	}
 
-	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("HTTP server error: %v", err)
-		}
-	}()
+	if keys := len(s.config.Server.Auth.APIKeys); keys > 0 {
+		slog.Info("MCP endpoints require an API key", "keys", keys)
+	}
+	if auth := s.config.Server.Auth; auth.Issuer != "" {
+		slog.Info("MCP endpoints accept access tokens", "issuer", auth.Issuer)
+	} else if auth.JWKSURL != "" {
+		slog.Info("MCP endpoints accept access tokens", "jwksURL", auth.JWKSURL)
+	}
+
+	// Send the server log lines to the clients listening for notifications
+	go s.forwardClientLogs()
+
+	// Bind every HTTP address up front so a bad address fails startup instead of
+	// leaving a partially running server
+	httpAddresses := s.config.Server.ListenAddresses
+	if len(httpAddresses) == 0 {
+		httpAddresses = []string{fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)}
+	}
+
+	listeners, err := listenAll(httpAddresses)
+	if err != nil {
+		return err
+	}
+
+	// Re-index the watched repositories when their files change, and the others on schedule
+	if err := s.startWatcher(); err != nil {
+		slog.Warn("Watched repositories will not be re-indexed on change", "error", err)
+	}
+	s.startScheduler()
+
+	// Start one HTTP server per address, all sharing the same mux
+	for _, listener := range listeners {
+		httpServer := s.newHTTPServer(listener.Addr().String(), httpHandler)
+		s.httpServers = append(s.httpServers, httpServer)
+
+		slog.Info("Starting HTTP MCP server", "address", listener.Addr().String(), "endpoint", fmt.Sprintf("http://%s/mcp", listener.Addr()))
+
+		s.wg.Add(1)
+		go func(server *http.Server, listener net.Listener) {
+			defer s.wg.Done()
+			if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+				slog.Error("HTTP server error", "address", listener.Addr().String(), "error", err)
+			}
+		}(httpServer, listener)
+	}
 
 	// Start HTTPS server if enabled
 	if s.config.Server.HTTPSEnabled {
@@ -142,38 +300,53 @@ func (s *Server) Start() error {
 		if s.config.Server.Host != "localhost" {
 			hosts = append(hosts, "localhost", "127.0.0.1", "::1")
 		}
+		hosts = append(hosts, s.config.Server.CertExtraSANs...)
 
-		tlsConfig, err := LoadTLSConfig(s.config.Server.CertPath, s.config.Server.KeyPath, s.config.Server.AutoGenCert, hosts)
-		if err != nil {
-			return fmt.Errorf("failed to configure TLS: %w", err)
+		certOptions := CertOptions{
+			ValidityDays: s.config.Server.CertValidityDays,
+			KeyAlgorithm: s.config.Server.CertKeyAlgorithm,
 		}
 
-		s.httpsServer = &http.Server{
-			Addr:      httpsAddress,
-			Handler:   mux,
-			TLSConfig: tlsConfig,
+		var tlsConfig *tls.Config
+		if certManager != nil {
+			tlsConfig = ACMETLSConfig(certManager)
+			slog.Info("Using ACME certificates", "hostnames", s.config.Server.ACME.Hostnames, "cacheDir", s.config.Server.ACME.CacheDir)
+		} else {
+			var err error
+			tlsConfig, err = LoadTLSConfig(s.config.Server.CertPath, s.config.Server.KeyPath, s.config.Server.AutoGenCert, hosts, certOptions)
+			if err != nil {
+				return fmt.Errorf("failed to configure TLS: %w", err)
+			}
+			if s.config.Server.AutoGenCert {
+				slog.Info("Using auto-generated self-signed certificate", "certificate", s.config.Server.CertPath, "key", s.config.Server.KeyPath)
+			}
+		}
+		if s.config.Server.ClientCAPath != "" {
+			if err := ConfigureClientAuth(tlsConfig, s.config.Server.ClientCAPath, s.config.Server.RequireClientCert); err != nil {
+				return fmt.Errorf("failed to configure client certificates: %w", err)
+			}
 		}
 
-		log.Printf("Starting HTTPS MCP server on %s", httpsAddress)
-		log.Printf("HTTPS MCP endpoint available at: https://%s/mcp", httpsAddress)
+		s.httpsServer = s.newHTTPServer(httpsAddress, mux)
+		s.httpsServer.TLSConfig = tlsConfig
 
-		if s.config.Server.AutoGenCert {
-			log.Printf("Using auto-generated self-signed certificate")
-			log.Printf("Certificate: %s", s.config.Server.CertPath)
-			log.Printf("Private Key: %s", s.config.Server.KeyPath)
-		}
+		slog.Info("Starting HTTPS MCP server", "address", httpsAddress, "endpoint", fmt.Sprintf("https://%s/mcp", httpsAddress))
 
 		s.wg.Add(1)
 		go func() {
 			defer s.wg.Done()
 			if err := s.httpsServer.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
-				log.Printf("HTTPS server error: %v", err)
+				slog.Error("HTTPS server error", "error", err)
 			}
 		}()
 	}
 
-	// Wait for servers to start
+	// Wait for the servers to stop; they stop serving as soon as Stop shuts them down, which then
+	// goes on draining the requests being handled
 	s.wg.Wait()
+	if s.stopping() {
+		<-s.stopped
+	}
 	return nil
 }
 
@@ -182,8 +355,9 @@ func (s *Server) Start() error {
 func (s *Server) handleMCPEndpoint(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, MCP-Protocol-Version")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, MCP-Protocol-Version, "+sessionHeader)
+	w.Header().Set("Access-Control-Expose-Headers", sessionHeader)
 
 	// Handle preflight requests
 	if r.Method == http.MethodOptions {
@@ -191,12 +365,40 @@ func (s *Server) handleMCPEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A DELETE request terminates a session
+	if r.Method == http.MethodDelete {
+		s.handleSessionDelete(w, r)
+		return
+	}
+
+	sess, ok := s.requestSession(w, r)
+	if !ok {
+		return
+	}
+
+	// A GET request opens an event stream for server-initiated notifications
+	if r.Method == http.MethodGet && acceptsEventStream(r) {
+		s.handleEventStream(w, r, sess)
+		return
+	}
+
 	// Only allow POST requests for JSON-RPC
 	if r.Method != http.MethodPost {
 		s.sendJSONRPCError(w, nil, -32600, "Invalid Request", "Only POST method is allowed")
 		return
 	}
 
+	// Requests of a session must use the protocol version it negotiated
+	ctx := tracing.Extract(withClientKey(r.Context(), requestClientKey(r)), r.Header)
+	if sess != nil {
+		version := r.Header.Get("MCP-Protocol-Version")
+		if negotiated := sess.negotiatedVersion(); version != "" && negotiated != "" && version != negotiated {
+			s.sendJSONRPCError(w, nil, -32600, "Invalid Request", fmt.Sprintf("Protocol version %s does not match the version %s negotiated by the session", version, negotiated))
+			return
+		}
+		ctx = withSession(ctx, sess)
+	}
+
 	// Parse JSON-RPC request
 	var jsonRPCReq types.JSONRPCRequest
 	if err := json.NewDecoder(r.Body).Decode(&jsonRPCReq); err != nil {
@@ -204,27 +406,63 @@ func (s *Server) handleMCPEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Clients accepting an event stream receive the notifications sent while handling the request
+	if acceptsEventStream(r) {
+		streaming := &streamingResponseWriter{ResponseWriter: w}
+		defer streaming.finish()
+		w = streaming
+	}
+
+	s.handleJSONRPCRequest(ctx, w, jsonRPCReq)
+}
+
+// ************************************************************************************************
+// handleJSONRPCRequest validates a JSON-RPC request and routes it to its handler, whatever the
+// transport it arrived on. The request is cancelled with ctx or by notifications/cancelled.
+func (s *Server) handleJSONRPCRequest(ctx context.Context, w http.ResponseWriter, jsonRPCReq types.JSONRPCRequest) {
 	// Validate JSON-RPC version
 	if jsonRPCReq.JsonRPC != "2.0" {
 		s.sendJSONRPCError(w, jsonRPCReq.ID, -32600, "Invalid Request", "JSON-RPC version must be 2.0")
 		return
 	}
 
+	// Requests received while the server stops are refused, the others are waited for by Stop
+	if !s.beginRequest() {
+		s.sendJSONRPCError(w, jsonRPCReq.ID, -32000, "Server error", errShuttingDown.Error())
+		return
+	}
+	defer s.requests.Done()
+
+	// Requests, unlike notifications, can be cancelled by the client
+	if jsonRPCReq.ID != nil {
+		var finished func()
+		ctx, finished = s.trackRequest(ctx, jsonRPCReq.ID)
+		defer finished()
+	}
+
 	// Add verbose logging
-	log.Printf("Received JSON-RPC request: method=%s, id=%v", jsonRPCReq.Method, jsonRPCReq.ID)
+	slog.DebugContext(ctx, "Received JSON-RPC request", "method", jsonRPCReq.Method, "id", jsonRPCReq.ID)
 
 	// Route to appropriate handler
 	switch jsonRPCReq.Method {
 	case "initialize":
-		s.handleInitialize(w, jsonRPCReq)
+		s.handleInitialize(ctx, w, jsonRPCReq)
 	case "initialized":
 		s.handleInitialized(w, jsonRPCReq)
 	case "notifications/initialized":
 		s.handleInitialized(w, jsonRPCReq)
+	case "notifications/cancelled":
+		s.handleCancelled(ctx, w, jsonRPCReq)
 	case "tools/list":
 		s.handleToolsList(w, jsonRPCReq)
 	case "tools/call":
-		s.handleToolsCall(w, jsonRPCReq)
+		s.handleToolsCall(ctx, w, jsonRPCReq)
+	case "logging/setLevel":
+		s.handleLoggingSetLevel(ctx, w, jsonRPCReq)
+	case "prompts/list":
+		s.handlePromptsList(w, jsonRPCReq)
+	case "prompts/get":
+		s.handlePromptsGet(ctx, w, jsonRPCReq)
 	case "ping":
 		s.handlePing(w, jsonRPCReq)
 	default:
@@ -233,16 +471,37 @@ func (s *Server) handleMCPEndpoint(w http.ResponseWriter, r *http.Request) {
 }
 
 // ************************************************************************************************
-// handleInitialize handles the MCP initialize request.
-func (s *Server) handleInitialize(w http.ResponseWriter, req types.JSONRPCRequest) {
-	log.Printf("Handling initialize request")
+// supportedProtocolVersions lists the MCP protocol versions the server speaks, latest first.
+// 2025-03-26 introduced the Streamable HTTP transport.
+var supportedProtocolVersions = []string{"2025-03-26", "2024-11-05"}
+
+// ************************************************************************************************
+// handleInitialize handles the MCP initialize request. HTTP clients without session receive a
+// new one in the Mcp-Session-Id header; the negotiation is recorded in the session.
+func (s *Server) handleInitialize(ctx context.Context, w http.ResponseWriter, req types.JSONRPCRequest) {
+	slog.DebugContext(ctx, "Handling initialize request")
+
+	// Answer with the version the client asks for when supported, the latest otherwise
+	protocolVersion := supportedProtocolVersions[0]
+	var params types.MCPInitializeRequest
+	if err := s.parseParams(req.Params, &params); err == nil {
+		for _, version := range supportedProtocolVersions {
+			if params.ProtocolVersion == version {
+				protocolVersion = version
+			}
+		}
+	}
 
 	result := types.MCPInitializeResult{
-		ProtocolVersion: "2024-11-05",
+		ProtocolVersion: protocolVersion,
 		Capabilities: map[string]interface{}{
 			"tools": map[string]interface{}{
 				"listChanged": false,
 			},
+			"prompts": map[string]interface{}{
+				"listChanged": false,
+			},
+			"logging": map[string]interface{}{},
 		},
 		ServerInfo: map[string]interface{}{
 			"name":    "repomix-mcp",
@@ -250,13 +509,27 @@ func (s *Server) handleInitialize(w http.ResponseWriter, req types.JSONRPCReques
 		},
 	}
 
+	sess := sessionFromContext(ctx)
+	if sess == nil {
+		var err error
+		if sess, err = s.newSession(false); err != nil {
+			slog.WarnContext(ctx, "Continuing without session", "error", err)
+		} else {
+			w.Header().Set(sessionHeader, sess.id)
+			slog.InfoContext(ctx, "Started session", "session", sess.id)
+		}
+	}
+	if sess != nil {
+		sess.initialize(protocolVersion, params.ClientInfo, params.Capabilities)
+	}
+
 	s.sendJSONRPCResult(w, req.ID, result)
 }
 
 // ************************************************************************************************
 // handleInitialized handles the MCP initialized notification.
 func (s *Server) handleInitialized(w http.ResponseWriter, req types.JSONRPCRequest) {
-	log.Printf("Handling initialized notification")
+	slog.Debug("Handling initialized notification")
 
 	// For notifications (no ID), we don't send a JSON-RPC response
 	// Just return HTTP 202 Accepted
@@ -266,9 +539,23 @@ func (s *Server) handleInitialized(w http.ResponseWriter, req types.JSONRPCReque
 // ************************************************************************************************
 // handleToolsList handles the tools/list request.
 func (s *Server) handleToolsList(w http.ResponseWriter, req types.JSONRPCRequest) {
-	log.Printf("Handling tools/list request")
+	slog.Debug("Handling tools/list request")
+
+	result := types.MCPToolsListResult{
+		Tools: toolDefinitions(),
+	}
+
+	s.sendJSONRPCResult(w, req.ID, result)
+}
 
-	tools := []types.MCPTool{
+// ************************************************************************************************
+// toolDefinitions returns the tools exposed by the server with their input schemas.
+// The schemas are advertised by tools/list and enforced on every tools/call.
+//
+// Returns:
+//   - []types.MCPTool: The tool definitions.
+func toolDefinitions() []types.MCPTool {
+	return []types.MCPTool{
 		{
 			Name:        "resolve-library-id",
 			Description: "Resolves a general library name into a repository ID. If exactly one match is found, automatically includes the documentation content (public/exported data only).",
@@ -312,6 +599,12 @@ func (s *Server) handleToolsList(w http.ResponseWriter, req types.JSONRPCRequest
 						"description": "Include non-exported constructs in Go projects (default: false)",
 						"default":     false,
 					},
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"description": "Rendering mode: full documentation, or a compact summary of the exported Go API (signatures and one-line docs only)",
+						"enum":        []string{"full", "summary"},
+						"default":     "full",
+					},
 				},
 				"required": []string{"library-id"},
 			},
@@ -355,370 +648,1693 @@ func (s *Server) handleToolsList(w http.ResponseWriter, req types.JSONRPCRequest
 				"required": []string{"library-id"},
 			},
 		},
-	}
-
-	result := types.MCPToolsListResult{
-		Tools: tools,
-	}
-
-	s.sendJSONRPCResult(w, req.ID, result)
-}
-
-// ************************************************************************************************
-// handleToolsCall handles the tools/call request.
-func (s *Server) handleToolsCall(w http.ResponseWriter, req types.JSONRPCRequest) {
-	log.Printf("Handling tools/call request")
-
-	// Parse parameters
-	var params types.MCPToolCallParams
-	if err := s.parseParams(req.Params, &params); err != nil {
-		s.sendJSONRPCError(w, req.ID, -32602, "Invalid params", fmt.Sprintf("Failed to parse parameters: %v", err))
-		return
-	}
-
-	log.Printf("Tool call: name=%s, arguments=%+v", params.Name, params.Arguments)
-
-	// Route to specific tool handler
-	switch params.Name {
-	case "resolve-library-id":
-		s.handleResolveLibraryID(w, req.ID, params.Arguments)
-	case "get-library-docs":
-		s.handleGetLibraryDocs(w, req.ID, params.Arguments)
-	case "refresh":
-		s.handleRefresh(w, req.ID, params.Arguments)
-	case "get-readme":
-		s.handleGetReadme(w, req.ID, params.Arguments)
-	default:
-		s.sendJSONRPCError(w, req.ID, -32602, "Invalid params", fmt.Sprintf("Unknown tool: %s", params.Name))
-	}
-}
-
-// ************************************************************************************************
-// handlePing handles the ping request.
-func (s *Server) handlePing(w http.ResponseWriter, req types.JSONRPCRequest) {
-	log.Printf("Handling ping request")
-	s.sendJSONRPCResult(w, req.ID, map[string]interface{}{})
-}
-
-// ************************************************************************************************
-// handleResolveLibraryID handles the resolve-library-id tool.
-func (s *Server) handleResolveLibraryID(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
-	// Extract library name
-	libraryName, ok := arguments["libraryName"].(string)
-	if !ok || libraryName == "" {
-		s.sendToolError(w, id, "libraryName parameter is required and must be a string")
-		return
-	}
-
-	// Extract optional tokens parameter (only used for single match auto-content)
-	tokens := 10000 // Default value
-	if tokensParam, exists := arguments["tokens"]; exists {
-		switch v := tokensParam.(type) {
-		case float64:
-			tokens = int(v)
-		case int:
-			tokens = v
-		case string:
-			if parsed, err := strconv.Atoi(v); err == nil {
-				tokens = parsed
-			}
-		}
-	}
-
-	// Ensure minimum token count
-	if tokens < 1000 {
-		tokens = 1000
-	}
-
-	log.Printf("Resolving library: %s (tokens=%d)", libraryName, tokens)
-
-	// Find matching repositories
-	matches := s.findRepositoryMatches(libraryName)
-
-	// If no matches found, try Go module fallback
-	if len(matches) == 0 && s.isGoModuleEnabled() {
-		if godoc.IsGoModulePath(libraryName) {
-			log.Printf("Attempting Go module fallback for: %s", libraryName)
-			if repoID, err := s.tryGoModuleFallback(libraryName); err == nil {
-				matches = append(matches, repoID)
-			} else {
-				log.Printf("Go module fallback failed for %s: %v", libraryName, err)
-			}
-		}
-	}
-
-	if len(matches) == 0 {
-		s.sendToolError(w, id, fmt.Sprintf("No repository found for library: %s", libraryName))
-		return
-	}
-
-	// Enhanced behavior: if exactly one match, include documentation content
-	if len(matches) == 1 {
-		bestMatch := matches[0]
-		log.Printf("Single match found for library '%s': %s - including documentation content (public/exported only)", libraryName, bestMatch)
-
-		// Get documentation content for the single match (public/exported data only)
-		docs, err := s.getRepositoryDocs(bestMatch, "", tokens, false) // includeNonExported=false
-		if err != nil {
-			log.Printf("Warning: failed to get documentation for %s: %v", bestMatch, err)
-			// Fall back to just returning the ID
-			result := types.MCPToolCallResult{
-				Content: []types.MCPContent{
-					{
-						Type: "text",
-						Text: bestMatch,
+		{
+			Name:        "list-packages",
+			Description: "List all packages of a Go module fetched through the Go module fallback",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"library-id": map[string]interface{}{
+						"type":        "string",
+						"description": "Go module repository ID (gomod:<module path>) from resolve-library-id",
 					},
 				},
-				IsError: false,
-			}
-			s.sendJSONRPCResult(w, id, result)
-			return
-		}
-
-		// Return both the ID and the documentation content
-		result := types.MCPToolCallResult{
-			Content: []types.MCPContent{
-				{
-					Type: "text",
-					Text: fmt.Sprintf("Repository ID: %s\n\n%s", bestMatch, docs),
+				"required": []string{"library-id"},
+			},
+		},
+		{
+			Name:        "get-file-tree",
+			Description: "Show the directory tree of a repository annotated with file count, size and dominant language per directory (requires indexing.directoryStats)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"library-id": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository ID from resolve-library-id",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory to start from, relative to the repository root (default: root)",
+					},
+					"depth": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of directory levels to show below the starting directory",
+						"default":     3,
+					},
 				},
+				"required": []string{"library-id"},
 			},
-			IsError: false,
-		}
-		s.sendJSONRPCResult(w, id, result)
-		return
-	}
-
-	// Multiple matches: return list of IDs (original behavior)
-	log.Printf("Multiple matches found for library '%s': %v", libraryName, matches)
-	var matchList strings.Builder
-	matchList.WriteString(fmt.Sprintf("Multiple repositories found for '%s':\n\n", libraryName))
-	for i, match := range matches {
-		matchList.WriteString(fmt.Sprintf("%d. %s\n", i+1, match))
-	}
-	matchList.WriteString(fmt.Sprintf("\nUse get-library-docs with one of these IDs to retrieve documentation."))
-
-	result := types.MCPToolCallResult{
-		Content: []types.MCPContent{
-			{
-				Type: "text",
-				Text: matchList.String(),
+		},
+		{
+			Name:        "list-todos",
+			Description: "List the TODO/FIXME/HACK/XXX work item comments found in a repository, with file and line",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"library-id": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository ID from resolve-library-id",
+					},
+					"marker": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list items with this marker, e.g. FIXME",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list items in files below this path, relative to the repository root",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of items to return",
+						"default":     100,
+					},
+				},
+				"required": []string{"library-id"},
 			},
 		},
-		IsError: false,
-	}
-
-	s.sendJSONRPCResult(w, id, result)
-}
-
-// ************************************************************************************************
-// handleRefresh handles the refresh tool for cache invalidation.
+		{
+			Name:        "get-working-diff",
+			Description: "List the files of a local repository added, modified or deleted since it was last indexed, without re-indexing",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"library-id": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository ID from resolve-library-id",
+					},
+					"include-content": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include the current content of added and modified files",
+						"default":     false,
+					},
+					"tokens": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum size of the included file content",
+						"default":     10000,
+					},
+				},
+				"required": []string{"library-id"},
+			},
+		},
+		{
+			Name:        "get-implementations",
+			Description: "List the types implementing a Go interface across the packages of a repository, or the interfaces a type implements (Go-native indexing only)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"library-id": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository ID from resolve-library-id",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Interface or type name: bare (Store), package-qualified (storage.Store) or fully qualified (example.com/app/storage.Store)",
+					},
+				},
+				"required": []string{"library-id", "name"},
+			},
+		},
+		{
+			Name:        "get-references",
+			Description: "List the call sites of a Go function or method within a repository, with the function containing each call (Go-native indexing only)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"library-id": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository ID from resolve-library-id",
+					},
+					"symbol": map[string]interface{}{
+						"type":        "string",
+						"description": "Function or method name: bare (Open), package-qualified (storage.Open), method with its receiver type (Store.Get) or fully qualified (example.com/app/storage.Store.Get)",
+					},
+				},
+				"required": []string{"library-id", "symbol"},
+			},
+		},
+		{
+			Name:        "get-symbol",
+			Description: "Look up a Go function, method, type, variable or constant of a repository by name, returning its signature, file, line and doc comment (Go-native indexing only)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"library-id": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository ID from resolve-library-id",
+					},
+					"symbol": map[string]interface{}{
+						"type":        "string",
+						"description": "Symbol name: bare (Open), package-qualified (storage.Open), method with its receiver type (Store.Get) or fully qualified (example.com/app/storage.Store.Get)",
+					},
+				},
+				"required": []string{"library-id", "symbol"},
+			},
+		},
+		{
+			Name:        "search-code",
+			Description: "Search the content of indexed repositories and return ranked snippets with line numbers, instead of retrieving whole documentation",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Text to search for, case-insensitive, or a regular expression between slashes, e.g. /func New\\w+/",
+					},
+					"library-id": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository ID from resolve-library-id (default: all repositories)",
+					},
+					"language": map[string]interface{}{
+						"type":        "string",
+						"description": "Only search files of this language, e.g. go, python, typescript",
+					},
+					"filePattern": map[string]interface{}{
+						"type":        "string",
+						"description": "Only search files matching this glob, against the file name (*.go) or, with a slash, the path (internal/*/*.go)",
+					},
+					"maxResults": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of files returned",
+						"default":     20,
+					},
+					"exportedOnly": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only match lines of exported Go constructs",
+						"default":     false,
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+		{
+			Name:        "grep-repository",
+			Description: "Search a repository for a regular expression, returning every matching line with its line number and surrounding context",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"library-id": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository ID from resolve-library-id or list-libraries",
+					},
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "Regular expression in RE2 syntax, matched against each line, e.g. func \\(s \\*Server\\) handle\\w+",
+					},
+					"ignoreCase": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Match case-insensitively",
+						"default":     false,
+					},
+					"filePattern": map[string]interface{}{
+						"type":        "string",
+						"description": "Only search files matching this glob, against the file name (*.go) or, with a slash, the path (internal/*/*.go)",
+					},
+					"language": map[string]interface{}{
+						"type":        "string",
+						"description": "Only search files of this language, e.g. go, python, typescript",
+					},
+					"contextLines": map[string]interface{}{
+						"type":        "integer",
+						"description": "Lines shown before and after each match",
+						"default":     2,
+					},
+					"maxMatches": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of matching lines returned",
+						"default":     100,
+					},
+				},
+				"required": []string{"library-id", "pattern"},
+			},
+		},
+		{
+			Name:        "find-repository-by-file",
+			Description: "Find the repositories containing a file, given its path or the end of its path, ranked by how specific the match is",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "File path, e.g. internal/cache/cache.go, cache/cache.go or an absolute path on the server",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of files listed",
+						"default":     20,
+					},
+				},
+				"required": []string{"path"},
+			},
+		},
+		{
+			Name:        "get-file",
+			Description: "Retrieve the exact content of a single indexed file of a repository, given its path",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"library-id": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository ID from resolve-library-id or list-libraries",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "File path relative to the repository root, e.g. internal/cache/cache.go",
+					},
+				},
+				"required": []string{"library-id", "path"},
+			},
+		},
+		{
+			Name:        "index-repository",
+			Description: "Start indexing a configured repository in the background, returning a job ID to follow with get-index-job",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repository": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository alias from the server configuration; glob entries index every matching repository",
+					},
+					"wait": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Wait for the job to end, reporting its progress to requests with a progress token (default: false)",
+					},
+				},
+				"required": []string{"repository"},
+			},
+		},
+		{
+			Name:        "get-index-job",
+			Description: "Get the status of an indexing job started by index-repository, or list the recent jobs",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jobId": map[string]interface{}{
+						"type":        "string",
+						"description": "Job ID returned by index-repository, empty to list the recent jobs",
+					},
+				},
+			},
+		},
+		{
+			Name:        "list-libraries",
+			Description: "List every available repository ID, Go modules included, with its file count, last indexing time and commit",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"includeGoModules": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also list the Go modules fetched on demand (gomod: IDs)",
+						"default":     true,
+					},
+				},
+			},
+		},
+		{
+			Name:        "get-api-spec",
+			Description: "Get the OpenAPI/Swagger specification of a repository, or a summary of its endpoints",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"library-id": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository ID from resolve-library-id",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Specification path or file name, when the repository has several (default: the first one)",
+					},
+					"summary": map[string]interface{}{
+						"type":        "boolean",
+						"description": "List the endpoints and schemas instead of returning the specification content",
+						"default":     false,
+					},
+				},
+				"required": []string{"library-id"},
+			},
+		},
+	}
+}
+
+// ************************************************************************************************
+// findTool returns the definition of the named tool.
+func findTool(name string) (types.MCPTool, bool) {
+	for _, tool := range toolDefinitions() {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return types.MCPTool{}, false
+}
+
+// ************************************************************************************************
+// handleToolsCall handles the tools/call request.
+func (s *Server) handleToolsCall(ctx context.Context, w http.ResponseWriter, req types.JSONRPCRequest) {
+	slog.DebugContext(ctx, "Handling tools/call request")
+
+	// Parse parameters
+	var params types.MCPToolCallParams
+	if err := s.parseParams(req.Params, &params); err != nil {
+		s.sendJSONRPCError(w, req.ID, -32602, "Invalid params", fmt.Sprintf("Failed to parse parameters: %v", err))
+		return
+	}
+
+	tool, exists := findTool(params.Name)
+	if !exists {
+		s.sendJSONRPCError(w, req.ID, -32602, "Invalid params", fmt.Sprintf("Unknown tool: %s", params.Name))
+		return
+	}
+
+	// Tag the log lines of the call with the tool and repository it is about
+	ctx = logging.ContextWith(ctx, "tool", params.Name)
+	ctx, span := tracing.Start(ctx, "tools/call "+params.Name, tracing.String("mcp.tool.name", params.Name))
+	defer span.End()
+	if libraryID, ok := params.Arguments["library-id"].(string); ok {
+		ctx = logging.ContextWith(ctx, "repository", libraryID)
+		span.SetAttributes(tracing.String("mcp.library_id", libraryID))
+	}
+	slog.InfoContext(ctx, "Tool call", "arguments", params.Arguments)
+	if err := validateArguments(tool.InputSchema, params.Arguments); err != nil {
+		s.sendJSONRPCError(w, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	if err := s.checkToolAccess(ctx, params.Name, params.Arguments); err != nil {
+		span.RecordError(err)
+		s.sendToolError(w, req.ID, err.Error())
+		return
+	}
+	if wait := s.allowToolCall(ctx, params.Name); wait > 0 {
+		span.RecordError(errRateLimited{retryAfter: wait})
+		sendRateLimited(w, req.ID, wait)
+		return
+	}
+	if pooledTools[params.Name] {
+		workerCtx, release, err := s.acquireWorker(ctx, params.Name)
+		if err != nil {
+			span.RecordError(err)
+			s.sendJSONRPCError(w, req.ID, -32000, "Server busy", err.Error())
+			return
+		}
+		defer release()
+		ctx = workerCtx
+	}
+	w = withProgressToken(w, params.Meta)
+
+	// Route to specific tool handler
+	switch params.Name {
+	case "resolve-library-id":
+		s.handleResolveLibraryID(ctx, w, req.ID, params.Arguments)
+	case "get-library-docs":
+		s.handleGetLibraryDocs(ctx, w, req.ID, params.Arguments)
+	case "refresh":
+		s.handleRefresh(w, req.ID, params.Arguments)
+	case "get-readme":
+		s.handleGetReadme(w, req.ID, params.Arguments)
+	case "list-packages":
+		s.handleListPackages(ctx, w, req.ID, params.Arguments)
+	case "get-file-tree":
+		s.handleGetFileTree(w, req.ID, params.Arguments)
+	case "list-todos":
+		s.handleListTodos(w, req.ID, params.Arguments)
+	case "get-working-diff":
+		s.handleGetWorkingDiff(w, req.ID, params.Arguments)
+	case "get-implementations":
+		s.handleGetImplementations(w, req.ID, params.Arguments)
+	case "get-api-spec":
+		s.handleGetAPISpec(w, req.ID, params.Arguments)
+	case "get-references":
+		s.handleGetReferences(w, req.ID, params.Arguments)
+	case "get-symbol":
+		s.handleGetSymbol(w, req.ID, params.Arguments)
+	case "search-code":
+		s.handleSearchCode(ctx, w, req.ID, params.Arguments)
+	case "grep-repository":
+		s.handleGrepRepository(w, req.ID, params.Arguments)
+	case "find-repository-by-file":
+		s.handleFindRepositoryByFile(ctx, w, req.ID, params.Arguments)
+	case "get-file":
+		s.handleGetFile(w, req.ID, params.Arguments)
+	case "index-repository":
+		s.handleIndexRepository(ctx, w, req.ID, params.Arguments)
+	case "get-index-job":
+		s.handleGetIndexJob(ctx, w, req.ID, params.Arguments)
+	case "list-libraries":
+		s.handleListLibraries(ctx, w, req.ID, params.Arguments)
+	default:
+		s.sendJSONRPCError(w, req.ID, -32602, "Invalid params", fmt.Sprintf("Unknown tool: %s", params.Name))
+	}
+}
+
+// ************************************************************************************************
+// handlePing handles the ping request.
+func (s *Server) handlePing(w http.ResponseWriter, req types.JSONRPCRequest) {
+	slog.Debug("Handling ping request")
+	s.sendJSONRPCResult(w, req.ID, map[string]interface{}{})
+}
+
+// ************************************************************************************************
+// handleResolveLibraryID handles the resolve-library-id tool.
+func (s *Server) handleResolveLibraryID(ctx context.Context, w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	// Extract library name
+	libraryName, ok := arguments["libraryName"].(string)
+	if !ok || libraryName == "" {
+		s.sendToolError(w, id, "libraryName parameter is required and must be a string")
+		return
+	}
+
+	// Extract optional tokens parameter (only used for single match auto-content)
+	tokens, err := parseIntArg(arguments, "tokens", 10000, 1, maxTokensArg)
+	if err != nil {
+		s.sendJSONRPCError(w, id, -32602, "Invalid params", err.Error())
+		return
+	}
+
+	// Ensure minimum token count
+	if tokens < 1000 {
+		tokens = 1000
+	}
+
+	slog.InfoContext(ctx, "Resolving library", "library", libraryName, "tokens", tokens)
+
+	// Find matching repositories and workspaces
+	matches := s.findRepositoryMatches(libraryName)
+	matches = s.filterAccessible(ctx, append(matches, s.findWorkspaceMatches(libraryName)...))
+
+	// If no matches found, try Go module fallback
+	if len(matches) == 0 && s.isGoModuleEnabled() {
+		if godoc.IsGoModulePath(libraryName) {
+			slog.InfoContext(ctx, "Attempting Go module fallback", "library", libraryName)
+			s.notifyMessage(ctx, w, "info", fmt.Sprintf("Retrieving Go module documentation for %s", libraryName))
+			if repoID, err := s.tryGoModuleFallback(ctx, libraryName, s.progressFunc(w)); err == nil {
+				matches = append(matches, repoID)
+			} else {
+				slog.WarnContext(ctx, "Go module fallback failed", "library", libraryName, "error", err)
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		s.sendToolError(w, id, fmt.Sprintf("No repository found for library: %s", libraryName))
+		return
+	}
+
+	// Enhanced behavior: if exactly one match, include documentation content
+	if len(matches) == 1 {
+		bestMatch := matches[0]
+		slog.InfoContext(ctx, "Single match found for library, including its documentation", "library", libraryName, "repository", bestMatch)
+
+		// Get documentation content for the single match (public/exported data only)
+		docs, err := s.getRepositoryDocs(ctx, bestMatch, "", tokens, false) // includeNonExported=false
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to get documentation", "repository", bestMatch, "error", err)
+			// Fall back to just returning the ID
+			result := types.MCPToolCallResult{
+				Content: []types.MCPContent{
+					{
+						Type: "text",
+						Text: bestMatch,
+					},
+				},
+				IsError: false,
+			}
+			s.sendJSONRPCResult(w, id, result)
+			return
+		}
+
+		// Return both the ID and the documentation content
+		result := types.MCPToolCallResult{
+			Content: []types.MCPContent{
+				{
+					Type: "text",
+					Text: fmt.Sprintf("Repository ID: %s\n\n%s", bestMatch, docs),
+				},
+			},
+			IsError: false,
+		}
+		s.sendJSONRPCResult(w, id, result)
+		return
+	}
+
+	// Multiple matches: return list of IDs (original behavior)
+	slog.InfoContext(ctx, "Multiple matches found for library", "library", libraryName, "matches", matches)
+	var matchList strings.Builder
+	matchList.WriteString(fmt.Sprintf("Multiple repositories found for '%s':\n\n", libraryName))
+	for i, match := range matches {
+		matchList.WriteString(fmt.Sprintf("%d. %s", i+1, match))
+		if workspace := s.config.Workspaces[strings.TrimPrefix(match, types.WorkspaceRepositoryPrefix)]; types.IsWorkspaceRepositoryID(match) && workspace.Description != "" {
+			matchList.WriteString(fmt.Sprintf(" - %s", workspace.Description))
+		}
+		matchList.WriteString("\n")
+	}
+	matchList.WriteString(fmt.Sprintf("\nUse get-library-docs with one of these IDs to retrieve documentation."))
+
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: matchList.String(),
+			},
+		},
+		IsError: false,
+	}
+
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleRefresh handles the refresh tool for cache invalidation.
 func (s *Server) handleRefresh(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
 	// Extract optional parameters
-	repositoryID, _ := arguments["repositoryID"].(string)
-	force, _ := arguments["force"].(bool)
+	repositoryID, _ := arguments["repositoryID"].(string)
+	force, _ := arguments["force"].(bool)
+
+	slog.Info("Handling refresh", "repository", repositoryID, "force", force)
+
+	var refreshedCount int
+	var errors []string
+
+	if s.cache == nil {
+		s.sendToolError(w, id, "Cache not available")
+		return
+	}
+
+	if repositoryID != "" {
+		// Refresh specific repository
+		err := s.cache.InvalidateRepository(repositoryID)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("Failed to refresh %s: %v", repositoryID, err))
+		} else {
+			refreshedCount = 1
+			s.removeFromSearchIndex(repositoryID)
+			slog.Info("Refreshed repository cache", "repository", repositoryID)
+		}
+	} else {
+		// Refresh all repositories
+		err := s.cache.InvalidateAll()
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("Failed to refresh all repositories: %v", err))
+		} else {
+			// Count how many repositories were in cache
+			repos, err := s.cache.ListRepositories()
+			if err == nil {
+				refreshedCount = len(repos)
+			}
+			for _, repoID := range s.memoryRepositoryIDs() {
+				s.removeFromSearchIndex(repoID)
+			}
+			slog.Info("Refreshed all repository caches")
+		}
+	}
+
+	// Build response message
+	var message strings.Builder
+	if refreshedCount > 0 {
+		if repositoryID != "" {
+			message.WriteString(fmt.Sprintf("Successfully refreshed repository: %s", repositoryID))
+		} else {
+			message.WriteString(fmt.Sprintf("Successfully refreshed %d repositories", refreshedCount))
+		}
+	}
+
+	if len(errors) > 0 {
+		if message.Len() > 0 {
+			message.WriteString("\n\nErrors encountered:\n")
+		}
+		message.WriteString(strings.Join(errors, "\n"))
+	}
+
+	if refreshedCount == 0 && len(errors) == 0 {
+		message.WriteString("No repositories found to refresh")
+	}
+
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: message.String(),
+			},
+		},
+		IsError: len(errors) > 0 && refreshedCount == 0,
+	}
+
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleListPackages handles the list-packages tool for Go module repositories.
+func (s *Server) handleListPackages(ctx context.Context, w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	// Extract library ID
+	libraryID, ok := arguments["library-id"].(string)
+	if !ok || libraryID == "" {
+		s.sendToolError(w, id, "library-id parameter is required and must be a string")
+		return
+	}
+
+	if !types.IsGoModuleRepositoryID(libraryID) {
+		s.sendToolError(w, id, fmt.Sprintf("list-packages only supports Go module repositories (gomod:<module path>), got: %s", libraryID))
+		return
+	}
+
+	slog.InfoContext(ctx, "Listing packages")
+	s.notifyMessage(ctx, w, "info", fmt.Sprintf("Retrieving Go module %s", libraryID))
+
+	repo, err := s.getGoModuleRepository(ctx, libraryID, s.progressFunc(w))
+	if err != nil {
+		s.sendToolError(w, id, fmt.Sprintf("Failed to get Go module %s: %v", libraryID, err))
+		return
+	}
+
+	var packages []string
+	if file, exists := repo.Files["packages.txt"]; exists {
+		for _, pkg := range strings.Split(file.Content, "\n") {
+			if pkg = strings.TrimSpace(pkg); pkg != "" {
+				packages = append(packages, pkg)
+			}
+		}
+	}
+
+	if len(packages) == 0 {
+		s.sendToolError(w, id, fmt.Sprintf("No packages found for Go module: %s", libraryID))
+		return
+	}
+
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("# Packages of %s\n\n", strings.TrimPrefix(libraryID, types.GoModuleRepositoryPrefix)))
+	response.WriteString(fmt.Sprintf("Found %d packages.\n\n", len(packages)))
+	for _, pkg := range packages {
+		response.WriteString(fmt.Sprintf("- %s\n", pkg))
+	}
+
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: response.String(),
+			},
+		},
+		IsError: false,
+	}
+
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleGetReadme handles the get-readme tool for README extraction.
+func (s *Server) handleGetReadme(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	// Extract library ID
+	libraryID, ok := arguments["library-id"].(string)
+	if !ok || libraryID == "" {
+		s.sendToolError(w, id, "library-id parameter is required and must be a string")
+		return
+	}
+	if s.isRepositoryDisabled(libraryID) {
+		s.sendToolError(w, id, fmt.Sprintf("Repository %s is disabled", libraryID))
+		return
+	}
+
+	// Extract optional format parameter
+	format, _ := arguments["format"].(string)
+	if format == "" {
+		format = "markdown"
+	}
+
+	slog.Info("Getting README", "repository", libraryID, "format", format)
+
+	// Get repository from cache
+	var repo *types.RepositoryIndex
+	var err error
+
+	if s.cache != nil {
+		repo, err = s.cache.GetRepository(libraryID)
+		if err != nil {
+			// Try in-memory repositories
+			if repoMem, exists := s.getMemoryRepository(libraryID); exists {
+				repo = repoMem
+			} else {
+				s.sendToolError(w, id, fmt.Sprintf("Repository not found: %s", libraryID))
+				return
+			}
+		}
+	} else {
+		// Try in-memory repositories
+		if repoMem, exists := s.getMemoryRepository(libraryID); exists {
+			repo = repoMem
+		} else {
+			s.sendToolError(w, id, fmt.Sprintf("Repository not found: %s", libraryID))
+			return
+		}
+	}
+
+	// Look for README files from all subfolders
+	readmeFiles := s.findAllReadmeFiles(repo)
+
+	if len(readmeFiles) == 0 {
+		s.sendToolError(w, id, fmt.Sprintf("No README files found in repository: %s", libraryID))
+		return
+	}
+
+	// Use the first (highest priority) README file for single file response
+	// Priority order: root → shallow subfolders → deeper subfolders
+	readmeFile := &readmeFiles[0]
+	readmePath := readmeFile.Path
+
+	// Format the content based on requested format
+	content := readmeFile.Content
+	if format == "text" && strings.HasSuffix(strings.ToLower(readmePath), ".md") {
+		// Simple markdown to text conversion - remove basic markdown syntax
+		content = strings.ReplaceAll(content, "**", "")
+		content = strings.ReplaceAll(content, "*", "")
+		content = strings.ReplaceAll(content, "`", "")
+		// Remove markdown headers
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			if strings.HasPrefix(line, "#") {
+				lines[i] = strings.TrimLeft(line, "# ")
+			}
+		}
+		content = strings.Join(lines, "\n")
+	}
+
+	// Build response with multiple README files if available
+	var response strings.Builder
+
+	if len(readmeFiles) == 1 {
+		// Single README file response
+		response.WriteString(fmt.Sprintf("# README from %s\n\n", libraryID))
+		response.WriteString(fmt.Sprintf("**File:** %s\n", readmePath))
+		response.WriteString(fmt.Sprintf("**Size:** %d bytes\n", readmeFile.Size))
+		response.WriteString(fmt.Sprintf("**Language:** %s\n", readmeFile.Language))
+		response.WriteString(fmt.Sprintf("**Format:** %s\n\n", format))
+		response.WriteString("---\n\n")
+		response.WriteString(content)
+	} else {
+		// Multiple README files response
+		response.WriteString(fmt.Sprintf("# README Files from %s\n\n", libraryID))
+		response.WriteString(fmt.Sprintf("Found %d README files in repository.\n\n", len(readmeFiles)))
+
+		for i, file := range readmeFiles {
+			folderPath := filepath.Dir(file.Path)
+			if folderPath == "." {
+				folderPath = "(root)"
+			}
+
+			response.WriteString(fmt.Sprintf("## README %d: %s\n", i+1, folderPath))
+			response.WriteString(fmt.Sprintf("**File:** %s\n", file.Path))
+			response.WriteString(fmt.Sprintf("**Size:** %d bytes\n", file.Size))
+			response.WriteString(fmt.Sprintf("**Language:** %s\n\n", file.Language))
+
+			// Format content for this README
+			fileContent := file.Content
+			if format == "text" && strings.HasSuffix(strings.ToLower(file.Path), ".md") {
+				// Simple markdown to text conversion
+				fileContent = strings.ReplaceAll(fileContent, "**", "")
+				fileContent = strings.ReplaceAll(fileContent, "*", "")
+				fileContent = strings.ReplaceAll(fileContent, "`", "")
+				// Remove markdown headers
+				lines := strings.Split(fileContent, "\n")
+				for j, line := range lines {
+					if strings.HasPrefix(line, "#") {
+						lines[j] = strings.TrimLeft(line, "# ")
+					}
+				}
+				fileContent = strings.Join(lines, "\n")
+			}
+
+			response.WriteString("```\n")
+			response.WriteString(fileContent)
+			response.WriteString("\n```\n\n")
+
+			if i < len(readmeFiles)-1 {
+				response.WriteString("---\n\n")
+			}
+		}
+	}
+
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: response.String(),
+			},
+		},
+		IsError: false,
+	}
+
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleGetLibraryDocs handles the get-library-docs tool.
+func (s *Server) handleGetLibraryDocs(ctx context.Context, w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	// Extract library ID
+	libraryID, ok := arguments["library-id"].(string)
+	if !ok || libraryID == "" {
+		s.sendToolError(w, id, "library-id parameter is required and must be a string")
+		return
+	}
+	if s.isRepositoryDisabled(libraryID) {
+		s.sendToolError(w, id, fmt.Sprintf("Repository %s is disabled", libraryID))
+		return
+	}
+
+	// Extract optional parameters
+	topic, _ := arguments["topic"].(string)
+	includeNonExported, _ := arguments["includeNonExported"].(bool)
+	mode, _ := arguments["mode"].(string)
+	if mode == "" {
+		mode = "full"
+	}
+	if mode != "full" && mode != "summary" {
+		s.sendToolError(w, id, fmt.Sprintf("invalid mode %q: must be \"full\" or \"summary\"", mode))
+		return
+	}
+
+	// Handle tokens parameter (can be number or string)
+	tokens, err := parseIntArg(arguments, "tokens", 10000, 1, maxTokensArg)
+	if err != nil {
+		s.sendJSONRPCError(w, id, -32602, "Invalid params", err.Error())
+		return
+	}
+
+	// Ensure minimum token count
+	if tokens < 1000 {
+		tokens = 1000
+	}
+
+	slog.InfoContext(ctx, "Getting library docs", "topic", topic, "tokens", tokens, "includeNonExported", includeNonExported, "mode", mode)
+
+	if types.IsGoModuleRepositoryID(libraryID) {
+		s.notifyMessage(ctx, w, "info", fmt.Sprintf("Retrieving Go module documentation for %s", libraryID))
+
+		// Fetch the module here so the retrieval reports its progress; the documentation is
+		// then read from the cache
+		if _, err := s.getGoModuleRepository(ctx, libraryID, s.progressFunc(w)); err != nil {
+			s.sendToolError(w, id, err.Error())
+			return
+		}
+	}
+
+	// Get repository documentation
+	var docs string
+	if mode == "summary" {
+		docs, err = s.getAPISummary(libraryID, tokens)
+	} else {
+		docs, err = s.getRepositoryDocs(ctx, libraryID, topic, tokens, includeNonExported)
+	}
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: docs,
+			},
+		},
+		IsError: false,
+	}
+
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleGetFileTree handles the get-file-tree tool.
+func (s *Server) handleGetFileTree(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	libraryID, _ := arguments["library-id"].(string)
+	if s.isRepositoryDisabled(libraryID) {
+		s.sendToolError(w, id, fmt.Sprintf("Repository %s is disabled", libraryID))
+		return
+	}
+
+	root, _ := arguments["path"].(string)
+	root = path.Clean("./" + filepath.ToSlash(root))
+	depth, err := parseIntArg(arguments, "depth", 3, 0, 100)
+	if err != nil {
+		s.sendJSONRPCError(w, id, -32602, "Invalid params", err.Error())
+		return
+	}
+
+	slog.Info("Getting file tree", "repository", libraryID, "path", root, "depth", depth)
+
+	repo, err := s.lookupRepository(libraryID)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+
+	value, exists := repo.Metadata[types.DirectoryStatsMetadataKey]
+	if !exists {
+		s.sendToolError(w, id, fmt.Sprintf("No directory statistics for %s: enable indexing.directoryStats and re-index the repository", libraryID))
+		return
+	}
+	stats, err := types.DecodeDirectoryStats(value)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+	if _, exists := stats[root]; !exists {
+		s.sendToolError(w, id, fmt.Sprintf("Directory not found in %s: %s", libraryID, root))
+		return
+	}
+
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: formatFileTree(libraryID, root, stats, depth),
+			},
+		},
+		IsError: false,
+	}
+
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// formatFileTree renders directory statistics as an indented tree below root, one line per
+// directory with its rolled-up file count, size and language breakdown.
+func formatFileTree(libraryID, root string, stats map[string]*types.DirectoryStats, depth int) string {
+	children := make(map[string][]string)
+	for dir := range stats {
+		if dir != "." {
+			parent := path.Dir(dir)
+			children[parent] = append(children[parent], dir)
+		}
+	}
+
+	var tree strings.Builder
+	tree.WriteString(fmt.Sprintf("# File tree: %s\n\n", libraryID))
+	tree.WriteString("Counts include subdirectories.\n\n")
+
+	var walk func(dir string, level int)
+	walk = func(dir string, level int) {
+		entry := stats[dir]
+		name := path.Base(dir) + "/"
+		if level == 0 {
+			name = dir + "/"
+		}
+
+		languages := make([]string, 0, len(entry.Languages))
+		for language := range entry.Languages {
+			languages = append(languages, language)
+		}
+		sort.Slice(languages, func(i, j int) bool {
+			if entry.Languages[languages[i]] != entry.Languages[languages[j]] {
+				return entry.Languages[languages[i]] > entry.Languages[languages[j]]
+			}
+			return languages[i] < languages[j]
+		})
+		breakdown := make([]string, len(languages))
+		for i, language := range languages {
+			breakdown[i] = fmt.Sprintf("%s: %d", language, entry.Languages[language])
+		}
+
+		tree.WriteString(fmt.Sprintf("%s%s - %d files, %s, mostly %s (%s)\n",
+			strings.Repeat("  ", level), name, entry.Files, types.FormatByteSize(entry.Bytes), entry.Dominant, strings.Join(breakdown, ", ")))
+
+		if level >= depth {
+			return
+		}
+		subdirs := children[dir]
+		sort.Strings(subdirs)
+		for _, subdir := range subdirs {
+			walk(subdir, level+1)
+		}
+	}
+	walk(root, 0)
+
+	return tree.String()
+}
+
+// ************************************************************************************************
+// handleListTodos handles the list-todos tool.
+func (s *Server) handleListTodos(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	libraryID, _ := arguments["library-id"].(string)
+	if s.isRepositoryDisabled(libraryID) {
+		s.sendToolError(w, id, fmt.Sprintf("Repository %s is disabled", libraryID))
+		return
+	}
+
+	marker, _ := arguments["marker"].(string)
+	prefix, _ := arguments["path"].(string)
+	prefix = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(prefix)), "/")
+	limit, err := parseIntArg(arguments, "limit", 100, 1, 10000)
+	if err != nil {
+		s.sendJSONRPCError(w, id, -32602, "Invalid params", err.Error())
+		return
+	}
+
+	slog.Info("Listing TODOs", "repository", libraryID, "marker", marker, "path", prefix, "limit", limit)
+
+	repo, err := s.lookupRepository(libraryID)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+
+	value, exists := repo.Metadata[types.TodosMetadataKey]
+	if !exists {
+		s.sendToolError(w, id, fmt.Sprintf("No work items recorded for %s: re-index the repository to collect them", libraryID))
+		return
+	}
+	todos, err := types.DecodeTodos(value)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+
+	var matched []types.TodoItem
+	for _, todo := range todos {
+		if marker != "" && todo.Marker != marker {
+			continue
+		}
+		if prefix != "" && todo.File != prefix && !strings.HasPrefix(todo.File, prefix+"/") {
+			continue
+		}
+		matched = append(matched, todo)
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("# Work items: %s\n\n", libraryID))
+	if len(matched) == 0 {
+		text.WriteString("No matching work items.\n")
+	}
+	for index, todo := range matched {
+		if index == limit {
+			text.WriteString(fmt.Sprintf("\n... %d more, narrow with marker or path, or raise limit\n", len(matched)-limit))
+			break
+		}
+		text.WriteString(fmt.Sprintf("- %s:%d [%s] %s\n", todo.File, todo.Line, todo.Marker, todo.Text))
+	}
+	text.WriteString(fmt.Sprintf("\nTotal: %d\n", len(matched)))
+
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: text.String(),
+			},
+		},
+		IsError: false,
+	}
+
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleGetWorkingDiff handles the get-working-diff tool. Only local repositories have a
+// working tree to compare with; the cached index is left untouched.
+func (s *Server) handleGetWorkingDiff(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	libraryID, _ := arguments["library-id"].(string)
+	if s.isRepositoryDisabled(libraryID) {
+		s.sendToolError(w, id, fmt.Sprintf("Repository %s is disabled", libraryID))
+		return
+	}
+
+	includeContent, _ := arguments["include-content"].(bool)
+	tokens, err := parseIntArg(arguments, "tokens", 10000, 1, maxTokensArg)
+	if err != nil {
+		s.sendJSONRPCError(w, id, -32602, "Invalid params", err.Error())
+		return
+	}
+
+	slog.Info("Getting working diff", "repository", libraryID, "includeContent", includeContent, "tokens", tokens)
+
+	repoConfig, exists := s.repositoryConfig(libraryID)
+	if !exists || repoConfig.Type != types.RepositoryTypeLocal {
+		s.sendToolError(w, id, fmt.Sprintf("Working diff is only available for local repositories: %s", libraryID))
+		return
+	}
+	if s.fileLister == nil {
+		s.sendToolError(w, id, "Working diff is not available: no file lister configured")
+		return
+	}
+
+	repo, err := s.lookupRepository(libraryID)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+
+	files, err := s.fileLister.ListFiles(repo.Path, repoConfig.Indexing)
+	if err != nil {
+		s.sendToolError(w, id, fmt.Sprintf("Failed to list working tree files: %v", err))
+		return
+	}
+	diff, err := computeWorkingDiff(repo, files)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: formatWorkingDiff(libraryID, repo.Path, diff, includeContent, tokens),
+			},
+		},
+		IsError: false,
+	}
+
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleGetImplementations handles the get-implementations tool.
+func (s *Server) handleGetImplementations(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	libraryID, _ := arguments["library-id"].(string)
+	if s.isRepositoryDisabled(libraryID) {
+		s.sendToolError(w, id, fmt.Sprintf("Repository %s is disabled", libraryID))
+		return
+	}
+	name, _ := arguments["name"].(string)
+
+	slog.Info("Getting implementations", "repository", libraryID, "name", name)
+
+	repo, err := s.lookupRepository(libraryID)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+
+	value, exists := repo.Metadata[types.ImplementationsMetadataKey]
+	if !exists {
+		s.sendToolError(w, id, fmt.Sprintf("No implementation index for %s: only repositories indexed by the Go parser have one, re-index to build it", libraryID))
+		return
+	}
+	index, err := types.DecodeImplementations(value)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+
+	text, found := formatImplementations(libraryID, name, index)
+	if !found {
+		s.sendToolError(w, id, fmt.Sprintf("No interface or implementing type named %s in %s", name, libraryID))
+		return
+	}
+
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+		IsError: false,
+	}
+
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleGetReferences handles the get-references tool.
+func (s *Server) handleGetReferences(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	libraryID, _ := arguments["library-id"].(string)
+	if s.isRepositoryDisabled(libraryID) {
+		s.sendToolError(w, id, fmt.Sprintf("Repository %s is disabled", libraryID))
+		return
+	}
+	symbol, _ := arguments["symbol"].(string)
+
+	slog.Info("Getting references", "repository", libraryID, "symbol", symbol)
+
+	repo, err := s.lookupRepository(libraryID)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+
+	value, exists := repo.Metadata[types.ReferencesMetadataKey]
+	if !exists {
+		s.sendToolError(w, id, fmt.Sprintf("No reference index for %s: only repositories indexed by the Go parser have one, re-index to build it", libraryID))
+		return
+	}
+	index, err := types.DecodeReferences(value)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+
+	text, found := formatReferences(libraryID, symbol, index)
+	if !found {
+		s.sendToolError(w, id, fmt.Sprintf("No function or method named %s in %s", symbol, libraryID))
+		return
+	}
+
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+		IsError: false,
+	}
+
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleGetSymbol handles the get-symbol tool.
+func (s *Server) handleGetSymbol(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	libraryID, _ := arguments["library-id"].(string)
+	if s.isRepositoryDisabled(libraryID) {
+		s.sendToolError(w, id, fmt.Sprintf("Repository %s is disabled", libraryID))
+		return
+	}
+	symbol, _ := arguments["symbol"].(string)
+
+	slog.Info("Getting symbol", "repository", libraryID, "symbol", symbol)
+
+	repo, err := s.lookupRepository(libraryID)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+
+	value, exists := repo.Metadata[types.SymbolsMetadataKey]
+	if !exists {
+		s.sendToolError(w, id, fmt.Sprintf("No symbol index for %s: only repositories indexed by the Go parser have one, re-index to build it", libraryID))
+		return
+	}
+	index, err := types.DecodeSymbols(value)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
+	}
+
+	text, found := formatSymbols(libraryID, symbol, index)
+	if !found {
+		s.sendToolError(w, id, fmt.Sprintf("No symbol named %s in %s", symbol, libraryID))
+		return
+	}
 
-	log.Printf("Handling refresh: repositoryID=%s, force=%v", repositoryID, force)
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+		IsError: false,
+	}
 
-	var refreshedCount int
-	var errors []string
+	s.sendJSONRPCResult(w, id, result)
+}
 
-	if s.cache == nil {
-		s.sendToolError(w, id, "Cache not available")
+// ************************************************************************************************
+// handleSearchCode handles the search-code tool.
+func (s *Server) handleSearchCode(ctx context.Context, w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	queryText, _ := arguments["query"].(string)
+	if strings.TrimSpace(queryText) == "" {
+		s.sendToolError(w, id, "query parameter is required and must be a non-empty string")
+		return
+	}
+	libraryID, _ := arguments["library-id"].(string)
+	language, _ := arguments["language"].(string)
+	filePattern, _ := arguments["filePattern"].(string)
+	exportedOnly, _ := arguments["exportedOnly"].(bool)
+	maxResults, err := parseIntArg(arguments, "maxResults", 20, 1, 1000)
+	if err != nil {
+		s.sendJSONRPCError(w, id, -32602, "Invalid params", err.Error())
 		return
 	}
 
-	if repositoryID != "" {
-		// Refresh specific repository
-		err := s.cache.InvalidateRepository(repositoryID)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("Failed to refresh %s: %v", repositoryID, err))
-		} else {
-			refreshedCount = 1
-			log.Printf("Refreshed repository cache: %s", repositoryID)
+	slog.InfoContext(ctx, "Searching code", "query", queryText, "language", language, "filePattern", filePattern, "maxResults", maxResults)
+
+	if s.searchEngine == nil {
+		s.sendToolError(w, id, "Search is not available on this server")
+		return
+	}
+	if libraryID != "" {
+		if s.isRepositoryDisabled(libraryID) {
+			s.sendToolError(w, id, fmt.Sprintf("Repository %s is disabled", libraryID))
+			return
 		}
-	} else {
-		// Refresh all repositories
-		err := s.cache.InvalidateAll()
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("Failed to refresh all repositories: %v", err))
-		} else {
-			// Count how many repositories were in cache
-			repos, err := s.cache.ListRepositories()
-			if err == nil {
-				refreshedCount = len(repos)
-			}
-			log.Printf("Refreshed all repository caches")
+		if _, err := s.lookupRepository(libraryID); err != nil {
+			s.sendToolError(w, id, err.Error())
+			return
 		}
 	}
 
-	// Build response message
-	var message strings.Builder
-	if refreshedCount > 0 {
-		if repositoryID != "" {
-			message.WriteString(fmt.Sprintf("Successfully refreshed repository: %s", repositoryID))
-		} else {
-			message.WriteString(fmt.Sprintf("Successfully refreshed %d repositories", refreshedCount))
-		}
+	results, err := s.searchEngine.Search(types.SearchQuery{
+		Query:        queryText,
+		RepositoryID: libraryID,
+		Language:     strings.ToLower(language),
+		FilePattern:  filePattern,
+		ExportedOnly: exportedOnly,
+	})
+	if err != nil {
+		s.sendToolError(w, id, fmt.Sprintf("Search failed: %v", err))
+		return
 	}
 
-	if len(errors) > 0 {
-		if message.Len() > 0 {
-			message.WriteString("\n\nErrors encountered:\n")
+	// Disabled repositories stay indexed until the server reloads, their results are dropped
+	// along with those of the repositories the client may not see
+	enabled := results[:0]
+	for _, result := range results {
+		if !s.isRepositoryDisabled(result.File.RepositoryID) && s.canAccessRepository(ctx, result.File.RepositoryID) {
+			enabled = append(enabled, result)
 		}
-		message.WriteString(strings.Join(errors, "\n"))
 	}
-
-	if refreshedCount == 0 && len(errors) == 0 {
-		message.WriteString("No repositories found to refresh")
+	results = enabled
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	if len(results) == 0 {
+		s.sendToolError(w, id, fmt.Sprintf("No matches found for: %s", queryText))
+		return
 	}
 
 	result := types.MCPToolCallResult{
 		Content: []types.MCPContent{
 			{
 				Type: "text",
-				Text: message.String(),
+				Text: formatSearchResults(queryText, results),
 			},
 		},
-		IsError: len(errors) > 0 && refreshedCount == 0,
+		IsError: false,
 	}
 
 	s.sendJSONRPCResult(w, id, result)
 }
 
 // ************************************************************************************************
-// handleGetReadme handles the get-readme tool for README extraction.
-func (s *Server) handleGetReadme(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
-	// Extract library ID
-	libraryID, ok := arguments["library-id"].(string)
-	if !ok || libraryID == "" {
-		s.sendToolError(w, id, "library-id parameter is required and must be a string")
+// handleGrepRepository handles the grep-repository tool.
+func (s *Server) handleGrepRepository(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	libraryID, _ := arguments["library-id"].(string)
+	if s.isRepositoryDisabled(libraryID) {
+		s.sendToolError(w, id, fmt.Sprintf("Repository %s is disabled", libraryID))
+		return
+	}
+	pattern, _ := arguments["pattern"].(string)
+	if pattern == "" {
+		s.sendToolError(w, id, "pattern parameter is required and must be a non-empty string")
+		return
+	}
+	contextLines, err := parseIntArg(arguments, "contextLines", 2, 0, 100)
+	if err != nil {
+		s.sendJSONRPCError(w, id, -32602, "Invalid params", err.Error())
 		return
 	}
+	maxMatches, err := parseIntArg(arguments, "maxMatches", 100, 1, 10000)
+	if err != nil {
+		s.sendJSONRPCError(w, id, -32602, "Invalid params", err.Error())
+		return
+	}
+	ignoreCase, _ := arguments["ignoreCase"].(bool)
+	filePattern, _ := arguments["filePattern"].(string)
+	language, _ := arguments["language"].(string)
 
-	// Extract optional format parameter
-	format, _ := arguments["format"].(string)
-	if format == "" {
-		format = "markdown"
+	slog.Info("Grepping repository", "repository", libraryID, "pattern", pattern, "ignoreCase", ignoreCase, "filePattern", filePattern, "language", language)
+
+	grepper, ok := s.searchEngine.(RepositoryGrepper)
+	if !ok {
+		s.sendToolError(w, id, "Regular expression search is not available")
+		return
+	}
+	repo, err := s.lookupRepository(libraryID)
+	if err != nil {
+		s.sendToolError(w, id, fmt.Sprintf("Repository not found: %s", libraryID))
+		return
 	}
 
-	log.Printf("Getting README: id=%s, format=%s", libraryID, format)
+	results, truncated, err := grepper.Grep(types.GrepQuery{
+		Pattern:      pattern,
+		IgnoreCase:   ignoreCase,
+		FilePattern:  filePattern,
+		Language:     strings.ToLower(language),
+		ContextLines: contextLines,
+		MaxMatches:   maxMatches,
+	}, repo)
+	if err != nil {
+		s.sendToolError(w, id, fmt.Sprintf("Search failed: %v", err))
+		return
+	}
+	if len(results) == 0 {
+		s.sendToolError(w, id, fmt.Sprintf("No line of %s matches: %s", libraryID, pattern))
+		return
+	}
 
-	// Get repository from cache
-	var repo *types.RepositoryIndex
-	var err error
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: formatGrepResults(libraryID, pattern, results, truncated),
+			},
+		},
+		IsError: false,
+	}
 
-	if s.cache != nil {
-		repo, err = s.cache.GetRepository(libraryID)
-		if err != nil {
-			// Try in-memory repositories
-			if repoMem, exists := s.repositories[libraryID]; exists {
-				repo = repoMem
-			} else {
-				s.sendToolError(w, id, fmt.Sprintf("Repository not found: %s", libraryID))
-				return
-			}
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleFindRepositoryByFile handles the find-repository-by-file tool.
+func (s *Server) handleFindRepositoryByFile(ctx context.Context, w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	filePath, _ := arguments["path"].(string)
+	if strings.TrimSpace(filePath) == "" {
+		s.sendToolError(w, id, "path parameter is required and must be a non-empty string")
+		return
+	}
+	limit, err := parseIntArg(arguments, "limit", 20, 1, 1000)
+	if err != nil {
+		s.sendJSONRPCError(w, id, -32602, "Invalid params", err.Error())
+		return
+	}
+
+	slog.InfoContext(ctx, "Finding repositories by file", "path", filePath, "limit", limit)
+
+	var matches []fileMatch
+	for _, match := range s.findFileMatches(filePath) {
+		if s.canAccessRepository(ctx, match.RepositoryID) {
+			matches = append(matches, match)
 		}
-	} else {
-		// Try in-memory repositories
-		if repoMem, exists := s.repositories[libraryID]; exists {
-			repo = repoMem
-		} else {
-			s.sendToolError(w, id, fmt.Sprintf("Repository not found: %s", libraryID))
-			return
+	}
+	if len(matches) == 0 {
+		s.sendToolError(w, id, fmt.Sprintf("No indexed repository contains a file matching: %s", filePath))
+		return
+	}
+
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: formatFileMatches(filePath, matches, limit),
+			},
+		},
+		IsError: false,
+	}
+
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleGetFile handles the get-file tool. The file content is returned as is, untruncated.
+func (s *Server) handleGetFile(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	libraryID, _ := arguments["library-id"].(string)
+	if s.isRepositoryDisabled(libraryID) {
+		s.sendToolError(w, id, fmt.Sprintf("Repository %s is disabled", libraryID))
+		return
+	}
+	filePath, _ := arguments["path"].(string)
+	if strings.TrimSpace(filePath) == "" {
+		s.sendToolError(w, id, "path parameter is required and must be a non-empty string")
+		return
+	}
+
+	slog.Info("Getting file", "repository", libraryID, "path", filePath)
+
+	repo, err := s.lookupRepository(libraryID)
+	if err != nil {
+		s.sendToolError(w, id, fmt.Sprintf("Repository not found: %s", libraryID))
+		return
+	}
+
+	file, exists, suggestions := findRepositoryFile(repo, filePath)
+	if !exists {
+		message := fmt.Sprintf("File not found in repository %s: %s", libraryID, filePath)
+		if len(suggestions) > 0 {
+			message += "\nSimilar files: " + strings.Join(suggestions, ", ")
 		}
+		s.sendToolError(w, id, message)
+		return
 	}
 
-	// Look for README files from all subfolders
-	readmeFiles := s.findAllReadmeFiles(repo)
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: file.Content,
+			},
+		},
+		IsError: false,
+	}
 
-	if len(readmeFiles) == 0 {
-		s.sendToolError(w, id, fmt.Sprintf("No README files found in repository: %s", libraryID))
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleIndexRepository handles the index-repository tool.
+func (s *Server) handleIndexRepository(ctx context.Context, w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	alias, _ := arguments["repository"].(string)
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		s.sendToolError(w, id, "repository parameter is required and must be a non-empty string")
 		return
 	}
 
-	// Use the first (highest priority) README file for single file response
-	// Priority order: root → shallow subfolders → deeper subfolders
-	readmeFile := &readmeFiles[0]
-	readmePath := readmeFile.Path
+	wait, _ := arguments["wait"].(bool)
 
-	// Format the content based on requested format
-	content := readmeFile.Content
-	if format == "text" && strings.HasSuffix(strings.ToLower(readmePath), ".md") {
-		// Simple markdown to text conversion - remove basic markdown syntax
-		content = strings.ReplaceAll(content, "**", "")
-		content = strings.ReplaceAll(content, "*", "")
-		content = strings.ReplaceAll(content, "`", "")
-		// Remove markdown headers
-		lines := strings.Split(content, "\n")
-		for i, line := range lines {
-			if strings.HasPrefix(line, "#") {
-				lines[i] = strings.TrimLeft(line, "# ")
+	slog.InfoContext(ctx, "Handling index-repository", "repository", alias, "wait", wait)
+
+	job, started, err := s.startIndexJob(alias)
+	if err != nil {
+		s.sendToolError(w, id, fmt.Sprintf("Cannot index %s: %v", alias, err))
+		return
+	}
+
+	if wait {
+		s.waitIndexJob(ctx, w, id, job, started)
+		return
+	}
+
+	message := fmt.Sprintf("Indexing of %s queued as job %s.", alias, job.ID)
+	if !started {
+		message = fmt.Sprintf("Indexing of %s is already %s as job %s.", alias, job.Status, job.ID)
+	}
+	message += fmt.Sprintf(" Follow it with get-index-job using jobId %s.", job.ID)
+
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: message,
+			},
+		},
+		IsError: false,
+	}
+
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// waitIndexJob answers an index-repository call once its job has ended, reporting the progress
+// of the job meanwhile. When the call is cancelled, the job is cancelled too if the call queued
+// it, since nobody else waits for it.
+func (s *Server) waitIndexJob(ctx context.Context, w http.ResponseWriter, id interface{}, job indexJob, queued bool) {
+	updates, stop := s.indexJobs.watch(job.ID)
+	defer stop()
+
+wait:
+	for {
+		select {
+		case update, open := <-updates:
+			if !open {
+				break wait
+			}
+			s.notifyProgress(w, update.Progress, update.Total, update.Message)
+		case <-ctx.Done():
+			if queued {
+				s.indexJobs.cancel(job.ID)
 			}
+			s.sendToolError(w, id, fmt.Sprintf("Stopped waiting for indexing job %s: %v", job.ID, ctx.Err()))
+			return
 		}
-		content = strings.Join(lines, "\n")
+	}
+	if ended, exists := s.indexJobs.get(job.ID); exists {
+		job = ended
 	}
 
-	// Build response with multiple README files if available
-	var response strings.Builder
+	if job.Status == indexJobFailed {
+		s.sendToolError(w, id, fmt.Sprintf("Indexing job %s for repository %s failed: %s", job.ID, job.Alias, job.Error))
+		return
+	}
 
-	if len(readmeFiles) == 1 {
-		// Single README file response
-		response.WriteString(fmt.Sprintf("# README from %s\n\n", libraryID))
-		response.WriteString(fmt.Sprintf("**File:** %s\n", readmePath))
-		response.WriteString(fmt.Sprintf("**Size:** %d bytes\n", readmeFile.Size))
-		response.WriteString(fmt.Sprintf("**Language:** %s\n", readmeFile.Language))
-		response.WriteString(fmt.Sprintf("**Format:** %s\n\n", format))
-		response.WriteString("---\n\n")
-		response.WriteString(content)
-	} else {
-		// Multiple README files response
-		response.WriteString(fmt.Sprintf("# README Files from %s\n\n", libraryID))
-		response.WriteString(fmt.Sprintf("Found %d README files in repository.\n\n", len(readmeFiles)))
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Indexing job %s for repository %s %s.\n\n%s", job.ID, job.Alias, job.Status, formatIndexJobs([]indexJob{job})),
+			},
+		},
+		IsError: false,
+	}
 
-		for i, file := range readmeFiles {
-			folderPath := filepath.Dir(file.Path)
-			if folderPath == "." {
-				folderPath = "(root)"
+	s.sendJSONRPCResult(w, id, result)
+}
+
+// ************************************************************************************************
+// handleGetIndexJob handles the get-index-job tool.
+func (s *Server) handleGetIndexJob(ctx context.Context, w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	jobID, _ := arguments["jobId"].(string)
+	jobID = strings.TrimSpace(jobID)
+
+	slog.InfoContext(ctx, "Handling get-index-job", "job", jobID)
+
+	var jobs []indexJob
+	if jobID != "" {
+		job, exists := s.indexJobs.get(jobID)
+		if !exists || !s.canAccessRepository(ctx, job.Alias) {
+			s.sendToolError(w, id, fmt.Sprintf("No indexing job %s, finished jobs are forgotten once %d newer jobs exist", jobID, maxIndexJobs))
+			return
+		}
+		jobs = []indexJob{job}
+	} else {
+		for _, job := range s.indexJobs.list() {
+			if s.canAccessRepository(ctx, job.Alias) {
+				jobs = append(jobs, job)
 			}
+		}
+	}
 
-			response.WriteString(fmt.Sprintf("## README %d: %s\n", i+1, folderPath))
-			response.WriteString(fmt.Sprintf("**File:** %s\n", file.Path))
-			response.WriteString(fmt.Sprintf("**Size:** %d bytes\n", file.Size))
-			response.WriteString(fmt.Sprintf("**Language:** %s\n\n", file.Language))
+	text := "No indexing job was started since the server started."
+	if len(jobs) > 0 {
+		text = formatIndexJobs(jobs)
+	}
 
-			// Format content for this README
-			fileContent := file.Content
-			if format == "text" && strings.HasSuffix(strings.ToLower(file.Path), ".md") {
-				// Simple markdown to text conversion
-				fileContent = strings.ReplaceAll(fileContent, "**", "")
-				fileContent = strings.ReplaceAll(fileContent, "*", "")
-				fileContent = strings.ReplaceAll(fileContent, "`", "")
-				// Remove markdown headers
-				lines := strings.Split(fileContent, "\n")
-				for j, line := range lines {
-					if strings.HasPrefix(line, "#") {
-						lines[j] = strings.TrimLeft(line, "# ")
-					}
-				}
-				fileContent = strings.Join(lines, "\n")
-			}
+	result := types.MCPToolCallResult{
+		Content: []types.MCPContent{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+		IsError: false,
+	}
 
-			response.WriteString("```\n")
-			response.WriteString(fileContent)
-			response.WriteString("\n```\n\n")
+	s.sendJSONRPCResult(w, id, result)
+}
 
-			if i < len(readmeFiles)-1 {
-				response.WriteString("---\n\n")
-			}
+// ************************************************************************************************
+// handleListLibraries handles the list-libraries tool.
+func (s *Server) handleListLibraries(ctx context.Context, w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	includeGoModules := true
+	if include, ok := arguments["includeGoModules"].(bool); ok {
+		includeGoModules = include
+	}
+
+	slog.InfoContext(ctx, "Listing libraries", "includeGoModules", includeGoModules)
+
+	var libraries []libraryInfo
+	for _, library := range s.listLibraries(includeGoModules) {
+		if s.canAccessRepository(ctx, library.ID) {
+			libraries = append(libraries, library)
 		}
 	}
+	if len(libraries) == 0 {
+		s.sendToolError(w, id, "No repository is indexed yet")
+		return
+	}
 
 	result := types.MCPToolCallResult{
 		Content: []types.MCPContent{
 			{
 				Type: "text",
-				Text: response.String(),
+				Text: formatLibraryList(libraries),
 			},
 		},
 		IsError: false,
@@ -728,43 +2344,47 @@ func (s *Server) handleGetReadme(w http.ResponseWriter, id interface{}, argument
 }
 
 // ************************************************************************************************
-// handleGetLibraryDocs handles the get-library-docs tool.
-func (s *Server) handleGetLibraryDocs(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
-	// Extract library ID
-	libraryID, ok := arguments["library-id"].(string)
-	if !ok || libraryID == "" {
-		s.sendToolError(w, id, "library-id parameter is required and must be a string")
+// handleGetAPISpec handles the get-api-spec tool.
+func (s *Server) handleGetAPISpec(w http.ResponseWriter, id interface{}, arguments map[string]interface{}) {
+	libraryID, _ := arguments["library-id"].(string)
+	if s.isRepositoryDisabled(libraryID) {
+		s.sendToolError(w, id, fmt.Sprintf("Repository %s is disabled", libraryID))
 		return
 	}
+	specPath, _ := arguments["path"].(string)
+	summarize, _ := arguments["summary"].(bool)
 
-	// Extract optional parameters
-	topic, _ := arguments["topic"].(string)
-	includeNonExported, _ := arguments["includeNonExported"].(bool)
+	slog.Info("Getting API spec", "repository", libraryID, "path", specPath, "summary", summarize)
 
-	// Handle tokens parameter (can be number or string)
-	tokens := 10000 // Default value
-	if tokensParam, exists := arguments["tokens"]; exists {
-		switch v := tokensParam.(type) {
-		case float64:
-			tokens = int(v)
-		case int:
-			tokens = v
-		case string:
-			if parsed, err := strconv.Atoi(v); err == nil {
-				tokens = parsed
-			}
-		}
+	repo, err := s.lookupRepository(libraryID)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
 	}
 
-	// Ensure minimum token count
-	if tokens < 1000 {
-		tokens = 1000
+	value, exists := repo.Metadata[types.APISpecsMetadataKey]
+	if !exists {
+		s.sendToolError(w, id, fmt.Sprintf("No API specification found in %s", libraryID))
+		return
+	}
+	specs, err := types.DecodeAPISpecs(value)
+	if err != nil {
+		s.sendToolError(w, id, err.Error())
+		return
 	}
 
-	log.Printf("Getting library docs: id=%s, topic=%s, tokens=%d, includeNonExported=%v", libraryID, topic, tokens, includeNonExported)
+	selected, found := selectAPISpec(specs, specPath)
+	if !found {
+		s.sendToolError(w, id, fmt.Sprintf("No API specification %s in %s", specPath, libraryID))
+		return
+	}
+	file, exists := repo.Files[selected]
+	if !exists {
+		s.sendToolError(w, id, fmt.Sprintf("API specification %s is missing from the index of %s: re-index the repository", selected, libraryID))
+		return
+	}
 
-	// Get repository documentation
-	docs, err := s.getRepositoryDocs(libraryID, topic, tokens, includeNonExported)
+	text, err := formatAPISpec(libraryID, file, specs, summarize)
 	if err != nil {
 		s.sendToolError(w, id, err.Error())
 		return
@@ -774,7 +2394,7 @@ func (s *Server) handleGetLibraryDocs(w http.ResponseWriter, id interface{}, arg
 		Content: []types.MCPContent{
 			{
 				Type: "text",
-				Text: docs,
+				Text: text,
 			},
 		},
 		IsError: false,
@@ -788,7 +2408,7 @@ func (s *Server) handleGetLibraryDocs(w http.ResponseWriter, id interface{}, arg
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	status := map[string]interface{}{
 		"status":           "healthy",
-		"repositories":     len(s.repositories),
+		"repositories":     s.memoryRepositoryCount(),
 		"cache_available":  s.cache != nil,
 		"search_available": s.searchEngine != nil,
 		"protocol":         "MCP JSON-RPC 2.0",
@@ -807,9 +2427,31 @@ func (s *Server) sendJSONRPCResult(w http.ResponseWriter, id interface{}, result
 		Result:  result,
 	}
 
+	data, err := encodeResponse(response)
+	if err != nil {
+		slog.Error("Failed to encode JSON-RPC response", "error", err)
+		return
+	}
+
+	// Token budgets are estimates, enforce the hard size limit on the assembled tool response
+	if limit := s.maxResponseSize(); limit > 0 && len(data) > limit {
+		if toolResult, ok := result.(types.MCPToolCallResult); ok {
+			limited, _, err := limitToolResult(id, toolResult, limit)
+			if err != nil {
+				slog.Error("Failed to limit JSON-RPC response", "error", err)
+				limited, _ = encodeResponse(types.JSONRPCResponse{JsonRPC: "2.0", ID: id, Result: types.MCPToolCallResult{
+					Content: []types.MCPContent{{Type: "text", Text: err.Error()}},
+					IsError: true,
+				}})
+			}
+			slog.Info("Truncated tool response", "bytes", len(data), "truncatedBytes", len(limited), "maxResponseSize", limit)
+			data = limited
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding JSON-RPC response: %v", err)
+	if _, err := w.Write(data); err != nil {
+		slog.Warn("Failed to write JSON-RPC response", "error", err)
 	}
 }
 
@@ -829,7 +2471,7 @@ func (s *Server) sendJSONRPCError(w http.ResponseWriter, id interface{}, code in
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusBadRequest)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding JSON-RPC error response: %v", err)
+		slog.Error("Failed to encode JSON-RPC error response", "error", err)
 	}
 }
 
@@ -879,9 +2521,11 @@ func (s *Server) findRepositoryMatches(libraryName string) []string {
 		repoIDs, err := s.cache.ListRepositories()
 		if err == nil {
 			for _, repoID := range repoIDs {
-				// Simple string matching (case-insensitive)
-				if strings.Contains(strings.ToLower(repoID), strings.ToLower(libraryName)) ||
-					strings.Contains(strings.ToLower(libraryName), strings.ToLower(repoID)) {
+				if s.isRepositoryDisabled(repoID) {
+					continue
+				}
+				// Simple string matching (Unicode case-insensitive)
+				if s.libraryNameMatches(repoID, libraryName) {
 					matches = append(matches, repoID)
 				}
 			}
@@ -889,9 +2533,11 @@ func (s *Server) findRepositoryMatches(libraryName string) []string {
 	}
 
 	// Also check in-memory repositories
-	for repoID := range s.repositories {
-		if strings.Contains(strings.ToLower(repoID), strings.ToLower(libraryName)) ||
-			strings.Contains(strings.ToLower(libraryName), strings.ToLower(repoID)) {
+	for _, repoID := range s.memoryRepositoryIDs() {
+		if s.isRepositoryDisabled(repoID) {
+			continue
+		}
+		if s.libraryNameMatches(repoID, libraryName) {
 			// Avoid duplicates
 			found := false
 			for _, match := range matches {
@@ -909,6 +2555,36 @@ func (s *Server) findRepositoryMatches(libraryName string) []string {
 	return matches
 }
 
+// ************************************************************************************************
+// libraryNameMatches reports whether a repository or workspace name and a requested library
+// name contain one another, ignoring case with Unicode case folding and, when
+// server.resolveIgnoreAccents is set, accents.
+func (s *Server) libraryNameMatches(name, libraryName string) bool {
+	return types.NamesOverlap(name, libraryName, s.config.Server.ResolveIgnoreAccents)
+}
+
+// ************************************************************************************************
+// removeFromSearchIndex drops a repository whose cached index was invalidated from the search
+// index, until it is indexed again.
+func (s *Server) removeFromSearchIndex(repositoryID string) {
+	if updater, ok := s.searchEngine.(SearchIndexUpdater); ok {
+		updater.RemoveRepository(repositoryID)
+	}
+}
+
+// ************************************************************************************************
+// SetFileLister sets the working tree listing used by the get-working-diff tool.
+func (s *Server) SetFileLister(fileLister FileLister) {
+	s.fileLister = fileLister
+}
+
+// ************************************************************************************************
+// SetRepositoryIndexer sets the indexing of configured repositories used by the
+// index-repository tool.
+func (s *Server) SetRepositoryIndexer(repositoryIndexer RepositoryIndexer) {
+	s.repositoryIndexer = repositoryIndexer
+}
+
 // ************************************************************************************************
 // SetVerbose sets the verbose logging mode for the server.
 func (s *Server) SetVerbose(verbose bool) {
@@ -921,15 +2597,22 @@ func (s *Server) SetVerbose(verbose bool) {
 }
 
 // getRepositoryDocs retrieves documentation for a repository.
-func (s *Server) getRepositoryDocs(libraryID, topic string, tokens int, includeNonExported bool) (string, error) {
+func (s *Server) getRepositoryDocs(ctx context.Context, libraryID, topic string, tokens int, includeNonExported bool) (string, error) {
 	// Check if this is a Go module repository
-	if strings.HasPrefix(libraryID, "gomod:") {
-		return s.getGoModuleDocs(libraryID, topic, tokens, includeNonExported)
+	if types.IsGoModuleRepositoryID(libraryID) {
+		return s.getGoModuleDocs(ctx, libraryID, topic, tokens, includeNonExported)
+	}
+
+	// Workspaces merge the documentation of their member repositories
+	if types.IsWorkspaceRepositoryID(libraryID) {
+		return s.aggregateWorkspace(libraryID, tokens, func(repositoryID string, budget int) (string, error) {
+			return s.getRepositoryDocs(ctx, repositoryID, topic, budget, includeNonExported)
+		})
 	}
 
 	// Try to get from cache first
 	if s.cache != nil {
-		repo, err := s.cache.GetRepository(libraryID)
+		repo, err := s.getCachedRepository(ctx, libraryID)
 		if err == nil {
 			// Verbose logging for cache operations
 			if s.verbose {
@@ -940,7 +2623,7 @@ func (s *Server) getRepositoryDocs(libraryID, topic string, tokens int, includeN
 				}); ok {
 					if rawData, rawErr := cacheImpl.GetRawValue("repo:" + libraryID); rawErr == nil {
 						preview := cacheImpl.FormatValuePreview(rawData)
-						log.Printf("[CACHE] Retrieved key: repo:%s -> %s", libraryID, preview)
+						slog.InfoContext(ctx, "Retrieved repository from cache", "key", "repo:"+libraryID, "value", preview)
 					}
 				}
 			}
@@ -949,9 +2632,9 @@ func (s *Server) getRepositoryDocs(libraryID, topic string, tokens int, includeN
 	}
 
 	// Try in-memory repositories
-	if repo, exists := s.repositories[libraryID]; exists {
+	if repo, exists := s.getMemoryRepository(libraryID); exists {
 		if s.verbose {
-			log.Printf("[MEMORY] Retrieved repository: %s", libraryID)
+			slog.InfoContext(ctx, "Retrieved repository from memory", "repository", libraryID)
 		}
 		return s.extractDocumentation(repo, topic, tokens, includeNonExported), nil
 	}
@@ -959,10 +2642,54 @@ func (s *Server) getRepositoryDocs(libraryID, topic string, tokens int, includeN
 	return "", fmt.Errorf("repository not found: %s", libraryID)
 }
 
+// ************************************************************************************************
+// getAPISummary returns the compact public API summary recorded by the Go parser at
+// indexing time, truncated to the token budget.
+//
+// Returns:
+//   - string: The API summary.
+//   - error: An error if the repository is unknown or has no API summary.
+func (s *Server) getAPISummary(libraryID string, tokens int) (string, error) {
+	if types.IsWorkspaceRepositoryID(libraryID) {
+		return s.aggregateWorkspace(libraryID, tokens, s.getAPISummary)
+	}
+
+	var repo *types.RepositoryIndex
+	if types.IsGoModuleRepositoryID(libraryID) {
+		goRepo, err := s.getGoModuleRepository(context.Background(), libraryID, nil)
+		if err != nil {
+			return "", err
+		}
+		repo = goRepo
+	} else if s.cache != nil {
+		if cachedRepo, err := s.cache.GetRepository(libraryID); err == nil {
+			repo = cachedRepo
+		}
+	}
+	if repo == nil {
+		memRepo, exists := s.getMemoryRepository(libraryID)
+		if !exists {
+			return "", fmt.Errorf("repository not found: %s", libraryID)
+		}
+		repo = memRepo
+	}
+
+	summary, ok := repo.Metadata["api_summary"].(string)
+	if !ok || summary == "" {
+		return "", fmt.Errorf("API summary not available for %s: only Go repositories indexed by the native Go parser provide one", libraryID)
+	}
+	summary = s.stalenessNote(repo.LastUpdated) + summary
+
+	if s.tokenizer.Count(summary) > tokens {
+		summary = s.tokenizer.Truncate(summary, tokens-100) + "\n\n[Content truncated...]"
+	}
+	return summary, nil
+}
+
 // ************************************************************************************************
 // extractDocumentation extracts and formats documentation from a repository.
 func (s *Server) extractDocumentation(repo *types.RepositoryIndex, topic string, tokens int, includeNonExported bool) string {
-	log.Printf("Starting extractDocumentation: repo=%s, topic='%s', tokens=%d, includeNonExported=%v", repo.Name, topic, tokens, includeNonExported)
+	slog.Debug("Extracting documentation", "repository", repo.ID, "topic", topic, "tokens", tokens, "includeNonExported", includeNonExported)
 
 	// Note: includeNonExported only affects the initial XML generation by the Go parser,
 	// not the filtering at this extraction stage. The XML content already reflects
@@ -970,6 +2697,9 @@ func (s *Server) extractDocumentation(repo *types.RepositoryIndex, topic string,
 
 	var docs strings.Builder
 
+	// Warn about old indexes before anything else, the note counts against the budget
+	docs.WriteString(s.stalenessNote(repo.LastUpdated))
+
 	// Add repository header
 	docs.WriteString(fmt.Sprintf("# Repository: %s\n\n", repo.Name))
 	docs.WriteString(fmt.Sprintf("**Path:** %s\n", repo.Path))
@@ -979,9 +2709,10 @@ func (s *Server) extractDocumentation(repo *types.RepositoryIndex, topic string,
 	}
 	docs.WriteString("\n")
 
-	// Collect and prioritize files
+	// Collect and prioritize files, minified files only get the leftover budget
 	var priorityFiles []types.IndexedFile
 	var otherFiles []types.IndexedFile
+	var minifiedFiles []types.IndexedFile
 
 	for _, file := range repo.Files {
 		// Skip if topic is specified and file doesn't contain it
@@ -989,6 +2720,37 @@ func (s *Server) extractDocumentation(repo *types.RepositoryIndex, topic string,
 			continue
 		}
 
+		// Serve API specifications as their endpoints and schemas, more precise than the raw file
+		if file.Metadata["file_type"] == types.APISpecFileType {
+			if summary, err := types.SummarizeAPISpec(file.Content); err == nil {
+				file.Content = formatAPISpecSummary(summary)
+			}
+		}
+
+		// Serve only the sections of Markdown files about the topic
+		narrowed := false
+		if value, exists := file.Metadata[types.MarkdownSectionsMetadataKey]; exists && topic != "" {
+			if sections, err := types.ParseMarkdownSections(value); err == nil {
+				if content, found := types.MarkdownTopicContent(file.Content, sections, topic); found {
+					file.Content, narrowed = content, true
+				}
+			}
+		}
+
+		// Serve the chunks of large files about the topic, most relevant first
+		if value, exists := file.Metadata[types.ChunksMetadataKey]; exists && topic != "" && !narrowed {
+			if chunks, err := types.ParseChunks(value); err == nil {
+				if content, found := types.ChunkTopicContent(file.Content, chunks, topic); found {
+					file.Content = content
+				}
+			}
+		}
+
+		if file.Metadata["minified"] == "true" {
+			minifiedFiles = append(minifiedFiles, file)
+			continue
+		}
+
 		// Prioritize documentation files
 		fileName := strings.ToLower(file.Path)
 		if strings.Contains(fileName, "readme") ||
@@ -1002,96 +2764,101 @@ func (s *Server) extractDocumentation(repo *types.RepositoryIndex, topic string,
 		}
 	}
 
-	log.Printf("File categorization: priority=%d, other=%d, total=%d", len(priorityFiles), len(otherFiles), len(repo.Files))
+	slog.Debug("Categorized files", "priority", len(priorityFiles), "other", len(otherFiles), "minified", len(minifiedFiles), "total", len(repo.Files))
+	otherFiles = append(otherFiles, minifiedFiles...)
 
 	// Add priority files first
-	currentTokens := len(docs.String())
-	log.Printf("Initial token count: %d", currentTokens)
+	currentTokens := s.tokenizer.Count(docs.String())
+	slog.Log(context.Background(), logging.SlogLevelTrace, "Initial token count", "tokens", currentTokens)
 
 	for i, file := range priorityFiles {
-		log.Printf("Processing priority file %d/%d: %s (content length: %d)", i+1, len(priorityFiles), file.Path, len(file.Content))
+		slog.Log(context.Background(), logging.SlogLevelTrace, "Processing priority file", "index", i+1, "count", len(priorityFiles), "path", file.Path, "length", len(file.Content))
 
 		if currentTokens >= tokens {
-			log.Printf("Token limit reached, skipping remaining priority files")
+			slog.Debug("Token limit reached, skipping remaining priority files")
 			break
 		}
 
-		docs.WriteString(fmt.Sprintf("\n## File: %s\n\n", file.Path))
+		header := fmt.Sprintf("\n## File: %s\n\n", file.Path)
+		docs.WriteString(header)
 
 		// Safe truncation with bounds checking
 		content := file.Content
-		contentLength := len(content)
+		contentLength := s.tokenizer.Count(content)
 		remainingTokens := tokens - currentTokens
 
-		log.Printf("Token calculation: current=%d, remaining=%d, content=%d", currentTokens, remainingTokens, contentLength)
+		slog.Log(context.Background(), logging.SlogLevelTrace, "Token calculation", "current", currentTokens, "remaining", remainingTokens, "content", contentLength)
 
 		if contentLength > remainingTokens {
 			// Calculate safe truncation point
-			truncateLength := remainingTokens - 100 // Reserve 100 chars for truncation message
+			truncateLength := remainingTokens - 100 // Reserve 100 tokens for truncation message
 			if truncateLength <= 0 {
-				log.Printf("No space left for content, skipping file: %s", file.Path)
+				slog.Debug("No space left for content, skipping file", "path", file.Path)
 				continue
 			}
 			if truncateLength > contentLength {
 				truncateLength = contentLength
 			}
 
-			log.Printf("Truncating content from %d to %d characters", contentLength, truncateLength)
-			content = content[:truncateLength] + "\n\n[Content truncated...]"
+			slog.Log(context.Background(), logging.SlogLevelTrace, "Truncating content", "length", contentLength, "truncatedLength", truncateLength)
+			content = s.tokenizer.Truncate(content, truncateLength) + "\n\n[Content truncated...]"
+			contentLength = s.tokenizer.Count(content)
 		}
 
 		docs.WriteString(content)
 		docs.WriteString("\n")
-		currentTokens = len(docs.String())
-		log.Printf("Updated token count after file %s: %d", file.Path, currentTokens)
+		currentTokens += s.tokenizer.Count(header) + contentLength + 1
+		slog.Log(context.Background(), logging.SlogLevelTrace, "Updated token count", "path", file.Path, "tokens", currentTokens)
 	}
 
 	// Add other files if we still have token budget
 	for i, file := range otherFiles {
-		log.Printf("Processing other file %d/%d: %s (content length: %d)", i+1, len(otherFiles), file.Path, len(file.Content))
+		slog.Log(context.Background(), logging.SlogLevelTrace, "Processing other file", "index", i+1, "count", len(otherFiles), "path", file.Path, "length", len(file.Content))
 
 		if currentTokens >= tokens {
-			log.Printf("Token limit reached, skipping remaining other files")
+			slog.Debug("Token limit reached, skipping remaining other files")
 			break
 		}
 
-		docs.WriteString(fmt.Sprintf("\n## File: %s\n\n", file.Path))
+		header := fmt.Sprintf("\n## File: %s\n\n", file.Path)
+		docs.WriteString(header)
 
 		// Safe truncation with bounds checking
 		content := file.Content
-		contentLength := len(content)
+		contentLength := s.tokenizer.Count(content)
 		remainingTokens := tokens - currentTokens
 
-		log.Printf("Token calculation: current=%d, remaining=%d, content=%d", currentTokens, remainingTokens, contentLength)
+		slog.Log(context.Background(), logging.SlogLevelTrace, "Token calculation", "current", currentTokens, "remaining", remainingTokens, "content", contentLength)
 
 		if contentLength > remainingTokens {
 			// Calculate safe truncation point
-			truncateLength := remainingTokens - 100 // Reserve 100 chars for truncation message
+			truncateLength := remainingTokens - 100 // Reserve 100 tokens for truncation message
 			if truncateLength <= 0 {
-				log.Printf("No space left for content, skipping file: %s", file.Path)
+				slog.Debug("No space left for content, skipping file", "path", file.Path)
 				continue
 			}
 			if truncateLength > contentLength {
 				truncateLength = contentLength
 			}
 
-			log.Printf("Truncating content from %d to %d characters", contentLength, truncateLength)
-			content = content[:truncateLength] + "\n\n[Content truncated...]"
+			slog.Log(context.Background(), logging.SlogLevelTrace, "Truncating content", "length", contentLength, "truncatedLength", truncateLength)
+			content = s.tokenizer.Truncate(content, truncateLength) + "\n\n[Content truncated...]"
+			contentLength = s.tokenizer.Count(content)
 		}
 
 		docs.WriteString(content)
 		docs.WriteString("\n")
-		currentTokens = len(docs.String())
-		log.Printf("Updated token count after file %s: %d", file.Path, currentTokens)
+		currentTokens += s.tokenizer.Count(header) + contentLength + 1
+		slog.Log(context.Background(), logging.SlogLevelTrace, "Updated token count", "path", file.Path, "tokens", currentTokens)
 	}
 
 	// Add summary if we truncated
-	finalLength := len(docs.String())
+	finalLength := currentTokens
 	if finalLength >= tokens {
 		docs.WriteString(fmt.Sprintf("\n---\n**Note:** Documentation truncated to %d tokens. Repository contains %d total files.\n", tokens, len(repo.Files)))
 	}
 
-	log.Printf("Documentation extraction completed: final length=%d, target=%d", finalLength, tokens)
+	slog.Debug("Documentation extraction completed", "length", finalLength, "target", tokens)
 	return docs.String()
 }
 
@@ -1102,30 +2869,197 @@ func (s *Server) UpdateRepository(repo *types.RepositoryIndex) error {
 		return fmt.Errorf("repository cannot be nil")
 	}
 
+	s.reposMu.Lock()
 	s.repositories[repo.ID] = repo
-	log.Printf("Updated repository in MCP server: %s", repo.ID)
+	s.storeMemoryRepositoryLocked(repo.ID, time.Now())
+	s.reposMu.Unlock()
+
+	if updater, ok := s.searchEngine.(SearchIndexUpdater); ok {
+		updater.IndexRepository(repo)
+	}
+
+	slog.Info("Updated repository in MCP server", "repository", repo.ID)
+	s.broadcastMessage("info", fmt.Sprintf("Repository %s updated", repo.ID))
 	return nil
 }
 
 // ************************************************************************************************
-// Stop gracefully stops the MCP server.
-func (s *Server) Stop() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// ReloadRepositories re-reads the cache's repository list into the server's in-memory
+// map, so repositories indexed by a separate process become resolvable without a restart.
+// Repositories that are no longer cached are dropped.
+//
+// Returns:
+//   - added: IDs of repositories that were not previously loaded.
+//   - removed: IDs of repositories that are no longer in the cache.
+//   - error: An error if the cache cannot be read.
+//
+// Example usage:
+//
+//	added, removed, err := server.ReloadRepositories()
+//	if err != nil {
+//		slog.Error("Reload failed", "error", err)
+//	}
+func (s *Server) ReloadRepositories() (added []string, removed []string, err error) {
+	if s.cache == nil {
+		return nil, nil, fmt.Errorf("%w: cache not available", types.ErrNotInitialized)
+	}
+
+	repoIDs, err := s.cache.ListRepositories()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list cached repositories\n>    %w", err)
+	}
+
+	reloaded := make(map[string]*types.RepositoryIndex, len(repoIDs))
+	for _, repoID := range repoIDs {
+		repo, err := s.cache.GetRepository(repoID)
+		if err != nil {
+			slog.Warn("Failed to reload repository", "repository", repoID, "error", err)
+			continue
+		}
+		reloaded[repoID] = repo
+	}
 
-	if s.httpServer != nil {
-		if err := s.httpServer.Shutdown(ctx); err != nil {
-			log.Printf("HTTP server shutdown error: %v", err)
+	var changed []*types.RepositoryIndex
+	s.reposMu.Lock()
+	for repoID, repo := range reloaded {
+		previous, exists := s.repositories[repoID]
+		if !exists {
+			added = append(added, repoID)
+		}
+		if !exists || !previous.LastUpdated.Equal(repo.LastUpdated) {
+			changed = append(changed, repo)
+		}
+	}
+	for repoID := range s.repositories {
+		if _, exists := reloaded[repoID]; !exists {
+			removed = append(removed, repoID)
 		}
 	}
+	s.repositories = reloaded
+	now := time.Now()
+	s.repoAccess = make(map[string]time.Time, len(reloaded))
+	for repoID := range reloaded {
+		s.repoAccess[repoID] = now
+	}
+	s.evicted = make(map[string]bool)
+	s.evictRepositoriesLocked(now)
+	s.reposMu.Unlock()
 
-	if s.httpsServer != nil {
-		if err := s.httpsServer.Shutdown(ctx); err != nil {
-			log.Printf("HTTPS server shutdown error: %v", err)
+	// Only the repositories that changed are re-indexed for search
+	if updater, ok := s.searchEngine.(SearchIndexUpdater); ok {
+		for _, repo := range changed {
+			updater.IndexRepository(repo)
+		}
+		for _, repoID := range removed {
+			updater.RemoveRepository(repoID)
 		}
 	}
 
-	log.Printf("MCP server stopped")
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	slog.Info("Reloaded repositories from cache", "count", len(reloaded), "added", added, "removed", removed)
+	if len(added) > 0 || len(removed) > 0 || len(changed) > 0 {
+		s.broadcastMessage("info", fmt.Sprintf("Repositories reloaded from cache: %d changed, added %v, removed %v", len(changed), added, removed))
+	}
+	return added, removed, nil
+}
+
+// ************************************************************************************************
+// In-memory repository accessors, safe for concurrent use by request handlers.
+
+// getMemoryRepository returns an in-memory repository by ID, reloading it from the cache if it
+// was evicted.
+func (s *Server) getMemoryRepository(repositoryID string) (*types.RepositoryIndex, bool) {
+	s.reposMu.Lock()
+	now := time.Now()
+	s.evictRepositoriesLocked(now)
+	repo, exists := s.repositories[repositoryID]
+	if exists {
+		s.repoAccess[repositoryID] = now
+	}
+	s.reposMu.Unlock()
+
+	if !exists {
+		repo = s.reloadEvictedRepository(repositoryID)
+		exists = repo != nil
+	}
+	return repo, exists
+}
+
+// memoryRepositoryIDs returns the IDs of all in-memory repositories, evicted ones included.
+func (s *Server) memoryRepositoryIDs() []string {
+	s.reposMu.RLock()
+	defer s.reposMu.RUnlock()
+	ids := make([]string, 0, len(s.repositories)+len(s.evicted))
+	for repoID := range s.repositories {
+		ids = append(ids, repoID)
+	}
+	for repoID := range s.evicted {
+		ids = append(ids, repoID)
+	}
+	return ids
+}
+
+// removeMemoryRepository drops a repository from memory for good, unlike eviction.
+func (s *Server) removeMemoryRepository(repositoryID string) {
+	s.reposMu.Lock()
+	defer s.reposMu.Unlock()
+	delete(s.repositories, repositoryID)
+	delete(s.repoAccess, repositoryID)
+	delete(s.evicted, repositoryID)
+}
+
+// memoryRepositoryCount returns the number of in-memory repositories.
+func (s *Server) memoryRepositoryCount() int {
+	s.reposMu.RLock()
+	defer s.reposMu.RUnlock()
+	return len(s.repositories)
+}
+
+// ************************************************************************************************
+// Stop gracefully stops the MCP server: new requests are refused, the requests being handled
+// are given server.shutdownTimeout to finish, then the event streams and WebSocket sessions end
+// and the HTTP and HTTPS servers shut down. Start returns once the server is stopped; later
+// calls wait for the first one to finish.
+//
+// Returns:
+//   - error: Always nil, shutdown errors are logged.
+//
+// Example usage:
+//
+//	<-signals
+//	server.Stop()
+func (s *Server) Stop() error {
+	s.stopOnce.Do(func() {
+		defer close(s.stopped)
+		ctx, cancel := context.WithTimeout(context.Background(), parseTimeout(s.config.Server.ShutdownTimeout, 30*time.Second))
+		defer cancel()
+
+		// Stop listening while the requests are drained; the servers are shut down once their
+		// event streams end
+		s.stopAccepting()
+		s.stopWatcher()
+		var servers sync.WaitGroup
+		for _, httpServer := range append(append([]*http.Server(nil), s.httpServers...), s.httpsServer) {
+			if httpServer == nil {
+				continue
+			}
+			servers.Add(1)
+			go func(server *http.Server) {
+				defer servers.Done()
+				shutdownHTTPServer(ctx, server)
+			}(httpServer)
+		}
+
+		if s.waitRequests(ctx) {
+			slog.Info("Drained the requests being handled")
+		}
+		close(s.done)
+		servers.Wait()
+		slog.Info("MCP server stopped")
+	})
+	<-s.stopped
 	return nil
 }
 
@@ -1196,6 +3130,75 @@ func (s *Server) findAllReadmeFiles(repo *types.RepositoryIndex) []types.Indexed
 	return readmeFiles
 }
 
+// ************************************************************************************************
+// lookupRepository returns an indexed repository from the cache, falling back to the
+// repositories held in memory.
+//
+// Returns:
+//   - *types.RepositoryIndex: The repository index.
+//   - error: An error if the repository is not indexed.
+func (s *Server) lookupRepository(libraryID string) (*types.RepositoryIndex, error) {
+	if s.cache != nil {
+		if repo, err := s.cache.GetRepository(libraryID); err == nil {
+			return repo, nil
+		}
+	}
+	if repo, exists := s.getMemoryRepository(libraryID); exists {
+		return repo, nil
+	}
+	return nil, fmt.Errorf("repository not found: %s", libraryID)
+}
+
+// ************************************************************************************************
+// repositoryAlias returns the configuration alias a repository ID comes from: the alias itself,
+// or for glob expansions ("<alias>-<name>") the longest matching alias.
+//
+// Returns:
+//   - string: The alias.
+//   - bool: False if no configured alias matches.
+func (s *Server) repositoryAlias(repositoryID string) (string, bool) {
+	if _, exists := s.config.Repositories[repositoryID]; exists {
+		return repositoryID, true
+	}
+	var best string
+	for alias := range s.config.Repositories {
+		if strings.HasPrefix(repositoryID, alias+"-") && len(alias) > len(best) {
+			best = alias
+		}
+	}
+	return best, best != ""
+}
+
+// ************************************************************************************************
+// repositoryConfig returns the configuration entry a repository ID comes from.
+//
+// Returns:
+//   - types.RepositoryConfig: The configuration entry.
+//   - bool: False if no entry matches.
+func (s *Server) repositoryConfig(repositoryID string) (types.RepositoryConfig, bool) {
+	alias, exists := s.repositoryAlias(repositoryID)
+	if !exists {
+		return types.RepositoryConfig{}, false
+	}
+	return s.config.Repositories[alias], true
+}
+
+// ************************************************************************************************
+// isRepositoryDisabled reports whether a repository ID belongs to a configuration entry marked
+// disabled, either directly or as a glob expansion ("<alias>-<name>") of a disabled entry.
+// Disabled repositories may still be cached from earlier runs but are never served.
+func (s *Server) isRepositoryDisabled(repositoryID string) bool {
+	if repoConfig, exists := s.config.Repositories[repositoryID]; exists {
+		return repoConfig.Disabled
+	}
+	for alias, repoConfig := range s.config.Repositories {
+		if repoConfig.Disabled && strings.HasPrefix(repositoryID, alias+"-") {
+			return true
+		}
+	}
+	return false
+}
+
 // ************************************************************************************************
 // Go module fallback helper methods
 
@@ -1204,74 +3207,113 @@ func (s *Server) isGoModuleEnabled() bool {
 	return s.config.GoModule.Enabled && s.goDocRetriever != nil
 }
 
-// tryGoModuleFallback attempts to retrieve Go module documentation and cache it.
-func (s *Server) tryGoModuleFallback(libraryName string) (string, error) {
+// tryGoModuleFallback attempts to retrieve Go module documentation and cache it, reporting the
+// retrieval to progress when not nil.
+func (s *Server) tryGoModuleFallback(ctx context.Context, libraryName string, progress types.ProgressFunc) (string, error) {
 	if !s.isGoModuleEnabled() {
 		return "", fmt.Errorf("Go module fallback is disabled")
 	}
 
-	log.Printf("Attempting Go module documentation retrieval for: %s", libraryName)
+	if err := s.allowExpensiveCall(ctx, "Go module retrieval of "+libraryName); err != nil {
+		return "", err
+	}
+	ctx, release, err := s.acquireWorker(ctx, "Go module retrieval of "+libraryName)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	slog.InfoContext(ctx, "Attempting Go module documentation retrieval", "module", libraryName)
 
 	// Set verbose mode if server is verbose
 	s.goDocRetriever.SetVerbose(s.verbose)
 
 	// Retrieve documentation
-	_, err := s.goDocRetriever.GetOrRetrieveDocumentation(libraryName)
-	if err != nil {
+	if _, err := s.goDocRetriever.GetOrRetrieveDocumentationContext(ctx, libraryName, progress); err != nil {
 		return "", fmt.Errorf("failed to retrieve Go module documentation: %w", err)
 	}
 
 	// Create synthetic repository ID
-	repoID := fmt.Sprintf("gomod:%s", libraryName)
+	repoID := types.GoModuleRepositoryID(libraryName)
 
-	log.Printf("Successfully retrieved Go module documentation for: %s (ID: %s)", libraryName, repoID)
+	slog.InfoContext(ctx, "Retrieved Go module documentation", "module", libraryName, "repository", repoID)
 	return repoID, nil
 }
 
+// getCachedRepository reads a repository from the cache, in the trace of the request when the
+// cache records its accesses.
+func (s *Server) getCachedRepository(ctx context.Context, repositoryID string) (*types.RepositoryIndex, error) {
+	if tracedCache, ok := s.cache.(ContextCacheInterface); ok {
+		return tracedCache.GetRepositoryContext(ctx, repositoryID)
+	}
+	return s.cache.GetRepository(repositoryID)
+}
+
 // getGoModuleDocs retrieves documentation for a Go module repository.
-func (s *Server) getGoModuleDocs(libraryID, topic string, tokens int, includeNonExported bool) (string, error) {
-	if !strings.HasPrefix(libraryID, "gomod:") {
-		return "", fmt.Errorf("invalid Go module repository ID: %s", libraryID)
+func (s *Server) getGoModuleDocs(ctx context.Context, libraryID, topic string, tokens int, includeNonExported bool) (string, error) {
+	repo, err := s.getGoModuleRepository(ctx, libraryID, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// Extract and return documentation
+	return s.extractDocumentation(repo, topic, tokens, includeNonExported), nil
+}
+
+// getGoModuleRepository returns the synthetic repository of a Go module, fetching it
+// only when it is neither in the cache nor in memory. The retrieval is reported to progress
+// when not nil.
+func (s *Server) getGoModuleRepository(ctx context.Context, libraryID string, progress types.ProgressFunc) (*types.RepositoryIndex, error) {
+	if !types.IsGoModuleRepositoryID(libraryID) {
+		return nil, fmt.Errorf("invalid Go module repository ID: %s", libraryID)
 	}
 
 	// Extract module path from repository ID
-	modulePath := strings.TrimPrefix(libraryID, "gomod:")
+	modulePath := strings.TrimPrefix(libraryID, types.GoModuleRepositoryPrefix)
 
-	// Try to get from cache first
+	// Any cached synthetic repository is served whatever topic or token budget the
+	// caller asks for; the network is only used when the module is not cached at all.
 	if s.cache != nil {
-		repo, err := s.cache.GetRepository(libraryID)
+		repo, err := s.getCachedRepository(ctx, libraryID)
 		if err == nil {
 			if s.verbose {
-				log.Printf("Found cached Go module documentation for: %s", modulePath)
+				slog.InfoContext(ctx, "Found cached Go module documentation", "module", modulePath)
 			}
-			return s.extractDocumentation(repo, topic, tokens, includeNonExported), nil
+			return repo, nil
+		}
+	}
+
+	// Try in-memory repositories
+	if repo, exists := s.getMemoryRepository(libraryID); exists {
+		if s.verbose {
+			slog.InfoContext(ctx, "Retrieved Go module repository from memory", "repository", libraryID)
 		}
+		return repo, nil
 	}
 
-	// Not in cache, retrieve fresh documentation
+	// Not cached anywhere, retrieve fresh documentation
 	if !s.isGoModuleEnabled() {
-		return "", fmt.Errorf("Go module fallback is disabled")
+		return nil, fmt.Errorf("Go module fallback is disabled")
+	}
+	if err := s.allowExpensiveCall(ctx, "Go module retrieval of "+modulePath); err != nil {
+		return nil, err
+	}
+	ctx, release, err := s.acquireWorker(ctx, "Go module retrieval of "+modulePath)
+	if err != nil {
+		return nil, err
 	}
+	defer release()
 
-	log.Printf("Retrieving fresh Go module documentation for: %s", modulePath)
+	slog.InfoContext(ctx, "Retrieving fresh Go module documentation", "module", modulePath)
 
 	// Set verbose mode if server is verbose
 	s.goDocRetriever.SetVerbose(s.verbose)
 
-	// Retrieve documentation
-	moduleInfo, err := s.goDocRetriever.RetrieveDocumentation(modulePath)
+	// Retrieve documentation; the retriever stores the result in the shared cache
+	moduleInfo, err := s.goDocRetriever.GetOrRetrieveDocumentationContext(ctx, modulePath, progress)
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve Go module documentation: %w", err)
-	}
-
-	// Create synthetic repository and cache it
-	repo := s.goDocRetriever.CreateSyntheticRepository(modulePath, moduleInfo)
-	if s.cache != nil {
-		if err := s.cache.StoreRepository(repo); err != nil {
-			log.Printf("Warning: failed to cache Go module documentation for %s: %v", modulePath, err)
-		}
+		return nil, fmt.Errorf("failed to retrieve Go module documentation: %w", err)
 	}
 
-	// Extract and return documentation
-	return s.extractDocumentation(repo, topic, tokens, includeNonExported), nil
+	return s.goDocRetriever.CreateSyntheticRepository(modulePath, moduleInfo), nil
 }
@@ -0,0 +1,2888 @@
+// ************************************************************************************************
+// Package mcp - Unit tests for MCP server functionality.
+// This file covers repository documentation rendering and Go module fallback behavior.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"repomix-mcp/internal/search"
+	"repomix-mcp/pkg/types"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// mockCache implements CacheInterface for testing
+type mockCache struct {
+	repos map[string]*types.RepositoryIndex
+}
+
+func (m *mockCache) GetRepository(id string) (*types.RepositoryIndex, error) {
+	if repo, exists := m.repos[id]; exists {
+		return repo, nil
+	}
+	return nil, fmt.Errorf("%w: %s", types.ErrRepositoryNotFound, id)
+}
+
+func (m *mockCache) StoreRepository(repo *types.RepositoryIndex) error {
+	if m.repos == nil {
+		m.repos = make(map[string]*types.RepositoryIndex)
+	}
+	m.repos[repo.ID] = repo
+	return nil
+}
+
+func (m *mockCache) ListRepositories() ([]string, error) {
+	var ids []string
+	for id := range m.repos {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (m *mockCache) InvalidateAll() error {
+	m.repos = make(map[string]*types.RepositoryIndex)
+	return nil
+}
+
+func (m *mockCache) InvalidateRepository(repositoryID string) error {
+	delete(m.repos, repositoryID)
+	return nil
+}
+
+// installFakeGo puts a fake `go` executable first in PATH that records every invocation
+// into the returned log file and prints canned output for the commands the retriever runs.
+func installFakeGo(t *testing.T) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake go toolchain script requires a POSIX shell")
+	}
+
+	binDir := t.TempDir()
+	logPath := filepath.Join(binDir, "calls.log")
+	script := `#!/bin/sh
+echo "$@" >> "` + logPath + `"
+case "$1" in
+version) echo "go version go1.23.0 linux/amd64" ;;
+doc) echo "package fake // import \"example.com/fake\"" ;;
+list)
+	case "$*" in
+	*example.com/empty*) ;;
+	*) printf 'example.com/fake\nexample.com/fake/internal/store\n' ;;
+	esac ;;
+esac
+exit 0
+`
+	if err := os.WriteFile(filepath.Join(binDir, "go"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake go binary: %v", err)
+	}
+
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logPath
+}
+
+// countCalls returns the number of recorded fake go invocations.
+func countCalls(t *testing.T, logPath string) int {
+	t.Helper()
+
+	data, err := os.ReadFile(logPath)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatalf("Failed to read call log: %v", err)
+	}
+	return strings.Count(string(data), "\n")
+}
+
+// ************************************************************************************************
+// Test that cached Go module docs are re-rendered for a narrower request without a fetch
+func TestGetGoModuleDocs_CachedNarrowedRequest(t *testing.T) {
+	logPath := installFakeGo(t)
+
+	config := &types.Config{
+		GoModule: types.GoModuleConfig{
+			Enabled:         true,
+			TempDirBase:     t.TempDir(),
+			CommandTimeout:  "10s",
+			VerifyChecksums: true,
+		},
+	}
+	cache := &mockCache{repos: make(map[string]*types.RepositoryIndex)}
+
+	server, err := NewServer(config, cache, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	if _, err := server.getGoModuleDocs(context.Background(), "gomod:example.com/fake", "", 10000, false); err != nil {
+		t.Fatalf("First request failed: %v", err)
+	}
+
+	callsAfterFirst := countCalls(t, logPath)
+	if callsAfterFirst == 0 {
+		t.Fatal("Expected the first request to run go subprocesses")
+	}
+
+	if _, err := server.getGoModuleDocs(context.Background(), "gomod:example.com/fake", "fake", 1000, false); err != nil {
+		t.Fatalf("Narrowed request failed: %v", err)
+	}
+
+	if calls := countCalls(t, logPath); calls != callsAfterFirst {
+		t.Errorf("Expected no subprocess calls for the narrowed request, got %d", calls-callsAfterFirst)
+	}
+}
+
+// ************************************************************************************************
+// Test that list-packages lists the packages of a retrieved Go module, and reports modules
+// without packages and repositories that are not Go modules
+func TestHandleListPackages(t *testing.T) {
+	installFakeGo(t)
+
+	config := &types.Config{
+		GoModule: types.GoModuleConfig{
+			Enabled:        true,
+			TempDirBase:    t.TempDir(),
+			CommandTimeout: "10s",
+		},
+	}
+	server, err := NewServer(config, &mockCache{repos: make(map[string]*types.RepositoryIndex)}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	call := func(libraryID string) types.MCPToolCallResult {
+		recorder := httptest.NewRecorder()
+		server.handleToolsCall(context.Background(), recorder, types.JSONRPCRequest{
+			JsonRPC: "2.0",
+			ID:      1,
+			Method:  "tools/call",
+			Params:  types.MCPToolCallParams{Name: "list-packages", Arguments: map[string]interface{}{"library-id": libraryID}},
+		})
+		var response struct {
+			Result types.MCPToolCallResult `json:"result"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Result.Content) == 0 {
+			t.Fatalf("Expected a tool result, got %s", recorder.Body.String())
+		}
+		return response.Result
+	}
+
+	result := call("gomod:example.com/fake")
+	expected := "# Packages of example.com/fake\n\nFound 2 packages.\n\n- example.com/fake\n- example.com/fake/internal/store\n"
+	if result.IsError || result.Content[0].Text != expected {
+		t.Errorf("Expected the package list, got %q (error %v)", result.Content[0].Text, result.IsError)
+	}
+
+	if result := call("gomod:example.com/empty"); !result.IsError || !strings.Contains(result.Content[0].Text, "No packages found") {
+		t.Errorf("Expected a module without packages to be reported, got %q", result.Content[0].Text)
+	}
+	if result := call("my-repo"); !result.IsError || !strings.Contains(result.Content[0].Text, "only supports Go module repositories") {
+		t.Errorf("Expected a repository that is not a Go module to be refused, got %q", result.Content[0].Text)
+	}
+}
+
+// ************************************************************************************************
+// Test that reloading picks up newly cached repositories and drops removed ones
+func TestReloadRepositories(t *testing.T) {
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"kept": {ID: "kept"},
+		"new":  {ID: "new"},
+	}}
+
+	server, err := NewServer(&types.Config{}, cache, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server.UpdateRepository(&types.RepositoryIndex{ID: "kept"})
+	server.UpdateRepository(&types.RepositoryIndex{ID: "gone"})
+
+	added, removed, err := server.ReloadRepositories()
+	if err != nil {
+		t.Fatalf("ReloadRepositories failed: %v", err)
+	}
+
+	if len(added) != 1 || added[0] != "new" {
+		t.Errorf("Expected added [new], got %v", added)
+	}
+	if len(removed) != 1 || removed[0] != "gone" {
+		t.Errorf("Expected removed [gone], got %v", removed)
+	}
+	if _, exists := server.getMemoryRepository("new"); !exists {
+		t.Error("Expected reloaded repository to be resolvable")
+	}
+}
+
+// recordingSearchIndex records the incremental search index updates made by the server
+type recordingSearchIndex struct {
+	indexed []string
+	removed []string
+}
+
+func (r *recordingSearchIndex) Search(query types.SearchQuery) ([]types.SearchResult, error) {
+	return nil, nil
+}
+
+func (r *recordingSearchIndex) IndexRepository(repo *types.RepositoryIndex) {
+	r.indexed = append(r.indexed, repo.ID)
+}
+
+func (r *recordingSearchIndex) RemoveRepository(repositoryID string) {
+	r.removed = append(r.removed, repositoryID)
+}
+
+// ************************************************************************************************
+// Test that only changed repositories are re-indexed for search on update and reload
+func TestReloadRepositories_SearchIndex(t *testing.T) {
+	indexedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"kept":    {ID: "kept", LastUpdated: indexedAt},
+		"changed": {ID: "changed", LastUpdated: indexedAt.Add(time.Hour)},
+	}}
+	searchIndex := &recordingSearchIndex{}
+
+	server, err := NewServer(&types.Config{}, cache, searchIndex)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server.UpdateRepository(&types.RepositoryIndex{ID: "kept", LastUpdated: indexedAt})
+	server.UpdateRepository(&types.RepositoryIndex{ID: "changed", LastUpdated: indexedAt})
+	server.UpdateRepository(&types.RepositoryIndex{ID: "gone", LastUpdated: indexedAt})
+	searchIndex.indexed = nil
+
+	if _, _, err := server.ReloadRepositories(); err != nil {
+		t.Fatalf("ReloadRepositories failed: %v", err)
+	}
+	if len(searchIndex.indexed) != 1 || searchIndex.indexed[0] != "changed" {
+		t.Errorf("Expected only the changed repository to be re-indexed, got %v", searchIndex.indexed)
+	}
+	if len(searchIndex.removed) != 1 || searchIndex.removed[0] != "gone" {
+		t.Errorf("Expected the removed repository to leave the search index, got %v", searchIndex.removed)
+	}
+}
+
+// ************************************************************************************************
+// Test that tool-call arguments are validated against the tool input schema before dispatch
+func TestHandleToolsCall_ArgumentValidation(t *testing.T) {
+	server, err := NewServer(&types.Config{}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		tool      string
+		arguments map[string]interface{}
+		wantField string
+	}{
+		{"missing required", "get-library-docs", map[string]interface{}{"tokens": 500.0}, "library-id"},
+		{"wrong type", "get-library-docs", map[string]interface{}{"library-id": "repo", "tokens": "many"}, "tokens"},
+		{"fractional numeric string", "get-library-docs", map[string]interface{}{"library-id": "repo", "tokens": "10.7"}, "tokens"},
+		{"numeric string below minimum", "get-library-docs", map[string]interface{}{"library-id": "repo", "tokens": "-5"}, "tokens"},
+		{"bad enum", "get-library-docs", map[string]interface{}{"library-id": "repo", "mode": "brief"}, "mode"},
+		{"wrong boolean", "refresh", map[string]interface{}{"force": "yes"}, "force"},
+		{"null required", "resolve-library-id", map[string]interface{}{"libraryName": nil}, "libraryName"},
+		{"fractional number", "get-library-docs", map[string]interface{}{"library-id": "repo", "tokens": 10.7}, "tokens"},
+		{"below minimum", "get-library-docs", map[string]interface{}{"library-id": "repo", "tokens": -5.0}, "tokens"},
+		{"above maximum", "list-todos", map[string]interface{}{"library-id": "repo", "limit": 1e9}, "limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			server.handleToolsCall(context.Background(), recorder, types.JSONRPCRequest{
+				JsonRPC: "2.0",
+				ID:      1,
+				Method:  "tools/call",
+				Params:  types.MCPToolCallParams{Name: tt.tool, Arguments: tt.arguments},
+			})
+
+			var response types.JSONRPCResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Error == nil {
+				t.Fatalf("Expected an error response, got %+v", response.Result)
+			}
+			if response.Error.Code != -32602 {
+				t.Errorf("Expected code -32602, got %d", response.Error.Code)
+			}
+			if data, _ := response.Error.Data.(string); !strings.Contains(data, `"`+tt.wantField+`"`) {
+				t.Errorf("Expected error data to name %q, got %v", tt.wantField, response.Error.Data)
+			}
+		})
+	}
+}
+
+// ************************************************************************************************
+// Test that integer arguments are read from JSON numbers, Go integers and strings, and that
+// fractional and out of bounds values are rejected
+func TestParseIntArg(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    int
+		wantErr bool
+	}{
+		{"absent", nil, 42, false},
+		{"float64", 5000.0, 5000, false},
+		{"fractional float64", 12.9, 0, true},
+		{"negative float64", -3.0, 0, true},
+		{"zero", 0.0, 0, false},
+		{"maximum", 10000.0, 10000, false},
+		{"above maximum", 10001.0, 0, true},
+		{"int", 7, 7, false},
+		{"int64", int64(8), 8, false},
+		{"huge int64", int64(1) << 40, 0, true},
+		{"string", " 2500 ", 2500, false},
+		{"float string", "1e3", 1000, false},
+		{"fractional string", "2.5", 0, true},
+		{"json.Number", json.Number("640"), 640, false},
+		{"invalid string", "many", 0, true},
+		{"boolean", true, 0, true},
+		{"array", []interface{}{1.0}, 0, true},
+		{"NaN", math.NaN(), 0, true},
+		{"infinite", math.Inf(1), 0, true},
+		{"out of range", 1e19, 0, true},
+		{"out of range string", "-1e300", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arguments := map[string]interface{}{}
+			if tt.value != nil {
+				arguments["tokens"] = tt.value
+			}
+			got, err := parseIntArg(arguments, "tokens", 42, 0, 10000)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIntArg(%v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), `"tokens"`) {
+				t.Errorf("Expected the error to name the argument, got %v", err)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseIntArg(%v) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	// A null argument takes the default
+	if got, err := parseIntArg(map[string]interface{}{"tokens": nil}, "tokens", 42, 0, 10000); err != nil || got != 42 {
+		t.Errorf("Expected the default for a null argument, got %d (%v)", got, err)
+	}
+}
+
+// ************************************************************************************************
+// Test that valid arguments, numeric strings and undeclared extra arguments pass validation
+func TestValidateArguments_Valid(t *testing.T) {
+	tool, exists := findTool("get-library-docs")
+	if !exists {
+		t.Fatal("Expected get-library-docs to be defined")
+	}
+
+	arguments := map[string]interface{}{
+		"library-id":         "repo",
+		"tokens":             5000.0,
+		"includeNonExported": true,
+		"mode":               "summary",
+		"extra":              42.0,
+	}
+	if err := validateArguments(tool.InputSchema, arguments); err != nil {
+		t.Errorf("Expected valid arguments, got %v", err)
+	}
+
+	for _, tokens := range []interface{}{" 5000 ", "5e3", json.Number("5000")} {
+		arguments["tokens"] = tokens
+		if err := validateArguments(tool.InputSchema, arguments); err != nil {
+			t.Errorf("Expected tokens %#v to be valid, got %v", tokens, err)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that disabled repositories and their glob expansions are never resolved
+func TestFindRepositoryMatches_Disabled(t *testing.T) {
+	config := &types.Config{Repositories: map[string]types.RepositoryConfig{
+		"projects":       {Type: types.RepositoryTypeLocal, Path: "/src/*", Disabled: true},
+		"projects-admin": {Type: types.RepositoryTypeLocal, Path: "/admin"},
+	}}
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"projects":       {ID: "projects"},
+		"projects-web":   {ID: "projects-web"},
+		"projects-admin": {ID: "projects-admin"},
+	}}
+
+	server, err := NewServer(config, cache, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	matches := server.findRepositoryMatches("projects")
+	if len(matches) != 1 || matches[0] != "projects-admin" {
+		t.Errorf("Expected only [projects-admin], got %v", matches)
+	}
+}
+
+// ************************************************************************************************
+// Test that non-ASCII repository IDs match regardless of case, and of accents when enabled
+func TestFindRepositoryMatches_Unicode(t *testing.T) {
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"Café-API":    {ID: "Café-API"},
+		"straße-maps": {ID: "straße-maps"},
+		"Σύνταξη":     {ID: "Σύνταξη"},
+	}}
+	server, err := NewServer(&types.Config{}, cache, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		query        string
+		ignoreAccent bool
+		expected     string
+	}{
+		{"CAFÉ", false, "Café-API"},
+		{"cafe", false, ""},
+		{"cafe", true, "Café-API"},
+		{"STRASSE", false, "straße-maps"},
+		{"ΣΎΝΤΑΞΗ", false, "Σύνταξη"},
+		{"συνταξη", true, "Σύνταξη"},
+	}
+	for _, test := range tests {
+		server.config.Server.ResolveIgnoreAccents = test.ignoreAccent
+		matches := server.findRepositoryMatches(test.query)
+		if test.expected == "" && len(matches) != 0 {
+			t.Errorf("%q (ignoreAccents=%v): expected no match, got %v", test.query, test.ignoreAccent, matches)
+		}
+		if test.expected != "" && (len(matches) != 1 || matches[0] != test.expected) {
+			t.Errorf("%q (ignoreAccents=%v): expected [%s], got %v", test.query, test.ignoreAccent, test.expected, matches)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that the working diff reports added, modified and deleted files, and for Go-native
+// indexes compares the recorded source hashes
+func TestComputeWorkingDiff(t *testing.T) {
+	tempDir := t.TempDir()
+	write := func(name, content string) {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	write("same.md", "unchanged")
+	write("docs/changed.md", "new content")
+	write("added.md", "added")
+
+	repo := &types.RepositoryIndex{
+		Path: tempDir,
+		Files: map[string]types.IndexedFile{
+			"same.md":         {Path: "same.md", Hash: types.ContentHash("unchanged")},
+			"docs/changed.md": {Path: "docs/changed.md", Hash: types.ContentHash("old")},
+			"removed.md":      {Path: "removed.md", Hash: types.ContentHash("gone")},
+		},
+		Metadata: map[string]interface{}{},
+	}
+
+	diff, err := computeWorkingDiff(repo, []string{"same.md", filepath.Join("docs", "changed.md"), "added.md"})
+	if err != nil {
+		t.Fatalf("computeWorkingDiff failed: %v", err)
+	}
+	if strings.Join(diff.Added, ",") != "added.md" || strings.Join(diff.Modified, ",") != "docs/changed.md" || strings.Join(diff.Deleted, ",") != "removed.md" {
+		t.Errorf("Unexpected diff: %+v", diff)
+	}
+
+	// Go-native index: only the synthetic document is stored, sources are tracked by hash
+	write("lib.go", "package lib")
+	write("new.go", "package lib")
+	write("lib_test.go", "package lib")
+	goRepo := &types.RepositoryIndex{
+		Path: tempDir,
+		Files: map[string]types.IndexedFile{
+			".repomix.xml": {Path: ".repomix.xml", Metadata: map[string]string{"indexer_type": "go_native"}},
+		},
+		Metadata: map[string]interface{}{
+			"indexer_type": "go_native",
+			// Generic JSON object, as loaded from the cache
+			types.SourceHashesMetadataKey: map[string]interface{}{"lib.go": types.ContentHash("package old")},
+		},
+	}
+
+	diff, err = computeWorkingDiff(goRepo, []string{"lib.go", "new.go", "lib_test.go", "same.md"})
+	if err != nil {
+		t.Fatalf("computeWorkingDiff failed: %v", err)
+	}
+	if strings.Join(diff.Added, ",") != "new.go" || strings.Join(diff.Modified, ",") != "lib.go" || len(diff.Deleted) != 0 {
+		t.Errorf("Unexpected Go-native diff: %+v", diff)
+	}
+}
+
+// ************************************************************************************************
+// Test that a workspace merges its members with attribution, glob expansions included
+func TestGetAPISummary_Workspace(t *testing.T) {
+	summary := func(id string) *types.RepositoryIndex {
+		return &types.RepositoryIndex{ID: id, Metadata: map[string]interface{}{"api_summary": "API of " + id}}
+	}
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"billing":          summary("billing"),
+		"services-orders":  summary("services-orders"),
+		"services-users":   {ID: "services-users", Metadata: map[string]interface{}{}},
+		"services-gateway": summary("services-gateway"),
+		"unrelated":        summary("unrelated"),
+	}}
+	config := &types.Config{
+		Repositories: map[string]types.RepositoryConfig{
+			"billing":          {Type: types.RepositoryTypeLocal},
+			"services":         {Type: types.RepositoryTypeLocal, Path: "/src/services/*"},
+			"services-gateway": {Type: types.RepositoryTypeLocal},
+			"unrelated":        {Type: types.RepositoryTypeLocal},
+		},
+		Workspaces: map[string]types.WorkspaceConfig{
+			"shop": {Repositories: []string{"billing", "services"}},
+		},
+	}
+	server, err := NewServer(config, cache, nil)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	docs, err := server.getAPISummary(types.WorkspaceRepositoryID("shop"), 10000)
+	if err != nil {
+		t.Fatalf("getAPISummary failed: %v", err)
+	}
+	for _, expected := range []string{"## Repository: billing\n\nAPI of billing", "## Repository: services-orders\n\nAPI of services-orders", "## Repository: services-users\n\n_Not available:"} {
+		if !strings.Contains(docs, expected) {
+			t.Errorf("Expected workspace docs to contain %q, got:\n%s", expected, docs)
+		}
+	}
+	// Configured aliases are not glob expansions of a shorter alias
+	if strings.Contains(docs, "services-gateway") || strings.Contains(docs, "unrelated") {
+		t.Errorf("Expected only workspace members, got:\n%s", docs)
+	}
+
+	if matches := server.findWorkspaceMatches("shop"); len(matches) != 1 || matches[0] != "workspace:shop" {
+		t.Errorf("Expected workspace:shop to match, got %v", matches)
+	}
+	if _, err := server.getAPISummary(types.WorkspaceRepositoryID("missing"), 10000); err == nil {
+		t.Error("Expected an error for an unknown workspace")
+	}
+}
+
+func TestFormatImplementations(t *testing.T) {
+	index := []types.InterfaceImplementations{
+		{
+			Interface: "example.com/app/storage.Store",
+			File:      "storage/storage.go",
+			Line:      6,
+			Implementations: []types.Implementation{
+				{Type: "example.com/app/memory.Memory", Pointer: true, File: "memory/memory.go", Line: 10},
+			},
+		},
+	}
+
+	for _, query := range []string{"Store", "storage.Store", "example.com/app/storage.Store"} {
+		text, found := formatImplementations("app", query, index)
+		if !found || !strings.Contains(text, "- *example.com/app/memory.Memory (memory/memory.go:10)") {
+			t.Errorf("Expected %q to list the implementation, got:\n%s", query, text)
+		}
+	}
+
+	// A type name lists the interfaces it implements
+	text, found := formatImplementations("app", "memory.Memory", index)
+	if !found || !strings.Contains(text, "- example.com/app/storage.Store as *example.com/app/memory.Memory") {
+		t.Errorf("Expected the interfaces implemented by Memory, got:\n%s", text)
+	}
+
+	if _, found := formatImplementations("app", "age.Store", index); found {
+		t.Error("Expected a partial package name not to match")
+	}
+}
+
+func TestFormatReferences(t *testing.T) {
+	index := []types.SymbolReferences{
+		{
+			Symbol: "example.com/app/storage.Store.Get",
+			File:   "storage/storage.go",
+			Line:   12,
+			References: []types.Reference{
+				{File: "main.go", Line: 8, Column: 2, Caller: "example.com/app.main"},
+			},
+		},
+		{Symbol: "example.com/app/storage.Open", File: "storage/storage.go", Line: 5},
+	}
+
+	for _, query := range []string{"Get", "Store.Get", "storage.Store.Get", "example.com/app/storage.Store.Get"} {
+		text, found := formatReferences("app", query, index)
+		if !found || !strings.Contains(text, "- main.go:8:2 in example.com/app.main") || strings.Contains(text, "storage.Open") {
+			t.Errorf("Expected %q to list the call site of Get only, got:\n%s", query, text)
+		}
+	}
+
+	text, found := formatReferences("app", "Open", index)
+	if !found || !strings.Contains(text, "No call sites in this repository.") {
+		t.Errorf("Expected Open to be listed without call sites, got:\n%s", text)
+	}
+
+	if _, found := formatReferences("app", "tore.Get", index); found {
+		t.Error("Expected a partial type name not to match")
+	}
+}
+
+// ************************************************************************************************
+// Test that symbols are looked up by any qualified form of their name
+func TestFormatSymbols(t *testing.T) {
+	index := []types.Symbol{
+		{Name: "Store.Get", Kind: "method", Package: "example.com/app/storage", Signature: "func (s *Store) Get(key string) string", Doc: "Get returns the value of a key.", File: "storage/storage.go", Line: 12},
+		{Name: "Open", Kind: "func", Package: "example.com/app/storage", Signature: "func Open() *Store", File: "storage/storage.go", Line: 5},
+	}
+
+	for _, query := range []string{"Get", "Store.Get", "storage.Store.Get", "example.com/app/storage.Store.Get"} {
+		text, found := formatSymbols("app", query, index)
+		if !found || !strings.Contains(text, "## method example.com/app/storage.Store.Get\n\nDeclared in storage/storage.go:12") ||
+			!strings.Contains(text, "func (s *Store) Get(key string) string") || !strings.Contains(text, "Get returns the value of a key.") || strings.Contains(text, "Open") {
+			t.Errorf("Expected %q to describe Get only, got:\n%s", query, text)
+		}
+	}
+
+	if text, found := formatSymbols("app", "Open", index); !found || !strings.Contains(text, "Undocumented.") {
+		t.Errorf("Expected Open to be listed as undocumented, got:\n%s", text)
+	}
+	if _, found := formatSymbols("app", "Close", index); found {
+		t.Error("Expected an unknown name not to match")
+	}
+}
+
+// ************************************************************************************************
+// Test that old indexes are served with an age note, unless the note is disabled
+func TestExtractDocumentation_StalenessNote(t *testing.T) {
+	repo := &types.RepositoryIndex{
+		ID:          "app",
+		Name:        "app",
+		LastUpdated: time.Now().Add(-10 * 24 * time.Hour),
+		Files:       map[string]types.IndexedFile{"README.md": {Path: "README.md", Content: "# App"}},
+	}
+
+	server, err := NewServer(&types.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	docs := server.extractDocumentation(repo, "", 10000, false)
+	if !strings.HasPrefix(docs, "> **Note:** this content was indexed 10 days ago") {
+		t.Errorf("Expected the staleness note first, got:\n%s", docs)
+	}
+
+	server.config.Server.StalenessThreshold = "720h"
+	if docs := server.extractDocumentation(repo, "", 10000, false); strings.Contains(docs, "**Note:**") {
+		t.Errorf("Expected no note below the threshold, got:\n%s", docs)
+	}
+
+	server.config.Server.StalenessThreshold = "0"
+	if docs := server.extractDocumentation(repo, "", 10000, false); strings.Contains(docs, "**Note:**") {
+		t.Errorf("Expected no note when disabled, got:\n%s", docs)
+	}
+}
+
+// ************************************************************************************************
+// Test that a topic selects the matching sections of Markdown files with recorded sections
+func TestExtractDocumentation_MarkdownTopic(t *testing.T) {
+	content := "# Guide\n\nIntro.\n\n## Install\n\nRun make.\n\n## Usage\n\nCall the tool.\n"
+	sections := []types.MarkdownSection{
+		{Level: 1, Title: "Guide", Parent: -1, Start: 1, End: 12},
+		{Level: 2, Title: "Install", Parent: 0, Start: 5, End: 8},
+		{Level: 2, Title: "Usage", Parent: 0, Start: 9, End: 12},
+	}
+	repo := &types.RepositoryIndex{
+		ID:          "docs",
+		Name:        "docs",
+		LastUpdated: time.Now(),
+		Files: map[string]types.IndexedFile{"guide.md": {
+			Path:     "guide.md",
+			Content:  content,
+			Metadata: map[string]string{types.MarkdownSectionsMetadataKey: types.FormatMarkdownSections(sections)},
+		}},
+	}
+
+	server, err := NewServer(&types.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	docs := server.extractDocumentation(repo, "install", 10000, false)
+	if !strings.Contains(docs, "## Install\n\nRun make.") || strings.Contains(docs, "Call the tool.") {
+		t.Errorf("Expected only the Install section, got:\n%s", docs)
+	}
+	if docs := server.extractDocumentation(repo, "", 10000, false); !strings.Contains(docs, "Call the tool.") {
+		t.Errorf("Expected the whole file without topic, got:\n%s", docs)
+	}
+}
+
+// ************************************************************************************************
+// Test that documentation budgets are counted in tokens of the configured tokenizer
+func TestExtractDocumentation_TokenBudget(t *testing.T) {
+	// Every repetition is two cl100k_base tokens for five characters
+	content := strings.Repeat(" word", 2000)
+	repo := &types.RepositoryIndex{
+		ID:          "words",
+		Name:        "words",
+		LastUpdated: time.Now(),
+		Files:       map[string]types.IndexedFile{"words.txt": {Path: "words.txt", Content: content}},
+	}
+
+	server, err := NewServer(&types.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	docs := server.extractDocumentation(repo, "", 1000, false)
+	if tokens := server.tokenizer.Count(docs); tokens > 1100 || tokens < 800 {
+		t.Errorf("Expected about 1000 tokens, got %d", tokens)
+	}
+	if len(docs) < 3000 || !strings.Contains(docs, "[Content truncated...]") {
+		t.Errorf("Expected the content truncated to the token budget rather than 1000 characters, got %d characters", len(docs))
+	}
+
+	chars, err := NewServer(&types.Config{Server: types.ServerConfig{Tokenizer: types.TokenizerChars}}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	if docs := chars.extractDocumentation(repo, "", 1000, false); len(docs) > 1100 {
+		t.Errorf("Expected at most about 1000 characters, got %d", len(docs))
+	}
+
+	if _, err := NewServer(&types.Config{Server: types.ServerConfig{Tokenizer: "unknown"}}, nil, nil); err == nil {
+		t.Error("Expected an error for an unknown tokenizer")
+	}
+}
+
+// ************************************************************************************************
+// Test that the chunks of large files about the topic are served instead of the whole files
+func TestExtractDocumentation_Chunks(t *testing.T) {
+	content := "package store\n\nfunc Open() {\n" + strings.Repeat("\tprepare()\n", 300) + "}\n\n" +
+		"// Retry runs an operation again.\nfunc Retry() {\n\tbackoff()\n}\n"
+	chunks := []types.Chunk{
+		{Start: 1, End: 305, Kind: "func", Symbols: []string{"Open"}},
+		{Start: 306, End: 309, Kind: "func", Symbols: []string{"Retry"}},
+	}
+	repo := &types.RepositoryIndex{
+		ID:          "store",
+		Name:        "store",
+		LastUpdated: time.Now(),
+		Files: map[string]types.IndexedFile{"store.go": {
+			Path:     "store.go",
+			Content:  content,
+			Metadata: map[string]string{types.ChunksMetadataKey: types.FormatChunks(chunks)},
+		}},
+	}
+
+	server, err := NewServer(&types.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	docs := server.extractDocumentation(repo, "retry", 10000, false)
+	if !strings.Contains(docs, "### Lines 306-309 (Retry)\n\n// Retry runs an operation again.\nfunc Retry() {") {
+		t.Errorf("Expected the Retry chunk, got %s", docs)
+	}
+	if strings.Contains(docs, "prepare()") {
+		t.Error("Expected the chunks of Open to be left out")
+	}
+
+	if docs := server.extractDocumentation(repo, "", 10000, false); strings.Contains(docs, "### Lines") {
+		t.Error("Expected the whole file without a topic")
+	}
+}
+
+// ************************************************************************************************
+// Test that API specifications are served as their endpoints and schemas
+func TestExtractDocumentation_APISpec(t *testing.T) {
+	spec := `{"openapi": "3.0.3", "info": {"title": "Pets"},
+		"paths": {"/pets": {"get": {"operationId": "listPets", "summary": "List pets"}}},
+		"components": {"schemas": {"Pet": {"type": "object", "required": ["id"],
+			"properties": {"id": {"type": "integer"}, "tags": {"type": "array", "items": {"type": "string"}}}}}}}`
+	repo := &types.RepositoryIndex{
+		ID:          "pets",
+		Name:        "pets",
+		LastUpdated: time.Now(),
+		Files: map[string]types.IndexedFile{"openapi.json": {
+			Path:     "openapi.json",
+			Content:  spec,
+			Metadata: map[string]string{"file_type": types.APISpecFileType},
+		}},
+	}
+
+	server, err := NewServer(&types.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	docs := server.extractDocumentation(repo, "pet", 10000, false)
+	for _, expected := range []string{
+		"**Title:** Pets\n",
+		"- GET /pets (listPets): List pets\n",
+		"## Schemas (1)\n\n- Pet (object): id integer (required), tags []string\n",
+	} {
+		if !strings.Contains(docs, expected) {
+			t.Errorf("Expected docs to contain %q, got:\n%s", expected, docs)
+		}
+	}
+	if strings.Contains(docs, `"openapi"`) {
+		t.Errorf("Expected the summary instead of the raw specification, got:\n%s", docs)
+	}
+}
+
+// ************************************************************************************************
+// Test that oversized tool responses are truncated to the byte limit with a note
+func TestSendJSONRPCResult_MaxResponseSize(t *testing.T) {
+	server, err := NewServer(&types.Config{Server: types.ServerConfig{MaxResponseSize: "2KB"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	// Escaped characters make the encoded response longer than the text
+	text := strings.Repeat("<é>", 2000)
+	recorder := httptest.NewRecorder()
+	server.sendJSONRPCResult(recorder, 1, types.MCPToolCallResult{Content: []types.MCPContent{{Type: "text", Text: text}}})
+
+	if recorder.Body.Len() > 2048 {
+		t.Fatalf("Expected the response to fit in 2048 bytes, got %d", recorder.Body.Len())
+	}
+	var response struct {
+		Result types.MCPToolCallResult `json:"result"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Expected a valid JSON response: %v", err)
+	}
+	truncated := response.Result.Content[0].Text
+	if !strings.HasSuffix(truncated, truncationNote(2048)) || !strings.HasPrefix(text, strings.TrimSuffix(truncated, truncationNote(2048))) {
+		t.Errorf("Expected a prefix of the text followed by the truncation note, got %q", truncated)
+	}
+
+	server.config.Server.MaxResponseSize = "0"
+	recorder = httptest.NewRecorder()
+	server.sendJSONRPCResult(recorder, 1, types.MCPToolCallResult{Content: []types.MCPContent{{Type: "text", Text: text}}})
+	if !strings.Contains(recorder.Body.String(), `"isError":false`) || recorder.Body.Len() < len(text) {
+		t.Errorf("Expected the full response when the limit is disabled, got %d bytes", recorder.Body.Len())
+	}
+}
+
+// ************************************************************************************************
+// Test that in-memory repositories are evicted when idle or least recently used, and reloaded
+// from the cache on demand
+func TestServer_MemoryEviction(t *testing.T) {
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"a": {ID: "a"},
+		"b": {ID: "b"},
+		"c": {ID: "c"},
+	}}
+	config := &types.Config{Server: types.ServerConfig{MaxMemoryRepositories: 2}}
+	server, err := NewServer(config, cache, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	for _, repoID := range []string{"a", "b", "c"} {
+		server.UpdateRepository(&types.RepositoryIndex{ID: repoID})
+		time.Sleep(time.Millisecond)
+	}
+	if _, exists := server.repositories["a"]; exists || server.memoryRepositoryCount() != 2 {
+		t.Fatalf("Expected the least recently used repository to be evicted, holding %d", server.memoryRepositoryCount())
+	}
+	if ids := server.memoryRepositoryIDs(); len(ids) != 3 {
+		t.Errorf("Expected evicted repositories to stay listed, got %v", ids)
+	}
+
+	// Reloading a evicts b, now the least recently used
+	if repo, exists := server.getMemoryRepository("a"); !exists || repo.ID != "a" {
+		t.Fatal("Expected the evicted repository to be reloaded from the cache")
+	}
+	if _, exists := server.repositories["b"]; exists {
+		t.Error("Expected b to be evicted after reloading a")
+	}
+
+	// Idle repositories are evicted on the next access
+	config.Server.MemoryIdleTimeout = "1h"
+	server.reposMu.Lock()
+	server.repoAccess["c"] = time.Now().Add(-2 * time.Hour)
+	server.reposMu.Unlock()
+	server.getMemoryRepository("a")
+	if _, exists := server.repositories["c"]; exists {
+		t.Error("Expected the idle repository to be evicted")
+	}
+
+	// Concurrent lookups keep the policy consistent
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(repoID string) {
+			defer wg.Done()
+			if _, exists := server.getMemoryRepository(repoID); !exists {
+				t.Errorf("Expected %s to be resolvable", repoID)
+			}
+		}([]string{"a", "b", "c"}[i%3])
+	}
+	wg.Wait()
+	if count := server.memoryRepositoryCount(); count > 2 {
+		t.Errorf("Expected at most 2 repositories in memory, got %d", count)
+	}
+
+	// Without a cache nothing is evicted
+	server, _ = NewServer(config, nil, nil)
+	for _, repoID := range []string{"a", "b", "c"} {
+		server.UpdateRepository(&types.RepositoryIndex{ID: repoID})
+	}
+	if count := server.memoryRepositoryCount(); count != 3 {
+		t.Errorf("Expected no eviction without a cache, got %d repositories", count)
+	}
+}
+
+// ************************************************************************************************
+// Test that large responses are compressed with the negotiated encoding and small ones are not
+func TestWithCompression(t *testing.T) {
+	server, err := NewServer(&types.Config{}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	large := strings.Repeat(`{"text":"repeated documentation"}`, 100)
+	handler := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(body))
+		}
+	}
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		body           string
+		wantEncoding   string
+	}{
+		{"gzip", "gzip, deflate", large, "gzip"},
+		{"deflate", "deflate", large, "deflate"},
+		{"gzip refused", "gzip;q=0, deflate;q=0.5", large, "deflate"},
+		{"wildcard", "*", large, "gzip"},
+		{"not accepted", "", large, ""},
+		{"identity only", "identity", large, ""},
+		{"small response", "gzip", `{"ok":true}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			if tt.acceptEncoding != "" {
+				request.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			recorder := httptest.NewRecorder()
+			server.withCompression(handler(tt.body))(recorder, request)
+
+			if recorder.Code != http.StatusCreated {
+				t.Errorf("Expected the handler status to be kept, got %d", recorder.Code)
+			}
+			if got := recorder.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if recorder.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Expected Vary: Accept-Encoding, got %q", recorder.Header().Get("Vary"))
+			}
+
+			var reader io.Reader = recorder.Body
+			switch tt.wantEncoding {
+			case "gzip":
+				reader, err = gzip.NewReader(recorder.Body)
+			case "deflate":
+				reader, err = zlib.NewReader(recorder.Body)
+			}
+			if err != nil {
+				t.Fatalf("Failed to open the compressed body: %v", err)
+			}
+			if tt.wantEncoding != "" && recorder.Body.Len() >= len(tt.body) {
+				t.Errorf("Expected a smaller compressed body, got %d bytes for %d", recorder.Body.Len(), len(tt.body))
+			}
+			body, err := io.ReadAll(reader)
+			if err != nil || string(body) != tt.body {
+				t.Errorf("Expected the original body back, got %d bytes (%v)", len(body), err)
+			}
+		})
+	}
+
+	// Compression disabled with "0"
+	server.config.Server.CompressionMinSize = "0"
+	request := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	server.withCompression(handler(large))(recorder, request)
+	if recorder.Header().Get("Content-Encoding") != "" || recorder.Body.String() != large {
+		t.Error("Expected no compression when disabled")
+	}
+}
+
+// ************************************************************************************************
+// Test that requests beyond the concurrency limit are rejected with 503 until a slot frees up
+func TestWithConcurrencyLimit(t *testing.T) {
+	config := &types.Config{}
+	config.Server.MaxConcurrentRequests = 1
+	server, err := NewServer(config, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := server.withConcurrencyLimit(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("block") != "" {
+			close(started)
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	done := make(chan int)
+	go func() {
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(http.MethodPost, "/mcp?block=1", nil))
+		done <- recorder.Code
+	}()
+	<-started
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/mcp", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 while saturated, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+	var response types.JSONRPCResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || response.Error == nil {
+		t.Errorf("Expected a JSON-RPC error body, got %q", recorder.Body.String())
+	}
+
+	// WebSocket requests share the same slots
+	client, serverSide := net.Pipe()
+	defer client.Close()
+	go server.handleWebSocketMessage(context.Background(), &webSocketConn{conn: serverSide}, []byte(`{"jsonrpc":"2.0","id":3,"method":"ping"}`))
+	client.SetDeadline(time.Now().Add(10 * time.Second))
+	_, payload := readServerFrame(t, bufio.NewReader(client))
+	if err := json.Unmarshal(payload, &response); err != nil || response.Error == nil || response.Error.Code != -32000 || fmt.Sprint(response.ID) != "3" {
+		t.Errorf("Expected a server busy error over WebSocket, got %q", payload)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("Expected the first request to complete, got %d", code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/mcp", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected a freed slot to accept requests, got %d", recorder.Code)
+	}
+}
+
+// ************************************************************************************************
+// Test that repositories are found by file path and ranked by how specific the match is
+func TestFindFileMatches(t *testing.T) {
+	files := func(paths ...string) map[string]types.IndexedFile {
+		indexed := make(map[string]types.IndexedFile)
+		for _, filePath := range paths {
+			indexed[filePath] = types.IndexedFile{Path: filePath}
+		}
+		return indexed
+	}
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"app":      {ID: "app", Path: "/src/app", Files: files("internal/cache/cache.go", "cmd/main.go")},
+		"lib":      {ID: "lib", Path: "/src/lib", Files: files("cache/cache.go", "cache/cache_test.go")},
+		"other":    {ID: "other", Path: "/src/other", Files: files("pkg/mycache/cache.go")},
+		"disabled": {ID: "disabled", Files: files("cache/cache.go")},
+	}}
+	config := &types.Config{Repositories: map[string]types.RepositoryConfig{
+		"disabled": {Disabled: true},
+	}}
+	server, err := NewServer(config, cache, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"exact first", "cache/cache.go", []string{"lib:cache/cache.go", "app:internal/cache/cache.go", "other:pkg/mycache/cache.go"}},
+		{"leading dot slash", "./cmd/main.go", []string{"app:cmd/main.go"}},
+		{"windows separators", "internal\\cache\\cache.go", []string{"app:internal/cache/cache.go"}},
+		{"absolute path", "/src/lib/cache/cache_test.go", []string{"lib:cache/cache_test.go"}},
+		{"substring", "cache_t", []string{"lib:cache/cache_test.go"}},
+		{"no match", "missing.go", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, match := range server.findFileMatches(tt.query) {
+				got = append(got, match.RepositoryID+":"+match.Path)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("findFileMatches(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	text := formatFileMatches("cache.go", server.findFileMatches("cache.go"), 2)
+	if !strings.Contains(text, "## lib\n\n- cache/cache.go (path suffix)") {
+		t.Errorf("Expected the best match grouped under its repository, got:\n%s", text)
+	}
+	if !strings.Contains(text, "1 more matching files not shown") {
+		t.Errorf("Expected the limit to be reported, got:\n%s", text)
+	}
+}
+
+// ************************************************************************************************
+// Test that notifications switch a POST response to an event stream ending with the response
+func TestStreamingResponseWriter(t *testing.T) {
+	server, err := NewServer(&types.Config{}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	// Without notification the response stays plain JSON
+	recorder := httptest.NewRecorder()
+	streaming := &streamingResponseWriter{ResponseWriter: recorder}
+	server.sendJSONRPCResult(streaming, 1, "pong")
+	streaming.finish()
+	if recorder.Header().Get("Content-Type") != "application/json" || !strings.Contains(recorder.Body.String(), `"result":"pong"`) {
+		t.Errorf("Expected a plain JSON response, got %q (%s)", recorder.Body.String(), recorder.Header().Get("Content-Type"))
+	}
+
+	recorder = httptest.NewRecorder()
+	streaming = &streamingResponseWriter{ResponseWriter: recorder}
+	server.notifyMessage(context.Background(), streaming, "info", "Retrieving Go module documentation")
+	server.sendJSONRPCResult(streaming, 2, "done")
+	streaming.finish()
+
+	// Headers set once the stream started are not sent
+	if contentType := recorder.Result().Header.Get("Content-Type"); contentType != eventStreamContentType {
+		t.Fatalf("Expected an event stream, got %s", contentType)
+	}
+	events := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n\n")
+	if len(events) != 2 {
+		t.Fatalf("Expected a notification and a response event, got %q", recorder.Body.String())
+	}
+	if !strings.HasPrefix(events[0], "id: 1\nevent: message\ndata: ") || !strings.Contains(events[0], `"method":"notifications/message"`) {
+		t.Errorf("Expected the notification first, got %q", events[0])
+	}
+	if !strings.Contains(events[1], `"id":2`) || !strings.Contains(events[1], `"result":"done"`) {
+		t.Errorf("Expected the response last, got %q", events[1])
+	}
+
+	// Requests from clients not accepting an event stream get no notification
+	recorder = httptest.NewRecorder()
+	server.notifyMessage(context.Background(), recorder, "info", "dropped")
+	if recorder.Body.Len() != 0 {
+		t.Errorf("Expected the notification to be dropped, got %q", recorder.Body.String())
+	}
+}
+
+// ************************************************************************************************
+// Test that a GET event stream receives server-initiated notifications, uncompressed
+func TestHandleEventStream(t *testing.T) {
+	server, err := NewServer(&types.Config{}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	httpServer := httptest.NewServer(server.withCompression(server.handleMCPEndpoint))
+	defer httpServer.Close()
+	defer server.Stop()
+
+	request, _ := http.NewRequest(http.MethodGet, httpServer.URL, nil)
+	request.Header.Set("Accept", eventStreamContentType)
+	request.Header.Set("Accept-Encoding", "gzip")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Failed to open the event stream: %v", err)
+	}
+	defer response.Body.Close()
+	if response.Header.Get("Content-Type") != eventStreamContentType || response.Header.Get("Content-Encoding") != "" {
+		t.Fatalf("Expected an uncompressed event stream, got %s (%s)", response.Header.Get("Content-Type"), response.Header.Get("Content-Encoding"))
+	}
+
+	// The stream is registered once its headers are flushed
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		server.streamsMu.Lock()
+		open := len(server.streams)
+		server.streamsMu.Unlock()
+		if open == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("The event stream was not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	server.UpdateRepository(&types.RepositoryIndex{ID: "fresh"})
+	reader := bufio.NewReader(response.Body)
+	var event strings.Builder
+	for !strings.Contains(event.String(), "\n\n") {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read the event: %v", err)
+		}
+		event.WriteString(line)
+	}
+	if !strings.Contains(event.String(), `"method":"notifications/message"`) || !strings.Contains(event.String(), "Repository fresh updated") {
+		t.Errorf("Expected the repository update notification, got %q", event.String())
+	}
+}
+
+// ************************************************************************************************
+// Test that initialize answers with the requested protocol version when supported
+func TestHandleInitialize_ProtocolVersion(t *testing.T) {
+	server, err := NewServer(&types.Config{}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		requested string
+		want      string
+	}{
+		{"2024-11-05", "2024-11-05"},
+		{"2025-03-26", "2025-03-26"},
+		{"2030-01-01", "2025-03-26"},
+	}
+
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		server.handleInitialize(context.Background(), recorder, types.JSONRPCRequest{
+			JsonRPC: "2.0",
+			ID:      1,
+			Method:  "initialize",
+			Params:  types.MCPInitializeRequest{ProtocolVersion: tt.requested},
+		})
+		var response struct {
+			Result types.MCPInitializeResult `json:"result"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Result.ProtocolVersion != tt.want {
+			t.Errorf("Requested %s, got %s, want %s", tt.requested, response.Result.ProtocolVersion, tt.want)
+		}
+	}
+}
+
+// ************************************************************************************************
+// writeClientFrame writes a masked WebSocket frame, as a client does.
+func writeClientFrame(t *testing.T, conn net.Conn, final bool, opcode int, payload []byte) {
+	t.Helper()
+	first := byte(opcode)
+	if final {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, 0x80|byte(len(payload)))
+	default:
+		frame = append(frame, 0x80|126, byte(len(payload)>>8), byte(len(payload)))
+	}
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame = append(frame, mask...)
+	for index, b := range payload {
+		frame = append(frame, b^mask[index%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf("Failed to write frame: %v", err)
+	}
+}
+
+// ************************************************************************************************
+// readServerFrame reads an unmasked WebSocket frame, as sent by the server.
+func readServerFrame(t *testing.T, reader *bufio.Reader) (int, []byte) {
+	t.Helper()
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		t.Fatalf("Failed to read frame: %v", err)
+	}
+	length := int(header[1] & 0x7F)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		io.ReadFull(reader, extended)
+		length = int(extended[0])<<8 | int(extended[1])
+	case 127:
+		t.Fatal("Unexpected 64-bit frame length")
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		t.Fatalf("Failed to read payload: %v", err)
+	}
+	return int(header[0] & 0x0F), payload
+}
+
+// ************************************************************************************************
+// Test that a WebSocket session handles JSON-RPC requests and receives notifications
+func TestHandleWebSocket(t *testing.T) {
+	server, err := NewServer(&types.Config{}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(server.handleWebSocket))
+	defer httpServer.Close()
+	defer server.Stop()
+
+	// A plain request is refused
+	response, err := http.Get(httpServer.URL)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("Expected 426 without upgrade headers, got %d", response.StatusCode)
+	}
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(httpServer.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	fmt.Fprintf(conn, "GET /mcp/ws HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Protocol: mcp\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	handshake, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+	if handshake.StatusCode != http.StatusSwitchingProtocols || handshake.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Unexpected handshake: %d %v", handshake.StatusCode, handshake.Header)
+	}
+	if handshake.Header.Get("Sec-WebSocket-Protocol") != "mcp" {
+		t.Errorf("Expected the mcp subprotocol, got %q", handshake.Header.Get("Sec-WebSocket-Protocol"))
+	}
+
+	// A request fragmented in two frames, with a ping in between
+	request := []byte(`{"jsonrpc":"2.0","id":7,"method":"ping"}`)
+	writeClientFrame(t, conn, false, wsOpText, request[:10])
+	writeClientFrame(t, conn, true, wsOpPing, []byte("hello"))
+	writeClientFrame(t, conn, true, wsOpContinuation, request[10:])
+
+	if opcode, payload := readServerFrame(t, reader); opcode != wsOpPong || string(payload) != "hello" {
+		t.Errorf("Expected the pong first, got opcode %d %q", opcode, payload)
+	}
+	opcode, payload := readServerFrame(t, reader)
+	var pong types.JSONRPCResponse
+	if opcode != wsOpText || json.Unmarshal(payload, &pong) != nil || fmt.Sprint(pong.ID) != "7" || pong.Error != nil {
+		t.Errorf("Expected the ping response, got opcode %d %q", opcode, payload)
+	}
+
+	// Invalid JSON gets a parse error
+	writeClientFrame(t, conn, true, wsOpText, []byte("{"))
+	if _, payload := readServerFrame(t, reader); !strings.Contains(string(payload), "-32700") {
+		t.Errorf("Expected a parse error, got %q", payload)
+	}
+
+	// Server-initiated notifications reach the session
+	server.UpdateRepository(&types.RepositoryIndex{ID: "fresh"})
+	if _, payload := readServerFrame(t, reader); !strings.Contains(string(payload), "Repository fresh updated") {
+		t.Errorf("Expected the repository update notification, got %q", payload)
+	}
+
+	// The server answers a close frame and ends the session
+	writeClientFrame(t, conn, true, wsOpClose, []byte{0x03, 0xE8})
+	if opcode, _ := readServerFrame(t, reader); opcode != wsOpClose {
+		t.Errorf("Expected a close frame, got opcode %d", opcode)
+	}
+}
+
+// ************************************************************************************************
+// Test that a client that stops reading has its WebSocket connection closed
+func TestWebSocketConn_WriteTimeout(t *testing.T) {
+	previous := webSocketWriteTimeout
+	webSocketWriteTimeout = 50 * time.Millisecond
+	defer func() { webSocketWriteTimeout = previous }()
+
+	client, serverSide := net.Pipe()
+	defer client.Close()
+	conn := &webSocketConn{conn: serverSide}
+
+	if err := conn.send([]byte("unread")); err == nil {
+		t.Fatal("Expected the write to time out")
+	}
+	if err := conn.send([]byte("next")); err == nil {
+		t.Error("Expected writes to fail once the connection is closed")
+	}
+	if _, err := client.Read(make([]byte, 1)); err == nil {
+		t.Error("Expected the connection to be closed")
+	}
+}
+
+// ************************************************************************************************
+// Test that the search engine searches the repositories the server updates and reloads
+func TestServer_IndexedSearch(t *testing.T) {
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"cached": {ID: "cached", Files: map[string]types.IndexedFile{
+			"store.go": {Path: "store.go", RepositoryID: "cached", Content: "func OpenStore() *Store"},
+		}},
+	}}
+	server, err := NewServer(&types.Config{}, cache, search.NewEngine().Indexed())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if _, ok := server.searchEngine.(SearchIndexUpdater); !ok {
+		t.Fatal("Expected the indexed searcher to keep its index up to date")
+	}
+
+	server.UpdateRepository(&types.RepositoryIndex{ID: "fresh", Files: map[string]types.IndexedFile{
+		"server.go": {Path: "server.go", RepositoryID: "fresh", Content: "func NewServer() *Server"},
+	}})
+	if _, _, err := server.ReloadRepositories(); err != nil {
+		t.Fatalf("ReloadRepositories failed: %v", err)
+	}
+
+	results, err := server.searchEngine.Search(types.SearchQuery{Query: "OpenStore"})
+	if err != nil || len(results) != 1 || results[0].File.RepositoryID != "cached" {
+		t.Errorf("Expected the cached repository to be searchable, got %v (%v)", results, err)
+	}
+
+	// The reload dropped the repository missing from the cache
+	if results, _ := server.searchEngine.Search(types.SearchQuery{Query: "NewServer"}); len(results) != 0 {
+		t.Errorf("Expected the removed repository to leave the search index, got %v", results)
+	}
+}
+
+// ************************************************************************************************
+// Test that search-code returns ranked, numbered snippets of the enabled repositories
+func TestHandleSearchCode(t *testing.T) {
+	engine := search.NewEngine()
+	config := &types.Config{Repositories: map[string]types.RepositoryConfig{
+		"hidden": {Disabled: true},
+	}}
+	server, err := NewServer(config, &mockCache{}, engine.Indexed())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server.UpdateRepository(&types.RepositoryIndex{ID: "app", Files: map[string]types.IndexedFile{
+		"internal/store.go": {Path: "internal/store.go", RepositoryID: "app", Language: "go",
+			Content: "package internal\n\n// OpenStore opens the store\nfunc OpenStore() *Store {\n\treturn nil\n}"},
+		"README.md": {Path: "README.md", RepositoryID: "app", Language: "markdown", Content: "Call OpenStore first."},
+	}})
+	server.UpdateRepository(&types.RepositoryIndex{ID: "hidden", Files: map[string]types.IndexedFile{
+		"store.go": {Path: "store.go", RepositoryID: "hidden", Language: "go", Content: "func OpenStore()"},
+	}})
+
+	call := func(arguments map[string]interface{}) types.MCPToolCallResult {
+		recorder := httptest.NewRecorder()
+		server.handleToolsCall(context.Background(), recorder, types.JSONRPCRequest{
+			JsonRPC: "2.0",
+			ID:      1,
+			Method:  "tools/call",
+			Params:  types.MCPToolCallParams{Name: "search-code", Arguments: arguments},
+		})
+		var response struct {
+			Result types.MCPToolCallResult `json:"result"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Result
+	}
+
+	result := call(map[string]interface{}{"query": "func OpenStore", "language": "Go"})
+	if result.IsError {
+		t.Fatalf("Expected results, got %q", result.Content[0].Text)
+	}
+	text := result.Content[0].Text
+	if !strings.Contains(text, "## 1. app: internal/store.go:4") || !strings.Contains(text, ">4 | func OpenStore() *Store {") || !strings.Contains(text, " 2 | ") {
+		t.Errorf("Expected a numbered snippet around line 4, got:\n%s", text)
+	}
+	if strings.Contains(text, "hidden") || strings.Contains(text, "README.md") {
+		t.Errorf("Expected disabled repositories and other languages to be left out, got:\n%s", text)
+	}
+
+	if result := call(map[string]interface{}{"query": "OpenStore", "filePattern": "*.md"}); result.IsError || !strings.Contains(result.Content[0].Text, "app: README.md:1") {
+		t.Errorf("Expected the file pattern to select README.md, got %+v", result)
+	}
+	if result := call(map[string]interface{}{"query": "OpenStore", "library-id": "hidden"}); !result.IsError {
+		t.Error("Expected a disabled repository to be refused")
+	}
+	if result := call(map[string]interface{}{"query": "CloseStore"}); !result.IsError {
+		t.Error("Expected an error without matches")
+	}
+}
+
+// ************************************************************************************************
+// Test that list-libraries lists the enabled repositories with their metadata
+func TestListLibraries(t *testing.T) {
+	updated := time.Date(2025, 3, 2, 14, 10, 0, 0, time.UTC)
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"app": {ID: "app", LastUpdated: updated, CommitHash: "0123456789abcdef0123", Files: map[string]types.IndexedFile{
+			"main.go": {Path: "main.go"},
+			"go.mod":  {Path: "go.mod"},
+		}},
+		"gomod:github.com/spf13/cobra": {ID: "gomod:github.com/spf13/cobra", Files: map[string]types.IndexedFile{
+			"go-doc.md": {Path: "go-doc.md"},
+		}},
+		"disabled": {ID: "disabled"},
+	}}
+	config := &types.Config{Repositories: map[string]types.RepositoryConfig{
+		"disabled": {Disabled: true},
+	}}
+	server, err := NewServer(config, cache, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	text := formatLibraryList(server.listLibraries(true))
+	for _, want := range []string{
+		"2 libraries",
+		"| app | repository | 2 | 2025-03-02T14:10:00Z | 0123456789ab |",
+		"| gomod:github.com/spf13/cobra | Go module | 1 | - | - |",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in the library list, got:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "disabled") {
+		t.Errorf("Expected disabled repositories to be hidden, got:\n%s", text)
+	}
+
+	libraries := server.listLibraries(false)
+	if len(libraries) != 1 || libraries[0].ID != "app" {
+		t.Errorf("Expected only the configured repository without Go modules, got %+v", libraries)
+	}
+}
+
+// ************************************************************************************************
+// Test that get-file returns the exact content of a file and suggests paths when it is missing
+func TestHandleGetFile(t *testing.T) {
+	content := strings.Repeat("package cache\n\n// Line of a long file\n", 5000)
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"app": {ID: "app", Path: "/src/app", Files: map[string]types.IndexedFile{
+			"internal/cache/cache.go": {Path: "internal/cache/cache.go", Content: content},
+			"internal/cache/mock.go":  {Path: "internal/cache/mock.go", Content: "package cache"},
+		}},
+	}}
+	server, err := NewServer(&types.Config{}, cache, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	call := func(arguments map[string]interface{}) types.MCPToolCallResult {
+		recorder := httptest.NewRecorder()
+		server.handleToolsCall(context.Background(), recorder, types.JSONRPCRequest{
+			JsonRPC: "2.0",
+			ID:      1,
+			Method:  "tools/call",
+			Params:  types.MCPToolCallParams{Name: "get-file", Arguments: arguments},
+		})
+		var response struct {
+			Result types.MCPToolCallResult `json:"result"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Result
+	}
+
+	for _, filePath := range []string{"internal/cache/cache.go", "./internal/cache/cache.go", "/src/app/internal/cache/cache.go"} {
+		result := call(map[string]interface{}{"library-id": "app", "path": filePath})
+		if result.IsError || result.Content[0].Text != content {
+			t.Errorf("Expected the exact content for %s, got error=%v and %d bytes", filePath, result.IsError, len(result.Content[0].Text))
+		}
+	}
+
+	result := call(map[string]interface{}{"library-id": "app", "path": "cache/cache.go"})
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "Similar files: internal/cache/cache.go") {
+		t.Errorf("Expected a suggestion for a partial path, got %+v", result)
+	}
+	if result := call(map[string]interface{}{"library-id": "missing", "path": "cache.go"}); !result.IsError {
+		t.Error("Expected an error for an unknown repository")
+	}
+}
+
+// ************************************************************************************************
+// Test that grep-repository renders matching lines grep-style
+func TestHandleGrepRepository(t *testing.T) {
+	server, err := NewServer(&types.Config{}, &mockCache{}, search.NewEngine().Indexed())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server.UpdateRepository(&types.RepositoryIndex{ID: "app", Files: map[string]types.IndexedFile{
+		"server.go": {Path: "server.go", Language: "go", Content: "package app\n\nfunc Start() {}\n\n\n\nfunc Stop() {}"},
+	}})
+
+	recorder := httptest.NewRecorder()
+	server.handleToolsCall(context.Background(), recorder, types.JSONRPCRequest{
+		JsonRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params: types.MCPToolCallParams{Name: "grep-repository", Arguments: map[string]interface{}{
+			"library-id": "app", "pattern": "^func St", "contextLines": 1,
+		}},
+	})
+	var response struct {
+		Result types.MCPToolCallResult `json:"result"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Result.IsError {
+		t.Fatalf("Expected matches, got %q", response.Result.Content[0].Text)
+	}
+	if text := response.Result.Content[0].Text; !strings.Contains(text, "2-\n3:func Start() {}\n4-\n--\n6-\n7:func Stop() {}\n") {
+		t.Errorf("Expected grep-style lines, got:\n%s", text)
+	}
+}
+
+// ************************************************************************************************
+// blockingIndexer is a RepositoryIndexer whose runs end when released, failing for "broken".
+type blockingIndexer struct {
+	started chan string
+	release chan struct{}
+}
+
+func (b *blockingIndexer) IndexRepository(alias string) error {
+	b.started <- alias
+	<-b.release
+	if alias == "broken" {
+		return fmt.Errorf("clone failed")
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// Test that indexing jobs run one at a time, are not duplicated and report their outcome
+func TestIndexJobs(t *testing.T) {
+	config := &types.Config{Repositories: map[string]types.RepositoryConfig{
+		"app":      {Indexing: types.IndexingConfig{Enabled: true}},
+		"broken":   {Indexing: types.IndexingConfig{Enabled: true}},
+		"disabled": {Disabled: true, Indexing: types.IndexingConfig{Enabled: true}},
+	}}
+	server, err := NewServer(config, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if _, _, err := server.startIndexJob("app"); err == nil {
+		t.Error("Expected an error without a repository indexer")
+	}
+	indexer := &blockingIndexer{started: make(chan string, 2), release: make(chan struct{})}
+	server.SetRepositoryIndexer(indexer)
+
+	for _, alias := range []string{"missing", "disabled"} {
+		if _, _, err := server.startIndexJob(alias); err == nil {
+			t.Errorf("Expected %s to be refused", alias)
+		}
+	}
+
+	first, started, err := server.startIndexJob("app")
+	if err != nil || !started || first.Status != indexJobQueued {
+		t.Fatalf("Expected a queued job, got %+v, started=%v, err=%v", first, started, err)
+	}
+	<-indexer.started
+	if again, started, _ := server.startIndexJob("app"); started || again.ID != first.ID || again.Status != indexJobRunning {
+		t.Errorf("Expected the running job to be returned, got %+v, started=%v", again, started)
+	}
+	second, _, _ := server.startIndexJob("broken")
+	if job, _ := server.indexJobs.get(second.ID); job.Status != indexJobQueued {
+		t.Errorf("Expected the second job to wait for the first, got %s", job.Status)
+	}
+
+	indexer.release <- struct{}{}
+	<-indexer.started
+	indexer.release <- struct{}{}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, _ := server.indexJobs.get(second.ID)
+		if job.Status == indexJobFailed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the second job to fail, got %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if job, _ := server.indexJobs.get(first.ID); job.Status != indexJobSucceeded {
+		t.Errorf("Expected the first job to succeed, got %+v", job)
+	}
+	text := formatIndexJobs(server.indexJobs.list())
+	if !strings.Contains(text, "| index-2 | broken | failed |") || !strings.Contains(text, "| clone failed |") || strings.Index(text, "index-2") > strings.Index(text, "index-1") {
+		t.Errorf("Expected the failed job listed first with its error, got:\n%s", text)
+	}
+}
+
+// ************************************************************************************************
+// progressIndexer is a ContextRepositoryIndexer reporting two repositories once released.
+type progressIndexer struct {
+	release chan struct{}
+}
+
+func (p *progressIndexer) IndexRepository(alias string) error {
+	return p.IndexRepositoryContext(context.Background(), alias, nil)
+}
+
+func (p *progressIndexer) IndexRepositoryContext(ctx context.Context, alias string, progress types.ProgressFunc) error {
+	<-p.release
+	progress(1, 2, "Indexed app-a: 3 files, 3 files in total")
+	progress(2, 2, "Indexed app-b: 4 files, 7 files in total")
+	return nil
+}
+
+// ************************************************************************************************
+// Test that tool calls with a progress token receive increasing progress notifications
+func TestProgressNotifications(t *testing.T) {
+	config := &types.Config{Repositories: map[string]types.RepositoryConfig{
+		"app": {Indexing: types.IndexingConfig{Enabled: true}},
+	}}
+	server, err := NewServer(config, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	indexer := &progressIndexer{release: make(chan struct{})}
+	server.SetRepositoryIndexer(indexer)
+
+	// Without a progress token nothing is reported
+	recorder := httptest.NewRecorder()
+	streaming := &streamingResponseWriter{ResponseWriter: recorder}
+	if server.progressFunc(withProgressToken(streaming, nil)) != nil {
+		t.Error("Expected no progress function without a progress token")
+	}
+	server.notifyProgress(streaming, 1, 2, "dropped")
+	if streaming.stream != nil {
+		t.Error("Expected no notification without a progress token")
+	}
+
+	recorder = httptest.NewRecorder()
+	streaming = &streamingResponseWriter{ResponseWriter: recorder}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.handleToolsCall(context.Background(), streaming, types.JSONRPCRequest{ID: 1, Params: types.MCPToolCallParams{
+			Name:      "index-repository",
+			Arguments: map[string]interface{}{"repository": "app", "wait": true},
+			Meta:      &types.MCPRequestMeta{ProgressToken: "index-app"},
+		}})
+	}()
+
+	// Release the indexer once the call waits for its job
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		server.indexJobs.mu.Lock()
+		waiting := len(server.indexJobs.watchers["index-1"])
+		server.indexJobs.mu.Unlock()
+		if waiting > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the call to wait for its job")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(indexer.release)
+	<-done
+	streaming.finish()
+
+	events := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n\n")
+	if len(events) != 3 {
+		t.Fatalf("Expected two progress notifications and a response, got %q", recorder.Body.String())
+	}
+	for index, event := range events[:2] {
+		expected := fmt.Sprintf(`"progress":%d,"progressToken":"index-app","total":2`, index+1)
+		if !strings.Contains(event, `"method":"notifications/progress"`) || !strings.Contains(event, expected) {
+			t.Errorf("Expected progress notification %d, got %q", index+1, event)
+		}
+	}
+	if !strings.Contains(events[1], "7 files in total") {
+		t.Errorf("Expected the files indexed in the message, got %q", events[1])
+	}
+	if !strings.Contains(events[2], "Indexing job index-1 for repository app succeeded") || !strings.Contains(events[2], "2/2 Indexed app-b") {
+		t.Errorf("Expected the ended job in the response, got %q", events[2])
+	}
+
+	// Progress must increase
+	recorder = httptest.NewRecorder()
+	tracked := withProgressToken(&streamingResponseWriter{ResponseWriter: recorder}, &types.MCPRequestMeta{ProgressToken: 7})
+	server.notifyProgress(tracked, 1, 0, "")
+	server.notifyProgress(tracked, 1, 0, "repeated")
+	server.notifyProgress(tracked, 2, 0, "")
+	if count := strings.Count(recorder.Body.String(), "notifications/progress"); count != 2 {
+		t.Errorf("Expected 2 progress notifications, got %d:\n%s", count, recorder.Body.String())
+	}
+	if strings.Contains(recorder.Body.String(), `"total"`) {
+		t.Errorf("Expected no total when unknown, got %s", recorder.Body.String())
+	}
+}
+
+// ************************************************************************************************
+// cancellableIndexer is a ContextRepositoryIndexer running until its context is cancelled.
+type cancellableIndexer struct {
+	started chan string
+}
+
+func (c *cancellableIndexer) IndexRepository(alias string) error {
+	return c.IndexRepositoryContext(context.Background(), alias, nil)
+}
+
+func (c *cancellableIndexer) IndexRepositoryContext(ctx context.Context, alias string, progress types.ProgressFunc) error {
+	c.started <- alias
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// ************************************************************************************************
+// Test that notifications/cancelled cancels a tool call and the indexing job it queued
+func TestRequestCancellation(t *testing.T) {
+	config := &types.Config{Repositories: map[string]types.RepositoryConfig{
+		"app": {Indexing: types.IndexingConfig{Enabled: true}},
+	}}
+	server, err := NewServer(config, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	indexer := &cancellableIndexer{started: make(chan string, 1)}
+	server.SetRepositoryIndexer(indexer)
+
+	recorder := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.handleJSONRPCRequest(context.Background(), recorder, types.JSONRPCRequest{JsonRPC: "2.0", ID: 7, Method: "tools/call", Params: types.MCPToolCallParams{
+			Name:      "index-repository",
+			Arguments: map[string]interface{}{"repository": "app", "wait": true},
+		}})
+	}()
+	<-indexer.started
+
+	// Cancelling an unknown request is ignored
+	for _, requestID := range []interface{}{8, 7} {
+		notification := httptest.NewRecorder()
+		server.handleJSONRPCRequest(context.Background(), notification, types.JSONRPCRequest{JsonRPC: "2.0", Method: "notifications/cancelled", Params: map[string]interface{}{"requestId": requestID, "reason": "User requested"}})
+		if notification.Code != http.StatusAccepted || notification.Body.Len() != 0 {
+			t.Errorf("Expected an empty 202 response to the notification, got %d %q", notification.Code, notification.Body.String())
+		}
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the cancelled call to return")
+	}
+	if !strings.Contains(recorder.Body.String(), "Stopped waiting for indexing job index-1") {
+		t.Errorf("Expected the call to stop waiting, got %s", recorder.Body.String())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, _ := server.indexJobs.get("index-1")
+		if job.Status == indexJobFailed {
+			if !strings.Contains(job.Error, "context canceled") {
+				t.Errorf("Expected the job to be cancelled, got %q", job.Error)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the queued job to be cancelled, got %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	server.inFlightMu.Lock()
+	defer server.inFlightMu.Unlock()
+	if len(server.inFlight) != 0 {
+		t.Errorf("Expected no request left in flight, got %v", server.inFlight)
+	}
+}
+
+// ************************************************************************************************
+// Test that prompts are listed and filled with the indexed documentation
+func TestPrompts(t *testing.T) {
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"app": {ID: "app", Files: map[string]types.IndexedFile{
+			"README.md": {Path: "README.md", Content: "# App\n\nStores values."},
+		}, Metadata: map[string]interface{}{
+			"api_summary": "# API Summary: app\n\nfunc Open() *Store",
+			types.SymbolsMetadataKey: []types.Symbol{
+				{Name: "Open", Kind: "func", Package: "example.com/app", Signature: "func Open() *Store", Doc: "Open opens the store.", File: "app.go", Line: 3},
+			},
+		}},
+	}}
+	server, err := NewServer(&types.Config{}, cache, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	call := func(method string, params interface{}) map[string]interface{} {
+		recorder := httptest.NewRecorder()
+		server.handleJSONRPCRequest(context.Background(), recorder, types.JSONRPCRequest{JsonRPC: "2.0", ID: 1, Method: method, Params: params})
+		var response map[string]interface{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	initialize := call("initialize", map[string]interface{}{"protocolVersion": "2025-03-26"})
+	if capabilities, _ := initialize["result"].(map[string]interface{})["capabilities"].(map[string]interface{}); capabilities["prompts"] == nil {
+		t.Errorf("Expected the prompts capability, got %v", initialize["result"])
+	}
+	if list := fmt.Sprint(call("prompts/list", nil)["result"]); !strings.Contains(list, "summarize-repository") || !strings.Contains(list, "explain-api") {
+		t.Errorf("Expected both prompts to be listed, got %s", list)
+	}
+
+	tests := []struct {
+		name      string
+		arguments map[string]string
+		want      []string
+	}{
+		{"summarize-repository", map[string]string{"library-id": "app"}, []string{"Summarize the repository app", "Stores values.", "func Open() *Store"}},
+		{"explain-api", map[string]string{"library-id": "app", "symbol": "Open"}, []string{"Explain the API Open", "Open opens the store.", "Declared in app.go:3"}},
+	}
+	for _, tt := range tests {
+		response := call("prompts/get", types.MCPPromptGetParams{Name: tt.name, Arguments: tt.arguments})
+		text := fmt.Sprint(response["result"])
+		for _, want := range tt.want {
+			if !strings.Contains(text, want) {
+				t.Errorf("Expected %q in prompt %s, got %s", want, tt.name, text)
+			}
+		}
+	}
+
+	for _, params := range []types.MCPPromptGetParams{
+		{Name: "unknown", Arguments: map[string]string{"library-id": "app"}},
+		{Name: "explain-api", Arguments: map[string]string{"library-id": "app"}},
+		{Name: "summarize-repository", Arguments: map[string]string{"library-id": "missing"}},
+	} {
+		if response := call("prompts/get", params); response["error"] == nil {
+			t.Errorf("Expected an error for %+v, got %v", params, response["result"])
+		}
+	}
+}
+
+// ************************************************************************************************
+// recordingSink is a messageSink collecting the messages sent to it.
+type recordingSink struct {
+	messages chan string
+}
+
+func (r *recordingSink) send(data []byte) error {
+	r.messages <- string(data)
+	return nil
+}
+
+// ************************************************************************************************
+// Test that log messages reach clients at or above the level they set
+func TestClientLogging(t *testing.T) {
+	server, err := NewServer(&types.Config{Server: types.ServerConfig{LogLevel: "warning"}}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	defer server.Stop()
+	sink := &recordingSink{messages: make(chan string, 10)}
+	server.streams[sink] = nil
+	go server.forwardClientLogs()
+
+	setLevel := func(level string) map[string]interface{} {
+		recorder := httptest.NewRecorder()
+		server.handleJSONRPCRequest(context.Background(), recorder, types.JSONRPCRequest{JsonRPC: "2.0", ID: 1, Method: "logging/setLevel", Params: map[string]interface{}{"level": level}})
+		var response map[string]interface{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+	receive := func() string {
+		select {
+		case message := <-sink.messages:
+			return message
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected a notification")
+			return ""
+		}
+	}
+
+	// The server log level applies until the client sets one
+	server.broadcastMessage("info", "Indexed app")
+	server.broadcastMessage("warning", "Index of app is stale")
+	if message := receive(); !strings.Contains(message, `"level":"warning"`) || !strings.Contains(message, "Index of app is stale") {
+		t.Errorf("Expected only the warning, got %s", message)
+	}
+
+	if response := setLevel("debug"); response["error"] != nil {
+		t.Fatalf("Expected the level to be set, got %v", response["error"])
+	}
+	logger := slog.New(server.LogHandler())
+	logger.Warn("Failed to expand glob", "glob", "repos/*")
+	if message := receive(); !strings.Contains(message, `"level":"warning"`) || !strings.Contains(message, `"data":"Failed to expand glob glob=repos/*"`) {
+		t.Errorf("Expected the log message as a warning with its attributes, got %s", message)
+	}
+
+	if response := setLevel("verbose"); response["error"] == nil {
+		t.Error("Expected an unknown level to be refused")
+	}
+	setLevel("error")
+	logger.Info("Handling tools/call request")
+	server.broadcastMessage("error", "Indexing job index-1 failed")
+	if message := receive(); !strings.Contains(message, "index-1 failed") {
+		t.Errorf("Expected only the error, got %s", message)
+	}
+}
+
+// ************************************************************************************************
+// Test that HTTP clients get their own session, with its protocol version and log level
+func TestSessions(t *testing.T) {
+	server, err := NewServer(&types.Config{Server: types.ServerConfig{LogLevel: "warning"}}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(server.handleMCPEndpoint))
+	defer httpServer.Close()
+	defer server.Stop()
+
+	post := func(id string, headers map[string]string, req types.JSONRPCRequest) *http.Response {
+		body, _ := json.Marshal(req)
+		request, _ := http.NewRequest(http.MethodPost, httpServer.URL, bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if id != "" {
+			request.Header.Set(sessionHeader, id)
+		}
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Failed to send %s: %v", req.Method, err)
+		}
+		response.Body.Close()
+		return response
+	}
+	initialize := func(version string) string {
+		response := post("", nil, types.JSONRPCRequest{JsonRPC: "2.0", ID: 1, Method: "initialize", Params: types.MCPInitializeRequest{ProtocolVersion: version}})
+		id := response.Header.Get(sessionHeader)
+		if id == "" {
+			t.Fatal("Expected a session ID in the initialize response")
+		}
+		return id
+	}
+
+	first := initialize("2025-03-26")
+	second := initialize("2024-11-05")
+	if first == second {
+		t.Fatalf("Expected distinct sessions, got %s twice", first)
+	}
+
+	// The log level set by a client applies to its session only
+	setLevel := types.JSONRPCRequest{JsonRPC: "2.0", ID: 2, Method: "logging/setLevel", Params: map[string]interface{}{"level": "debug"}}
+	if response := post(first, nil, setLevel); response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the level to be set, got status %d", response.StatusCode)
+	}
+	firstSession, _ := server.lookupSession(first)
+	secondSession, _ := server.lookupSession(second)
+	firstSink := &recordingSink{messages: make(chan string, 10)}
+	secondSink := &recordingSink{messages: make(chan string, 10)}
+	server.streams[firstSink] = firstSession
+	server.streams[secondSink] = secondSession
+	server.broadcastMessage("debug", "Cache hit for app")
+	if len(firstSink.messages) != 1 || len(secondSink.messages) != 0 {
+		t.Errorf("Expected the debug message in the first session only, got %d and %d", len(firstSink.messages), len(secondSink.messages))
+	}
+	if !server.clientLogEnabled("warning") || server.clientLogEnabled("info") {
+		t.Error("Expected the server-wide log level to stay at warning")
+	}
+
+	// Requests must use the protocol version negotiated by their session
+	if response := post(second, map[string]string{"MCP-Protocol-Version": "2025-03-26"}, setLevel); response.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a protocol version mismatch to be refused, got status %d", response.StatusCode)
+	}
+
+	// A terminated session is unknown
+	request, _ := http.NewRequest(http.MethodDelete, httpServer.URL, nil)
+	request.Header.Set(sessionHeader, first)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Failed to terminate the session: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected the session to be terminated, got status %d", response.StatusCode)
+	}
+	if response := post(first, nil, setLevel); response.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected an unknown session to be refused, got status %d", response.StatusCode)
+	}
+}
+
+// ************************************************************************************************
+// Test that the MCP endpoints require a configured API key when keys are set
+func TestWithAuthentication(t *testing.T) {
+	server, err := NewServer(&types.Config{Server: types.ServerConfig{Auth: types.ServerAuth{APIKeys: []string{"first-key-0123456789", "second-key-0123456789"}}}}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	handler := server.withAuthentication(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		wantStatus    int
+		wantChallenge string
+	}{
+		{"Missing header", http.MethodPost, "", http.StatusUnauthorized, `Bearer realm="repomix-mcp"`},
+		{"Wrong scheme", http.MethodPost, "Basic c2Vjb25kLWtleQ==", http.StatusUnauthorized, `Bearer realm="repomix-mcp"`},
+		{"Invalid key", http.MethodPost, "Bearer first-key", http.StatusUnauthorized, `Bearer realm="repomix-mcp", error="invalid_token"`},
+		{"First key", http.MethodPost, "Bearer first-key-0123456789", http.StatusOK, ""},
+		{"Second key, lowercase scheme", http.MethodPost, "bearer second-key-0123456789", http.StatusOK, ""},
+		{"CORS preflight", http.MethodOptions, "", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, "/mcp", nil)
+			if tt.authorization != "" {
+				request.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			handler(recorder, request)
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, recorder.Code)
+			}
+			if challenge := recorder.Header().Get("WWW-Authenticate"); challenge != tt.wantChallenge {
+				t.Errorf("Expected challenge %q, got %q", tt.wantChallenge, challenge)
+			}
+		})
+	}
+
+	// Without keys the endpoints stay open
+	open, err := NewServer(&types.Config{}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	recorder := httptest.NewRecorder()
+	open.withAuthentication(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})(recorder, httptest.NewRequest(http.MethodPost, "/mcp", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected an open endpoint without keys, got status %d", recorder.Code)
+	}
+}
+
+// ************************************************************************************************
+// Test that access tokens of the identity provider are validated and restrict the repositories
+func TestTokenAuthentication(t *testing.T) {
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	var provider *httptest.Server
+	provider = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"jwks_uri": provider.URL + "/jwks"})
+		case "/jwks":
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+				"kty": "EC", "kid": "key-1", "use": "sig", "crv": "P-256",
+				"x": base64.RawURLEncoding.EncodeToString(signingKey.X.FillBytes(make([]byte, 32))),
+				"y": base64.RawURLEncoding.EncodeToString(signingKey.Y.FillBytes(make([]byte, 32))),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer provider.Close()
+
+	sign := func(key *ecdsa.PrivateKey, algorithm string, claims map[string]interface{}) string {
+		header, _ := json.Marshal(map[string]string{"alg": algorithm, "kid": "key-1", "typ": "JWT"})
+		payload, _ := json.Marshal(claims)
+		input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(input))
+		r, s, _ := ecdsa.Sign(rand.Reader, key, digest[:])
+		signature := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+	}
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		base := map[string]interface{}{
+			"iss":   provider.URL,
+			"aud":   []string{"repomix"},
+			"sub":   "alice",
+			"scope": "openid team-a",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		for name, value := range changes {
+			base[name] = value
+		}
+		return base
+	}
+
+	server, err := NewServer(&types.Config{
+		Repositories: map[string]types.RepositoryConfig{
+			"billing":  {Scopes: []string{"team-a"}},
+			"payroll":  {Scopes: []string{"team-b", "admin"}},
+			"handbook": {},
+		},
+		Workspaces: map[string]types.WorkspaceConfig{
+			"finance": {Repositories: []string{"billing", "payroll"}},
+		},
+		Server: types.ServerConfig{Auth: types.ServerAuth{
+			APIKeys:  []string{"static-key-0123456789"},
+			Issuer:   provider.URL,
+			Audience: "repomix",
+		}},
+	}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	var client *principal
+	var restricted context.Context
+	handler := server.withAuthentication(func(w http.ResponseWriter, r *http.Request) {
+		client = principalFromContext(r.Context())
+		restricted = r.Context()
+		w.WriteHeader(http.StatusOK)
+	})
+	authenticate := func(token string) int {
+		client = nil
+		request := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		return recorder.Code
+	}
+
+	rejected := map[string]string{
+		"Expired":         sign(signingKey, "ES256", claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"Other audience":  sign(signingKey, "ES256", claims(map[string]interface{}{"aud": "other"})),
+		"Other issuer":    sign(signingKey, "ES256", claims(map[string]interface{}{"iss": "https://evil.example.com"})),
+		"Other key":       sign(otherKey, "ES256", claims(nil)),
+		"Wrong algorithm": sign(signingKey, "RS256", claims(nil)),
+		"Unsigned":        strings.TrimSuffix(sign(signingKey, "none", claims(nil)), "."),
+	}
+	for name, token := range rejected {
+		if status := authenticate(token); status != http.StatusUnauthorized {
+			t.Errorf("%s: expected the token to be rejected, got status %d", name, status)
+		}
+	}
+
+	if status := authenticate("static-key-0123456789"); status != http.StatusOK || client != nil {
+		t.Errorf("Expected the API key to grant unrestricted access, got status %d and client %v", status, client)
+	}
+	if status := authenticate(sign(signingKey, "ES256", claims(nil))); status != http.StatusOK || client == nil || client.subject != "alice" {
+		t.Fatalf("Expected the token to be accepted for alice, got status %d and client %v", status, client)
+	}
+
+	// The client only sees the repositories of its scopes
+	access := map[string]bool{
+		"billing":                        true,
+		"billing-api":                    true,
+		"payroll":                        false,
+		"payroll-exports":                false,
+		"handbook":                       true,
+		"gomod:github.com/gin-gonic/gin": true,
+		"workspace:finance":              false,
+	}
+	for repositoryID, want := range access {
+		if got := server.canAccessRepository(restricted, repositoryID); got != want {
+			t.Errorf("canAccessRepository(%s) = %v, want %v", repositoryID, got, want)
+		}
+	}
+	if err := server.checkToolAccess(restricted, "get-library-docs", map[string]interface{}{"library-id": "payroll"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected payroll to be reported as not found, got %v", err)
+	}
+	if err := server.checkToolAccess(restricted, "refresh", map[string]interface{}{}); err == nil {
+		t.Error("Expected a restricted client to be refused a full refresh")
+	}
+	if err := server.checkToolAccess(context.Background(), "refresh", map[string]interface{}{}); err != nil {
+		t.Errorf("Expected an unrestricted client to refresh everything, got %v", err)
+	}
+}
+
+// ************************************************************************************************
+// Test that the key set is refreshed once for concurrent callers, known keys being served from
+// the cached set while the fetch runs
+func TestJWTVerifier_RefreshKeys(t *testing.T) {
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	var mu sync.Mutex
+	fetches := 0
+	release := make(chan struct{})
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches++
+		blocked := fetches > 1
+		mu.Unlock()
+		if blocked {
+			<-release
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "EC", "kid": "key-1", "use": "sig", "crv": "P-256",
+			"x": base64.RawURLEncoding.EncodeToString(signingKey.X.FillBytes(make([]byte, 32))),
+			"y": base64.RawURLEncoding.EncodeToString(signingKey.Y.FillBytes(make([]byte, 32))),
+		}}})
+	}))
+	defer provider.Close()
+
+	verifier := newJWTVerifier(types.ServerAuth{JWKSURL: provider.URL})
+	if _, err := verifier.signingKey(context.Background(), "key-1"); err != nil {
+		t.Fatalf("Expected the first fetch to load the key, got %v", err)
+	}
+
+	// Stale keys start a refresh, blocked by the provider
+	verifier.mu.Lock()
+	verifier.fetched = time.Now().Add(-2 * jwksRefreshInterval)
+	verifier.lastAttempt = time.Now().Add(-2 * jwksMinRefreshInterval)
+	verifier.mu.Unlock()
+
+	started := time.Now()
+	if _, err := verifier.signingKey(context.Background(), "key-1"); err != nil || time.Since(started) > time.Second {
+		t.Errorf("Expected the cached key during the refresh, got %v after %v", err, time.Since(started))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := verifier.signingKey(ctx, "key-2"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected an unknown key to wait for the refresh, got %v", err)
+	}
+
+	close(release)
+	verifier.mu.Lock()
+	refreshing := verifier.refreshing
+	verifier.mu.Unlock()
+	if refreshing != nil {
+		<-refreshing
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fetches != 2 {
+		t.Errorf("Expected one refresh for concurrent callers, got %d fetches", fetches)
+	}
+}
+
+// ************************************************************************************************
+// Test that tool calls are limited per client, with a tighter limit on the expensive ones
+func TestRateLimit(t *testing.T) {
+	limiter := newRateLimiter(60, 2)
+	now := time.Now()
+	if limiter.allow("ip:10.0.0.1", now) != 0 || limiter.allow("ip:10.0.0.1", now) != 0 {
+		t.Fatal("Expected the burst to be allowed")
+	}
+	if wait := limiter.allow("ip:10.0.0.1", now); wait <= 0 || wait > time.Second {
+		t.Errorf("Expected the third call to wait up to a second, got %v", wait)
+	}
+	if limiter.allow("ip:10.0.0.2", now) != 0 {
+		t.Error("Expected another client to have its own bucket")
+	}
+	if limiter.allow("ip:10.0.0.1", now.Add(time.Second)) != 0 {
+		t.Error("Expected a token to be added after a second")
+	}
+	if newRateLimiter(0, 5).allow("ip:10.0.0.1", now) != 0 {
+		t.Error("Expected no limit when the rate is 0")
+	}
+
+	server, err := NewServer(&types.Config{Server: types.ServerConfig{RateLimit: types.RateLimitConfig{
+		ToolCallsPerMinute:      3,
+		ExpensiveCallsPerMinute: 1,
+	}}}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	call := func(client, tool string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		server.handleToolsCall(withClientKey(context.Background(), client), recorder, types.JSONRPCRequest{
+			JsonRPC: "2.0",
+			ID:      1,
+			Method:  "tools/call",
+			Params:  map[string]interface{}{"name": tool, "arguments": map[string]interface{}{}},
+		})
+		return recorder
+	}
+
+	if recorder := call("api-key:0", "refresh"); recorder.Code == http.StatusTooManyRequests {
+		t.Fatal("Expected the first refresh to be allowed")
+	}
+	for range 3 {
+		recorder := call("api-key:0", "refresh")
+		if recorder.Code != http.StatusTooManyRequests || recorder.Header().Get("Retry-After") == "" {
+			t.Errorf("Expected further refreshes to be refused with Retry-After, got status %d", recorder.Code)
+		}
+	}
+	// Refused refreshes do not count against the tool calls
+	for range 2 {
+		if recorder := call("api-key:0", "list-libraries"); recorder.Code == http.StatusTooManyRequests {
+			t.Error("Expected cheap calls to stay allowed")
+		}
+	}
+	if recorder := call("api-key:0", "list-libraries"); recorder.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the fourth allowed call to be refused, got status %d", recorder.Code)
+	}
+	if recorder := call("api-key:1", "refresh"); recorder.Code == http.StatusTooManyRequests {
+		t.Error("Expected another client to keep its own limits")
+	}
+}
+
+// ************************************************************************************************
+// Test that Stop refuses new requests, waits for the requests being handled and cancels those
+// still running after the shutdown timeout
+func TestStop(t *testing.T) {
+	server, err := NewServer(&types.Config{Server: types.ServerConfig{ShutdownTimeout: "200ms"}}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	// A request finishing before the timeout is waited for
+	if !server.beginRequest() {
+		t.Fatal("Expected the request to be accepted")
+	}
+	stopped := make(chan struct{})
+	go func() {
+		server.Stop()
+		close(stopped)
+	}()
+	for !server.stopping() {
+		time.Sleep(time.Millisecond)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.handleJSONRPCRequest(context.Background(), recorder, types.JSONRPCRequest{JsonRPC: "2.0", ID: 2, Method: "ping"})
+	if !strings.Contains(recorder.Body.String(), errShuttingDown.Error()) {
+		t.Errorf("Expected new requests to be refused while stopping, got %s", recorder.Body.String())
+	}
+	select {
+	case <-stopped:
+		t.Fatal("Expected Stop to wait for the request being handled")
+	case <-time.After(50 * time.Millisecond):
+	}
+	server.requests.Done()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Stop to return once the request finished")
+	}
+
+	// A request still running after the timeout is cancelled
+	server, err = NewServer(&types.Config{Server: types.ServerConfig{ShutdownTimeout: "50ms"}}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server.beginRequest()
+	ctx, finished := server.trackRequest(context.Background(), 1)
+	go func() {
+		<-ctx.Done()
+		finished()
+		server.requests.Done()
+	}()
+	server.Stop()
+	if ctx.Err() == nil {
+		t.Error("Expected the running request to be cancelled")
+	}
+	server.Stop()
+}
+
+// ************************************************************************************************
+// Test the admin REST API: listing, describing, reindexing and deleting repositories behind the
+// authentication of the MCP endpoints
+func TestAdminAPI(t *testing.T) {
+	config := &types.Config{
+		Server: types.ServerConfig{Auth: types.ServerAuth{APIKeys: []string{"secret"}}},
+		Repositories: map[string]types.RepositoryConfig{
+			"app": {Indexing: types.IndexingConfig{Enabled: true}},
+		},
+	}
+	cache := &mockCache{repos: map[string]*types.RepositoryIndex{
+		"app": {ID: "app", Name: "app", Path: "/src/app", CommitHash: "abc123", Files: map[string]types.IndexedFile{
+			"main.go":   {Path: "main.go", Language: "go"},
+			"README.md": {Path: "README.md", Language: "markdown"},
+		}},
+	}}
+	server, err := NewServer(config, cache, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	indexer := &blockingIndexer{started: make(chan string, 1), release: make(chan struct{})}
+	server.SetRepositoryIndexer(indexer)
+	mux := http.NewServeMux()
+	server.registerAdminRoutes(mux)
+
+	request := func(method, path, key string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, req)
+		var body map[string]interface{}
+		json.Unmarshal(recorder.Body.Bytes(), &body)
+		return recorder, body
+	}
+
+	if recorder, _ := request(http.MethodGet, "/api/v1/repositories", ""); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without API key, got %d", recorder.Code)
+	}
+	recorder, body := request(http.MethodGet, "/api/v1/repositories", "secret")
+	if repositories, _ := body["repositories"].([]interface{}); recorder.Code != http.StatusOK || len(repositories) != 1 {
+		t.Fatalf("Expected one repository, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	recorder, body = request(http.MethodGet, "/api/v1/repositories/app", "secret")
+	if languages, _ := body["languages"].(map[string]interface{}); recorder.Code != http.StatusOK || body["files"] != 2.0 || languages["go"] != 1.0 || body["alias"] != "app" {
+		t.Errorf("Expected the repository detail, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder, body := request(http.MethodGet, "/api/v1/repositories/missing", "secret"); recorder.Code != http.StatusNotFound || body["error"] == nil {
+		t.Errorf("Expected 404 with an error, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder, body = request(http.MethodPost, "/api/v1/repositories/app/reindex", "secret")
+	if recorder.Code != http.StatusAccepted || body["status"] != indexJobQueued || recorder.Header().Get("Location") != "/api/v1/jobs/"+body["id"].(string) {
+		t.Fatalf("Expected a queued job, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	<-indexer.started
+	if recorder, body := request(http.MethodGet, recorder.Header().Get("Location"), "secret"); recorder.Code != http.StatusOK || body["status"] != indexJobRunning {
+		t.Errorf("Expected the running job, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	indexer.release <- struct{}{}
+
+	if recorder, _ := request(http.MethodGet, "/api/v1/cache/stats", "secret"); recorder.Code != http.StatusOK {
+		t.Errorf("Expected the cache statistics, got %d", recorder.Code)
+	}
+	if recorder, _ := request(http.MethodDelete, "/api/v1/repositories/app", "secret"); recorder.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 on delete, got %d", recorder.Code)
+	}
+	if _, exists := cache.repos["app"]; exists {
+		t.Error("Expected the repository to be deleted from the cache")
+	}
+	if recorder, _ := request(http.MethodGet, "/api/v1/repositories/app", "secret"); recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 once deleted, got %d", recorder.Code)
+	}
+}
+
+// ************************************************************************************************
+// Test that the HTTPS server only accepts clients with a certificate issued by the client CA,
+// rate limited by the name of their certificate
+func TestClientCertificates(t *testing.T) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Workstations CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+	caPath := filepath.Join(t.TempDir(), "clients-ca.pem")
+	if err := os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600); err != nil {
+		t.Fatalf("Failed to write CA bundle: %v", err)
+	}
+
+	clientKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	clientDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "workstation-1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCert, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create client certificate: %v", err)
+	}
+	clientCert := tls.Certificate{Certificate: [][]byte{clientDER}, PrivateKey: clientKey}
+
+	if err := ConfigureClientAuth(&tls.Config{}, filepath.Join(t.TempDir(), "missing.pem"), true); err == nil {
+		t.Error("Expected a missing CA bundle to be refused")
+	}
+
+	var clientKeys []string
+	httpsServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientKeys = append(clientKeys, requestClientKey(r))
+	}))
+	httpsServer.TLS = &tls.Config{}
+	if err := ConfigureClientAuth(httpsServer.TLS, caPath, true); err != nil {
+		t.Fatalf("ConfigureClientAuth failed: %v", err)
+	}
+	httpsServer.StartTLS()
+	defer httpsServer.Close()
+
+	client := httpsServer.Client()
+	if response, err := client.Get(httpsServer.URL); err == nil {
+		response.Body.Close()
+		t.Error("Expected a client without certificate to be refused")
+	}
+	client.Transport.(*http.Transport).TLSClientConfig.Certificates = []tls.Certificate{clientCert}
+	response, err := client.Get(httpsServer.URL)
+	if err != nil {
+		t.Fatalf("Expected a client with an issued certificate to be accepted: %v", err)
+	}
+	response.Body.Close()
+	if len(clientKeys) != 1 || clientKeys[0] != "cert:workstation-1" {
+		t.Errorf("Expected the client to be identified by its certificate, got %v", clientKeys)
+	}
+}
+
+// ************************************************************************************************
+// Test that the ACME manager only requests certificates for the configured hostnames, stores
+// them in the cache directory and answers the challenges of the CA
+func TestNewACMEManager(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), "acme")
+	manager := NewACMEManager(types.ACMEConfig{
+		Enabled:      true,
+		Hostnames:    []string{"mcp.example.com"},
+		Email:        "ops@example.com",
+		DirectoryURL: "https://acme-staging-v02.api.letsencrypt.org/directory",
+		CacheDir:     cacheDir,
+	})
+
+	if err := manager.HostPolicy(context.Background(), "mcp.example.com"); err != nil {
+		t.Errorf("Expected the configured hostname to be allowed: %v", err)
+	}
+	if err := manager.HostPolicy(context.Background(), "other.example.com"); err == nil {
+		t.Error("Expected other hostnames to be refused")
+	}
+	if dir, ok := manager.Cache.(autocert.DirCache); !ok || string(dir) != cacheDir {
+		t.Errorf("Expected certificates to be stored in %s, got %v", cacheDir, manager.Cache)
+	}
+	if manager.Client == nil || manager.Client.DirectoryURL != "https://acme-staging-v02.api.letsencrypt.org/directory" {
+		t.Errorf("Expected the configured ACME directory, got %+v", manager.Client)
+	}
+
+	tlsConfig := ACMETLSConfig(manager)
+	if tlsConfig.GetCertificate == nil || tlsConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("Expected certificates to be served by the manager from TLS 1.2, got %+v", tlsConfig)
+	}
+	foundALPN := false
+	for _, proto := range tlsConfig.NextProtos {
+		foundALPN = foundALPN || proto == acme.ALPNProto
+	}
+	if !foundALPN {
+		t.Errorf("Expected the TLS-ALPN-01 challenges to be answered, got protocols %v", tlsConfig.NextProtos)
+	}
+
+	// HTTP-01 challenges are answered by the manager, other requests reach the server
+	handler := manager.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	for path, expected := range map[string]int{
+		"/health":                           http.StatusTeapot,
+		"/.well-known/acme-challenge/token": http.StatusNotFound,
+	} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://mcp.example.com"+path, nil))
+		if recorder.Code != expected {
+			t.Errorf("Expected status %d for %s, got %d", expected, path, recorder.Code)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that expensive tool calls wait for a worker of the pool and are refused as busy when none
+// becomes free in time, while nested executions reuse the worker of their call
+func TestWorkerPool(t *testing.T) {
+	if pool := newWorkerPool(0, time.Second); pool != nil {
+		t.Error("Expected no pool when unlimited")
+	}
+
+	server, err := NewServer(&types.Config{Server: types.ServerConfig{MaxConcurrentTools: 1, ToolQueueTimeout: "50ms"}}, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	workerCtx, release, err := server.acquireWorker(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("Expected a free worker: %v", err)
+	}
+	if _, nestedRelease, err := server.acquireWorker(workerCtx, "nested"); err != nil {
+		t.Errorf("Expected a nested execution to reuse the worker: %v", err)
+	} else {
+		nestedRelease()
+	}
+
+	call := func(tool string) types.JSONRPCResponse {
+		recorder := httptest.NewRecorder()
+		server.handleToolsCall(context.Background(), recorder, types.JSONRPCRequest{
+			JsonRPC: "2.0",
+			ID:      1,
+			Method:  "tools/call",
+			Params:  map[string]interface{}{"name": tool, "arguments": map[string]interface{}{}},
+		})
+		var response types.JSONRPCResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Invalid response %q: %v", recorder.Body.String(), err)
+		}
+		return response
+	}
+
+	start := time.Now()
+	if response := call("refresh"); response.Error == nil || response.Error.Message != "Server busy" {
+		t.Errorf("Expected refresh to be refused as busy, got %+v", response)
+	}
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Errorf("Expected the call to wait for a worker, refused after %v", waited)
+	}
+	if response := call("list-libraries"); response.Error != nil {
+		t.Errorf("Expected cheap calls to run without a worker, got %+v", response.Error)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := server.acquireWorker(cancelled, "cancelled"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled execution to stop waiting, got %v", err)
+	}
+
+	release()
+	if response := call("refresh"); response.Error != nil && response.Error.Message == "Server busy" {
+		t.Errorf("Expected a freed worker to run refresh, got %+v", response.Error)
+	}
+}
+
+// ************************************************************************************************
+// Test that a watched repository is re-indexed once its files stop changing, new subdirectories
+// included, and that changes under .git are ignored
+func TestRepositoryWatcher(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := &types.Config{Repositories: map[string]types.RepositoryConfig{
+		"app":       {Type: types.RepositoryTypeLocal, Path: root, Watch: true, Indexing: types.IndexingConfig{Enabled: true}},
+		"unwatched": {Type: types.RepositoryTypeLocal, Path: t.TempDir(), Indexing: types.IndexingConfig{Enabled: true}},
+	}}
+	config.Server.WatchDebounce = "100ms"
+	server, err := NewServer(config, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	indexer := &blockingIndexer{started: make(chan string, 2), release: make(chan struct{})}
+	server.SetRepositoryIndexer(indexer)
+	if err := server.startWatcher(); err != nil {
+		t.Fatalf("Failed to start watcher: %v", err)
+	}
+	defer server.stopWatcher()
+
+	expectIndexed := func(change string) {
+		t.Helper()
+		select {
+		case alias := <-indexer.started:
+			if alias != "app" {
+				t.Errorf("Expected app to be re-indexed after %s, got %s", change, alias)
+			}
+			indexer.release <- struct{}{}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected app to be re-indexed after %s", change)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(root, ".git", "index"), []byte("index"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(filepath.Join(root, "main.go"), []byte(fmt.Sprintf("package main // %d", i)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectIndexed("writing main.go")
+
+	if err := os.Mkdir(filepath.Join(root, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	expectIndexed("creating pkg")
+	if err := os.WriteFile(filepath.Join(root, "pkg", "lib.go"), []byte("package pkg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expectIndexed("writing pkg/lib.go")
+
+	if err := os.WriteFile(filepath.Join(root, ".git", "HEAD"), []byte("ref: refs/heads/main"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case alias := <-indexer.started:
+		t.Errorf("Expected changes under .git to be ignored, %s was re-indexed", alias)
+		indexer.release <- struct{}{}
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+// ************************************************************************************************
+// Test that repositories with a refresh interval are re-indexed on schedule until the server stops
+func TestScheduledRefresh(t *testing.T) {
+	config := &types.Config{Repositories: map[string]types.RepositoryConfig{
+		"upstream": {Type: types.RepositoryTypeRemote, RefreshInterval: "50ms", Indexing: types.IndexingConfig{Enabled: true}},
+		"manual":   {Type: types.RepositoryTypeRemote, Indexing: types.IndexingConfig{Enabled: true}},
+		"disabled": {Type: types.RepositoryTypeRemote, RefreshInterval: "50ms", Disabled: true, Indexing: types.IndexingConfig{Enabled: true}},
+	}}
+	server, err := NewServer(config, &mockCache{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	indexer := &blockingIndexer{started: make(chan string, 2), release: make(chan struct{})}
+	server.SetRepositoryIndexer(indexer)
+	server.startScheduler()
+
+	for run := 1; run <= 2; run++ {
+		select {
+		case alias := <-indexer.started:
+			if alias != "upstream" {
+				t.Errorf("Expected upstream to be re-indexed, got %s", alias)
+			}
+			indexer.release <- struct{}{}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected scheduled re-indexing run %d", run)
+		}
+	}
+
+	close(server.done)
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case alias := <-indexer.started:
+		indexer.release <- struct{}{}
+		select {
+		case alias = <-indexer.started:
+			t.Errorf("Expected no re-indexing once the server stopped, %s was re-indexed", alias)
+			indexer.release <- struct{}{}
+		case <-time.After(200 * time.Millisecond):
+		}
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,223 @@
+// ************************************************************************************************
+// Package mcp provides the client sessions of the MCP server.
+// An HTTP client receives a session ID in the Mcp-Session-Id header of its initialize response
+// and sends it back with its next requests; a WebSocket connection is a session by itself.
+// Sessions keep what a client negotiated, its protocol version and capabilities, and its log
+// level, so concurrent clients do not change each other's settings. Requests without a session
+// ID share the server-wide settings, as before sessions existed.
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// sessionHeader is the HTTP header carrying the session ID, per the Streamable HTTP transport.
+const sessionHeader = "Mcp-Session-Id"
+
+// ************************************************************************************************
+// Session limits: idle sessions are forgotten after sessionIdleTimeout, and the least recently
+// used ones beyond maxSessions, since clients may never terminate them.
+const (
+	sessionIdleTimeout = time.Hour
+	maxSessions        = 1000
+)
+
+// ************************************************************************************************
+// session is the state of an MCP client. Its fields are set by initialize; the log level and
+// the last use may change concurrently.
+type session struct {
+	id        string
+	webSocket bool // True for the session of a WebSocket connection
+
+	mu              sync.Mutex
+	protocolVersion string
+	clientInfo      map[string]interface{}
+	capabilities    map[string]interface{}
+
+	logMin   atomic.Int32 // Minimum severity of the log messages sent to the client
+	lastSeen atomic.Int64 // Unix time in nanoseconds of the last request
+}
+
+// ************************************************************************************************
+// sessionContextKey is the context key of the session of a request.
+type sessionContextKey struct{}
+
+// ************************************************************************************************
+// withSession returns a context carrying the session of a request.
+func withSession(ctx context.Context, sess *session) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, sess)
+}
+
+// ************************************************************************************************
+// sessionFromContext returns the session of a request, nil for requests without session.
+func sessionFromContext(ctx context.Context) *session {
+	sess, _ := ctx.Value(sessionContextKey{}).(*session)
+	return sess
+}
+
+// ************************************************************************************************
+// newSession creates a session with the server-wide log level and registers it, forgetting the
+// idle sessions.
+//
+// Returns:
+//   - *session: The session.
+//   - error: An error if no random session ID can be generated.
+func (s *Server) newSession(webSocket bool) (*session, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate a session ID: %w", err)
+	}
+	sess := &session{id: hex.EncodeToString(random), webSocket: webSocket}
+	sess.logMin.Store(s.clientLogMin.Load())
+	sess.lastSeen.Store(time.Now().UnixNano())
+
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	s.forgetIdleSessionsLocked()
+	s.sessions[sess.id] = sess
+	return sess, nil
+}
+
+// ************************************************************************************************
+// forgetIdleSessionsLocked drops the idle HTTP sessions, then the least recently used ones
+// until a new session fits within maxSessions. WebSocket sessions end with their connection.
+// The caller holds s.sessionsMu.
+func (s *Server) forgetIdleSessionsLocked() {
+	idleSince := time.Now().Add(-sessionIdleTimeout).UnixNano()
+	var sessions []*session
+	for id, sess := range s.sessions {
+		if sess.webSocket {
+			continue
+		}
+		if sess.lastSeen.Load() < idleSince {
+			delete(s.sessions, id)
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+	if len(s.sessions) < maxSessions {
+		return
+	}
+	sort.Slice(sessions, func(a, b int) bool {
+		return sessions[a].lastSeen.Load() < sessions[b].lastSeen.Load()
+	})
+	for _, sess := range sessions {
+		if len(s.sessions) < maxSessions {
+			return
+		}
+		delete(s.sessions, sess.id)
+	}
+}
+
+// ************************************************************************************************
+// lookupSession returns a registered session, recording its use.
+//
+// Returns:
+//   - *session: The session.
+//   - bool: False if the session is unknown, terminated or forgotten.
+func (s *Server) lookupSession(id string) (*session, bool) {
+	s.sessionsMu.Lock()
+	sess, exists := s.sessions[id]
+	s.sessionsMu.Unlock()
+	if exists {
+		sess.lastSeen.Store(time.Now().UnixNano())
+	}
+	return sess, exists
+}
+
+// ************************************************************************************************
+// endSession forgets a session.
+func (s *Server) endSession(id string) {
+	s.sessionsMu.Lock()
+	delete(s.sessions, id)
+	s.sessionsMu.Unlock()
+}
+
+// ************************************************************************************************
+// requestSession resolves the session of an HTTP request from its Mcp-Session-Id header. An
+// unknown session is answered with 404 Not Found, so the client starts a new one.
+//
+// Returns:
+//   - *session: The session, nil for requests without session ID.
+//   - bool: False if the request has been answered.
+func (s *Server) requestSession(w http.ResponseWriter, r *http.Request) (*session, bool) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		return nil, true
+	}
+	sess, exists := s.lookupSession(id)
+	if !exists {
+		sendSessionNotFound(w, id)
+		return nil, false
+	}
+	return sess, true
+}
+
+// ************************************************************************************************
+// sendSessionNotFound answers a request naming an unknown session with 404 Not Found and a
+// JSON-RPC error.
+func sendSessionNotFound(w http.ResponseWriter, id string) {
+	response := types.JSONRPCResponse{
+		JsonRPC: "2.0",
+		Error: &types.JSONRPCError{
+			Code:    -32001,
+			Message: "Session not found",
+			Data:    fmt.Sprintf("Unknown or expired session: %s", id),
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Failed to encode JSON-RPC error response", "error", err)
+	}
+}
+
+// ************************************************************************************************
+// handleSessionDelete handles a DELETE request terminating the session of its Mcp-Session-Id
+// header.
+func (s *Server) handleSessionDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		s.sendJSONRPCError(w, nil, -32600, "Invalid Request", fmt.Sprintf("Missing %s header", sessionHeader))
+		return
+	}
+	if _, exists := s.lookupSession(id); !exists {
+		sendSessionNotFound(w, id)
+		return
+	}
+	s.endSession(id)
+	slog.Info("Terminated session", "session", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ************************************************************************************************
+// initialize records what a client negotiated in its initialize request.
+func (sess *session) initialize(protocolVersion string, clientInfo, capabilities map[string]interface{}) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.protocolVersion = protocolVersion
+	sess.clientInfo = clientInfo
+	sess.capabilities = capabilities
+}
+
+// ************************************************************************************************
+// negotiatedVersion returns the protocol version negotiated by the client, empty before
+// initialize.
+func (sess *session) negotiatedVersion() string {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.protocolVersion
+}
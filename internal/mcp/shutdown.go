@@ -0,0 +1,94 @@
+// ************************************************************************************************
+// Package mcp provides the graceful shutdown of the MCP server.
+// Stop refuses new JSON-RPC requests, lets the requests being handled finish within
+// server.shutdownTimeout, cancelling those still running after it, then ends the event streams
+// and WebSocket sessions and shuts the HTTP and HTTPS servers down.
+package mcp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ************************************************************************************************
+// errShuttingDown is the error of the requests received while the server stops.
+var errShuttingDown = errors.New("server is shutting down")
+
+// ************************************************************************************************
+// beginRequest registers a JSON-RPC request being handled, so Stop waits for it. The caller
+// calls s.requests.Done once the request is answered.
+//
+// Returns:
+//   - bool: False if the server is stopping and the request must be refused.
+func (s *Server) beginRequest() bool {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+	if s.draining {
+		return false
+	}
+	s.requests.Add(1)
+	return true
+}
+
+// ************************************************************************************************
+// stopAccepting makes the server refuse new requests, so Stop can wait for those being handled.
+func (s *Server) stopAccepting() {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+	s.draining = true
+}
+
+// ************************************************************************************************
+// stopping reports whether Stop has been called.
+func (s *Server) stopping() bool {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+	return s.draining
+}
+
+// ************************************************************************************************
+// waitRequests waits for the requests being handled to finish, until ctx is done; the requests
+// still running then are cancelled. New requests must be refused first with stopAccepting.
+//
+// Returns:
+//   - bool: True if every request finished in time.
+func (s *Server) waitRequests(ctx context.Context) bool {
+	drained := make(chan struct{})
+	go func() {
+		s.requests.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return true
+	case <-ctx.Done():
+	}
+
+	s.inFlightMu.Lock()
+	slog.Warn("Cancelling the requests still running after the shutdown timeout", "requests", len(s.inFlight))
+	for _, cancel := range s.inFlight {
+		cancel()
+	}
+	s.inFlightMu.Unlock()
+
+	// Cancelled requests answer promptly, do not hold the shutdown for ever on one that does not
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+	}
+	return false
+}
+
+// ************************************************************************************************
+// shutdownHTTPServer shuts an HTTP server down, closing its connections when they are still
+// active once ctx is done.
+func shutdownHTTPServer(ctx context.Context, server *http.Server) {
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("HTTP server shutdown error, closing its connections", "address", server.Addr, "error", err)
+		_ = server.Close()
+	}
+}
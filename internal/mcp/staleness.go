@@ -0,0 +1,44 @@
+// ************************************************************************************************
+// Package mcp provides the staleness note of served documentation.
+// Documentation indexed longer ago than the configured threshold is prefixed with a note
+// giving its age, so agents can caveat answers that rely on it.
+package mcp
+
+import (
+	"fmt"
+	"time"
+)
+
+// ************************************************************************************************
+// defaultStalenessThreshold is the index age above which the note is added when
+// server.stalenessThreshold is not configured.
+const defaultStalenessThreshold = 7 * 24 * time.Hour
+
+// ************************************************************************************************
+// stalenessNote returns the note prepended to documentation indexed at lastUpdated, empty when
+// the index is recent, its time is unknown or the note is disabled with a zero threshold.
+//
+// Returns:
+//   - string: The Markdown note followed by a blank line, or an empty string.
+func (s *Server) stalenessNote(lastUpdated time.Time) string {
+	threshold := parseTimeout(s.config.Server.StalenessThreshold, defaultStalenessThreshold)
+	if threshold == 0 || lastUpdated.IsZero() {
+		return ""
+	}
+
+	age := time.Since(lastUpdated)
+	if age <= threshold {
+		return ""
+	}
+	return fmt.Sprintf("> **Note:** this content was indexed %s ago (%s) and may be out of date.\n\n",
+		formatAge(age), lastUpdated.Format("2006-01-02 15:04"))
+}
+
+// ************************************************************************************************
+// formatAge renders a duration in whole days, or whole hours below two days.
+func formatAge(age time.Duration) string {
+	if age < 48*time.Hour {
+		return fmt.Sprintf("%d hours", int(age.Hours()))
+	}
+	return fmt.Sprintf("%d days", int(age.Hours()/24))
+}
@@ -0,0 +1,321 @@
+// ************************************************************************************************
+// Package mcp provides the Streamable HTTP transport of the MCP server.
+// A POST request from a client accepting text/event-stream is answered with Server-Sent Events
+// as soon as its handler sends a notification, e.g. while a Go module is fetched, the JSON-RPC
+// response being the last event; requests sending no notification get a plain JSON response.
+// A GET request opens a long-lived event stream receiving the server-initiated notifications.
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// eventStreamContentType is the media type of Server-Sent Events.
+const eventStreamContentType = "text/event-stream"
+
+// ************************************************************************************************
+// streamKeepAliveInterval is the interval at which idle GET streams receive a comment, so
+// proxies do not close them.
+var streamKeepAliveInterval = 30 * time.Second
+
+// ************************************************************************************************
+// acceptsEventStream reports whether a request accepts Server-Sent Events.
+func acceptsEventStream(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(accepted, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), eventStreamContentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// ************************************************************************************************
+// messageSink is an open connection receiving the server-initiated notifications: a GET event
+// stream or a WebSocket session.
+type messageSink interface {
+	send(data []byte) error
+}
+
+// ************************************************************************************************
+// notifier is implemented by the response writers of transports able to deliver notifications
+// before the response of the request being handled.
+type notifier interface {
+	notify(data []byte) error
+}
+
+// ************************************************************************************************
+// eventStream writes JSON-RPC messages as Server-Sent Events. It is safe for concurrent use.
+type eventStream struct {
+	mu         sync.Mutex
+	w          http.ResponseWriter
+	controller *http.ResponseController
+	nextID     int
+}
+
+// ************************************************************************************************
+// newEventStream sends the headers of an event stream and flushes them.
+//
+// Returns:
+//   - *eventStream: The stream.
+//   - error: An error if the response cannot be flushed.
+func newEventStream(w http.ResponseWriter) (*eventStream, error) {
+	w.Header().Set("Content-Type", eventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &eventStream{w: w, controller: http.NewResponseController(w)}
+	if err := stream.controller.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to start event stream\n>    %w", err)
+	}
+	return stream, nil
+}
+
+// ************************************************************************************************
+// send writes an encoded JSON-RPC message as a "message" event and flushes it.
+func (e *eventStream) send(data []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.nextID++
+	var event bytes.Buffer
+	event.WriteString(fmt.Sprintf("id: %d\nevent: message\n", e.nextID))
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		event.WriteString("data: " + line + "\n")
+	}
+	event.WriteString("\n")
+
+	if _, err := e.w.Write(event.Bytes()); err != nil {
+		return err
+	}
+	return e.controller.Flush()
+}
+
+// ************************************************************************************************
+// keepAlive writes a comment line, ignored by clients, and flushes it.
+func (e *eventStream) keepAlive() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, err := e.w.Write([]byte(": keep-alive\n\n")); err != nil {
+		return err
+	}
+	return e.controller.Flush()
+}
+
+// ************************************************************************************************
+// streamingResponseWriter is handed to the handler of a POST request accepting an event stream.
+// It switches the response to an event stream on the first notification; the JSON-RPC response
+// the handler writes is then sent as the last event instead of as the body.
+type streamingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+	stream *eventStream
+}
+
+// ************************************************************************************************
+// WriteHeader records the status code, sent with a plain JSON response only.
+func (s *streamingResponseWriter) WriteHeader(status int) {
+	s.status = status
+}
+
+// ************************************************************************************************
+// Write buffers the JSON-RPC response.
+func (s *streamingResponseWriter) Write(data []byte) (int, error) {
+	return s.body.Write(data)
+}
+
+// ************************************************************************************************
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (s *streamingResponseWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// ************************************************************************************************
+// notify sends a notification on the event stream, starting it when needed.
+func (s *streamingResponseWriter) notify(data []byte) error {
+	if s.stream == nil {
+		stream, err := newEventStream(s.ResponseWriter)
+		if err != nil {
+			return err
+		}
+		s.stream = stream
+	}
+	return s.stream.send(data)
+}
+
+// ************************************************************************************************
+// finish sends the buffered JSON-RPC response: as the last event of the stream once started,
+// otherwise as a plain response with the recorded status.
+func (s *streamingResponseWriter) finish() {
+	if s.stream != nil {
+		if s.body.Len() > 0 {
+			if err := s.stream.send(s.body.Bytes()); err != nil {
+				slog.Warn("Failed to write JSON-RPC response event", "error", err)
+			}
+		}
+		return
+	}
+
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	s.ResponseWriter.WriteHeader(s.status)
+	if _, err := s.ResponseWriter.Write(s.body.Bytes()); err != nil {
+		slog.Warn("Failed to write JSON-RPC response", "error", err)
+	}
+}
+
+// ************************************************************************************************
+// notify sends a JSON-RPC notification to the client of a request, when it is answered with an
+// event stream or arrived on a WebSocket. Notifications are dropped for clients expecting a
+// single JSON response.
+//
+// Example usage:
+//
+//	s.notify(w, "notifications/message", map[string]interface{}{"level": "info", "data": "Fetching module"})
+func (s *Server) notify(w http.ResponseWriter, method string, params interface{}) {
+	streaming, ok := w.(notifier)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(types.JSONRPCRequest{JsonRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		slog.Error("Failed to encode notification", "method", method, "error", err)
+		return
+	}
+	if err := streaming.notify(data); err != nil {
+		slog.Warn("Failed to send notification", "method", method, "error", err)
+	}
+}
+
+// ************************************************************************************************
+// notifyMessage sends a log message notification to the client of a request, so long-running
+// tool calls report what they are doing, when its level is enabled for the session of ctx.
+func (s *Server) notifyMessage(ctx context.Context, w http.ResponseWriter, level, message string) {
+	if s.sessionLogEnabled(sessionFromContext(ctx), level) {
+		s.notify(w, "notifications/message", messageParams(level, message))
+	}
+}
+
+// ************************************************************************************************
+// broadcastMessage sends a log message notification to every open GET event stream and
+// WebSocket session whose client enabled its level.
+func (s *Server) broadcastMessage(level, message string) {
+	var streams []messageSink
+	for stream, sess := range s.openStreams() {
+		if s.sessionLogEnabled(sess, level) {
+			streams = append(streams, stream)
+		}
+	}
+	s.send(streams, "notifications/message", messageParams(level, message))
+}
+
+// ************************************************************************************************
+// messageParams returns the parameters of a notifications/message notification.
+func messageParams(level, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"level":  level,
+		"logger": "repomix-mcp",
+		"data":   message,
+	}
+}
+
+// ************************************************************************************************
+// broadcast sends a JSON-RPC notification to every open GET event stream and WebSocket session.
+func (s *Server) broadcast(method string, params interface{}) {
+	var streams []messageSink
+	for stream := range s.openStreams() {
+		streams = append(streams, stream)
+	}
+	s.send(streams, method, params)
+}
+
+// ************************************************************************************************
+// send sends a JSON-RPC notification to open GET event streams and WebSocket sessions.
+func (s *Server) send(streams []messageSink, method string, params interface{}) {
+	if len(streams) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(types.JSONRPCRequest{JsonRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		slog.Error("Failed to encode notification", "method", method, "error", err)
+		return
+	}
+	for _, stream := range streams {
+		if err := stream.send(data); err != nil {
+			slog.Warn("Failed to send notification", "method", method, "error", err)
+		}
+	}
+}
+
+// ************************************************************************************************
+// openStreams returns the open GET event streams and WebSocket sessions, with their client
+// session.
+func (s *Server) openStreams() map[messageSink]*session {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+	streams := make(map[messageSink]*session, len(s.streams))
+	for stream, sess := range s.streams {
+		streams[stream] = sess
+	}
+	return streams
+}
+
+// ************************************************************************************************
+// handleEventStream serves a GET request opening an event stream for server-initiated
+// notifications, for the client of a session when sess is not nil. The stream stays open until
+// the client disconnects or the server stops.
+func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request, sess *session) {
+	// The write timeout would otherwise cut the stream
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		slog.Warn("Cannot disable the write timeout of the event stream", "error", err)
+	}
+
+	stream, err := newEventStream(w)
+	if err != nil {
+		slog.Error("Failed to open event stream", "error", err)
+		return
+	}
+
+	s.streamsMu.Lock()
+	s.streams[stream] = sess
+	s.streamsMu.Unlock()
+	slog.Info("Opened event stream", "remote", r.RemoteAddr)
+
+	defer func() {
+		s.streamsMu.Lock()
+		delete(s.streams, stream)
+		s.streamsMu.Unlock()
+		slog.Info("Closed event stream", "remote", r.RemoteAddr)
+	}()
+
+	ticker := time.NewTicker(streamKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if err := stream.keepAlive(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// ************************************************************************************************
+// Package mcp provides the lookup of Go symbols for the MCP server.
+// The symbol index recorded by the Go parser is queried by name, returning the signature,
+// location and doc comment of every matching declaration instead of the whole documentation.
+package mcp
+
+import (
+	"fmt"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// formatSymbols renders the entries of a symbol index matching a name as Markdown, each with
+// its location, signature and doc comment.
+//
+// Returns:
+//   - string: The Markdown document.
+//   - bool: Whether any symbol matched.
+func formatSymbols(repositoryID, name string, index []types.Symbol) (string, bool) {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("# Symbol: %s in %s\n\n", name, repositoryID))
+
+	matched := false
+	for _, symbol := range index {
+		if !symbolNameMatches(symbol.QualifiedName(), name) {
+			continue
+		}
+		matched = true
+		text.WriteString(fmt.Sprintf("## %s %s\n\nDeclared in %s:%d\n\n", symbol.Kind, symbol.QualifiedName(), symbol.File, symbol.Line))
+		text.WriteString("```go\n" + symbol.Signature + "\n```\n\n")
+		if symbol.Doc != "" {
+			text.WriteString(symbol.Doc + "\n\n")
+		} else {
+			text.WriteString("Undocumented.\n\n")
+		}
+	}
+	return text.String(), matched
+}
@@ -1,8 +1,12 @@
 // ************************************************************************************************
-// Package mcp provides TLS certificate generation utilities for HTTPS support.
+// Package mcp provides TLS certificate generation utilities for HTTPS support, and the
+// verification of client certificates against a CA bundle for mutual TLS.
 package mcp
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/tls"
@@ -12,11 +16,19 @@ import (
 	"fmt"
 	"math/big"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"time"
 )
 
+// ************************************************************************************************
+// CertOptions controls how self-signed certificates are generated.
+type CertOptions struct {
+	ValidityDays int    // Number of days the certificate is valid (default: 365)
+	KeyAlgorithm string // "rsa" (default) or "ecdsa"
+}
+
 // ************************************************************************************************
 // GenerateSelfSignedCert generates a self-signed certificate and private key.
 // It creates both certificate and key files at the specified paths.
@@ -25,16 +37,37 @@ import (
 //   - certPath: Path where the certificate file will be saved
 //   - keyPath: Path where the private key file will be saved
 //   - hosts: List of hostnames/IPs the certificate should be valid for
+//   - opts: Validity period and key algorithm of the certificate
 //
 // Returns:
 //   - error: An error if certificate generation fails
-func GenerateSelfSignedCert(certPath, keyPath string, hosts []string) error {
-	// Generate RSA private key
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+func GenerateSelfSignedCert(certPath, keyPath string, hosts []string, opts CertOptions) error {
+	validityDays := opts.ValidityDays
+	if validityDays <= 0 {
+		validityDays = 365
+	}
+
+	// Generate private key
+	var privateKey crypto.Signer
+	var err error
+	switch opts.KeyAlgorithm {
+	case "", "rsa":
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ecdsa":
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return fmt.Errorf("unsupported key algorithm: %s", opts.KeyAlgorithm)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to generate private key: %w", err)
 	}
 
+	// ECDSA keys cannot be used for key encipherment
+	keyUsage := x509.KeyUsageDigitalSignature
+	if _, isRSA := privateKey.(*rsa.PrivateKey); isRSA {
+		keyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
 	// Create certificate template
 	template := x509.Certificate{
 		SerialNumber: big.NewInt(1),
@@ -47,8 +80,8 @@ func GenerateSelfSignedCert(certPath, keyPath string, hosts []string) error {
 			PostalCode:    []string{""},
 		},
 		NotBefore:    time.Now(),
-		NotAfter:     time.Now().Add(365 * 24 * time.Hour), // Valid for 1 year
-		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		NotAfter:     time.Now().Add(time.Duration(validityDays) * 24 * time.Hour),
+		KeyUsage:     keyUsage,
 		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 		BasicConstraintsValid: true,
 	}
@@ -63,7 +96,7 @@ func GenerateSelfSignedCert(certPath, keyPath string, hosts []string) error {
 	}
 
 	// Create certificate
-	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, privateKey.Public(), privateKey)
 	if err != nil {
 		return fmt.Errorf("failed to create certificate: %w", err)
 	}
@@ -115,11 +148,12 @@ func GenerateSelfSignedCert(certPath, keyPath string, hosts []string) error {
 //   - keyPath: Path to the private key file
 //   - autoGenCert: Whether to auto-generate certificates if they don't exist
 //   - hosts: List of hostnames/IPs for certificate generation
+//   - opts: Validity period and key algorithm used for certificate generation
 //
 // Returns:
 //   - *tls.Config: The TLS configuration
 //   - error: An error if loading or generation fails
-func LoadTLSConfig(certPath, keyPath string, autoGenCert bool, hosts []string) (*tls.Config, error) {
+func LoadTLSConfig(certPath, keyPath string, autoGenCert bool, hosts []string, opts CertOptions) (*tls.Config, error) {
 	// Check if certificate and key files exist
 	_, certErr := os.Stat(certPath)
 	_, keyErr := os.Stat(keyPath)
@@ -130,7 +164,7 @@ func LoadTLSConfig(certPath, keyPath string, autoGenCert bool, hosts []string) (
 			hosts = []string{"localhost", "127.0.0.1", "::1"}
 		}
 		
-		if err := GenerateSelfSignedCert(certPath, keyPath, hosts); err != nil {
+		if err := GenerateSelfSignedCert(certPath, keyPath, hosts, opts); err != nil {
 			return nil, fmt.Errorf("failed to generate self-signed certificate: %w", err)
 		}
 	}
@@ -141,11 +175,67 @@ func LoadTLSConfig(certPath, keyPath string, autoGenCert bool, hosts []string) (
 		return nil, fmt.Errorf("failed to load certificate and key: %w", err)
 	}
 
-	// Create TLS configuration
+	// Create TLS configuration; advertising h2 keeps HTTP/2 enabled alongside HTTP/1.1
 	tlsConfig := &tls.Config{
 		Certificates: []tls.Certificate{cert},
 		ServerName:   "localhost",
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
 	}
 
 	return tlsConfig, nil
-}
\ No newline at end of file
+}
+
+// ************************************************************************************************
+// ConfigureClientAuth makes a TLS configuration verify client certificates against the CA
+// certificates of a PEM bundle. When required, clients without a certificate issued by one of
+// the CAs fail the handshake; otherwise a certificate is only verified when presented.
+//
+// Parameters:
+//   - tlsConfig: The server TLS configuration to update
+//   - caPath: Path of the PEM bundle of the CAs issuing client certificates
+//   - require: Whether clients must present a certificate
+//
+// Returns:
+//   - error: An error if the bundle cannot be read or holds no certificate
+//
+// Example usage:
+//
+//	if err := ConfigureClientAuth(tlsConfig, "/etc/repomix-mcp/clients-ca.pem", true); err != nil {
+//		return fmt.Errorf("failed to configure client certificates: %w", err)
+//	}
+func ConfigureClientAuth(tlsConfig *tls.Config, caPath string, require bool) error {
+	bundle, err := os.ReadFile(caPath)
+	if err != nil {
+		return fmt.Errorf("failed to read client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		return fmt.Errorf("no PEM certificate found in client CA bundle %s", caPath)
+	}
+
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	if require {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// clientCertificateName returns the name of the verified client certificate of a request: its
+// subject common name, or its serial number when it has none.
+//
+// Returns:
+//   - string: The name.
+//   - bool: False if the request carries no verified client certificate.
+func clientCertificateName(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName, true
+	}
+	return cert.SerialNumber.String(), true
+}
@@ -0,0 +1,180 @@
+// ************************************************************************************************
+// Package mcp - Unit tests for the generation and loading of TLS certificates.
+package mcp
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readCertificate parses the PEM certificate written at path.
+func readCertificate(t *testing.T, path string) *x509.Certificate {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read certificate: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		t.Fatalf("Expected a PEM certificate in %s", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+// ************************************************************************************************
+// Test that generated certificates use the configured key algorithm, validity period and SANs
+func TestGenerateSelfSignedCert(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         CertOptions
+		validityDays int
+		encipherment bool
+	}{
+		{"Defaults", CertOptions{}, 365, true},
+		{"RSA", CertOptions{ValidityDays: 30, KeyAlgorithm: "rsa"}, 30, true},
+		{"ECDSA", CertOptions{ValidityDays: 825, KeyAlgorithm: "ecdsa"}, 825, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			certPath := filepath.Join(dir, "certs", "server.crt")
+			keyPath := filepath.Join(dir, "certs", "server.key")
+
+			hosts := []string{"localhost", "127.0.0.1", "::1", "mcp.internal"}
+			if err := GenerateSelfSignedCert(certPath, keyPath, hosts, tt.opts); err != nil {
+				t.Fatalf("Failed to generate certificate: %v", err)
+			}
+			cert := readCertificate(t, certPath)
+
+			switch cert.PublicKey.(type) {
+			case *ecdsa.PublicKey:
+				if tt.opts.KeyAlgorithm != "ecdsa" {
+					t.Errorf("Expected an RSA key, got ECDSA")
+				}
+			case *rsa.PublicKey:
+				if tt.opts.KeyAlgorithm == "ecdsa" {
+					t.Errorf("Expected an ECDSA key, got RSA")
+				}
+			default:
+				t.Errorf("Unexpected public key type %T", cert.PublicKey)
+			}
+
+			validity := cert.NotAfter.Sub(cert.NotBefore)
+			if expected := time.Duration(tt.validityDays) * 24 * time.Hour; validity < expected-time.Minute || validity > expected+time.Minute {
+				t.Errorf("Expected a validity of %d days, got %v", tt.validityDays, validity)
+			}
+
+			if encipherment := cert.KeyUsage&x509.KeyUsageKeyEncipherment != 0; encipherment != tt.encipherment {
+				t.Errorf("Expected key encipherment %v, got %v", tt.encipherment, encipherment)
+			}
+
+			if len(cert.DNSNames) != 2 || cert.DNSNames[0] != "localhost" || cert.DNSNames[1] != "mcp.internal" {
+				t.Errorf("Expected DNS SANs [localhost mcp.internal], got %v", cert.DNSNames)
+			}
+			if len(cert.IPAddresses) != 2 || cert.IPAddresses[0].String() != "127.0.0.1" || cert.IPAddresses[1].String() != "::1" {
+				t.Errorf("Expected IP SANs [127.0.0.1 ::1], got %v", cert.IPAddresses)
+			}
+			if err := cert.VerifyHostname("mcp.internal"); err != nil {
+				t.Errorf("Expected the certificate to be valid for mcp.internal: %v", err)
+			}
+		})
+	}
+
+	dir := t.TempDir()
+	err := GenerateSelfSignedCert(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), []string{"localhost"}, CertOptions{KeyAlgorithm: "dsa"})
+	if err == nil {
+		t.Errorf("Expected an error for an unsupported key algorithm")
+	}
+}
+
+// ************************************************************************************************
+// Test that LoadTLSConfig generates a missing certificate once and reuses it afterwards
+func TestLoadTLSConfig_AutoGenerate(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "server.crt")
+	keyPath := filepath.Join(dir, "server.key")
+
+	if _, err := LoadTLSConfig(certPath, keyPath, false, nil, CertOptions{}); err == nil {
+		t.Errorf("Expected an error for a missing certificate without auto-generation")
+	}
+
+	tlsConfig, err := LoadTLSConfig(certPath, keyPath, true, nil, CertOptions{ValidityDays: 7, KeyAlgorithm: "ecdsa"})
+	if err != nil {
+		t.Fatalf("Failed to load TLS config: %v", err)
+	}
+	if len(tlsConfig.Certificates) != 1 {
+		t.Fatalf("Expected one certificate, got %d", len(tlsConfig.Certificates))
+	}
+	generated := readCertificate(t, certPath)
+	if err := generated.VerifyHostname("127.0.0.1"); err != nil {
+		t.Errorf("Expected the default hosts in the certificate: %v", err)
+	}
+
+	// Existing files are loaded as they are, whatever the options
+	if _, err := LoadTLSConfig(certPath, keyPath, true, []string{"other.example.com"}, CertOptions{KeyAlgorithm: "rsa"}); err != nil {
+		t.Fatalf("Failed to reload TLS config: %v", err)
+	}
+	if reloaded := readCertificate(t, certPath); !reloaded.Equal(generated) {
+		t.Errorf("Expected the existing certificate to be reused")
+	}
+}
+
+// ************************************************************************************************
+// Test that an HTTPS server using the loaded TLS configuration negotiates HTTP/2
+func TestLoadTLSConfig_HTTP2(t *testing.T) {
+	dir := t.TempDir()
+	tlsConfig, err := LoadTLSConfig(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), true, nil, CertOptions{})
+	if err != nil {
+		t.Fatalf("Failed to load TLS config: %v", err)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("Expected TLS 1.2 as the minimum version, got %x", tlsConfig.MinVersion)
+	}
+	if len(tlsConfig.NextProtos) == 0 || tlsConfig.NextProtos[0] != "h2" {
+		t.Errorf("Expected h2 to be advertised first, got %v", tlsConfig.NextProtos)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Proto))
+		}),
+		TLSConfig: tlsConfig,
+	}
+	go server.ServeTLS(listener, "", "")
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: true,
+		},
+	}
+	resp, err := client.Get("https://" + listener.Addr().String() + "/")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("Expected HTTP/2, got %s", resp.Proto)
+	}
+}
@@ -0,0 +1,281 @@
+// ************************************************************************************************
+// Package mcp provides the filesystem watcher re-indexing local repositories while serving.
+// The directories of the local repositories configured with "watch": true are watched
+// recursively; once their files stop changing for server.watchDebounce, an indexing job is
+// queued for the repository so the cache never serves stale local code. Directories matched by
+// a glob path are resolved when the server starts.
+package mcp
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"repomix-mcp/pkg/types"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/fsnotify/fsnotify"
+)
+
+// ************************************************************************************************
+// unwatchedDirs are the directories whose changes never trigger a re-indexing.
+var unwatchedDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	"node_modules": true,
+}
+
+// ************************************************************************************************
+// repositoryWatcher re-indexes the watched repositories when their files change.
+type repositoryWatcher struct {
+	server    *Server
+	fsWatcher *fsnotify.Watcher
+	debounce  time.Duration
+	roots     map[string]string // Repository alias by watched root directory
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer // Pending re-indexing by alias
+	closed bool
+}
+
+// ************************************************************************************************
+// startWatcher starts watching the local repositories configured with watch enabled.
+// Nothing is watched when no repository enables it.
+//
+// Returns:
+//   - error: An error if the watcher cannot be created.
+//
+// Example usage:
+//
+//	if err := s.startWatcher(); err != nil {
+//		return err
+//	}
+//	defer s.stopWatcher()
+func (s *Server) startWatcher() error {
+	roots := make(map[string]string)
+	for alias, repoConfig := range s.config.Repositories {
+		if !repoConfig.Watch || repoConfig.Disabled || repoConfig.Type != types.RepositoryTypeLocal {
+			continue
+		}
+		dirs, err := watchedDirectories(repoConfig.Path)
+		if err != nil {
+			slog.Warn("Cannot watch repository", "repository", alias, "path", repoConfig.Path, "error", err)
+			continue
+		}
+		for _, dir := range dirs {
+			roots[dir] = alias
+		}
+	}
+	if len(roots) == 0 {
+		return nil
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create filesystem watcher: %w", err)
+	}
+	watcher := &repositoryWatcher{
+		server:    s,
+		fsWatcher: fsWatcher,
+		debounce:  parseTimeout(s.config.Server.WatchDebounce, 2*time.Second),
+		roots:     roots,
+		timers:    make(map[string]*time.Timer),
+	}
+	for root, alias := range roots {
+		watcher.addTree(root)
+		slog.Info("Watching repository for changes", "repository", alias, "path", root)
+	}
+
+	s.watcher = watcher
+	go watcher.run()
+	return nil
+}
+
+// ************************************************************************************************
+// stopWatcher stops watching the repositories and drops the pending re-indexing.
+func (s *Server) stopWatcher() {
+	if s.watcher != nil {
+		s.watcher.close()
+	}
+}
+
+// ************************************************************************************************
+// watchedDirectories resolves the path of a local repository to the directories to watch,
+// expanding "~" and glob patterns.
+//
+// Returns:
+//   - []string: The directories, sorted.
+//   - error: An error if the path matches no directory.
+func watchedDirectories(path string) ([]string, error) {
+	if strings.HasPrefix(path, "~") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		path = filepath.Join(homeDir, path[1:])
+	}
+
+	matches := []string{path}
+	if strings.ContainsAny(path, "*?[]{}") {
+		var err error
+		if matches, err = doublestar.FilepathGlob(path); err != nil {
+			return nil, fmt.Errorf("failed to expand glob pattern %s: %w", path, err)
+		}
+	}
+
+	var dirs []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			if absolute, err := filepath.Abs(match); err == nil {
+				match = absolute
+			}
+			dirs = append(dirs, filepath.Clean(match))
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no directory found at %s", path)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// ************************************************************************************************
+// addTree watches a directory and its subdirectories, unwatched directories excluded.
+func (w *repositoryWatcher) addTree(root string) {
+	_ = filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if path != root && unwatchedDirs[entry.Name()] {
+			return filepath.SkipDir
+		}
+		if err := w.fsWatcher.Add(path); err != nil {
+			slog.Warn("Cannot watch directory", "path", path, "error", err)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// ************************************************************************************************
+// run handles the filesystem events until the watcher is closed.
+func (w *repositoryWatcher) run() {
+	for {
+		select {
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(event)
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Warn("Filesystem watcher error", "error", err)
+		}
+	}
+}
+
+// ************************************************************************************************
+// handleEvent schedules the re-indexing of the repository a changed file belongs to, and
+// watches the directories created in it.
+func (w *repositoryWatcher) handleEvent(event fsnotify.Event) {
+	if event.Op == fsnotify.Chmod {
+		return
+	}
+	alias, root := w.repositoryOf(event.Name)
+	if alias == "" {
+		return
+	}
+	relPath, err := filepath.Rel(root, event.Name)
+	if err != nil {
+		return
+	}
+	for _, part := range strings.Split(relPath, string(filepath.Separator)) {
+		if unwatchedDirs[part] {
+			return
+		}
+	}
+
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			w.addTree(event.Name)
+		}
+	}
+	w.schedule(alias)
+}
+
+// ************************************************************************************************
+// repositoryOf returns the repository a path belongs to.
+//
+// Returns:
+//   - string: The repository alias, empty if the path is not watched.
+//   - string: The watched root directory holding the path.
+func (w *repositoryWatcher) repositoryOf(path string) (string, string) {
+	for root, alias := range w.roots {
+		if path == root || strings.HasPrefix(path, root+string(filepath.Separator)) {
+			return alias, root
+		}
+	}
+	return "", ""
+}
+
+// ************************************************************************************************
+// schedule re-indexes a repository once its files stopped changing for the debounce delay.
+func (w *repositoryWatcher) schedule(alias string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	if timer, exists := w.timers[alias]; exists {
+		timer.Reset(w.debounce)
+		return
+	}
+	w.timers[alias] = time.AfterFunc(w.debounce, func() { w.reindex(alias) })
+}
+
+// ************************************************************************************************
+// reindex queues the indexing job of a changed repository. A job already running may have read
+// the files before they changed, so the re-indexing is scheduled again until it ends.
+func (w *repositoryWatcher) reindex(alias string) {
+	w.mu.Lock()
+	delete(w.timers, alias)
+	closed := w.closed
+	w.mu.Unlock()
+	if closed {
+		return
+	}
+
+	job, queued, err := w.server.startIndexJob(alias)
+	switch {
+	case err != nil:
+		slog.Warn("Cannot re-index changed repository", "repository", alias, "error", err)
+	case queued:
+		slog.Info("Re-indexing changed repository", "repository", alias, "job", job.ID)
+	case job.Status == indexJobRunning:
+		w.schedule(alias)
+	}
+}
+
+// ************************************************************************************************
+// close stops the watcher and the pending re-indexing.
+func (w *repositoryWatcher) close() {
+	w.mu.Lock()
+	w.closed = true
+	for alias, timer := range w.timers {
+		timer.Stop()
+		delete(w.timers, alias)
+	}
+	w.mu.Unlock()
+
+	if err := w.fsWatcher.Close(); err != nil {
+		slog.Warn("Failed to close filesystem watcher", "error", err)
+	}
+}
@@ -0,0 +1,450 @@
+// ************************************************************************************************
+// Package mcp provides the WebSocket transport of the MCP server.
+// The /mcp/ws endpoint upgrades to a WebSocket (RFC 6455) carrying one JSON-RPC message per text
+// message in both directions, for clients behind proxies that break Server-Sent Events. Requests
+// are handled concurrently, responses are sent as they complete, and the session receives the
+// notifications of its requests and the server-initiated ones.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"repomix-mcp/internal/tracing"
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// WebSocket protocol constants (RFC 6455).
+const (
+	webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11" // Appended to the client key in the handshake
+
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	wsCloseNormal      = 1000
+	wsCloseGoingAway   = 1001
+	wsCloseUnsupported = 1003
+	wsCloseTooBig      = 1009
+
+	// maxWebSocketMessageSize bounds a message from the client, JSON-RPC requests are small
+	maxWebSocketMessageSize = 4 << 20
+
+	// maxWebSocketInFlight bounds the requests of a session handled at once, further messages
+	// are read once one completes
+	maxWebSocketInFlight = 16
+)
+
+// ************************************************************************************************
+// webSocketWriteTimeout bounds a write to the client, so a client that stops reading has its
+// connection closed instead of blocking the handlers of its session.
+var webSocketWriteTimeout = 10 * time.Second
+
+// ************************************************************************************************
+// errMessageTooBig is returned when a client message exceeds maxWebSocketMessageSize.
+var errMessageTooBig = errors.New("websocket message too big")
+
+// ************************************************************************************************
+// webSocketAccept returns the Sec-WebSocket-Accept value answering a Sec-WebSocket-Key.
+func webSocketAccept(key string) string {
+	hash := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// ************************************************************************************************
+// headerContainsToken reports whether a comma-separated header contains a token, ignoring case.
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ************************************************************************************************
+// webSocketConn is the server side of a WebSocket connection. Reads happen on one goroutine,
+// writes are serialized.
+type webSocketConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+	closed  bool
+}
+
+// ************************************************************************************************
+// upgradeWebSocket validates a WebSocket handshake, answers it and takes over the connection.
+//
+// Returns:
+//   - *webSocketConn: The connection, with its read deadline cleared.
+//   - error: An error if the request is not a valid handshake, after answering it, or if the
+//     connection cannot be taken over.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*webSocketConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	switch {
+	case r.Method != http.MethodGet:
+		http.Error(w, "WebSocket handshake requires GET", http.StatusMethodNotAllowed)
+		return nil, fmt.Errorf("invalid handshake method %s", r.Method)
+	case !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket"):
+		http.Error(w, "WebSocket upgrade required", http.StatusUpgradeRequired)
+		return nil, fmt.Errorf("missing upgrade headers")
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, fmt.Errorf("unsupported version %q", r.Header.Get("Sec-WebSocket-Version"))
+	case key == "":
+		http.Error(w, "Missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, fmt.Errorf("missing Sec-WebSocket-Key")
+	}
+
+	conn, buffered, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("failed to take over connection\n>    %w", err)
+	}
+	// Sessions stay open while idle, writes are bounded by webSocketWriteTimeout
+	conn.SetDeadline(time.Time{})
+	conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+
+	var response strings.Builder
+	response.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	response.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	response.WriteString("Sec-WebSocket-Accept: " + webSocketAccept(key) + "\r\n")
+	if headerContainsToken(r.Header, "Sec-WebSocket-Protocol", "mcp") {
+		response.WriteString("Sec-WebSocket-Protocol: mcp\r\n")
+	}
+	response.WriteString("\r\n")
+	if _, err := conn.Write([]byte(response.String())); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to answer handshake\n>    %w", err)
+	}
+
+	return &webSocketConn{conn: conn, reader: buffered.Reader}, nil
+}
+
+// ************************************************************************************************
+// readMessage reads the next data message, reassembling fragments and answering the control
+// frames received in between.
+//
+// Returns:
+//   - int: The opcode of the message, text or binary.
+//   - []byte: The payload.
+//   - error: io.EOF once the client closed the connection, or a read or protocol error.
+func (c *webSocketConn) readMessage() (int, []byte, error) {
+	var message []byte
+	messageOpcode := -1
+	for {
+		final, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			c.close(wsCloseNormal, "")
+			return 0, nil, io.EOF
+		case wsOpContinuation:
+			if messageOpcode < 0 {
+				return 0, nil, fmt.Errorf("continuation frame without a message")
+			}
+		case wsOpText, wsOpBinary:
+			if messageOpcode >= 0 {
+				return 0, nil, fmt.Errorf("new message before the end of the previous one")
+			}
+			messageOpcode = opcode
+		default:
+			return 0, nil, fmt.Errorf("unknown opcode %d", opcode)
+		}
+
+		if len(message)+len(payload) > maxWebSocketMessageSize {
+			return 0, nil, errMessageTooBig
+		}
+		message = append(message, payload...)
+		if final {
+			return messageOpcode, message, nil
+		}
+	}
+}
+
+// ************************************************************************************************
+// readFrame reads one frame and unmasks its payload. Client frames must be masked.
+func (c *webSocketConn) readFrame() (bool, int, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	final := header[0]&0x80 != 0
+	opcode := int(header[0] & 0x0F)
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, fmt.Errorf("unmasked client frame")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if opcode >= wsOpClose && (length > 125 || !final) {
+		return false, 0, nil, fmt.Errorf("invalid control frame")
+	}
+	if length > maxWebSocketMessageSize {
+		return false, 0, nil, errMessageTooBig
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for index := range payload {
+		payload[index] ^= mask[index%4]
+	}
+	return final, opcode, payload, nil
+}
+
+// ************************************************************************************************
+// writeFrame writes one unmasked, unfragmented frame. A write failing, or not done within
+// webSocketWriteTimeout, closes the connection.
+func (c *webSocketConn) writeFrame(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closed {
+		return net.ErrClosed
+	}
+
+	var frame bytes.Buffer
+	frame.WriteByte(0x80 | byte(opcode))
+	switch length := len(payload); {
+	case length <= 125:
+		frame.WriteByte(byte(length))
+	case length <= 0xFFFF:
+		frame.WriteByte(126)
+		binary.Write(&frame, binary.BigEndian, uint16(length))
+	default:
+		frame.WriteByte(127)
+		binary.Write(&frame, binary.BigEndian, uint64(length))
+	}
+	frame.Write(payload)
+
+	c.conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+	if _, err := c.conn.Write(frame.Bytes()); err != nil {
+		c.closed = true
+		c.conn.Close()
+		return err
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// send writes an encoded JSON-RPC message as a text message.
+func (c *webSocketConn) send(data []byte) error {
+	return c.writeFrame(wsOpText, bytes.TrimRight(data, "\n"))
+}
+
+// ************************************************************************************************
+// close sends a close frame with a status code and reason, then closes the connection. Closing
+// twice does nothing.
+func (c *webSocketConn) close(code int, reason string) {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	c.writeFrame(wsOpClose, payload)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if !c.closed {
+		c.closed = true
+		c.conn.Close()
+	}
+}
+
+// ************************************************************************************************
+// webSocketResponseWriter is handed to the handler of a request received on a WebSocket: the
+// JSON-RPC response it writes is sent as one message, its notifications right away.
+type webSocketResponseWriter struct {
+	conn   *webSocketConn
+	header http.Header
+	body   bytes.Buffer
+}
+
+// ************************************************************************************************
+// Header returns the response headers, unused on a WebSocket.
+func (w *webSocketResponseWriter) Header() http.Header {
+	return w.header
+}
+
+// ************************************************************************************************
+// WriteHeader ignores the status code, unused on a WebSocket.
+func (w *webSocketResponseWriter) WriteHeader(status int) {}
+
+// ************************************************************************************************
+// Write buffers the JSON-RPC response.
+func (w *webSocketResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+// ************************************************************************************************
+// notify sends a notification on the WebSocket.
+func (w *webSocketResponseWriter) notify(data []byte) error {
+	return w.conn.send(data)
+}
+
+// ************************************************************************************************
+// handleWebSocket serves the /mcp/ws endpoint: it upgrades the connection, then handles the
+// JSON-RPC messages of the session until the client disconnects or the server stops.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	// The connection is the session of its client, whatever its Mcp-Session-Id header
+	sess, err := s.newSession(true)
+	if err != nil {
+		slog.Warn("Rejected WebSocket connection", "remote", r.RemoteAddr, "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		s.endSession(sess.id)
+		slog.Warn("Rejected WebSocket connection", "remote", r.RemoteAddr, "error", err)
+		return
+	}
+	slog.Info("Opened WebSocket session", "session", sess.id, "remote", r.RemoteAddr)
+
+	s.streamsMu.Lock()
+	s.streams[conn] = sess
+	s.streamsMu.Unlock()
+
+	// Requests still handled when the session ends are cancelled; they keep the authenticated
+	// client of the upgrade request
+	ctx, cancel := context.WithCancel(withSession(tracing.Extract(withClientKey(context.WithoutCancel(r.Context()), requestClientKey(r)), r.Header), sess))
+	var handlers sync.WaitGroup
+	sessionDone := make(chan struct{})
+	defer func() {
+		close(sessionDone)
+		cancel()
+		handlers.Wait()
+		s.streamsMu.Lock()
+		delete(s.streams, conn)
+		s.streamsMu.Unlock()
+		s.endSession(sess.id)
+		conn.close(wsCloseNormal, "")
+		slog.Info("Closed WebSocket session", "session", sess.id, "remote", r.RemoteAddr)
+	}()
+
+	// Keep the session alive through proxies and end it when the server stops
+	go func() {
+		ticker := time.NewTicker(streamKeepAliveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-sessionDone:
+				return
+			case <-s.done:
+				conn.close(wsCloseGoingAway, "server stopping")
+				return
+			case <-ticker.C:
+				if err := conn.writeFrame(wsOpPing, nil); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	slots := make(chan struct{}, maxWebSocketInFlight)
+	for {
+		opcode, message, err := conn.readMessage()
+		if err != nil {
+			switch {
+			case errors.Is(err, errMessageTooBig):
+				conn.close(wsCloseTooBig, "message too big")
+			case err != io.EOF && !errors.Is(err, net.ErrClosed):
+				slog.Warn("WebSocket session error", "session", sess.id, "remote", r.RemoteAddr, "error", err)
+			}
+			return
+		}
+		if opcode != wsOpText {
+			conn.close(wsCloseUnsupported, "JSON-RPC messages must be text")
+			return
+		}
+
+		slots <- struct{}{}
+		handlers.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				handlers.Done()
+			}()
+			s.handleWebSocketMessage(ctx, conn, message)
+		}()
+	}
+}
+
+// ************************************************************************************************
+// handleWebSocketMessage handles one JSON-RPC message of a WebSocket session and sends its
+// response, if any. The request is cancelled with ctx.
+func (s *Server) handleWebSocketMessage(ctx context.Context, conn *webSocketConn, message []byte) {
+	writer := &webSocketResponseWriter{conn: conn, header: make(http.Header)}
+
+	var jsonRPCReq types.JSONRPCRequest
+	if err := json.Unmarshal(message, &jsonRPCReq); err != nil {
+		s.sendJSONRPCError(writer, nil, -32700, "Parse error", fmt.Sprintf("Invalid JSON: %v", err))
+	} else if jsonRPCReq.ID == nil {
+		// Notifications such as notifications/cancelled do no work and are never rejected
+		s.handleJSONRPCRequest(ctx, writer, jsonRPCReq)
+	} else if release, acquired := s.acquireRequestSlot(); acquired {
+		func() {
+			defer release()
+			s.handleJSONRPCRequest(ctx, writer, jsonRPCReq)
+		}()
+	} else {
+		slog.Warn("Rejecting WebSocket request: too many requests in progress", "limit", cap(s.requestSlots))
+		s.sendJSONRPCError(writer, jsonRPCReq.ID, serverBusyError.Code, serverBusyError.Message, serverBusyError.Data)
+	}
+
+	// Notifications such as notifications/initialized have no response
+	if writer.body.Len() == 0 {
+		return
+	}
+	if err := conn.send(writer.body.Bytes()); err != nil {
+		slog.Warn("Failed to write WebSocket response", "error", err)
+	}
+}
@@ -0,0 +1,173 @@
+// ************************************************************************************************
+// Package mcp provides the comparison of an indexed repository with its working tree.
+// Files are compared by content hash, so agents can see what changed since the last
+// indexing without re-indexing. The cached index is never modified.
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// FileLister defines the interface for listing the files of a working tree that the indexing
+// configuration selects.
+type FileLister interface {
+	ListFiles(localPath string, indexingConfig types.IndexingConfig) ([]string, error)
+}
+
+// ************************************************************************************************
+// workingDiff lists the files that differ between an indexed repository and its working tree.
+// Paths are slash-separated and relative to the repository root.
+type workingDiff struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+}
+
+// ************************************************************************************************
+// computeWorkingDiff compares the hashes of an indexed repository with the files on disk.
+// onDisk lists the files the indexing configuration currently selects. For repositories
+// indexed by the Go parser, the sources are compared with the hashes it recorded and only
+// Go sources are reported as added, since other files are not indexed individually.
+//
+// Returns:
+//   - workingDiff: The added, modified and deleted files, each sorted.
+//   - error: An error if the recorded source hashes cannot be decoded.
+//
+// Example usage:
+//
+//	files, _ := lister.ListFiles(repo.Path, repoConfig.Indexing)
+//	diff, err := computeWorkingDiff(repo, files)
+func computeWorkingDiff(repo *types.RepositoryIndex, onDisk []string) (workingDiff, error) {
+	var diff workingDiff
+
+	// Hashes of the indexed files by slash-separated path
+	indexed := make(map[string]string)
+	for filePath, file := range repo.Files {
+		if file.Metadata["indexer_type"] == "go_native" {
+			continue // Synthetic document, not a file of the working tree
+		}
+		indexed[filepath.ToSlash(filePath)] = file.Hash
+	}
+
+	goNative := repo.Metadata["indexer_type"] == "go_native"
+	if value, exists := repo.Metadata[types.SourceHashesMetadataKey]; exists {
+		hashes, err := types.DecodeSourceHashes(value)
+		if err != nil {
+			return diff, err
+		}
+		for filePath, hash := range hashes {
+			indexed[filePath] = hash
+		}
+	}
+
+	for filePath, hash := range indexed {
+		data, err := os.ReadFile(filepath.Join(repo.Path, filepath.FromSlash(filePath)))
+		if os.IsNotExist(err) {
+			diff.Deleted = append(diff.Deleted, filePath)
+			continue
+		}
+		if err != nil {
+			continue // Unreadable files cannot be compared
+		}
+		if !types.ContentMatchesHash(string(data), hash) {
+			diff.Modified = append(diff.Modified, filePath)
+		}
+	}
+
+	for _, filePath := range onDisk {
+		filePath = filepath.ToSlash(filePath)
+		if _, exists := indexed[filePath]; exists {
+			continue
+		}
+		if goNative && !isGoSourceFile(filePath) {
+			continue
+		}
+		diff.Added = append(diff.Added, filePath)
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Modified)
+	sort.Strings(diff.Deleted)
+	return diff, nil
+}
+
+// ************************************************************************************************
+// isGoSourceFile reports whether a slash-separated path is a source the Go parser summarizes:
+// a non-test Go file outside hidden, vendor and node_modules directories.
+func isGoSourceFile(filePath string) bool {
+	if !strings.HasSuffix(filePath, ".go") || strings.HasSuffix(filePath, "_test.go") {
+		return false
+	}
+	dirs := strings.Split(filePath, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if strings.HasPrefix(dir, ".") || dir == "vendor" || dir == "node_modules" {
+			return false
+		}
+	}
+	return true
+}
+
+// ************************************************************************************************
+// formatWorkingDiff renders a working diff as Markdown. With includeContent, the current content
+// of added and modified files is appended, up to maxContentBytes in total.
+//
+// Returns:
+//   - string: The Markdown document.
+func formatWorkingDiff(repositoryID, localPath string, diff workingDiff, includeContent bool, maxContentBytes int) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("# Working diff: %s\n\n", repositoryID))
+
+	if len(diff.Added)+len(diff.Modified)+len(diff.Deleted) == 0 {
+		text.WriteString("No changes since the last indexing.\n")
+		return text.String()
+	}
+
+	sections := []struct {
+		title string
+		files []string
+	}{
+		{"Added", diff.Added},
+		{"Modified", diff.Modified},
+		{"Deleted", diff.Deleted},
+	}
+	for _, section := range sections {
+		if len(section.files) == 0 {
+			continue
+		}
+		text.WriteString(fmt.Sprintf("## %s (%d)\n\n", section.title, len(section.files)))
+		for _, filePath := range section.files {
+			text.WriteString(fmt.Sprintf("- %s\n", filePath))
+		}
+		text.WriteString("\n")
+	}
+
+	if !includeContent {
+		return text.String()
+	}
+
+	changed := append(append([]string{}, diff.Added...), diff.Modified...)
+	sort.Strings(changed)
+
+	remaining := maxContentBytes
+	for index, filePath := range changed {
+		data, err := os.ReadFile(filepath.Join(localPath, filepath.FromSlash(filePath)))
+		if err != nil {
+			continue
+		}
+		if len(data) > remaining {
+			text.WriteString(fmt.Sprintf("... content of %d more files omitted, the response size limit was reached\n", len(changed)-index))
+			break
+		}
+		remaining -= len(data)
+		text.WriteString(fmt.Sprintf("### %s\n\n```\n%s\n```\n\n", filePath, strings.TrimRight(string(data), "\n")))
+	}
+
+	return text.String()
+}
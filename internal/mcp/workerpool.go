@@ -0,0 +1,101 @@
+// ************************************************************************************************
+// Package mcp provides the worker pool of the expensive tool executions.
+// get-library-docs, refresh and the retrieval of uncached Go modules assemble large documentation
+// or run subprocesses, so at most server.maxConcurrentTools of them run at once across every
+// client and transport. Further executions wait for a worker up to server.toolQueueTimeout, then
+// are refused with a "Server busy" JSON-RPC error instead of piling up goroutines and subprocesses.
+package mcp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ************************************************************************************************
+// pooledTools are the tools run by a worker of the pool.
+var pooledTools = map[string]bool{
+	"get-library-docs": true,
+	"refresh":          true,
+}
+
+// ************************************************************************************************
+// errServerBusy reports an execution refused because no worker became free in time.
+var errServerBusy = errors.New("too many tool executions in progress, retry later")
+
+// ************************************************************************************************
+// workerPool bounds the expensive executions running at once. A nil pool is unlimited.
+type workerPool struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+}
+
+// ************************************************************************************************
+// workerContextKey is the context key marking an execution holding a worker, so the nested
+// executions it starts, such as a Go module retrieval for get-library-docs, do not wait for
+// another one.
+type workerContextKey struct{}
+
+// ************************************************************************************************
+// newWorkerPool returns a pool of workers, nil when workers is 0 or less.
+func newWorkerPool(workers int, queueTimeout time.Duration) *workerPool {
+	if workers <= 0 {
+		return nil
+	}
+	return &workerPool{
+		slots:        make(chan struct{}, workers),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// ************************************************************************************************
+// acquire waits for a free worker, until the queue timeout expires or ctx is done.
+//
+// Returns:
+//   - context.Context: ctx marked as holding the worker.
+//   - func(): Frees the worker, to call once the execution ends.
+//   - error: errServerBusy if no worker became free in time, or the error of ctx.
+func (p *workerPool) acquire(ctx context.Context) (context.Context, func(), error) {
+	if p == nil || ctx.Value(workerContextKey{}) != nil {
+		return ctx, func() {}, nil
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		timer := time.NewTimer(p.queueTimeout)
+		defer timer.Stop()
+		select {
+		case p.slots <- struct{}{}:
+		case <-timer.C:
+			return ctx, nil, errServerBusy
+		case <-ctx.Done():
+			return ctx, nil, ctx.Err()
+		}
+	}
+	return context.WithValue(ctx, workerContextKey{}, true), func() { <-p.slots }, nil
+}
+
+// ************************************************************************************************
+// acquireWorker waits for a worker of the pool to run an expensive execution.
+//
+// Returns:
+//   - context.Context: The context of the execution, holding the worker.
+//   - func(): Frees the worker, to call once the execution ends.
+//   - error: errServerBusy if every worker stayed busy, or the error of ctx.
+//
+// Example usage:
+//
+//	ctx, release, err := s.acquireWorker(ctx, "refresh")
+//	if err != nil {
+//		return err
+//	}
+//	defer release()
+func (s *Server) acquireWorker(ctx context.Context, what string) (context.Context, func(), error) {
+	workerCtx, release, err := s.workers.acquire(ctx)
+	if errors.Is(err, errServerBusy) {
+		slog.WarnContext(ctx, "Refusing execution: every worker stayed busy", "call", what, "workers", cap(s.workers.slots), "queueTimeout", s.workers.queueTimeout)
+	}
+	return workerCtx, release, err
+}
@@ -0,0 +1,149 @@
+// ************************************************************************************************
+// Package mcp provides workspace resolution for the MCP server.
+// A workspace is a configured group of repositories served under the virtual repository ID
+// "workspace:<name>"; queries fan out to the indexed member repositories and the results
+// are merged with per-repository attribution within the token budget.
+package mcp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// minWorkspaceMemberTokens is the smallest budget a member repository is queried with; members
+// reached once less is left are listed as omitted.
+const minWorkspaceMemberTokens = 500
+
+// ************************************************************************************************
+// workspaceMembers resolves a workspace ID to the indexed repositories of its members.
+// Glob entries contribute every repository expanded from them, disabled repositories are left out.
+//
+// Returns:
+//   - []string: The member repository IDs, in configuration order.
+//   - error: An error if the workspace is unknown or none of its members is indexed.
+func (s *Server) workspaceMembers(workspaceID string) ([]string, error) {
+	name := strings.TrimPrefix(workspaceID, types.WorkspaceRepositoryPrefix)
+	workspace, exists := s.config.Workspaces[name]
+	if !exists {
+		return nil, fmt.Errorf("workspace not found: %s", name)
+	}
+
+	indexed := s.indexedRepositoryIDs()
+
+	var members []string
+	for _, alias := range workspace.Repositories {
+		for _, repoID := range indexed {
+			if owner, _ := s.repositoryAlias(repoID); owner != alias || s.isRepositoryDisabled(repoID) {
+				continue
+			}
+			members = append(members, repoID)
+		}
+	}
+
+	if len(members) == 0 {
+		return nil, fmt.Errorf("workspace %s has no indexed repositories", name)
+	}
+	return members, nil
+}
+
+// ************************************************************************************************
+// aggregateWorkspace fans a query out to the members of a workspace and merges the results
+// under one heading per repository. Each member is queried with an even share of the budget
+// left, so the budget a member does not use goes to the following ones.
+//
+// Returns:
+//   - string: The merged Markdown document.
+//   - error: An error if the workspace cannot be resolved or no member returned a result.
+//
+// Example usage:
+//
+//	docs, err := s.aggregateWorkspace("workspace:payments", 10000, func(repositoryID string, tokens int) (string, error) {
+//		return s.getRepositoryDocs(ctx, repositoryID, topic, tokens, false)
+//	})
+func (s *Server) aggregateWorkspace(workspaceID string, tokens int, fetch func(repositoryID string, tokens int) (string, error)) (string, error) {
+	members, err := s.workspaceMembers(workspaceID)
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("# Workspace: %s\n\n", strings.TrimPrefix(workspaceID, types.WorkspaceRepositoryPrefix)))
+	text.WriteString(fmt.Sprintf("Repositories: %s\n\n", strings.Join(members, ", ")))
+
+	remaining := tokens - s.tokenizer.Count(text.String())
+	failed := 0
+	for index, member := range members {
+		budget := remaining / (len(members) - index)
+		if budget < minWorkspaceMemberTokens {
+			text.WriteString(fmt.Sprintf("## Repository: %s\n\n_Omitted: the token budget is exhausted._\n\n", member))
+			continue
+		}
+
+		var section string
+		docs, err := fetch(member, budget)
+		if err != nil {
+			failed++
+			section = fmt.Sprintf("## Repository: %s\n\n_Not available: %v_\n\n", member, err)
+		} else {
+			section = fmt.Sprintf("## Repository: %s\n\n%s\n\n", member, strings.TrimSpace(docs))
+			if s.tokenizer.Count(section) > budget {
+				section = s.tokenizer.Truncate(section, budget-100) + "\n\n[Content truncated...]\n\n"
+			}
+		}
+
+		text.WriteString(section)
+		remaining -= s.tokenizer.Count(section)
+	}
+
+	if failed == len(members) {
+		return "", fmt.Errorf("no documentation available for workspace %s", strings.TrimPrefix(workspaceID, types.WorkspaceRepositoryPrefix))
+	}
+	return text.String(), nil
+}
+
+// ************************************************************************************************
+// findWorkspaceMatches returns the IDs of the workspaces whose name matches a library name,
+// using the same loose matching as repositories.
+//
+// Returns:
+//   - []string: The workspace repository IDs, sorted.
+func (s *Server) findWorkspaceMatches(libraryName string) []string {
+	var matches []string
+	for name := range s.config.Workspaces {
+		if s.libraryNameMatches(name, libraryName) {
+			matches = append(matches, types.WorkspaceRepositoryID(name))
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// ************************************************************************************************
+// indexedRepositoryIDs returns the IDs of the repositories in the cache or in memory.
+//
+// Returns:
+//   - []string: The repository IDs, sorted and without duplicates.
+func (s *Server) indexedRepositoryIDs() []string {
+	seen := make(map[string]bool)
+	if s.cache != nil {
+		if repoIDs, err := s.cache.ListRepositories(); err == nil {
+			for _, repoID := range repoIDs {
+				seen[repoID] = true
+			}
+		}
+	}
+	for _, repoID := range s.memoryRepositoryIDs() {
+		seen[repoID] = true
+	}
+
+	repoIDs := make([]string, 0, len(seen))
+	for repoID := range seen {
+		repoIDs = append(repoIDs, repoID)
+	}
+	sort.Strings(repoIDs)
+	return repoIDs
+}
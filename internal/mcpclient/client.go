@@ -9,7 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
@@ -27,6 +27,7 @@ type Client struct {
 	verbose       bool
 	initialized   bool
 	sessionID     string
+	apiKey        string
 }
 
 // ************************************************************************************************
@@ -79,6 +80,12 @@ func (c *Client) SetVerbose(verbose bool) {
 	c.verbose = verbose
 }
 
+// ************************************************************************************************
+// SetAPIKey sets the API key sent as a bearer token to servers requiring authentication.
+func (c *Client) SetAPIKey(apiKey string) {
+	c.apiKey = apiKey
+}
+
 // ************************************************************************************************
 // Connect establishes a connection to the MCP server and initializes the session.
 //
@@ -93,7 +100,7 @@ func (c *Client) SetVerbose(verbose bool) {
 //	}
 func (c *Client) Connect() error {
 	if c.verbose {
-		log.Printf("Connecting to MCP server: %s", c.serverAddress)
+		slog.Info("Connecting to MCP server", "address", c.serverAddress)
 	}
 
 	// Test connection with a ping
@@ -113,7 +120,7 @@ func (c *Client) Connect() error {
 
 	c.initialized = true
 	if c.verbose {
-		log.Printf("Successfully connected and initialized MCP session")
+		slog.Info("Connected and initialized MCP session")
 	}
 
 	return nil
@@ -140,7 +147,7 @@ func (c *Client) ListTools() ([]types.MCPTool, error) {
 	}
 
 	if c.verbose {
-		log.Printf("Requesting tools list from MCP server")
+		slog.Info("Requesting tools list from MCP server")
 	}
 
 	// Create tools/list request
@@ -169,7 +176,7 @@ func (c *Client) ListTools() ([]types.MCPTool, error) {
 	}
 
 	if c.verbose {
-		log.Printf("Retrieved %d tools from MCP server", len(toolsResult.Tools))
+		slog.Info("Retrieved tools from MCP server", "tools", len(toolsResult.Tools))
 	}
 
 	return toolsResult.Tools, nil
@@ -200,7 +207,7 @@ func (c *Client) CallTool(toolName string, arguments map[string]interface{}) (*t
 	}
 
 	if c.verbose {
-		log.Printf("Calling tool '%s' with arguments: %+v", toolName, arguments)
+		slog.Info("Calling tool", "tool", toolName, "arguments", arguments)
 	}
 
 	// Create tools/call request
@@ -234,7 +241,7 @@ func (c *Client) CallTool(toolName string, arguments map[string]interface{}) (*t
 	}
 
 	if c.verbose {
-		log.Printf("Tool '%s' executed successfully, isError: %v", toolName, toolResult.IsError)
+		slog.Info("Tool executed", "tool", toolName, "isError", toolResult.IsError)
 	}
 
 	return &toolResult, nil
@@ -244,7 +251,7 @@ func (c *Client) CallTool(toolName string, arguments map[string]interface{}) (*t
 // Close closes the client connection and cleans up resources.
 func (c *Client) Close() error {
 	if c.verbose && c.initialized {
-		log.Printf("Closing MCP client connection")
+		slog.Info("Closing MCP client connection")
 	}
 	
 	c.initialized = false
@@ -313,7 +320,7 @@ func (c *Client) initialize() error {
 	}
 
 	if c.verbose {
-		log.Printf("MCP session initialized with protocol version: %s", initResult.ProtocolVersion)
+		slog.Info("MCP session initialized", "protocolVersion", initResult.ProtocolVersion)
 	}
 
 	return nil
@@ -340,7 +347,7 @@ func (c *Client) sendJSONRPCRequest(request types.JSONRPCRequest) (*types.JSONRP
 	}
 
 	if c.verbose {
-		log.Printf("Sending JSON-RPC request: %s", string(reqData))
+		slog.Info("Sending JSON-RPC request", "request", string(reqData))
 	}
 
 	// Create HTTP request
@@ -352,6 +359,9 @@ func (c *Client) sendJSONRPCRequest(request types.JSONRPCRequest) (*types.JSONRP
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("MCP-Protocol-Version", "2024-11-05")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
 
 	// Send HTTP request
 	resp, err := c.httpClient.Do(httpReq)
@@ -367,7 +377,7 @@ func (c *Client) sendJSONRPCRequest(request types.JSONRPCRequest) (*types.JSONRP
 	}
 
 	if c.verbose {
-		log.Printf("Received JSON-RPC response: %s", string(respData))
+		slog.Info("Received JSON-RPC response", "response", string(respData))
 	}
 
 	// Check HTTP status
@@ -393,7 +403,7 @@ func (c *Client) sendJSONRPCNotification(notification types.JSONRPCRequest) erro
 	}
 
 	if c.verbose {
-		log.Printf("Sending JSON-RPC notification: %s", string(reqData))
+		slog.Info("Sending JSON-RPC notification", "notification", string(reqData))
 	}
 
 	// Create HTTP request
@@ -405,6 +415,9 @@ func (c *Client) sendJSONRPCNotification(notification types.JSONRPCRequest) erro
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("MCP-Protocol-Version", "2024-11-05")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
 
 	// Send HTTP request
 	resp, err := c.httpClient.Do(httpReq)
@@ -0,0 +1,286 @@
+// ************************************************************************************************
+// Package parser provides the chunking of large indexed files. A file longer than a chunk is
+// cut where its constructs start: Go declarations, the declarations found by the source parsers,
+// Markdown headings, or the file and package sections of the repomix-style documents. The
+// comments and annotations written right before a construct stay with it. Consecutive
+// constructs are packed into chunks up to the maximum length, and a construct longer than
+// that is split into windows sharing a few lines, like files without known structure.
+package parser
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// Default chunking limits, used when the IndexingConfig values are zero.
+const (
+	defaultChunkMaxLines = 200
+	defaultChunkOverlap  = 20
+)
+
+// ************************************************************************************************
+// chunkLanguages are the source languages whose declarations delimit chunks.
+var chunkLanguages = []SourceLanguage{PythonLanguage, TypeScriptLanguage, RustLanguage, JavaLanguage, CSharpLanguage, ProtobufLanguage, TreeSitterLanguage}
+
+// ************************************************************************************************
+// chunkMemberTypes are the construct types too small to start a chunk of their own.
+var chunkMemberTypes = []string{"field", "property", "event"}
+
+// ************************************************************************************************
+// chunkBoundary is a line where a construct or section starts.
+type chunkBoundary struct {
+	line int
+	kind string
+	name string
+}
+
+// ************************************************************************************************
+// ChunkFile splits a file into chunks of at most maxLines lines, consecutive windows of a long
+// construct sharing overlap lines. Files of maxLines lines or less are not chunked. A zero
+// maxLines or overlap uses the default and a negative maxLines disables chunking.
+//
+// Returns:
+//   - []types.Chunk: The chunks covering the file, nil if it is not chunked.
+//
+// Example usage:
+//
+//	chunks := parser.ChunkFile(file.Path, file.Content, config.ChunkMaxLines, config.ChunkOverlap)
+func ChunkFile(path, content string, maxLines, overlap int) []types.Chunk {
+	if maxLines == 0 {
+		maxLines = defaultChunkMaxLines
+	}
+	if overlap == 0 {
+		overlap = defaultChunkOverlap
+	}
+	if maxLines < 0 {
+		return nil
+	}
+	overlap = min(max(overlap, 0), maxLines-1)
+
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) <= maxLines {
+		return nil
+	}
+
+	// Cut the file into segments, each starting at a boundary
+	var segments []types.Chunk
+	boundaries := chunkBoundaries(path, content, lines)
+	if len(boundaries) == 0 || boundaries[0].line > 1 {
+		kind := "preamble"
+		if len(boundaries) == 0 {
+			kind = "lines"
+		}
+		segments = append(segments, types.Chunk{Start: 1, Kind: kind})
+	}
+	for _, boundary := range boundaries {
+		if last := len(segments) - 1; last >= 0 && segments[last].Start == boundary.line {
+			if boundary.name != "" {
+				segments[last].Symbols = append(segments[last].Symbols, boundary.name)
+			}
+			continue
+		}
+		segment := types.Chunk{Start: boundary.line, Kind: boundary.kind}
+		if boundary.name != "" {
+			segment.Symbols = []string{boundary.name}
+		}
+		segments = append(segments, segment)
+	}
+	for index := range segments {
+		segments[index].End = len(lines)
+		if index+1 < len(segments) {
+			segments[index].End = segments[index+1].Start - 1
+		}
+	}
+
+	// Pack consecutive segments, splitting the long ones into windows
+	var chunks []types.Chunk
+	var current *types.Chunk
+	for _, segment := range segments {
+		if current != nil && segment.End-current.Start+1 <= maxLines {
+			current.End = segment.End
+			current.Symbols = append(current.Symbols, segment.Symbols...)
+			continue
+		}
+		if current != nil {
+			chunks = append(chunks, *current)
+			current = nil
+		}
+		if segment.End-segment.Start+1 <= maxLines {
+			current = &segment
+			continue
+		}
+		for start := segment.Start; ; start += maxLines - overlap {
+			window := segment
+			window.Start, window.End = start, min(start+maxLines-1, segment.End)
+			chunks = append(chunks, window)
+			if window.End == segment.End {
+				break
+			}
+		}
+	}
+	if current != nil {
+		chunks = append(chunks, *current)
+	}
+	return chunks
+}
+
+// ************************************************************************************************
+// chunkBoundaries returns the lines where the constructs or sections of a file start, sorted
+// and past the first line of the file.
+func chunkBoundaries(path, content string, lines []string) []chunkBoundary {
+	extension := strings.ToLower(filepath.Ext(path))
+
+	var boundaries []chunkBoundary
+	switch {
+	case extension == ".go":
+		boundaries = goChunkBoundaries(path, content)
+	case slices.Contains(MarkdownExtensions, extension):
+		for _, section := range ParseMarkdown(content) {
+			boundaries = append(boundaries, chunkBoundary{line: section.Start, kind: "section", name: section.Title})
+		}
+	case extension == ".xml":
+		boundaries = xmlChunkBoundaries(lines)
+	default:
+		for _, language := range chunkLanguages {
+			if !slices.Contains(language.Extensions, extension) {
+				continue
+			}
+			for _, construct := range language.ParseFile(path, []byte(content)).Constructs {
+				if !slices.Contains(chunkMemberTypes, construct.Type) {
+					boundaries = append(boundaries, chunkBoundary{line: construct.Line, kind: construct.Type, name: construct.Name})
+				}
+			}
+			sort.SliceStable(boundaries, func(a, b int) bool {
+				return boundaries[a].line < boundaries[b].line
+			})
+			attachLeadingComments(boundaries, lines)
+			break
+		}
+	}
+
+	sort.SliceStable(boundaries, func(a, b int) bool {
+		return boundaries[a].line < boundaries[b].line
+	})
+	return slices.DeleteFunc(boundaries, func(boundary chunkBoundary) bool {
+		return boundary.line < 1 || boundary.line > len(lines)
+	})
+}
+
+// ************************************************************************************************
+// goChunkBoundaries returns the top-level declarations of a Go file, imports left out, starting
+// at their doc comments. Files that do not parse have none.
+func goChunkBoundaries(path, content string) []chunkBoundary {
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, path, content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	var boundaries []chunkBoundary
+	for _, decl := range file.Decls {
+		start := decl.Pos()
+		boundary := chunkBoundary{}
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			boundary.kind, boundary.name = "func", decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				receiver, _ := receiverTypeName(decl.Recv.List[0].Type)
+				boundary.kind, boundary.name = "method", receiver+"."+decl.Name.Name
+			}
+			if decl.Doc != nil {
+				start = decl.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if decl.Tok == token.IMPORT {
+				continue
+			}
+			boundary.kind = decl.Tok.String()
+			var names []string
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, spec.Name.Name)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						names = append(names, name.Name)
+					}
+				}
+			}
+			boundary.name = strings.Join(names, ", ")
+			if decl.Doc != nil {
+				start = decl.Doc.Pos()
+			}
+		}
+		boundary.line = fileSet.Position(start).Line
+		boundaries = append(boundaries, boundary)
+	}
+	return boundaries
+}
+
+// ************************************************************************************************
+// xmlChunkBoundaries returns the summary, directory structure, file and package sections of a
+// repomix-style document.
+func xmlChunkBoundaries(lines []string) []chunkBoundary {
+	var boundaries []chunkBoundary
+	for index, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "<file_summary>":
+			boundaries = append(boundaries, chunkBoundary{line: index + 1, kind: "summary"})
+		case line == "<directory_structure>":
+			boundaries = append(boundaries, chunkBoundary{line: index + 1, kind: "directory_structure"})
+		case strings.HasPrefix(line, `<file path="`):
+			boundaries = append(boundaries, chunkBoundary{line: index + 1, kind: "file", name: xmlAttribute(line, "path")})
+		case strings.HasPrefix(line, `<package name="`):
+			boundaries = append(boundaries, chunkBoundary{line: index + 1, kind: "package", name: xmlAttribute(line, "name")})
+		}
+	}
+	return boundaries
+}
+
+// ************************************************************************************************
+// xmlAttribute returns the unescaped value of a double-quoted attribute of an XML start tag.
+func xmlAttribute(tag, name string) string {
+	_, value, found := strings.Cut(tag, " "+name+`="`)
+	if !found {
+		return ""
+	}
+	value, _, _ = strings.Cut(value, `"`)
+	return strings.NewReplacer("&quot;", `"`, "&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(value)
+}
+
+// ************************************************************************************************
+// attachLeadingComments moves the boundaries of sorted constructs up to the comments,
+// decorators and attributes written right before them, never past the previous boundary.
+func attachLeadingComments(boundaries []chunkBoundary, lines []string) {
+	for index := range boundaries {
+		limit := 1
+		if index > 0 {
+			limit = boundaries[index-1].line + 1
+		}
+		for boundaries[index].line > limit && boundaries[index].line <= len(lines) && isLeadingCommentLine(lines[boundaries[index].line-2]) {
+			boundaries[index].line--
+		}
+	}
+}
+
+// ************************************************************************************************
+// isLeadingCommentLine reports whether a line is a comment, decorator or attribute line, which
+// documents the declaration following it.
+func isLeadingCommentLine(line string) bool {
+	line = strings.TrimSpace(line)
+	for _, prefix := range []string{"//", "/*", "*", "#", "@", "["} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
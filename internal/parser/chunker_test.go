@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+func TestChunkFile(t *testing.T) {
+	goSrc := "package cache\n\nimport \"fmt\"\n\n" + // 1-4
+		"// Get returns a value.\nfunc Get() string {\n\treturn fmt.Sprint(1)\n}\n\n" + // 5-9
+		"type Cache struct{}\n\n" + // 10-11
+		"// Evict drops every entry.\nfunc (c *Cache) Evict() {\n" + // 12-13
+		strings.Repeat("\tc.drop()\n", 10) + "}\n" // 14-24
+	pythonSrc := "import os\n\n@cached\ndef load():\n    return os.getcwd()\n\n" + // 1-6
+		"class Store:\n    # Saves.\n    def save(self):\n        pass\n" // 7-10
+	xmlSrc := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<repository>\n" + // 1-2
+		"<file_summary>\nSummary.\n</file_summary>\n<files>\n" + // 3-6
+		"<file path=\"a&amp;b.go\" package=\"a\">\nfunc A()\n</file>\n</files>\n</repository>\n" // 7-11
+
+	tests := []struct {
+		path     string
+		content  string
+		maxLines int
+		overlap  int
+		expected []types.Chunk
+	}{
+		{"cache.go", goSrc, 8, 2, []types.Chunk{
+			{Start: 1, End: 4, Kind: "preamble"},
+			{Start: 5, End: 11, Kind: "func", Symbols: []string{"Get", "Cache"}},
+			{Start: 12, End: 19, Kind: "method", Symbols: []string{"Cache.Evict"}},
+			{Start: 18, End: 24, Kind: "method", Symbols: []string{"Cache.Evict"}},
+		}},
+		{"store.py", pythonSrc, 4, 1, []types.Chunk{
+			{Start: 1, End: 2, Kind: "preamble"},
+			{Start: 3, End: 6, Kind: "function", Symbols: []string{"load"}},
+			{Start: 7, End: 10, Kind: "class", Symbols: []string{"Store", "Store.save"}},
+		}},
+		{"guide.md", "# Guide\nIntro.\n## Install\nRun make.\n## Usage\nCall it.\n", 3, 1, []types.Chunk{
+			{Start: 1, End: 2, Kind: "section", Symbols: []string{"Guide"}},
+			{Start: 3, End: 4, Kind: "section", Symbols: []string{"Install"}},
+			{Start: 5, End: 6, Kind: "section", Symbols: []string{"Usage"}},
+		}},
+		{".repomix.xml", xmlSrc, 4, 1, []types.Chunk{
+			{Start: 1, End: 2, Kind: "preamble"},
+			{Start: 3, End: 6, Kind: "summary"},
+			{Start: 7, End: 10, Kind: "file", Symbols: []string{"a&b.go"}},
+			{Start: 10, End: 11, Kind: "file", Symbols: []string{"a&b.go"}},
+		}},
+		{"notes.txt", strings.Repeat("line\n", 10), 4, 1, []types.Chunk{
+			{Start: 1, End: 4, Kind: "lines"},
+			{Start: 4, End: 7, Kind: "lines"},
+			{Start: 7, End: 10, Kind: "lines"},
+		}},
+		{"short.txt", "one\ntwo\nthree\n", 4, 1, nil},
+		{"notes.txt", strings.Repeat("line\n", 10), -1, 0, nil},
+	}
+	for _, test := range tests {
+		got := ChunkFile(test.path, test.content, test.maxLines, test.overlap)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %+v, got %+v", test.path, test.expected, got)
+		}
+	}
+
+	// Default limits leave files of 200 lines whole
+	if chunks := ChunkFile("notes.txt", strings.Repeat("line\n", 200), 0, 0); chunks != nil {
+		t.Errorf("Expected no chunks with the default limits, got %+v", chunks)
+	}
+	if chunks := ChunkFile("notes.txt", strings.Repeat("line\n", 201), 0, 0); len(chunks) != 2 || chunks[1].Start != 181 {
+		t.Errorf("Expected two windows sharing 20 lines with the default limits, got %+v", chunks)
+	}
+}
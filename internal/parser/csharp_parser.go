@@ -0,0 +1,644 @@
+// ************************************************************************************************
+// Package parser provides the C# source parser of the repomix-mcp application. Sources are split
+// into tokens, preprocessor directives left out, then the type declarations of every namespace
+// are scanned: classes, structs, interfaces, records, enums and delegates with their
+// constructors, methods, properties, events and fields, nested types included. Attributes are
+// kept with the declarations they apply to, and XML doc comments give their documentation. A
+// declaration is exported when it is public in an exported type; the members of interfaces are
+// public unless declared private.
+package parser
+
+import (
+	"html"
+	"path"
+	"regexp"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// CSharpLanguage describes the C# sources indexed by the csharp_native strategy.
+var CSharpLanguage = SourceLanguage{
+	Name:           "csharp",
+	DisplayName:    "C#",
+	Strategy:       types.IndexingStrategyCSharpNative,
+	Extensions:     []string{".cs"},
+	SkippedDirs:    []string{"bin", "obj", "packages", "TestResults"},
+	CommentPrefix:  "//",
+	ConstructTypes: []string{"interface", "class", "struct", "record", "enum", "delegate", "constructor", "property", "event", "method", "field"},
+	IsTestFile:     isCSharpTestFile,
+	ParseFile:      ParseCSharpFile,
+}
+
+// ************************************************************************************************
+// CSharpProjectPatterns match the files at the root of a repository marking a .NET solution or
+// project.
+var CSharpProjectPatterns = []string{"*.sln", "*.csproj"}
+
+// ************************************************************************************************
+// csharpDocReference matches the XML doc elements naming a symbol or a keyword, such as
+// <see cref="Client"/> or <paramref name="url"/>.
+var csharpDocReference = regexp.MustCompile(`<(?:see|seealso|paramref|typeparamref)\s+(?:cref|name|langword|href)="(?:[A-Z]:)?([^"]*)"\s*/>`)
+
+// ************************************************************************************************
+// csharpDocSection matches the summary and remarks elements of an XML doc comment.
+var csharpDocSection = regexp.MustCompile(`(?s)<(summary|remarks)>(.*?)</(?:summary|remarks)>`)
+
+// ************************************************************************************************
+// csharpDocTag matches the other XML doc tags, such as <c> or <para>.
+var csharpDocTag = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+
+// ************************************************************************************************
+// NewCSharpParser creates the parser of C# repositories.
+//
+// Example usage:
+//
+//	repoIndex, err := parser.NewCSharpParser().ParseRepository("billing", "/path/to/billing", config)
+func NewCSharpParser() *SourceParser {
+	return NewSourceParser(CSharpLanguage)
+}
+
+// ************************************************************************************************
+// csharpParser extracts the declarations of the tokens of a file.
+type csharpParser struct {
+	tokenStream
+	analysis       *SourceFile
+	namespace      string // Namespace of the declarations being scanned
+	firstNamespace string // First namespace declared by the file
+}
+
+// ************************************************************************************************
+// ParseCSharpFile extracts the namespace and the type declarations of a C# file. The file is
+// grouped by the namespace of its first type, "global" when it declares none.
+//
+// Example usage:
+//
+//	analysis := parser.ParseCSharpFile("src/Billing/Invoice.cs", src)
+func ParseCSharpFile(relPath string, src []byte) *SourceFile {
+	p := &csharpParser{
+		tokenStream: newTokenStream(scanCSharp(string(src))),
+		analysis:    &SourceFile{Path: relPath},
+	}
+	p.parseMembers(0, len(p.tokens), "", "", true, "")
+
+	if p.analysis.Package == "" {
+		p.analysis.Package = p.firstNamespace
+	}
+	if p.analysis.Package == "" {
+		p.analysis.Package = "global"
+	}
+	p.analysis.Module = p.analysis.Package
+	return p.analysis
+}
+
+// ************************************************************************************************
+// scanCSharp splits C# source into tokens, comments and preprocessor directives left out. The
+// lines of /// XML doc comments are attached to the next token.
+func scanCSharp(src string) []sourceToken {
+	var tokens []sourceToken
+	var docLines []string
+	line := 1
+	newline, lineStart := false, true
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		start, startLine := i, line
+		literal := false
+
+		switch {
+		case c == '\n':
+			line++
+			newline, lineStart = true, true
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+			continue
+		case c == '#' && lineStart, strings.HasPrefix(src[i:], "//"):
+			// Preprocessor directives and line comments, /// lines being documentation
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			if strings.HasPrefix(src[start:], "///") && !strings.HasPrefix(src[start:], "////") {
+				docLines = append(docLines, src[start+3:i])
+			}
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i + 4
+			}
+			line += strings.Count(src[i:end], "\n")
+			i = end
+			continue
+		case c == '"' || (c == '$' || c == '@') && strings.IndexByte(src[i:min(i+3, len(src))], '"') > 0:
+			i = skipCSharpString(src, i)
+			literal = true
+		case c == '\'':
+			i = skipScriptString(src, i)
+			literal = true
+		case isIdentifierStart(c) || c >= '0' && c <= '9' || c == '@' && i+1 < len(src) && isIdentifierStart(src[i+1]):
+			// Verbatim identifiers such as @class are kept whole, never read as keywords
+			i++
+			for i < len(src) && (isIdentifierStart(src[i]) || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			if c >= '0' && c <= '9' {
+				for i < len(src) && (src[i] == '.' || isIdentifierStart(src[i]) || src[i] >= '0' && src[i] <= '9') {
+					i++
+				}
+			}
+		case strings.HasPrefix(src[i:], "=>"):
+			i += 2
+		default:
+			i++
+		}
+
+		text := src[start:i]
+		line += strings.Count(text, "\n")
+		token := sourceToken{text: text, literal: literal, start: start, end: i, line: startLine, newline: newline}
+		if len(docLines) > 0 {
+			token.doc = csharpDocText(strings.Join(docLines, "\n"))
+		}
+		tokens = append(tokens, token)
+		docLines, newline, lineStart = nil, false, false
+	}
+	return tokens
+}
+
+// ************************************************************************************************
+// parseMembers extracts the namespaces, type declarations and members between two token indexes:
+// the top level of a file or the body of a namespace when owner is empty, or the body of a type.
+//
+// Parameters:
+//   - owner: The qualified name of the enclosing type, e.g. "Outer.Inner".
+//   - ownerKind: The declaration keyword of the enclosing type, e.g. "interface".
+//   - ownerExported: Whether the enclosing type is exported.
+//   - className: The simple name of the enclosing type, naming its constructors.
+func (p *csharpParser) parseMembers(lo, hi int, owner, ownerKind string, ownerExported bool, className string) {
+	for i := lo; i < hi; {
+		if p.is(i, ";") {
+			i++
+			continue
+		}
+
+		doc := p.tokens[i].doc
+		attributes, sigStart := p.parseAttributes(i, hi)
+		if sigStart < hi && doc == "" {
+			doc = p.tokens[sigStart].doc
+		}
+
+		// Using directives and extern aliases
+		if owner == "" && (p.is(sigStart, "using") || p.is(sigStart, "global") && p.is(sigStart+1, "using") ||
+			p.is(sigStart, "extern") && p.is(sigStart+1, "alias")) {
+			i = p.endOfCSharpStatement(sigStart, hi) + 1
+			continue
+		}
+
+		// Namespaces, block-scoped or file-scoped, after the assembly attributes
+		if owner == "" && p.is(sigStart, "namespace") {
+			end := sigStart + 1
+			for end < hi && !p.is(end, "{") && !p.is(end, ";") {
+				end++
+			}
+			name := p.join(sigStart+1, end)
+			if p.namespace != "" {
+				name = p.namespace + "." + name
+			}
+			if p.firstNamespace == "" {
+				p.firstNamespace = name
+			}
+			if p.is(end, "{") {
+				enclosing := p.namespace
+				p.namespace = name
+				p.parseMembers(end+1, p.matchOr(end, hi), "", "", true, "")
+				p.namespace = enclosing
+			} else {
+				p.namespace = name
+			}
+			i = p.skip(end)
+			continue
+		}
+
+		// Modifiers
+		j := sigStart
+		public, private, static, event := false, false, false, false
+		for j < hi && p.isWord(j) && isCSharpModifier(p.tokens[j].text) {
+			public = public || p.is(j, "public")
+			private = private || p.is(j, "private")
+			static = static || p.is(j, "static")
+			event = event || p.is(j, "event")
+			j++
+		}
+		if ownerKind == "interface" {
+			public = !private
+		}
+		exported := public && ownerExported
+
+		add := func(constructType, name, signature string) {
+			if owner == "" && p.analysis.Package == "" {
+				p.analysis.Package = p.namespace
+			}
+			p.analysis.Constructs = append(p.analysis.Constructs, SourceConstruct{
+				Type:        constructType,
+				Name:        name,
+				Signature:   signature,
+				Annotations: attributes,
+				File:        p.analysis.Path,
+				Line:        p.tokens[min(sigStart, len(p.tokens)-1)].line,
+				Summary:     docSummary(doc),
+				Doc:         doc,
+				Exported:    exported,
+			})
+		}
+		qualify := func(name string) string {
+			if owner == "" {
+				return name
+			}
+			return owner + "." + name
+		}
+
+		// Type declarations, nested types included
+		kind, nameIndex := "", j+1
+		switch {
+		case p.is(j, "record"):
+			kind = "record"
+			if p.is(j+1, "class") || p.is(j+1, "struct") {
+				nameIndex++
+			}
+		case p.is(j, "class") || p.is(j, "struct") || p.is(j, "interface") || p.is(j, "enum"):
+			kind = p.tokens[j].text
+		}
+		if kind != "" && p.isWord(nameIndex) {
+			name := p.tokens[nameIndex].text
+			body := nameIndex + 1
+			for body < hi && !p.is(body, "{") && !p.is(body, ";") {
+				body = p.skip(body)
+			}
+			signature := p.join(sigStart, body)
+			if kind == "enum" && p.is(body, "{") {
+				signature += " { " + strings.Join(p.enumMembers(body+1, p.matchOr(body, hi)), ", ") + " }"
+			}
+			add(kind, qualify(name), signature)
+			if p.is(body, "{") && kind != "enum" {
+				p.parseMembers(body+1, p.matchOr(body, hi), qualify(name), kind, exported, name)
+			}
+			i = p.skip(body)
+			continue
+		}
+		if p.is(j, "delegate") {
+			end := p.endOfCSharpStatement(j, hi)
+			k := j + 1
+			for k < end && !p.is(k, "(") {
+				k++
+			}
+			if name, _ := p.csharpMethodName(j+1, k); name != "" {
+				add("delegate", qualify(name), p.join(sigStart, end))
+			}
+			i = end + 1
+			continue
+		}
+
+		// Static constructors, other blocks and the top-level statements of a program
+		if p.is(j, "{") || owner == "" {
+			i = p.skipCSharpBody(j, hi)
+			continue
+		}
+
+		// Methods, constructors, properties, events and fields: the first "(", "=", "=>", ";" or
+		// "{" outside generic arguments tells them apart, tuple types starting a declaration aside
+		k, depth := j, 0
+		for k < hi {
+			if depth == 0 && (p.is(k, "(") && k > j || p.is(k, "=") || p.is(k, "=>") || p.is(k, ";") || p.is(k, "{")) {
+				break
+			}
+			switch {
+			case p.is(k, "operator"):
+				// Operator tokens such as < are not generic brackets
+				for k+1 < hi && !p.is(k+1, "(") {
+					k++
+				}
+			case p.is(k, "<"):
+				depth++
+			case p.is(k, ">"):
+				depth--
+			}
+			k = p.skip(k)
+		}
+
+		switch {
+		case k >= hi:
+			i = hi
+		case p.is(k, "("):
+			name, nameStart := p.csharpMethodName(j, k)
+			if name == "" || p.is(nameStart-1, "~") || static && name == className && nameStart == j {
+				// Destructors and static constructors are not part of the API
+				i = p.skipCSharpBody(k, hi)
+				continue
+			}
+			constructType := "method"
+			if name == className && nameStart == j {
+				constructType = "constructor"
+			}
+			if strings.Contains(name, ".") && !strings.HasPrefix(name, "operator") {
+				// Explicit interface implementations are public through their interface
+				exported = ownerExported
+			}
+			end := p.matchOr(k, hi) + 1
+			for end < hi && !p.is(end, "{") && !p.is(end, ";") && !p.is(end, "=>") &&
+				!(constructType == "constructor" && p.is(end, ":")) {
+				end = p.skip(end)
+			}
+			add(constructType, qualify(name), p.join(sigStart, end))
+			i = p.skipCSharpBody(end, hi)
+		case (p.is(k, "{") || p.is(k, "=>")) && k > j && (p.isWord(k-1) || p.is(k-1, "]")):
+			name := p.tokens[k-1].text
+			if p.is(k-1, "]") {
+				name = "this[]"
+			}
+			constructType, accessors := "property", "{ get; }"
+			if event {
+				constructType, accessors = "event", ""
+			}
+			if p.is(k, "{") {
+				if !event {
+					accessors = p.accessorList(k+1, p.matchOr(k, hi))
+				}
+				i = p.skip(k)
+				if p.is(i, "=") {
+					i = p.endOfCSharpStatement(i, hi) + 1
+				}
+			} else {
+				i = p.endOfCSharpStatement(k, hi) + 1
+			}
+			add(constructType, qualify(name), strings.TrimSpace(p.join(sigStart, k)+" "+accessors))
+		case (p.is(k, "=") || p.is(k, ";")) && k > j && p.isWord(k-1):
+			constructType := "field"
+			if event {
+				constructType = "event"
+			}
+			add(constructType, qualify(p.tokens[k-1].text), p.join(sigStart, k))
+			i = p.endOfCSharpStatement(k, hi) + 1
+		default:
+			i = p.skip(max(k, i))
+		}
+	}
+}
+
+// ************************************************************************************************
+// csharpMethodName returns the name of the method whose parameter list opens at a token:
+// "Get" for "Get<T>(", "operator +" for operators and "IDisposable.Dispose" for explicit
+// interface implementations.
+//
+// Returns:
+//   - string: The method name, empty when the tokens do not declare a method.
+//   - int: The index of the first token of the name.
+func (p *csharpParser) csharpMethodName(lo, open int) (string, int) {
+	for k := lo; k < open; k++ {
+		if p.is(k, "operator") {
+			start := k
+			if p.is(k-1, "implicit") || p.is(k-1, "explicit") {
+				start--
+			}
+			return p.join(k, open), start
+		}
+	}
+
+	// Type parameters follow the name
+	end := open - 1
+	if p.is(end, ">") {
+		depth := 0
+		for ; end > lo; end-- {
+			if p.is(end, ">") {
+				depth++
+			} else if p.is(end, "<") {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+		end--
+	}
+	if end < lo || !p.isWord(end) {
+		return "", open
+	}
+	start := end
+	for start-2 >= lo && p.is(start-1, ".") && p.isWord(start-2) {
+		start -= 2
+	}
+	return p.join(start, end+1), start
+}
+
+// ************************************************************************************************
+// parseAttributes reads the attribute sections starting at a token, e.g. [Serializable] or
+// [Route("api/[controller]")]. Assembly and module attributes are skipped without being kept.
+//
+// Returns:
+//   - []string: The attribute sections, each on a single line.
+//   - int: The index of the token after the attributes.
+func (p *csharpParser) parseAttributes(i, hi int) ([]string, int) {
+	var attributes []string
+	for p.is(i, "[") && p.match[i] > i {
+		if !(p.is(i+1, "assembly") || p.is(i+1, "module")) || !p.is(i+2, ":") {
+			attributes = append(attributes, p.join(i, p.match[i]+1))
+		}
+		i = p.skip(i)
+	}
+	return attributes, i
+}
+
+// ************************************************************************************************
+// enumMembers returns the names of the members of an enum body.
+func (p *csharpParser) enumMembers(lo, hi int) []string {
+	var members []string
+	expectName := true
+	for i := lo; i < hi; i = p.skip(i) {
+		if p.is(i, ",") {
+			expectName = true
+			continue
+		}
+		if expectName {
+			_, i = p.parseAttributes(i, hi)
+			if p.isWord(i) {
+				members = append(members, p.tokens[i].text)
+			}
+			expectName = false
+		}
+	}
+	return members
+}
+
+// ************************************************************************************************
+// accessorList renders the accessors of a property body on a single line, without their bodies,
+// e.g. "{ get; private set; }".
+func (p *csharpParser) accessorList(lo, hi int) string {
+	var accessors []string
+	for i := lo; i < hi; {
+		_, start := p.parseAttributes(i, hi)
+		end := start
+		for end < hi && p.isWord(end) {
+			end++
+		}
+		if end > start {
+			accessors = append(accessors, p.join(start, end)+";")
+		}
+		if p.is(end, "=>") {
+			end = p.endOfCSharpStatement(end, hi)
+		}
+		i = p.skip(max(end, start))
+	}
+	return "{ " + strings.Join(accessors, " ") + " }"
+}
+
+// ************************************************************************************************
+// skipCSharpBody returns the index of the token after the body of a member, a block or an
+// expression body, starting at a token before it.
+func (p *csharpParser) skipCSharpBody(i, hi int) int {
+	for ; i < hi; i = p.skip(i) {
+		switch {
+		case p.is(i, "{"):
+			return p.skip(i)
+		case p.is(i, ";"), p.is(i, "=>"):
+			return p.endOfCSharpStatement(i, hi) + 1
+		}
+	}
+	return hi
+}
+
+// ************************************************************************************************
+// endOfCSharpStatement returns the index of the semicolon ending a statement.
+func (p *csharpParser) endOfCSharpStatement(i, hi int) int {
+	for j := i; j < hi; j = p.skip(j) {
+		if p.is(j, ";") {
+			return j
+		}
+	}
+	return hi
+}
+
+// ************************************************************************************************
+// skipCSharpString returns the offset after the string literal starting at an offset: regular,
+// verbatim (@"...") and raw ("""...""") strings, interpolated ($"...") or not. Regular strings
+// end at the end of the line when unterminated.
+func skipCSharpString(src string, i int) int {
+	verbatim, interpolated := false, false
+	for ; i < len(src) && src[i] != '"'; i++ {
+		verbatim = verbatim || src[i] == '@'
+		interpolated = interpolated || src[i] == '$'
+	}
+
+	// Raw strings end with as many quotes as they start with
+	quotes := 0
+	for i+quotes < len(src) && src[i+quotes] == '"' {
+		quotes++
+	}
+	if quotes >= 3 {
+		if end := strings.Index(src[i+quotes:], src[i:i+quotes]); end >= 0 {
+			return i + quotes + end + quotes
+		}
+		return len(src)
+	}
+
+	for i++; i < len(src); i++ {
+		switch {
+		case src[i] == '\\' && !verbatim:
+			i++
+		case src[i] == '"' && verbatim && i+1 < len(src) && src[i+1] == '"':
+			i++
+		case src[i] == '"':
+			return i + 1
+		case src[i] == '{' && interpolated:
+			if i+1 < len(src) && src[i+1] == '{' {
+				i++
+			} else {
+				i = skipCSharpInterpolation(src, i+1)
+			}
+		case src[i] == '\n' && !verbatim:
+			return i
+		}
+	}
+	return len(src)
+}
+
+// ************************************************************************************************
+// skipCSharpInterpolation returns the offset of the brace closing the interpolation hole whose
+// expression starts at an offset.
+func skipCSharpInterpolation(src string, i int) int {
+	depth := 1
+	for ; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case c == '"' || (c == '$' || c == '@') && i+1 < len(src) && (src[i+1] == '"' || src[i+1] == '$' || src[i+1] == '@'):
+			i = skipCSharpString(src, i) - 1
+		case c == '\'':
+			i = skipScriptString(src, i) - 1
+		}
+	}
+	return len(src)
+}
+
+// ************************************************************************************************
+// csharpDocText returns the description of an XML doc comment: the text of its summary and
+// remarks elements, references replaced by the names they refer to and the other tags removed.
+// Comments without these elements are kept whole.
+func csharpDocText(comment string) string {
+	if strings.Contains(comment, "<inheritdoc") {
+		return ""
+	}
+	var paragraphs []string
+	sections := csharpDocSection.FindAllStringSubmatch(comment, -1)
+	if len(sections) == 0 {
+		sections = [][]string{{comment, "", comment}}
+	}
+	for _, section := range sections {
+		text := csharpDocReference.ReplaceAllString(section[2], "$1")
+		text = html.UnescapeString(csharpDocTag.ReplaceAllString(text, ""))
+		var lines []string
+		for _, line := range strings.Split(text, "\n") {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+		if paragraph := strings.Trim(strings.Join(lines, "\n"), "\n"); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// ************************************************************************************************
+// isCSharpModifier reports whether a word is a modifier of declarations.
+func isCSharpModifier(word string) bool {
+	switch word {
+	case "public", "protected", "private", "internal", "file", "static", "abstract", "sealed",
+		"virtual", "override", "readonly", "const", "new", "partial", "async", "extern", "unsafe",
+		"volatile", "required", "ref", "event", "fixed":
+		return true
+	}
+	return false
+}
+
+// ************************************************************************************************
+// isCSharpTestFile reports whether a file belongs to a test project, e.g.
+// "Billing.Tests/InvoiceTests.cs", or is generated, e.g. "Form1.Designer.cs".
+func isCSharpTestFile(relPath string) bool {
+	name := strings.TrimSuffix(path.Base(relPath), ".cs")
+	if strings.HasSuffix(name, "Tests") || strings.HasSuffix(name, "Test") ||
+		strings.HasSuffix(name, ".g") || strings.HasSuffix(name, ".g.i") || strings.HasSuffix(name, ".Designer") {
+		return true
+	}
+	for _, dir := range strings.Split(path.Dir(relPath), "/") {
+		if strings.Contains(dir, ".") && (strings.HasSuffix(dir, "Tests") || strings.HasSuffix(dir, ".Test")) {
+			return true
+		}
+	}
+	return false
+}
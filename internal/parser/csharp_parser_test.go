@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+func TestParseCSharpFile(t *testing.T) {
+	src := `using System;
+using static System.Math;
+[assembly: InternalsVisibleTo("Billing.Tests")]
+
+namespace Acme.Billing;
+
+#region Invoices
+/// <summary>
+/// Bills a <see cref="T:Acme.Customer"/> for its orders.
+/// </summary>
+/// <remarks>Amounts are in &lt;cents&gt;.</remarks>
+/// <param name="id">Ignored.</param>
+[Serializable]
+[Table("invoices")]
+public sealed partial class Invoice<T> : IDisposable where T : class, new()
+{
+    public const int MaxLines = 100;
+    private readonly string _id = $"inv-{Guid.NewGuid():N}-{"}"}";
+    internal static string Query = @"select ""}"" from x";
+
+    /// <summary>Creates an invoice.</summary>
+    public Invoice(string id) : this() { _id = id; }
+
+    static Invoice() { }
+
+    ~Invoice() { }
+
+    public decimal Total { get; private set; } = 0m;
+    public int Count => _lines.Count;
+    public string this[int index] { get => _lines[index]; }
+
+    public event EventHandler<EventArgs> Paid;
+
+    /// <inheritdoc/>
+    public async Task<(bool ok, string error)> PayAsync<TResult>(CancellationToken token = default) where TResult : struct
+    {
+        var text = """
+            class Hidden { }
+            """;
+        return (true, null);
+    }
+
+    public static Invoice<T> operator +(Invoice<T> a, Invoice<T> b) => a;
+
+    void IDisposable.Dispose() { }
+
+    private void Reset() { }
+
+    public class Line
+    {
+        public string Sku { get; init; }
+    }
+}
+#endregion
+
+public interface IPayable
+{
+    bool Pay(decimal amount);
+    decimal Balance { get; }
+}
+
+internal record struct Money(decimal Amount, string Currency);
+
+public record Customer(string Name)
+{
+    public string @class { get; set; }
+}
+
+[Flags]
+public enum Status : byte
+{
+    None = 0,
+    [Description("paid")] Paid = 1 << 0,
+    Void,
+}
+
+public delegate Task<bool> PaymentHandler(Invoice<object> invoice);
+`
+	analysis := ParseCSharpFile("src/Billing/Invoice.cs", []byte(src))
+
+	if analysis.Module != "Acme.Billing" || analysis.Package != "Acme.Billing" {
+		t.Errorf("Expected module Acme.Billing in package Acme.Billing, got %s in %s", analysis.Module, analysis.Package)
+	}
+
+	expected := []SourceConstruct{
+		{Type: "class", Name: "Invoice", Signature: "public sealed partial class Invoice<T> : IDisposable where T : class, new()", Line: 15, Exported: true, Summary: "Bills a Acme.Customer for its orders."},
+		{Type: "field", Name: "Invoice.MaxLines", Signature: "public const int MaxLines", Line: 17, Exported: true},
+		{Type: "field", Name: "Invoice._id", Signature: "private readonly string _id", Line: 18},
+		{Type: "field", Name: "Invoice.Query", Signature: "internal static string Query", Line: 19},
+		{Type: "constructor", Name: "Invoice.Invoice", Signature: "public Invoice(string id)", Line: 22, Exported: true, Summary: "Creates an invoice."},
+		{Type: "property", Name: "Invoice.Total", Signature: "public decimal Total { get; private set; }", Line: 28, Exported: true},
+		{Type: "property", Name: "Invoice.Count", Signature: "public int Count { get; }", Line: 29, Exported: true},
+		{Type: "property", Name: "Invoice.this[]", Signature: "public string this[int index] { get; }", Line: 30, Exported: true},
+		{Type: "event", Name: "Invoice.Paid", Signature: "public event EventHandler<EventArgs> Paid", Line: 32, Exported: true},
+		{Type: "method", Name: "Invoice.PayAsync", Signature: "public async Task<(bool ok, string error)> PayAsync<TResult>(CancellationToken token = default) where TResult : struct", Line: 35, Exported: true},
+		{Type: "method", Name: "Invoice.operator +", Signature: "public static Invoice<T> operator +(Invoice<T> a, Invoice<T> b)", Line: 43, Exported: true},
+		{Type: "method", Name: "Invoice.IDisposable.Dispose", Signature: "void IDisposable.Dispose()", Line: 45, Exported: true},
+		{Type: "method", Name: "Invoice.Reset", Signature: "private void Reset()", Line: 47},
+		{Type: "class", Name: "Invoice.Line", Signature: "public class Line", Line: 49, Exported: true},
+		{Type: "property", Name: "Invoice.Line.Sku", Signature: "public string Sku { get; init; }", Line: 51, Exported: true},
+		{Type: "interface", Name: "IPayable", Signature: "public interface IPayable", Line: 56, Exported: true},
+		{Type: "method", Name: "IPayable.Pay", Signature: "bool Pay(decimal amount)", Line: 58, Exported: true},
+		{Type: "property", Name: "IPayable.Balance", Signature: "decimal Balance { get; }", Line: 59, Exported: true},
+		{Type: "record", Name: "Money", Signature: "internal record struct Money(decimal Amount, string Currency)", Line: 62},
+		{Type: "record", Name: "Customer", Signature: "public record Customer(string Name)", Line: 64, Exported: true},
+		{Type: "enum", Name: "Status", Signature: "public enum Status : byte { None, Paid, Void }", Line: 70, Exported: true},
+		{Type: "delegate", Name: "PaymentHandler", Signature: "public delegate Task<bool> PaymentHandler(Invoice<object> invoice)", Line: 77, Exported: true},
+	}
+	if len(analysis.Constructs) != len(expected) {
+		for _, construct := range analysis.Constructs {
+			t.Logf("%s %s at line %d: %s", construct.Type, construct.Name, construct.Line, construct.Signature)
+		}
+		t.Fatalf("Expected %d constructs, got %d", len(expected), len(analysis.Constructs))
+	}
+	for i, want := range expected {
+		got := analysis.Constructs[i]
+		if got.Type != want.Type || got.Name != want.Name || got.Signature != want.Signature ||
+			got.Line != want.Line || got.Exported != want.Exported || got.Summary != want.Summary {
+			t.Errorf("Construct %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	if doc := analysis.Constructs[0].Doc; doc != "Bills a Acme.Customer for its orders.\n\nAmounts are in <cents>." {
+		t.Errorf("Unexpected documentation of Invoice %q", doc)
+	}
+	if attributes := analysis.Constructs[0].Annotations; len(attributes) != 2 || attributes[0] != "[Serializable]" || attributes[1] != `[Table("invoices")]` {
+		t.Errorf("Expected the Serializable and Table attributes on Invoice, got %v", attributes)
+	}
+}
+
+func TestParseCSharpFile_Namespaces(t *testing.T) {
+	src := `namespace Acme
+{
+    namespace Billing.Internal
+    {
+        public class Ledger { }
+    }
+}
+
+Console.WriteLine("top-level statement");
+`
+	analysis := ParseCSharpFile("Ledger.cs", []byte(src))
+
+	if analysis.Package != "Acme.Billing.Internal" {
+		t.Errorf("Expected package Acme.Billing.Internal, got %s", analysis.Package)
+	}
+	if len(analysis.Constructs) != 1 || analysis.Constructs[0].Name != "Ledger" {
+		t.Errorf("Expected the Ledger class only, got %+v", analysis.Constructs)
+	}
+}
+
+func TestCSharpParser_ParseRepository(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"Acme.sln":                              "\n",
+		"src/Billing/Billing.csproj":            "<Project></Project>\n",
+		"src/Billing/Invoice.cs":                "namespace Acme.Billing;\n\npublic class Invoice\n{\n    // TODO: add taxes\n    public decimal Total() => 0;\n    private void Reset() { }\n}\n",
+		"src/Billing/Form1.Designer.cs":         "namespace Acme.Billing;\n\npublic class Generated { }\n",
+		"src/Billing/obj/Debug/AssemblyInfo.cs": "namespace Acme.Billing;\n\npublic class Built { }\n",
+		"tests/Billing.Tests/InvoiceFacts.cs":   "namespace Acme.Billing.Tests;\n\npublic class InvoiceFacts { }\n",
+		"src/Billing/InvoiceTests.cs":           "namespace Acme.Billing;\n\npublic class InvoiceChecks { }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	repoIndex, err := NewCSharpParser().ParseRepository("billing", tempDir, types.IndexingConfig{})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+
+	if repoIndex.Metadata["indexer_type"] != "csharp_native" {
+		t.Errorf("Expected indexer_type 'csharp_native', got '%v'", repoIndex.Metadata["indexer_type"])
+	}
+	if repoIndex.Metadata["file_count"] != 1 {
+		t.Errorf("Expected file_count 1, got '%v'", repoIndex.Metadata["file_count"])
+	}
+
+	xmlFile, exists := repoIndex.Files[".repomix.xml"]
+	if !exists {
+		t.Fatal("Expected .repomix.xml file to be generated")
+	}
+	for _, pattern := range []string{
+		`<file path="src/Billing/Invoice.cs" module="Acme.Billing">`,
+		`<package name="Acme.Billing">`,
+		"C#",
+		"public class Invoice  // src/Billing/Invoice.cs:3",
+		"public decimal Total()",
+	} {
+		if !strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content to contain '%s'", pattern)
+		}
+	}
+	for _, pattern := range []string{"Generated", "Built", "InvoiceFacts", "InvoiceChecks", "Reset"} {
+		if strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content not to contain '%s'", pattern)
+		}
+	}
+}
@@ -8,20 +8,27 @@ import (
 	"fmt"
 	"go/ast"
 	"go/parser"
+	"go/printer"
 	"go/token"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"time"
 
+	"repomix-mcp/internal/repository"
 	"repomix-mcp/pkg/types"
 )
 
 // ************************************************************************************************
 // GoParser handles Go AST parsing and code structure extraction.
 type GoParser struct {
-	fileSet *token.FileSet
+	fileSet         *token.FileSet
+	functionMetrics bool // Record line span and cyclomatic complexity for functions
+	includeTests    bool // Parse *_test.go files, tagging their constructs test=true
 }
 
 // ************************************************************************************************
@@ -33,12 +40,21 @@ type GoConstruct struct {
 	Package    string            `json:"package"`    // Package name
 	File       string            `json:"file"`       // Source file path
 	Line       int               `json:"line"`       // Line number
+	Summary    string            `json:"summary"`    // First sentence of the doc comment
+	Doc        string            `json:"doc"`        // Full doc comment text
+	EndLine    int               `json:"endLine"`    // Last line number (functions, when metrics are enabled)
+	LineSpan   int               `json:"lineSpan"`   // End line minus start line (functions, when metrics are enabled)
+	Complexity int               `json:"complexity"` // Cyclomatic complexity estimate (functions, when metrics are enabled)
 	Exported   bool              `json:"exported"`   // Whether construct is exported (public)
 	Receiver   string            `json:"receiver"`   // Method receiver (for methods)
+	TypeParams string            `json:"typeParams"` // Type parameter list of generic functions and types, e.g. "[K comparable, V any]"
 	Parameters []string          `json:"parameters"` // Function parameters
 	Returns    []string          `json:"returns"`    // Function return types
 	Fields     []string          `json:"fields"`     // Struct fields
+	FieldDocs  []string          `json:"fieldDocs"`  // Doc comment of each struct field, empty when undocumented
 	Methods    []string          `json:"methods"`    // Interface methods
+	Body       string            `json:"body"`       // Source of the function body (example functions of test files)
+	MethodDocs []string          `json:"methodDocs"` // Doc comment of each interface method, empty when undocumented
 	Metadata   map[string]string `json:"metadata"`   // Additional metadata
 }
 
@@ -47,6 +63,9 @@ type GoConstruct struct {
 type GoFileAnalysis struct {
 	FilePath    string        `json:"filePath"`
 	PackageName string        `json:"packageName"`
+	Module      string        `json:"module"`   // Workspace module path, empty outside go.work repositories
+	Vendored    bool          `json:"vendored"` // Whether the file belongs to a vendored dependency
+	Test        bool          `json:"test"`     // Whether the file is a *_test.go file
 	Constructs  []GoConstruct `json:"constructs"`
 }
 
@@ -54,6 +73,8 @@ type GoFileAnalysis struct {
 // GoPackageAnalysis represents the complete analysis of a Go package.
 type GoPackageAnalysis struct {
 	PackageName  string                   `json:"packageName"`
+	Module       string                   `json:"module"`   // Workspace module path, empty outside go.work repositories
+	Vendored     bool                     `json:"vendored"` // Whether the package is a vendored dependency
 	Path         string                   `json:"path"`
 	Files        []string                 `json:"files"`
 	Constructs   map[string][]GoConstruct `json:"constructs"`   // Organized by type
@@ -76,18 +97,35 @@ func (p *GoParser) ParseRepository(repositoryID, localPath string, config types.
 	if repositoryID == "" || localPath == "" {
 		return nil, fmt.Errorf("%w: invalid parameters", types.ErrInvalidConfig)
 	}
+	// Parse with a copy holding the options of this run, so repositories can be parsed concurrently
+	p = &GoParser{fileSet: token.NewFileSet(), functionMetrics: config.FunctionMetrics, includeTests: config.IncludeTests}
 
 	// Check if this is a Go project
 	if !p.isGoProject(localPath) {
-		return nil, fmt.Errorf("not a Go project: no go.mod found in %s", localPath)
+		return nil, fmt.Errorf("not a Go project: no go.mod or go.work found in %s", localPath)
 	}
 
-	// Find all Go files (excluding test files)
-	goFiles, err := p.findGoFiles(localPath)
+	// Find all Go files (excluding test files unless requested)
+	goFiles, err := p.findGoFiles(localPath, config.IncludeVendor)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find Go files: %w", err)
 	}
 
+	// Limit to committed files when requested, non-git directories keep every file
+	if config.GitTrackedOnly {
+		if tracked, err := repository.TrackedFiles(localPath); err == nil {
+			trackedGoFiles := goFiles[:0]
+			for _, goFile := range goFiles {
+				if tracked[filepath.ToSlash(goFile)] {
+					trackedGoFiles = append(trackedGoFiles, goFile)
+				}
+			}
+			goFiles = trackedGoFiles
+		} else {
+			slog.Warn("Ignoring gitTrackedOnly", "path", localPath, "error", err)
+		}
+	}
+
 	if len(goFiles) == 0 {
 		return nil, fmt.Errorf("no Go files found in repository")
 	}
@@ -96,26 +134,85 @@ func (p *GoParser) ParseRepository(repositoryID, localPath string, config types.
 	fileAnalyses := make(map[string]*GoFileAnalysis)
 	packageAnalyses := make(map[string]*GoPackageAnalysis)
 
-	for _, goFile := range goFiles {
-		constructs, pkg, err := p.parseGoFile(goFile, localPath)
-		if err != nil {
+	// Workspace repositories attribute files and packages to their member module
+	modules, err := p.findWorkspaceModules(localPath)
+	if err != nil {
+		slog.Warn("Ignoring go.work", "path", localPath, "error", err)
+	}
+
+	// Interfaces and method sets are collected by import path to link implementations across packages
+	implementations := newImplementationIndex()
+	references := newReferenceIndex()
+	symbols := &symbolIndex{}
+	importModules := modules
+	if len(importModules) == 0 {
+		if goMod, err := os.ReadFile(filepath.Join(localPath, "go.mod")); err == nil {
+			importModules = []GoModule{{Path: parseModulePath(string(goMod)), Dir: "."}}
+		}
+	}
+
+	// Files are parsed concurrently and merged in order, so the analysis does not depend on timing
+	parsed := p.parseGoFiles(goFiles, localPath)
+	for index, goFile := range goFiles {
+		result := <-parsed[index]
+		if result.err != nil {
 			// Log error but continue with other files
-			fmt.Printf("Warning: failed to parse %s: %v\n", goFile, err)
+			slog.Warn("Failed to parse Go file", "path", goFile, "error", result.err)
 			continue
 		}
+		file, constructs, pkg := result.file, result.constructs, result.pkg
+
+		// Vendored packages are imported by the path below vendor/, not by the module path
+		importPath, vendored := vendoredImportPath(goFile)
+		module := ""
+		if vendored {
+			for index := range constructs {
+				constructs[index].Metadata["vendored"] = "true"
+			}
+		} else {
+			importPath = importPathForFile(importModules, goFile)
+			module = moduleForFile(modules, goFile)
+		}
+		implementations.addFile(result.fileSet, file, filepath.ToSlash(goFile), importPath)
+		references.addFile(result.fileSet, file, filepath.ToSlash(goFile), importPath)
+		symbols.addConstructs(constructs, filepath.ToSlash(goFile), importPath)
+
+		if module != "" {
+			for index := range constructs {
+				constructs[index].Metadata["module"] = module
+			}
+		}
+		test := strings.HasSuffix(goFile, "_test.go")
+		if test {
+			for index := range constructs {
+				constructs[index].Metadata["test"] = "true"
+			}
+		}
 
 		// Create file analysis
 		fileAnalyses[goFile] = &GoFileAnalysis{
 			FilePath:    goFile,
 			PackageName: pkg,
+			Module:      module,
+			Vendored:    vendored,
+			Test:        test,
 			Constructs:  constructs,
 		}
 
-		// Track package analysis
+		// Track package analysis, packages of different modules and vendored packages are kept apart
 		if pkg != "" {
-			if _, exists := packageAnalyses[pkg]; !exists {
-				packageAnalyses[pkg] = &GoPackageAnalysis{
+			packageKey := pkg
+			if module != "" {
+				packageKey = module + " " + pkg
+			}
+			if vendored {
+				packageKey = "vendor " + importPath
+			}
+			if _, exists := packageAnalyses[packageKey]; !exists {
+				packageAnalyses[packageKey] = &GoPackageAnalysis{
 					PackageName:  pkg,
+					Module:       module,
+					Vendored:     vendored,
 					Path:         filepath.Dir(goFile),
 					Files:        make([]string, 0),
 					Constructs:   make(map[string][]GoConstruct),
@@ -123,31 +220,31 @@ func (p *GoParser) ParseRepository(repositoryID, localPath string, config types.
 					Summary:      make(map[string]int),
 				}
 			}
-			packageAnalyses[pkg].Files = append(packageAnalyses[pkg].Files, goFile)
+			packageAnalyses[packageKey].Files = append(packageAnalyses[packageKey].Files, goFile)
 
 			// Add constructs to package analysis
 			for _, construct := range constructs {
 				constructType := construct.Type
 
 				// Add to all constructs
-				if _, exists := packageAnalyses[pkg].Constructs[constructType]; !exists {
-					packageAnalyses[pkg].Constructs[constructType] = make([]GoConstruct, 0)
+				if _, exists := packageAnalyses[packageKey].Constructs[constructType]; !exists {
+					packageAnalyses[packageKey].Constructs[constructType] = make([]GoConstruct, 0)
 				}
-				packageAnalyses[pkg].Constructs[constructType] = append(packageAnalyses[pkg].Constructs[constructType], construct)
+				packageAnalyses[packageKey].Constructs[constructType] = append(packageAnalyses[packageKey].Constructs[constructType], construct)
 
 				// Add to exported-only if exported
 				if construct.Exported {
-					if _, exists := packageAnalyses[pkg].ExportedOnly[constructType]; !exists {
-						packageAnalyses[pkg].ExportedOnly[constructType] = make([]GoConstruct, 0)
+					if _, exists := packageAnalyses[packageKey].ExportedOnly[constructType]; !exists {
+						packageAnalyses[packageKey].ExportedOnly[constructType] = make([]GoConstruct, 0)
 					}
-					packageAnalyses[pkg].ExportedOnly[constructType] = append(packageAnalyses[pkg].ExportedOnly[constructType], construct)
+					packageAnalyses[packageKey].ExportedOnly[constructType] = append(packageAnalyses[packageKey].ExportedOnly[constructType], construct)
 				}
 			}
 		}
 	}
 
 	// Generate XML content
-	xmlContent := p.generateRepomixXML(repositoryID, localPath, fileAnalyses, packageAnalyses, goFiles, config.IncludeNonExported)
+	xmlContent, exportedLines := p.renderRepomixXML(repositoryID, localPath, fileAnalyses, packageAnalyses, goFiles, config.IncludeNonExported)
 
 	// Create repository index
 	repoIndex := &types.RepositoryIndex{
@@ -164,7 +261,7 @@ func (p *GoParser) ParseRepository(repositoryID, localPath string, config types.
 	xmlFile := types.IndexedFile{
 		Path:         ".repomix.xml",
 		Content:      xmlContent,
-		Hash:         p.calculateContentHash(xmlContent),
+		Hash:         p.calculateContentHash(config.HashAlgorithm, xmlContent),
 		Size:         int64(len(xmlContent)),
 		ModTime:      time.Now(),
 		Language:     "xml",
@@ -173,6 +270,8 @@ func (p *GoParser) ParseRepository(repositoryID, localPath string, config types.
 			"indexer_type":   "go_native",
 			"go_files_count": fmt.Sprintf("%d", len(goFiles)),
 			"packages_count": fmt.Sprintf("%d", len(packageAnalyses)),
+
+			types.ExportedLinesMetadataKey: types.FormatLineRanges(exportedLines),
 		},
 	}
 
@@ -180,10 +279,43 @@ func (p *GoParser) ParseRepository(repositoryID, localPath string, config types.
 
 	// Add metadata
 	repoIndex.Metadata["indexer_type"] = "go_native"
+	repoIndex.Metadata["api_summary"] = p.generateAPISummary(repositoryID, packageAnalyses)
 	repoIndex.Metadata["file_count"] = len(goFiles)
 	repoIndex.Metadata["packages_count"] = len(packageAnalyses)
 	repoIndex.Metadata["indexed_at"] = time.Now().Format(time.RFC3339)
 	repoIndex.Metadata["indexer_version"] = "repomix-mcp-go-v1.0.0"
+	if len(modules) > 0 {
+		repoIndex.Metadata["go_workspace_modules"] = modules
+	}
+
+	// Roll up the parsed sources by directory, the indexer adds the files it serves verbatim
+	if config.DirectoryStats {
+		stats := make(map[string]*types.DirectoryStats)
+		for _, goFile := range goFiles {
+			var size int64
+			if info, err := os.Stat(filepath.Join(localPath, goFile)); err == nil {
+				size = info.Size()
+			}
+			types.AddDirectoryStat(stats, filepath.ToSlash(goFile), "go", size)
+		}
+		repoIndex.Metadata[types.DirectoryStatsMetadataKey] = stats
+	}
+
+	// Collect work item markers from the sources, the construct summary drops comments,
+	// and hash them so the working diff can detect changes to individual files
+	var todos []types.TodoItem
+	sourceHashes := make(map[string]string)
+	for _, goFile := range goFiles {
+		if src, err := os.ReadFile(filepath.Join(localPath, goFile)); err == nil {
+			todos = append(todos, types.ScanTodos(filepath.ToSlash(goFile), string(src), config.TodoMarkers)...)
+			sourceHashes[filepath.ToSlash(goFile)] = types.HashContent(config.HashAlgorithm, string(src))
+		}
+	}
+	repoIndex.Metadata[types.TodosMetadataKey] = todos
+	repoIndex.Metadata[types.SourceHashesMetadataKey] = sourceHashes
+	repoIndex.Metadata[types.ImplementationsMetadataKey] = implementations.resolve()
+	repoIndex.Metadata[types.ReferencesMetadataKey] = references.resolve()
+	repoIndex.Metadata[types.SymbolsMetadataKey] = symbols.resolve()
 
 	// Count constructs by type across all packages
 	constructCounts := make(map[string]int)
@@ -207,8 +339,13 @@ func (p *GoParser) isGoProject(localPath string) bool {
 		return true
 	}
 
+	// Multi-module workspaces may have no go.mod at the root
+	if _, err := os.Stat(filepath.Join(localPath, "go.work")); err == nil {
+		return true
+	}
+
 	// Fallback: check for significant number of .go files
-	goFiles, err := p.findGoFiles(localPath)
+	goFiles, err := p.findGoFiles(localPath, false)
 	if err != nil {
 		return false
 	}
@@ -217,8 +354,9 @@ func (p *GoParser) isGoProject(localPath string) bool {
 }
 
 // ************************************************************************************************
-// findGoFiles recursively finds all Go files in the repository, excluding test files.
-func (p *GoParser) findGoFiles(localPath string) ([]string, error) {
+// findGoFiles recursively finds all Go files in the repository, excluding test files unless
+// the parser includes tests. Vendor directories are skipped unless includeVendor is set.
+func (p *GoParser) findGoFiles(localPath string, includeVendor bool) ([]string, error) {
 	var goFiles []string
 
 	err := filepath.Walk(localPath, func(path string, info os.FileInfo, err error) error {
@@ -229,14 +367,14 @@ func (p *GoParser) findGoFiles(localPath string) ([]string, error) {
 		// Skip hidden directories and common ignore patterns
 		if info.IsDir() {
 			name := info.Name()
-			if strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" {
+			if strings.HasPrefix(name, ".") || (name == "vendor" && !includeVendor) || name == "node_modules" {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		// Check for Go files, excluding test files
-		if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+		// Check for Go files, excluding test files unless requested
+		if strings.HasSuffix(path, ".go") && (p.includeTests || !strings.HasSuffix(path, "_test.go")) {
 			relPath, err := filepath.Rel(localPath, path)
 			if err != nil {
 				return err
@@ -250,22 +388,106 @@ func (p *GoParser) findGoFiles(localPath string) ([]string, error) {
 	return goFiles, err
 }
 
+// ************************************************************************************************
+// vendoredImportPath returns the import path of a file below a vendor directory: its directory
+// relative to the innermost vendor directory.
+//
+// Returns:
+//   - string: The import path, empty if the file is not vendored.
+//   - bool: Whether the file is vendored.
+func vendoredImportPath(relPath string) (string, bool) {
+	dir := path.Dir(filepath.ToSlash(relPath))
+	index := strings.LastIndex("/"+dir+"/", "/vendor/")
+	if index < 0 {
+		return "", false
+	}
+	importPath := strings.TrimSuffix(dir[min(index+len("vendor/"), len(dir)):], "/")
+	if importPath == "" {
+		return "", false
+	}
+	return importPath, true
+}
+
 // ************************************************************************************************
 // parseGoFile parses a single Go file and extracts all constructs.
 func (p *GoParser) parseGoFile(filePath, basePath string) ([]GoConstruct, string, error) {
+	file, err := p.parseGoSource(filePath, basePath)
+	if err != nil {
+		return nil, "", err
+	}
+	constructs, packageName := p.extractConstructs(file, filePath)
+	return constructs, packageName, nil
+}
+
+// ************************************************************************************************
+// parseGoSource reads and parses a single Go file with its comments.
+func (p *GoParser) parseGoSource(filePath, basePath string) (*ast.File, error) {
 	fullPath := filepath.Join(basePath, filePath)
 
 	// Parse the Go file
 	src, err := os.ReadFile(fullPath)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to read file: %w", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
 	file, err := parser.ParseFile(p.fileSet, fullPath, src, parser.ParseComments)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to parse Go file: %w", err)
+		return nil, fmt.Errorf("failed to parse Go file: %w", err)
+	}
+	return file, nil
+}
+
+// ************************************************************************************************
+// parsedGoFile is a Go file parsed by a worker of parseGoFiles, with its constructs.
+type parsedGoFile struct {
+	fileSet    *token.FileSet // FileSet of the worker, resolving the positions of file
+	file       *ast.File
+	constructs []GoConstruct
+	pkg        string
+	err        error
+}
+
+// ************************************************************************************************
+// parseGoFiles parses Go files and extracts their constructs on one worker per CPU, each with
+// its own FileSet. The result of every file is sent on the channel at its index, so the caller
+// merges them in order as they complete.
+//
+// Returns:
+//   - []chan parsedGoFile: The channels receiving the result of each file, once.
+func (p *GoParser) parseGoFiles(goFiles []string, basePath string) []chan parsedGoFile {
+	results := make([]chan parsedGoFile, len(goFiles))
+	for index := range results {
+		results[index] = make(chan parsedGoFile, 1)
 	}
 
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for index := range goFiles {
+			indexes <- index
+		}
+	}()
+
+	for worker := 0; worker < min(runtime.GOMAXPROCS(0), len(goFiles)); worker++ {
+		go func() {
+			workerParser := &GoParser{fileSet: token.NewFileSet(), functionMetrics: p.functionMetrics, includeTests: p.includeTests}
+			for index := range indexes {
+				file, err := workerParser.parseGoSource(goFiles[index], basePath)
+				if err != nil {
+					results[index] <- parsedGoFile{err: err}
+					continue
+				}
+				constructs, pkg := workerParser.extractConstructs(file, goFiles[index])
+				results[index] <- parsedGoFile{fileSet: workerParser.fileSet, file: file, constructs: constructs, pkg: pkg}
+			}
+		}()
+	}
+	return results
+}
+
+// ************************************************************************************************
+// extractConstructs extracts all constructs of a parsed Go file.
+func (p *GoParser) extractConstructs(file *ast.File, filePath string) ([]GoConstruct, string) {
 	var constructs []GoConstruct
 	packageName := file.Name.Name
 
@@ -274,6 +496,17 @@ func (p *GoParser) parseGoFile(filePath, basePath string) ([]GoConstruct, string
 		switch node := n.(type) {
 		case *ast.FuncDecl:
 			construct := p.extractFunction(node, filePath, packageName)
+			// Examples document usage, their body is kept with the ignored "// Output:" comment
+			if target, ok := exampleTarget(node); ok && strings.HasSuffix(filePath, "_test.go") {
+				if target == "" {
+					target = packageName
+				}
+				construct.Metadata["example"] = target
+				var body strings.Builder
+				if err := printer.Fprint(&body, p.fileSet, &printer.CommentedNode{Node: node.Body, Comments: file.Comments}); err == nil {
+					construct.Body = body.String()
+				}
+			}
 			constructs = append(constructs, construct)
 
 		case *ast.GenDecl:
@@ -293,7 +526,37 @@ func (p *GoParser) parseGoFile(filePath, basePath string) ([]GoConstruct, string
 		return true
 	})
 
-	return constructs, packageName, nil
+	return constructs, packageName
+}
+
+// ************************************************************************************************
+// exampleTarget returns the identifier documented by a Go example function, following the
+// go doc naming: Example documents the package, ExampleF the function F, ExampleT_M the method
+// T.M, and a lowercase "_suffix" distinguishes several examples of the same identifier.
+//
+// Returns:
+//   - string: The documented identifier, e.g. "Client.Do", empty for package examples.
+//   - bool: Whether the function is an example.
+func exampleTarget(fn *ast.FuncDecl) (string, bool) {
+	name, found := strings.CutPrefix(fn.Name.Name, "Example")
+	if !found || fn.Recv != nil || fn.Body == nil || fn.Type.Params.NumFields() > 0 || fn.Type.Results.NumFields() > 0 {
+		return "", false
+	}
+	if name == "" || strings.HasPrefix(name, "_") {
+		return "", name == "" || !ast.IsExported(name[1:])
+	}
+	if !ast.IsExported(name) {
+		return "", false
+	}
+
+	var parts []string
+	for _, part := range strings.Split(name, "_") {
+		if !ast.IsExported(part) {
+			break // Suffix
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "."), true
 }
 
 // ************************************************************************************************
@@ -319,6 +582,8 @@ func (p *GoParser) extractFunction(fn *ast.FuncDecl, filePath, packageName strin
 		}
 	}
 
+	construct.TypeParams = p.typeParamsToString(fn.Type.TypeParams)
+
 	// Extract parameters
 	if fn.Type.Params != nil {
 		for _, param := range fn.Type.Params.List {
@@ -340,42 +605,146 @@ func (p *GoParser) extractFunction(fn *ast.FuncDecl, filePath, packageName strin
 		}
 	}
 
+	p.applyDocComment(&construct, fn.Doc)
+
+	// Record size metrics only when requested, walking the body is not free
+	if p.functionMetrics {
+		construct.EndLine = p.fileSet.Position(fn.End()).Line
+		construct.LineSpan = construct.EndLine - construct.Line
+		construct.Complexity = p.cyclomaticComplexity(fn.Body)
+	}
+
 	// Generate signature
 	construct.Signature = p.generateFunctionSignature(construct)
 
 	return construct
 }
 
+// ************************************************************************************************
+// cyclomaticComplexity estimates the cyclomatic complexity of a function body.
+// It starts at 1 and adds one for each branch point: if, for, range, non-default
+// case and select clauses, and each short-circuit && or || operator.
+//
+// Returns:
+//   - int: The complexity estimate, 1 for a body without branches or a nil body.
+func (p *GoParser) cyclomaticComplexity(body *ast.BlockStmt) int {
+	complexity := 1
+	if body == nil {
+		return complexity
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if node.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if node.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if node.Op == token.LAND || node.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+
+	return complexity
+}
+
+// ************************************************************************************************
+// applyDocComment records the one-line summary of a construct's doc comment and tags the
+// construct as deprecated when the comment contains a paragraph starting with the Go
+// "Deprecated:" convention. The first non-nil comment group is used, so a spec's own doc
+// takes precedence over its enclosing declaration's doc.
+// The full comment text is kept for symbol lookups.
+// Sets Metadata "deprecated" to "true" and "deprecation" to the note text.
+func (p *GoParser) applyDocComment(construct *GoConstruct, docs ...*ast.CommentGroup) {
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		text := doc.Text()
+		construct.Summary = docSummary(text)
+		construct.Doc = strings.TrimSpace(text)
+		if note, found := deprecationNote(text); found {
+			construct.Metadata["deprecated"] = "true"
+			construct.Metadata["deprecation"] = note
+		}
+		return
+	}
+}
+
+// ************************************************************************************************
+// docSummary returns the first sentence of a doc comment's first paragraph on a single line.
+// A "Deprecated:" paragraph is never used as the summary.
+func docSummary(text string) string {
+	paragraph := strings.TrimSpace(strings.SplitN(text, "\n\n", 2)[0])
+	if strings.HasPrefix(paragraph, "Deprecated:") {
+		return ""
+	}
+	paragraph = strings.Join(strings.Fields(paragraph), " ")
+	if end := strings.Index(paragraph, ". "); end >= 0 {
+		return paragraph[:end+1]
+	}
+	return paragraph
+}
+
+// ************************************************************************************************
+// deprecationNote extracts the text of the "Deprecated:" paragraph from a doc comment.
+//
+// Returns:
+//   - string: The deprecation note, joined into a single line.
+//   - bool: Whether the comment contains a deprecation paragraph.
+func deprecationNote(text string) (string, bool) {
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if !strings.HasPrefix(paragraph, "Deprecated:") {
+			continue
+		}
+		note := strings.TrimSpace(strings.TrimPrefix(paragraph, "Deprecated:"))
+		return strings.Join(strings.Fields(note), " "), true
+	}
+	return "", false
+}
+
 // ************************************************************************************************
 // extractType extracts type declarations (struct, interface, type alias).
 func (p *GoParser) extractType(ts *ast.TypeSpec, genDecl *ast.GenDecl, filePath, packageName string) GoConstruct {
 	pos := p.fileSet.Position(ts.Pos())
 
 	construct := GoConstruct{
-		Name:     ts.Name.Name,
-		Package:  packageName,
-		File:     filePath,
-		Line:     pos.Line,
-		Exported: ast.IsExported(ts.Name.Name),
-		Metadata: make(map[string]string),
+		Name:       ts.Name.Name,
+		Package:    packageName,
+		File:       filePath,
+		Line:       pos.Line,
+		Exported:   ast.IsExported(ts.Name.Name),
+		TypeParams: p.typeParamsToString(ts.TypeParams),
+		Metadata:   make(map[string]string),
 	}
 
 	switch t := ts.Type.(type) {
 	case *ast.StructType:
 		construct.Type = "struct"
-		construct.Fields = p.extractStructFields(t)
+		construct.Fields, construct.FieldDocs = p.extractStructFields(t)
 		construct.Signature = p.generateStructSignature(construct)
 
 	case *ast.InterfaceType:
 		construct.Type = "interface"
-		construct.Methods = p.extractInterfaceMethods(t)
+		construct.Methods, construct.MethodDocs = p.extractInterfaceMethods(t)
 		construct.Signature = p.generateInterfaceSignature(construct)
 
 	default:
 		construct.Type = "type"
-		construct.Signature = fmt.Sprintf("type %s = %s", construct.Name, p.typeToString(ts.Type))
+		construct.Signature = fmt.Sprintf("type %s%s = %s", construct.Name, construct.TypeParams, p.typeToString(ts.Type))
 	}
 
+	p.applyDocComment(&construct, ts.Doc, genDecl.Doc)
+
 	return construct
 }
 
@@ -400,6 +769,7 @@ func (p *GoParser) extractValueSpec(vs *ast.ValueSpec, genDecl *ast.GenDecl, fil
 			Exported: ast.IsExported(name.Name),
 			Metadata: make(map[string]string),
 		}
+		p.applyDocComment(&construct, vs.Doc, genDecl.Doc)
 
 		// Generate signature
 		var typeStr string
@@ -438,12 +808,17 @@ func (p *GoParser) extractValueSpec(vs *ast.ValueSpec, genDecl *ast.GenDecl, fil
 
 // ************************************************************************************************
 // extractStructFields extracts field information from a struct type.
-func (p *GoParser) extractStructFields(st *ast.StructType) []string {
-	var fields []string
+//
+// Returns:
+//   - []string: The fields, one per name, with their type and tag.
+//   - []string: The doc comment of each field, empty when undocumented.
+func (p *GoParser) extractStructFields(st *ast.StructType) ([]string, []string) {
+	var fields, docs []string
 
 	if st.Fields != nil {
 		for _, field := range st.Fields.List {
 			fieldType := p.typeToString(field.Type)
+			doc := fieldDoc(field)
 
 			if len(field.Names) > 0 {
 				for _, name := range field.Names {
@@ -452,37 +827,58 @@ func (p *GoParser) extractStructFields(st *ast.StructType) []string {
 						tagStr = " " + field.Tag.Value
 					}
 					fields = append(fields, fmt.Sprintf("%s %s%s", name.Name, fieldType, tagStr))
+					docs = append(docs, doc)
 				}
 			} else {
 				// Embedded field
 				fields = append(fields, fieldType)
+				docs = append(docs, doc)
 			}
 		}
 	}
 
-	return fields
+	return fields, docs
 }
 
 // ************************************************************************************************
 // extractInterfaceMethods extracts method signatures from an interface type.
-func (p *GoParser) extractInterfaceMethods(it *ast.InterfaceType) []string {
-	var methods []string
+//
+// Returns:
+//   - []string: The methods and embedded interfaces.
+//   - []string: The doc comment of each method, empty when undocumented.
+func (p *GoParser) extractInterfaceMethods(it *ast.InterfaceType) ([]string, []string) {
+	var methods, docs []string
 
 	if it.Methods != nil {
 		for _, method := range it.Methods.List {
 			if len(method.Names) > 0 {
 				// Method
 				methodName := method.Names[0].Name
-				methodType := p.typeToString(method.Type)
+				methodType := strings.TrimPrefix(p.typeToString(method.Type), "func")
 				methods = append(methods, fmt.Sprintf("%s%s", methodName, methodType))
 			} else {
-				// Embedded interface
+				// Embedded interface or type set element, such as ~int | ~string
 				methods = append(methods, p.typeToString(method.Type))
 			}
+			docs = append(docs, fieldDoc(method))
 		}
 	}
 
-	return methods
+	return methods, docs
+}
+
+// ************************************************************************************************
+// fieldDoc returns the doc comment of a struct field or an interface method, falling back to
+// its trailing line comment.
+func fieldDoc(field *ast.Field) string {
+	for _, doc := range []*ast.CommentGroup{field.Doc, field.Comment} {
+		if doc != nil {
+			if text := strings.TrimSpace(doc.Text()); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
 }
 
 // ************************************************************************************************
@@ -498,6 +894,7 @@ func (p *GoParser) generateFunctionSignature(construct GoConstruct) string {
 	}
 
 	sig.WriteString(construct.Name)
+	sig.WriteString(construct.TypeParams)
 	sig.WriteString("(")
 	sig.WriteString(strings.Join(construct.Parameters, ", "))
 	sig.WriteString(")")
@@ -514,11 +911,11 @@ func (p *GoParser) generateFunctionSignature(construct GoConstruct) string {
 }
 
 func (p *GoParser) generateStructSignature(construct GoConstruct) string {
-	return fmt.Sprintf("type %s struct", construct.Name)
+	return fmt.Sprintf("type %s%s struct", construct.Name, construct.TypeParams)
 }
 
 func (p *GoParser) generateInterfaceSignature(construct GoConstruct) string {
-	return fmt.Sprintf("type %s interface", construct.Name)
+	return fmt.Sprintf("type %s%s interface", construct.Name, construct.TypeParams)
 }
 
 func (p *GoParser) typeToString(expr ast.Expr) string {
@@ -550,14 +947,60 @@ func (p *GoParser) typeToString(expr ast.Expr) string {
 	case *ast.FuncType:
 		return p.funcTypeToString(t)
 	case *ast.InterfaceType:
-		return "interface{}"
+		methods, _ := p.extractInterfaceMethods(t)
+		if len(methods) == 0 {
+			return "interface{}"
+		}
+		return "interface{ " + strings.Join(methods, "; ") + " }"
 	case *ast.SelectorExpr:
 		return p.typeToString(t.X) + "." + t.Sel.Name
+	case *ast.Ellipsis:
+		return "..." + p.typeToString(t.Elt)
+	case *ast.IndexExpr:
+		// Generic instantiation with a single type argument, e.g. List[int]
+		return p.typeToString(t.X) + "[" + p.typeToString(t.Index) + "]"
+	case *ast.IndexListExpr:
+		// Generic instantiation with several type arguments, e.g. Map[string, int]
+		args := make([]string, 0, len(t.Indices))
+		for _, index := range t.Indices {
+			args = append(args, p.typeToString(index))
+		}
+		return p.typeToString(t.X) + "[" + strings.Join(args, ", ") + "]"
+	case *ast.UnaryExpr:
+		// Underlying type constraint, e.g. ~string
+		return t.Op.String() + p.typeToString(t.X)
+	case *ast.BinaryExpr:
+		// Type set union, e.g. ~int | ~float64
+		return p.typeToString(t.X) + " " + t.Op.String() + " " + p.typeToString(t.Y)
+	case *ast.ParenExpr:
+		return "(" + p.typeToString(t.X) + ")"
 	default:
 		return "unknown"
 	}
 }
 
+// ************************************************************************************************
+// typeParamsToString renders the type parameter list of a generic function or type, keeping the
+// grouping of the source, e.g. "[K comparable, V any]" or "[S ~[]E, E any]".
+//
+// Returns:
+//   - string: The bracketed type parameter list, empty for non-generic declarations.
+func (p *GoParser) typeParamsToString(typeParams *ast.FieldList) string {
+	if typeParams == nil || len(typeParams.List) == 0 {
+		return ""
+	}
+
+	groups := make([]string, 0, len(typeParams.List))
+	for _, field := range typeParams.List {
+		names := make([]string, 0, len(field.Names))
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		groups = append(groups, strings.Join(names, ", ")+" "+p.typeToString(field.Type))
+	}
+	return "[" + strings.Join(groups, ", ") + "]"
+}
+
 func (p *GoParser) funcTypeToString(ft *ast.FuncType) string {
 	var sig strings.Builder
 	sig.WriteString("func(")
@@ -643,6 +1086,8 @@ func (p *GoParser) nodeToString(node ast.Node) string {
 		return p.nodeToString(n.Key) + ": " + p.nodeToString(n.Value)
 	case *ast.IndexExpr:
 		return p.nodeToString(n.X) + "[" + p.nodeToString(n.Index) + "]"
+	case *ast.IndexListExpr:
+		return p.typeToString(n)
 	case *ast.SliceExpr:
 		low := ""
 		high := ""
@@ -663,22 +1108,72 @@ func (p *GoParser) nodeToString(node ast.Node) string {
 	}
 }
 
-func (p *GoParser) calculateContentHash(content string) string {
-	// Simple hash based on content length and first/last characters
-	if len(content) == 0 {
-		return "empty"
-	}
+// ************************************************************************************************
+// calculateContentHash hashes content for change detection with the configured algorithm,
+// SHA-256 by default.
+//
+// Returns:
+//   - string: The content hash, prefixed with the algorithm name.
+func (p *GoParser) calculateContentHash(algorithm, content string) string {
+	return types.HashContent(algorithm, content)
+}
 
-	first := content[0]
-	last := content[len(content)-1]
+// ************************************************************************************************
+// goDocComment renders a doc comment as Go comment lines with the given indentation. The
+// "Deprecated:" paragraph is left out, the deprecation note being rendered on its own line.
+func goDocComment(doc, indent string) string {
+	var comment strings.Builder
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" || strings.HasPrefix(paragraph, "Deprecated:") {
+			continue
+		}
+		if comment.Len() > 0 {
+			comment.WriteString(indent + "//\n")
+		}
+		for _, line := range strings.Split(paragraph, "\n") {
+			comment.WriteString(strings.TrimRight(indent+"// "+line, " ") + "\n")
+		}
+	}
+	return comment.String()
+}
 
-	return fmt.Sprintf("go_%d_%c_%c", len(content), first, last)
+// ************************************************************************************************
+// constructLocation renders the trailing source location comment for a construct,
+// including line span and complexity when function metrics were recorded.
+func (p *GoParser) constructLocation(construct GoConstruct) string {
+	if construct.EndLine > 0 {
+		return fmt.Sprintf("  // %s:%d (lines: %d, complexity: %d)\n", construct.File, construct.Line, construct.LineSpan, construct.Complexity)
+	}
+	return fmt.Sprintf("  // %s:%d\n", construct.File, construct.Line)
 }
 
 // ************************************************************************************************
 // generateRepomixXML generates XML output in repomix-compatible format for Go projects.
 func (p *GoParser) generateRepomixXML(repositoryID, localPath string, fileAnalyses map[string]*GoFileAnalysis, packageAnalyses map[string]*GoPackageAnalysis, goFiles []string, includeNonExported bool) string {
+	content, _ := p.renderRepomixXML(repositoryID, localPath, fileAnalyses, packageAnalyses, goFiles, includeNonExported)
+	return content
+}
+
+// ************************************************************************************************
+// renderRepomixXML generates the repomix-compatible XML and records the output lines
+// occupied by exported constructs, so searches can be limited to the public API.
+//
+// Returns:
+//   - string: The XML content.
+//   - []types.LineRange: The lines of exported constructs, in output order.
+func (p *GoParser) renderRepomixXML(repositoryID, localPath string, fileAnalyses map[string]*GoFileAnalysis, packageAnalyses map[string]*GoPackageAnalysis, goFiles []string, includeNonExported bool) (string, []types.LineRange) {
 	var xml strings.Builder
+	var exportedLines []types.LineRange
+
+	// nextLine returns the line number the next write starts on, counting incrementally
+	counted, lines := 0, 0
+	nextLine := func() int {
+		content := xml.String()
+		lines += strings.Count(content[counted:], "\n")
+		counted = len(content)
+		return lines + 1
+	}
 
 	// XML header
 	xml.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
@@ -714,7 +1209,11 @@ func (p *GoParser) generateRepomixXML(repositoryID, localPath string, fileAnalys
 	xml.WriteString("</usage_guidelines>\n\n")
 
 	xml.WriteString("<notes>\n")
-	xml.WriteString("- Test files (*_test.go) are excluded from this analysis\n")
+	if p.includeTests {
+		xml.WriteString("- Test files (*_test.go) are included, with the body of their example functions\n")
+	} else {
+		xml.WriteString("- Test files (*_test.go) are excluded from this analysis\n")
+	}
 	if includeNonExported {
 		xml.WriteString("- All constructs (both exported and unexported) are included\n")
 	} else {
@@ -730,7 +1229,7 @@ func (p *GoParser) generateRepomixXML(repositoryID, localPath string, fileAnalys
 	xml.WriteString("<directory_structure>\n")
 	sort.Strings(goFiles)
 	for _, file := range goFiles {
-		xml.WriteString(file + "\n")
+		xml.WriteString(escapeXMLText(file) + "\n")
 	}
 	xml.WriteString("</directory_structure>\n\n")
 
@@ -764,9 +1263,18 @@ func (p *GoParser) generateRepomixXML(repositoryID, localPath string, fileAnalys
 		if len(fileConstructsByType) == 0 {
 			continue // Skip files with no constructs
 		}
-		xml.WriteString(fmt.Sprintf(`<file path="%s" package="%s">`+"\n", filePath, fileAnalysis.PackageName))
+		xml.WriteString(fmt.Sprintf(`<file path="%s" package="%s">`+"\n", escapeXMLAttr(filePath), escapeXMLAttr(fileAnalysis.PackageName)))
 		xml.WriteString(fmt.Sprintf("// Package: %s\n", fileAnalysis.PackageName))
-		xml.WriteString(fmt.Sprintf("// File: %s\n\n", filePath))
+		if fileAnalysis.Module != "" {
+			xml.WriteString(fmt.Sprintf("// Module: %s\n", escapeXMLText(fileAnalysis.Module)))
+		}
+		if fileAnalysis.Vendored {
+			xml.WriteString("// Vendored dependency\n")
+		}
+		if fileAnalysis.Test {
+			xml.WriteString("// Test file\n")
+		}
+		xml.WriteString(fmt.Sprintf("// File: %s\n\n", escapeXMLText(filePath)))
 
 		// Sort construct types for consistent output
 		constructTypes := []string{"const", "var", "type", "struct", "interface", "func", "method"}
@@ -779,21 +1287,37 @@ func (p *GoParser) generateRepomixXML(repositoryID, localPath string, fileAnalys
 				})
 
 				for _, construct := range constructs {
-					xml.WriteString(construct.Signature)
+					start := nextLine()
+					xml.WriteString(escapeXMLText(goDocComment(construct.Doc, "")))
+					if construct.Metadata["deprecated"] == "true" {
+						xml.WriteString(fmt.Sprintf("// Deprecated: %s\n", escapeXMLText(construct.Metadata["deprecation"])))
+					}
+					xml.WriteString(escapeXMLText(construct.Signature))
 					if constructType == "struct" && len(construct.Fields) > 0 {
 						xml.WriteString(" {\n")
-						for _, field := range construct.Fields {
-							xml.WriteString(fmt.Sprintf("    %s\n", field))
+						for i, field := range construct.Fields {
+							if i < len(construct.FieldDocs) {
+								xml.WriteString(escapeXMLText(goDocComment(construct.FieldDocs[i], "    ")))
+							}
+							xml.WriteString(fmt.Sprintf("    %s\n", escapeXMLText(field)))
 						}
 						xml.WriteString("}")
 					} else if constructType == "interface" && len(construct.Methods) > 0 {
 						xml.WriteString(" {\n")
-						for _, method := range construct.Methods {
-							xml.WriteString(fmt.Sprintf("    %s\n", method))
+						for i, method := range construct.Methods {
+							if i < len(construct.MethodDocs) {
+								xml.WriteString(escapeXMLText(goDocComment(construct.MethodDocs[i], "    ")))
+							}
+							xml.WriteString(fmt.Sprintf("    %s\n", escapeXMLText(method)))
 						}
 						xml.WriteString("}")
+					} else if construct.Body != "" {
+						xml.WriteString(" " + escapeXMLText(construct.Body))
+					}
+					xml.WriteString(escapeXMLText(p.constructLocation(construct)))
+					if construct.Exported {
+						exportedLines = append(exportedLines, types.LineRange{Start: start, End: nextLine() - 1})
 					}
-					xml.WriteString(fmt.Sprintf("  // %s:%d\n", construct.File, construct.Line))
 				}
 				xml.WriteString("\n")
 			}
@@ -809,9 +1333,16 @@ func (p *GoParser) generateRepomixXML(repositoryID, localPath string, fileAnalys
 	}
 	sort.Strings(sortedPackages)
 
-	for _, packageName := range sortedPackages {
-		pkgAnalysis := packageAnalyses[packageName]
-		xml.WriteString(fmt.Sprintf(`<package name="%s">`+"\n", packageName))
+	for _, packageKey := range sortedPackages {
+		pkgAnalysis := packageAnalyses[packageKey]
+		packageName := pkgAnalysis.PackageName
+		if pkgAnalysis.Module != "" {
+			xml.WriteString(fmt.Sprintf(`<package name="%s" module="%s">`+"\n", escapeXMLAttr(packageName), escapeXMLAttr(pkgAnalysis.Module)))
+		} else if pkgAnalysis.Vendored {
+			xml.WriteString(fmt.Sprintf(`<package name="%s" vendored="%s">`+"\n", escapeXMLAttr(packageName), escapeXMLAttr(filepath.ToSlash(pkgAnalysis.Path))))
+		} else {
+			xml.WriteString(fmt.Sprintf(`<package name="%s">`+"\n", escapeXMLAttr(packageName)))
+		}
 		if includeNonExported {
 			xml.WriteString(fmt.Sprintf("// Package: %s (all constructs)\n\n", packageName))
 		} else {
@@ -837,21 +1368,37 @@ func (p *GoParser) generateRepomixXML(repositoryID, localPath string, fileAnalys
 				})
 
 				for _, construct := range constructs {
-					xml.WriteString(construct.Signature)
+					start := nextLine()
+					xml.WriteString(escapeXMLText(goDocComment(construct.Doc, "")))
+					if construct.Metadata["deprecated"] == "true" {
+						xml.WriteString(fmt.Sprintf("// Deprecated: %s\n", escapeXMLText(construct.Metadata["deprecation"])))
+					}
+					xml.WriteString(escapeXMLText(construct.Signature))
 					if constructType == "struct" && len(construct.Fields) > 0 {
 						xml.WriteString(" {\n")
-						for _, field := range construct.Fields {
-							xml.WriteString(fmt.Sprintf("    %s\n", field))
+						for i, field := range construct.Fields {
+							if i < len(construct.FieldDocs) {
+								xml.WriteString(escapeXMLText(goDocComment(construct.FieldDocs[i], "    ")))
+							}
+							xml.WriteString(fmt.Sprintf("    %s\n", escapeXMLText(field)))
 						}
 						xml.WriteString("}")
 					} else if constructType == "interface" && len(construct.Methods) > 0 {
 						xml.WriteString(" {\n")
-						for _, method := range construct.Methods {
-							xml.WriteString(fmt.Sprintf("    %s\n", method))
+						for i, method := range construct.Methods {
+							if i < len(construct.MethodDocs) {
+								xml.WriteString(escapeXMLText(goDocComment(construct.MethodDocs[i], "    ")))
+							}
+							xml.WriteString(fmt.Sprintf("    %s\n", escapeXMLText(method)))
 						}
 						xml.WriteString("}")
+					} else if construct.Body != "" {
+						xml.WriteString(" " + escapeXMLText(construct.Body))
+					}
+					xml.WriteString(escapeXMLText(p.constructLocation(construct)))
+					if construct.Exported {
+						exportedLines = append(exportedLines, types.LineRange{Start: start, End: nextLine() - 1})
 					}
-					xml.WriteString(fmt.Sprintf("  // %s:%d\n", construct.File, construct.Line))
 				}
 				xml.WriteString("\n")
 			}
@@ -863,5 +1410,59 @@ func (p *GoParser) generateRepomixXML(repositoryID, localPath string, fileAnalys
 	xml.WriteString("</files>\n")
 	xml.WriteString("</repository>\n")
 
-	return xml.String()
+	return xml.String(), exportedLines
 }
+
+
+// ************************************************************************************************
+// generateAPISummary renders a compact overview of the public API surface: per package, the
+// signature of every exported construct with its one-line doc summary. Struct fields and
+// interface methods are not expanded, and methods on unexported receivers are omitted.
+//
+// Returns:
+//   - string: The summary as Markdown.
+func (p *GoParser) generateAPISummary(repositoryID string, packageAnalyses map[string]*GoPackageAnalysis) string {
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("# API Summary: %s\n", repositoryID))
+
+	sortedPackages := make([]string, 0, len(packageAnalyses))
+	for packageName := range packageAnalyses {
+		sortedPackages = append(sortedPackages, packageName)
+	}
+	sort.Strings(sortedPackages)
+
+	constructTypes := []string{"const", "var", "type", "struct", "interface", "func", "method"}
+	for _, packageKey := range sortedPackages {
+		pkgAnalysis := packageAnalyses[packageKey]
+		if pkgAnalysis.Module != "" {
+			summary.WriteString(fmt.Sprintf("\n## package %s (module %s)\n\n", pkgAnalysis.PackageName, pkgAnalysis.Module))
+		} else if pkgAnalysis.Vendored {
+			summary.WriteString(fmt.Sprintf("\n## package %s (vendored %s)\n\n", pkgAnalysis.PackageName, filepath.ToSlash(pkgAnalysis.Path)))
+		} else {
+			summary.WriteString(fmt.Sprintf("\n## package %s\n\n", packageKey))
+		}
+
+		for _, constructType := range constructTypes {
+			constructs := append([]GoConstruct(nil), pkgAnalysis.ExportedOnly[constructType]...)
+			sort.Slice(constructs, func(i, j int) bool {
+				return constructs[i].Name < constructs[j].Name
+			})
+
+			for _, construct := range constructs {
+				if constructType == "method" && !ast.IsExported(strings.TrimLeft(construct.Receiver, "*")) {
+					continue
+				}
+				summary.WriteString(fmt.Sprintf("- `%s`", construct.Signature))
+				if construct.Summary != "" {
+					summary.WriteString(" - " + construct.Summary)
+				}
+				if construct.Metadata["deprecated"] == "true" {
+					summary.WriteString(" (deprecated)")
+				}
+				summary.WriteString("\n")
+			}
+		}
+	}
+
+	return summary.String()
+}
\ No newline at end of file
@@ -2,6 +2,8 @@ package parser
 
 import (
 	"fmt"
+	"go/ast"
+	goparser "go/parser"
 	"os"
 	"path/filepath"
 	"strings"
@@ -272,7 +274,7 @@ func TestGoParser_findGoFiles(t *testing.T) {
 		}
 	}
 
-	goFiles, err := parser.findGoFiles(tempDir)
+	goFiles, err := parser.findGoFiles(tempDir, false)
 	if err != nil {
 		t.Fatalf("findGoFiles failed: %v", err)
 	}
@@ -542,4 +544,937 @@ func main() {
 			t.Error("Expected package section to indicate all constructs are included")
 		}
 	})
-}
\ No newline at end of file
+}
+
+func TestGoParser_FunctionMetrics(t *testing.T) {
+	source := `package main
+
+func branchy(values []int, ok bool) int {
+	total := 0
+	for _, v := range values {
+		if v > 0 && ok {
+			total += v
+		}
+	}
+	switch total {
+	case 0:
+		return 0
+	default:
+		return total
+	}
+}
+`
+	parser := NewGoParser()
+	file, err := goparser.ParseFile(parser.fileSet, "main.go", source, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse source: %v", err)
+	}
+	fn := file.Decls[0].(*ast.FuncDecl)
+
+	// Metrics are not recorded unless enabled
+	construct := parser.extractFunction(fn, "main.go", "main")
+	if construct.EndLine != 0 || construct.Complexity != 0 {
+		t.Errorf("Expected no metrics when disabled, got end line %d, complexity %d", construct.EndLine, construct.Complexity)
+	}
+
+	parser.functionMetrics = true
+	construct = parser.extractFunction(fn, "main.go", "main")
+	if construct.LineSpan != 13 {
+		t.Errorf("Expected line span 13, got %d", construct.LineSpan)
+	}
+	// 1 + range + if + && + case 0
+	if construct.Complexity != 5 {
+		t.Errorf("Expected complexity 5, got %d", construct.Complexity)
+	}
+	if location := parser.constructLocation(construct); !strings.Contains(location, "(lines: 13, complexity: 5)") {
+		t.Errorf("Expected metrics in location comment, got %q", location)
+	}
+}
+
+func TestGoParser_Deprecation(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module test-repo\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("Failed to write go.mod: %v", err)
+	}
+
+	testGoContent := `package lib
+
+// OldFunc does things the old way.
+//
+// Deprecated: Use NewFunc instead,
+// it handles errors.
+func OldFunc() {}
+
+// NewFunc does things the new way.
+func NewFunc() {}
+
+// OldType is kept for compatibility.
+//
+// Deprecated: Use NewType.
+type OldType struct{}
+
+// NewType mentions Deprecated: inline, which is not a marker.
+type NewType struct{}
+
+const (
+	// Deprecated: Use CurrentLimit.
+	OldLimit = 1
+	CurrentLimit = 2
+)
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "lib.go"), []byte(testGoContent), 0644); err != nil {
+		t.Fatalf("Failed to write lib.go: %v", err)
+	}
+
+	parser := NewGoParser()
+	constructs, _, err := parser.parseGoFile("lib.go", tempDir)
+	if err != nil {
+		t.Fatalf("parseGoFile failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"OldFunc":      "Use NewFunc instead, it handles errors.",
+		"NewFunc":      "",
+		"OldType":      "Use NewType.",
+		"NewType":      "",
+		"OldLimit":     "Use CurrentLimit.",
+		"CurrentLimit": "",
+	}
+	for _, construct := range constructs {
+		note, ok := expected[construct.Name]
+		if !ok {
+			continue
+		}
+		deprecated := construct.Metadata["deprecated"] == "true"
+		if deprecated != (note != "") {
+			t.Errorf("%s: expected deprecated=%v, got %v", construct.Name, note != "", deprecated)
+		}
+		if construct.Metadata["deprecation"] != note {
+			t.Errorf("%s: expected deprecation note %q, got %q", construct.Name, note, construct.Metadata["deprecation"])
+		}
+		delete(expected, construct.Name)
+	}
+	if len(expected) > 0 {
+		t.Errorf("Constructs not found: %v", expected)
+	}
+
+	repoIndex, err := parser.ParseRepository("test-repo", tempDir, types.IndexingConfig{Enabled: true})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+	if xmlContent := repoIndex.Files[".repomix.xml"].Content; !strings.Contains(xmlContent, "// Deprecated: Use NewFunc instead, it handles errors.") {
+		t.Error("Expected deprecation note in generated XML")
+	}
+}
+
+func TestGoParser_DocComments(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module test-repo\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("Failed to write go.mod: %v", err)
+	}
+
+	testGoContent := `package lib
+
+// Client talks to the server.
+//
+// It retries failed requests.
+type Client struct {
+	// Endpoint is the server URL.
+	Endpoint string
+	Retries  int // Number of attempts
+	timeout  int
+}
+
+// Store persists records.
+type Store interface {
+	// Save writes a record.
+	Save(record string) error
+	Close() error
+}
+
+// Connect opens a client.
+//
+// Deprecated: Use Dial.
+func Connect() *Client { return nil }
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "lib.go"), []byte(testGoContent), 0644); err != nil {
+		t.Fatalf("Failed to write lib.go: %v", err)
+	}
+
+	parser := NewGoParser()
+	constructs, _, err := parser.parseGoFile("lib.go", tempDir)
+	if err != nil {
+		t.Fatalf("parseGoFile failed: %v", err)
+	}
+	for _, construct := range constructs {
+		switch construct.Name {
+		case "Client":
+			if construct.Doc != "Client talks to the server.\n\nIt retries failed requests." {
+				t.Errorf("Client: unexpected doc %q", construct.Doc)
+			}
+			expected := []string{"Endpoint is the server URL.", "Number of attempts", ""}
+			if strings.Join(construct.FieldDocs, "|") != strings.Join(expected, "|") {
+				t.Errorf("Client: expected field docs %q, got %q", expected, construct.FieldDocs)
+			}
+		case "Store":
+			expected := []string{"Save writes a record.", ""}
+			if strings.Join(construct.MethodDocs, "|") != strings.Join(expected, "|") {
+				t.Errorf("Store: expected method docs %q, got %q", expected, construct.MethodDocs)
+			}
+		}
+	}
+
+	repoIndex, err := parser.ParseRepository("test-repo", tempDir, types.IndexingConfig{Enabled: true})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+	xmlContent := repoIndex.Files[".repomix.xml"].Content
+	expectedPatterns := []string{
+		"// Client talks to the server.\n//\n// It retries failed requests.\ntype Client struct {\n",
+		"    // Endpoint is the server URL.\n    Endpoint string\n",
+		"    // Number of attempts\n    Retries int\n",
+		"    // Save writes a record.\n    Save(string) error\n",
+		"// Connect opens a client.\n// Deprecated: Use Dial.\nfunc Connect() *Client",
+	}
+	for _, pattern := range expectedPatterns {
+		if !strings.Contains(xmlContent, pattern) {
+			t.Errorf("Expected XML content to contain %q", pattern)
+		}
+	}
+	if strings.Count(xmlContent, "Deprecated: Use Dial.") != 2 {
+		t.Error("Expected the deprecation note once per section")
+	}
+}
+
+func TestGoParser_Generics(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module test-repo\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("Failed to write go.mod: %v", err)
+	}
+
+	testGoContent := `package lib
+
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+type Stack[T any] struct {
+	items []T
+	Index map[string]List[T]
+}
+
+type Cache[K comparable, V any] interface {
+	Get(key K) (V, bool)
+}
+
+type Pairs[K comparable, V any] = map[K]V
+
+func Map[S ~[]E, E, R any](items S, fn func(E) R) []R { return nil }
+
+func Sum[T interface{ ~int | ~float64 }](values ...T) T { var t T; return t }
+
+func (s *Stack[T]) Push(item T) {}
+
+func NewCache[K comparable, V any]() Cache[K, V] { return nil }
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "lib.go"), []byte(testGoContent), 0644); err != nil {
+		t.Fatalf("Failed to write lib.go: %v", err)
+	}
+
+	parser := NewGoParser()
+	constructs, _, err := parser.parseGoFile("lib.go", tempDir)
+	if err != nil {
+		t.Fatalf("parseGoFile failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"Number":   "type Number interface",
+		"Stack":    "type Stack[T any] struct",
+		"Cache":    "type Cache[K comparable, V any] interface",
+		"Pairs":    "type Pairs[K comparable, V any] = map[K]V",
+		"Map":      "func Map[S ~[]E, E, R any](items S, fn func(E) R) []R",
+		"Sum":      "func Sum[T interface{ ~int | ~float64 }](values ...T) T",
+		"Push":     "func (*Stack[T]) Push(item T)",
+		"NewCache": "func NewCache[K comparable, V any]() Cache[K, V]",
+	}
+	for _, construct := range constructs {
+		signature, ok := expected[construct.Name]
+		if !ok {
+			continue
+		}
+		if construct.Signature != signature {
+			t.Errorf("%s: expected signature %q, got %q", construct.Name, signature, construct.Signature)
+		}
+		switch construct.Name {
+		case "Number":
+			if len(construct.Methods) != 1 || construct.Methods[0] != "~int | ~int64 | ~float64" {
+				t.Errorf("Number: unexpected type set %q", construct.Methods)
+			}
+		case "Stack":
+			if strings.Join(construct.Fields, "; ") != "items []T; Index map[string]List[T]" {
+				t.Errorf("Stack: unexpected fields %q", construct.Fields)
+			}
+		case "Cache":
+			if len(construct.Methods) != 1 || construct.Methods[0] != "Get(K) (V, bool)" {
+				t.Errorf("Cache: unexpected methods %q", construct.Methods)
+			}
+		}
+		delete(expected, construct.Name)
+	}
+	if len(expected) > 0 {
+		t.Errorf("Constructs not found: %v", expected)
+	}
+}
+
+func TestGoParser_ExportedLines(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module test-repo\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("Failed to write go.mod: %v", err)
+	}
+
+	testGoContent := `package lib
+
+// Public is part of the API.
+func Public() {}
+
+func helper() {}
+
+// Config is exported.
+type Config struct {
+	Name string
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "lib.go"), []byte(testGoContent), 0644); err != nil {
+		t.Fatalf("Failed to write lib.go: %v", err)
+	}
+
+	parser := NewGoParser()
+	repoIndex, err := parser.ParseRepository("test-repo", tempDir, types.IndexingConfig{Enabled: true, IncludeNonExported: true})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+
+	xmlFile := repoIndex.Files[".repomix.xml"]
+	value, exists := xmlFile.Metadata[types.ExportedLinesMetadataKey]
+	if !exists {
+		t.Fatal("Expected exported line ranges in the XML file metadata")
+	}
+	ranges := types.ParseLineRanges(value)
+
+	inRanges := func(line int) bool {
+		for _, r := range ranges {
+			if line >= r.Start && line <= r.End {
+				return true
+			}
+		}
+		return false
+	}
+
+	expected := map[string]bool{
+		"func Public()":      true,
+		"func helper()":      false,
+		"type Config struct": true,
+	}
+	for lineNum, line := range strings.Split(xmlFile.Content, "\n") {
+		for signature, exported := range expected {
+			if !strings.HasPrefix(line, signature) {
+				continue
+			}
+			if inRanges(lineNum+1) != exported {
+				t.Errorf("%s on line %d: expected exported=%v", signature, lineNum+1, exported)
+			}
+			delete(expected, signature)
+		}
+	}
+	if len(expected) > 0 {
+		t.Errorf("Signatures not found in generated XML: %v", expected)
+	}
+}
+
+func TestGoParser_GoWorkspace(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.work":          "go 1.21\n\nuse (\n\t./svc-a // orders\n\t./svc-b\n)\n",
+		"svc-a/go.mod":     "module example.com/svc-a\n\ngo 1.21\n",
+		"svc-a/api/api.go": "package api\n\n// CreateOrder creates an order.\nfunc CreateOrder() {}\n",
+		"svc-b/go.mod":     "module example.com/svc-b\n\ngo 1.21\n",
+		"svc-b/api/api.go": "package api\n\n// CreateUser creates a user.\nfunc CreateUser() {}\n",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	parser := NewGoParser()
+	if !parser.isGoProject(tempDir) {
+		t.Fatal("Expected a go.work repository without a root go.mod to be a Go project")
+	}
+
+	repoIndex, err := parser.ParseRepository("workspace-repo", tempDir, types.IndexingConfig{Enabled: true})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+
+	modules, ok := repoIndex.Metadata["go_workspace_modules"].([]GoModule)
+	if !ok || len(modules) != 2 || modules[0].Path != "example.com/svc-a" || modules[1].Dir != "svc-b" {
+		t.Fatalf("Expected both workspace modules, got %v", repoIndex.Metadata["go_workspace_modules"])
+	}
+
+	// Packages with the same name in different modules are kept apart
+	if count := repoIndex.Metadata["packages_count"]; count != 2 {
+		t.Errorf("Expected 2 packages, got %v", count)
+	}
+	summary, _ := repoIndex.Metadata["api_summary"].(string)
+	for _, expected := range []string{"## package api (module example.com/svc-a)", "## package api (module example.com/svc-b)", "func CreateOrder()", "func CreateUser()"} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("Expected API summary to contain %q, got:\n%s", expected, summary)
+		}
+	}
+	if xmlContent := repoIndex.Files[".repomix.xml"].Content; !strings.Contains(xmlContent, `<package name="api" module="example.com/svc-b">`) {
+		t.Error("Expected module attribution in the package sections of the generated XML")
+	}
+}
+
+func TestGoParser_IncludeVendor(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.21\n",
+		"main.go": "package main\n\nimport \"github.com/acme/errs\"\n\n// Describer describes itself.\ntype Describer interface {\n\tDescribe() string\n}\n\n// Fail fails.\nfunc Fail() error { return errs.New() }\n",
+		"vendor/github.com/acme/errs/errs.go": "package errs\n\n// Error is an error.\ntype Error struct{}\n\nfunc (Error) Describe() string { return \"\" }\n\n// New returns an error.\nfunc New() error { return Error{} }\n",
+		"vendor/modules.txt": "# github.com/acme/errs v1.0.0\n",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	parser := NewGoParser()
+	repoIndex, err := parser.ParseRepository("app", tempDir, types.IndexingConfig{Enabled: true})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+	if strings.Contains(repoIndex.Files[".repomix.xml"].Content, "vendor/") {
+		t.Error("Expected the vendor tree to be skipped by default")
+	}
+
+	parser = NewGoParser()
+	repoIndex, err = parser.ParseRepository("app", tempDir, types.IndexingConfig{Enabled: true, IncludeVendor: true})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+	xmlContent := repoIndex.Files[".repomix.xml"].Content
+	for _, expected := range []string{`<package name="errs" vendored="vendor/github.com/acme/errs">`, "// Vendored dependency", "func New() error"} {
+		if !strings.Contains(xmlContent, expected) {
+			t.Errorf("Expected the generated XML to contain %q", expected)
+		}
+	}
+	summary, _ := repoIndex.Metadata["api_summary"].(string)
+	if !strings.Contains(summary, "## package errs (vendored vendor/github.com/acme/errs)") {
+		t.Errorf("Expected the vendored package in the API summary, got:\n%s", summary)
+	}
+
+	// Vendored packages are linked by their import path
+	index, _ := repoIndex.Metadata[types.ImplementationsMetadataKey].([]types.InterfaceImplementations)
+	found := false
+	for _, entry := range index {
+		for _, implementation := range entry.Implementations {
+			if entry.Interface == "example.com/app.Describer" && implementation.Type == "github.com/acme/errs.Error" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected the vendored type to implement Describer under its import path, got %+v", index)
+	}
+}
+
+func TestGoParser_IncludeTests(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module test-repo\n\ngo 1.21\n",
+		"lib.go": "package lib\n\n// New creates a client.\nfunc New() *Client { return &Client{} }\n\ntype Client struct{}\n\nfunc (c *Client) Do() string { return \"done\" }\n",
+		"lib_test.go": `package lib_test
+
+import (
+	"fmt"
+	"testing"
+
+	"test-repo"
+)
+
+func TestNew(t *testing.T) {}
+
+func Example() {
+	fmt.Println("package")
+}
+
+func ExampleNew_second() {
+	fmt.Println(lib.New() != nil)
+	// Output: true
+}
+
+func ExampleClient_Do() {
+	fmt.Println(lib.New().Do())
+	// Output: done
+}
+
+func Examples() {}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	parser := NewGoParser()
+	repoIndex, err := parser.ParseRepository("test-repo", tempDir, types.IndexingConfig{Enabled: true})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+	if strings.Contains(repoIndex.Files[".repomix.xml"].Content, "ExampleClient_Do") {
+		t.Error("Expected test files to be excluded by default")
+	}
+
+	repoIndex, err = parser.ParseRepository("test-repo", tempDir, types.IndexingConfig{Enabled: true, IncludeTests: true})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+	if count := repoIndex.Metadata["file_count"]; count != 2 {
+		t.Errorf("Expected 2 Go files, got %v", count)
+	}
+	xmlContent := repoIndex.Files[".repomix.xml"].Content
+	expectedPatterns := []string{
+		"- Test files (*_test.go) are included",
+		`<file path="lib_test.go" package="lib_test">` + "\n// Package: lib_test\n// Test file\n",
+		"func TestNew(t *testing.T)  // lib_test.go:",
+		"func ExampleClient_Do() {\n\tfmt.Println(lib.New().Do())\n\t// Output: done\n}  // lib_test.go:",
+		`<package name="lib_test">`,
+	}
+	for _, pattern := range expectedPatterns {
+		if !strings.Contains(xmlContent, pattern) {
+			t.Errorf("Expected XML content to contain %q", pattern)
+		}
+	}
+
+	constructs, _, err := parser.parseGoFile("lib_test.go", tempDir)
+	if err != nil {
+		t.Fatalf("parseGoFile failed: %v", err)
+	}
+	expected := map[string]string{
+		"Example":           "lib_test",
+		"ExampleNew_second": "New",
+		"ExampleClient_Do":  "Client.Do",
+		"TestNew":           "",
+		"Examples":          "",
+	}
+	for _, construct := range constructs {
+		target, ok := expected[construct.Name]
+		if !ok {
+			continue
+		}
+		if construct.Metadata["example"] != target {
+			t.Errorf("%s: expected example target %q, got %q", construct.Name, target, construct.Metadata["example"])
+		}
+		if (construct.Body != "") != (target != "") {
+			t.Errorf("%s: unexpected body %q", construct.Name, construct.Body)
+		}
+		delete(expected, construct.Name)
+	}
+	if len(expected) > 0 {
+		t.Errorf("Constructs not found: %v", expected)
+	}
+}
+
+func TestVendoredImportPath(t *testing.T) {
+	tests := map[string]string{
+		"vendor/github.com/acme/errs/errs.go":                  "github.com/acme/errs",
+		"tools/vendor/golang.org/x/mod/semver/semver.go":       "golang.org/x/mod/semver",
+		"vendor/a/vendor/b/b.go":                               "b",
+		"vendor/doc.go":                                        "",
+		"internal/vendorutil/util.go":                          "",
+	}
+	for relPath, expected := range tests {
+		importPath, vendored := vendoredImportPath(relPath)
+		if importPath != expected || vendored != (expected != "") {
+			t.Errorf("vendoredImportPath(%q) = %q, %v; expected %q", relPath, importPath, vendored, expected)
+		}
+	}
+}
+
+func TestGoParser_generateAPISummary(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module test-repo\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("Failed to write go.mod: %v", err)
+	}
+
+	testGoContent := `package lib
+
+// Client talks to the service. It is safe for concurrent use.
+type Client struct {
+	Endpoint string
+}
+
+// Do sends a request.
+func (c *Client) Do() error { return nil }
+
+type helper struct{}
+
+// Run is exported but hangs off an unexported receiver.
+func (h helper) Run() {}
+
+// Deprecated: Use Client.
+func Legacy() {}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "lib.go"), []byte(testGoContent), 0644); err != nil {
+		t.Fatalf("Failed to write lib.go: %v", err)
+	}
+
+	repoIndex, err := NewGoParser().ParseRepository("test-repo", tempDir, types.IndexingConfig{Enabled: true})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+	summary, ok := repoIndex.Metadata["api_summary"].(string)
+	if !ok {
+		t.Fatal("Expected api_summary metadata")
+	}
+
+	expected := []string{
+		"## package lib",
+		"- `type Client struct` - Client talks to the service.",
+		"- `func (*Client) Do() error` - Do sends a request.",
+		"- `func Legacy()` (deprecated)",
+	}
+	for _, want := range expected {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", want, summary)
+		}
+	}
+	for _, unwanted := range []string{"Endpoint", "helper", "Run()"} {
+		if strings.Contains(summary, unwanted) {
+			t.Errorf("Expected summary not to contain %q", unwanted)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that interfaces are linked to implementations in other packages of the module
+func TestGoParser_Implementations(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"storage/storage.go": `package storage
+
+import "context"
+
+type Config struct{}
+
+// Store persists values.
+type Store interface {
+	Configurer
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Configurer accepts a configuration.
+type Configurer interface {
+	Configure(cfg *Config) error
+}
+`,
+		"memory/memory.go": `package memory
+
+import (
+	"context"
+
+	st "example.com/app/storage"
+)
+
+// Memory is an in-memory store.
+type Memory struct{}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) { return nil, nil }
+
+func (m Memory) Configure(cfg *st.Config) error { return nil }
+`,
+		"other/other.go": `package other
+
+import "context"
+
+type Config struct{}
+
+// Fake has the method names of Store but configures another type.
+type Fake struct{}
+
+func (Fake) Get(ctx context.Context, key string) ([]byte, error) { return nil, nil }
+
+func (Fake) Configure(cfg *Config) error { return nil }
+`,
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	parser := NewGoParser()
+	repoIndex, err := parser.ParseRepository("app", tempDir, types.IndexingConfig{Enabled: true})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+
+	index, err := types.DecodeImplementations(repoIndex.Metadata[types.ImplementationsMetadataKey])
+	if err != nil {
+		t.Fatalf("DecodeImplementations failed: %v", err)
+	}
+
+	found := make(map[string][]types.Implementation)
+	for _, entry := range index {
+		found[entry.Interface] = entry.Implementations
+	}
+
+	// Memory only implements Store through its pointer, Configure has a value receiver
+	store := found["example.com/app/storage.Store"]
+	if len(store) != 1 || store[0].Type != "example.com/app/memory.Memory" || !store[0].Pointer || store[0].File != "memory/memory.go" {
+		t.Errorf("Expected *memory.Memory to implement Store, got %+v", store)
+	}
+	configurer := found["example.com/app/storage.Configurer"]
+	if len(configurer) != 1 || configurer[0].Type != "example.com/app/memory.Memory" || configurer[0].Pointer {
+		t.Errorf("Expected memory.Memory to implement Configurer, got %+v", configurer)
+	}
+}
+
+func TestGoParser_References(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"store/store.go": `package store
+
+// Store holds values.
+type Store struct{}
+
+// Open opens a store.
+func Open() *Store { return &Store{} }
+
+func (s *Store) Get(key string) string { return s.lookup(key) }
+
+func (s *Store) lookup(key string) string { return key }
+`,
+		"store/helpers.go": `package store
+
+func reset(s *Store) {
+	s.Get("")
+	Open()
+}
+`,
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	st "example.com/app/store"
+)
+
+func main() {
+	s := st.Open()
+	fmt.Println(s.Get("a"))
+
+	var typed *st.Store
+	typed.Get("b")
+
+	literal := &st.Store{}
+	literal.Get("c")
+
+	Open := func() {}
+	Open()
+	run()
+}
+
+func run() {}
+`,
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	parser := NewGoParser()
+	repoIndex, err := parser.ParseRepository("app", tempDir, types.IndexingConfig{Enabled: true})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+
+	index, err := types.DecodeReferences(repoIndex.Metadata[types.ReferencesMetadataKey])
+	if err != nil {
+		t.Fatalf("DecodeReferences failed: %v", err)
+	}
+	found := make(map[string][]string)
+	for _, entry := range index {
+		var sites []string
+		for _, reference := range entry.References {
+			sites = append(sites, fmt.Sprintf("%s:%d %s", reference.File, reference.Line, reference.Caller))
+		}
+		found[entry.Symbol] = sites
+	}
+
+	tests := map[string][]string{
+		// The local Open variable of main is not the package function
+		"example.com/app/store.Open": {"main.go:10 example.com/app.main", "store/helpers.go:5 example.com/app/store.reset"},
+		// s is typed by the call result, only declared types are resolved
+		"example.com/app/store.Store.Get":    {"main.go:14 example.com/app.main", "main.go:17 example.com/app.main", "store/helpers.go:4 example.com/app/store.reset"},
+		"example.com/app/store.Store.lookup": {"store/store.go:9 example.com/app/store.Store.Get"},
+		"example.com/app.run":                {"main.go:21 example.com/app.main"},
+		"example.com/app.main":               nil,
+	}
+	for symbol, expected := range tests {
+		sites, exists := found[symbol]
+		if !exists {
+			t.Errorf("Expected %s to be declared", symbol)
+			continue
+		}
+		if strings.Join(sites, ", ") != strings.Join(expected, ", ") {
+			t.Errorf("References of %s = %v, expected %v", symbol, sites, expected)
+		}
+	}
+	if _, exists := found["example.com/app.Open"]; exists {
+		t.Error("Expected local function values not to be declared symbols")
+	}
+}
+
+// ************************************************************************************************
+// Test that the symbol index records declarations with their signature and doc comment
+func TestGoParser_Symbols(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"store/store.go": `package store
+
+// Store holds values.
+//
+// It is safe for concurrent use.
+type Store struct{}
+
+// Get returns the value of a key.
+func (s *Store) Get(key string) string { return key }
+
+const defaultKey = "key"
+`,
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	parser := NewGoParser()
+	repoIndex, err := parser.ParseRepository("app", tempDir, types.IndexingConfig{Enabled: true})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+	index, err := types.DecodeSymbols(repoIndex.Metadata[types.SymbolsMetadataKey])
+	if err != nil {
+		t.Fatalf("DecodeSymbols failed: %v", err)
+	}
+
+	found := make(map[string]types.Symbol)
+	for _, symbol := range index {
+		found[symbol.QualifiedName()] = symbol
+	}
+	get, exists := found["example.com/app/store.Store.Get"]
+	if !exists || get.Kind != "method" || get.File != "store/store.go" || get.Line != 9 || get.Doc != "Get returns the value of a key." {
+		t.Errorf("Unexpected method symbol: %+v", get)
+	}
+	if store := found["example.com/app/store.Store"]; store.Kind != "struct" || store.Doc != "Store holds values.\n\nIt is safe for concurrent use." {
+		t.Errorf("Expected the whole doc comment of Store, got %+v", store)
+	}
+	if constant, exists := found["example.com/app/store.defaultKey"]; !exists || constant.Exported || constant.Signature != `const defaultKey = "key"` {
+		t.Errorf("Unexpected constant symbol: %+v", constant)
+	}
+}
+
+func TestGoParser_ParallelParsing(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":           "module example.com/app\n\ngo 1.21\n",
+		"broken/broken.go": "package broken\n\nfunc {\n",
+	}
+	for pkg := 0; pkg < 5; pkg++ {
+		for file := 0; file < 20; file++ {
+			files[fmt.Sprintf("pkg%d/file%d.go", pkg, file)] = fmt.Sprintf("package pkg%d\n\n%s// Func%d_%d is declared on line %d.\nfunc Func%d_%d() {}\n",
+				pkg, strings.Repeat("\n", file), pkg, file, file+4, pkg, file)
+		}
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// The same parser indexes repositories concurrently
+	parser := NewGoParser()
+	results := make(chan *types.RepositoryIndex, 2)
+	for run := 0; run < 2; run++ {
+		go func() {
+			repoIndex, err := parser.ParseRepository("app", tempDir, types.IndexingConfig{Enabled: true})
+			if err != nil {
+				t.Errorf("ParseRepository failed: %v", err)
+			}
+			results <- repoIndex
+		}()
+	}
+	first, second := <-results, <-results
+	if first == nil || second == nil {
+		t.FailNow()
+	}
+
+	for path, file := range first.Files {
+		if second.Files[path].Content != file.Content {
+			t.Errorf("Expected %s to be identical across runs", path)
+		}
+	}
+
+	index, err := types.DecodeSymbols(first.Metadata[types.SymbolsMetadataKey])
+	if err != nil {
+		t.Fatalf("DecodeSymbols failed: %v", err)
+	}
+	found := make(map[string]types.Symbol)
+	for _, symbol := range index {
+		found[symbol.QualifiedName()] = symbol
+	}
+	if len(found) != 100 {
+		t.Errorf("Expected the 100 functions of the parsed files, got %d symbols", len(found))
+	}
+	for pkg := 0; pkg < 5; pkg++ {
+		for file := 0; file < 20; file++ {
+			symbol := found[fmt.Sprintf("example.com/app/pkg%d.Func%d_%d", pkg, pkg, file)]
+			if symbol.File != fmt.Sprintf("pkg%d/file%d.go", pkg, file) || symbol.Line != file+4 {
+				t.Errorf("Unexpected position of Func%d_%d: %s:%d", pkg, file, symbol.File, symbol.Line)
+			}
+		}
+	}
+}
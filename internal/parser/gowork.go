@@ -0,0 +1,154 @@
+// ************************************************************************************************
+// Package parser provides Go workspace (go.work) support for the repomix-mcp application.
+// A workspace repository holds several modules; their packages are parsed into the combined
+// analysis and attributed to the module they belong to.
+package parser
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ************************************************************************************************
+// GoModule is a module of a Go workspace.
+type GoModule struct {
+	Path string `json:"path"` // Module path declared in go.mod
+	Dir  string `json:"dir"`  // Module directory relative to the repository root, slash-separated
+}
+
+// ************************************************************************************************
+// findWorkspaceModules reads the go.work file at the repository root and returns its member
+// modules. Members without a readable go.mod are skipped with a warning.
+//
+// Returns:
+//   - []GoModule: The member modules sorted by directory, nil if there is no go.work file.
+//   - error: An error if go.work exists but cannot be read.
+func (p *GoParser) findWorkspaceModules(localPath string) ([]GoModule, error) {
+	data, err := os.ReadFile(filepath.Join(localPath, "go.work"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.work: %w", err)
+	}
+
+	var modules []GoModule
+	for _, dir := range parseGoWorkUses(string(data)) {
+		goMod, err := os.ReadFile(filepath.Join(localPath, filepath.FromSlash(dir), "go.mod"))
+		if err != nil {
+			slog.Warn("Skipping workspace module", "module", dir, "error", err)
+			continue
+		}
+		modulePath := parseModulePath(string(goMod))
+		if modulePath == "" {
+			slog.Warn("Skipping workspace module without module directive in go.mod", "module", dir)
+			continue
+		}
+		modules = append(modules, GoModule{Path: modulePath, Dir: dir})
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Dir < modules[j].Dir
+	})
+	return modules, nil
+}
+
+// ************************************************************************************************
+// parseGoWorkUses returns the directories of the use directives of a go.work file, in both the
+// single-line and the block form, cleaned and relative to the workspace root.
+func parseGoWorkUses(content string) []string {
+	var dirs []string
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		if index := strings.Index(line, "//"); index >= 0 {
+			line = line[:index]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case inBlock:
+		case line == "use (" || line == "use(":
+			inBlock = true
+			continue
+		case strings.HasPrefix(line, "use "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "use "))
+		default:
+			continue
+		}
+
+		if dir := strings.Trim(line, "\"`"); dir != "" {
+			dirs = append(dirs, path.Clean(filepath.ToSlash(dir)))
+		}
+	}
+	return dirs
+}
+
+// ************************************************************************************************
+// parseModulePath returns the module path declared by the module directive of a go.mod file.
+func parseModulePath(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], "\"`")
+		}
+	}
+	return ""
+}
+
+// ************************************************************************************************
+// moduleForFile returns the path of the workspace module containing a file, the module with the
+// deepest directory winning for nested modules.
+//
+// Returns:
+//   - string: The module path, empty if the file belongs to no member module.
+func moduleForFile(modules []GoModule, relPath string) string {
+	if module := containingModule(modules, relPath); module != nil {
+		return module.Path
+	}
+	return ""
+}
+
+// ************************************************************************************************
+// importPathForFile returns the import path of the package containing a file: the path of its
+// module joined with the directory below the module root. Files outside every module fall back
+// to their slash-separated directory.
+func importPathForFile(modules []GoModule, relPath string) string {
+	dir := path.Dir(filepath.ToSlash(relPath))
+	module := containingModule(modules, relPath)
+	if module == nil || module.Path == "" {
+		return dir
+	}
+	if module.Dir != "." {
+		dir = strings.TrimPrefix(strings.TrimPrefix(dir, module.Dir), "/")
+	}
+	if dir == "." || dir == "" {
+		return module.Path
+	}
+	return module.Path + "/" + dir
+}
+
+// ************************************************************************************************
+// containingModule returns the module containing a file, nil if none does.
+func containingModule(modules []GoModule, relPath string) *GoModule {
+	relPath = filepath.ToSlash(relPath)
+
+	var best *GoModule
+	for i := range modules {
+		module := &modules[i]
+		if module.Dir != "." && !strings.HasPrefix(relPath, module.Dir+"/") {
+			continue
+		}
+		if best == nil || len(module.Dir) > len(best.Dir) || best.Dir == "." {
+			best = module
+		}
+	}
+	return best
+}
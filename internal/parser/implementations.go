@@ -0,0 +1,423 @@
+// ************************************************************************************************
+// Package parser provides the interface implementation index for the repomix-mcp application.
+// Interfaces and method sets are collected from the parsed files with every type qualified by
+// the import path of its package, resolving selector expressions against the file imports, so
+// an interface of one package is linked to the types of other packages implementing it.
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// predeclaredTypes are the type names of the universe scope, never qualified by a package.
+var predeclaredTypes = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true, "complex128": true,
+	"error": true, "float32": true, "float64": true, "int": true, "int8": true, "int16": true,
+	"int32": true, "int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+}
+
+// ************************************************************************************************
+// predeclaredAliases maps the predeclared alias types to the type they denote.
+var predeclaredAliases = map[string]string{
+	"any":  "interface{}",
+	"byte": "uint8",
+	"rune": "int32",
+}
+
+// ************************************************************************************************
+// implementationIndex collects the interfaces and method sets of a repository by qualified
+// type name. Methods promoted from embedded fields are not followed.
+type implementationIndex struct {
+	interfaces map[string]*interfaceDecl
+	types      map[string]*typeDecl
+	methods    map[string]map[string]methodDecl // Methods by qualified receiver type, then name
+}
+
+// ************************************************************************************************
+// interfaceDecl is an interface declaration with its canonical method signatures.
+type interfaceDecl struct {
+	file    string
+	line    int
+	methods map[string]string // Canonical signature by method key
+	embeds  []string          // Qualified names of embedded interfaces
+}
+
+// ************************************************************************************************
+// typeDecl is the declaration of a named non-interface type.
+type typeDecl struct {
+	file string
+	line int
+}
+
+// ************************************************************************************************
+// methodDecl is a method with its canonical signature.
+type methodDecl struct {
+	signature string
+	pointer   bool // Declared on the pointer receiver
+}
+
+// ************************************************************************************************
+// newImplementationIndex creates an empty implementation index.
+func newImplementationIndex() *implementationIndex {
+	return &implementationIndex{
+		interfaces: make(map[string]*interfaceDecl),
+		types:      make(map[string]*typeDecl),
+		methods:    make(map[string]map[string]methodDecl),
+	}
+}
+
+// ************************************************************************************************
+// addFile records the type declarations and methods of a parsed file belonging to the package
+// with the given import path.
+func (x *implementationIndex) addFile(fileSet *token.FileSet, file *ast.File, filePath, importPath string) {
+	imports := fileImports(file)
+	qualifier := typeQualifier{importPath: importPath, imports: imports}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if typeSpec.Assign.IsValid() {
+					continue // Aliases share the method set of the aliased type
+				}
+				name := importPath + "." + typeSpec.Name.Name
+				line := fileSet.Position(typeSpec.Pos()).Line
+
+				interfaceType, isInterface := typeSpec.Type.(*ast.InterfaceType)
+				if !isInterface {
+					x.types[name] = &typeDecl{file: filePath, line: line}
+					continue
+				}
+
+				iface := &interfaceDecl{file: filePath, line: line, methods: make(map[string]string)}
+				for _, field := range interfaceType.Methods.List {
+					funcType, isMethod := field.Type.(*ast.FuncType)
+					if !isMethod {
+						iface.embeds = append(iface.embeds, qualifier.qualify(field.Type))
+						continue
+					}
+					for _, methodName := range field.Names {
+						iface.methods[methodKey(importPath, methodName.Name)] = qualifier.signature(funcType)
+					}
+				}
+				x.interfaces[name] = iface
+			}
+
+		case *ast.FuncDecl:
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				continue
+			}
+			receiver, pointer := receiverTypeName(decl.Recv.List[0].Type)
+			if receiver == "" {
+				continue
+			}
+			receiver = importPath + "." + receiver
+			if x.methods[receiver] == nil {
+				x.methods[receiver] = make(map[string]methodDecl)
+			}
+			x.methods[receiver][methodKey(importPath, decl.Name.Name)] = methodDecl{
+				signature: qualifier.signature(decl.Type),
+				pointer:   pointer,
+			}
+		}
+	}
+}
+
+// ************************************************************************************************
+// resolve links every interface to the types implementing it. Empty interfaces and interfaces
+// embedding an interface declared outside the repository are left out, their method set is
+// not known.
+//
+// Returns:
+//   - []types.InterfaceImplementations: The interfaces with at least one method, sorted by name.
+func (x *implementationIndex) resolve() []types.InterfaceImplementations {
+	typeNames := make([]string, 0, len(x.types))
+	for name := range x.types {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+
+	var index []types.InterfaceImplementations
+	for name := range x.interfaces {
+		methods, complete := x.methodSet(name, make(map[string]bool))
+		if !complete || len(methods) == 0 {
+			continue
+		}
+
+		entry := types.InterfaceImplementations{
+			Interface: name,
+			File:      x.interfaces[name].file,
+			Line:      x.interfaces[name].line,
+		}
+		for _, typeName := range typeNames {
+			implements, pointer := x.implements(typeName, methods)
+			if !implements {
+				continue
+			}
+			entry.Implementations = append(entry.Implementations, types.Implementation{
+				Type:    typeName,
+				Pointer: pointer,
+				File:    x.types[typeName].file,
+				Line:    x.types[typeName].line,
+			})
+		}
+		index = append(index, entry)
+	}
+
+	sort.Slice(index, func(i, j int) bool {
+		return index[i].Interface < index[j].Interface
+	})
+	return index
+}
+
+// ************************************************************************************************
+// methodSet returns the full method set of an interface, embedded interfaces included.
+//
+// Returns:
+//   - map[string]string: The canonical signature by method key.
+//   - bool: Whether every embedded interface could be resolved.
+func (x *implementationIndex) methodSet(name string, visiting map[string]bool) (map[string]string, bool) {
+	if name == "error" {
+		return map[string]string{"Error": "() (string)"}, true
+	}
+	iface, exists := x.interfaces[name]
+	if !exists || visiting[name] {
+		return nil, false
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	methods := make(map[string]string, len(iface.methods))
+	for key, signature := range iface.methods {
+		methods[key] = signature
+	}
+	for _, embedded := range iface.embeds {
+		embeddedMethods, complete := x.methodSet(embedded, visiting)
+		if !complete {
+			return nil, false
+		}
+		for key, signature := range embeddedMethods {
+			methods[key] = signature
+		}
+	}
+	return methods, true
+}
+
+// ************************************************************************************************
+// implements reports whether a named type has every method of an interface method set.
+//
+// Returns:
+//   - bool: Whether the type or its pointer implements the interface.
+//   - bool: Whether only the pointer type does, some methods having a pointer receiver.
+func (x *implementationIndex) implements(typeName string, interfaceMethods map[string]string) (bool, bool) {
+	declared := x.methods[typeName]
+	pointer := false
+	for key, signature := range interfaceMethods {
+		method, exists := declared[key]
+		if !exists || method.signature != signature {
+			return false, false
+		}
+		pointer = pointer || method.pointer
+	}
+	return true, pointer
+}
+
+// ************************************************************************************************
+// methodKey identifies a method by name. Unexported names are qualified by the import path,
+// since only types of the same package can implement them.
+func methodKey(importPath, name string) string {
+	if ast.IsExported(name) {
+		return name
+	}
+	return importPath + "." + name
+}
+
+// ************************************************************************************************
+// receiverTypeName returns the base type name of a method receiver, without type parameters.
+//
+// Returns:
+//   - string: The type name, empty for an unsupported receiver expression.
+//   - bool: Whether the receiver is a pointer.
+func receiverTypeName(expr ast.Expr) (string, bool) {
+	pointer := false
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr, pointer = star.X, true
+	}
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr = t.X
+	case *ast.IndexListExpr:
+		expr = t.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name, pointer
+	}
+	return "", false
+}
+
+// ************************************************************************************************
+// fileImports maps the names a file refers to its imports by to their import paths.
+// Blank and dot imports are left out.
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := defaultImportName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		imports[name] = importPath
+	}
+	return imports
+}
+
+// ************************************************************************************************
+// defaultImportName guesses the package name of an import path: its last element, skipping a
+// major version suffix such as "/v2" and dropping a gopkg.in ".v3" suffix.
+func defaultImportName(importPath string) string {
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && isDigits(name[1:]) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+	if index := strings.Index(name, ".v"); index > 0 && isDigits(name[index+2:]) {
+		name = name[:index]
+	}
+	return name
+}
+
+// ************************************************************************************************
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ************************************************************************************************
+// typeQualifier renders type expressions of a file with every named type qualified by the
+// import path of its package, so signatures from different packages compare equal.
+type typeQualifier struct {
+	importPath string            // Import path of the file's package
+	imports    map[string]string // Import path by name used in the file
+}
+
+// ************************************************************************************************
+// signature renders the canonical parameter and result types of a function type.
+func (q typeQualifier) signature(funcType *ast.FuncType) string {
+	return "(" + strings.Join(q.fieldTypes(funcType.Params), ", ") + ") (" + strings.Join(q.fieldTypes(funcType.Results), ", ") + ")"
+}
+
+// ************************************************************************************************
+// fieldTypes renders the qualified type of every entry of a field list, repeated per name.
+func (q typeQualifier) fieldTypes(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var rendered []string
+	for _, field := range fields.List {
+		typeString := q.qualify(field.Type)
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			rendered = append(rendered, typeString)
+		}
+	}
+	return rendered
+}
+
+// ************************************************************************************************
+// qualify renders a type expression with named types qualified by import path.
+func (q typeQualifier) qualify(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if aliased, exists := predeclaredAliases[t.Name]; exists {
+			return aliased
+		}
+		if predeclaredTypes[t.Name] {
+			return t.Name
+		}
+		return q.importPath + "." + t.Name
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			if importPath, exists := q.imports[pkg.Name]; exists {
+				return importPath + "." + t.Sel.Name
+			}
+			return pkg.Name + "." + t.Sel.Name
+		}
+		return "unknown"
+	case *ast.StarExpr:
+		return "*" + q.qualify(t.X)
+	case *ast.Ellipsis:
+		return "..." + q.qualify(t.Elt)
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "[]" + q.qualify(t.Elt)
+		}
+		if length, ok := t.Len.(*ast.BasicLit); ok {
+			return "[" + length.Value + "]" + q.qualify(t.Elt)
+		}
+		return "[...]" + q.qualify(t.Elt)
+	case *ast.MapType:
+		return "map[" + q.qualify(t.Key) + "]" + q.qualify(t.Value)
+	case *ast.ChanType:
+		switch t.Dir {
+		case ast.RECV:
+			return "<-chan " + q.qualify(t.Value)
+		case ast.SEND:
+			return "chan<- " + q.qualify(t.Value)
+		default:
+			return "chan " + q.qualify(t.Value)
+		}
+	case *ast.FuncType:
+		return "func" + q.signature(t)
+	case *ast.ParenExpr:
+		return q.qualify(t.X)
+	case *ast.IndexExpr:
+		return q.qualify(t.X) + "[" + q.qualify(t.Index) + "]"
+	case *ast.IndexListExpr:
+		arguments := make([]string, len(t.Indices))
+		for i, index := range t.Indices {
+			arguments[i] = q.qualify(index)
+		}
+		return q.qualify(t.X) + "[" + strings.Join(arguments, ", ") + "]"
+	case *ast.InterfaceType:
+		if t.Methods == nil || len(t.Methods.List) == 0 {
+			return "interface{}"
+		}
+		return "interface{...}"
+	case *ast.StructType:
+		if t.Fields == nil || len(t.Fields.List) == 0 {
+			return "struct{}"
+		}
+		return "struct{...}"
+	default:
+		return "unknown"
+	}
+}
\ No newline at end of file
@@ -0,0 +1,417 @@
+// ************************************************************************************************
+// Package parser provides the Java source parser of the repomix-mcp application. Sources are
+// split into tokens, then the type declarations of every file are scanned: classes, interfaces,
+// enums, records and annotation types with their constructors, methods and fields, nested types
+// included. Annotations are kept with the declarations they apply to, and Javadoc comments give
+// their documentation. A declaration is exported when it is public in an exported type; the
+// members of interfaces and annotation types are public unless declared private.
+package parser
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// JavaLanguage describes the Java sources indexed by the java_native strategy.
+var JavaLanguage = SourceLanguage{
+	Name:           "java",
+	Strategy:       types.IndexingStrategyJavaNative,
+	Extensions:     []string{".java"},
+	SkippedDirs:    []string{"target", "build", "out", "test"},
+	CommentPrefix:  "//",
+	ConstructTypes: []string{"class", "interface", "enum", "record", "annotation", "constructor", "method", "field"},
+	IsTestFile:     isJavaTestFile,
+	ParseFile:      ParseJavaFile,
+}
+
+// ************************************************************************************************
+// JavaProjectFiles are the files at the root of a repository marking a Maven or Gradle project.
+var JavaProjectFiles = []string{"pom.xml", "build.gradle", "build.gradle.kts"}
+
+// ************************************************************************************************
+// javaInlineTag matches Javadoc inline tags such as {@code value} or {@link Type#method}.
+var javaInlineTag = regexp.MustCompile(`\{@\w+\s*([^}]*)\}`)
+
+// ************************************************************************************************
+// javaHTMLTag matches the HTML tags of Javadoc comments, such as <p> or </code>.
+var javaHTMLTag = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+
+// ************************************************************************************************
+// NewJavaParser creates the parser of Java repositories.
+//
+// Example usage:
+//
+//	repoIndex, err := parser.NewJavaParser().ParseRepository("service", "/path/to/service", config)
+func NewJavaParser() *SourceParser {
+	return NewSourceParser(JavaLanguage)
+}
+
+// ************************************************************************************************
+// javaParser extracts the declarations of the tokens of a file.
+type javaParser struct {
+	tokenStream
+	analysis *SourceFile
+}
+
+// ************************************************************************************************
+// ParseJavaFile extracts the package and the type declarations of a Java file. The Javadoc of
+// the package declaration of package-info.java documents the package.
+//
+// Example usage:
+//
+//	analysis := parser.ParseJavaFile("src/main/java/com/acme/Client.java", src)
+func ParseJavaFile(relPath string, src []byte) *SourceFile {
+	tokens := scanJava(string(src))
+	p := &javaParser{
+		tokenStream: newTokenStream(tokens),
+		analysis:    &SourceFile{Path: relPath, Package: "default"},
+	}
+
+	// The package declaration, after the annotations of package-info.java
+	i := 0
+	if _, next := p.parseAnnotations(0, len(tokens)); p.is(next, "package") {
+		end := p.endOfJavaStatement(next+1, len(tokens))
+		p.analysis.Package = p.join(next+1, end)
+		if path.Base(relPath) == "package-info.java" {
+			p.analysis.Doc = tokens[0].doc
+		}
+		i = end + 1
+	}
+	p.analysis.Module = p.analysis.Package
+
+	p.parseMembers(i, len(tokens), "", "", true, "")
+	return p.analysis
+}
+
+// ************************************************************************************************
+// scanJava splits Java source into tokens, comments left out. Javadoc comments are attached to
+// the next token.
+func scanJava(src string) []sourceToken {
+	var tokens []sourceToken
+	var doc string
+	line := 1
+	newline := false
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		start, startLine := i, line
+		literal := false
+
+		switch {
+		case c == '\n':
+			line++
+			newline = true
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i + 4
+			}
+			comment := src[i:end]
+			line += strings.Count(comment, "\n")
+			i = end
+			if strings.HasPrefix(comment, "/**") && comment != "/**/" {
+				doc = javadocText(comment)
+			}
+			continue
+		case strings.HasPrefix(src[i:], `"""`):
+			i = skipJavaTextBlock(src, i)
+			literal = true
+		case c == '"' || c == '\'':
+			i = skipScriptString(src, i)
+			literal = true
+		case isIdentifierStart(c) || c >= '0' && c <= '9':
+			i++
+			for i < len(src) && (isIdentifierStart(src[i]) || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			if c >= '0' && c <= '9' {
+				for i < len(src) && (src[i] == '.' || isIdentifierStart(src[i]) || src[i] >= '0' && src[i] <= '9') {
+					i++
+				}
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			i += 3
+		case strings.HasPrefix(src[i:], "::") || strings.HasPrefix(src[i:], "->"):
+			i += 2
+		default:
+			i++
+		}
+
+		text := src[start:i]
+		line += strings.Count(text, "\n")
+		tokens = append(tokens, sourceToken{text: text, literal: literal, start: start, end: i, line: startLine, newline: newline, doc: doc})
+		doc, newline = "", false
+	}
+	return tokens
+}
+
+// ************************************************************************************************
+// parseMembers extracts the type declarations and members between two token indexes: the top
+// level of a file when owner is empty, or the body of a type.
+//
+// Parameters:
+//   - owner: The qualified name of the enclosing type, e.g. "Outer.Inner".
+//   - ownerKind: The declaration keyword of the enclosing type, e.g. "interface".
+//   - ownerExported: Whether the enclosing type is exported.
+//   - className: The simple name of the enclosing type, naming its constructors.
+func (p *javaParser) parseMembers(lo, hi int, owner, ownerKind string, ownerExported bool, className string) {
+	i := lo
+	if ownerKind == "enum" {
+		i = p.skipEnumConstants(lo, hi)
+	}
+
+	for i < hi {
+		if p.is(i, ";") {
+			i++
+			continue
+		}
+		if owner == "" && p.is(i, "import") {
+			i = p.endOfJavaStatement(i, hi) + 1
+			continue
+		}
+		doc := p.tokens[i].doc
+		annotations, sigStart := p.parseAnnotations(i, hi)
+		if sigStart < hi && doc == "" {
+			doc = p.tokens[sigStart].doc
+		}
+
+		// Modifiers, non-sealed being split by the scanner
+		j := sigStart
+		public, private := false, false
+		for j < hi {
+			if p.is(j, "non") && p.is(j+1, "-") && p.is(j+2, "sealed") {
+				j += 3
+				continue
+			}
+			if !p.isWord(j) || !isJavaModifier(p.tokens[j].text) {
+				break
+			}
+			public = public || p.is(j, "public")
+			private = private || p.is(j, "private")
+			j++
+		}
+		if ownerKind == "interface" || ownerKind == "annotation" {
+			public = !private
+		}
+		exported := public && ownerExported
+
+		add := func(constructType, name, signature string) {
+			p.analysis.Constructs = append(p.analysis.Constructs, SourceConstruct{
+				Type:        constructType,
+				Name:        name,
+				Signature:   signature,
+				Annotations: annotations,
+				File:        p.analysis.Path,
+				Line:        p.tokens[min(sigStart, len(p.tokens)-1)].line,
+				Summary:     docSummary(doc),
+				Doc:         doc,
+				Exported:    exported,
+			})
+		}
+		qualify := func(name string) string {
+			if owner == "" {
+				return name
+			}
+			return owner + "." + name
+		}
+
+		// Type declarations, nested types included
+		kind := ""
+		switch {
+		case p.is(j, "@") && p.is(j+1, "interface"):
+			kind, j = "annotation", j+1
+		case p.is(j, "class") || p.is(j, "interface") || p.is(j, "enum") || p.is(j, "record"):
+			kind = p.tokens[j].text
+		}
+		if kind != "" && p.isWord(j+1) {
+			name := p.tokens[j+1].text
+			body := j + 2
+			for body < hi && !p.is(body, "{") && !p.is(body, ";") {
+				body = p.skip(body)
+			}
+			signature := p.join(sigStart, body)
+			if kind == "enum" && p.is(body, "{") {
+				signature += " { " + strings.Join(p.enumConstants(body+1, p.matchOr(body, hi)), ", ") + " }"
+			}
+			add(kind, qualify(name), signature)
+			if p.is(body, "{") {
+				p.parseMembers(body+1, p.matchOr(body, hi), qualify(name), kind, exported, name)
+			}
+			i = p.skip(body)
+			continue
+		}
+
+		// Initializer blocks
+		if p.is(j, "{") {
+			i = p.skip(j)
+			continue
+		}
+
+		// Methods, constructors and fields: the first "(", "=" or ";" outside generic arguments
+		// tells them apart
+		k, depth := j, 0
+		for k < hi && (depth > 0 || !p.is(k, "(") && !p.is(k, "=") && !p.is(k, ";") && !p.is(k, "{")) {
+			switch {
+			case p.is(k, "<"):
+				depth++
+			case p.is(k, ">"):
+				depth--
+			}
+			k = p.skip(k)
+		}
+		switch {
+		case k >= hi:
+			i = hi
+		case p.is(k, "(") && k > j && p.isWord(k-1):
+			name := p.tokens[k-1].text
+			constructType := "method"
+			if name == className && (k-1 == j || p.is(k-2, ">")) {
+				constructType = "constructor"
+			}
+			end := p.matchOr(k, hi) + 1
+			for end < hi && !p.is(end, "{") && !p.is(end, ";") {
+				end = p.skip(end)
+			}
+			add(constructType, qualify(name), p.join(sigStart, end))
+			i = p.skip(end)
+		case (p.is(k, "=") || p.is(k, ";")) && k > j && p.isWord(k-1):
+			add("field", qualify(p.tokens[k-1].text), p.join(sigStart, k))
+			i = p.endOfJavaStatement(k, hi) + 1
+		default:
+			i = p.skip(max(k, i))
+		}
+	}
+}
+
+// ************************************************************************************************
+// parseAnnotations reads the annotations starting at a token, e.g. @Override or
+// @RequestMapping("/users"); "@interface" starts an annotation type instead.
+//
+// Returns:
+//   - []string: The annotations, each on a single line.
+//   - int: The index of the token after the annotations.
+func (p *javaParser) parseAnnotations(i, hi int) ([]string, int) {
+	var annotations []string
+	for p.is(i, "@") && p.isWord(i+1) && !p.is(i+1, "interface") {
+		start := i
+		i += 2
+		for p.is(i, ".") && p.isWord(i+1) {
+			i += 2
+		}
+		if p.is(i, "(") {
+			i = p.matchOr(i, hi) + 1
+		}
+		annotations = append(annotations, p.join(start, i))
+	}
+	return annotations, i
+}
+
+// ************************************************************************************************
+// enumConstants returns the names of the constants starting an enum body.
+func (p *javaParser) enumConstants(lo, hi int) []string {
+	var constants []string
+	expectName := true
+	for i := lo; i < hi && !p.is(i, ";"); i = p.skip(i) {
+		if p.is(i, ",") {
+			expectName = true
+			continue
+		}
+		if expectName {
+			_, i = p.parseAnnotations(i, hi)
+			if p.isWord(i) {
+				constants = append(constants, p.tokens[i].text)
+			}
+			expectName = false
+		}
+	}
+	return constants
+}
+
+// ************************************************************************************************
+// skipEnumConstants returns the index of the first member declared after the constants of an
+// enum body.
+func (p *javaParser) skipEnumConstants(lo, hi int) int {
+	for i := lo; i < hi; i = p.skip(i) {
+		if p.is(i, ";") {
+			return i + 1
+		}
+	}
+	return hi
+}
+
+// ************************************************************************************************
+// endOfJavaStatement returns the index of the semicolon ending a statement.
+func (p *javaParser) endOfJavaStatement(i, hi int) int {
+	for j := i; j < hi; j = p.skip(j) {
+		if p.is(j, ";") {
+			return j
+		}
+	}
+	return hi
+}
+
+// ************************************************************************************************
+// skipJavaTextBlock returns the offset after the text block starting at an offset.
+func skipJavaTextBlock(src string, i int) int {
+	for i += 3; i < len(src); i++ {
+		if src[i] == '\\' {
+			i++
+		} else if strings.HasPrefix(src[i:], `"""`) {
+			return i + 3
+		}
+	}
+	return len(src)
+}
+
+// ************************************************************************************************
+// javadocText returns the description of a Javadoc comment: block tags are left out, inline
+// tags are replaced by their text and HTML tags removed.
+func javadocText(comment string) string {
+	comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/**"), "*/")
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if strings.HasPrefix(line, "@") {
+			break
+		}
+		lines = append(lines, line)
+	}
+	text := strings.Trim(strings.Join(lines, "\n"), "\n")
+	text = javaInlineTag.ReplaceAllString(text, "$1")
+	return strings.TrimSpace(javaHTMLTag.ReplaceAllString(text, ""))
+}
+
+// ************************************************************************************************
+// isJavaModifier reports whether a word is a modifier of declarations.
+func isJavaModifier(word string) bool {
+	switch word {
+	case "public", "protected", "private", "abstract", "static", "final", "sealed", "strictfp",
+		"synchronized", "native", "transient", "volatile", "default":
+		return true
+	}
+	return false
+}
+
+// ************************************************************************************************
+// isJavaTestFile reports whether a file holds JUnit or TestNG tests, e.g. "ClientTest.java".
+func isJavaTestFile(relPath string) bool {
+	name := strings.TrimSuffix(path.Base(relPath), ".java")
+	return strings.HasSuffix(name, "Test") || strings.HasSuffix(name, "Tests") || strings.HasSuffix(name, "IT") ||
+		strings.HasPrefix(name, "Test") && len(name) > 4 && name[4] >= 'A' && name[4] <= 'Z'
+}
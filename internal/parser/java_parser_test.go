@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+func TestParseJavaFile(t *testing.T) {
+	src := `/*
+ * Licensed under the Apache License, Version 2.0.
+ */
+package com.acme.client;
+
+import java.util.List;
+
+/**
+ * Sends requests to the {@code Acme} API.
+ * <p>
+ * Thread safe.
+ *
+ * @author someone
+ */
+@Service
+@RequestMapping(value = "/api", produces = "application/json")
+public final class Client<T extends Comparable<T>> extends Base implements Closeable {
+    public static final String VERSION = "1.0";
+    private final Map<String, List<T>> cache = new HashMap<>();
+    protected int retries;
+    static final String QUERY = """
+        class Hidden { }
+        """;
+
+    static {
+        init();
+    }
+
+    /** Creates a client. */
+    public Client(String url) {
+        this.url = url;
+    }
+
+    @Override
+    public <R> List<R> fetch(Class<R> type, String... ids) throws IOException {
+        Runnable r = () -> { hidden(); };
+        return List.of();
+    }
+
+    private void reset() {}
+
+    public static class Builder {
+        public Builder url(String url) { return this; }
+    }
+
+    private static class Cache {
+        public void clear() {}
+    }
+}
+
+public interface Closeable extends AutoCloseable {
+    void close() throws IOException;
+    default boolean isOpen() { return true; }
+    private void check() {}
+}
+
+public enum Level {
+    @Deprecated LOW("l") { }, HIGH("h");
+
+    private final String code;
+
+    Level(String code) { this.code = code; }
+}
+
+public record Point(int x, int y) implements Shape {
+    public double norm() { return 0; }
+}
+
+public @interface Retry {
+    int attempts() default 3;
+}
+
+public sealed interface Shape permits Point {}
+
+non-sealed class Square {}
+`
+	analysis := ParseJavaFile("src/main/java/com/acme/client/Client.java", []byte(src))
+
+	if analysis.Module != "com.acme.client" || analysis.Package != "com.acme.client" {
+		t.Errorf("Expected module com.acme.client in package com.acme.client, got %s in %s", analysis.Module, analysis.Package)
+	}
+	if analysis.Doc != "" {
+		t.Errorf("Expected no package documentation outside package-info.java, got %q", analysis.Doc)
+	}
+
+	expected := []SourceConstruct{
+		{Type: "class", Name: "Client", Signature: "public final class Client<T extends Comparable<T>> extends Base implements Closeable", Line: 17, Exported: true, Summary: "Sends requests to the Acme API."},
+		{Type: "field", Name: "Client.VERSION", Signature: "public static final String VERSION", Line: 18, Exported: true},
+		{Type: "field", Name: "Client.cache", Signature: "private final Map<String, List<T>> cache", Line: 19},
+		{Type: "field", Name: "Client.retries", Signature: "protected int retries", Line: 20},
+		{Type: "field", Name: "Client.QUERY", Signature: "static final String QUERY", Line: 21},
+		{Type: "constructor", Name: "Client.Client", Signature: "public Client(String url)", Line: 30, Exported: true, Summary: "Creates a client."},
+		{Type: "method", Name: "Client.fetch", Signature: "public <R> List<R> fetch(Class<R> type, String... ids) throws IOException", Line: 35, Exported: true},
+		{Type: "method", Name: "Client.reset", Signature: "private void reset()", Line: 40},
+		{Type: "class", Name: "Client.Builder", Signature: "public static class Builder", Line: 42, Exported: true},
+		{Type: "method", Name: "Client.Builder.url", Signature: "public Builder url(String url)", Line: 43, Exported: true},
+		{Type: "class", Name: "Client.Cache", Signature: "private static class Cache", Line: 46},
+		{Type: "method", Name: "Client.Cache.clear", Signature: "public void clear()", Line: 47},
+		{Type: "interface", Name: "Closeable", Signature: "public interface Closeable extends AutoCloseable", Line: 51, Exported: true},
+		{Type: "method", Name: "Closeable.close", Signature: "void close() throws IOException", Line: 52, Exported: true},
+		{Type: "method", Name: "Closeable.isOpen", Signature: "default boolean isOpen()", Line: 53, Exported: true},
+		{Type: "method", Name: "Closeable.check", Signature: "private void check()", Line: 54},
+		{Type: "enum", Name: "Level", Signature: "public enum Level { LOW, HIGH }", Line: 57, Exported: true},
+		{Type: "field", Name: "Level.code", Signature: "private final String code", Line: 60},
+		{Type: "constructor", Name: "Level.Level", Signature: "Level(String code)", Line: 62},
+		{Type: "record", Name: "Point", Signature: "public record Point(int x, int y) implements Shape", Line: 65, Exported: true},
+		{Type: "method", Name: "Point.norm", Signature: "public double norm()", Line: 66, Exported: true},
+		{Type: "annotation", Name: "Retry", Signature: "public @interface Retry", Line: 69, Exported: true},
+		{Type: "method", Name: "Retry.attempts", Signature: "int attempts() default 3", Line: 70, Exported: true},
+		{Type: "interface", Name: "Shape", Signature: "public sealed interface Shape permits Point", Line: 73, Exported: true},
+		{Type: "class", Name: "Square", Signature: "non-sealed class Square", Line: 75},
+	}
+	if len(analysis.Constructs) != len(expected) {
+		for _, construct := range analysis.Constructs {
+			t.Logf("%s %s at line %d: %s", construct.Type, construct.Name, construct.Line, construct.Signature)
+		}
+		t.Fatalf("Expected %d constructs, got %d", len(expected), len(analysis.Constructs))
+	}
+	for i, want := range expected {
+		got := analysis.Constructs[i]
+		if got.Type != want.Type || got.Name != want.Name || got.Signature != want.Signature ||
+			got.Line != want.Line || got.Exported != want.Exported || got.Summary != want.Summary {
+			t.Errorf("Construct %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	if doc := analysis.Constructs[0].Doc; doc != "Sends requests to the Acme API.\n\nThread safe." {
+		t.Errorf("Unexpected documentation of Client %q", doc)
+	}
+	if annotations := analysis.Constructs[0].Annotations; len(annotations) != 2 || annotations[0] != "@Service" ||
+		annotations[1] != `@RequestMapping(value = "/api", produces = "application/json")` {
+		t.Errorf("Expected the Service and RequestMapping annotations on Client, got %v", annotations)
+	}
+	if annotations := analysis.Constructs[6].Annotations; len(annotations) != 1 || annotations[0] != "@Override" {
+		t.Errorf("Expected the Override annotation on fetch, got %v", annotations)
+	}
+}
+
+func TestParseJavaFile_PackageInfo(t *testing.T) {
+	src := "/**\n * Clients of the Acme API.\n */\n@NonNullApi\npackage com.acme.client;\n"
+	analysis := ParseJavaFile("src/main/java/com/acme/client/package-info.java", []byte(src))
+
+	if analysis.Package != "com.acme.client" {
+		t.Errorf("Expected package com.acme.client, got %s", analysis.Package)
+	}
+	if analysis.Doc != "Clients of the Acme API." {
+		t.Errorf("Unexpected package documentation %q", analysis.Doc)
+	}
+	if len(analysis.Constructs) != 0 {
+		t.Errorf("Expected no constructs, got %d", len(analysis.Constructs))
+	}
+}
+
+func TestJavaParser_ParseRepository(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"pom.xml":                                  "<project></project>\n",
+		"src/main/java/com/acme/App.java":          "package com.acme;\n\npublic class App {\n    public static void main(String[] args) {}\n}\n",
+		"src/main/java/com/acme/api/Client.java":   "package com.acme.api;\n\npublic class Client {\n    // TODO: add retries\n    public String fetch() { return null; }\n    private void reset() {}\n}\n",
+		"src/main/java/com/acme/api/Helper.java":   "class Helper {}\n",
+		"src/main/java/com/acme/api/Fixtures.java": "package com.acme.api;\n\npublic class Fixtures {}\n",
+		"src/test/java/com/acme/AppSupport.java":   "package com.acme;\n\npublic class AppSupport {}\n",
+		"src/main/java/com/acme/ClientTest.java":   "package com.acme;\n\npublic class ClientTest {}\n",
+		"target/generated/Generated.java":          "package gen;\n\npublic class Generated {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	repoIndex, err := NewJavaParser().ParseRepository("service", tempDir, types.IndexingConfig{})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+
+	if repoIndex.Metadata["indexer_type"] != "java_native" {
+		t.Errorf("Expected indexer_type 'java_native', got '%v'", repoIndex.Metadata["indexer_type"])
+	}
+	if repoIndex.Metadata["file_count"] != 4 {
+		t.Errorf("Expected file_count 4, got '%v'", repoIndex.Metadata["file_count"])
+	}
+
+	xmlFile, exists := repoIndex.Files[".repomix.xml"]
+	if !exists {
+		t.Fatal("Expected .repomix.xml file to be generated")
+	}
+	for _, pattern := range []string{
+		`<file path="src/main/java/com/acme/api/Client.java" module="com.acme.api">`,
+		`<package name="com.acme.api">`,
+		"public class Client  // src/main/java/com/acme/api/Client.java:3",
+		"public String fetch()",
+		"public static void main(String[] args)",
+	} {
+		if !strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content to contain '%s'", pattern)
+		}
+	}
+	for _, pattern := range []string{"AppSupport", "ClientTest", "Generated", "reset", "class Helper"} {
+		if strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content not to contain '%s'", pattern)
+		}
+	}
+}
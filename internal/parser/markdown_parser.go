@@ -0,0 +1,259 @@
+// ************************************************************************************************
+// Package parser provides the Markdown structure parser of the repomix-mcp application.
+// Markdown files are scanned line by line: ATX ("## Title") and setext (underlined) headings
+// split the document into nested sections, and the fenced code blocks and links of each
+// section are recorded, so documentation can be retrieved by topic instead of whole files.
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// MarkdownExtensions are the extensions of the files parsed by ParseMarkdown.
+var MarkdownExtensions = []string{".md", ".markdown"}
+
+// ************************************************************************************************
+// markdownLinkPattern matches inline links, "[text](target)" with an optional title; images
+// are told apart by the "!" before them.
+var markdownLinkPattern = regexp.MustCompile(`(!?)\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+["'(][^)]*)?\)`)
+
+// ************************************************************************************************
+// markdownReferencePattern matches link reference definitions, "[label]: target".
+var markdownReferencePattern = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:\s*<?([^\s>]+)>?`)
+
+// ************************************************************************************************
+// ParseMarkdown extracts the sections of a Markdown document, in document order. Each heading
+// opens a section closed by the next heading of the same or a higher level, so sections nest;
+// the text before the first heading, if any, is a level 0 section. Code blocks and links are
+// attached to the innermost section; headings, links and fences inside code blocks are ignored.
+//
+// Returns:
+//   - []types.MarkdownSection: The sections, empty for a document without text.
+//
+// Example usage:
+//
+//	sections := parser.ParseMarkdown(file.Content)
+func ParseMarkdown(content string) []types.MarkdownSection {
+	lines := strings.Split(content, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var sections []types.MarkdownSection
+	var open []int // Sections not closed yet, outermost first
+	current := -1  // Innermost section
+	fence := ""    // Opening fence of the code block being scanned
+	paragraph := 0 // Lines of the paragraph being scanned, a setext heading underlines one
+	anchors := make(map[string]int)
+
+	// openSection closes the sections of the same or a deeper level and opens a heading's
+	openSection := func(level int, title string, line int) {
+		for len(open) > 0 && sections[open[len(open)-1]].Level >= level {
+			sections[open[len(open)-1]].End = line - 1
+			open = open[:len(open)-1]
+		}
+		if current >= 0 && sections[current].Level == 0 {
+			sections[current].End = line - 1
+			if sections[current].End < sections[current].Start {
+				sections = sections[:current] // Its only line underlines the heading
+			}
+		}
+		parent := -1
+		if len(open) > 0 {
+			parent = open[len(open)-1]
+		}
+		sections = append(sections, types.MarkdownSection{
+			Level:  level,
+			Title:  title,
+			Anchor: markdownAnchor(title, anchors),
+			Parent: parent,
+			Start:  line,
+		})
+		current = len(sections) - 1
+		if level > 0 {
+			open = append(open, current)
+		}
+	}
+
+	for index, raw := range lines {
+		number := index + 1
+		line := strings.TrimRight(raw, "\r")
+		trimmed := strings.TrimLeft(line, " ")
+		indented := len(line)-len(trimmed) > 3 || strings.HasPrefix(line, "\t")
+
+		// Code block content, up to a closing fence of the same character at least as long
+		if fence != "" {
+			if !indented && strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				sections[current].Code[len(sections[current].Code)-1].End = number
+				fence = ""
+			}
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			paragraph = 0
+			continue
+		}
+		if current < 0 {
+			openSection(0, "", number)
+		}
+
+		if !indented {
+			if marker := markdownFence(trimmed); marker != "" {
+				language := ""
+				if fields := strings.Fields(trimmed[len(marker):]); len(fields) > 0 {
+					language = strings.TrimPrefix(strings.TrimSuffix(fields[0], "}"), "{.")
+				}
+				sections[current].Code = append(sections[current].Code, types.MarkdownCodeBlock{Language: language, Start: number, End: len(lines)})
+				fence, paragraph = marker, 0
+				continue
+			}
+			if level, title, ok := markdownATXHeading(trimmed); ok {
+				openSection(level, title, number)
+				paragraph = 0
+				continue
+			}
+			if level := markdownSetextLevel(trimmed); level > 0 && paragraph == 1 {
+				// The underlined line moves from the enclosing section to the heading's
+				links := sections[current].Links
+				var moved []types.MarkdownLink
+				for len(links) > 0 && links[len(links)-1].Line == number-1 {
+					moved = append([]types.MarkdownLink{links[len(links)-1]}, moved...)
+					links = links[:len(links)-1]
+				}
+				sections[current].Links = links
+				openSection(level, strings.TrimSpace(lines[index-1]), number-1)
+				sections[current].Links = moved
+				paragraph = 0
+				continue
+			}
+		}
+
+		sections[current].Links = append(sections[current].Links, markdownLinks(line, number)...)
+		paragraph++
+	}
+
+	for _, index := range open {
+		sections[index].End = len(lines)
+	}
+	if current >= 0 && sections[current].Level == 0 {
+		sections[current].End = len(lines)
+	}
+	return sections
+}
+
+// ************************************************************************************************
+// markdownFence returns the opening fence of a code block line, "```" or "~~~" possibly longer,
+// or an empty string. Backtick fences cannot have backticks in their info string.
+func markdownFence(trimmed string) string {
+	for _, char := range []string{"`", "~"} {
+		if !strings.HasPrefix(trimmed, char+char+char) {
+			continue
+		}
+		marker := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, char))]
+		if char == "`" && strings.Contains(trimmed[len(marker):], "`") {
+			return ""
+		}
+		return marker
+	}
+	return ""
+}
+
+// ************************************************************************************************
+// markdownATXHeading parses an ATX heading, 1 to 6 "#" followed by a space, without its
+// closing sequence of "#".
+//
+// Returns:
+//   - int: The heading level.
+//   - string: The heading text.
+//   - bool: Whether the line is an ATX heading.
+func markdownATXHeading(trimmed string) (int, string, bool) {
+	level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+	if level < 1 || level > 6 {
+		return 0, "", false
+	}
+	rest := trimmed[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false
+	}
+	title := strings.TrimSpace(rest)
+	if closing := strings.TrimRight(title, "#"); closing == "" || strings.HasSuffix(closing, " ") {
+		title = strings.TrimSpace(closing)
+	}
+	return level, title, true
+}
+
+// ************************************************************************************************
+// markdownSetextLevel returns the level of a setext underline, 1 for "===" and 2 for "---",
+// or 0 for other lines.
+func markdownSetextLevel(trimmed string) int {
+	underline := strings.TrimRight(trimmed, " \t")
+	switch {
+	case strings.Trim(underline, "=") == "":
+		return 1
+	case strings.Trim(underline, "-") == "":
+		return 2
+	}
+	return 0
+}
+
+// ************************************************************************************************
+// markdownLinks extracts the links of a line outside code spans: inline links and reference
+// definitions. Images are not links.
+func markdownLinks(line string, number int) []types.MarkdownLink {
+	if match := markdownReferencePattern.FindStringSubmatch(line); match != nil {
+		return []types.MarkdownLink{{Text: match[1], Target: match[2], Line: number}}
+	}
+
+	// Code spans are blanked so their brackets are not read as links
+	var text strings.Builder
+	inCode := false
+	for _, char := range line {
+		if char == '`' {
+			inCode = !inCode
+		}
+		if inCode || char == '`' {
+			text.WriteByte(' ')
+			continue
+		}
+		text.WriteRune(char)
+	}
+
+	var links []types.MarkdownLink
+	for _, match := range markdownLinkPattern.FindAllStringSubmatch(text.String(), -1) {
+		if match[1] == "!" {
+			continue
+		}
+		links = append(links, types.MarkdownLink{Text: strings.TrimSpace(match[2]), Target: match[3], Line: number})
+	}
+	return links
+}
+
+// ************************************************************************************************
+// markdownAnchor returns the GitHub-style anchor of a heading: lower case, punctuation removed
+// and spaces replaced by hyphens, with a "-1", "-2"... suffix for repeated headings.
+func markdownAnchor(title string, anchors map[string]int) string {
+	var anchor strings.Builder
+	for _, char := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(char) || unicode.IsDigit(char) || char == '-' || char == '_':
+			anchor.WriteRune(char)
+		case char == ' ':
+			anchor.WriteByte('-')
+		}
+	}
+
+	base := anchor.String()
+	count := anchors[base]
+	anchors[base] = count + 1
+	if count > 0 {
+		return base + "-" + strconv.Itoa(count)
+	}
+	return base
+}
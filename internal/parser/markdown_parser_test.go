@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	src := "Intro with a [link](https://example.com).\n" + // 1
+		"\n" + // 2
+		"# Guide #\n" + // 3
+		"\n" + // 4
+		"See [install](#install) and `[not](a-link)`, ![logo](logo.png).\n" + // 5
+		"\n" + // 6
+		"Install\n" + // 7
+		"-------\n" + // 8
+		"\n" + // 9
+		"```bash\n" + // 10
+		"# not a heading\n" + // 11
+		"[not](a-link)\n" + // 12
+		"```\n" + // 13
+		"\n" + // 14
+		"### Options\n" + // 15
+		"~~~~ {.yaml}\n" + // 16
+		"key: value\n" + // 17
+		"~~~~\n" + // 18
+		"\n" + // 19
+		"# Guide\n" + // 20
+		"\n" + // 21
+		"[docs]: ./docs/README.md\n" + // 22
+		"#hashtag is text\n" // 23
+
+	expected := []types.MarkdownSection{
+		{Level: 0, Title: "", Anchor: "", Parent: -1, Start: 1, End: 2,
+			Links: []types.MarkdownLink{{Text: "link", Target: "https://example.com", Line: 1}}},
+		{Level: 1, Title: "Guide", Anchor: "guide", Parent: -1, Start: 3, End: 19,
+			Links: []types.MarkdownLink{{Text: "install", Target: "#install", Line: 5}}},
+		{Level: 2, Title: "Install", Anchor: "install", Parent: 1, Start: 7, End: 19,
+			Code: []types.MarkdownCodeBlock{{Language: "bash", Start: 10, End: 13}}},
+		{Level: 3, Title: "Options", Anchor: "options", Parent: 2, Start: 15, End: 19,
+			Code: []types.MarkdownCodeBlock{{Language: "yaml", Start: 16, End: 18}}},
+		{Level: 1, Title: "Guide", Anchor: "guide-1", Parent: -1, Start: 20, End: 23,
+			Links: []types.MarkdownLink{{Text: "docs", Target: "./docs/README.md", Line: 22}}},
+	}
+
+	sections := ParseMarkdown(src)
+	if len(sections) != len(expected) {
+		t.Fatalf("Expected %d sections, got %d: %+v", len(expected), len(sections), sections)
+	}
+	for index, want := range expected {
+		if got := sections[index]; !reflect.DeepEqual(got, want) {
+			t.Errorf("Section %d: expected %+v, got %+v", index, want, got)
+		}
+	}
+
+	// A document starting with a setext heading has no text before it
+	sections = ParseMarkdown("Title\r\n=====\r\nBody\r\n")
+	if len(sections) != 1 || sections[0].Title != "Title" || sections[0].Start != 1 || sections[0].End != 3 {
+		t.Errorf("Expected a single Title section, got %+v", sections)
+	}
+	if sections := ParseMarkdown("\n\n"); len(sections) != 0 {
+		t.Errorf("Expected no section for a blank document, got %+v", sections)
+	}
+}
@@ -0,0 +1,300 @@
+// ************************************************************************************************
+// Package parser provides the Protocol Buffers parser of the repomix-mcp application. The .proto
+// files of a repository are split into tokens, then their services with their rpcs, messages
+// with their fields, nested messages included, and enums with their values are extracted with
+// the comments written right before them. Protocol Buffers declare no private API, so every
+// declaration is public. Proto definitions are indexed on top of any indexing strategy, many APIs
+// being published only as proto.
+package parser
+
+import (
+	"strings"
+)
+
+// ************************************************************************************************
+// ProtobufLanguage describes the Protocol Buffers definitions indexed alongside every strategy.
+var ProtobufLanguage = SourceLanguage{
+	Name:           "protobuf",
+	DisplayName:    "Protocol Buffers",
+	Strategy:       "protobuf",
+	Extensions:     []string{".proto"},
+	SkippedDirs:    []string{"node_modules", "vendor", "third_party", "build", "dist"},
+	CommentPrefix:  "//",
+	ConstructTypes: []string{"service", "rpc", "message", "enum", "field"},
+	IsTestFile:     func(relPath string) bool { return false },
+	ParseFile:      ParseProtobufFile,
+}
+
+// ************************************************************************************************
+// ProtobufDefinitionsFile is the path of the indexed file holding the rendered proto definitions.
+const ProtobufDefinitionsFile = ".protobuf.xml"
+
+// ************************************************************************************************
+// NewProtobufParser creates the parser of the Protocol Buffers definitions of repositories.
+//
+// Example usage:
+//
+//	protoIndex, err := parser.NewProtobufParser().ParseRepository("billing", "/path/to/billing", config)
+func NewProtobufParser() *SourceParser {
+	return NewSourceParser(ProtobufLanguage)
+}
+
+// ************************************************************************************************
+// protobufParser extracts the declarations of the tokens of a file.
+type protobufParser struct {
+	tokenStream
+	analysis *SourceFile
+}
+
+// ************************************************************************************************
+// ParseProtobufFile extracts the package, services, messages and enums of a .proto file. Files
+// without a package declaration belong to the "default" package.
+//
+// Example usage:
+//
+//	analysis := parser.ParseProtobufFile("api/billing/v1/billing.proto", src)
+func ParseProtobufFile(relPath string, src []byte) *SourceFile {
+	tokens := scanProtobuf(string(src))
+	p := &protobufParser{
+		tokenStream: newTokenStream(tokens),
+		analysis:    &SourceFile{Path: relPath, Package: "default"},
+	}
+	p.parseDeclarations(0, len(tokens), "", "")
+	p.analysis.Module = p.analysis.Package
+	return p.analysis
+}
+
+// ************************************************************************************************
+// scanProtobuf splits proto source into tokens, comments left out. The comments written right
+// before a token, without a blank line between them, document it; comments following a token on
+// its line do not document the next one.
+func scanProtobuf(src string) []sourceToken {
+	var tokens []sourceToken
+	var doc []string
+	line := 1
+	newline, blank := false, true // blank: nothing written on the line yet
+	tokenLine := false            // Whether a token was written on the line
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		start, startLine := i, line
+		literal := false
+
+		switch {
+		case c == '\n':
+			if blank {
+				doc = nil // A blank line detaches the comments above it
+			}
+			line++
+			newline, blank, tokenLine = true, true, false
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			if !tokenLine {
+				doc = append(doc, strings.TrimPrefix(strings.TrimPrefix(src[i:i+end], "//"), " "))
+			}
+			i += end
+			blank = false
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i + 4
+			}
+			comment := src[i:end]
+			line += strings.Count(comment, "\n")
+			i = end
+			if !tokenLine {
+				doc = append(doc, protobufBlockCommentLines(comment)...)
+			}
+			blank = false
+			continue
+		case c == '"' || c == '\'':
+			i = skipScriptString(src, i)
+			literal = true
+		case isIdentifierStart(c) || c >= '0' && c <= '9':
+			i++
+			for i < len(src) && (isIdentifierStart(src[i]) || src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+		default:
+			i++
+		}
+
+		text := src[start:i]
+		tokens = append(tokens, sourceToken{text: text, literal: literal, start: start, end: i, line: startLine, newline: newline, doc: strings.TrimSpace(strings.Join(doc, "\n"))})
+		doc, newline, blank, tokenLine = nil, false, false, true
+	}
+	return tokens
+}
+
+// ************************************************************************************************
+// parseDeclarations extracts the declarations between two token indexes: the top level of a
+// file, or the body of a message, enum, oneof or service named owner.
+func (p *protobufParser) parseDeclarations(lo, hi int, owner, ownerKind string) {
+	for i := lo; i < hi; {
+		token := p.tokens[i]
+		if token.literal || p.is(i, ";") {
+			i++
+			continue
+		}
+
+		switch {
+		case p.is(i, "package") && owner == "":
+			end := p.endOfProtobufStatement(i+1, hi)
+			p.analysis.Package = p.join(i+1, end)
+			i = end + 1
+
+		case (p.is(i, "message") || p.is(i, "enum") || p.is(i, "service")) && p.isWord(i+1) && ownerKind != "service":
+			body := p.findProtobufBody(i+2, hi)
+			name := p.tokens[i+1].text
+			if owner != "" && ownerKind != "oneof" {
+				name = owner + "." + name
+			}
+			signature := p.join(i, i+2)
+			if token.text == "enum" && body < hi {
+				signature += " { " + strings.Join(p.enumValues(body+1, p.matchOr(body, hi)), ", ") + " }"
+			}
+			p.addConstruct(token.text, name, signature, i)
+			if body < hi && token.text != "enum" {
+				p.parseDeclarations(body+1, p.matchOr(body, hi), name, token.text)
+			}
+			i = p.skip(body)
+
+		case p.is(i, "rpc") && ownerKind == "service" && p.isWord(i+1):
+			end := p.endOfProtobufStatement(i+1, hi)
+			body := p.findProtobufBody(i+2, end)
+			p.addConstruct("rpc", owner+"."+p.tokens[i+1].text, p.join(i, min(body, end)), i)
+			i = end + 1
+
+		case p.is(i, "oneof") && p.isWord(i+1) && ownerKind == "message":
+			// The fields of a oneof are fields of the message
+			body := p.findProtobufBody(i+2, hi)
+			if body < hi {
+				p.parseDeclarations(body+1, p.matchOr(body, hi), owner, "oneof")
+			}
+			i = p.skip(body)
+
+		case ownerKind == "message" || ownerKind == "oneof":
+			// Fields end with their number and options; options, reserved ranges, extensions and
+			// extend blocks are skipped
+			end := p.endOfProtobufStatement(i, hi)
+			if name, ok := p.protobufFieldName(i, end); ok {
+				p.addConstruct("field", owner+"."+name, p.join(i, end), i)
+			}
+			i = end + 1
+
+		default:
+			// Syntax, edition, imports, options and extend blocks
+			end := p.endOfProtobufStatement(i, hi)
+			i = end + 1
+		}
+	}
+}
+
+// ************************************************************************************************
+// addConstruct records a declaration starting at a token index, documented by its comments.
+func (p *protobufParser) addConstruct(constructType, name, signature string, i int) {
+	doc := p.tokens[i].doc
+	p.analysis.Constructs = append(p.analysis.Constructs, SourceConstruct{
+		Type:      constructType,
+		Name:      name,
+		Signature: signature,
+		File:      p.analysis.Path,
+		Line:      p.tokens[i].line,
+		Summary:   docSummary(doc),
+		Doc:       doc,
+		Exported:  true,
+	})
+}
+
+// ************************************************************************************************
+// protobufFieldName returns the name of the field declared by a statement: the word before "=",
+// as in "repeated string tags = 3" or "map<string, int32> labels = 4".
+//
+// Returns:
+//   - string: The field name.
+//   - bool: Whether the statement declares a field.
+func (p *protobufParser) protobufFieldName(lo, hi int) (string, bool) {
+	switch p.tokens[lo].text {
+	case "option", "reserved", "extensions", "extend", "message", "enum", "oneof", "group":
+		return "", false
+	}
+	for i := lo + 1; i < hi; i++ {
+		if p.is(i, "=") && p.isWord(i-1) {
+			return p.tokens[i-1].text, true
+		}
+	}
+	return "", false
+}
+
+// ************************************************************************************************
+// enumValues returns the values of an enum body, "NAME = number", options left out.
+func (p *protobufParser) enumValues(lo, hi int) []string {
+	var values []string
+	for i := lo; i < hi; {
+		end := p.endOfProtobufStatement(i, hi)
+		if p.isWord(i) && p.is(i+1, "=") && !p.is(i, "option") && !p.is(i, "reserved") {
+			value := p.join(i, min(i+3, end))
+			if p.is(i+2, "-") {
+				value = p.join(i, min(i+4, end))
+			}
+			values = append(values, value)
+		}
+		i = end + 1
+	}
+	return values
+}
+
+// ************************************************************************************************
+// findProtobufBody returns the index of the opening brace of a declaration body, hi if the
+// statement ends first.
+func (p *protobufParser) findProtobufBody(i, hi int) int {
+	for ; i < hi; i = p.skip(i) {
+		if p.is(i, "{") {
+			return i
+		}
+		if p.is(i, ";") {
+			return hi
+		}
+	}
+	return hi
+}
+
+// ************************************************************************************************
+// endOfProtobufStatement returns the index of the ";" ending the statement starting at a token,
+// or of the closing brace of a block statement such as an extend block, hi if there is none.
+func (p *protobufParser) endOfProtobufStatement(i, hi int) int {
+	for ; i < hi; i = p.skip(i) {
+		if p.is(i, ";") {
+			return i
+		}
+		if p.is(i, "{") {
+			return p.matchOr(i, hi)
+		}
+	}
+	return hi
+}
+
+// ************************************************************************************************
+// protobufBlockCommentLines returns the text lines of a block comment, without the comment
+// markers and the leading "*" of its lines.
+func protobufBlockCommentLines(comment string) []string {
+	comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimLeft(line, "*"), " "))
+		lines = append(lines, line)
+	}
+	return strings.Split(strings.TrimSpace(strings.Join(lines, "\n")), "\n")
+}
@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+func TestParseProtobufFile(t *testing.T) {
+	src := `// Copyright Acme.
+
+syntax = "proto3";
+
+package acme.billing.v1;
+
+import "google/protobuf/timestamp.proto";
+
+option go_package = "github.com/acme/billing/v1;billing";
+
+// Bills customers.
+service BillingService {
+  option (acme.scope) = "billing";
+
+  // Creates an invoice.
+  rpc CreateInvoice(CreateInvoiceRequest) returns (Invoice);
+  rpc WatchInvoices(stream WatchRequest) returns (stream Invoice) {
+    option (google.api.http) = { get: "/v1/invoices" };
+  }
+}
+
+/**
+ * An invoice sent to a customer.
+ */
+message Invoice {
+  string id = 1; // Not the doc of the next field
+  repeated string tags = 2 [deprecated = true];
+  map<string, int64> amounts = 3;
+  reserved 4, 5;
+
+  // Lifecycle of an invoice.
+  enum Status {
+    option allow_alias = true;
+    STATUS_UNKNOWN = 0;
+    STATUS_PAID = 1 [(acme.label) = "paid"];
+  }
+
+  message Line {
+    string sku = 1;
+  }
+
+  oneof payer {
+    // Customer paying.
+    string customer_id = 6;
+    Line line = 7;
+  }
+  google.protobuf.Timestamp created_at = 8;
+}
+
+extend google.protobuf.FieldOptions {
+  string label = 50000;
+}
+`
+	analysis := ParseProtobufFile("proto/acme/billing/v1/billing.proto", []byte(src))
+
+	if analysis.Module != "acme.billing.v1" || analysis.Package != "acme.billing.v1" {
+		t.Errorf("Expected module acme.billing.v1 in package acme.billing.v1, got %s in %s", analysis.Module, analysis.Package)
+	}
+
+	expected := []SourceConstruct{
+		{Type: "service", Name: "BillingService", Signature: "service BillingService", Line: 12, Summary: "Bills customers."},
+		{Type: "rpc", Name: "BillingService.CreateInvoice", Signature: "rpc CreateInvoice(CreateInvoiceRequest) returns (Invoice)", Line: 16, Summary: "Creates an invoice."},
+		{Type: "rpc", Name: "BillingService.WatchInvoices", Signature: "rpc WatchInvoices(stream WatchRequest) returns (stream Invoice)", Line: 17},
+		{Type: "message", Name: "Invoice", Signature: "message Invoice", Line: 25, Summary: "An invoice sent to a customer."},
+		{Type: "field", Name: "Invoice.id", Signature: "string id = 1", Line: 26},
+		{Type: "field", Name: "Invoice.tags", Signature: "repeated string tags = 2 [deprecated = true]", Line: 27},
+		{Type: "field", Name: "Invoice.amounts", Signature: "map<string, int64> amounts = 3", Line: 28},
+		{Type: "enum", Name: "Invoice.Status", Signature: "enum Status { STATUS_UNKNOWN = 0, STATUS_PAID = 1 }", Line: 32, Summary: "Lifecycle of an invoice."},
+		{Type: "message", Name: "Invoice.Line", Signature: "message Line", Line: 38},
+		{Type: "field", Name: "Invoice.Line.sku", Signature: "string sku = 1", Line: 39},
+		{Type: "field", Name: "Invoice.customer_id", Signature: "string customer_id = 6", Line: 44, Summary: "Customer paying."},
+		{Type: "field", Name: "Invoice.line", Signature: "Line line = 7", Line: 45},
+		{Type: "field", Name: "Invoice.created_at", Signature: "google.protobuf.Timestamp created_at = 8", Line: 47},
+	}
+	if len(analysis.Constructs) != len(expected) {
+		for _, construct := range analysis.Constructs {
+			t.Logf("%s %s at line %d: %s", construct.Type, construct.Name, construct.Line, construct.Signature)
+		}
+		t.Fatalf("Expected %d constructs, got %d", len(expected), len(analysis.Constructs))
+	}
+	for i, want := range expected {
+		got := analysis.Constructs[i]
+		if got.Type != want.Type || got.Name != want.Name || got.Signature != want.Signature ||
+			got.Line != want.Line || !got.Exported || got.Summary != want.Summary {
+			t.Errorf("Construct %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	if analysis := ParseProtobufFile("empty.proto", []byte(`syntax = "proto2";`)); analysis.Package != "default" || len(analysis.Constructs) != 0 {
+		t.Errorf("Expected no constructs in the default package, got %+v", analysis)
+	}
+}
+
+func TestProtobufParser_ParseRepository(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                    "module example.com/billing\n",
+		"proto/billing.proto":       "syntax = \"proto3\";\npackage billing;\n\n// Bills customers.\nservice Billing {\n  rpc Pay(PayRequest) returns (PayResponse);\n}\n\nmessage PayRequest {\n  string id = 1;\n}\n",
+		"vendor/dep/dep.proto":      "package dep;\nmessage Vendored {}\n",
+		"third_party/api/api.proto": "package api;\nmessage ThirdParty {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	repoIndex, err := NewProtobufParser().ParseRepository("billing", tempDir, types.IndexingConfig{})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+	if repoIndex.Metadata["file_count"] != 1 {
+		t.Errorf("Expected file_count 1, got '%v'", repoIndex.Metadata["file_count"])
+	}
+
+	xmlFile := repoIndex.Files[".repomix.xml"]
+	for _, pattern := range []string{
+		`<file path="proto/billing.proto" module="billing">`,
+		"service Billing  // proto/billing.proto:5",
+		"rpc Pay(PayRequest) returns (PayResponse)",
+		"string id = 1",
+	} {
+		if !strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content to contain '%s'", pattern)
+		}
+	}
+	for _, pattern := range []string{"Vendored", "ThirdParty"} {
+		if strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content not to contain '%s'", pattern)
+		}
+	}
+
+	symbols, _ := repoIndex.Metadata[types.SymbolsMetadataKey].([]types.Symbol)
+	names := make([]string, len(symbols))
+	for index, symbol := range symbols {
+		names[index] = symbol.QualifiedName()
+	}
+	if got := strings.Join(names, ","); got != "billing.Billing,billing.Billing.Pay,billing.PayRequest,billing.PayRequest.id" {
+		t.Errorf("Unexpected symbols %s", got)
+	}
+
+	if _, err := NewProtobufParser().ParseRepository("empty", t.TempDir(), types.IndexingConfig{}); err == nil {
+		t.Error("Expected an error for a repository without .proto files")
+	}
+}
@@ -0,0 +1,413 @@
+// ************************************************************************************************
+// Package parser provides the Python source parser of the repomix-mcp application.
+// Python files are scanned line by line without running Python: strings, comments, brackets
+// and line continuations are tracked to split the source into logical lines, whose indentation
+// gives the nesting of classes and functions. Module docstrings, classes, functions and methods
+// are extracted with their signatures, decorators and docstrings.
+package parser
+
+import (
+	"path"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// PythonLanguage describes the Python sources indexed by the python_native strategy.
+var PythonLanguage = SourceLanguage{
+	Name:           "python",
+	Strategy:       types.IndexingStrategyPythonNative,
+	Extensions:     []string{".py", ".pyi"},
+	SkippedDirs:    []string{"__pycache__", "venv", "env", "site-packages", "build", "dist", "node_modules", "tests", "test"},
+	CommentPrefix:  "#",
+	ConstructTypes: []string{"class", "function", "method"},
+	IsTestFile:     isPythonTestFile,
+	ParseFile:      ParsePythonFile,
+}
+
+// ************************************************************************************************
+// PythonProjectFiles are the files at the root of a repository marking a Python project.
+var PythonProjectFiles = []string{"pyproject.toml", "setup.py"}
+
+// ************************************************************************************************
+// NewPythonParser creates the parser of Python repositories.
+//
+// Example usage:
+//
+//	repoIndex, err := parser.NewPythonParser().ParseRepository("app", "/path/to/app", config)
+func NewPythonParser() *SourceParser {
+	return NewSourceParser(PythonLanguage)
+}
+
+// ************************************************************************************************
+// pythonLine is a logical line of Python source: physical lines joined by open brackets,
+// backslash continuations or multi-line strings, comments removed.
+type pythonLine struct {
+	number int    // Line number of the first physical line
+	indent int    // Indentation width of the first physical line, tabs to multiples of 8
+	text   string // Content without indentation and comments
+}
+
+// ************************************************************************************************
+// pythonScope is a class or function enclosing the lines being scanned.
+type pythonScope struct {
+	indent   int
+	kind     string // "class" or "def"
+	name     string // Name within the module, "Outer.Inner" for nested classes
+	exported bool
+}
+
+// ************************************************************************************************
+// ParsePythonFile extracts the module docstring, classes, functions and methods of a Python
+// file. Functions nested in functions are left out. Names starting with an underscore are
+// private, except special "__name__" methods; members of private classes are private.
+//
+// Example usage:
+//
+//	analysis := parser.ParsePythonFile("app/models.py", src)
+func ParsePythonFile(relPath string, src []byte) *SourceFile {
+	module := pythonModuleName(relPath)
+	analysis := &SourceFile{Path: relPath, Module: module, Package: pythonPackageName(relPath, module)}
+
+	var scopes []pythonScope
+	var decorators []string
+	docTarget := -1 // Construct whose docstring may follow, -1 for none
+	docIndent := -1 // Indentation the docstring must exceed
+	first := true
+
+	for _, line := range splitPythonLines(string(src)) {
+		if line.text == "" {
+			continue
+		}
+
+		// The first statement of the module or of a body may be its docstring
+		if doc, ok := pythonDocstring(line.text); ok {
+			if first {
+				analysis.Doc = doc
+			} else if docTarget >= 0 && line.indent > docIndent {
+				analysis.Constructs[docTarget].Doc = doc
+				analysis.Constructs[docTarget].Summary = docSummary(doc)
+			}
+		}
+		first = false
+		docTarget = -1
+
+		for len(scopes) > 0 && scopes[len(scopes)-1].indent >= line.indent {
+			scopes = scopes[:len(scopes)-1]
+		}
+
+		if strings.HasPrefix(line.text, "@") {
+			decorators = append(decorators, strings.Join(strings.Fields(line.text), " "))
+			continue
+		}
+
+		kind, name, signature := parsePythonDefinition(line.text)
+		if kind == "" {
+			decorators = nil
+			continue
+		}
+
+		var parent *pythonScope
+		if len(scopes) > 0 {
+			parent = &scopes[len(scopes)-1]
+		}
+		scope := pythonScope{indent: line.indent, kind: kind, name: name, exported: isPythonPublicName(name)}
+		if parent != nil {
+			scope.name = parent.name + "." + name
+			scope.exported = scope.exported && parent.exported
+		}
+		scopes = append(scopes, scope)
+
+		// Functions nested in functions are implementation details
+		if parent != nil && parent.kind == "def" {
+			decorators = nil
+			continue
+		}
+
+		constructType := "class"
+		if kind == "def" {
+			constructType = "function"
+			if parent != nil {
+				constructType = "method"
+			}
+		}
+		analysis.Constructs = append(analysis.Constructs, SourceConstruct{
+			Type:        constructType,
+			Name:        scope.name,
+			Signature:   signature,
+			Annotations: decorators,
+			File:        relPath,
+			Line:        line.number,
+			Exported:    scope.exported,
+		})
+		decorators = nil
+		docTarget = len(analysis.Constructs) - 1
+		docIndent = line.indent
+	}
+
+	return analysis
+}
+
+// ************************************************************************************************
+// splitPythonLines splits Python source into logical lines. Lines inside brackets, after a
+// backslash or inside triple-quoted strings continue the current logical line.
+func splitPythonLines(src string) []pythonLine {
+	var lines []pythonLine
+	var text strings.Builder
+	number, depth := 1, 0
+	start, indent := 1, 0
+	atLineStart := true
+	quote := "" // Delimiter of the string being scanned
+
+	flush := func() {
+		lines = append(lines, pythonLine{number: start, indent: indent, text: strings.TrimSpace(text.String())})
+		text.Reset()
+		atLineStart = true
+	}
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+
+		// Measure the indentation of a new logical line
+		if atLineStart {
+			start, indent = number, 0
+			for i < len(src) && (src[i] == ' ' || src[i] == '\t' || src[i] == '\f') {
+				if src[i] == '\t' {
+					indent = indent/8*8 + 8
+				} else if src[i] == ' ' {
+					indent++
+				}
+				i++
+			}
+			atLineStart = false
+			if i == len(src) {
+				break
+			}
+			c = src[i]
+		}
+
+		if quote != "" {
+			text.WriteByte(c)
+			switch {
+			case c == '\\' && i+1 < len(src):
+				i++
+				text.WriteByte(src[i])
+				if src[i] == '\n' {
+					number++
+				}
+			case c == '\n':
+				number++
+				if len(quote) == 1 {
+					quote = "" // Unterminated string, recover at the end of the line
+					if depth == 0 {
+						flush()
+					}
+				}
+			case strings.HasPrefix(src[i:], quote):
+				text.WriteString(quote[1:])
+				i += len(quote) - 1
+				quote = ""
+			}
+			continue
+		}
+
+		switch c {
+		case '#':
+			for i+1 < len(src) && src[i+1] != '\n' {
+				i++
+			}
+		case '"', '\'':
+			quote = string(c)
+			if strings.HasPrefix(src[i:], strings.Repeat(string(c), 3)) {
+				quote = strings.Repeat(string(c), 3)
+				i += 2
+			}
+			text.WriteString(quote)
+		case '(', '[', '{':
+			depth++
+			text.WriteByte(c)
+		case ')', ']', '}':
+			if depth > 0 {
+				depth--
+			}
+			text.WriteByte(c)
+		case '\\':
+			if i+1 < len(src) && src[i+1] == '\n' {
+				i++
+				number++
+				text.WriteByte(' ')
+			} else {
+				text.WriteByte(c)
+			}
+		case '\r':
+		case '\n':
+			number++
+			if depth > 0 {
+				text.WriteByte(' ')
+			} else {
+				flush()
+			}
+		default:
+			text.WriteByte(c)
+		}
+	}
+	if text.Len() > 0 {
+		flush()
+	}
+	return lines
+}
+
+// ************************************************************************************************
+// parsePythonDefinition parses the header of a class or function definition.
+//
+// Returns:
+//   - string: "class", "def", or empty if the line is not a definition.
+//   - string: The name of the class or function.
+//   - string: The header without the trailing colon and body, on a single line.
+func parsePythonDefinition(text string) (string, string, string) {
+	header := text
+	prefix := ""
+	if rest, ok := strings.CutPrefix(header, "async "); ok {
+		prefix = "async "
+		header = strings.TrimSpace(rest)
+	}
+
+	kind := ""
+	switch {
+	case strings.HasPrefix(header, "def ") || strings.HasPrefix(header, "def\t"):
+		kind = "def"
+	case prefix == "" && (strings.HasPrefix(header, "class ") || strings.HasPrefix(header, "class\t")):
+		kind = "class"
+	default:
+		return "", "", ""
+	}
+
+	rest := strings.TrimSpace(header[len(kind):])
+	end := 0
+	for end < len(rest) && (rest[end] == '_' || rest[end] >= '0' && rest[end] <= '9' || rest[end] >= 'a' && rest[end] <= 'z' || rest[end] >= 'A' && rest[end] <= 'Z' || rest[end] >= 0x80) {
+		end++
+	}
+	name := rest[:end]
+	if name == "" {
+		return "", "", ""
+	}
+
+	// The header ends at the first colon outside brackets and strings
+	depth, quote := 0, byte(0)
+	colon := -1
+	for i := end; i < len(rest) && colon < 0; i++ {
+		c := rest[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == ':' && depth == 0:
+			colon = i
+		}
+	}
+	if colon < 0 {
+		return "", "", ""
+	}
+
+	signature := strings.Join(strings.Fields(prefix+kind+" "+rest[:colon]), " ")
+	signature = strings.ReplaceAll(strings.ReplaceAll(signature, "( ", "("), " )", ")")
+	signature = strings.ReplaceAll(strings.ReplaceAll(signature, "[ ", "["), " ]", "]")
+	return kind, name, signature
+}
+
+// ************************************************************************************************
+// pythonDocstring returns the text of a logical line made of a single string literal, cleaned
+// like inspect.cleandoc: leading and trailing blank lines removed, continuation lines dedented.
+//
+// Returns:
+//   - string: The docstring text.
+//   - bool: Whether the line is a string literal.
+func pythonDocstring(text string) (string, bool) {
+	literal := strings.TrimLeft(text, "rRuU")
+	if len(text)-len(literal) > 1 || literal == "" || (literal[0] != '"' && literal[0] != '\'') {
+		return "", false
+	}
+
+	quote := literal[:1]
+	if strings.HasPrefix(literal, strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+	if len(literal) < 2*len(quote) || !strings.HasSuffix(literal, quote) {
+		return "", false
+	}
+	body := literal[len(quote) : len(literal)-len(quote)]
+	if len(quote) == 1 && strings.Contains(body, quote) && !strings.Contains(body, "\\"+quote) {
+		return "", false // Several string literals, such as an implicit concatenation
+	}
+
+	lines := strings.Split(strings.ReplaceAll(body, "\t", "        "), "\n")
+	margin := -1
+	for _, line := range lines[1:] {
+		if trimmed := strings.TrimLeft(line, " "); trimmed != "" {
+			if indent := len(line) - len(trimmed); margin < 0 || indent < margin {
+				margin = indent
+			}
+		}
+	}
+	lines[0] = strings.TrimSpace(lines[0])
+	for i := 1; i < len(lines); i++ {
+		if len(lines[i]) >= margin && margin > 0 {
+			lines[i] = lines[i][margin:]
+		}
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n"), true
+}
+
+// ************************************************************************************************
+// isPythonPublicName reports whether a name is public: not starting with an underscore, or a
+// special "__name__" method.
+func isPythonPublicName(name string) bool {
+	if !strings.HasPrefix(name, "_") {
+		return true
+	}
+	return len(name) > 4 && strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__")
+}
+
+// ************************************************************************************************
+// isPythonTestFile reports whether a file holds pytest or unittest tests.
+func isPythonTestFile(relPath string) bool {
+	name := path.Base(relPath)
+	return strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test.py") || name == "conftest.py"
+}
+
+// ************************************************************************************************
+// pythonModuleName returns the dotted module name of a file: "src/app/models.py" is
+// "app.models" and "app/__init__.py" is "app".
+func pythonModuleName(relPath string) string {
+	module := strings.TrimSuffix(strings.TrimSuffix(relPath, ".pyi"), ".py")
+	module = strings.TrimPrefix(module, "src/")
+	module = strings.TrimSuffix(strings.TrimSuffix(module, "__init__"), "/")
+	if module == "" {
+		return path.Base(path.Dir("/" + relPath))
+	}
+	return strings.ReplaceAll(module, "/", ".")
+}
+
+// ************************************************************************************************
+// pythonPackageName returns the package a module belongs to: the package itself for an
+// __init__.py file, the enclosing package otherwise, and the module for top-level modules.
+func pythonPackageName(relPath, module string) string {
+	if strings.HasPrefix(path.Base(relPath), "__init__.") {
+		return module
+	}
+	if index := strings.LastIndex(module, "."); index >= 0 {
+		return module[:index]
+	}
+	return module
+}
@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+func TestParsePythonFile(t *testing.T) {
+	src := `#!/usr/bin/env python
+"""Models of the application.
+
+Users and their accounts.
+"""
+
+import os
+
+DEFAULT = "def not_a_function():"
+
+
+@dataclass(frozen=True)
+class User(Base,
+           metaclass=Meta):
+    """A registered user."""
+
+    name: str
+
+    def __init__(self, name: str) -> None:
+        self.name = name
+
+    @property
+    def display_name(self) -> str:
+        '''Returns the name shown
+           to other users.'''
+        def helper():
+            pass
+        return helper()
+
+    def _secret(self):
+        return "x"
+
+    class Settings:
+        pass
+
+
+async def fetch(url,
+                timeout: float = 1.0,  # seconds
+                ) -> bytes:
+    text = """
+def inside_string():
+    pass
+"""
+    return b""
+
+
+def _private(a, \
+             b):
+    pass
+
+
+class _Hidden:
+    def visible(self):
+        pass
+`
+	analysis := ParsePythonFile("src/app/models.py", []byte(src))
+
+	if analysis.Module != "app.models" || analysis.Package != "app" {
+		t.Errorf("Expected module app.models in package app, got %s in %s", analysis.Module, analysis.Package)
+	}
+	if analysis.Doc != "Models of the application.\n\nUsers and their accounts." {
+		t.Errorf("Unexpected module docstring %q", analysis.Doc)
+	}
+
+	expected := []SourceConstruct{
+		{Type: "class", Name: "User", Signature: "class User(Base, metaclass=Meta)", Line: 13, Exported: true, Summary: "A registered user."},
+		{Type: "method", Name: "User.__init__", Signature: "def __init__(self, name: str) -> None", Line: 19, Exported: true},
+		{Type: "method", Name: "User.display_name", Signature: "def display_name(self) -> str", Line: 23, Exported: true, Summary: "Returns the name shown to other users."},
+		{Type: "method", Name: "User._secret", Signature: "def _secret(self)", Line: 30, Exported: false},
+		{Type: "class", Name: "User.Settings", Signature: "class Settings", Line: 33, Exported: true},
+		{Type: "function", Name: "fetch", Signature: "async def fetch(url, timeout: float = 1.0,) -> bytes", Line: 37, Exported: true},
+		{Type: "function", Name: "_private", Signature: "def _private(a, b)", Line: 47, Exported: false},
+		{Type: "class", Name: "_Hidden", Signature: "class _Hidden", Line: 52, Exported: false},
+		{Type: "method", Name: "_Hidden.visible", Signature: "def visible(self)", Line: 53, Exported: false},
+	}
+	if len(analysis.Constructs) != len(expected) {
+		for _, construct := range analysis.Constructs {
+			t.Logf("%s %s at line %d", construct.Type, construct.Name, construct.Line)
+		}
+		t.Fatalf("Expected %d constructs, got %d", len(expected), len(analysis.Constructs))
+	}
+	for i, want := range expected {
+		got := analysis.Constructs[i]
+		if got.Type != want.Type || got.Name != want.Name || got.Signature != want.Signature ||
+			got.Line != want.Line || got.Exported != want.Exported || got.Summary != want.Summary {
+			t.Errorf("Construct %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	if annotations := analysis.Constructs[0].Annotations; len(annotations) != 1 || annotations[0] != "@dataclass(frozen=True)" {
+		t.Errorf("Expected the dataclass decorator on User, got %v", annotations)
+	}
+	if annotations := analysis.Constructs[2].Annotations; len(annotations) != 1 || annotations[0] != "@property" {
+		t.Errorf("Expected the property decorator on display_name, got %v", annotations)
+	}
+	if analysis.Constructs[1].Annotations != nil {
+		t.Errorf("Expected no decorator on __init__, got %v", analysis.Constructs[1].Annotations)
+	}
+}
+
+func TestPythonParser_ParseRepository(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"pyproject.toml":        "[project]\nname = \"app\"\n",
+		"app/__init__.py":       "\"\"\"The application package.\"\"\"\n",
+		"app/service.py":        "class Service:\n    \"\"\"Serves requests.\"\"\"\n\n    def handle(self, request):\n        # TODO: validate the request\n        pass\n\n    def _log(self):\n        pass\n",
+		"app/__pycache__/x.py":  "def cached():\n    pass\n",
+		"tests/test_service.py": "def test_handle():\n    pass\n",
+		"app/test_utils.py":     "def test_helper():\n    pass\n",
+		".venv/lib/site.py":     "def vendored():\n    pass\n",
+		"scripts/run.py":        "def main():\n    pass\n",
+		"app/service_extra.txt": "not python\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	repoIndex, err := NewPythonParser().ParseRepository("app", tempDir, types.IndexingConfig{})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+
+	if repoIndex.Metadata["indexer_type"] != "python_native" {
+		t.Errorf("Expected indexer_type 'python_native', got '%v'", repoIndex.Metadata["indexer_type"])
+	}
+	if repoIndex.Metadata["file_count"] != 3 {
+		t.Errorf("Expected file_count 3, got '%v'", repoIndex.Metadata["file_count"])
+	}
+
+	xmlFile, exists := repoIndex.Files[".repomix.xml"]
+	if !exists {
+		t.Fatal("Expected .repomix.xml file to be generated")
+	}
+	for _, pattern := range []string{
+		`<file path="app/service.py" module="app.service">`,
+		`<package name="app">`,
+		"class Service",
+		"def handle(self, request)",
+		"The application package.",
+		"def main()",
+	} {
+		if !strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content to contain '%s'", pattern)
+		}
+	}
+	for _, pattern := range []string{"def cached", "def test_handle", "def test_helper", "def vendored"} {
+		if strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content not to contain '%s'", pattern)
+		}
+	}
+	if strings.Contains(xmlFile.Content, "def _log(self)") {
+		t.Error("Expected private methods to be left out by default")
+	}
+}
@@ -0,0 +1,225 @@
+// ************************************************************************************************
+// Package parser provides the reference index for the repomix-mcp application.
+// Call expressions are resolved without type checking: calls of package functions, of imported
+// package functions and of methods on variables whose type is written in their declaration are
+// linked to the functions and methods declared in the repository. Calls through struct fields,
+// interfaces and function values are not resolved.
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// referenceIndex collects the declared functions and the call sites of a repository. Calls are
+// resolved to candidate names as files are added and kept once every declaration is known.
+type referenceIndex struct {
+	declarations map[string]*types.SymbolReferences
+	calls        map[string][]types.Reference // Call sites by candidate qualified name
+}
+
+// ************************************************************************************************
+// newReferenceIndex creates an empty reference index.
+func newReferenceIndex() *referenceIndex {
+	return &referenceIndex{
+		declarations: make(map[string]*types.SymbolReferences),
+		calls:        make(map[string][]types.Reference),
+	}
+}
+
+// ************************************************************************************************
+// addFile records the functions declared by a parsed file belonging to the package with the
+// given import path, and the calls made in their bodies.
+func (x *referenceIndex) addFile(fileSet *token.FileSet, file *ast.File, filePath, importPath string) {
+	qualifier := typeQualifier{importPath: importPath, imports: fileImports(file)}
+
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		name := importPath + "." + funcDecl.Name.Name
+		if funcDecl.Recv != nil && len(funcDecl.Recv.List) > 0 {
+			receiver, _ := receiverTypeName(funcDecl.Recv.List[0].Type)
+			if receiver == "" {
+				continue
+			}
+			name = importPath + "." + receiver + "." + funcDecl.Name.Name
+		}
+		x.declarations[name] = &types.SymbolReferences{
+			Symbol: name,
+			File:   filePath,
+			Line:   fileSet.Position(funcDecl.Pos()).Line,
+		}
+
+		if funcDecl.Body == nil {
+			continue
+		}
+		ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if target := qualifier.callTarget(call.Fun); target != "" {
+				position := fileSet.Position(call.Pos())
+				x.calls[target] = append(x.calls[target], types.Reference{
+					File:   filePath,
+					Line:   position.Line,
+					Column: position.Column,
+					Caller: name,
+				})
+			}
+			return true
+		})
+	}
+}
+
+// ************************************************************************************************
+// resolve links every declared function and method to its call sites.
+//
+// Returns:
+//   - []types.SymbolReferences: The declared symbols, sorted by name, with their call sites.
+func (x *referenceIndex) resolve() []types.SymbolReferences {
+	index := make([]types.SymbolReferences, 0, len(x.declarations))
+	for name, declaration := range x.declarations {
+		entry := *declaration
+		entry.References = append([]types.Reference(nil), x.calls[name]...)
+		sort.Slice(entry.References, func(i, j int) bool {
+			a, b := entry.References[i], entry.References[j]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return a.Column < b.Column
+		})
+		index = append(index, entry)
+	}
+
+	sort.Slice(index, func(i, j int) bool {
+		return index[i].Symbol < index[j].Symbol
+	})
+	return index
+}
+
+// ************************************************************************************************
+// callTarget returns the qualified name of the function or method a call expression may call,
+// empty when it cannot be resolved from the syntax alone.
+func (q typeQualifier) callTarget(fun ast.Expr) string {
+	switch f := fun.(type) {
+	case *ast.ParenExpr:
+		return q.callTarget(f.X)
+	case *ast.IndexExpr:
+		return q.callTarget(f.X) // Explicit type arguments
+	case *ast.IndexListExpr:
+		return q.callTarget(f.X)
+	case *ast.Ident:
+		// Objects of the same file are resolved by the parser, functions of other files are not
+		if f.Obj != nil && f.Obj.Kind != ast.Fun {
+			return ""
+		}
+		return q.importPath + "." + f.Name
+	case *ast.SelectorExpr:
+		receiver, ok := f.X.(*ast.Ident)
+		if !ok {
+			return ""
+		}
+		if receiver.Obj == nil {
+			if importPath, exists := q.imports[receiver.Name]; exists {
+				return importPath + "." + f.Sel.Name
+			}
+			return ""
+		}
+		switch receiver.Obj.Kind {
+		case ast.Typ:
+			return q.importPath + "." + receiver.Name + "." + f.Sel.Name // Method expression
+		case ast.Var:
+			if typeName := q.namedType(variableType(receiver)); typeName != "" {
+				return typeName + "." + f.Sel.Name
+			}
+		}
+	}
+	return ""
+}
+
+// ************************************************************************************************
+// namedType renders the qualified name of a possibly pointer or generic named type, empty for
+// any other type expression.
+func (q typeQualifier) namedType(expr ast.Expr) string {
+	if expr == nil {
+		return ""
+	}
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr = t.X
+	case *ast.IndexListExpr:
+		expr = t.X
+	}
+	switch expr.(type) {
+	case *ast.Ident, *ast.SelectorExpr:
+		name := q.qualify(expr)
+		if !strings.Contains(name, ".") {
+			return "" // Predeclared type
+		}
+		return name
+	}
+	return ""
+}
+
+// ************************************************************************************************
+// variableType returns the type expression a variable is declared with: the type of a
+// parameter, receiver or var declaration, or the type of a composite literal, &T{} or new(T)
+// it is assigned. Returns nil when the type is not written in the declaration.
+func variableType(ident *ast.Ident) ast.Expr {
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.Field:
+		return decl.Type
+	case *ast.ValueSpec:
+		if decl.Type != nil {
+			return decl.Type
+		}
+		for index, name := range decl.Names {
+			if name.Name == ident.Name && index < len(decl.Values) {
+				return literalType(decl.Values[index])
+			}
+		}
+	case *ast.AssignStmt:
+		if len(decl.Lhs) != len(decl.Rhs) {
+			return nil
+		}
+		for index, lhs := range decl.Lhs {
+			if name, ok := lhs.(*ast.Ident); ok && name.Name == ident.Name {
+				return literalType(decl.Rhs[index])
+			}
+		}
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// literalType returns the type of a composite literal, &T{} or new(T) expression, nil for any
+// other expression.
+func literalType(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.CompositeLit:
+		return e.Type
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return literalType(e.X)
+		}
+	case *ast.CallExpr:
+		if fun, ok := e.Fun.(*ast.Ident); ok && fun.Name == "new" && fun.Obj == nil && len(e.Args) == 1 {
+			return e.Args[0]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,644 @@
+// ************************************************************************************************
+// Package parser provides the Rust source parser of the repomix-mcp application. Sources are
+// split into tokens, then the items of every module are scanned: functions, structs, enums,
+// unions, traits with their items, impl blocks with their methods, type aliases, constants,
+// statics and exported macros. Inline modules are walked; test modules and test functions are
+// left out. An item is exported when it is declared pub, without restriction, in an exported
+// scope.
+package parser
+
+import (
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// RustLanguage describes the Rust sources indexed by the rust_native strategy.
+var RustLanguage = SourceLanguage{
+	Name:           "rust",
+	Strategy:       types.IndexingStrategyRustNative,
+	Extensions:     []string{".rs"},
+	SkippedDirs:    []string{"target", "tests", "benches", "examples"},
+	CommentPrefix:  "//",
+	ConstructTypes: []string{"module", "trait", "struct", "enum", "type", "const", "macro", "function", "impl", "method"},
+	IsTestFile:     isRustTestFile,
+	ParseFile:      ParseRustFile,
+}
+
+// ************************************************************************************************
+// RustProjectFiles are the files at the root of a repository marking a Rust crate or workspace.
+var RustProjectFiles = []string{"Cargo.toml"}
+
+// ************************************************************************************************
+// NewRustParser creates the parser of Rust repositories.
+//
+// Example usage:
+//
+//	repoIndex, err := parser.NewRustParser().ParseRepository("engine", "/path/to/engine", config)
+func NewRustParser() *SourceParser {
+	return NewSourceParser(RustLanguage)
+}
+
+// ************************************************************************************************
+// rustScope is the module, trait or impl block whose items are being scanned.
+type rustScope struct {
+	prefix    string // Prefix of the item names, e.g. "Point::" in an impl block
+	exported  bool   // Whether the scope is exported
+	container string // "module", "trait", "impl" or "trait impl"
+}
+
+// ************************************************************************************************
+// rustParser extracts the items of the tokens of a file.
+type rustParser struct {
+	tokenStream
+	analysis *SourceFile
+}
+
+// ************************************************************************************************
+// ParseRustFile extracts the items of a Rust file.
+//
+// Example usage:
+//
+//	analysis := parser.ParseRustFile("src/geometry/point.rs", src)
+func ParseRustFile(relPath string, src []byte) *SourceFile {
+	crate, module := rustModulePath(relPath)
+	tokens, fileDoc := scanRust(string(src))
+	p := &rustParser{
+		tokenStream: newTokenStream(tokens),
+		analysis:    &SourceFile{Path: relPath, Module: module, Package: crate, Doc: fileDoc},
+	}
+	p.parseItems(0, len(tokens), rustScope{exported: true, container: "module"})
+	return p.analysis
+}
+
+// ************************************************************************************************
+// scanRust splits Rust source into tokens, comments left out. Outer documentation comments,
+// "///" and "/** */", are attached to the next token.
+//
+// Returns:
+//   - []sourceToken: The tokens.
+//   - string: The module documentation, from the "//!" and "/*! */" comments before any token.
+func scanRust(src string) ([]sourceToken, string) {
+	var tokens []sourceToken
+	var docLines, fileDocLines []string
+	line := 1
+	newline := false
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		start, startLine := i, line
+		literal := false
+
+		switch {
+		case c == '\n':
+			line++
+			newline = true
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			comment := src[i : i+end]
+			switch {
+			case strings.HasPrefix(comment, "///") && !strings.HasPrefix(comment, "////"):
+				docLines = append(docLines, strings.TrimPrefix(strings.TrimPrefix(comment, "///"), " "))
+			case strings.HasPrefix(comment, "//!") && len(tokens) == 0:
+				fileDocLines = append(fileDocLines, strings.TrimPrefix(strings.TrimPrefix(comment, "//!"), " "))
+			}
+			i += end
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			// Block comments nest in Rust
+			depth := 0
+			for i < len(src) {
+				if strings.HasPrefix(src[i:], "/*") {
+					depth++
+					i += 2
+				} else if strings.HasPrefix(src[i:], "*/") {
+					depth--
+					i += 2
+					if depth == 0 {
+						break
+					}
+				} else {
+					i++
+				}
+			}
+			comment := src[start:i]
+			line += strings.Count(comment, "\n")
+			switch {
+			case strings.HasPrefix(comment, "/**") && !strings.HasPrefix(comment, "/***") && comment != "/**/":
+				docLines = append(docLines, rustBlockDocLines(comment)...)
+			case strings.HasPrefix(comment, "/*!") && len(tokens) == 0:
+				fileDocLines = append(fileDocLines, rustBlockDocLines(comment)...)
+			}
+			continue
+		case c == '"':
+			i = skipRustString(src, i+1)
+			literal = true
+		case c == '\'':
+			i = skipRustCharOrLifetime(src, i)
+			literal = src[i-1] == '\'' && i-start > 2
+		case (c == 'r' || c == 'b' || c == 'c') && isRustStringPrefix(src[i:]):
+			i = skipRustPrefixedString(src, i)
+			literal = true
+		case isIdentifierStart(c) || c >= '0' && c <= '9':
+			i++
+			for i < len(src) && (isIdentifierStart(src[i]) || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			if c >= '0' && c <= '9' && i+1 < len(src) && src[i] == '.' && src[i+1] >= '0' && src[i+1] <= '9' {
+				for i++; i < len(src) && (isIdentifierStart(src[i]) || src[i] >= '0' && src[i] <= '9'); i++ {
+				}
+			}
+		case strings.HasPrefix(src[i:], "::") || strings.HasPrefix(src[i:], "->") || strings.HasPrefix(src[i:], "=>"):
+			i += 2
+		default:
+			i++
+		}
+
+		text := src[start:i]
+		line += strings.Count(text, "\n")
+		tokens = append(tokens, sourceToken{text: text, literal: literal, start: start, end: i, line: startLine, newline: newline, doc: strings.Join(docLines, "\n")})
+		docLines, newline = nil, false
+	}
+	return tokens, strings.TrimSpace(strings.Join(fileDocLines, "\n"))
+}
+
+// ************************************************************************************************
+// parseItems extracts the items between two token indexes.
+func (p *rustParser) parseItems(lo, hi int, scope rustScope) {
+	for i := lo; i < hi; {
+		if next := p.parseItem(i, hi, scope); next > i {
+			i = next
+			continue
+		}
+		i = p.skip(i)
+	}
+}
+
+// ************************************************************************************************
+// parseItem extracts the item starting at a token, if any.
+//
+// Returns:
+//   - int: The index of the token after the item, i when there is no item.
+func (p *rustParser) parseItem(i, hi int, scope rustScope) int {
+	start := i
+	doc := p.tokens[i].doc
+
+	// Attributes, #[derive(Debug)]; inner attributes #![...] are skipped
+	var annotations []string
+	test, macroExport := false, false
+	for p.is(i, "#") {
+		inner := p.is(i+1, "!")
+		open := i + 1
+		if inner {
+			open++
+		}
+		if !p.is(open, "[") {
+			break
+		}
+		end := p.matchOr(open, hi) + 1
+		attribute := p.join(i, end)
+		switch {
+		case attribute == "#[test]" || strings.HasPrefix(attribute, "#[cfg(test)") || strings.HasSuffix(attribute, "::test]"):
+			test = true
+		case attribute == "#[macro_export]":
+			macroExport = true
+		}
+		if !inner && !strings.HasPrefix(attribute, "#[doc") {
+			annotations = append(annotations, attribute)
+		}
+		i = end
+		if doc == "" && i < hi {
+			doc = p.tokens[i].doc
+		}
+	}
+	if i >= hi {
+		return max(i, start+1)
+	}
+
+	// Visibility: only an unrestricted pub is part of the public API
+	sigStart := i
+	public := false
+	if p.is(i, "pub") {
+		public = !p.is(i+1, "(")
+		i++
+		if p.is(i, "(") {
+			i = p.matchOr(i, hi) + 1
+		}
+	}
+	switch scope.container {
+	case "trait", "trait impl":
+		public = true
+	}
+	exported := public && scope.exported
+
+	// Qualifiers
+	for p.is(i, "default") || p.is(i, "async") || p.is(i, "unsafe") || p.is(i, "const") && (p.is(i+1, "fn") || p.is(i+1, "unsafe") || p.is(i+1, "async") || p.is(i+1, "extern")) || p.is(i, "extern") && p.tokens[min(i+1, len(p.tokens)-1)].literal {
+		if p.is(i, "extern") {
+			i++
+		}
+		i++
+	}
+	if i >= hi {
+		return start
+	}
+
+	add := func(constructType, name string, end int) {
+		if test {
+			return
+		}
+		signature := stripRustAttributes(p.join(sigStart, end))
+		signature = strings.ReplaceAll(signature, ", }", " }")
+		p.analysis.Constructs = append(p.analysis.Constructs, SourceConstruct{
+			Type:        constructType,
+			Name:        scope.prefix + name,
+			Signature:   signature,
+			Annotations: annotations,
+			File:        p.analysis.Path,
+			Line:        p.tokens[sigStart].line,
+			Summary:     docSummary(doc),
+			Doc:         doc,
+			Exported:    exported,
+		})
+	}
+
+	switch {
+	case p.is(i, "fn") && p.isWord(i+1):
+		body, end := p.findRustBody(i+2, hi)
+		constructType := "function"
+		if scope.container != "module" {
+			constructType = "method"
+		}
+		add(constructType, p.tokens[i+1].text, end)
+		return p.afterRustItem(body, end, hi)
+
+	case (p.is(i, "struct") || p.is(i, "enum") || p.is(i, "union")) && p.isWord(i+1):
+		constructType := p.tokens[i].text
+		if constructType == "union" {
+			constructType = "struct"
+		}
+		body, end := p.findRustBody(i+2, hi)
+		if body >= 0 {
+			end = p.matchOr(body, hi) + 1
+		}
+		add(constructType, p.tokens[i+1].text, end)
+		if p.is(end, ";") {
+			end++
+		}
+		return end
+
+	case p.is(i, "trait") && p.isWord(i+1) || p.is(i, "auto") && p.is(i+1, "trait"):
+		if p.is(i, "auto") {
+			i++
+		}
+		name := p.tokens[i+1].text
+		body, end := p.findRustBody(i+2, hi)
+		add("trait", name, end)
+		if body >= 0 && !test {
+			p.parseItems(body+1, p.matchOr(body, hi), rustScope{prefix: scope.prefix + name + "::", exported: exported, container: "trait"})
+		}
+		return p.afterRustItem(body, end, hi)
+
+	case p.is(i, "impl"):
+		body, end := p.findRustBody(i+1, hi)
+		traitName, typeName := p.rustImplNames(i+1, end)
+		name := typeName
+		if traitName != "" {
+			name = traitName + " for " + typeName
+		}
+		exported = scope.exported
+		add("impl", name, end)
+		if body >= 0 && !test {
+			container := "impl"
+			if traitName != "" {
+				container = "trait impl"
+			}
+			p.parseItems(body+1, p.matchOr(body, hi), rustScope{prefix: scope.prefix + typeName + "::", exported: scope.exported, container: container})
+		}
+		return p.afterRustItem(body, end, hi)
+
+	case p.is(i, "mod") && p.isWord(i+1):
+		name := p.tokens[i+1].text
+		if !p.is(i+2, "{") {
+			return i + 2 // Module declared in its own file
+		}
+		if !test {
+			add("module", name, i+2)
+			p.parseItems(i+3, p.matchOr(i+2, hi), rustScope{prefix: scope.prefix + name + "::", exported: exported, container: "module"})
+		}
+		return p.matchOr(i+2, hi) + 1
+
+	case p.is(i, "type") && p.isWord(i+1):
+		end := p.endOfRustItem(i+2, hi)
+		add("type", p.tokens[i+1].text, end)
+		return end + 1
+
+	case (p.is(i, "const") || p.is(i, "static")) && p.isWord(i+1):
+		nameIndex := i + 1
+		if p.is(nameIndex, "mut") {
+			nameIndex++
+		}
+		end := p.endOfRustItem(nameIndex+1, hi)
+		signatureEnd := end
+		for j := nameIndex + 1; j < end; j = p.skip(j) {
+			if p.is(j, "=") {
+				signatureEnd = j
+				break
+			}
+		}
+		if p.tokens[nameIndex].text != "_" {
+			add("const", p.tokens[nameIndex].text, signatureEnd)
+		}
+		return end + 1
+
+	case p.is(i, "macro_rules") && p.is(i+1, "!") && p.isWord(i+2):
+		exported = macroExport
+		add("macro", p.tokens[i+2].text, i+3)
+		end := p.skip(i + 3)
+		if p.is(end, ";") {
+			end++
+		}
+		return end
+	}
+
+	if i > start {
+		return i // Attributes or visibility of an item that is not extracted
+	}
+	return start
+}
+
+// ************************************************************************************************
+// findRustBody finds the body of an item whose header continues at a token.
+//
+// Returns:
+//   - int: The index of the opening brace of the body, -1 for an item without body.
+//   - int: The index of the token ending the header.
+func (p *rustParser) findRustBody(i, hi int) (int, int) {
+	for j := i; j < hi; j = p.skip(j) {
+		switch {
+		case p.is(j, ";"):
+			return -1, j
+		case p.is(j, "{"):
+			return j, j
+		case p.is(j, "(") && p.is(p.matchOr(j, hi)+1, ";"):
+			// Tuple struct fields
+			return -1, p.matchOr(j, hi) + 1
+		}
+	}
+	return -1, hi
+}
+
+// ************************************************************************************************
+// afterRustItem returns the index following an item: after its body, or after the semicolon
+// ending its header.
+func (p *rustParser) afterRustItem(body, end, hi int) int {
+	if body >= 0 {
+		return p.matchOr(body, hi) + 1
+	}
+	if p.is(end, ";") {
+		return end + 1
+	}
+	return end
+}
+
+// ************************************************************************************************
+// endOfRustItem returns the index of the semicolon ending an item.
+func (p *rustParser) endOfRustItem(i, hi int) int {
+	for j := i; j < hi; j = p.skip(j) {
+		if p.is(j, ";") {
+			return j
+		}
+	}
+	return hi
+}
+
+// ************************************************************************************************
+// rustImplNames reads the header of an impl block, e.g. "impl<T> Display for Point<T> where ...".
+//
+// Returns:
+//   - string: The implemented trait, empty for an inherent impl.
+//   - string: The name of the implementing type, without generic arguments.
+func (p *rustParser) rustImplNames(i, end int) (string, string) {
+	// Skip the generic parameters of the impl
+	if p.is(i, "<") {
+		for depth := 0; i < end; i++ {
+			if p.is(i, "<") {
+				depth++
+			} else if p.is(i, ">") {
+				depth--
+				if depth == 0 {
+					i++
+					break
+				}
+			}
+		}
+	}
+
+	// Split the header at "for", before the where clause
+	headerEnd := i
+	split := -1
+	for depth := 0; headerEnd < end && !p.is(headerEnd, "where"); headerEnd = p.skip(headerEnd) {
+		switch {
+		case p.is(headerEnd, "<"):
+			depth++
+		case p.is(headerEnd, ">"):
+			depth--
+		case depth == 0 && p.is(headerEnd, "for") && split < 0 && headerEnd > i:
+			split = headerEnd
+		}
+	}
+	if split < 0 {
+		return "", p.rustTypeName(i, headerEnd)
+	}
+	return p.rustTypeName(i, split), p.rustTypeName(split+1, headerEnd)
+}
+
+// ************************************************************************************************
+// rustTypeName returns the name of the type or trait written between two token indexes: the
+// last path segment without generic arguments, e.g. "Display" for "std::fmt::Display" and "Vec"
+// for "&'a mut Vec<T>", or the whole type for tuples, slices and arrays.
+func (p *rustParser) rustTypeName(lo, hi int) string {
+	name := ""
+	for j, depth := lo, 0; j < hi; j = p.skip(j) {
+		switch {
+		case p.is(j, "<"):
+			depth++
+		case p.is(j, ">"):
+			depth--
+		case depth == 0 && p.isWord(j) && !p.is(j, "mut") && !p.is(j, "dyn") && !p.is(j, "const"):
+			name = p.tokens[j].text
+		}
+	}
+	if name == "" {
+		return p.join(lo, hi)
+	}
+	return name
+}
+
+// ************************************************************************************************
+// stripRustAttributes removes the #[...] attributes written inside an item, such as those of
+// struct fields and enum variants.
+func stripRustAttributes(signature string) string {
+	for {
+		start := strings.Index(signature, "#[")
+		if start < 0 {
+			return signature
+		}
+		depth, end := 0, len(signature)
+		for j := start + 1; j < len(signature); j++ {
+			if signature[j] == '[' {
+				depth++
+			} else if signature[j] == ']' {
+				depth--
+				if depth == 0 {
+					end = j + 1
+					break
+				}
+			}
+		}
+		signature = signature[:start] + strings.TrimLeft(signature[end:], " ")
+	}
+}
+
+// ************************************************************************************************
+// rustBlockDocLines returns the lines of a "/** */" or "/*! */" documentation comment, without
+// their leading asterisks.
+func rustBlockDocLines(comment string) []string {
+	comment = strings.TrimSuffix(comment[3:], "*/")
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*")))
+	}
+	return lines
+}
+
+// ************************************************************************************************
+// skipRustString returns the offset after the closing quote of a string whose content starts
+// at an offset.
+func skipRustString(src string, i int) int {
+	for ; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(src)
+}
+
+// ************************************************************************************************
+// skipRustCharOrLifetime returns the offset after the character literal or the lifetime, such
+// as 'a, starting at an offset.
+func skipRustCharOrLifetime(src string, i int) int {
+	if i+1 < len(src) && src[i+1] == '\\' {
+		end := strings.IndexByte(src[i+2:], '\'')
+		if end < 0 {
+			return len(src)
+		}
+		return i + 2 + end + 1
+	}
+	_, size := utf8.DecodeRuneInString(src[min(i+1, len(src)):])
+	if i+1+size < len(src) && src[i+1+size] == '\'' {
+		return i + 2 + size
+	}
+	// Lifetime or label
+	i++
+	for i < len(src) && (isIdentifierStart(src[i]) || src[i] >= '0' && src[i] <= '9') {
+		i++
+	}
+	return i
+}
+
+// ************************************************************************************************
+// isRustStringPrefix reports whether source starts with a prefixed string or character literal:
+// b"x", b'x', c"x", r"x", r#"x"#, br"x" or cr"x".
+func isRustStringPrefix(src string) bool {
+	i := 0
+	if src[0] == 'b' || src[0] == 'c' {
+		i++
+		if i < len(src) && src[i] == '\'' {
+			return src[0] == 'b'
+		}
+	}
+	if i < len(src) && src[i] == 'r' {
+		i++
+		for i < len(src) && src[i] == '#' {
+			i++
+		}
+	}
+	return i > 0 && i < len(src) && src[i] == '"'
+}
+
+// ************************************************************************************************
+// skipRustPrefixedString returns the offset after the prefixed literal starting at an offset.
+func skipRustPrefixedString(src string, i int) int {
+	if src[i] == 'b' || src[i] == 'c' {
+		i++
+		if src[i] == '\'' {
+			return skipRustCharOrLifetime(src, i)
+		}
+	}
+	if src[i] != 'r' {
+		return skipRustString(src, i+1)
+	}
+
+	// Raw strings end at a quote followed by as many "#" as they start with
+	i++
+	hashes := 0
+	for src[i] == '#' {
+		hashes++
+		i++
+	}
+	closing := "\"" + strings.Repeat("#", hashes)
+	end := strings.Index(src[i+1:], closing)
+	if end < 0 {
+		return len(src)
+	}
+	return i + 1 + end + len(closing)
+}
+
+// ************************************************************************************************
+// isRustTestFile reports whether a file holds a test module, e.g. "src/parser/tests.rs".
+func isRustTestFile(relPath string) bool {
+	name := path.Base(relPath)
+	return name == "tests.rs" || name == "test.rs" || strings.HasSuffix(name, "_test.rs") || strings.HasSuffix(name, "_tests.rs")
+}
+
+// ************************************************************************************************
+// rustModulePath returns the crate and module path of a file. The crate is the directory
+// holding the src directory, "crate" for the root crate: "src/geometry/mod.rs" is the module
+// "crate::geometry" and "crates/engine/src/lib.rs" the module "engine".
+//
+// Returns:
+//   - string: The crate name.
+//   - string: The module path.
+func rustModulePath(relPath string) (string, string) {
+	parts := strings.Split(strings.TrimSuffix(relPath, ".rs"), "/")
+	crate := "crate"
+	for index := len(parts) - 2; index >= 0; index-- {
+		if parts[index] == "src" {
+			if index > 0 {
+				crate = parts[index-1]
+			}
+			parts = parts[index+1:]
+			break
+		}
+	}
+
+	last := parts[len(parts)-1]
+	if last == "mod" || (last == "lib" || last == "main") && len(parts) == 1 {
+		parts = parts[:len(parts)-1]
+	}
+	return crate, strings.Join(append([]string{crate}, parts...), "::")
+}
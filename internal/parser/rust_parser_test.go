@@ -0,0 +1,226 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+func TestParseRustFile(t *testing.T) {
+	src := `//! Geometry primitives.
+//!
+//! Points and shapes.
+#![allow(dead_code)]
+
+use std::fmt;
+
+/// A point in the plane.
+#[derive(Debug, Clone)]
+pub struct Point<T> {
+    /// Horizontal coordinate.
+    pub x: T,
+    #[serde(default)]
+    pub y: T,
+}
+
+pub struct Meters(pub f64);
+
+pub(crate) struct Cache;
+
+/** Shapes that can be drawn. */
+pub enum Shape {
+    Circle { radius: f64 },
+    Square(f64),
+}
+
+pub trait Area: fmt::Debug {
+    /// Returns the area.
+    fn area(&self) -> f64;
+    fn name(&self) -> &'static str { "shape" }
+}
+
+impl<T: Copy> Point<T> {
+    pub const ORIGIN: usize = 0;
+
+    /// Creates a point.
+    pub fn new(x: T, y: T) -> Self {
+        let c = '}';
+        let s = r#"fn hidden() {}"#;
+        Self { x, y }
+    }
+
+    fn swap(&mut self) {}
+}
+
+impl<'a> fmt::Display for &'a Meters {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result { Ok(()) }
+}
+
+pub type Pair<'a> = (&'a str, Point<i32>);
+
+pub static mut COUNTER: u32 = 0;
+
+pub const unsafe extern "C" fn raw(ptr: *const u8) -> u8 { 0 }
+
+pub async fn fetch<F>(url: &str, f: F) -> Result<(), Error>
+where
+    F: Fn() -> u8,
+{
+    todo!()
+}
+
+fn private_helper() {}
+
+#[macro_export]
+macro_rules! point {
+    ($x:expr, $y:expr) => { Point::new($x, $y) };
+}
+
+pub mod shapes {
+    pub fn unit() -> Shape { Shape::Square(1.0) }
+    fn hidden() {}
+}
+
+mod internal {
+    pub fn not_exported() {}
+}
+
+#[cfg(test)]
+mod tests {
+    #[test]
+    fn it_works() {}
+}
+`
+	analysis := ParseRustFile("src/geometry/mod.rs", []byte(src))
+
+	if analysis.Module != "crate::geometry" || analysis.Package != "crate" {
+		t.Errorf("Expected module crate::geometry in crate, got %s in %s", analysis.Module, analysis.Package)
+	}
+	if analysis.Doc != "Geometry primitives.\n\nPoints and shapes." {
+		t.Errorf("Unexpected module documentation %q", analysis.Doc)
+	}
+
+	expected := []SourceConstruct{
+		{Type: "struct", Name: "Point", Signature: "pub struct Point<T> { pub x: T, pub y: T }", Line: 10, Exported: true, Summary: "A point in the plane."},
+		{Type: "struct", Name: "Meters", Signature: "pub struct Meters(pub f64)", Line: 17, Exported: true},
+		{Type: "struct", Name: "Cache", Signature: "pub(crate) struct Cache", Line: 19},
+		{Type: "enum", Name: "Shape", Signature: "pub enum Shape { Circle { radius: f64 }, Square(f64) }", Line: 22, Exported: true, Summary: "Shapes that can be drawn."},
+		{Type: "trait", Name: "Area", Signature: "pub trait Area: fmt::Debug", Line: 27, Exported: true},
+		{Type: "method", Name: "Area::area", Signature: "fn area(&self) -> f64", Line: 29, Exported: true, Summary: "Returns the area."},
+		{Type: "method", Name: "Area::name", Signature: "fn name(&self) -> &'static str", Line: 30, Exported: true},
+		{Type: "impl", Name: "Point", Signature: "impl<T: Copy> Point<T>", Line: 33, Exported: true},
+		{Type: "const", Name: "Point::ORIGIN", Signature: "pub const ORIGIN: usize", Line: 34, Exported: true},
+		{Type: "method", Name: "Point::new", Signature: "pub fn new(x: T, y: T) -> Self", Line: 37, Exported: true, Summary: "Creates a point."},
+		{Type: "method", Name: "Point::swap", Signature: "fn swap(&mut self)", Line: 43},
+		{Type: "impl", Name: "Display for Meters", Signature: "impl<'a> fmt::Display for &'a Meters", Line: 46, Exported: true},
+		{Type: "method", Name: "Meters::fmt", Signature: "fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result", Line: 47, Exported: true},
+		{Type: "type", Name: "Pair", Signature: "pub type Pair<'a> = (&'a str, Point<i32>)", Line: 50, Exported: true},
+		{Type: "const", Name: "COUNTER", Signature: "pub static mut COUNTER: u32", Line: 52, Exported: true},
+		{Type: "function", Name: "raw", Signature: `pub const unsafe extern "C" fn raw(ptr: *const u8) -> u8`, Line: 54, Exported: true},
+		{Type: "function", Name: "fetch", Signature: "pub async fn fetch<F>(url: &str, f: F) -> Result<(), Error> where F: Fn() -> u8,", Line: 56, Exported: true},
+		{Type: "function", Name: "private_helper", Signature: "fn private_helper()", Line: 63},
+		{Type: "macro", Name: "point", Signature: "macro_rules! point", Line: 66, Exported: true},
+		{Type: "module", Name: "shapes", Signature: "pub mod shapes", Line: 70, Exported: true},
+		{Type: "function", Name: "shapes::unit", Signature: "pub fn unit() -> Shape", Line: 71, Exported: true},
+		{Type: "function", Name: "shapes::hidden", Signature: "fn hidden()", Line: 72},
+		{Type: "module", Name: "internal", Signature: "mod internal", Line: 75},
+		{Type: "function", Name: "internal::not_exported", Signature: "pub fn not_exported()", Line: 76},
+	}
+	if len(analysis.Constructs) != len(expected) {
+		for _, construct := range analysis.Constructs {
+			t.Logf("%s %s at line %d: %s", construct.Type, construct.Name, construct.Line, construct.Signature)
+		}
+		t.Fatalf("Expected %d constructs, got %d", len(expected), len(analysis.Constructs))
+	}
+	for i, want := range expected {
+		got := analysis.Constructs[i]
+		if got.Type != want.Type || got.Name != want.Name || got.Signature != want.Signature ||
+			got.Line != want.Line || got.Exported != want.Exported || got.Summary != want.Summary {
+			t.Errorf("Construct %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	if annotations := analysis.Constructs[0].Annotations; len(annotations) != 1 || annotations[0] != "#[derive(Debug, Clone)]" {
+		t.Errorf("Expected the derive attribute on Point, got %v", annotations)
+	}
+}
+
+func TestRustModulePath(t *testing.T) {
+	tests := []struct {
+		relPath string
+		crate   string
+		module  string
+	}{
+		{"src/lib.rs", "crate", "crate"},
+		{"src/main.rs", "crate", "crate"},
+		{"src/net/http.rs", "crate", "crate::net::http"},
+		{"crates/engine/src/lib.rs", "engine", "engine"},
+		{"crates/engine/src/render/mod.rs", "engine", "engine::render"},
+		{"build.rs", "crate", "crate::build"},
+	}
+	for _, tt := range tests {
+		crate, module := rustModulePath(tt.relPath)
+		if crate != tt.crate || module != tt.module {
+			t.Errorf("rustModulePath(%q) = %s, %s; expected %s, %s", tt.relPath, crate, module, tt.crate, tt.module)
+		}
+	}
+}
+
+func TestRustParser_ParseRepository(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"Cargo.toml":            "[package]\nname = \"engine\"\n",
+		"src/lib.rs":            "//! The engine.\npub mod render;\npub fn start() {}\n",
+		"src/render/mod.rs":     "pub struct Renderer;\nimpl Renderer {\n    // TODO: vsync\n    pub fn draw(&self) {}\n    fn reset(&self) {}\n}\n",
+		"src/render/tests.rs":   "pub fn test_helper() {}\n",
+		"tests/integration.rs":  "pub fn integration() {}\n",
+		"target/debug/build.rs": "pub fn generated() {}\n",
+		"examples/demo.rs":      "pub fn demo() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	repoIndex, err := NewRustParser().ParseRepository("engine", tempDir, types.IndexingConfig{})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+
+	if repoIndex.Metadata["indexer_type"] != "rust_native" {
+		t.Errorf("Expected indexer_type 'rust_native', got '%v'", repoIndex.Metadata["indexer_type"])
+	}
+	if repoIndex.Metadata["file_count"] != 2 {
+		t.Errorf("Expected file_count 2, got '%v'", repoIndex.Metadata["file_count"])
+	}
+
+	xmlFile, exists := repoIndex.Files[".repomix.xml"]
+	if !exists {
+		t.Fatal("Expected .repomix.xml file to be generated")
+	}
+	for _, pattern := range []string{
+		`<file path="src/render/mod.rs" module="crate::render">`,
+		`<package name="crate">`,
+		"pub struct Renderer  // src/render/mod.rs:1",
+		"pub fn draw(&amp;self)",
+		"pub fn start()",
+		"The engine.",
+	} {
+		if !strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content to contain '%s'", pattern)
+		}
+	}
+	for _, pattern := range []string{"test_helper", "integration", "generated", "demo", "reset"} {
+		if strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content not to contain '%s'", pattern)
+		}
+	}
+}
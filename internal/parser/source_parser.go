@@ -0,0 +1,468 @@
+// ************************************************************************************************
+// Package parser provides the native source parsers of languages other than Go.
+// A SourceParser walks a repository for the source files of one language, extracts their
+// declarations with the scanner of that language, and renders them in the same repomix-style
+// XML as the Go parser: a summary, the directory structure, one section per file, then one
+// section per package with its public API. Symbols, work items, source hashes and an API
+// summary are recorded in the repository metadata like for Go repositories.
+package parser
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"repomix-mcp/internal/repository"
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// SourceLanguage describes a language indexed by a SourceParser.
+type SourceLanguage struct {
+	Name           string                                       // Language name, e.g. "python"
+	DisplayName    string                                       // Name shown in the rendered XML (default: Name capitalized)
+	Strategy       string                                       // Indexing strategy recorded as indexer_type, e.g. "python_native"
+	Extensions     []string                                     // Extensions of the source files, e.g. ".py"
+	SkippedDirs    []string                                     // Directory names never walked, hidden directories are always skipped
+	CommentPrefix  string                                       // Line comment prefix used in the rendered XML, e.g. "#"
+	ConstructTypes []string                                     // Construct types in rendering order
+	IsTestFile     func(relPath string) bool                    // Reports test files, left out of the analysis
+	ParseFile      func(relPath string, src []byte) *SourceFile // Extracts the declarations of a file
+}
+
+// ************************************************************************************************
+// SourceConstruct is a declaration extracted from a source file.
+type SourceConstruct struct {
+	Type        string   // One of the SourceLanguage.ConstructTypes, e.g. "class" or "method"
+	Name        string   // Name within its module, "Class.method" for members
+	Signature   string   // Declaration without body, on a single line
+	Annotations []string // Decorators or attributes written before the declaration
+	File        string   // Source file path, relative to the repository root
+	Line        int      // 1-based line of the declaration
+	Summary     string   // First sentence of the documentation
+	Doc         string   // Full documentation text
+	Exported    bool     // Whether the declaration belongs to the public API
+}
+
+// ************************************************************************************************
+// SourceFile is the analysis of a source file.
+type SourceFile struct {
+	Path       string // Path relative to the repository root
+	Module     string // Module or namespace the file declares, e.g. "app.models"
+	Package    string // Package grouping the file in the package sections, e.g. "app"
+	Doc        string // Module documentation, such as a Python module docstring
+	Constructs []SourceConstruct
+}
+
+// ************************************************************************************************
+// SourceParser indexes the repositories of a SourceLanguage. It is safe for concurrent use.
+type SourceParser struct {
+	language SourceLanguage
+}
+
+// ************************************************************************************************
+// NewSourceParser creates a parser for the source files of a language.
+//
+// Example usage:
+//
+//	repoIndex, err := parser.NewSourceParser(parser.PythonLanguage).ParseRepository("app", "/path/to/app", config)
+func NewSourceParser(language SourceLanguage) *SourceParser {
+	return &SourceParser{language: language}
+}
+
+// ************************************************************************************************
+// ParseRepository extracts the declarations of every source file of the language in a
+// repository and renders them as a single .repomix.xml file.
+//
+// Returns:
+//   - *types.RepositoryIndex: The repository index holding .repomix.xml.
+//   - error: An error if the repository holds no source file of the language.
+func (p *SourceParser) ParseRepository(repositoryID, localPath string, config types.IndexingConfig) (*types.RepositoryIndex, error) {
+	if repositoryID == "" || localPath == "" {
+		return nil, fmt.Errorf("%w: invalid parameters", types.ErrInvalidConfig)
+	}
+
+	sourceFiles, err := p.findSourceFiles(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s files: %w", p.language.Name, err)
+	}
+
+	// Limit to committed files when requested, non-git directories keep every file
+	if config.GitTrackedOnly {
+		if tracked, err := repository.TrackedFiles(localPath); err == nil {
+			trackedFiles := sourceFiles[:0]
+			for _, sourceFile := range sourceFiles {
+				if tracked[filepath.ToSlash(sourceFile)] {
+					trackedFiles = append(trackedFiles, sourceFile)
+				}
+			}
+			sourceFiles = trackedFiles
+		} else {
+			slog.Warn("Ignoring gitTrackedOnly", "path", localPath, "error", err)
+		}
+	}
+
+	if len(sourceFiles) == 0 {
+		return nil, fmt.Errorf("no %s files found in repository", p.language.Name)
+	}
+
+	// Parse every file, keeping the sources for work items and change detection
+	var analyses []*SourceFile
+	var todos []types.TodoItem
+	symbols := &symbolIndex{}
+	sourceHashes := make(map[string]string)
+	stats := make(map[string]*types.DirectoryStats)
+	for _, sourceFile := range sourceFiles {
+		slashPath := filepath.ToSlash(sourceFile)
+		src, err := os.ReadFile(filepath.Join(localPath, sourceFile))
+		if err != nil {
+			slog.Warn("Failed to read source file", "path", sourceFile, "error", err)
+			continue
+		}
+
+		analysis := p.language.ParseFile(slashPath, src)
+		analyses = append(analyses, analysis)
+		for _, construct := range analysis.Constructs {
+			symbols.symbols = append(symbols.symbols, types.Symbol{
+				Name:      construct.Name,
+				Kind:      construct.Type,
+				Package:   analysis.Module,
+				Signature: construct.Signature,
+				Doc:       construct.Doc,
+				File:      slashPath,
+				Line:      construct.Line,
+				Exported:  construct.Exported,
+			})
+		}
+
+		todos = append(todos, types.ScanTodos(slashPath, string(src), config.TodoMarkers)...)
+		sourceHashes[slashPath] = types.HashContent(config.HashAlgorithm, string(src))
+		types.AddDirectoryStat(stats, slashPath, p.language.Name, int64(len(src)))
+	}
+
+	xmlContent, exportedLines, packages := p.renderRepomixXML(analyses, sourceFiles, config.IncludeNonExported)
+
+	repoIndex := &types.RepositoryIndex{
+		ID:          repositoryID,
+		Name:        repositoryID,
+		Path:        localPath,
+		LastUpdated: time.Now(),
+		Files:       make(map[string]types.IndexedFile),
+		Metadata:    make(map[string]interface{}),
+	}
+	repoIndex.Files[".repomix.xml"] = types.IndexedFile{
+		Path:         ".repomix.xml",
+		Content:      xmlContent,
+		Hash:         types.HashContent(config.HashAlgorithm, xmlContent),
+		Size:         int64(len(xmlContent)),
+		ModTime:      time.Now(),
+		Language:     "xml",
+		RepositoryID: repositoryID,
+		Metadata: map[string]string{
+			"indexer_type": p.language.Strategy,
+			fmt.Sprintf("%s_files_count", p.language.Name): fmt.Sprintf("%d", len(analyses)),
+			"packages_count": fmt.Sprintf("%d", packages),
+
+			types.ExportedLinesMetadataKey: types.FormatLineRanges(exportedLines),
+		},
+	}
+
+	repoIndex.Metadata["indexer_type"] = p.language.Strategy
+	repoIndex.Metadata["api_summary"] = p.generateAPISummary(repositoryID, analyses)
+	repoIndex.Metadata["file_count"] = len(analyses)
+	repoIndex.Metadata["packages_count"] = packages
+	repoIndex.Metadata["indexed_at"] = time.Now().Format(time.RFC3339)
+	repoIndex.Metadata["indexer_version"] = fmt.Sprintf("repomix-mcp-%s-v1.0.0", p.language.Name)
+	if config.DirectoryStats {
+		repoIndex.Metadata[types.DirectoryStatsMetadataKey] = stats
+	}
+	repoIndex.Metadata[types.TodosMetadataKey] = todos
+	repoIndex.Metadata[types.SourceHashesMetadataKey] = sourceHashes
+	repoIndex.Metadata[types.SymbolsMetadataKey] = symbols.resolve()
+
+	// Count constructs by type across all files
+	constructCounts := make(map[string]int)
+	for _, analysis := range analyses {
+		for _, construct := range analysis.Constructs {
+			constructCounts[construct.Type]++
+		}
+	}
+	for constructType, count := range constructCounts {
+		repoIndex.Metadata[fmt.Sprintf("%s_count", constructType)] = count
+	}
+
+	return repoIndex, nil
+}
+
+// ************************************************************************************************
+// findSourceFiles returns the source files of the language in a repository, test files,
+// hidden directories and skipped directories excluded.
+//
+// Returns:
+//   - []string: The file paths relative to localPath, in walk order.
+//   - error: An error if the repository cannot be walked.
+func (p *SourceParser) findSourceFiles(localPath string) ([]string, error) {
+	skipped := make(map[string]bool, len(p.language.SkippedDirs))
+	for _, name := range p.language.SkippedDirs {
+		skipped[name] = true
+	}
+
+	var sourceFiles []string
+	err := filepath.WalkDir(localPath, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != localPath && (strings.HasPrefix(entry.Name(), ".") || skipped[entry.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !p.hasSourceExtension(entry.Name()) {
+			return nil
+		}
+		relPath, err := filepath.Rel(localPath, path)
+		if err != nil {
+			return err
+		}
+		if p.language.IsTestFile != nil && p.language.IsTestFile(filepath.ToSlash(relPath)) {
+			return nil
+		}
+		sourceFiles = append(sourceFiles, relPath)
+		return nil
+	})
+	return sourceFiles, err
+}
+
+// ************************************************************************************************
+// hasSourceExtension reports whether a file name has one of the extensions of the language.
+func (p *SourceParser) hasSourceExtension(name string) bool {
+	for _, extension := range p.language.Extensions {
+		if strings.HasSuffix(name, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// ************************************************************************************************
+// renderRepomixXML generates the repomix-compatible XML of the parsed files and records the
+// output lines occupied by exported constructs, so searches can be limited to the public API.
+//
+// Returns:
+//   - string: The XML content.
+//   - []types.LineRange: The lines of exported constructs, in output order.
+//   - int: The number of package sections.
+func (p *SourceParser) renderRepomixXML(analyses []*SourceFile, sourceFiles []string, includeNonExported bool) (string, []types.LineRange, int) {
+	var xml strings.Builder
+	var exportedLines []types.LineRange
+	language := p.language
+	comment := language.CommentPrefix
+
+	// nextLine returns the line number the next write starts on, counting incrementally
+	counted, lines := 0, 0
+	nextLine := func() int {
+		content := xml.String()
+		lines += strings.Count(content[counted:], "\n")
+		counted = len(content)
+		return lines + 1
+	}
+
+	// writeConstructs renders constructs grouped by type in language order, sorted by name
+	writeConstructs := func(constructs []SourceConstruct) {
+		byType := make(map[string][]SourceConstruct)
+		for _, construct := range constructs {
+			if includeNonExported || construct.Exported {
+				byType[construct.Type] = append(byType[construct.Type], construct)
+			}
+		}
+		for _, constructType := range language.ConstructTypes {
+			group := byType[constructType]
+			if len(group) == 0 {
+				continue
+			}
+			sort.SliceStable(group, func(i, j int) bool {
+				return group[i].Name < group[j].Name
+			})
+			for _, construct := range group {
+				start := nextLine()
+				for _, annotation := range construct.Annotations {
+					xml.WriteString(escapeXMLText(annotation) + "\n")
+				}
+				xml.WriteString(escapeXMLText(fmt.Sprintf("%s  %s %s:%d\n", construct.Signature, comment, construct.File, construct.Line)))
+				if construct.Exported {
+					exportedLines = append(exportedLines, types.LineRange{Start: start, End: nextLine() - 1})
+				}
+			}
+			xml.WriteString("\n")
+		}
+	}
+
+	// XML header
+	xml.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	xml.WriteString("<repository>\n")
+
+	// File summary section
+	displayName := language.DisplayName
+	if displayName == "" {
+		displayName = strings.ToUpper(language.Name[:1]) + language.Name[1:]
+	}
+	xml.WriteString("<file_summary>\n")
+	xml.WriteString(fmt.Sprintf("This file is a merged representation of a subset of the codebase, containing %s files with extracted language constructs.\n", displayName))
+	xml.WriteString(fmt.Sprintf("The content has been processed where %s source analysis extracted %s.\n\n", displayName, strings.Join(language.ConstructTypes, ", ")))
+
+	xml.WriteString("<purpose>\n")
+	xml.WriteString(fmt.Sprintf("This file contains a %s-specific analysis of the repository's %s source code.\n", displayName, displayName))
+	xml.WriteString(fmt.Sprintf("It is designed to be easily consumable by AI systems for %s code analysis,\n", displayName))
+	xml.WriteString("code review, or other automated processes focusing on language constructs.\n")
+	xml.WriteString("</purpose>\n\n")
+
+	xml.WriteString("<file_format>\n")
+	xml.WriteString("The content is organized as follows:\n")
+	xml.WriteString("1. This summary section\n")
+	xml.WriteString("2. Repository information\n")
+	xml.WriteString("3. Directory structure\n")
+	xml.WriteString("4. Individual file sections with constructs from each file\n")
+	xml.WriteString("5. Package sections with exported constructs only\n")
+	xml.WriteString("</file_format>\n\n")
+
+	xml.WriteString("<usage_guidelines>\n")
+	xml.WriteString("- This file should be treated as read-only. Any changes should be made to the\n")
+	xml.WriteString("  original repository files, not this packed version.\n")
+	xml.WriteString("- When processing this file, use the construct signatures to understand\n")
+	xml.WriteString("  the codebase structure and relationships.\n")
+	xml.WriteString("- Be aware that this file may contain sensitive information. Handle it with\n")
+	xml.WriteString("  the same level of security as you would the original repository.\n")
+	xml.WriteString("</usage_guidelines>\n\n")
+
+	xml.WriteString("<notes>\n")
+	xml.WriteString("- Test files are excluded from this analysis\n")
+	if includeNonExported {
+		xml.WriteString("- All constructs (both public and private) are included\n")
+	} else {
+		xml.WriteString("- Only public constructs are included\n")
+	}
+	xml.WriteString("- Constructs are organized by type for easy navigation\n")
+	xml.WriteString("- Line numbers and file locations are preserved for reference\n")
+	xml.WriteString("</notes>\n\n")
+	xml.WriteString("</file_summary>\n\n")
+
+	// Directory structure
+	xml.WriteString("<directory_structure>\n")
+	sortedPaths := make([]string, 0, len(sourceFiles))
+	for _, sourceFile := range sourceFiles {
+		sortedPaths = append(sortedPaths, filepath.ToSlash(sourceFile))
+	}
+	sort.Strings(sortedPaths)
+	for _, sourceFile := range sortedPaths {
+		xml.WriteString(escapeXMLText(sourceFile) + "\n")
+	}
+	xml.WriteString("</directory_structure>\n\n")
+
+	// Individual file sections
+	xml.WriteString("<files>\n")
+	sortedAnalyses := append([]*SourceFile(nil), analyses...)
+	sort.Slice(sortedAnalyses, func(i, j int) bool {
+		return sortedAnalyses[i].Path < sortedAnalyses[j].Path
+	})
+
+	packages := make(map[string][]SourceConstruct)
+	for _, analysis := range sortedAnalyses {
+		packages[analysis.Package] = append(packages[analysis.Package], analysis.Constructs...)
+
+		visible := 0
+		for _, construct := range analysis.Constructs {
+			if includeNonExported || construct.Exported {
+				visible++
+			}
+		}
+		if visible == 0 && analysis.Doc == "" {
+			continue // Skip files with nothing to show
+		}
+
+		xml.WriteString(fmt.Sprintf(`<file path="%s" module="%s">`+"\n", escapeXMLAttr(analysis.Path), escapeXMLAttr(analysis.Module)))
+		xml.WriteString(fmt.Sprintf("%s Module: %s\n", comment, escapeXMLText(analysis.Module)))
+		xml.WriteString(fmt.Sprintf("%s File: %s\n", comment, escapeXMLText(analysis.Path)))
+		if analysis.Doc != "" {
+			xml.WriteString(comment + "\n")
+			for _, line := range strings.Split(analysis.Doc, "\n") {
+				xml.WriteString(escapeXMLText(strings.TrimRight(comment+" "+line, " ")) + "\n")
+			}
+		}
+		xml.WriteString("\n")
+		writeConstructs(analysis.Constructs)
+		xml.WriteString("</file>\n\n")
+	}
+
+	// Package sections with exported constructs only
+	sortedPackages := make([]string, 0, len(packages))
+	for packageName := range packages {
+		sortedPackages = append(sortedPackages, packageName)
+	}
+	sort.Strings(sortedPackages)
+
+	for _, packageName := range sortedPackages {
+		xml.WriteString(fmt.Sprintf(`<package name="%s">`+"\n", escapeXMLAttr(packageName)))
+		if includeNonExported {
+			xml.WriteString(fmt.Sprintf("%s Package: %s (all constructs)\n\n", comment, escapeXMLText(packageName)))
+		} else {
+			xml.WriteString(fmt.Sprintf("%s Package: %s (public constructs only)\n\n", comment, escapeXMLText(packageName)))
+		}
+		writeConstructs(packages[packageName])
+		xml.WriteString("</package>\n\n")
+	}
+
+	xml.WriteString("</files>\n")
+	xml.WriteString("</repository>\n")
+
+	return xml.String(), exportedLines, len(packages)
+}
+
+// ************************************************************************************************
+// generateAPISummary renders a compact overview of the public API surface: per module, the
+// signature of every exported construct with its one-line documentation summary.
+//
+// Returns:
+//   - string: The summary as Markdown.
+func (p *SourceParser) generateAPISummary(repositoryID string, analyses []*SourceFile) string {
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("# API Summary: %s\n", repositoryID))
+
+	sortedAnalyses := append([]*SourceFile(nil), analyses...)
+	sort.Slice(sortedAnalyses, func(i, j int) bool {
+		return sortedAnalyses[i].Module < sortedAnalyses[j].Module
+	})
+
+	for _, analysis := range sortedAnalyses {
+		var exported []SourceConstruct
+		for _, construct := range analysis.Constructs {
+			if construct.Exported {
+				exported = append(exported, construct)
+			}
+		}
+		if len(exported) == 0 {
+			continue
+		}
+
+		summary.WriteString(fmt.Sprintf("\n## module %s\n\n", analysis.Module))
+		for _, constructType := range p.language.ConstructTypes {
+			for _, construct := range exported {
+				if construct.Type != constructType {
+					continue
+				}
+				summary.WriteString(fmt.Sprintf("- `%s`", construct.Signature))
+				if construct.Summary != "" {
+					summary.WriteString(" - " + construct.Summary)
+				}
+				summary.WriteString("\n")
+			}
+		}
+	}
+
+	return summary.String()
+}
@@ -0,0 +1,154 @@
+// ************************************************************************************************
+// Package parser provides the token streams shared by the source parsers scanning languages
+// without a Go parser. A scanner splits the source into tokens, comments left out and literals
+// kept whole, then brackets are paired so declaration bodies can be skipped or walked without
+// parsing their content.
+package parser
+
+import (
+	"strings"
+)
+
+// ************************************************************************************************
+// sourceToken is a token of source code.
+type sourceToken struct {
+	text    string // Source text; a whole literal for strings, characters and regular expressions
+	literal bool   // Whether the token is a literal rather than an identifier or punctuation
+	start   int    // Byte offset of the token
+	end     int    // Byte offset after the token
+	line    int    // 1-based line of the token
+	newline bool   // Whether a line break separates the token from the previous one
+	doc     string // Documentation comment written right before the token
+}
+
+// ************************************************************************************************
+// tokenStream holds the tokens of a file with their paired brackets.
+type tokenStream struct {
+	tokens []sourceToken
+	match  []int // Index of the matching bracket of every bracket token, -1 otherwise
+
+	// separateMembers renders line breaks between the members of braces as "; " in join, for
+	// languages where line breaks end members
+	separateMembers bool
+}
+
+// ************************************************************************************************
+// newTokenStream pairs the brackets of tokens.
+//
+// Example usage:
+//
+//	stream := newTokenStream(tokens)
+//	body := stream.matchOr(open, len(tokens))
+func newTokenStream(tokens []sourceToken) tokenStream {
+	return tokenStream{tokens: tokens, match: matchBrackets(tokens)}
+}
+
+// ************************************************************************************************
+// matchBrackets pairs the opening and closing brackets of tokens.
+//
+// Returns:
+//   - []int: The index of the matching bracket by token index, -1 for other tokens and unmatched
+//     brackets.
+func matchBrackets(tokens []sourceToken) []int {
+	match := make([]int, len(tokens))
+	var stack []int
+	for i, token := range tokens {
+		match[i] = -1
+		if token.literal || len(token.text) != 1 {
+			continue
+		}
+		switch token.text {
+		case "(", "[", "{":
+			stack = append(stack, i)
+		case ")", "]", "}":
+			opening := map[string]string{")": "(", "]": "[", "}": "{"}[token.text]
+			// Unbalanced closing brackets are dropped with the openings they skip
+			for j := len(stack) - 1; j >= 0; j-- {
+				if tokens[stack[j]].text == opening {
+					match[i], match[stack[j]] = stack[j], i
+					stack = stack[:j]
+					break
+				}
+			}
+		}
+	}
+	return match
+}
+
+// ************************************************************************************************
+// skip returns the index of the token after a token, after its matching bracket for opening
+// brackets.
+func (p *tokenStream) skip(i int) int {
+	if i < len(p.match) && p.match[i] > i {
+		return p.match[i] + 1
+	}
+	return i + 1
+}
+
+// ************************************************************************************************
+// matchOr returns the index of the bracket matching a token, hi when it has none.
+func (p *tokenStream) matchOr(i, hi int) int {
+	if i < len(p.match) && p.match[i] > i {
+		return p.match[i]
+	}
+	return hi
+}
+
+// ************************************************************************************************
+// is reports whether the token at an index is the given identifier or punctuation.
+func (p *tokenStream) is(i int, text string) bool {
+	return i >= 0 && i < len(p.tokens) && !p.tokens[i].literal && p.tokens[i].text == text
+}
+
+// ************************************************************************************************
+// isWord reports whether the token at an index is an identifier or a keyword, "#name" private
+// names included.
+func (p *tokenStream) isWord(i int) bool {
+	if i < 0 || i >= len(p.tokens) || p.tokens[i].literal {
+		return false
+	}
+	text := p.tokens[i].text
+	return isIdentifierStart(text[0]) || text[0] == '#' && len(text) > 1
+}
+
+// ************************************************************************************************
+// join renders the tokens between two indexes on a single line. Tokens separated in the source
+// are separated by a space.
+func (p *tokenStream) join(lo, hi int) string {
+	var signature strings.Builder
+	var brackets []string
+	for i := lo; i < hi && i < len(p.tokens); i++ {
+		token := p.tokens[i]
+		if i > lo && token.start > p.tokens[i-1].end {
+			previous := p.tokens[i-1].text
+			if p.separateMembers && token.newline && len(brackets) > 0 && brackets[len(brackets)-1] == "{" &&
+				previous != "{" && previous != ";" && previous != "," && token.text != "}" {
+				signature.WriteString(";")
+			}
+			signature.WriteString(" ")
+		}
+		if strings.Contains(token.text, "\n") {
+			signature.WriteString(strings.Join(strings.Fields(token.text), " "))
+		} else {
+			signature.WriteString(token.text)
+		}
+		if !token.literal {
+			switch token.text {
+			case "(", "[", "{":
+				brackets = append(brackets, token.text)
+			case ")", "]", "}":
+				if len(brackets) > 0 {
+					brackets = brackets[:len(brackets)-1]
+				}
+			}
+		}
+	}
+	return signature.String()
+}
+
+// ************************************************************************************************
+// isIdentifierStart reports whether a byte may start an identifier. Bytes of non-ASCII
+// characters are accepted, languages allowing Unicode letters.
+func isIdentifierStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
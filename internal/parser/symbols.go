@@ -0,0 +1,68 @@
+// ************************************************************************************************
+// Package parser provides the symbol index for the repomix-mcp application.
+// The constructs extracted from every parsed file are recorded with the import path of their
+// package, so a symbol such as "Cache.StoreRepository" can be resolved to its declaration.
+package parser
+
+import (
+	"sort"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// symbolIndex collects the symbols declared by the parsed files of a repository.
+type symbolIndex struct {
+	symbols []types.Symbol
+}
+
+// ************************************************************************************************
+// addConstructs records the constructs of a file belonging to the package with the given
+// import path.
+func (x *symbolIndex) addConstructs(constructs []GoConstruct, filePath, importPath string) {
+	for _, construct := range constructs {
+		name := construct.Name
+		if construct.Type == "method" {
+			name = receiverBaseName(construct.Receiver) + "." + name
+		}
+		x.symbols = append(x.symbols, types.Symbol{
+			Name:      name,
+			Kind:      construct.Type,
+			Package:   importPath,
+			Signature: construct.Signature,
+			Doc:       construct.Doc,
+			File:      filePath,
+			Line:      construct.Line,
+			Exported:  construct.Exported,
+		})
+	}
+}
+
+// ************************************************************************************************
+// resolve returns the recorded symbols sorted by qualified name, then location.
+func (x *symbolIndex) resolve() []types.Symbol {
+	symbols := append([]types.Symbol(nil), x.symbols...)
+	sort.Slice(symbols, func(i, j int) bool {
+		a, b := symbols[i], symbols[j]
+		if a.QualifiedName() != b.QualifiedName() {
+			return a.QualifiedName() < b.QualifiedName()
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return symbols
+}
+
+// ************************************************************************************************
+// receiverBaseName returns the type name of a rendered method receiver: "*Cache[K, V]" becomes
+// "Cache".
+func receiverBaseName(receiver string) string {
+	receiver = strings.TrimPrefix(strings.TrimSpace(receiver), "*")
+	if index := strings.Index(receiver, "["); index >= 0 {
+		receiver = receiver[:index]
+	}
+	return receiver
+}
@@ -0,0 +1,428 @@
+//go:build cgo
+
+// ************************************************************************************************
+// Package parser provides the tree-sitter grammars of the universal construct extractor. Every
+// grammar maps the node types of its declarations to construct types; the syntax tree is walked
+// from the root, descending into the bodies of namespaces and types but never into function
+// bodies. Access specifiers, such as the public: sections of C++ classes or the private calls of
+// Ruby classes, and static storage decide which declarations are exported.
+package parser
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/c"
+	"github.com/smacker/go-tree-sitter/cpp"
+	"github.com/smacker/go-tree-sitter/ruby"
+)
+
+// ************************************************************************************************
+// TreeSitterAvailable reports whether the tree-sitter grammars are built in, cgo being required.
+const TreeSitterAvailable = true
+
+// ************************************************************************************************
+// treeSitterRule describes the declarations of a node type.
+type treeSitterRule struct {
+	constructType string // Construct type, functions declared in a type being methods
+	container     bool   // Whether the declarations of the body are extracted
+	body          string // Field of the body, left out of the signature (default: "body")
+	typeScope     bool   // Whether the declarations of the body are members of a type
+}
+
+// ************************************************************************************************
+// treeSitterGrammar describes how the declarations of a language are extracted.
+type treeSitterGrammar struct {
+	language  func() *sitter.Language
+	separator string                    // Separator of qualified names, e.g. "::"
+	rules     map[string]treeSitterRule // Rules by node type
+	wrappers  map[string]bool           // Node types wrapping a declaration, such as C++ templates
+	suffixes  []string                  // Trailing tokens trimmed from signatures, e.g. ";"
+
+	// name returns the name declared by a node of a rule, empty when the node declares nothing
+	name func(node *sitter.Node, src []byte) string
+	// access returns the access a node sets for the next members, empty when it is a member
+	access func(node *sitter.Node, src []byte) string
+	// visibility returns the access of a declaration, empty for the access of its scope
+	visibility func(node *sitter.Node, src []byte, inType bool) string
+	// defaultAccess returns the access of the members of a container before any specifier
+	defaultAccess func(containerType string) string
+}
+
+// ************************************************************************************************
+// treeSitterScope is the file, namespace or type whose declarations are being extracted.
+type treeSitterScope struct {
+	owner    string // Qualified name of the enclosing namespace or type, empty at the top level
+	inType   bool   // Whether the declarations are members of a type
+	exported bool   // Whether the scope is exported
+	access   string // Access of the next members, e.g. "public"
+}
+
+// ************************************************************************************************
+// treeSitterComment is a comment starting its line.
+type treeSitterComment struct {
+	startRow int
+	text     string
+}
+
+// ************************************************************************************************
+// cRules are the declarations shared by C and C++.
+var cRules = map[string]treeSitterRule{
+	"function_definition":  {constructType: "function"},
+	"declaration":          {constructType: "function"},
+	"struct_specifier":     {constructType: "struct"},
+	"union_specifier":      {constructType: "struct"},
+	"enum_specifier":       {constructType: "enum"},
+	"preproc_function_def": {constructType: "macro", body: "value"},
+	"preproc_def":          {constructType: "macro", body: "value"},
+}
+
+// ************************************************************************************************
+// cGrammar extracts the functions, structs, unions, enums and macros of C files.
+var cGrammar = &treeSitterGrammar{
+	language:      c.GetLanguage,
+	separator:     ".",
+	rules:         cRules,
+	suffixes:      []string{";"},
+	name:          cDeclarationName,
+	access:        func(node *sitter.Node, src []byte) string { return "" },
+	visibility:    cVisibility,
+	defaultAccess: func(containerType string) string { return "public" },
+}
+
+// ************************************************************************************************
+// cppGrammar extracts the namespaces, classes, structs, enums, functions, methods and macros of
+// C++ files and headers.
+var cppGrammar = &treeSitterGrammar{
+	language:  cpp.GetLanguage,
+	separator: "::",
+	rules: mergeTreeSitterRules(cRules, map[string]treeSitterRule{
+		"namespace_definition": {constructType: "namespace", container: true},
+		"class_specifier":      {constructType: "class", container: true, typeScope: true},
+		"struct_specifier":     {constructType: "struct", container: true, typeScope: true},
+		"union_specifier":      {constructType: "struct", container: true, typeScope: true},
+		"field_declaration":    {constructType: "function"},
+	}),
+	wrappers: map[string]bool{"template_declaration": true},
+	suffixes: []string{";"},
+	name:     cDeclarationName,
+	access: func(node *sitter.Node, src []byte) string {
+		if node.Type() == "access_specifier" {
+			return strings.TrimSpace(node.Content(src))
+		}
+		return ""
+	},
+	visibility: cVisibility,
+	defaultAccess: func(containerType string) string {
+		if containerType == "class_specifier" {
+			return "private"
+		}
+		return "public"
+	},
+}
+
+// ************************************************************************************************
+// rubyGrammar extracts the modules, classes, methods and singleton methods of Ruby files.
+var rubyGrammar = &treeSitterGrammar{
+	language:  ruby.GetLanguage,
+	separator: "::",
+	rules: map[string]treeSitterRule{
+		"module":           {constructType: "module", container: true, typeScope: true},
+		"class":            {constructType: "class", container: true, typeScope: true},
+		"method":           {constructType: "function"},
+		"singleton_method": {constructType: "function"},
+	},
+	suffixes: []string{"end", ";", "="},
+	name: func(node *sitter.Node, src []byte) string {
+		if object := node.ChildByFieldName("object"); node.Type() == "singleton_method" && object != nil {
+			return object.Content(src) + "." + treeSitterFieldName(node, src)
+		}
+		return treeSitterFieldName(node, src)
+	},
+	access: func(node *sitter.Node, src []byte) string {
+		// A bare private, protected or public call changes the access of the next methods
+		if node.Type() == "identifier" {
+			switch text := node.Content(src); text {
+			case "private", "protected", "public":
+				return text
+			}
+		}
+		return ""
+	},
+	visibility:    func(node *sitter.Node, src []byte, inType bool) string { return "" },
+	defaultAccess: func(containerType string) string { return "public" },
+}
+
+// ************************************************************************************************
+// treeSitterGrammars are the grammars by file extension, C headers being parsed as C++.
+var treeSitterGrammars = map[string]*treeSitterGrammar{
+	".c":   cGrammar,
+	".h":   cppGrammar,
+	".cc":  cppGrammar,
+	".cpp": cppGrammar,
+	".cxx": cppGrammar,
+	".hh":  cppGrammar,
+	".hpp": cppGrammar,
+	".hxx": cppGrammar,
+	".rb":  rubyGrammar,
+}
+
+// ************************************************************************************************
+// treeSitterExtractor extracts the declarations of the syntax tree of a file.
+type treeSitterExtractor struct {
+	grammar  *treeSitterGrammar
+	src      []byte
+	comments map[int]treeSitterComment // Comments starting their line, by last row
+	analysis *SourceFile
+}
+
+// ************************************************************************************************
+// parseTreeSitterFile extracts the declarations of a file with the grammar of its extension.
+// Syntax errors are tolerated, the declarations parsed around them being extracted.
+func parseTreeSitterFile(relPath string, src []byte) *SourceFile {
+	module, packageName := treeSitterModule(relPath)
+	analysis := &SourceFile{Path: relPath, Module: module, Package: packageName}
+
+	grammar := treeSitterGrammars[strings.ToLower(path.Ext(relPath))]
+	if grammar == nil {
+		return analysis
+	}
+	root, err := sitter.ParseCtx(context.Background(), src, grammar.language())
+	if err != nil {
+		return analysis
+	}
+
+	e := &treeSitterExtractor{grammar: grammar, src: src, comments: make(map[int]treeSitterComment), analysis: analysis}
+	e.collectComments(root)
+	e.walk(root, treeSitterScope{exported: true, access: "public"})
+	return analysis
+}
+
+// ************************************************************************************************
+// walk extracts the declarations among the children of a node, in source order.
+func (e *treeSitterExtractor) walk(node *sitter.Node, scope treeSitterScope) {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		if access := e.grammar.access(child, e.src); access != "" {
+			scope.access = access
+			continue
+		}
+		if e.grammar.wrappers[child.Type()] {
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if wrapped := child.NamedChild(j); !e.declare(wrapped, child, scope) {
+					e.walk(wrapped, scope)
+				}
+			}
+			continue
+		}
+		if !e.declare(child, child, scope) && child.Type() != "comment" {
+			e.walk(child, scope)
+		}
+	}
+}
+
+// ************************************************************************************************
+// declare extracts the declaration of a node, then the declarations of its body for namespaces
+// and types.
+//
+// Parameters:
+//   - node: The declaration node.
+//   - start: The node starting the declaration text, a wrapper such as a C++ template or node.
+//   - scope: The scope of the declaration.
+//
+// Returns:
+//   - bool: Whether the node is a declaration of the grammar.
+func (e *treeSitterExtractor) declare(node, start *sitter.Node, scope treeSitterScope) bool {
+	rule, ok := e.grammar.rules[node.Type()]
+	if !ok {
+		return false
+	}
+	name := e.grammar.name(node, e.src)
+	if name == "" {
+		return false
+	}
+
+	constructType := rule.constructType
+	if constructType == "function" && scope.inType {
+		constructType = "method"
+	}
+	access := e.grammar.visibility(node, e.src, scope.inType)
+	if access == "" {
+		access = scope.access
+	}
+	qualified := name
+	if scope.owner != "" {
+		qualified = scope.owner + e.grammar.separator + name
+	}
+
+	// The signature runs from the start of the declaration to its body, comments left out
+	bodyField := rule.body
+	if bodyField == "" {
+		bodyField = "body"
+	}
+	end := node.EndByte()
+	body := node.ChildByFieldName(bodyField)
+	if body != nil {
+		end = start.StartByte()
+		for i := 0; i < int(node.ChildCount()); i++ {
+			if child := node.Child(i); child.StartByte() < body.StartByte() && child.Type() != "comment" {
+				end = max(end, child.EndByte())
+			}
+		}
+	}
+	signature := strings.Join(strings.Fields(string(e.src[start.StartByte():end])), " ")
+	for _, suffix := range e.grammar.suffixes {
+		signature = strings.TrimSpace(strings.TrimSuffix(signature, suffix))
+	}
+
+	row := int(start.StartPoint().Row)
+	doc := e.docBefore(row)
+	exported := scope.exported && access == "public"
+	e.analysis.Constructs = append(e.analysis.Constructs, SourceConstruct{
+		Type:      constructType,
+		Name:      qualified,
+		Signature: signature,
+		File:      e.analysis.Path,
+		Line:      row + 1,
+		Summary:   docSummary(doc),
+		Doc:       doc,
+		Exported:  exported,
+	})
+
+	if rule.container && body != nil {
+		members := treeSitterScope{owner: qualified, inType: rule.typeScope, exported: exported, access: "public"}
+		if rule.typeScope {
+			members.access = e.grammar.defaultAccess(node.Type())
+		}
+		e.walk(body, members)
+	}
+	return true
+}
+
+// ************************************************************************************************
+// collectComments indexes the comments of a tree starting their line by their last row, so the
+// comments written right before a declaration can be found.
+func (e *treeSitterExtractor) collectComments(node *sitter.Node) {
+	if node.Type() == "comment" {
+		start := int(node.StartByte())
+		lineStart := strings.LastIndexByte(string(e.src[:start]), '\n') + 1
+		if strings.TrimSpace(string(e.src[lineStart:start])) == "" {
+			row := int(node.StartPoint().Row)
+			e.comments[int(node.EndPoint().Row)] = treeSitterComment{startRow: row, text: node.Content(e.src)}
+		}
+		return
+	}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		e.collectComments(node.NamedChild(i))
+	}
+}
+
+// ************************************************************************************************
+// docBefore returns the text of the comments ending on the lines right before a row, block tags
+// such as @param left out.
+func (e *treeSitterExtractor) docBefore(row int) string {
+	var blocks []string
+	for comment, ok := e.comments[row-1]; ok; comment, ok = e.comments[comment.startRow-1] {
+		blocks = append([]string{comment.text}, blocks...)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.Join(blocks, "\n"), "\n") {
+		line = strings.TrimSuffix(strings.TrimSpace(line), "*/")
+		for _, prefix := range []string{"/**", "/*!", "/*", "///", "//!", "//", "#", "*"} {
+			if strings.HasPrefix(line, prefix) {
+				line = strings.TrimPrefix(line, prefix)
+				break
+			}
+		}
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "@") {
+			break
+		}
+		lines = append(lines, line)
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// ************************************************************************************************
+// treeSitterFieldName returns the text of the name field of a node.
+func treeSitterFieldName(node *sitter.Node, src []byte) string {
+	if name := node.ChildByFieldName("name"); name != nil {
+		return name.Content(src)
+	}
+	return ""
+}
+
+// ************************************************************************************************
+// cDeclarationName returns the name declared by a C or C++ node. Declarations are functions
+// when their declarator is a function declarator; member definitions outside their class, such
+// as "int Client::size() {}", are left out, their declaration being the API.
+func cDeclarationName(node *sitter.Node, src []byte) string {
+	switch node.Type() {
+	case "function_definition", "declaration", "field_declaration":
+		declarator := node.ChildByFieldName("declarator")
+		for declarator != nil && declarator.Type() != "function_declarator" {
+			next := declarator.ChildByFieldName("declarator")
+			if next == nil && declarator.Type() == "reference_declarator" && declarator.NamedChildCount() > 0 {
+				next = declarator.NamedChild(0)
+			}
+			declarator = next
+		}
+		if declarator == nil {
+			return ""
+		}
+		name := declarator.ChildByFieldName("declarator")
+		if name == nil {
+			return ""
+		}
+		switch name.Type() {
+		case "identifier", "field_identifier", "destructor_name", "operator_name":
+			return name.Content(src)
+		}
+		return ""
+	case "struct_specifier", "union_specifier", "enum_specifier", "class_specifier":
+		if node.ChildByFieldName("body") == nil {
+			return ""
+		}
+	case "preproc_def":
+		// Include guards define no value
+		if node.ChildByFieldName("value") == nil {
+			return ""
+		}
+	}
+	return treeSitterFieldName(node, src)
+}
+
+// ************************************************************************************************
+// cVisibility returns "private" for the static functions of C and C++ files and the declarations
+// of anonymous namespaces, only visible in their translation unit.
+func cVisibility(node *sitter.Node, src []byte, inType bool) string {
+	if !inType {
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			if child := node.NamedChild(i); child.Type() == "storage_class_specifier" && child.Content(src) == "static" {
+				return "private"
+			}
+		}
+	}
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		if parent.Type() == "namespace_definition" && parent.ChildByFieldName("name") == nil {
+			return "private"
+		}
+	}
+	return ""
+}
+
+// ************************************************************************************************
+// mergeTreeSitterRules returns the rules of a base grammar overridden by the rules of another.
+func mergeTreeSitterRules(base, overrides map[string]treeSitterRule) map[string]treeSitterRule {
+	rules := make(map[string]treeSitterRule, len(base)+len(overrides))
+	for nodeType, rule := range base {
+		rules[nodeType] = rule
+	}
+	for nodeType, rule := range overrides {
+		rules[nodeType] = rule
+	}
+	return rules
+}
@@ -0,0 +1,18 @@
+//go:build !cgo
+
+// ************************************************************************************************
+// Package parser provides the stand-in of the tree-sitter parser for builds without cgo, such as
+// the static release binaries: the grammars are not built in, so repositories are indexed by
+// another strategy.
+package parser
+
+// ************************************************************************************************
+// TreeSitterAvailable reports whether the tree-sitter grammars are built in, cgo being required.
+const TreeSitterAvailable = false
+
+// ************************************************************************************************
+// parseTreeSitterFile returns the file without constructs, no grammar being built in.
+func parseTreeSitterFile(relPath string, src []byte) *SourceFile {
+	module, packageName := treeSitterModule(relPath)
+	return &SourceFile{Path: relPath, Module: module, Package: packageName}
+}
@@ -0,0 +1,67 @@
+// ************************************************************************************************
+// Package parser provides the tree-sitter parser of the repomix-mcp application, the universal
+// construct extractor of the languages without a native parser: C, C++ and Ruby. Sources
+// are parsed by the tree-sitter grammar of their extension, then the namespaces, modules, types,
+// functions and methods of the syntax tree are extracted with their signatures and the comments
+// written right before them. The grammars are C libraries, so the extractor is only available in
+// builds with cgo enabled.
+package parser
+
+import (
+	"path"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// TreeSitterLanguage describes the sources indexed by the tree_sitter strategy.
+var TreeSitterLanguage = SourceLanguage{
+	Name:           "treesitter",
+	DisplayName:    "Tree-sitter",
+	Strategy:       types.IndexingStrategyTreeSitter,
+	Extensions:     TreeSitterExtensions,
+	SkippedDirs:    []string{"vendor", "node_modules", "third_party", "build", "dist", "test", "tests", "spec"},
+	CommentPrefix:  "//",
+	ConstructTypes: []string{"namespace", "module", "class", "struct", "enum", "macro", "function", "method"},
+	IsTestFile:     isTreeSitterTestFile,
+	ParseFile:      parseTreeSitterFile,
+}
+
+// ************************************************************************************************
+// TreeSitterExtensions are the extensions of the source files parsed by tree-sitter grammars.
+var TreeSitterExtensions = []string{".c", ".h", ".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx", ".rb"}
+
+// ************************************************************************************************
+// NewTreeSitterParser creates the parser of repositories in languages parsed by tree-sitter
+// grammars. Check TreeSitterAvailable before indexing with it.
+//
+// Example usage:
+//
+//	if parser.TreeSitterAvailable {
+//		repoIndex, err := parser.NewTreeSitterParser().ParseRepository("engine", "/path/to/engine", config)
+//	}
+func NewTreeSitterParser() *SourceParser {
+	return NewSourceParser(TreeSitterLanguage)
+}
+
+// ************************************************************************************************
+// treeSitterModule returns the module and the package of a file: its path without extension and
+// its directory, the module itself for files at the root.
+func treeSitterModule(relPath string) (string, string) {
+	module := strings.TrimSuffix(relPath, path.Ext(relPath))
+	if dir := path.Dir(relPath); dir != "." {
+		return module, dir
+	}
+	return module, module
+}
+
+// ************************************************************************************************
+// isTreeSitterTestFile reports whether a file holds tests, e.g. "parser_test.c", "test_parser.c"
+// or "invoice_spec.rb".
+func isTreeSitterTestFile(relPath string) bool {
+	name := path.Base(relPath)
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	return strings.HasPrefix(name, "test_") || strings.HasSuffix(stem, "_test") ||
+		strings.HasSuffix(stem, "_unittest") || strings.HasSuffix(stem, "_spec")
+}
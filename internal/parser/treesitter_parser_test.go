@@ -0,0 +1,214 @@
+//go:build cgo
+
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+func TestParseTreeSitterFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		relPath  string
+		src      string
+		expected []SourceConstruct
+	}{
+		{
+			name:    "C",
+			relPath: "src/buffer.c",
+			src: `#include "buffer.h"
+#define BUFFER_H
+#define BUFFER_MAX(a, b) ((a) > (b) ? (a) : (b))
+
+/**
+ * Grows a buffer.
+ * @param buf The buffer.
+ */
+int *buffer_grow(struct buffer *buf, size_t size) {
+    return NULL;
+}
+
+// Internal helper.
+static void reset(struct buffer *buf) {}
+
+size_t buffer_len(const struct buffer *buf);
+
+struct buffer {
+    char *data;
+};
+
+enum mode { READ, WRITE };
+
+int counter = 0;
+`,
+			expected: []SourceConstruct{
+				{Type: "macro", Name: "BUFFER_MAX", Signature: "#define BUFFER_MAX(a, b)", Line: 3, Exported: true},
+				{Type: "function", Name: "buffer_grow", Signature: "int *buffer_grow(struct buffer *buf, size_t size)", Line: 9, Exported: true, Summary: "Grows a buffer."},
+				{Type: "function", Name: "reset", Signature: "static void reset(struct buffer *buf)", Line: 14, Summary: "Internal helper."},
+				{Type: "function", Name: "buffer_len", Signature: "size_t buffer_len(const struct buffer *buf)", Line: 16, Exported: true},
+				{Type: "struct", Name: "buffer", Signature: "struct buffer", Line: 18, Exported: true},
+				{Type: "enum", Name: "mode", Signature: "enum mode", Line: 22, Exported: true},
+			},
+		},
+		{
+			name:    "C++",
+			relPath: "include/net/client.hpp",
+			src: `#ifndef NET_CLIENT_HPP
+#define NET_CLIENT_HPP
+
+namespace net {
+
+/// A client.
+template <typename T>
+class Client : public Base {
+public:
+    explicit Client(int port);
+    /// Sends data.
+    virtual void send(const T& data) override;
+    int size() const { return 0; }
+private:
+    void reset();
+};
+
+struct Point {
+    double norm() const;
+};
+
+namespace {
+void hidden() {}
+}
+
+int Client::size2() { return 1; }
+
+}  // namespace net
+
+#endif
+`,
+			expected: []SourceConstruct{
+				{Type: "namespace", Name: "net", Signature: "namespace net", Line: 4, Exported: true},
+				{Type: "class", Name: "net::Client", Signature: "template <typename T> class Client : public Base", Line: 7, Exported: true, Summary: "A client."},
+				{Type: "method", Name: "net::Client::Client", Signature: "explicit Client(int port)", Line: 10, Exported: true},
+				{Type: "method", Name: "net::Client::send", Signature: "virtual void send(const T& data) override", Line: 12, Exported: true, Summary: "Sends data."},
+				{Type: "method", Name: "net::Client::size", Signature: "int size() const", Line: 13, Exported: true},
+				{Type: "method", Name: "net::Client::reset", Signature: "void reset()", Line: 15},
+				{Type: "struct", Name: "net::Point", Signature: "struct Point", Line: 18, Exported: true},
+				{Type: "method", Name: "net::Point::norm", Signature: "double norm() const", Line: 19, Exported: true},
+				{Type: "function", Name: "net::hidden", Signature: "void hidden()", Line: 23},
+			},
+		},
+		{
+			name:    "Ruby",
+			relPath: "lib/billing/invoice.rb",
+			src: `# Billing helpers.
+module Billing
+  # An invoice.
+  #
+  # @example Invoice.new(10)
+  class Invoice < Base
+    attr_reader :total
+
+    # Creates one.
+    def initialize(total)
+      @total = total
+    end
+
+    def self.build(x) = new(x)
+
+    private
+
+    def reset; end
+  end
+end
+`,
+			expected: []SourceConstruct{
+				{Type: "module", Name: "Billing", Signature: "module Billing", Line: 2, Exported: true, Summary: "Billing helpers."},
+				{Type: "class", Name: "Billing::Invoice", Signature: "class Invoice < Base", Line: 6, Exported: true, Summary: "An invoice."},
+				{Type: "method", Name: "Billing::Invoice::initialize", Signature: "def initialize(total)", Line: 10, Exported: true, Summary: "Creates one."},
+				{Type: "method", Name: "Billing::Invoice::self.build", Signature: "def self.build(x)", Line: 14, Exported: true},
+				{Type: "method", Name: "Billing::Invoice::reset", Signature: "def reset", Line: 18},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := parseTreeSitterFile(tt.relPath, []byte(tt.src))
+			if len(analysis.Constructs) != len(tt.expected) {
+				for _, construct := range analysis.Constructs {
+					t.Logf("%s %s at line %d: %s", construct.Type, construct.Name, construct.Line, construct.Signature)
+				}
+				t.Fatalf("Expected %d constructs, got %d", len(tt.expected), len(analysis.Constructs))
+			}
+			for i, want := range tt.expected {
+				got := analysis.Constructs[i]
+				if got.Type != want.Type || got.Name != want.Name || got.Signature != want.Signature ||
+					got.Line != want.Line || got.Exported != want.Exported || got.Summary != want.Summary {
+					t.Errorf("Construct %d: expected %+v, got %+v", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTreeSitterParser_ParseRepository(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"Makefile":            "all:\n\tcc -o app src/main.c\n",
+		"src/main.c":          "int main(void) { return 0; }\n",
+		"src/util.c":          "/* TODO: faster */\nint util_sum(int a, int b) { return a + b; }\nstatic int cache(void) { return 0; }\n",
+		"lib/report.rb":       "class Report\n  def render; end\nend\n",
+		"src/util_test.c":     "void test_sum(void) {}\n",
+		"tests/check.c":       "void check(void) {}\n",
+		"vendor/zlib/zlib.c":  "int deflate(void) { return 0; }\n",
+		"docs/guide.md":       "# Guide\n",
+		"spec/report_spec.rb": "describe Report do\nend\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	repoIndex, err := NewTreeSitterParser().ParseRepository("app", tempDir, types.IndexingConfig{})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+
+	if repoIndex.Metadata["indexer_type"] != "tree_sitter" {
+		t.Errorf("Expected indexer_type 'tree_sitter', got '%v'", repoIndex.Metadata["indexer_type"])
+	}
+	if repoIndex.Metadata["file_count"] != 3 {
+		t.Errorf("Expected file_count 3, got '%v'", repoIndex.Metadata["file_count"])
+	}
+
+	xmlFile, exists := repoIndex.Files[".repomix.xml"]
+	if !exists {
+		t.Fatal("Expected .repomix.xml file to be generated")
+	}
+	for _, pattern := range []string{
+		`<file path="src/util.c" module="src/util">`,
+		`<package name="src">`,
+		"int util_sum(int a, int b)  // src/util.c:2",
+		"int main(void)",
+		"class Report",
+		"def render",
+	} {
+		if !strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content to contain '%s'", pattern)
+		}
+	}
+	for _, pattern := range []string{"test_sum", "check", "deflate", "Guide", "describe", "cache"} {
+		if strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content not to contain '%s'", pattern)
+		}
+	}
+}
@@ -0,0 +1,818 @@
+// ************************************************************************************************
+// Package parser provides the TypeScript and JavaScript source parser of the repomix-mcp
+// application. Sources are split into tokens, strings, template literals, regular expressions
+// and JSX elements being kept whole, then the top-level statements are scanned for declarations:
+// functions, classes with their members, interfaces, type aliases, enums, namespaces and
+// variables. A declaration is exported when it carries the export keyword or is listed by an
+// export statement or a CommonJS module.exports assignment.
+package parser
+
+import (
+	"path"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// TypeScriptLanguage describes the TypeScript and JavaScript sources indexed by the
+// typescript_native strategy.
+var TypeScriptLanguage = SourceLanguage{
+	Name:           "typescript",
+	DisplayName:    "TypeScript/JavaScript",
+	Strategy:       types.IndexingStrategyTypeScriptNative,
+	Extensions:     []string{".ts", ".tsx", ".mts", ".cts", ".js", ".jsx", ".mjs", ".cjs"},
+	SkippedDirs:    []string{"node_modules", "dist", "build", "out", "coverage", "__tests__", "__mocks__"},
+	CommentPrefix:  "//",
+	ConstructTypes: []string{"namespace", "interface", "type", "enum", "class", "function", "variable", "method", "property"},
+	IsTestFile:     isScriptTestFile,
+	ParseFile:      ParseScriptFile,
+}
+
+// ************************************************************************************************
+// NodeProjectFiles are the files at the root of a repository marking a Node project.
+var NodeProjectFiles = []string{"package.json", "tsconfig.json"}
+
+// ************************************************************************************************
+// NewTypeScriptParser creates the parser of TypeScript and JavaScript repositories.
+//
+// Example usage:
+//
+//	repoIndex, err := parser.NewTypeScriptParser().ParseRepository("web", "/path/to/web", config)
+func NewTypeScriptParser() *SourceParser {
+	return NewSourceParser(TypeScriptLanguage)
+}
+
+// ************************************************************************************************
+// scriptKeywordsBeforeExpression are the keywords after which "/" starts a regular expression
+// and "<" a JSX element.
+var scriptKeywordsBeforeExpression = map[string]bool{
+	"return": true, "typeof": true, "case": true, "do": true, "else": true, "in": true, "of": true,
+	"new": true, "delete": true, "void": true, "throw": true, "yield": true, "await": true,
+	"instanceof": true, "default": true,
+}
+
+// ************************************************************************************************
+// scriptFileDocTags are the JSDoc tags marking the documentation of a whole file.
+var scriptFileDocTags = []string{"@fileoverview", "@file", "@module", "@packageDocumentation"}
+
+// ************************************************************************************************
+// ParseScriptFile extracts the declarations of a TypeScript or JavaScript file. Only top-level
+// declarations, namespace members and class or interface members are extracted, declarations in
+// function bodies being implementation details. Private, protected and "#" class members are not
+// exported.
+//
+// Example usage:
+//
+//	analysis := parser.ParseScriptFile("src/api/client.ts", src)
+func ParseScriptFile(relPath string, src []byte) *SourceFile {
+	module := scriptModuleName(relPath)
+	packageName := path.Dir(relPath)
+	if packageName == "." {
+		packageName = module
+	}
+
+	jsx := !strings.HasSuffix(relPath, ".ts") && !strings.HasSuffix(relPath, ".mts") && !strings.HasSuffix(relPath, ".cts")
+	tokens, fileDoc := scanScript(string(src), jsx)
+	p := &scriptParser{
+		tokenStream: newTokenStream(tokens),
+		analysis:    &SourceFile{Path: relPath, Module: module, Package: packageName, Doc: fileDoc},
+		exported:    make(map[string]bool),
+	}
+	p.separateMembers = true
+	p.parseStatements(0, len(tokens), "", false)
+
+	// Declarations exported by name after being declared
+	for i := range p.analysis.Constructs {
+		construct := &p.analysis.Constructs[i]
+		if !construct.Exported && p.exported[construct.Name] && !strings.Contains(construct.Name, ".") {
+			construct.Exported = true
+		}
+	}
+	return p.analysis
+}
+
+// ************************************************************************************************
+// scriptParser extracts the declarations of the tokens of a file.
+type scriptParser struct {
+	tokenStream
+	analysis *SourceFile
+	exported map[string]bool // Names listed by export statements
+}
+
+// ************************************************************************************************
+// scanScript splits TypeScript or JavaScript source into tokens, comments left out.
+//
+// Returns:
+//   - []sourceToken: The tokens.
+//   - string: The file documentation, from a JSDoc comment tagged @file, @fileoverview, @module
+//     or @packageDocumentation.
+func scanScript(src string, jsx bool) ([]sourceToken, string) {
+	var tokens []sourceToken
+	var doc, fileDoc string
+	line := 1
+	newline := false
+	i := 0
+	if strings.HasPrefix(src, "#!") {
+		i = strings.IndexByte(src, '\n')
+		if i < 0 {
+			i = len(src)
+		}
+	}
+
+	// expressionExpected reports whether the previous token ends before an expression
+	expressionExpected := func() bool {
+		if len(tokens) == 0 {
+			return true
+		}
+		previous := tokens[len(tokens)-1]
+		if previous.literal {
+			return false
+		}
+		if isIdentifierStart(previous.text[0]) || previous.text[0] >= '0' && previous.text[0] <= '9' {
+			return scriptKeywordsBeforeExpression[previous.text]
+		}
+		return !strings.Contains(")]}", previous.text)
+	}
+
+	for i < len(src) {
+		c := src[i]
+		start, startLine := i, line
+
+		switch {
+		case c == '\n':
+			line++
+			newline = true
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i + 4
+			}
+			comment := src[i:end]
+			line += strings.Count(comment, "\n")
+			i = end
+			if strings.HasPrefix(comment, "/**") && comment != "/**/" {
+				if fileDoc == "" && len(tokens) == 0 && hasScriptFileDocTag(comment) {
+					fileDoc = scriptDocText(comment, true)
+				} else {
+					doc = scriptDocText(comment, false)
+				}
+			}
+			continue
+		case c == '"' || c == '\'':
+			i = skipScriptString(src, i)
+		case c == '`':
+			i = skipScriptTemplate(src, i)
+		case c == '/' && expressionExpected():
+			i = skipScriptRegExp(src, i)
+		case c == '<' && jsx && expressionExpected() && i+1 < len(src) && (src[i+1] == '>' || isIdentifierStart(src[i+1])):
+			i = skipScriptJSX(src, i)
+		case isIdentifierStart(c) || c == '#' || c >= '0' && c <= '9':
+			i++
+			for i < len(src) && (isIdentifierStart(src[i]) || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			if c >= '0' && c <= '9' {
+				for i < len(src) && (src[i] == '.' || isIdentifierStart(src[i]) || src[i] >= '0' && src[i] <= '9') {
+					i++
+				}
+			}
+		case strings.HasPrefix(src[i:], "=>") || strings.HasPrefix(src[i:], "?."):
+			i += 2
+		case strings.HasPrefix(src[i:], "..."):
+			i += 3
+		default:
+			i++
+		}
+
+		text := src[start:i]
+		line += strings.Count(text, "\n")
+		literal := c == '"' || c == '\'' || c == '`' || c == '/' && len(text) > 1 || c == '<' && len(text) > 1
+		tokens = append(tokens, sourceToken{text: text, literal: literal, start: start, end: i, line: startLine, newline: newline, doc: doc})
+		doc, newline = "", false
+	}
+	return tokens, fileDoc
+}
+
+// ************************************************************************************************
+// parseStatements extracts the declarations of the statements between two token indexes.
+// Nested blocks are skipped, except class, interface and namespace bodies.
+func (p *scriptParser) parseStatements(lo, hi int, prefix string, ambient bool) {
+	for i := lo; i < hi; {
+		if i == lo || p.tokens[i].newline || p.is(i-1, ";") || p.is(i-1, "}") {
+			if next := p.parseDeclaration(i, hi, prefix, ambient); next > i {
+				i = next
+				continue
+			}
+		}
+		i = p.skip(i)
+	}
+}
+
+// ************************************************************************************************
+// parseDeclaration extracts the declaration starting a statement, if any.
+//
+// Returns:
+//   - int: The index of the token after the declaration header, i when there is no declaration.
+func (p *scriptParser) parseDeclaration(i, hi int, prefix string, ambient bool) int {
+	start := i
+	doc := p.tokens[i].doc
+	annotations, i := p.parseDecorators(i, hi)
+	if i < hi && doc == "" {
+		doc = p.tokens[i].doc
+	}
+
+	exported, explicit, isDefault := ambient, false, false
+	for i < hi {
+		switch {
+		case p.is(i, "export"):
+			exported, explicit = true, true
+		case p.is(i, "default") && i > start && p.is(i-1, "export"):
+			isDefault = true
+		case p.is(i, "declare") && i+1 < hi && p.isWord(i+1):
+		default:
+			return p.parseDeclarationKeyword(start, i, hi, prefix, annotations, doc, exported, explicit, isDefault)
+		}
+		i++
+	}
+	return start
+}
+
+// ************************************************************************************************
+// parseDeclarationKeyword extracts the declaration whose signature starts at sigStart, after
+// the decorators and export modifiers of the statement starting at start.
+func (p *scriptParser) parseDeclarationKeyword(start, sigStart, hi int, prefix string, annotations []string, doc string, exported, explicit, isDefault bool) int {
+	i := sigStart
+	for p.is(i, "async") || p.is(i, "abstract") {
+		i++
+	}
+	if i >= hi {
+		return start
+	}
+	add := func(constructType, name string, end int) {
+		p.analysis.Constructs = append(p.analysis.Constructs, SourceConstruct{
+			Type:        constructType,
+			Name:        prefix + name,
+			Signature:   p.join(sigStart, end),
+			Annotations: annotations,
+			File:        p.analysis.Path,
+			Line:        p.tokens[sigStart].line,
+			Summary:     docSummary(doc),
+			Doc:         doc,
+			Exported:    exported,
+		})
+	}
+	name := func(j int) string {
+		if j < hi && p.isWord(j) && !p.is(j, "extends") && !p.is(j, "implements") {
+			return p.tokens[j].text
+		}
+		if isDefault {
+			return "default"
+		}
+		return ""
+	}
+
+	switch {
+	case p.is(i, "function"):
+		i++
+		if p.is(i, "*") {
+			i++
+		}
+		functionName := name(i)
+		if functionName == "" {
+			return start
+		}
+		body, end := p.findBody(i, hi)
+		add("function", functionName, end)
+		return p.after(body, end)
+
+	case p.is(i, "class") || p.is(i, "interface"):
+		constructType := p.tokens[i].text
+		className := name(i + 1)
+		if className == "" {
+			return start
+		}
+		body, end := p.findBody(i+1, hi)
+		add(constructType, className, end)
+		if body >= 0 && p.match[body] > body {
+			p.parseMembers(body+1, p.match[body], prefix+className, exported, constructType == "interface")
+		}
+		return p.after(body, end)
+
+	case p.is(i, "enum") || p.is(i, "const") && p.is(i+1, "enum"):
+		if p.is(i, "const") {
+			i++
+		}
+		enumName := name(i + 1)
+		if enumName == "" {
+			return start
+		}
+		body, end := p.findBody(i+1, hi)
+		if body >= 0 && p.match[body] > body {
+			end = p.match[body] + 1
+		}
+		add("enum", enumName, end)
+		return end
+
+	case p.is(i, "type") && i+2 < hi && p.isWord(i+1) && (p.is(i+2, "=") || p.is(i+2, "<")):
+		end := p.endOfStatement(i+1, hi)
+		add("type", p.tokens[i+1].text, end)
+		return end
+
+	case (p.is(i, "namespace") || p.is(i, "module")) && i+1 < hi && (p.isWord(i+1) || p.tokens[i+1].literal):
+		body, end := p.findBody(i+1, hi)
+		namespaceName := strings.Trim(p.join(i+1, end), "\"'")
+		if body < 0 || p.match[body] < body {
+			return start
+		}
+		// Everything declared in an ambient module, declare module "name" {...}, is exported
+		ambientModule := p.tokens[i+1].literal
+		exported = exported || ambientModule
+		add("namespace", namespaceName, end)
+		p.parseStatements(body+1, p.match[body], prefix+namespaceName+".", ambientModule)
+		return p.match[body] + 1
+
+	case p.is(i, "const") || p.is(i, "let") || p.is(i, "var"):
+		if !p.isWord(i + 1) {
+			return start // Destructuring
+		}
+		return p.parseVariable(i, hi, sigStart, add)
+
+	case explicit && !isDefault && p.is(i, "{"):
+		// Export list: export { a, b as c }
+		p.markExported(i+1, p.matchOr(i, hi))
+		return p.matchOr(i, hi) + 1
+
+	case explicit && isDefault && p.isWord(i):
+		// export default name
+		p.exported[p.tokens[i].text] = true
+		return i + 1
+
+	case explicit && p.is(i, "=") && p.isWord(i+1):
+		// export = name
+		p.exported[p.tokens[i+1].text] = true
+		return i + 2
+
+	case p.is(i, "module") && p.is(i+1, ".") && p.is(i+2, "exports") || p.is(i, "exports") && p.is(i+1, "."):
+		p.parseCommonJSExports(i, hi)
+		return start
+	}
+	return start
+}
+
+// ************************************************************************************************
+// parseVariable extracts a variable declaration, a function when it is initialized with an
+// arrow function or a function expression.
+//
+// Returns:
+//   - int: The index of the token after the declaration header.
+func (p *scriptParser) parseVariable(i, hi, sigStart int, add func(constructType, name string, end int)) int {
+	variableName := p.tokens[i+1].text
+	end := p.endOfStatement(i+1, hi)
+	assign := -1
+	for j := i + 2; j < end; j = p.skip(j) {
+		if p.is(j, "=") {
+			assign = j
+			break
+		}
+	}
+	if assign < 0 {
+		add("variable", variableName, end)
+		return end
+	}
+
+	// Arrow functions and function expressions are functions
+	value := assign + 1
+	if p.is(value, "async") {
+		value++
+	}
+	switch {
+	case p.is(value, "function"):
+		_, header := p.findBody(value+1, hi)
+		add("function", variableName, header)
+		return header
+	case p.isWord(value) && p.is(value+1, "=>"):
+		add("function", variableName, value+2)
+		return value + 2
+	case p.is(value, "(") || p.is(value, "<"):
+		for j := value; j < end; j = p.skip(j) {
+			if p.is(j, "=>") {
+				add("function", variableName, j+1)
+				return j + 1
+			}
+			if p.is(j, "=") || p.is(j, ",") || p.is(j, ";") {
+				break
+			}
+		}
+	}
+
+	// Short literal values are kept, e.g. const VERSION = "1.0"
+	if end == value+1 && !p.is(i+2, ":") {
+		add("variable", variableName, end)
+	} else {
+		add("variable", variableName, assign)
+	}
+	return assign + 1
+}
+
+// ************************************************************************************************
+// parseMembers extracts the methods and properties of a class or interface body.
+func (p *scriptParser) parseMembers(lo, hi int, owner string, ownerExported, isInterface bool) {
+	for i := lo; i < hi; {
+		if !(i == lo || p.tokens[i].newline || p.is(i-1, ";") || p.is(i-1, "}") || p.is(i-1, ",")) {
+			i = p.skip(i)
+			continue
+		}
+
+		doc := p.tokens[i].doc
+		annotations, sigStart := p.parseDecorators(i, hi)
+		if sigStart < hi && doc == "" {
+			doc = p.tokens[sigStart].doc
+		}
+
+		// Modifiers are member names when followed by what follows a name
+		j, public := sigStart, true
+		for j+1 < hi && isScriptMemberModifier(p.tokens[j].text) && !strings.Contains("(:=?;!<,}", p.tokens[j+1].text) && !p.tokens[j+1].newline {
+			if p.is(j, "private") || p.is(j, "protected") {
+				public = false
+			}
+			j++
+		}
+		if p.is(j, "*") {
+			j++
+		}
+		if j >= hi || p.is(j, "{") || !p.isWord(j) && !p.tokens[j].literal {
+			i = p.skip(max(j, i))
+			continue // Static blocks, call and index signatures
+		}
+
+		memberName := strings.Trim(p.tokens[j].text, "\"'")
+		if strings.HasPrefix(memberName, "#") {
+			public = false
+		}
+		j++
+		if p.is(j, "?") || p.is(j, "!") {
+			j++
+		}
+
+		constructType := "property"
+		end := p.endOfStatement(j, hi)
+		next := end
+		if p.is(j, "(") || p.is(j, "<") {
+			var body int
+			constructType = "method"
+			body, end = p.findBody(j, hi)
+			next = p.after(body, end)
+		} else {
+			for k := j; k < end; k = p.skip(k) {
+				if p.is(k, "=") {
+					end, next = k, k+1
+					break
+				}
+			}
+		}
+		p.analysis.Constructs = append(p.analysis.Constructs, SourceConstruct{
+			Type:        constructType,
+			Name:        owner + "." + memberName,
+			Signature:   p.join(sigStart, end),
+			Annotations: annotations,
+			File:        p.analysis.Path,
+			Line:        p.tokens[sigStart].line,
+			Summary:     docSummary(doc),
+			Doc:         doc,
+			Exported:    ownerExported && (public || isInterface),
+		})
+		i = max(next, i+1)
+	}
+}
+
+// ************************************************************************************************
+// parseDecorators reads the decorators starting at a token, e.g. @Component({...}).
+//
+// Returns:
+//   - []string: The decorators, each on a single line.
+//   - int: The index of the token after the decorators.
+func (p *scriptParser) parseDecorators(i, hi int) ([]string, int) {
+	var annotations []string
+	for p.is(i, "@") && i+1 < hi && p.isWord(i+1) {
+		start := i
+		i += 2
+		for p.is(i, ".") && p.isWord(i+1) {
+			i += 2
+		}
+		if p.is(i, "(") && !p.tokens[i].newline {
+			i = p.matchOr(i, hi) + 1
+		}
+		annotations = append(annotations, p.join(start, i))
+	}
+	return annotations, i
+}
+
+// ************************************************************************************************
+// parseCommonJSExports marks the names exported by module.exports and exports assignments.
+func (p *scriptParser) parseCommonJSExports(i, hi int) {
+	if p.is(i, "module") {
+		i += 2
+	}
+	switch {
+	case p.is(i+1, ".") && p.isWord(i+2):
+		// exports.name = ... or module.exports.name = ...
+		p.exported[p.tokens[i+2].text] = true
+	case p.is(i+1, "=") && p.is(i+2, "{"):
+		p.markExported(i+3, p.matchOr(i+2, hi))
+	case p.is(i+1, "=") && p.isWord(i+2):
+		p.exported[p.tokens[i+2].text] = true
+	}
+}
+
+// ************************************************************************************************
+// markExported marks the local names of an export list or an exported object literal.
+func (p *scriptParser) markExported(lo, hi int) {
+	for i := lo; i < hi; i = p.skip(i) {
+		if p.isWord(i) && (i == lo || p.is(i-1, ",") || p.is(i-1, ":") || p.is(i-1, "{")) {
+			p.exported[p.tokens[i].text] = true
+		}
+	}
+}
+
+// ************************************************************************************************
+// findBody finds the body of a function, class, interface, enum or namespace declaration whose
+// header continues at a token. Braces in type positions, such as object return types, are part
+// of the header.
+//
+// Returns:
+//   - int: The index of the opening brace of the body, -1 for a declaration without body.
+//   - int: The index of the token ending the header.
+func (p *scriptParser) findBody(i, hi int) (int, int) {
+	for j := i; j < hi; j = p.skip(j) {
+		if j > i && p.tokens[j].newline && !p.continues(j) {
+			return -1, j
+		}
+		switch {
+		case p.is(j, ";"):
+			return -1, j
+		case p.is(j, "{"):
+			previous := p.tokens[j-1].text
+			if !strings.Contains(" : | & => < , ( [ ? extends keyof ", " "+previous+" ") || p.tokens[j-1].literal {
+				return j, j
+			}
+		}
+	}
+	return -1, hi
+}
+
+// ************************************************************************************************
+// endOfStatement returns the index of the token ending a statement: a semicolon, or the first
+// token of the next line when the statement cannot continue on it.
+func (p *scriptParser) endOfStatement(i, hi int) int {
+	for j := i; j < hi; j = p.skip(j) {
+		if j > i && p.tokens[j].newline && !p.continues(j) {
+			return j
+		}
+		if p.is(j, ";") || p.is(j, ",") && (j+1 >= hi || p.tokens[j+1].newline) {
+			return j
+		}
+	}
+	return hi
+}
+
+// ************************************************************************************************
+// continues reports whether the token starting a line continues the statement of the previous
+// line, the previous token or itself being an operator.
+func (p *scriptParser) continues(j int) bool {
+	previous, current := p.tokens[j-1], p.tokens[j]
+	if !previous.literal && strings.Contains(" = | & , => : ? < + - * / . ?. ( [ { extends implements keyof ", " "+previous.text+" ") {
+		return true
+	}
+	return !current.literal && strings.Contains(" | & => ? : . ?. = extends implements ", " "+current.text+" ")
+}
+
+// ************************************************************************************************
+// after returns the index following a declaration: after its body, or after its header.
+func (p *scriptParser) after(body, end int) int {
+	if body >= 0 {
+		return p.skip(body)
+	}
+	return end
+}
+
+// ************************************************************************************************
+// skipScriptString returns the offset after the quoted string starting at an offset. Strings
+// end at the end of the line when unterminated.
+func skipScriptString(src string, i int) int {
+	quote := src[i]
+	for i++; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		case '\n':
+			return i
+		}
+	}
+	return len(src)
+}
+
+// ************************************************************************************************
+// skipScriptTemplate returns the offset after the template literal starting at an offset,
+// ${...} substitutions included.
+func skipScriptTemplate(src string, i int) int {
+	for i++; i < len(src); i++ {
+		switch {
+		case src[i] == '\\':
+			i++
+		case src[i] == '`':
+			return i + 1
+		case strings.HasPrefix(src[i:], "${"):
+			i = skipScriptExpression(src, i+2) - 1
+		}
+	}
+	return len(src)
+}
+
+// ************************************************************************************************
+// skipScriptExpression returns the offset after the closing brace ending the expression of a
+// template substitution or a JSX attribute, nested braces, strings and comments skipped.
+func skipScriptExpression(src string, i int) int {
+	depth := 0
+	for i < len(src) {
+		switch c := src[i]; {
+		case c == '"' || c == '\'':
+			i = skipScriptString(src, i)
+			continue
+		case c == '`':
+			i = skipScriptTemplate(src, i)
+			continue
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			if end := strings.Index(src[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(src)
+			}
+			continue
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth == 0 {
+				return i + 1
+			}
+			depth--
+		}
+		i++
+	}
+	return len(src)
+}
+
+// ************************************************************************************************
+// skipScriptRegExp returns the offset after the regular expression literal starting at an
+// offset, flags included.
+func skipScriptRegExp(src string, i int) int {
+	inClass := false
+	for i++; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '\n':
+			return i
+		case '/':
+			if !inClass {
+				for i++; i < len(src) && isIdentifierStart(src[i]); i++ {
+				}
+				return i
+			}
+		}
+	}
+	return len(src)
+}
+
+// ************************************************************************************************
+// skipScriptJSX returns the offset after the JSX element starting at an offset, nested elements
+// included. Text content is skipped as is, attribute values and {...} children as expressions.
+func skipScriptJSX(src string, i int) int {
+	depth := 0
+	for i < len(src) {
+		switch c := src[i]; {
+		case c == '{':
+			i = skipScriptExpression(src, i+1)
+			continue
+		case c == '<':
+			closing := i+1 < len(src) && src[i+1] == '/'
+			// Read the tag up to its end, skipping attribute values
+			for i++; i < len(src) && src[i] != '>'; i++ {
+				switch src[i] {
+				case '"', '\'':
+					i = skipScriptString(src, i) - 1
+				case '{':
+					i = skipScriptExpression(src, i+1) - 1
+				}
+			}
+			selfClosing := i < len(src) && src[i-1] == '/'
+			i++
+			switch {
+			case closing:
+				depth--
+			case !selfClosing:
+				depth++
+			}
+			if depth <= 0 {
+				return min(i, len(src))
+			}
+			continue
+		}
+		i++
+	}
+	return len(src)
+}
+
+// ************************************************************************************************
+// scriptDocText returns the description of a JSDoc comment, without its block tags. The
+// description written after the file documentation tags is kept when fileDoc is set.
+func scriptDocText(comment string, fileDoc bool) string {
+	comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/**"), "*/")
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		if strings.HasPrefix(line, "@") {
+			if !fileDoc {
+				break
+			}
+			for _, tag := range scriptFileDocTags {
+				if rest, ok := strings.CutPrefix(line, tag); ok && tag != "@module" {
+					lines = append(lines, strings.TrimSpace(rest))
+				}
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// ************************************************************************************************
+// hasScriptFileDocTag reports whether a JSDoc comment documents a whole file.
+func hasScriptFileDocTag(comment string) bool {
+	for _, tag := range scriptFileDocTags {
+		if strings.Contains(comment, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ************************************************************************************************
+// isScriptMemberModifier reports whether a word is a modifier of class or interface members.
+func isScriptMemberModifier(word string) bool {
+	switch word {
+	case "public", "private", "protected", "static", "readonly", "abstract", "async", "override",
+		"declare", "accessor", "get", "set":
+		return true
+	}
+	return false
+}
+
+// ************************************************************************************************
+// isScriptTestFile reports whether a file holds tests or stories, e.g. "api.test.ts".
+func isScriptTestFile(relPath string) bool {
+	name := path.Base(relPath)
+	for _, marker := range []string{".test.", ".spec.", ".stories.", ".min.js"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// ************************************************************************************************
+// scriptModuleName returns the module path of a file, without extension: "src/api/index.ts"
+// is "src/api" and "src/types.d.ts" is "src/types".
+func scriptModuleName(relPath string) string {
+	module := strings.TrimSuffix(relPath, path.Ext(relPath))
+	module = strings.TrimSuffix(module, ".d")
+	if path.Base(module) == "index" && path.Dir(module) != "." {
+		return path.Dir(module)
+	}
+	return module
+}
@@ -0,0 +1,223 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+func TestParseScriptFile(t *testing.T) {
+	src := `/**
+ * @file HTTP client of the API.
+ */
+import { Agent } from "http";
+
+const pattern = /[/{]+/g;
+const template = ` + "`" + `{ ${ { a: "}" }.a } ` + "`" + `;
+
+/** Options of a request. */
+export interface RequestOptions<T> extends Base {
+  url: string;
+  retries?: number
+  transform(body: string): T
+}
+
+export type Method = "GET" | "POST";
+
+export type Handler =
+  | ((req: Request) => void)
+  | null;
+
+export const enum Level { Low, High = 2 }
+
+/**
+ * Sends requests.
+ * @param agent The HTTP agent.
+ */
+@Injectable({ scope: "request" })
+export class Client<T = unknown> extends BaseClient implements Closeable {
+  private agent: Agent;
+  static readonly VERSION = "1.0";
+  #secret = 1;
+
+  constructor(agent: Agent) {
+    super();
+    this.agent = agent;
+  }
+
+  /** Fetches a resource. */
+  async get(url: string, options?: { timeout: number }): Promise<{ body: string }> {
+    if (url) { return { body: "" }; }
+    function hidden() {}
+    return { body: url };
+  }
+
+  protected close(): void {}
+
+  get size(): number { return 0 }
+}
+
+export default function createClient(agent = new Agent()): Client {
+  return new Client(agent);
+}
+
+export async function* stream(): AsyncGenerator<string> {}
+
+export const sum = (a: number, b: number): number => a + b;
+export const VERSION = "2.0";
+const internal = async x => x;
+
+function helper() { return 1 }
+export { helper };
+
+declare module "express" {
+  interface Request { user: string }
+}
+`
+	analysis := ParseScriptFile("src/api/index.ts", []byte(src))
+
+	if analysis.Module != "src/api" || analysis.Package != "src/api" {
+		t.Errorf("Expected module src/api in package src/api, got %s in %s", analysis.Module, analysis.Package)
+	}
+	if analysis.Doc != "HTTP client of the API." {
+		t.Errorf("Unexpected file documentation %q", analysis.Doc)
+	}
+
+	expected := []SourceConstruct{
+		{Type: "variable", Name: "pattern", Signature: "const pattern = /[/{]+/g", Line: 6},
+		{Type: "variable", Name: "template", Signature: "const template = `{ ${ { a: \"}\" }.a } `", Line: 7},
+		{Type: "interface", Name: "RequestOptions", Signature: "interface RequestOptions<T> extends Base", Line: 10, Exported: true, Summary: "Options of a request."},
+		{Type: "property", Name: "RequestOptions.url", Signature: "url: string", Line: 11, Exported: true},
+		{Type: "property", Name: "RequestOptions.retries", Signature: "retries?: number", Line: 12, Exported: true},
+		{Type: "method", Name: "RequestOptions.transform", Signature: "transform(body: string): T", Line: 13, Exported: true},
+		{Type: "type", Name: "Method", Signature: `type Method = "GET" | "POST"`, Line: 16, Exported: true},
+		{Type: "type", Name: "Handler", Signature: "type Handler = | ((req: Request) => void) | null", Line: 18, Exported: true},
+		{Type: "enum", Name: "Level", Signature: "const enum Level { Low, High = 2 }", Line: 22, Exported: true},
+		{Type: "class", Name: "Client", Signature: "class Client<T = unknown> extends BaseClient implements Closeable", Line: 29, Exported: true, Summary: "Sends requests."},
+		{Type: "property", Name: "Client.agent", Signature: "private agent: Agent", Line: 30},
+		{Type: "property", Name: "Client.VERSION", Signature: "static readonly VERSION", Line: 31, Exported: true},
+		{Type: "property", Name: "Client.#secret", Signature: "#secret", Line: 32},
+		{Type: "method", Name: "Client.constructor", Signature: "constructor(agent: Agent)", Line: 34, Exported: true},
+		{Type: "method", Name: "Client.get", Signature: "async get(url: string, options?: { timeout: number }): Promise<{ body: string }>", Line: 40, Exported: true, Summary: "Fetches a resource."},
+		{Type: "method", Name: "Client.close", Signature: "protected close(): void", Line: 46},
+		{Type: "method", Name: "Client.size", Signature: "get size(): number", Line: 48, Exported: true},
+		{Type: "function", Name: "createClient", Signature: "function createClient(agent = new Agent()): Client", Line: 51, Exported: true},
+		{Type: "function", Name: "stream", Signature: "async function* stream(): AsyncGenerator<string>", Line: 55, Exported: true},
+		{Type: "function", Name: "sum", Signature: "const sum = (a: number, b: number): number =>", Line: 57, Exported: true},
+		{Type: "variable", Name: "VERSION", Signature: `const VERSION = "2.0"`, Line: 58, Exported: true},
+		{Type: "function", Name: "internal", Signature: "const internal = async x =>", Line: 59},
+		{Type: "function", Name: "helper", Signature: "function helper()", Line: 61, Exported: true},
+		{Type: "namespace", Name: "express", Signature: `module "express"`, Line: 64, Exported: true},
+		{Type: "interface", Name: "express.Request", Signature: "interface Request", Line: 65, Exported: true},
+		{Type: "property", Name: "express.Request.user", Signature: "user: string", Line: 65, Exported: true},
+	}
+	if len(analysis.Constructs) != len(expected) {
+		for _, construct := range analysis.Constructs {
+			t.Logf("%s %s at line %d: %s", construct.Type, construct.Name, construct.Line, construct.Signature)
+		}
+		t.Fatalf("Expected %d constructs, got %d", len(expected), len(analysis.Constructs))
+	}
+	for i, want := range expected {
+		got := analysis.Constructs[i]
+		if got.Type != want.Type || got.Name != want.Name || got.Signature != want.Signature ||
+			got.Line != want.Line || got.Exported != want.Exported || got.Summary != want.Summary {
+			t.Errorf("Construct %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	if annotations := analysis.Constructs[9].Annotations; len(annotations) != 1 || annotations[0] != `@Injectable({ scope: "request" })` {
+		t.Errorf("Expected the Injectable decorator on Client, got %v", annotations)
+	}
+}
+
+func TestParseScriptFile_JSX(t *testing.T) {
+	src := `import React from "react";
+
+export function Greeting({ name }) {
+  return (
+    <div className="greeting" onClick={() => alert("it's { me }")}>
+      Don't forget {name}'s <b>birthday</b> <br/>
+      <>{items.map(item => <Item key={item.id} {...item} />)}</>
+    </div>
+  );
+}
+
+const Hidden = () => <span>{"}"}</span>;
+
+module.exports = { Hidden, Greeting: Greeting };
+exports.version = 1;
+`
+	analysis := ParseScriptFile("src/Greeting.jsx", []byte(src))
+
+	var names []string
+	for _, construct := range analysis.Constructs {
+		names = append(names, construct.Name)
+		if !construct.Exported {
+			t.Errorf("Expected %s to be exported", construct.Name)
+		}
+	}
+	if strings.Join(names, ",") != "Greeting,Hidden" {
+		t.Errorf("Expected the Greeting and Hidden functions, got %v", names)
+	}
+}
+
+func TestTypeScriptParser_ParseRepository(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"package.json":                  `{"name": "web"}`,
+		"src/index.ts":                  "export function start(): void {}\n",
+		"src/api/client.ts":             "export class Client {\n  // TODO: add retries\n  fetch(): void {}\n  private reset(): void {}\n}\n",
+		"src/util.js":                   "function format(value) { return value }\nmodule.exports = { format };\n",
+		"src/api/client.test.ts":        "export function testFetch() {}\n",
+		"node_modules/lib/index.js":     "export function vendored() {}\n",
+		"dist/index.js":                 "export function built() {}\n",
+		"src/components/__tests__/a.js": "export function fixture() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	repoIndex, err := NewTypeScriptParser().ParseRepository("web", tempDir, types.IndexingConfig{})
+	if err != nil {
+		t.Fatalf("ParseRepository failed: %v", err)
+	}
+
+	if repoIndex.Metadata["indexer_type"] != "typescript_native" {
+		t.Errorf("Expected indexer_type 'typescript_native', got '%v'", repoIndex.Metadata["indexer_type"])
+	}
+	if repoIndex.Metadata["file_count"] != 3 {
+		t.Errorf("Expected file_count 3, got '%v'", repoIndex.Metadata["file_count"])
+	}
+
+	xmlFile, exists := repoIndex.Files[".repomix.xml"]
+	if !exists {
+		t.Fatal("Expected .repomix.xml file to be generated")
+	}
+	for _, pattern := range []string{
+		`<file path="src/api/client.ts" module="src/api/client">`,
+		`<package name="src/api">`,
+		"TypeScript/JavaScript",
+		"class Client  // src/api/client.ts:1",
+		"fetch(): void",
+		"function start(): void",
+		"function format(value)",
+	} {
+		if !strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content to contain '%s'", pattern)
+		}
+	}
+	for _, pattern := range []string{"testFetch", "vendored", "built", "fixture", "reset()"} {
+		if strings.Contains(xmlFile.Content, pattern) {
+			t.Errorf("Expected XML content not to contain '%s'", pattern)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// ************************************************************************************************
+// Package parser provides the XML escaping of the repomix-style documents rendered by the
+// parsers. Signatures, doc comments, example bodies and paths are written as character data or
+// attribute values, so "<", "&" and quotes in source code cannot break the documents. Line breaks
+// are kept as is, the line ranges of exported constructs being counted on the rendered text.
+package parser
+
+import "strings"
+
+// ************************************************************************************************
+// xmlTextEscaper escapes the characters that cannot appear as is in XML character data.
+var xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// ************************************************************************************************
+// xmlAttrEscaper escapes the characters that cannot appear as is in a double-quoted XML
+// attribute value.
+var xmlAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "\n", "&#xA;", "\r", "&#xD;", "\t", "&#x9;")
+
+// ************************************************************************************************
+// escapeXMLText escapes text written as XML character data. Quotes are kept, so struct tags
+// such as `json:"name"` stay readable.
+//
+// Example usage:
+//
+//	xml.WriteString(escapeXMLText(construct.Signature))
+func escapeXMLText(text string) string {
+	return xmlTextEscaper.Replace(text)
+}
+
+// ************************************************************************************************
+// escapeXMLAttr escapes text written as a double-quoted XML attribute value.
+//
+// Example usage:
+//
+//	xml.WriteString(fmt.Sprintf(`<file path="%s">`+"\n", escapeXMLAttr(filePath)))
+func escapeXMLAttr(value string) string {
+	return xmlAttrEscaper.Replace(value)
+}
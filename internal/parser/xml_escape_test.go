@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+func TestEscapeXML(t *testing.T) {
+	tests := []struct {
+		input string
+		text  string
+		attr  string
+	}{
+		{"func Watch() <-chan Event", "func Watch() &lt;-chan Event", "func Watch() &lt;-chan Event"},
+		{"Name string `json:\"name\"`", "Name string `json:\"name\"`", "Name string `json:&quot;name&quot;`"},
+		{"a && b > c\nd", "a &amp;&amp; b &gt; c\nd", "a &amp;&amp; b &gt; c&#xA;d"},
+		{"&amp;", "&amp;amp;", "&amp;amp;"},
+	}
+	for _, test := range tests {
+		if got := escapeXMLText(test.input); got != test.text {
+			t.Errorf("escapeXMLText(%q): expected %q, got %q", test.input, test.text, got)
+		}
+		if got := escapeXMLAttr(test.input); got != test.attr {
+			t.Errorf("escapeXMLAttr(%q): expected %q, got %q", test.input, test.attr, got)
+		}
+	}
+}
+
+func TestRenderRepomixXML_WellFormed(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"app.go": "package app\n\n// Number is an int & float constraint, a < b.\ntype Number interface {\n\t~int | ~float64\n}\n\n" +
+			"// Event has a \"kind\".\ntype Event struct {\n\tKind string `json:\"kind\"` // Kind <required>\n}\n\n" +
+			"// Watch streams events.\nfunc Watch[T Number](values map[string]T) <-chan Event { return nil }\n",
+		"src/main/java/app/Cache.java": "package app;\n\n/** Caches values & keys. */\npublic class Cache<K extends Comparable<K>, V> {\n" +
+			"    public Map<K, List<V>> snapshot() { return null; }\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	goIndex, err := NewGoParser().ParseRepository("app", tempDir, types.IndexingConfig{})
+	if err != nil {
+		t.Fatalf("Go ParseRepository failed: %v", err)
+	}
+	javaIndex, err := NewJavaParser().ParseRepository("app", tempDir, types.IndexingConfig{})
+	if err != nil {
+		t.Fatalf("Java ParseRepository failed: %v", err)
+	}
+
+	for name, test := range map[string]struct {
+		index    *types.RepositoryIndex
+		patterns []string
+	}{
+		"go": {goIndex, []string{
+			"// Number is an int &amp; float constraint, a &lt; b.",
+			"func Watch[T Number](values map[string]T) &lt;-chan Event",
+			"Kind string `json:\"kind\"`",
+		}},
+		"java": {javaIndex, []string{
+			"public class Cache&lt;K extends Comparable&lt;K&gt;, V&gt;",
+			"public Map&lt;K, List&lt;V&gt;&gt; snapshot()",
+		}},
+	} {
+		xmlFile := test.index.Files[".repomix.xml"]
+		decoder := xml.NewDecoder(strings.NewReader(xmlFile.Content))
+		for {
+			if _, err := decoder.Token(); err != nil {
+				if !errors.Is(err, io.EOF) {
+					t.Errorf("Expected well-formed %s XML: %v", name, err)
+				}
+				break
+			}
+		}
+		for _, pattern := range test.patterns {
+			if !strings.Contains(xmlFile.Content, pattern) {
+				t.Errorf("Expected %s XML content to contain '%s'", name, pattern)
+			}
+		}
+
+		// Escaping keeps the lines of exported constructs in place
+		lines := strings.Split(xmlFile.Content, "\n")
+		for _, lineRange := range types.ParseLineRanges(xmlFile.Metadata[types.ExportedLinesMetadataKey]) {
+			if lineRange.Start < 1 || lineRange.End > len(lines) || strings.TrimSpace(lines[lineRange.Start-1]) == "" {
+				t.Errorf("Unexpected %s exported line range %+v", name, lineRange)
+			}
+		}
+	}
+}
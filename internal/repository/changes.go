@@ -0,0 +1,121 @@
+// ************************************************************************************************
+// Package repository provides the detection of the files changed in a git repository since a
+// commit, so a repository indexed at that commit can be re-indexed incrementally: the files
+// changed by the commits since, plus those modified or untracked in the working tree.
+package repository
+
+import (
+	"fmt"
+	"sort"
+
+	"repomix-mcp/pkg/types"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// ************************************************************************************************
+// Changes lists the files of a git repository that changed since a commit.
+type Changes struct {
+	Commit    string   // Commit checked out in the working tree
+	Committed []string // Files added, modified, renamed or deleted by the commits since, slash-separated
+	Dirty     []string // Files modified, added, deleted or untracked in the working tree, slash-separated
+}
+
+// ************************************************************************************************
+// ChangesSince returns the files of the git repository rooted at localPath that changed since
+// a commit. Renamed files are listed under both their old and new paths.
+//
+// Returns:
+//   - *Changes: The changes; Committed is empty when sinceCommit is empty or checked out.
+//   - error: An error if localPath is not a git repository with commits, or sinceCommit is not
+//     in its history, e.g. after a force push or in a shallow clone.
+//
+// Example usage:
+//
+//	changes, err := repository.ChangesSince("/path/to/repo", previous.CommitHash)
+//	if err != nil {
+//		// Index the whole repository
+//	}
+func ChangesSince(localPath, sinceCommit string) (*Changes, error) {
+	repo, err := mock_gitPlainOpen(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a git repository: %s\n>    %w", types.ErrInvalidPath, localPath, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD\n>    %w", err)
+	}
+	changes := &Changes{Commit: head.Hash().String()}
+
+	if sinceCommit != "" && sinceCommit != changes.Commit {
+		committed, err := committedChanges(repo, plumbing.NewHash(sinceCommit), head.Hash())
+		if err != nil {
+			return nil, err
+		}
+		changes.Committed = committed
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open working tree\n>    %w", err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read working tree status\n>    %w", err)
+	}
+	for path, fileStatus := range status {
+		if fileStatus.Worktree != git.Unmodified || fileStatus.Staging != git.Unmodified {
+			changes.Dirty = append(changes.Dirty, path)
+		}
+	}
+	sort.Strings(changes.Dirty)
+
+	return changes, nil
+}
+
+// ************************************************************************************************
+// committedChanges returns the files changed between the trees of two commits.
+//
+// Returns:
+//   - []string: The changed paths, sorted.
+//   - error: An error if a commit or tree cannot be read.
+func committedChanges(repo *git.Repository, from, to plumbing.Hash) ([]string, error) {
+	fromCommit, err := repo.CommitObject(from)
+	if err != nil {
+		return nil, fmt.Errorf("commit %s not found\n>    %w", from, err)
+	}
+	toCommit, err := repo.CommitObject(to)
+	if err != nil {
+		return nil, fmt.Errorf("commit %s not found\n>    %w", to, err)
+	}
+	fromTree, err := fromCommit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree of %s\n>    %w", from, err)
+	}
+	toTree, err := toCommit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree of %s\n>    %w", to, err)
+	}
+
+	treeChanges, err := fromTree.Diff(toTree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s and %s\n>    %w", from, to, err)
+	}
+
+	paths := make(map[string]bool)
+	for _, change := range treeChanges {
+		if change.From.Name != "" {
+			paths[change.From.Name] = true
+		}
+		if change.To.Name != "" {
+			paths[change.To.Name] = true
+		}
+	}
+	changed := make([]string, 0, len(paths))
+	for path := range paths {
+		changed = append(changed, path)
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
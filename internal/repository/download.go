@@ -0,0 +1,288 @@
+// ************************************************************************************************
+// Package repository provides HTTP download support for the repomix-mcp application.
+// Repositories of type "http" are fetched from a URL as a single document or as a
+// zip/tar archive and unpacked into the working directory before indexing.
+package repository
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// defaultMaxDownloadSize applies when an http repository does not set maxDownloadSize.
+const defaultMaxDownloadSize = "100MB"
+
+// ************************************************************************************************
+// archiveKind identifies how a downloaded body must be unpacked.
+type archiveKind int
+
+const (
+	archiveNone archiveKind = iota
+	archiveZip
+	archiveTar
+	archiveTarGzip
+)
+
+// ************************************************************************************************
+// prepareHTTPRepository downloads an http repository into the working directory.
+// The previous download is discarded so the directory always mirrors the URL content.
+// Archives are extracted; any other content is stored as a single file.
+//
+// Returns:
+//   - string: The local path to the downloaded content.
+//   - error: An error if the download or extraction fails.
+func (m *Manager) prepareHTTPRepository(ctx context.Context, alias string, config *types.RepositoryConfig) (string, error) {
+	maxSize := config.MaxDownloadSize
+	if maxSize == "" {
+		maxSize = defaultMaxDownloadSize
+	}
+	limit, err := types.ParseByteSize(maxSize)
+	if err != nil {
+		return "", fmt.Errorf("invalid maxDownloadSize\n>    %w", err)
+	}
+
+	sourceURL, err := url.Parse(config.URL)
+	if err != nil || (sourceURL.Scheme != "http" && sourceURL.Scheme != "https") {
+		return "", fmt.Errorf("%w: http repository URL must use the http or https scheme: %s", types.ErrInvalidConfig, config.URL)
+	}
+
+	localPath, err := m.workPath(alias)
+	if err != nil {
+		return "", err
+	}
+
+	body, contentType, err := m.download(ctx, sourceURL.String(), config.Auth, limit)
+	if err != nil {
+		return "", err
+	}
+
+	if err := mock_osRemoveAll(localPath); err != nil {
+		return "", fmt.Errorf("failed to clear previous download\n>    %w", err)
+	}
+	if err := mock_osMkdirAll(localPath, 0755); err != nil {
+		return "", fmt.Errorf("failed to create download directory\n>    %w", err)
+	}
+
+	switch detectArchive(contentType, sourceURL.Path) {
+	case archiveZip:
+		err = extractZip(body, localPath, limit)
+	case archiveTar:
+		err = extractTar(bytes.NewReader(body), localPath, limit)
+	case archiveTarGzip:
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(bytes.NewReader(body)); err == nil {
+			err = extractTar(gz, localPath, limit)
+		}
+	default:
+		name := documentFileName(contentType, sourceURL.Path)
+		err = os.WriteFile(filepath.Join(localPath, name), body, 0644)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to unpack %s\n>    %w", types.ErrDownloadFailed, config.URL, err)
+	}
+
+	return localPath, nil
+}
+
+// ************************************************************************************************
+// download fetches a URL, refusing bodies larger than limit bytes.
+// Token auth is sent as HTTP basic auth when a username is set, as a bearer token otherwise.
+//
+// Returns:
+//   - []byte: The response body.
+//   - string: The media type from the Content-Type header, without parameters.
+//   - error: An error if the request fails, returns a non-200 status or exceeds the limit.
+func (m *Manager) download(ctx context.Context, sourceURL string, auth types.RepositoryAuth, limit int64) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: failed to create request\n>    %w", types.ErrDownloadFailed, err)
+	}
+	if auth.Type == types.AuthTypeToken {
+		if auth.Username != "" {
+			req.SetBasicAuth(auth.Username, auth.Token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+auth.Token)
+		}
+	}
+
+	resp, err := mock_httpClientDo(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: failed to download %s\n>    %w", types.ErrDownloadFailed, sourceURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%w: unexpected status %s for %s", types.ErrDownloadFailed, resp.Status, sourceURL)
+	}
+	if resp.ContentLength > limit {
+		return nil, "", fmt.Errorf("%w: %s is %d bytes, larger than the %d byte limit", types.ErrDownloadFailed, sourceURL, resp.ContentLength, limit)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: failed to read %s\n>    %w", types.ErrDownloadFailed, sourceURL, err)
+	}
+	if int64(len(body)) > limit {
+		return nil, "", fmt.Errorf("%w: %s exceeds the %d byte limit", types.ErrDownloadFailed, sourceURL, limit)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return body, mediaType, nil
+}
+
+// ************************************************************************************************
+// detectArchive decides how to unpack a download from its media type, falling back to
+// the URL file extension for generic types such as application/octet-stream.
+func detectArchive(mediaType, urlPath string) archiveKind {
+	switch mediaType {
+	case "application/zip", "application/x-zip-compressed":
+		return archiveZip
+	case "application/x-tar":
+		return archiveTar
+	case "application/gzip", "application/x-gzip", "application/x-compressed-tar":
+		return archiveTarGzip
+	}
+
+	name := strings.ToLower(path.Base(urlPath))
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return archiveZip
+	case strings.HasSuffix(name, ".tar"):
+		return archiveTar
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return archiveTarGzip
+	}
+	return archiveNone
+}
+
+// ************************************************************************************************
+// documentFileName picks the file name of a single-document download: the last URL path
+// segment when it has one, otherwise "index" with an extension matching the media type.
+func documentFileName(mediaType, urlPath string) string {
+	name := path.Base(urlPath)
+	if name == "." || name == ".." || name == "/" || name == "" {
+		name = "index"
+	}
+	if filepath.Ext(name) == "" {
+		switch mediaType {
+		case "text/markdown", "text/x-markdown":
+			name += ".md"
+		case "text/html":
+			name += ".html"
+		case "text/plain":
+			name += ".txt"
+		default:
+			if extensions, _ := mime.ExtensionsByType(mediaType); len(extensions) > 0 {
+				name += extensions[0]
+			}
+		}
+	}
+	return name
+}
+
+// ************************************************************************************************
+// extractZip unpacks a zip archive into destDir, limiting the total extracted size.
+// Only regular files are extracted; links and special files are skipped.
+//
+// Returns:
+//   - error: An error if the archive is invalid, escapes destDir or exceeds the limit.
+func extractZip(data []byte, destDir string, limit int64) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("invalid zip archive\n>    %w", err)
+	}
+
+	var total int64
+	for _, file := range reader.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s\n>    %w", file.Name, err)
+		}
+		written, err := writeArchiveEntry(destDir, file.Name, rc, limit-total)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		total += written
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// extractTar unpacks a tar stream into destDir, limiting the total extracted size.
+// Only regular files are extracted; links and special files are skipped.
+//
+// Returns:
+//   - error: An error if the archive is invalid, escapes destDir or exceeds the limit.
+func extractTar(r io.Reader, destDir string, limit int64) error {
+	reader := tar.NewReader(r)
+
+	var total int64
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar archive\n>    %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		written, err := writeArchiveEntry(destDir, header.Name, reader, limit-total)
+		if err != nil {
+			return err
+		}
+		total += written
+	}
+}
+
+// ************************************************************************************************
+// writeArchiveEntry writes one archive entry below destDir, rejecting entry names that
+// would escape it and content larger than the remaining size budget.
+//
+// Returns:
+//   - int64: The number of bytes written.
+//   - error: An error if the entry is unsafe, too large or cannot be written.
+func writeArchiveEntry(destDir, name string, r io.Reader, remaining int64) (int64, error) {
+	target := filepath.Join(destDir, filepath.FromSlash(name))
+	if rel, err := filepath.Rel(destDir, target); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return 0, fmt.Errorf("%w: archive entry escapes destination: %s", types.ErrInvalidPath, name)
+	}
+
+	if err := mock_osMkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create directory for %s\n>    %w", name, err)
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s\n>    %w", name, err)
+	}
+	defer out.Close()
+
+	written, err := io.Copy(out, io.LimitReader(r, remaining+1))
+	if err != nil {
+		return written, fmt.Errorf("failed to extract %s\n>    %w", name, err)
+	}
+	if written > remaining {
+		return written, fmt.Errorf("extracted content exceeds the size limit")
+	}
+	return written, nil
+}
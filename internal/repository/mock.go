@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"net/http"
 	"os"
 	"time"
 
@@ -11,13 +12,14 @@ import (
 // Mock functions to allow easy and in depth unit test
 var (
 	// Mock for external package
-	mock_osUserHomeDir  = os.UserHomeDir
-	mock_osMkdirAll     = os.MkdirAll
-	mock_osStat         = os.Stat
-	mock_osIsNotExist   = os.IsNotExist
-	mock_osReadFile     = os.ReadFile
-	mock_osRemoveAll    = os.RemoveAll
-	mock_timeNow        = time.Now
-	mock_gitPlainOpen   = git.PlainOpen
-	mock_gitPlainClone  = git.PlainClone
+	mock_osUserHomeDir        = os.UserHomeDir
+	mock_osMkdirAll           = os.MkdirAll
+	mock_osStat               = os.Stat
+	mock_osIsNotExist         = os.IsNotExist
+	mock_osReadFile           = os.ReadFile
+	mock_osRemoveAll          = os.RemoveAll
+	mock_timeNow              = time.Now
+	mock_gitPlainOpen         = git.PlainOpen
+	mock_gitPlainCloneContext = git.PlainCloneContext
+	mock_httpClientDo         = (&http.Client{Timeout: 5 * time.Minute}).Do
 )
\ No newline at end of file
@@ -5,11 +5,14 @@
 package repository
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"repomix-mcp/internal/tracing"
 	"repomix-mcp/pkg/types"
 
 	"github.com/bmatcuk/doublestar/v4"
@@ -61,9 +64,14 @@ func NewManager(workDir string) (*Manager, error) {
 	}, nil
 }
 
+// ************************************************************************************************
+// DefaultMaxGlobMatches applies when a glob repository does not set maxGlobMatches.
+const DefaultMaxGlobMatches = 100
+
 // ************************************************************************************************
 // PrepareRepository prepares a repository for indexing based on its configuration.
-// It handles cloning for remote repositories and validates local repositories.
+// It handles cloning for remote repositories, downloading for http repositories
+// and validates local repositories.
 // For local repositories with glob patterns, it returns the first matching path.
 //
 // Returns:
@@ -77,6 +85,24 @@ func NewManager(workDir string) (*Manager, error) {
 //		return fmt.Errorf("failed to prepare repository: %w", err)
 //	}
 func (m *Manager) PrepareRepository(alias string, config *types.RepositoryConfig) (string, error) {
+	return m.PrepareRepositoryContext(context.Background(), alias, config)
+}
+
+// ************************************************************************************************
+// PrepareRepositoryContext prepares a repository like PrepareRepository, stopping the clone,
+// pull or download when ctx is cancelled.
+//
+// Returns:
+//   - string: The local path to the prepared repository.
+//   - error: An error if preparation fails or ctx is cancelled.
+//
+// Example usage:
+//
+//	path, err := manager.PrepareRepositoryContext(ctx, "my-repo", &repoConfig)
+//	if err != nil {
+//		return fmt.Errorf("failed to prepare repository: %w", err)
+//	}
+func (m *Manager) PrepareRepositoryContext(ctx context.Context, alias string, config *types.RepositoryConfig) (string, error) {
 	if alias == "" || config == nil {
 		return "", fmt.Errorf("%w: invalid parameters", types.ErrInvalidConfig)
 	}
@@ -85,7 +111,9 @@ func (m *Manager) PrepareRepository(alias string, config *types.RepositoryConfig
 	case types.RepositoryTypeLocal:
 		return m.prepareLocalRepository(config)
 	case types.RepositoryTypeRemote:
-		return m.prepareRemoteRepository(alias, config)
+		return m.prepareRemoteRepository(ctx, alias, config)
+	case types.RepositoryTypeHTTP:
+		return m.prepareHTTPRepository(ctx, alias, config)
 	default:
 		return "", fmt.Errorf("%w: %s", types.ErrInvalidRepositoryType, config.Type)
 	}
@@ -94,6 +122,7 @@ func (m *Manager) PrepareRepository(alias string, config *types.RepositoryConfig
 // ************************************************************************************************
 // ExpandGlobRepositories expands a repository configuration with glob patterns into multiple repositories.
 // This allows a single config entry like "c:\xxx\*" to discover and create multiple repository configurations.
+// A pattern matching more directories than config.MaxGlobMatches is rejected before anything is indexed.
 //
 // Returns:
 //   - map[string]*types.RepositoryConfig: Map of discovered repositories with generated aliases.
@@ -137,20 +166,36 @@ func (m *Manager) ExpandGlobRepositories(baseAlias string, config *types.Reposit
 		return nil, fmt.Errorf("no directories found matching pattern: %s", path)
 	}
 
-	// Create repository configurations for each match
-	expanded := make(map[string]*types.RepositoryConfig)
-	for i, matchPath := range matches {
-		// Check if it's a directory
-		if info, err := mock_osStat(matchPath); err != nil || !info.IsDir() {
-			continue // Skip files, only process directories
+	// Only directories are repositories
+	var dirs []string
+	for _, matchPath := range matches {
+		if info, err := mock_osStat(matchPath); err == nil && info.IsDir() {
+			dirs = append(dirs, matchPath)
 		}
+	}
+
+	maxMatches := config.MaxGlobMatches
+	if maxMatches == 0 {
+		maxMatches = DefaultMaxGlobMatches
+	}
+	if maxMatches > 0 {
+		slog.Info("Glob matched directories", "glob", path, "count", len(dirs), "cap", maxMatches)
+		if len(dirs) > maxMatches {
+			return nil, fmt.Errorf("%w: glob %s matches %d directories, more than maxGlobMatches (%d); narrow the pattern or raise maxGlobMatches", types.ErrInvalidConfig, path, len(dirs), maxMatches)
+		}
+	} else {
+		slog.Info("Glob matched directories", "glob", path, "count", len(dirs), "cap", "none")
+	}
 
+	// Create repository configurations for each match
+	expanded := make(map[string]*types.RepositoryConfig)
+	for i, matchPath := range dirs {
 		// Generate alias for this match
 		dirName := filepath.Base(matchPath)
-		alias := fmt.Sprintf("%s-%s", baseAlias, dirName)
+		alias := types.ExpandedRepositoryID(baseAlias, dirName)
 		
 		// If there's only one match, use the original alias
-		if len(matches) == 1 {
+		if len(dirs) == 1 {
 			alias = baseAlias
 		} else if i == 0 && dirName == baseAlias {
 			// If the directory name matches the base alias, use it directly
@@ -166,6 +211,12 @@ func (m *Manager) ExpandGlobRepositories(baseAlias string, config *types.Reposit
 			Branch:   config.Branch,
 		}
 
+		// Directories with the same name at different depths would share an ID
+		if _, exists := expanded[alias]; exists {
+			slog.Warn("Skipping directory, its repository ID is already used", "path", matchPath, "repository", alias, "usedBy", expanded[alias].Path)
+			continue
+		}
+
 		expanded[alias] = newConfig
 	}
 
@@ -212,23 +263,40 @@ func (m *Manager) prepareLocalRepository(config *types.RepositoryConfig) (string
 	return path, nil
 }
 
+// ************************************************************************************************
+// workPath returns the directory of a cloned or downloaded repository below the working
+// directory. The alias must be a single path element, so the directory cannot escape it.
+//
+// Returns:
+//   - string: The repository directory.
+//   - error: An error if the alias is empty, "." or "..", or contains a path separator.
+func (m *Manager) workPath(alias string) (string, error) {
+	if alias == "" || alias == "." || alias == ".." || strings.ContainsAny(alias, `/\`) {
+		return "", fmt.Errorf("%w: repository alias must be a single path element: %q", types.ErrInvalidConfig, alias)
+	}
+	return filepath.Join(m.workDir, alias), nil
+}
+
 // ************************************************************************************************
 // prepareRemoteRepository clones or updates a remote repository.
 //
 // Returns:
 //   - string: The local path to the cloned repository.
 //   - error: An error if cloning/updating fails.
-func (m *Manager) prepareRemoteRepository(alias string, config *types.RepositoryConfig) (string, error) {
-	localPath := filepath.Join(m.workDir, alias)
+func (m *Manager) prepareRemoteRepository(ctx context.Context, alias string, config *types.RepositoryConfig) (string, error) {
+	localPath, err := m.workPath(alias)
+	if err != nil {
+		return "", err
+	}
 
 	// Check if repository already exists
 	if _, err := mock_osStat(localPath); err == nil {
 		// Repository exists, try to update it
-		return m.updateRepository(localPath, config)
+		return m.updateRepository(ctx, localPath, config)
 	}
 
 	// Repository doesn't exist, clone it
-	return m.cloneRepository(localPath, config)
+	return m.cloneRepository(ctx, localPath, config)
 }
 
 // ************************************************************************************************
@@ -237,7 +305,7 @@ func (m *Manager) prepareRemoteRepository(alias string, config *types.Repository
 // Returns:
 //   - string: The local path to the cloned repository.
 //   - error: An error if cloning fails.
-func (m *Manager) cloneRepository(localPath string, config *types.RepositoryConfig) (string, error) {
+func (m *Manager) cloneRepository(ctx context.Context, localPath string, config *types.RepositoryConfig) (string, error) {
 	// Create authentication
 	auth, err := m.createAuth(config.Auth)
 	if err != nil {
@@ -254,7 +322,10 @@ func (m *Manager) cloneRepository(localPath string, config *types.RepositoryConf
 	}
 
 	// Clone repository
-	_, err = mock_gitPlainClone(localPath, false, cloneOptions)
+	_, span := tracing.Start(ctx, "git.clone", tracing.String("git.branch", config.Branch))
+	_, err = mock_gitPlainCloneContext(ctx, localPath, false, cloneOptions)
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		return "", fmt.Errorf("%w: failed to clone repository\n>    %w", types.ErrGitCloneFailed, err)
 	}
@@ -268,7 +339,7 @@ func (m *Manager) cloneRepository(localPath string, config *types.RepositoryConf
 // Returns:
 //   - string: The local path to the updated repository.
 //   - error: An error if updating fails.
-func (m *Manager) updateRepository(localPath string, config *types.RepositoryConfig) (string, error) {
+func (m *Manager) updateRepository(ctx context.Context, localPath string, config *types.RepositoryConfig) (string, error) {
 	// Open repository
 	repo, err := mock_gitPlainOpen(localPath)
 	if err != nil {
@@ -293,7 +364,13 @@ func (m *Manager) updateRepository(localPath string, config *types.RepositoryCon
 		Progress: nil,
 	}
 
-	err = worktree.Pull(pullOptions)
+	_, span := tracing.Start(ctx, "git.pull", tracing.String("git.branch", config.Branch))
+	err = worktree.PullContext(ctx, pullOptions)
+	span.SetAttributes(tracing.Bool("git.up_to_date", err == git.NoErrAlreadyUpToDate))
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		span.RecordError(err)
+	}
+	span.End()
 	if err != nil && err != git.NoErrAlreadyUpToDate {
 		return "", fmt.Errorf("%w: failed to pull repository\n>    %w", types.ErrGitPullFailed, err)
 	}
@@ -452,6 +529,15 @@ func (m *Manager) ListFiles(localPath string, indexingConfig types.IndexingConfi
 		return nil, fmt.Errorf("%w: local path is empty", types.ErrInvalidPath)
 	}
 
+	// Git repositories can be limited to committed files, other directories are walked
+	if indexingConfig.GitTrackedOnly {
+		files, err := m.listTrackedFiles(localPath, indexingConfig)
+		if err == nil {
+			return files, nil
+		}
+		slog.Warn("Listing git-tracked files failed, walking the filesystem", "path", localPath, "error", err)
+	}
+
 	var files []string
 
 	err := filepath.Walk(localPath, func(path string, info os.FileInfo, err error) error {
@@ -501,6 +587,11 @@ func (m *Manager) shouldIndexFile(relPath string, info os.FileInfo, config types
 		return false
 	}
 
+	// Always-included files bypass size limits and patterns
+	if AlwaysIncluded(relPath, info.Size(), config) {
+		return true
+	}
+
 	// Check file size limit if specified
 	if config.MaxFileSize != "" {
 		// Simple size check - can be enhanced with proper parsing
@@ -535,6 +626,38 @@ func (m *Manager) shouldIndexFile(relPath string, info os.FileInfo, config types
 	return true // No include patterns specified, file passes exclude checks
 }
 
+// ************************************************************************************************
+// AlwaysIncludeMaxSize is the hard size cap for files matched by IndexingConfig.AlwaysInclude.
+const AlwaysIncludeMaxSize = 10 * 1024 * 1024
+
+// ************************************************************************************************
+// AlwaysIncluded reports whether a file matches an IndexingConfig.AlwaysInclude pattern and
+// is within AlwaysIncludeMaxSize. Patterns match the file name or the relative path.
+//
+// Returns:
+//   - bool: True if the file must be indexed regardless of other rules.
+//
+// Example usage:
+//
+//	if repository.AlwaysIncluded("go.mod", info.Size(), config) {
+//		// index it even if excluded
+//	}
+func AlwaysIncluded(relPath string, size int64, config types.IndexingConfig) bool {
+	if size > AlwaysIncludeMaxSize {
+		return false
+	}
+
+	for _, pattern := range config.AlwaysInclude {
+		if matched, _ := filepath.Match(pattern, filepath.Base(relPath)); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, relPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
 // ************************************************************************************************
 // GetFileContent reads the content of a file in the repository.
 //
@@ -576,11 +699,10 @@ func (m *Manager) GetFileContent(localPath, relPath string) (string, error) {
 //		return fmt.Errorf("failed to cleanup repository: %w", err)
 //	}
 func (m *Manager) CleanupRepository(alias string) error {
-	if alias == "" {
-		return fmt.Errorf("%w: alias is empty", types.ErrInvalidConfig)
+	localPath, err := m.workPath(alias)
+	if err != nil {
+		return err
 	}
-
-	localPath := filepath.Join(m.workDir, alias)
 	
 	if err := mock_osRemoveAll(localPath); err != nil {
 		return fmt.Errorf("failed to remove repository directory\n>    %w", err)
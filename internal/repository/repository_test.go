@@ -0,0 +1,449 @@
+// ************************************************************************************************
+// Package repository - Unit tests for repository management.
+// This file covers file selection rules used during indexing.
+package repository
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"repomix-mcp/pkg/types"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ************************************************************************************************
+// Test that always-included files survive exclude patterns and size limits
+func TestManager_shouldIndexFile_AlwaysInclude(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFile := func(name string, size int) os.FileInfo {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", name, err)
+		}
+		return info
+	}
+
+	manager := &Manager{workDir: tempDir}
+	config := types.IndexingConfig{
+		Enabled:         true,
+		ExcludePatterns: []string{"*.mod", "LICENSE", "*.json"},
+		IncludePatterns: []string{"*.go"},
+		MaxFileSize:     "1MB",
+		AlwaysInclude:   []string{"go.mod", "LICENSE"},
+	}
+
+	tests := []struct {
+		name     string
+		info     os.FileInfo
+		expected bool
+	}{
+		{"go.mod", writeFile("go.mod", 100), true},
+		{"LICENSE", writeFile("LICENSE", 2*1024*1024), true},
+		{"package.json", writeFile("package.json", 100), false},
+		{"main.go", writeFile("main.go", 100), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := manager.shouldIndexFile(tt.name, tt.info, config); result != tt.expected {
+				t.Errorf("shouldIndexFile(%s) = %v, expected %v", tt.name, result, tt.expected)
+			}
+		})
+	}
+
+	// The hard cap still applies to always-included files
+	if AlwaysIncluded("LICENSE", AlwaysIncludeMaxSize+1, config) {
+		t.Error("Expected files above AlwaysIncludeMaxSize not to be always included")
+	}
+}
+
+// ************************************************************************************************
+// Test that a glob matching more directories than maxGlobMatches is rejected
+func TestManager_ExpandGlobRepositories_MaxGlobMatches(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := 0; i < 3; i++ {
+		if err := os.Mkdir(filepath.Join(tempDir, fmt.Sprintf("project-%d", i)), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	// Files matched by the glob do not count against the cap
+	if err := os.WriteFile(filepath.Join(tempDir, "notes.txt"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	manager := &Manager{workDir: tempDir}
+	config := &types.RepositoryConfig{
+		Type: types.RepositoryTypeLocal,
+		Path: filepath.Join(tempDir, "*"),
+	}
+
+	config.MaxGlobMatches = 3
+	expanded, err := manager.ExpandGlobRepositories("projects", config)
+	if err != nil {
+		t.Fatalf("Expected 3 directories to fit the cap, got %v", err)
+	}
+	if len(expanded) != 3 {
+		t.Errorf("Expected 3 repositories, got %d", len(expanded))
+	}
+
+	config.MaxGlobMatches = 2
+	if _, err := manager.ExpandGlobRepositories("projects", config); !errors.Is(err, types.ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig when the cap is exceeded, got %v", err)
+	}
+
+	config.MaxGlobMatches = -1
+	if _, err := manager.ExpandGlobRepositories("projects", config); err != nil {
+		t.Errorf("Expected a negative cap to disable the check, got %v", err)
+	}
+}
+
+// ************************************************************************************************
+// buildZip returns a zip archive holding the given entries, in order
+func buildZip(t *testing.T, entries []*zip.FileHeader, contents []string) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for index, header := range entries {
+		w, err := writer.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", header.Name, err)
+		}
+		if _, err := w.Write([]byte(contents[index])); err != nil {
+			t.Fatalf("Failed to write %s: %v", header.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+// ************************************************************************************************
+// buildTar returns a tar archive holding the given entries, in order
+func buildTar(t *testing.T, entries []*tar.Header, contents []string) []byte {
+	var buffer bytes.Buffer
+	writer := tar.NewWriter(&buffer)
+	for index, header := range entries {
+		header.Size = int64(len(contents[index]))
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to add %s: %v", header.Name, err)
+		}
+		if _, err := writer.Write([]byte(contents[index])); err != nil {
+			t.Fatalf("Failed to write %s: %v", header.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close tar: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+// ************************************************************************************************
+// Test that zip entries escaping the destination are rejected, absolute entries stay below it
+// and links are skipped
+func TestExtractZip(t *testing.T) {
+	symlink := &zip.FileHeader{Name: "link"}
+	symlink.SetMode(os.ModeSymlink | 0777)
+
+	tests := []struct {
+		name    string
+		entries []*zip.FileHeader
+		content []string
+		valid   bool
+	}{
+		{"nested file", []*zip.FileHeader{{Name: "docs/guide.md"}}, []string{"# Guide"}, true},
+		{"parent entry", []*zip.FileHeader{{Name: "docs/guide.md"}, {Name: "../evil.txt"}}, []string{"# Guide", "evil"}, false},
+		{"nested parent entry", []*zip.FileHeader{{Name: "docs/../../evil.txt"}}, []string{"evil"}, false},
+		{"backslash parent entry", []*zip.FileHeader{{Name: `..\evil.txt`}}, []string{"evil"}, filepath.Separator == '/'},
+		{"absolute entry", []*zip.FileHeader{{Name: "/tmp/evil.txt"}}, []string{"evil"}, true},
+		{"symlink entry", []*zip.FileHeader{symlink}, []string{"/etc/passwd"}, true},
+		{"over the size limit", []*zip.FileHeader{{Name: "a.txt"}, {Name: "b.txt"}}, []string{"0123456789", "0123456789"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			destDir := filepath.Join(root, "dest")
+			err := extractZip(buildZip(t, test.entries, test.content), destDir, 15)
+			if (err == nil) != test.valid {
+				t.Fatalf("Expected valid %v, got error %v", test.valid, err)
+			}
+			if _, err := os.Stat(filepath.Join(root, "evil.txt")); err == nil {
+				t.Error("Expected nothing to be written outside of the destination")
+			}
+		})
+	}
+
+	destDir := t.TempDir()
+	if err := extractZip(buildZip(t, []*zip.FileHeader{{Name: "/tmp/evil.txt"}, symlink}, []string{"evil", "/etc/passwd"}), destDir, 100); err != nil {
+		t.Fatalf("extractZip failed: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(destDir, "tmp", "evil.txt")); err != nil || string(content) != "evil" {
+		t.Errorf("Expected the absolute entry below the destination, got %q, %v", content, err)
+	}
+	if _, err := os.Lstat(filepath.Join(destDir, "link")); !os.IsNotExist(err) {
+		t.Errorf("Expected the symlink entry to be skipped, got %v", err)
+	}
+
+	if err := extractZip([]byte("not a zip"), destDir, 100); err == nil {
+		t.Error("Expected an error for an invalid zip archive")
+	}
+}
+
+// ************************************************************************************************
+// Test that tar entries escaping the destination are rejected and links are skipped
+func TestExtractTar(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []*tar.Header
+		content []string
+		valid   bool
+	}{
+		{"nested file", []*tar.Header{{Name: "docs/guide.md", Typeflag: tar.TypeReg, Mode: 0644}}, []string{"# Guide"}, true},
+		{"parent entry", []*tar.Header{{Name: "../evil.txt", Typeflag: tar.TypeReg, Mode: 0644}}, []string{"evil"}, false},
+		{"nested parent entry", []*tar.Header{{Name: "a/b/../../../evil.txt", Typeflag: tar.TypeReg, Mode: 0644}}, []string{"evil"}, false},
+		{"symlink then write through it", []*tar.Header{
+			{Name: "link", Typeflag: tar.TypeSymlink, Linkname: ".."},
+			{Name: "link/evil.txt", Typeflag: tar.TypeReg, Mode: 0644},
+		}, []string{"", "evil"}, true},
+		{"hard link", []*tar.Header{{Name: "passwd", Typeflag: tar.TypeLink, Linkname: "/etc/passwd"}}, []string{""}, true},
+		{"over the size limit", []*tar.Header{{Name: "big.txt", Typeflag: tar.TypeReg, Mode: 0644}}, []string{strings.Repeat("x", 16)}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			destDir := filepath.Join(root, "dest")
+			err := extractTar(bytes.NewReader(buildTar(t, test.entries, test.content)), destDir, 15)
+			if (err == nil) != test.valid {
+				t.Fatalf("Expected valid %v, got error %v", test.valid, err)
+			}
+			if _, err := os.Stat(filepath.Join(root, "evil.txt")); err == nil {
+				t.Error("Expected nothing to be written outside of the destination")
+			}
+			if info, err := os.Lstat(filepath.Join(destDir, "link")); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				t.Error("Expected links not to be created")
+			}
+		})
+	}
+
+	if err := extractTar(strings.NewReader("not a tar archive, long enough to hold a header"), t.TempDir(), 100); err == nil {
+		t.Error("Expected an error for an invalid tar archive")
+	}
+}
+
+// ************************************************************************************************
+// Test that archives are detected from the media type first, then from the URL extension
+func TestDetectArchive(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		urlPath   string
+		expected  archiveKind
+	}{
+		{"application/zip", "/download", archiveZip},
+		{"application/x-zip-compressed", "/docs.md", archiveZip},
+		{"application/x-tar", "/docs", archiveTar},
+		{"application/gzip", "/docs", archiveTarGzip},
+		{"application/octet-stream", "/docs.ZIP", archiveZip},
+		{"application/octet-stream", "/docs.tar", archiveTar},
+		{"application/octet-stream", "/docs.tar.gz", archiveTarGzip},
+		{"", "/docs.tgz", archiveTarGzip},
+		{"text/markdown", "/guide.md", archiveNone},
+		{"application/octet-stream", "/", archiveNone},
+	}
+	for _, test := range tests {
+		if kind := detectArchive(test.mediaType, test.urlPath); kind != test.expected {
+			t.Errorf("detectArchive(%q, %q): expected %v, got %v", test.mediaType, test.urlPath, test.expected, kind)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that single documents are named after the URL, with an extension from the media type
+func TestDocumentFileName(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		urlPath   string
+		expected  string
+	}{
+		{"text/markdown", "/docs/guide.md", "guide.md"},
+		{"text/html", "/docs/guide.md", "guide.md"},
+		{"text/markdown", "/docs/guide", "guide.md"},
+		{"text/x-markdown", "", "index.md"},
+		{"text/html", "/", "index.html"},
+		{"text/plain", "/docs/", "docs.txt"},
+		{"application/x-unknown", "/docs/guide", "guide"},
+		{"text/plain", "/docs/..", "index.txt"},
+	}
+	for _, test := range tests {
+		if name := documentFileName(test.mediaType, test.urlPath); name != test.expected {
+			t.Errorf("documentFileName(%q, %q): expected %q, got %q", test.mediaType, test.urlPath, test.expected, name)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that an http repository is downloaded and extracted, and that aliases escaping the
+// working directory are rejected before anything is downloaded or removed
+func TestManager_prepareHTTPRepository(t *testing.T) {
+	original := mock_httpClientDo
+	defer func() { mock_httpClientDo = original }()
+
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	gz.Write(buildTar(t, []*tar.Header{{Name: "docs/guide.md", Typeflag: tar.TypeReg, Mode: 0644}}, []string{"# Guide"}))
+	gz.Close()
+
+	requests := 0
+	mock_httpClientDo = func(req *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			Status:        "200 OK",
+			Header:        http.Header{"Content-Type": []string{"application/octet-stream"}},
+			ContentLength: int64(archive.Len()),
+			Body:          io.NopCloser(bytes.NewReader(archive.Bytes())),
+		}, nil
+	}
+
+	workDir := t.TempDir()
+	manager := &Manager{workDir: workDir}
+	config := &types.RepositoryConfig{Type: types.RepositoryTypeHTTP, URL: "https://example.com/docs.tar.gz"}
+
+	localPath, err := manager.PrepareRepositoryContext(context.Background(), "docs", config)
+	if err != nil {
+		t.Fatalf("PrepareRepositoryContext failed: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(localPath, "docs", "guide.md")); err != nil || string(content) != "# Guide" {
+		t.Errorf("Expected the extracted guide, got %q, %v", content, err)
+	}
+
+	if _, err := manager.PrepareRepositoryContext(context.Background(), "docs", &types.RepositoryConfig{
+		Type: types.RepositoryTypeHTTP, URL: "https://example.com/docs.tar.gz", MaxDownloadSize: "10B",
+	}); !errors.Is(err, types.ErrDownloadFailed) {
+		t.Errorf("Expected ErrDownloadFailed above maxDownloadSize, got %v", err)
+	}
+
+	if _, err := manager.PrepareRepositoryContext(context.Background(), "docs", &types.RepositoryConfig{
+		Type: types.RepositoryTypeHTTP, URL: "file:///etc/passwd",
+	}); !errors.Is(err, types.ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for a file URL, got %v", err)
+	}
+
+	requests = 0
+	for _, alias := range []string{"..", ".", "../outside", "nested/docs", `nested\docs`} {
+		if _, err := manager.PrepareRepositoryContext(context.Background(), alias, config); !errors.Is(err, types.ErrInvalidConfig) {
+			t.Errorf("Alias %q: expected ErrInvalidConfig, got %v", alias, err)
+		}
+		if err := manager.CleanupRepository(alias); !errors.Is(err, types.ErrInvalidConfig) {
+			t.Errorf("Alias %q: expected CleanupRepository to refuse it, got %v", alias, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("Expected no download for invalid aliases, got %d", requests)
+	}
+	if _, err := os.Stat(workDir); err != nil {
+		t.Errorf("Expected the working directory to survive, got %v", err)
+	}
+}
+
+// ************************************************************************************************
+// Test that git-tracked-only listing keeps the committed files, and leaves out untracked, ignored
+// and deleted ones
+func TestManager_ListFiles_GitTrackedOnly(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	repo, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatalf("Failed to init repository: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to open worktree: %v", err)
+	}
+	for _, name := range []string{"main.go", "docs/guide.md", ".gitignore", "removed.go"} {
+		writeFile(name, map[string]string{".gitignore": "*.log\n"}[name]+"content of "+name)
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+	}
+	_, err = worktree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	// Untracked, ignored and deleted files
+	writeFile("notes.txt", "local notes")
+	writeFile("build/out/app.bin", "binary")
+	writeFile("docs/draft.md", "draft")
+	writeFile("debug.log", "ignored")
+	if err := os.Remove(filepath.Join(root, "removed.go")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+
+	tracked, err := TrackedFiles(root)
+	if err != nil {
+		t.Fatalf("TrackedFiles failed: %v", err)
+	}
+	if len(tracked) != 4 || !tracked["docs/guide.md"] || !tracked["removed.go"] || tracked["notes.txt"] {
+		t.Errorf("Expected the 4 committed files, got %v", tracked)
+	}
+
+	manager := &Manager{workDir: t.TempDir()}
+	config := types.IndexingConfig{Enabled: true, GitTrackedOnly: true, ExcludePatterns: []string{".gitignore"}}
+	files, err := manager.ListFiles(root, config)
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	expected := []string{filepath.Join("docs", "guide.md"), "main.go"}
+	if !slices.Equal(files, expected) {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+
+	patterns, err := UntrackedPatterns(root, tracked)
+	if err != nil {
+		t.Fatalf("UntrackedPatterns failed: %v", err)
+	}
+	if expected := []string{"build/**", "debug.log", "docs/draft.md", "notes.txt"}; !slices.Equal(patterns, expected) {
+		t.Errorf("Expected untracked patterns %v, got %v", expected, patterns)
+	}
+
+	// Directories outside of git are walked
+	plain := t.TempDir()
+	if err := os.WriteFile(filepath.Join(plain, "notes.txt"), []byte("notes"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := TrackedFiles(plain); !errors.Is(err, types.ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath outside of git, got %v", err)
+	}
+	if files, err := manager.ListFiles(plain, config); err != nil || !slices.Equal(files, []string{"notes.txt"}) {
+		t.Errorf("Expected the filesystem walk outside of git, got %v (%v)", files, err)
+	}
+}
\ No newline at end of file
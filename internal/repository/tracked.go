@@ -0,0 +1,132 @@
+// ************************************************************************************************
+// Package repository provides git-tracked file enumeration for the repomix-mcp application.
+// When IndexingConfig.GitTrackedOnly is set, files are listed from the git index instead of
+// the filesystem so untracked build output and local files never reach the index.
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// TrackedFiles returns the files recorded in the git index of the repository rooted at
+// localPath, as slash-separated paths relative to the root.
+//
+// Returns:
+//   - map[string]bool: The set of tracked file paths.
+//   - error: An error if localPath is not the root of a git repository or the index is unreadable.
+//
+// Example usage:
+//
+//	tracked, err := repository.TrackedFiles("/path/to/repo")
+//	if err != nil {
+//		// Not a git repository, walk the filesystem instead
+//	}
+func TrackedFiles(localPath string) (map[string]bool, error) {
+	repo, err := mock_gitPlainOpen(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a git repository: %s\n>    %w", types.ErrInvalidPath, localPath, err)
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git index\n>    %w", err)
+	}
+
+	tracked := make(map[string]bool, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		tracked[entry.Name] = true
+	}
+	return tracked, nil
+}
+
+// ************************************************************************************************
+// UntrackedPatterns lists the untracked files and directories on disk below localPath as
+// ignore patterns, for tools that walk the filesystem themselves. A directory without any
+// tracked file is reported once as "dir/**" rather than file by file.
+//
+// Returns:
+//   - []string: Sorted slash-separated ignore patterns.
+//   - error: An error if the filesystem walk fails.
+func UntrackedPatterns(localPath string, tracked map[string]bool) ([]string, error) {
+	// Every ancestor directory of a tracked file must be descended into
+	trackedDirs := make(map[string]bool)
+	for name := range tracked {
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			trackedDirs[dir] = true
+		}
+	}
+
+	var patterns []string
+	err := filepath.Walk(localPath, func(walkPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(localPath, walkPath)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		name := filepath.ToSlash(relPath)
+
+		if info.IsDir() {
+			if name == ".git" {
+				return filepath.SkipDir
+			}
+			if !trackedDirs[name] {
+				patterns = append(patterns, name+"/**")
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !tracked[name] {
+			patterns = append(patterns, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk repository files\n>    %w", err)
+	}
+
+	sort.Strings(patterns)
+	return patterns, nil
+}
+
+// ************************************************************************************************
+// listTrackedFiles returns the tracked files of a git repository that match the indexing
+// configuration. Tracked files deleted from the working tree are skipped.
+//
+// Returns:
+//   - []string: List of file paths relative to repository root.
+//   - error: An error if localPath is not a git repository.
+func (m *Manager) listTrackedFiles(localPath string, indexingConfig types.IndexingConfig) ([]string, error) {
+	tracked, err := TrackedFiles(localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for name := range tracked {
+		relPath := filepath.FromSlash(name)
+		info, err := mock_osStat(filepath.Join(localPath, relPath))
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if m.shouldIndexFile(relPath, info, indexingConfig) {
+			files = append(files, relPath)
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
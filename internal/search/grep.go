@@ -0,0 +1,122 @@
+// ************************************************************************************************
+// Package search provides regular expression search over a single repository.
+// Unlike Search, which ranks files by their best match, Grep returns every matching line of
+// every file with its surrounding context, like the grep command.
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// Grep searches every line of a repository's files for a regular expression.
+//
+// Returns:
+//   - []types.GrepResult: The files with matching lines, sorted by path.
+//   - bool: True if results were cut at the maximum number of matches.
+//   - error: An error if the pattern is not a valid regular expression.
+//
+// Example usage:
+//
+//	results, truncated, err := engine.Grep(types.GrepQuery{Pattern: `func New\w+`, ContextLines: 2}, repo)
+//	if err != nil {
+//		return fmt.Errorf("grep failed: %w", err)
+//	}
+func (e *Engine) Grep(query types.GrepQuery, repo *types.RepositoryIndex) ([]types.GrepResult, bool, error) {
+	if query.Pattern == "" {
+		return nil, false, fmt.Errorf("%w: empty pattern", types.ErrInvalidSearchQuery)
+	}
+	pattern := query.Pattern
+	if query.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	regexPattern, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: invalid regular expression: %v", types.ErrInvalidSearchQuery, err)
+	}
+
+	paths := make([]string, 0, len(repo.Files))
+	for filePath, file := range repo.Files {
+		if query.FilePattern != "" && !matchesFilePattern(query.FilePattern, file.Path) {
+			continue
+		}
+		if query.Language != "" && file.Language != query.Language {
+			continue
+		}
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	var results []types.GrepResult
+	remaining := query.MaxMatches
+	for _, filePath := range paths {
+		file := repo.Files[filePath]
+		result, truncated := grepFile(regexPattern, file, query.ContextLines, remaining)
+		if result.MatchCount > 0 {
+			results = append(results, result)
+		}
+		if query.MaxMatches > 0 {
+			remaining -= result.MatchCount
+			if truncated || remaining == 0 {
+				return results, true, nil
+			}
+		}
+	}
+	return results, false, nil
+}
+
+// ************************************************************************************************
+// grepFile returns the matching lines of a file with their context, overlapping contexts merged.
+//
+// Returns:
+//   - types.GrepResult: The matching lines, none if the file does not match.
+//   - bool: True if the file has more matches than maxMatches, when positive.
+func grepFile(regexPattern *regexp.Regexp, file types.IndexedFile, contextLines, maxMatches int) (types.GrepResult, bool) {
+	result := types.GrepResult{Path: file.Path, Language: file.Language}
+	if contextLines < 0 {
+		contextLines = 0
+	}
+
+	lines := strings.Split(file.Content, "\n")
+	next := 0 // First line not yet added to the result
+	for index, line := range lines {
+		if !regexPattern.MatchString(line) {
+			continue
+		}
+		if maxMatches > 0 && result.MatchCount == maxMatches {
+			return result, true
+		}
+		result.MatchCount++
+
+		start := index - contextLines
+		if start < next {
+			start = next
+		}
+		for before := start; before < index; before++ {
+			result.Lines = append(result.Lines, types.GrepLine{Number: before + 1, Text: lines[before]})
+		}
+		if index >= next {
+			result.Lines = append(result.Lines, types.GrepLine{Number: index + 1, Text: line, Match: true})
+		} else {
+			// Already added as context of the previous match
+			result.Lines[len(result.Lines)-(next-index)].Match = true
+		}
+
+		end := index + contextLines
+		if end >= len(lines) {
+			end = len(lines) - 1
+		}
+		for after := max(index+1, next); after <= end; after++ {
+			result.Lines = append(result.Lines, types.GrepLine{Number: after + 1, Text: lines[after]})
+		}
+		if end+1 > next {
+			next = end + 1
+		}
+	}
+	return result, false
+}
@@ -0,0 +1,238 @@
+// ************************************************************************************************
+// Package search provides the inverted index of the search engine.
+// Repositories are added, replaced and removed one at a time, so a change to one repository
+// never rebuilds the index of the others. The index narrows a query down to the files
+// containing its words before they are scanned line by line.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// documentKey identifies an indexed file.
+type documentKey struct {
+	repositoryID string
+	path         string
+}
+
+// ************************************************************************************************
+// IndexRepository adds a repository to the inverted index, replacing the documents of an
+// earlier version of the same repository.
+//
+// Example usage:
+//
+//	engine.IndexRepository(repoIndex)
+//	results, err := engine.SearchIndexed(query)
+func (e *Engine) IndexRepository(repo *types.RepositoryIndex) {
+	if repo == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.removeRepositoryLocked(repo.ID)
+
+	terms := make(map[string][]string, len(repo.Files))
+	for filePath, file := range repo.Files {
+		key := documentKey{repositoryID: repo.ID, path: filePath}
+		fileTerms := indexTerms(file.Content + "\n" + file.Path)
+		for _, term := range fileTerms {
+			if e.postings[term] == nil {
+				e.postings[term] = make(map[documentKey]struct{})
+			}
+			e.postings[term][key] = struct{}{}
+		}
+		terms[filePath] = fileTerms
+	}
+	e.documents[repo.ID] = terms
+	e.repositories[repo.ID] = repo
+}
+
+// ************************************************************************************************
+// RemoveRepository removes a repository and its documents from the inverted index.
+// Unknown repositories are ignored.
+func (e *Engine) RemoveRepository(repositoryID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.removeRepositoryLocked(repositoryID)
+}
+
+// ************************************************************************************************
+// removeRepositoryLocked removes the documents of a repository, dropping the terms no other
+// document contains. The caller holds e.mu.
+func (e *Engine) removeRepositoryLocked(repositoryID string) {
+	for filePath, fileTerms := range e.documents[repositoryID] {
+		key := documentKey{repositoryID: repositoryID, path: filePath}
+		for _, term := range fileTerms {
+			delete(e.postings[term], key)
+			if len(e.postings[term]) == 0 {
+				delete(e.postings, term)
+			}
+		}
+	}
+	delete(e.documents, repositoryID)
+	delete(e.repositories, repositoryID)
+}
+
+// ************************************************************************************************
+// SearchIndexed searches the repositories added with IndexRepository. Only the files holding
+// every word of the query, as part of an indexed word, are scanned; regular expression
+// queries scan every file.
+//
+// Returns:
+//   - []types.SearchResult: Ranked search results.
+//   - error: An error if the query is invalid.
+//
+// Example usage:
+//
+//	results, err := engine.SearchIndexed(types.SearchQuery{Query: "NewServer", MaxResults: 10})
+func (e *Engine) SearchIndexed(query types.SearchQuery) ([]types.SearchResult, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	candidates := e.candidateRepositoriesLocked(query)
+	return e.Search(query, candidates)
+}
+
+// ************************************************************************************************
+// IndexedRepositoryIDs returns the IDs of the repositories in the inverted index.
+//
+// Returns:
+//   - []string: The repository IDs, sorted.
+func (e *Engine) IndexedRepositoryIDs() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	repoIDs := make([]string, 0, len(e.repositories))
+	for repoID := range e.repositories {
+		repoIDs = append(repoIDs, repoID)
+	}
+	sort.Strings(repoIDs)
+	return repoIDs
+}
+
+// ************************************************************************************************
+// candidateRepositoriesLocked narrows the indexed repositories down to the files that may match
+// a query. The caller holds e.mu.
+//
+// Returns:
+//   - map[string]*types.RepositoryIndex: Repository views holding only the candidate files.
+func (e *Engine) candidateRepositoriesLocked(query types.SearchQuery) map[string]*types.RepositoryIndex {
+	words := indexTerms(query.Query)
+	isRegex := strings.HasPrefix(query.Query, "/") && strings.HasSuffix(query.Query, "/") && len(query.Query) > 2
+	if isRegex || len(words) == 0 {
+		return e.repositories
+	}
+
+	// A query word may be part of a longer word in the content, match it against the vocabulary
+	var candidates map[documentKey]struct{}
+	for _, word := range words {
+		matching := make(map[documentKey]struct{})
+		for term, documents := range e.postings {
+			if !strings.Contains(term, word) {
+				continue
+			}
+			for key := range documents {
+				if candidates == nil {
+					matching[key] = struct{}{}
+				} else if _, exists := candidates[key]; exists {
+					matching[key] = struct{}{}
+				}
+			}
+		}
+		candidates = matching
+		if len(candidates) == 0 {
+			break
+		}
+	}
+
+	views := make(map[string]*types.RepositoryIndex)
+	for key := range candidates {
+		repo := e.repositories[key.repositoryID]
+		view, exists := views[key.repositoryID]
+		if !exists {
+			copied := *repo
+			copied.Files = make(map[string]types.IndexedFile)
+			view = &copied
+			views[key.repositoryID] = view
+		}
+		view.Files[key.path] = repo.Files[key.path]
+	}
+	return views
+}
+
+// ************************************************************************************************
+// indexTerms splits text into its distinct lowercase words of letters and digits.
+//
+// Returns:
+//   - []string: The words, in order of first occurrence.
+func indexTerms(text string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// ************************************************************************************************
+// IndexedSearcher searches the inverted index of an engine with a query alone, and keeps the
+// index up to date as repositories change: the search interface of the MCP server.
+type IndexedSearcher struct {
+	engine *Engine
+}
+
+// ************************************************************************************************
+// Indexed returns the searcher of the repositories added to the engine's inverted index.
+//
+// Example usage:
+//
+//	server, err := mcp.NewServer(config, cache, search.NewEngine().Indexed())
+func (e *Engine) Indexed() *IndexedSearcher {
+	return &IndexedSearcher{engine: e}
+}
+
+// ************************************************************************************************
+// Search searches the indexed repositories, see Engine.SearchIndexed.
+//
+// Returns:
+//   - []types.SearchResult: Ranked search results.
+//   - error: An error if the query is invalid.
+func (i *IndexedSearcher) Search(query types.SearchQuery) ([]types.SearchResult, error) {
+	return i.engine.SearchIndexed(query)
+}
+
+// ************************************************************************************************
+// IndexRepository adds or replaces a repository in the inverted index.
+func (i *IndexedSearcher) IndexRepository(repo *types.RepositoryIndex) {
+	i.engine.IndexRepository(repo)
+}
+
+// ************************************************************************************************
+// RemoveRepository removes a repository from the inverted index.
+func (i *IndexedSearcher) RemoveRepository(repositoryID string) {
+	i.engine.RemoveRepository(repositoryID)
+}
+
+// ************************************************************************************************
+// Grep searches every line of a repository for a regular expression, see Engine.Grep.
+//
+// Returns:
+//   - []types.GrepResult: The files with matching lines, sorted by path.
+//   - bool: True if results were cut at the maximum number of matches.
+//   - error: An error if the pattern is invalid.
+func (i *IndexedSearcher) Grep(query types.GrepQuery, repo *types.RepositoryIndex) ([]types.GrepResult, bool, error) {
+	return i.engine.Grep(query, repo)
+}
\ No newline at end of file
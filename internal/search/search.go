@@ -6,9 +6,12 @@ package search
 
 import (
 	"fmt"
+	"path"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 
 	"repomix-mcp/pkg/types"
 )
@@ -16,9 +19,13 @@ import (
 // ************************************************************************************************
 // Engine provides search functionality for indexed repository content.
 // It supports text-based searching with filtering and ranking capabilities
-// to help users find relevant content across repositories.
+// to help users find relevant content across repositories, and keeps an
+// inverted index of the repositories added to it.
 type Engine struct {
-	// Future: can add more sophisticated indexing like inverted indexes
+	mu           sync.RWMutex
+	postings     map[string]map[documentKey]struct{} // Documents by lowercase word
+	documents    map[string]map[string][]string      // Words by repository ID, then file path
+	repositories map[string]*types.RepositoryIndex   // Indexed repositories by ID
 }
 
 // ************************************************************************************************
@@ -32,7 +39,11 @@ type Engine struct {
 //	engine := NewEngine()
 //	results, err := engine.Search(query, repositories)
 func NewEngine() *Engine {
-	return &Engine{}
+	return &Engine{
+		postings:     make(map[string]map[documentKey]struct{}),
+		documents:    make(map[string]map[string][]string),
+		repositories: make(map[string]*types.RepositoryIndex),
+	}
 }
 
 // ************************************************************************************************
@@ -96,10 +107,8 @@ func (e *Engine) searchRepository(query types.SearchQuery, repo *types.Repositor
 
 	for _, file := range repo.Files {
 		// Apply file pattern filter
-		if query.FilePattern != "" {
-			if matched, _ := mock_filepathMatch(query.FilePattern, file.Path); !matched {
-				continue
-			}
+		if query.FilePattern != "" && !matchesFilePattern(query.FilePattern, file.Path) {
+			continue
 		}
 
 		// Apply language filter
@@ -138,11 +147,26 @@ func (e *Engine) searchFile(query types.SearchQuery, file types.IndexedFile) []t
 		}
 	}
 
+	// Restrict to exported constructs when the parser recorded their lines;
+	// files without construct information are searched in full
+	var exportedLines []types.LineRange
+	restrictToExported := false
+	if query.ExportedOnly {
+		if value, exists := file.Metadata[types.ExportedLinesMetadataKey]; exists {
+			exportedLines = types.ParseLineRanges(value)
+			restrictToExported = true
+		}
+	}
+
 	matchCount := 0
 	var bestMatch types.SearchResult
 
 	// Search through each line
 	for lineNum, line := range lines {
+		if restrictToExported && !inLineRanges(exportedLines, lineNum+1) {
+			continue
+		}
+
 		var matched bool
 		var highlightedLine string
 
@@ -246,6 +270,11 @@ func (e *Engine) calculateScore(query types.SearchQuery, file types.IndexedFile,
 		score = 1.0
 	}
 
+	// Penalize minified files, their single huge lines make poor snippets
+	if file.Metadata["minified"] == "true" {
+		score *= 0.5
+	}
+
 	return score
 }
 
@@ -266,35 +295,23 @@ func (e *Engine) highlightMatches(line, query string) string {
 	// Find all matches and replace them with highlighted versions
 	result := line
 	searchLen := len(query)
+	offset := 0
 	
-	for {
-		index := strings.Index(strings.ToLower(result), lowerQuery)
+	for offset < len(result) {
+		// Continue searching after the previous highlighted match
+		index := strings.Index(strings.ToLower(result[offset:]), lowerQuery)
 		if index == -1 {
 			break
 		}
+		index += offset
 		
 		// Extract the actual match (preserving original case)
 		match := result[index : index+searchLen]
 		highlighted := fmt.Sprintf("**%s**", match)
 		
-		// Replace this occurrence
+		// Replace this occurrence and move past the highlighted portion
 		result = result[:index] + highlighted + result[index+searchLen:]
-		
-		// Move past the highlighted portion to find next occurrence
-		offset := index + len(highlighted)
-		if offset >= len(result) {
-			break
-		}
-		
-		// Continue searching from after this match
-		remaining := result[offset:]
-		nextIndex := strings.Index(strings.ToLower(remaining), lowerQuery)
-		if nextIndex == -1 {
-			break
-		}
-		
-		// Adjust the result to continue search
-		result = result[:offset] + remaining
+		offset = index + len(highlighted)
 	}
 
 	return result
@@ -457,4 +474,27 @@ func (e *Engine) GetSuggestions(prefix string, repositories map[string]*types.Re
 	}
 
 	return result, nil
+}
+
+// ************************************************************************************************
+// inLineRanges reports whether a 1-based line number falls within one of the ranges.
+func inLineRanges(ranges []types.LineRange, line int) bool {
+	for _, r := range ranges {
+		if line >= r.Start && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// ************************************************************************************************
+// matchesFilePattern reports whether a file path matches a glob: against the whole path when the
+// pattern holds a slash, e.g. "internal/*/*.go", and against the file name otherwise, e.g. "*.go".
+func matchesFilePattern(pattern, filePath string) bool {
+	filePath = filepath.ToSlash(filePath)
+	if !strings.Contains(pattern, "/") {
+		filePath = path.Base(filePath)
+	}
+	matched, _ := mock_filepathMatch(pattern, filePath)
+	return matched
 }
\ No newline at end of file
@@ -0,0 +1,154 @@
+// ************************************************************************************************
+// Package search - Unit tests for the search engine.
+// This file covers incremental updates of the inverted index, match highlighting and grep.
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// newTestRepository builds a repository index from file contents by path.
+func newTestRepository(id string, files map[string]string) *types.RepositoryIndex {
+	repo := &types.RepositoryIndex{ID: id, Files: make(map[string]types.IndexedFile)}
+	for path, content := range files {
+		repo.Files[path] = types.IndexedFile{Path: path, Content: content, RepositoryID: id, Size: int64(len(content))}
+	}
+	return repo
+}
+
+// ************************************************************************************************
+// searchPaths returns the "repository:path" of every result of an indexed search.
+func searchPaths(t *testing.T, engine *Engine, query string) map[string]bool {
+	t.Helper()
+	results, err := engine.SearchIndexed(types.SearchQuery{Query: query})
+	if err != nil {
+		t.Fatalf("SearchIndexed(%q) failed: %v", query, err)
+	}
+	paths := make(map[string]bool)
+	for _, result := range results {
+		paths[result.File.RepositoryID+":"+result.File.Path] = true
+	}
+	return paths
+}
+
+// ************************************************************************************************
+// Test that adding, modifying and removing a repository is reflected by indexed searches
+func TestEngine_IncrementalIndex(t *testing.T) {
+	engine := NewEngine()
+	engine.IndexRepository(newTestRepository("other", map[string]string{
+		"other.go": "func Unrelated() {}",
+	}))
+
+	// Add
+	engine.IndexRepository(newTestRepository("app", map[string]string{
+		"server.go": "func NewServer(config Config) *Server",
+		"README.md": "# App\nStart the server with NewServer.",
+	}))
+	paths := searchPaths(t, engine, "newserver")
+	if !paths["app:server.go"] || !paths["app:README.md"] || len(paths) != 2 {
+		t.Errorf("Expected both app files after adding, got %v", paths)
+	}
+	if paths := searchPaths(t, engine, "Serv"); !paths["app:server.go"] {
+		t.Errorf("Expected a partial word to match, got %v", paths)
+	}
+
+	// Modify: the new content is found, the old content is gone
+	engine.IndexRepository(newTestRepository("app", map[string]string{
+		"server.go": "func NewHTTPServer(config Config) *Server",
+	}))
+	if paths := searchPaths(t, engine, "NewServer"); len(paths) != 0 {
+		t.Errorf("Expected replaced content not to match, got %v", paths)
+	}
+	if paths := searchPaths(t, engine, "NewHTTPServer"); !paths["app:server.go"] || len(paths) != 1 {
+		t.Errorf("Expected the modified file to match, got %v", paths)
+	}
+
+	// Remove: only the other repository is left
+	engine.RemoveRepository("app")
+	if paths := searchPaths(t, engine, "Server"); len(paths) != 0 {
+		t.Errorf("Expected no match after removal, got %v", paths)
+	}
+	if paths := searchPaths(t, engine, "Unrelated"); !paths["other:other.go"] {
+		t.Errorf("Expected the other repository to be unaffected, got %v", paths)
+	}
+	if ids := engine.IndexedRepositoryIDs(); len(ids) != 1 || ids[0] != "other" {
+		t.Errorf("Expected only the other repository to be indexed, got %v", ids)
+	}
+	if _, exists := engine.postings["newhttpserver"]; exists {
+		t.Error("Expected the terms of the removed repository to be dropped")
+	}
+}
+
+// ************************************************************************************************
+// Test that every occurrence on a line is highlighted once, preserving its case
+func TestEngine_highlightMatches(t *testing.T) {
+	engine := NewEngine()
+	highlighted := engine.highlightMatches("func NewServer() *Server", "server")
+	if expected := "func New**Server**() ***Server**"; highlighted != expected {
+		t.Errorf("Expected %q, got %q", expected, highlighted)
+	}
+}
+
+// ************************************************************************************************
+// Test that file patterns without a slash match file names and others match paths
+func TestMatchesFilePattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		filePath string
+		want     bool
+	}{
+		{"*.go", "internal/cache/cache.go", true},
+		{"*.go", "README.md", false},
+		{"cache_*.go", "internal/cache/cache_test.go", true},
+		{"internal/*/*.go", "internal/cache/cache.go", true},
+		{"internal/*.go", "internal/cache/cache.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesFilePattern(tt.pattern, tt.filePath); got != tt.want {
+			t.Errorf("matchesFilePattern(%q, %q) = %v, want %v", tt.pattern, tt.filePath, got, tt.want)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that Grep returns every matching line with merged context and honors the match limit
+func TestEngine_Grep(t *testing.T) {
+	engine := NewEngine()
+	repo := &types.RepositoryIndex{ID: "app", Files: map[string]types.IndexedFile{
+		"server.go": {Path: "server.go", Language: "go", Content: "package app\n\nfunc NewServer() {}\nfunc newRouter() {}\n\n// end"},
+		"README.md": {Path: "README.md", Language: "markdown", Content: "Call NewServer."},
+	}}
+
+	results, truncated, err := engine.Grep(types.GrepQuery{Pattern: `^func \w+`, ContextLines: 1}, repo)
+	if err != nil {
+		t.Fatalf("Grep failed: %v", err)
+	}
+	if truncated || len(results) != 1 || results[0].Path != "server.go" || results[0].MatchCount != 2 {
+		t.Fatalf("Expected two matches in server.go, got %+v", results)
+	}
+	var lines []string
+	for _, line := range results[0].Lines {
+		lines = append(lines, fmt.Sprintf("%d:%v", line.Number, line.Match))
+	}
+	if got := strings.Join(lines, " "); got != "2:false 3:true 4:true 5:false" {
+		t.Errorf("Expected overlapping contexts merged, got %s", got)
+	}
+
+	results, truncated, _ = engine.Grep(types.GrepQuery{Pattern: "newserver", IgnoreCase: true, MaxMatches: 1}, repo)
+	if !truncated || len(results) != 1 || results[0].Path != "README.md" {
+		t.Errorf("Expected the first file in path order and a truncation, got %+v (truncated=%v)", results, truncated)
+	}
+	if results, _, _ := engine.Grep(types.GrepQuery{Pattern: "NewServer", Language: "go"}, repo); len(results) != 1 || results[0].Path != "server.go" {
+		t.Errorf("Expected the language filter to apply, got %+v", results)
+	}
+	if _, _, err := engine.Grep(types.GrepQuery{Pattern: "func ("}, repo); !errors.Is(err, types.ErrInvalidSearchQuery) {
+		t.Errorf("Expected an invalid pattern to be reported, got %v", err)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,12 @@
+package tracing
+
+import (
+	"time"
+)
+
+// ************************************************************************************************
+// Mock functions to allow easy and in depth unit test
+var (
+	// Mock for external package
+	mock_timeNow = time.Now
+)
@@ -0,0 +1,234 @@
+// ************************************************************************************************
+// Package tracing provides the OTLP/HTTP export of the recorded spans.
+// Ended spans are queued and posted in batches, JSON encoded, to the collector by a background
+// goroutine, so recording a span never waits for the network. Spans ended while the queue is
+// full are dropped, and failed exports are logged and not retried.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ************************************************************************************************
+// Export settings.
+const (
+	exportQueueSize     = 4096             // Ended spans waiting for export
+	exportBatchSize     = 512              // Spans posted at most per request
+	exportInterval      = 5 * time.Second  // Time after which a partial batch is posted
+	exportTimeout       = 10 * time.Second // Time allowed to post a batch
+	exportShutdownDelay = 5 * time.Second  // Time allowed to post the pending spans on shutdown
+)
+
+// ************************************************************************************************
+// OTLP span kind and status codes.
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusCodeError  = 2
+)
+
+// ************************************************************************************************
+// exporter posts the ended spans to an OTLP/HTTP collector.
+type exporter struct {
+	endpoint    string
+	serviceName string
+	headers     map[string]string
+	client      *http.Client
+
+	queue    chan *Span
+	done     chan struct{}
+	finished chan struct{}
+	once     sync.Once
+}
+
+// ************************************************************************************************
+// newExporter creates an exporter and starts posting the spans it is given.
+func newExporter(endpoint, serviceName string, headers map[string]string) *exporter {
+	e := &exporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		headers:     headers,
+		client:      &http.Client{Timeout: exportTimeout},
+		queue:       make(chan *Span, exportQueueSize),
+		done:        make(chan struct{}),
+		finished:    make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// ************************************************************************************************
+// enqueue queues an ended span, dropping it when the queue is full.
+func (e *exporter) enqueue(span *Span) {
+	select {
+	case e.queue <- span:
+	default:
+	}
+}
+
+// ************************************************************************************************
+// run posts the queued spans in batches until the exporter is shut down, then posts the spans
+// left.
+func (e *exporter) run() {
+	defer close(e.finished)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, exportBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.export(batch); err != nil {
+			slog.Warn("Failed to export spans", "spans", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) == exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.done:
+			for {
+				select {
+				case span := <-e.queue:
+					batch = append(batch, span)
+					if len(batch) == exportBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// ************************************************************************************************
+// shutdown posts the pending spans and stops the exporter, waiting for exportShutdownDelay at
+// most.
+func (e *exporter) shutdown() error {
+	e.once.Do(func() { close(e.done) })
+	select {
+	case <-e.finished:
+		return nil
+	case <-time.After(exportShutdownDelay):
+		return fmt.Errorf("timed out exporting the pending spans")
+	}
+}
+
+// ************************************************************************************************
+// export posts a batch of spans to the collector.
+//
+// Returns:
+//   - error: An error if the request fails or the collector refuses it.
+func (e *exporter) export(spans []*Span) error {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans\n>    %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create export request\n>    %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post spans\n>    %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector answered %s", resp.Status)
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// request returns the OTLP ExportTraceServiceRequest of a batch of spans, in the JSON encoding
+// of the OTLP/HTTP protocol: IDs in hexadecimal and 64-bit integers as strings.
+func (e *exporter) request(spans []*Span) map[string]interface{} {
+	encoded := make([]map[string]interface{}, 0, len(spans))
+	for _, span := range spans {
+		encoded = append(encoded, span.otlp())
+	}
+	return map[string]interface{}{
+		"resourceSpans": []map[string]interface{}{{
+			"resource": map[string]interface{}{
+				"attributes": otlpAttributes([]Attribute{String("service.name", e.serviceName)}),
+			},
+			"scopeSpans": []map[string]interface{}{{
+				"scope": map[string]interface{}{"name": defaultServiceName},
+				"spans": encoded,
+			}},
+		}},
+	}
+}
+
+// ************************************************************************************************
+// otlp returns the OTLP Span of an ended span.
+func (s *Span) otlp() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	encoded := map[string]interface{}{
+		"traceId":           hex.EncodeToString(s.context.traceID[:]),
+		"spanId":            hex.EncodeToString(s.context.spanID[:]),
+		"name":              s.name,
+		"kind":              otlpSpanKindInternal,
+		"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+		"attributes":        otlpAttributes(s.attributes),
+	}
+	if s.parentID != ([8]byte{}) {
+		encoded["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+	}
+	if s.errMessage != "" {
+		encoded["status"] = map[string]interface{}{"code": otlpStatusCodeError, "message": s.errMessage}
+	}
+	return encoded
+}
+
+// ************************************************************************************************
+// otlpAttributes returns the OTLP KeyValue list of attributes.
+func otlpAttributes(attributes []Attribute) []map[string]interface{} {
+	encoded := make([]map[string]interface{}, 0, len(attributes))
+	for _, attribute := range attributes {
+		var value map[string]interface{}
+		switch v := attribute.Value.(type) {
+		case int64:
+			value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case bool:
+			value = map[string]interface{}{"boolValue": v}
+		case float64:
+			value = map[string]interface{}{"doubleValue": v}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+		}
+		encoded = append(encoded, map[string]interface{}{"key": attribute.Key, "value": value})
+	}
+	return encoded
+}
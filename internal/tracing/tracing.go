@@ -0,0 +1,337 @@
+// ************************************************************************************************
+// Package tracing provides the OpenTelemetry tracing of the repomix-mcp application.
+// Tool calls, cache accesses, git operations and the repomix and go subprocesses record spans,
+// exported in batches to an OTLP/HTTP collector, so a slow get-library-docs call can be followed
+// step by step in Jaeger, Tempo or any OpenTelemetry backend. Until Setup is called with an
+// endpoint, tracing is disabled and spans are nil, whose methods do nothing.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// defaultServiceName is the service.name of the exported spans when none is configured.
+const defaultServiceName = "repomix-mcp"
+
+// ************************************************************************************************
+// activeTracer is the tracer of the running application, nil while tracing is disabled.
+var activeTracer atomic.Pointer[tracer]
+
+// ************************************************************************************************
+// tracer samples the traces and queues the ended spans of the recorded ones for export.
+type tracer struct {
+	sampleRatio float64
+	exporter    *exporter
+}
+
+// ************************************************************************************************
+// Attribute is a key-value pair describing a span, such as the repository or tool it handles.
+type Attribute struct {
+	Key   string
+	Value interface{} // string, int64, bool or float64
+}
+
+// ************************************************************************************************
+// String returns a string attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// ************************************************************************************************
+// Int returns an integer attribute.
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// ************************************************************************************************
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// ************************************************************************************************
+// spanContext identifies a span within its trace, as propagated by the W3C traceparent header.
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+	sampled bool
+}
+
+// ************************************************************************************************
+// Span is an operation of a trace. A nil span, returned while tracing is disabled, ignores every
+// call.
+type Span struct {
+	tracer   *tracer
+	name     string
+	context  spanContext
+	parentID [8]byte
+	start    time.Time
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes []Attribute
+	errMessage string // Status message, the span failed when not empty
+	ended      bool
+}
+
+// ************************************************************************************************
+// spanContextKey is the context key of the current span.
+type spanContextKey struct{}
+
+// ************************************************************************************************
+// remoteContextKey is the context key of the span of the caller, received in a traceparent
+// header.
+type remoteContextKey struct{}
+
+// ************************************************************************************************
+// Enabled reports whether spans are recorded and exported.
+func Enabled() bool {
+	return activeTracer.Load() != nil
+}
+
+// ************************************************************************************************
+// Start starts a span, child of the current span of ctx or of the caller's span extracted from
+// a traceparent header, and returns a context carrying it. End must be called on the span.
+//
+// Returns:
+//   - context.Context: The context carrying the new span, ctx itself while tracing is disabled.
+//   - *Span: The span, nil while tracing is disabled.
+//
+// Example usage:
+//
+//	ctx, span := tracing.Start(ctx, "cache.GetRepository", tracing.String("repository.id", id))
+//	defer span.End()
+func Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, *Span) {
+	t := activeTracer.Load()
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer:     t,
+		name:       name,
+		start:      mock_timeNow(),
+		attributes: attributes,
+	}
+	if parent, ok := ctx.Value(spanContextKey{}).(*Span); ok && parent != nil {
+		span.context.traceID = parent.context.traceID
+		span.context.sampled = parent.context.sampled
+		span.parentID = parent.context.spanID
+	} else if remote, ok := ctx.Value(remoteContextKey{}).(spanContext); ok {
+		span.context.traceID = remote.traceID
+		span.context.sampled = remote.sampled
+		span.parentID = remote.spanID
+	} else {
+		span.context.traceID = newTraceID()
+		span.context.sampled = t.sample(span.context.traceID)
+	}
+	span.context.spanID = newSpanID()
+
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// ************************************************************************************************
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes = append(s.attributes, attributes...)
+}
+
+// ************************************************************************************************
+// RecordError marks the span as failed with the message of err. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errMessage = err.Error()
+}
+
+// ************************************************************************************************
+// End ends the span and queues it for export when its trace is sampled. Only the first call
+// counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = mock_timeNow()
+	s.mu.Unlock()
+
+	if s.context.sampled {
+		s.tracer.exporter.enqueue(s)
+	}
+}
+
+// ************************************************************************************************
+// Extract returns a context carrying the caller's span of a W3C traceparent header, so the
+// spans of a request join the trace of the client. Invalid headers are ignored.
+//
+// Example usage:
+//
+//	ctx := tracing.Extract(r.Context(), r.Header)
+func Extract(ctx context.Context, header http.Header) context.Context {
+	if !Enabled() {
+		return ctx
+	}
+	remote, ok := parseTraceparent(header.Get("traceparent"))
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteContextKey{}, remote)
+}
+
+// ************************************************************************************************
+// parseTraceparent parses a W3C traceparent header: version, trace ID, parent span ID and
+// flags, e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+//
+// Returns:
+//   - spanContext: The caller's span.
+//   - bool: False if the header is missing or invalid.
+func parseTraceparent(value string) (spanContext, bool) {
+	var remote spanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return remote, false
+	}
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != len(remote.traceID) {
+		return remote, false
+	}
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != len(remote.spanID) {
+		return remote, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return remote, false
+	}
+	copy(remote.traceID[:], traceID)
+	copy(remote.spanID[:], spanID)
+	if remote.traceID == ([16]byte{}) || remote.spanID == ([8]byte{}) {
+		return remote, false
+	}
+	remote.sampled = flags[0]&1 == 1
+	return remote, true
+}
+
+// ************************************************************************************************
+// sample decides whether a new trace is recorded, from its random trace ID so every span of
+// the trace gets the same answer.
+func (t *tracer) sample(traceID [16]byte) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	return float64(binary.BigEndian.Uint64(traceID[8:])>>11) < t.sampleRatio*(1<<53)
+}
+
+// ************************************************************************************************
+// newTraceID returns a random trace ID.
+func newTraceID() [16]byte {
+	var id [16]byte
+	for id == ([16]byte{}) {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+// ************************************************************************************************
+// newSpanID returns a random span ID.
+func newSpanID() [8]byte {
+	var id [8]byte
+	for id == ([8]byte{}) {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+// ************************************************************************************************
+// Setup enables tracing when a collector endpoint is configured in server.tracing or the
+// OTEL_EXPORTER_OTLP_ENDPOINT environment variable. Spans are posted to the /v1/traces path of
+// the endpoint, unless the endpoint already names it.
+//
+// Returns:
+//   - io.Closer: Exports the pending spans and disables tracing, nil when tracing is disabled.
+//   - error: An error if the tracing configuration is invalid.
+//
+// Example usage:
+//
+//	tracer, err := tracing.Setup(&config.Server)
+//	if err != nil {
+//		return err
+//	}
+//	if tracer != nil {
+//		defer tracer.Close()
+//	}
+func Setup(server *types.ServerConfig) (io.Closer, error) {
+	config := server.Tracing
+	endpoint := strings.TrimSpace(config.Endpoint)
+	if endpoint == "" {
+		endpoint = strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	}
+	if endpoint == "" {
+		return nil, nil
+	}
+
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: invalid tracing endpoint: %s", types.ErrInvalidConfig, endpoint)
+	}
+	if !strings.HasSuffix(parsed.Path, "/v1/traces") {
+		parsed.Path = strings.TrimSuffix(parsed.Path, "/") + "/v1/traces"
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = os.Getenv("OTEL_SERVICE_NAME")
+	}
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	sampleRatio := 1.0
+	if config.SampleRatio != nil {
+		sampleRatio = math.Max(0, math.Min(1, *config.SampleRatio))
+	}
+
+	t := &tracer{
+		sampleRatio: sampleRatio,
+		exporter:    newExporter(parsed.String(), serviceName, config.Headers),
+	}
+	activeTracer.Store(t)
+	slog.Info("Exporting traces", "service", serviceName, "endpoint", parsed.Redacted(), "sampleRatio", sampleRatio)
+	return t, nil
+}
+
+// ************************************************************************************************
+// Close disables tracing and exports the spans still queued.
+func (t *tracer) Close() error {
+	activeTracer.CompareAndSwap(t, nil)
+	return t.exporter.shutdown()
+}
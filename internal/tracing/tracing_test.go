@@ -0,0 +1,168 @@
+// ************************************************************************************************
+// Package tracing - Unit tests for span recording, traceparent propagation and OTLP export.
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"repomix-mcp/pkg/types"
+)
+
+// ************************************************************************************************
+// Test that spans are nil and ignored while tracing is disabled
+func TestStart_Disabled(t *testing.T) {
+	ctx, span := Start(context.Background(), "disabled")
+	if span != nil || ctx != context.Background() {
+		t.Fatal("Expected no span while tracing is disabled")
+	}
+	span.SetAttributes(String("key", "value"))
+	span.RecordError(errors.New("ignored"))
+	span.End()
+
+	closer, err := Setup(&types.ServerConfig{})
+	if err != nil || closer != nil {
+		t.Errorf("Expected tracing to stay disabled without endpoint, got %v, %v", closer, err)
+	}
+}
+
+// ************************************************************************************************
+// Test the parsing of W3C traceparent headers
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		value   string
+		valid   bool
+		sampled bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		remote, ok := parseTraceparent(tt.value)
+		if ok != tt.valid || (ok && remote.sampled != tt.sampled) {
+			t.Errorf("parseTraceparent(%q) = %v, sampled %v; expected %v, sampled %v", tt.value, ok, remote.sampled, tt.valid, tt.sampled)
+		}
+	}
+}
+
+// ************************************************************************************************
+// Test that spans are exported to the collector in the OTLP JSON encoding, children of the
+// caller's span
+func TestSetup_Export(t *testing.T) {
+	var mu sync.Mutex
+	var requests []map[string]interface{}
+	var paths []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request map[string]interface{}
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("Invalid export request: %v", err)
+		}
+		mu.Lock()
+		requests = append(requests, request)
+		paths = append(paths, r.URL.Path+" "+r.Header.Get("X-Api-Key"))
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	closer, err := Setup(&types.ServerConfig{Tracing: types.TracingConfig{
+		Endpoint:    collector.URL,
+		ServiceName: "test-service",
+		Headers:     map[string]string{"X-Api-Key": "secret"},
+	}})
+	if err != nil || closer == nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, parent := Start(Extract(context.Background(), header), "tools/call get-library-docs", String("mcp.tool.name", "get-library-docs"))
+	_, child := Start(ctx, "cache.GetRepository", Bool("cache.hit", false))
+	child.RecordError(errors.New("boom"))
+	child.End()
+	parent.SetAttributes(Int("repository.files", 3))
+	parent.End()
+	parent.End()
+
+	if err := closer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if Enabled() {
+		t.Error("Expected tracing to be disabled once closed")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 1 || paths[0] != "/v1/traces secret" {
+		t.Fatalf("Expected one export to /v1/traces with the headers, got %v", paths)
+	}
+	resourceSpans := requests[0]["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	serviceName := resourceSpans["resource"].(map[string]interface{})["attributes"].([]interface{})[0].(map[string]interface{})
+	if value := serviceName["value"].(map[string]interface{})["stringValue"]; value != "test-service" {
+		t.Errorf("Expected service.name test-service, got %v", value)
+	}
+
+	spans := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	exportedChild, exportedParent := spans[0].(map[string]interface{}), spans[1].(map[string]interface{})
+	if exportedParent["traceId"] != "4bf92f3577b34da6a3ce929d0e0e4736" || exportedParent["parentSpanId"] != "00f067aa0ba902b7" {
+		t.Errorf("Expected the span to join the caller's trace, got %v", exportedParent)
+	}
+	if exportedChild["traceId"] != exportedParent["traceId"] || exportedChild["parentSpanId"] != exportedParent["spanId"] {
+		t.Errorf("Expected a child span, got %v", exportedChild)
+	}
+	if status, _ := exportedChild["status"].(map[string]interface{}); status == nil || status["message"] != "boom" {
+		t.Errorf("Expected the error status of the child span, got %v", exportedChild["status"])
+	}
+	if attributes := exportedParent["attributes"].([]interface{}); len(attributes) != 2 {
+		t.Errorf("Expected 2 attributes on the parent span, got %v", attributes)
+	}
+}
+
+// ************************************************************************************************
+// Test that traces are not exported when not sampled
+func TestSetup_Sampling(t *testing.T) {
+	exported := false
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exported = true
+	}))
+	defer collector.Close()
+
+	ratio := 0.0
+	closer, err := Setup(&types.ServerConfig{Tracing: types.TracingConfig{Endpoint: collector.URL + "/v1/traces", SampleRatio: &ratio}})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	ctx, span := Start(context.Background(), "unsampled")
+	_, child := Start(ctx, "unsampled child")
+	child.End()
+	span.End()
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	_, remote := Start(Extract(context.Background(), header), "caller not sampled")
+	remote.End()
+
+	if err := closer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if exported {
+		t.Error("Expected no span to be exported")
+	}
+}
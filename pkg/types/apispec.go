@@ -0,0 +1,524 @@
+// ************************************************************************************************
+// Package types provides OpenAPI/Swagger specification support for the repomix-mcp application.
+// Specification files are detected at indexing time so agents can fetch the API contract of a
+// repository directly, or only the list of its endpoints.
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ************************************************************************************************
+// APISpecsMetadataKey is the repository metadata key holding the paths of the API specifications.
+const APISpecsMetadataKey = "api_specs"
+
+// ************************************************************************************************
+// APISpecFileType is the file_type metadata value of indexed API specification files.
+const APISpecFileType = "api_spec"
+
+// ************************************************************************************************
+// DefaultAPISpecFiles are detected when IndexingConfig.APISpecFiles is empty.
+var DefaultAPISpecFiles = []string{
+	"openapi.yaml", "openapi.yml", "openapi.json",
+	"swagger.yaml", "swagger.yml", "swagger.json",
+}
+
+// ************************************************************************************************
+// apiSpecMethods are the operation keys of an OpenAPI path item, in display order.
+var apiSpecMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// ************************************************************************************************
+// APIEndpoint is an operation declared by an API specification.
+type APIEndpoint struct {
+	Method      string `json:"method"`      // HTTP method, upper case
+	Path        string `json:"path"`        // Path template, e.g. "/users/{id}"
+	OperationID string `json:"operationId"` // Operation ID, empty if not declared
+	Summary     string `json:"summary"`     // Operation summary, empty if not declared
+}
+
+// ************************************************************************************************
+// APISpecSummary is the outline of an API specification.
+type APISpecSummary struct {
+	Version   string        `json:"version"`   // Specification version, e.g. "3.0.3" or "2.0"
+	Title     string        `json:"title"`     // API title from the info object
+	Endpoints []APIEndpoint `json:"endpoints"` // Operations sorted by path then method
+	Schemas   []APISchema   `json:"schemas"`   // Schemas of components.schemas or definitions, sorted by name
+}
+
+// ************************************************************************************************
+// APISchema is a named schema declared by an API specification.
+type APISchema struct {
+	Name       string              `json:"name"`       // Schema name
+	Type       string              `json:"type"`       // Schema type, e.g. "object", or the schema it refers to
+	Properties []APISchemaProperty `json:"properties"` // Object properties sorted by name
+}
+
+// ************************************************************************************************
+// APISchemaProperty is a property of an object schema.
+type APISchemaProperty struct {
+	Name     string `json:"name"`     // Property name
+	Type     string `json:"type"`     // Property type, e.g. "string", "Pet" for references or "[]Pet" for arrays
+	Required bool   `json:"required"` // Whether the schema lists the property as required
+}
+
+// ************************************************************************************************
+// apiSchemaNode is the part of a JSON schema a summary is made of. JSON schemas are decoded into
+// it and YAML schemas are built from their lines.
+type apiSchemaNode struct {
+	Type       apiSchemaType             `json:"type"`
+	Ref        string                    `json:"$ref"`
+	Items      *apiSchemaNode            `json:"items"`
+	Properties map[string]*apiSchemaNode `json:"properties"`
+	Required   []string                  `json:"required"`
+}
+
+// ************************************************************************************************
+// IsAPISpecFile reports whether a file is an API specification: its name or its path relative
+// to the repository root matches one of the patterns, DefaultAPISpecFiles when there are none.
+//
+// Example usage:
+//
+//	if types.IsAPISpecFile("api/openapi.yaml", config.APISpecFiles) {
+//		// tag it
+//	}
+func IsAPISpecFile(relPath string, patterns []string) bool {
+	if len(patterns) == 0 {
+		patterns = DefaultAPISpecFiles
+	}
+
+	relPath = filepath.ToSlash(relPath)
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, filepath.Base(relPath)); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, relPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ************************************************************************************************
+// DecodeAPISpecs reads the API specification paths from repository metadata. The value is a
+// typed slice right after indexing and a generic JSON array once loaded from the cache.
+//
+// Returns:
+//   - []string: The specification paths.
+//   - error: An error if the value is not a list of paths.
+func DecodeAPISpecs(value interface{}) ([]string, error) {
+	if paths, ok := value.([]string); ok {
+		return paths, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode API specification list\n>    %w", err)
+	}
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return nil, fmt.Errorf("invalid API specification list\n>    %w", err)
+	}
+	return paths, nil
+}
+
+// ************************************************************************************************
+// apiSchemaType is the type of a schema, decoded from a name or, as OpenAPI 3.1 allows, a list
+// of names of which the first one other than "null" is kept.
+type apiSchemaType string
+
+// ************************************************************************************************
+// UnmarshalJSON decodes a type name or a list of type names.
+func (schemaType *apiSchemaType) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		names = []string{name}
+	}
+	for _, name := range names {
+		if name != "null" {
+			*schemaType = apiSchemaType(name)
+			return nil
+		}
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// SummarizeAPISpec extracts the version, title, endpoints and schemas of an OpenAPI or Swagger
+// specification in JSON or YAML. YAML is read line by line from its indentation, which covers
+// the block style specifications are written in; flow mappings are not supported.
+//
+// Returns:
+//   - APISpecSummary: The outline of the specification.
+//   - error: An error if a JSON specification cannot be parsed.
+//
+// Example usage:
+//
+//	summary, err := types.SummarizeAPISpec(file.Content)
+func SummarizeAPISpec(content string) (APISpecSummary, error) {
+	var summary APISpecSummary
+	if strings.HasPrefix(strings.TrimSpace(content), "{") {
+		if err := summarizeJSONAPISpec(content, &summary); err != nil {
+			return APISpecSummary{}, err
+		}
+	} else {
+		summarizeYAMLAPISpec(content, &summary)
+		summary.Schemas = apiSchemas(yamlAPISchemas(content))
+	}
+
+	methodOrder := make(map[string]int, len(apiSpecMethods))
+	for index, method := range apiSpecMethods {
+		methodOrder[strings.ToUpper(method)] = index
+	}
+	sort.SliceStable(summary.Endpoints, func(i, j int) bool {
+		if summary.Endpoints[i].Path != summary.Endpoints[j].Path {
+			return summary.Endpoints[i].Path < summary.Endpoints[j].Path
+		}
+		return methodOrder[summary.Endpoints[i].Method] < methodOrder[summary.Endpoints[j].Method]
+	})
+	return summary, nil
+}
+
+// ************************************************************************************************
+// summarizeJSONAPISpec fills a summary from a JSON specification.
+func summarizeJSONAPISpec(content string, summary *APISpecSummary) error {
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Swagger string `json:"swagger"`
+		Info    struct {
+			Title string `json:"title"`
+		} `json:"info"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+		Definitions map[string]json.RawMessage `json:"definitions"`
+	}
+	if err := json.Unmarshal([]byte(content), &spec); err != nil {
+		return fmt.Errorf("invalid API specification\n>    %w", err)
+	}
+
+	summary.Version = spec.OpenAPI
+	if summary.Version == "" {
+		summary.Version = spec.Swagger
+	}
+	summary.Title = spec.Info.Title
+
+	// OpenAPI 3 declares schemas in components, Swagger 2 in definitions
+	nodes := make(map[string]*apiSchemaNode)
+	for _, schemas := range []map[string]json.RawMessage{spec.Definitions, spec.Components.Schemas} {
+		for name, raw := range schemas {
+			node := &apiSchemaNode{}
+			// The fields of a schema with unexpected values, such as a list of types, are still read
+			_ = json.Unmarshal(raw, node)
+			nodes[name] = node
+		}
+	}
+	summary.Schemas = apiSchemas(nodes)
+
+	for path, item := range spec.Paths {
+		for _, method := range apiSpecMethods {
+			raw, exists := item[method]
+			if !exists {
+				continue
+			}
+			var operation struct {
+				OperationID string `json:"operationId"`
+				Summary     string `json:"summary"`
+			}
+			// A malformed operation still declares the endpoint
+			_ = json.Unmarshal(raw, &operation)
+			summary.Endpoints = append(summary.Endpoints, APIEndpoint{
+				Method:      strings.ToUpper(method),
+				Path:        path,
+				OperationID: operation.OperationID,
+				Summary:     operation.Summary,
+			})
+		}
+	}
+	return nil
+}
+
+// ************************************************************************************************
+// summarizeYAMLAPISpec fills a summary from a block style YAML specification.
+func summarizeYAMLAPISpec(content string, summary *APISpecSummary) {
+	section := ""
+	pathIndent, methodIndent, fieldIndent := -1, -1, -1
+	currentPath := ""
+	var endpoint *APIEndpoint
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		key, value, isKey := yamlKeyValue(trimmed)
+
+		if indent == 0 {
+			section, endpoint = "", nil
+			if !isKey {
+				continue
+			}
+			switch key {
+			case "openapi", "swagger":
+				summary.Version = value
+			case "info", "paths":
+				section = key
+				pathIndent, methodIndent = -1, -1
+			}
+			continue
+		}
+		if !isKey {
+			continue
+		}
+
+		switch section {
+		case "info":
+			if key == "title" && summary.Title == "" {
+				summary.Title = value
+			}
+		case "paths":
+			if pathIndent < 0 {
+				pathIndent = indent
+			}
+			switch {
+			case indent == pathIndent:
+				currentPath, endpoint, methodIndent = key, nil, -1
+			case indent > pathIndent && (methodIndent < 0 || indent == methodIndent):
+				methodIndent, fieldIndent = indent, -1
+				endpoint = nil
+				for _, method := range apiSpecMethods {
+					if key == method {
+						summary.Endpoints = append(summary.Endpoints, APIEndpoint{Method: strings.ToUpper(method), Path: currentPath})
+						endpoint = &summary.Endpoints[len(summary.Endpoints)-1]
+						break
+					}
+				}
+			case endpoint != nil && indent > methodIndent:
+				// Only the fields of the operation itself, not those of nested objects
+				if fieldIndent < 0 {
+					fieldIndent = indent
+				}
+				if indent != fieldIndent {
+					continue
+				}
+				switch key {
+				case "summary":
+					if endpoint.Summary == "" {
+						endpoint.Summary = value
+					}
+				case "operationId":
+					if endpoint.OperationID == "" {
+						endpoint.OperationID = value
+					}
+				}
+			}
+		}
+	}
+}
+
+// ************************************************************************************************
+// yamlAPISchemaFrame is a mapping of a block style YAML specification enclosing the lines being
+// scanned for schemas.
+type yamlAPISchemaFrame struct {
+	indent int            // Indentation of the key opening the mapping
+	kind   string         // "components", "schemas", "schema", "properties", "required" or "skip"
+	node   *apiSchemaNode // Schema of "schema", "properties" and "required" mappings
+}
+
+// ************************************************************************************************
+// yamlAPISchemas builds the schemas of a block style YAML specification, declared in
+// components.schemas or definitions, from the indentation of its lines.
+//
+// Returns:
+//   - map[string]*apiSchemaNode: The schemas by name.
+func yamlAPISchemas(content string) map[string]*apiSchemaNode {
+	nodes := make(map[string]*apiSchemaNode)
+	var frames []yamlAPISchemaFrame
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		// Required properties may be listed one per line, at the indentation of their key
+		if item, isItem := strings.CutPrefix(trimmed, "- "); isItem {
+			for len(frames) > 0 && frames[len(frames)-1].indent > indent {
+				frames = frames[:len(frames)-1]
+			}
+			if len(frames) > 0 && frames[len(frames)-1].kind == "required" {
+				node := frames[len(frames)-1].node
+				node.Required = append(node.Required, strings.Trim(strings.TrimSpace(item), "\"'"))
+			}
+			continue
+		}
+
+		key, value, isKey := yamlKeyValue(trimmed)
+		if !isKey {
+			continue
+		}
+		for len(frames) > 0 && frames[len(frames)-1].indent >= indent {
+			frames = frames[:len(frames)-1]
+		}
+		if len(frames) == 0 {
+			switch {
+			case indent == 0 && key == "components":
+				frames = append(frames, yamlAPISchemaFrame{indent: indent, kind: "components"})
+			case indent == 0 && key == "definitions":
+				frames = append(frames, yamlAPISchemaFrame{indent: indent, kind: "schemas"})
+			}
+			continue
+		}
+
+		frame := yamlAPISchemaFrame{indent: indent, kind: "skip"}
+		switch parent := frames[len(frames)-1]; parent.kind {
+		case "components":
+			if key == "schemas" {
+				frame.kind = "schemas"
+			}
+		case "schemas":
+			frame.kind, frame.node = "schema", &apiSchemaNode{}
+			nodes[key] = frame.node
+		case "properties":
+			frame.kind, frame.node = "schema", &apiSchemaNode{}
+			parent.node.Properties[key] = frame.node
+		case "schema":
+			switch key {
+			case "type":
+				parent.node.Type = apiSchemaType(value)
+				if names := yamlFlowList(value); names != nil {
+					// OpenAPI 3.1 list of types, the first one other than "null" is kept
+					parent.node.Type = ""
+					if index := slices.IndexFunc(names, func(name string) bool { return name != "null" }); index >= 0 {
+						parent.node.Type = apiSchemaType(names[index])
+					}
+				}
+			case "$ref":
+				parent.node.Ref = value
+			case "items":
+				parent.node.Items = &apiSchemaNode{}
+				frame.kind, frame.node = "schema", parent.node.Items
+			case "properties":
+				parent.node.Properties = make(map[string]*apiSchemaNode)
+				frame.kind, frame.node = "properties", parent.node
+			case "required":
+				parent.node.Required = yamlFlowList(value)
+				frame.kind, frame.node = "required", parent.node
+			}
+		}
+		frames = append(frames, frame)
+	}
+	return nodes
+}
+
+// ************************************************************************************************
+// yamlFlowList splits a YAML flow sequence of scalars, such as "[id, name]", unquoted.
+//
+// Returns:
+//   - []string: The items, nil for other values.
+func yamlFlowList(value string) []string {
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(value[1:len(value)-1], ",") {
+		if item = strings.Trim(strings.TrimSpace(item), "\"'"); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ************************************************************************************************
+// apiSchemas summarizes schemas, sorted by name with their properties sorted by name.
+func apiSchemas(nodes map[string]*apiSchemaNode) []APISchema {
+	schemas := make([]APISchema, 0, len(nodes))
+	for name, node := range nodes {
+		schema := APISchema{Name: name, Type: node.typeName()}
+		for propertyName, property := range node.Properties {
+			schema.Properties = append(schema.Properties, APISchemaProperty{
+				Name:     propertyName,
+				Type:     property.typeName(),
+				Required: slices.Contains(node.Required, propertyName),
+			})
+		}
+		sort.Slice(schema.Properties, func(i, j int) bool {
+			return schema.Properties[i].Name < schema.Properties[j].Name
+		})
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Name < schemas[j].Name
+	})
+	return schemas
+}
+
+// ************************************************************************************************
+// typeName returns the type of a schema: the name of the schema it refers to, "[]" followed by
+// the type of its items for arrays, "object" for untyped schemas with properties.
+func (node *apiSchemaNode) typeName() string {
+	switch {
+	case node == nil:
+		return ""
+	case node.Ref != "":
+		return node.Ref[strings.LastIndex(node.Ref, "/")+1:]
+	case node.Type == "array" && node.Items.typeName() != "":
+		return "[]" + node.Items.typeName()
+	case node.Type == "" && len(node.Properties) > 0:
+		return "object"
+	}
+	return string(node.Type)
+}
+
+// ************************************************************************************************
+// yamlKeyValue splits a YAML mapping line into its key and scalar value, unquoted. List items
+// and lines without a key are not mapping lines.
+//
+// Returns:
+//   - string: The key.
+//   - string: The value, empty for nested mappings.
+//   - bool: Whether the line is a mapping line.
+func yamlKeyValue(line string) (string, string, bool) {
+	if strings.HasPrefix(line, "-") {
+		return "", "", false
+	}
+
+	var key, rest string
+	if quote := line[0]; quote == '"' || quote == '\'' {
+		end := strings.IndexByte(line[1:], quote)
+		if end < 0 {
+			return "", "", false
+		}
+		key, rest = line[1:end+1], line[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		rest = rest[1:]
+	} else {
+		index := strings.Index(line, ":")
+		if index <= 0 || (index+1 < len(line) && line[index+1] != ' ') {
+			return "", "", false
+		}
+		key, rest = line[:index], line[index+1:]
+	}
+
+	value := strings.TrimSpace(rest)
+	if index := strings.Index(value, " #"); index >= 0 {
+		value = strings.TrimSpace(value[:index])
+	}
+	value = strings.Trim(value, "\"'")
+	return key, value, true
+}
\ No newline at end of file
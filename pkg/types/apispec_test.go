@@ -0,0 +1,124 @@
+// ************************************************************************************************
+// Package types - Unit tests for OpenAPI/Swagger specification support.
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ************************************************************************************************
+// Test that YAML and JSON specifications yield the same sorted endpoints
+func TestSummarizeAPISpec(t *testing.T) {
+	yamlSpec := "openapi: 3.0.3\n" +
+		"info:\n" +
+		"  title: Pet Store # comment\n" +
+		"  version: 1.0.0\n" +
+		"paths:\n" +
+		"  /pets:\n" +
+		"    post:\n" +
+		"      operationId: createPet\n" +
+		"      requestBody:\n" +
+		"        content:\n" +
+		"          application/json:\n" +
+		"            schema:\n" +
+		"              summary: not an operation summary\n" +
+		"    get:\n" +
+		"      summary: \"List pets\"\n" +
+		"      operationId: listPets\n" +
+		"  '/pets/{id}':\n" +
+		"    parameters:\n" +
+		"      - name: id\n" +
+		"    delete:\n" +
+		"      summary: Delete a pet\n" +
+		"components:\n" +
+		"  schemas:\n" +
+		"    get:\n" +
+		"      summary: not an endpoint\n" +
+		"    Pet:\n" +
+		"      type: object\n" +
+		"      required:\n" +
+		"      - id\n" +
+		"      properties:\n" +
+		"        id:\n" +
+		"          type: integer\n" +
+		"          example: 7\n" +
+		"        owner:\n" +
+		"          $ref: '#/components/schemas/User'\n" +
+		"        tags:\n" +
+		"          type: array\n" +
+		"          items:\n" +
+		"            type: string\n" +
+		"    Pets:\n" +
+		"      type: array\n" +
+		"      items:\n" +
+		"        $ref: \"#/components/schemas/Pet\"\n" +
+		"    User:\n" +
+		"      required: [name]\n" +
+		"      properties:\n" +
+		"        name: {type: string}\n" +
+		"        type:\n" +
+		"          type: string\n"
+	jsonSpec := `{"swagger": "2.0", "info": {"title": "Pet Store"}, "paths": {
+		"/pets/{id}": {"parameters": [], "delete": {"summary": "Delete a pet"}},
+		"/pets": {"post": {"operationId": "createPet"}, "get": {"summary": "List pets", "operationId": "listPets"}}},
+		"definitions": {
+			"get": {"summary": "not an endpoint"},
+			"Pet": {"type": "object", "required": ["id"], "properties": {
+				"id": {"type": "integer", "example": 7},
+				"owner": {"$ref": "#/definitions/User"},
+				"tags": {"type": "array", "items": {"type": "string"}}}},
+			"Pets": {"type": "array", "items": {"$ref": "#/definitions/Pet"}},
+			"User": {"required": ["name"], "properties": {"name": {}, "type": {"type": ["string", "null"]}}}}}`
+
+	expectedSchemas := []APISchema{
+		{Name: "Pet", Type: "object", Properties: []APISchemaProperty{
+			{Name: "id", Type: "integer", Required: true},
+			{Name: "owner", Type: "User"},
+			{Name: "tags", Type: "[]string"},
+		}},
+		{Name: "Pets", Type: "[]Pet"},
+		{Name: "User", Type: "object", Properties: []APISchemaProperty{
+			{Name: "name", Required: true},
+			{Name: "type", Type: "string"},
+		}},
+		{Name: "get"},
+	}
+
+	expected := []APIEndpoint{
+		{Method: "GET", Path: "/pets", OperationID: "listPets", Summary: "List pets"},
+		{Method: "POST", Path: "/pets", OperationID: "createPet"},
+		{Method: "DELETE", Path: "/pets/{id}", Summary: "Delete a pet"},
+	}
+
+	for name, test := range map[string]struct {
+		content string
+		version string
+	}{
+		"yaml": {yamlSpec, "3.0.3"},
+		"json": {jsonSpec, "2.0"},
+	} {
+		summary, err := SummarizeAPISpec(test.content)
+		if err != nil {
+			t.Fatalf("%s: SummarizeAPISpec failed: %v", name, err)
+		}
+		if summary.Version != test.version || summary.Title != "Pet Store" {
+			t.Errorf("%s: expected version %s and title Pet Store, got %q and %q", name, test.version, summary.Version, summary.Title)
+		}
+		if len(summary.Endpoints) != len(expected) {
+			t.Fatalf("%s: expected %d endpoints, got %+v", name, len(expected), summary.Endpoints)
+		}
+		for i := range expected {
+			if summary.Endpoints[i] != expected[i] {
+				t.Errorf("%s: endpoint %d: expected %+v, got %+v", name, i, expected[i], summary.Endpoints[i])
+			}
+		}
+		if !reflect.DeepEqual(summary.Schemas, expectedSchemas) {
+			t.Errorf("%s: expected schemas %+v, got %+v", name, expectedSchemas, summary.Schemas)
+		}
+	}
+
+	if _, err := SummarizeAPISpec("{not json"); err == nil {
+		t.Error("Expected an error for an invalid JSON specification")
+	}
+}
@@ -0,0 +1,115 @@
+// ************************************************************************************************
+// Package types provides the chunks of the repomix-mcp application.
+// Large indexed files are split at indexing time into chunks following their constructs or
+// sections, so a topic can be answered with the most relevant parts of a file rather than with
+// the file truncated to the token budget.
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ************************************************************************************************
+// ChunksMetadataKey is the IndexedFile metadata key holding the chunks of a large file, encoded
+// by FormatChunks.
+const ChunksMetadataKey = "chunks"
+
+// ************************************************************************************************
+// Chunk is a range of lines of an indexed file. Chunks cover the whole file in order; the
+// consecutive windows of a construct longer than a chunk share some lines.
+type Chunk struct {
+	Start   int      `json:"start"`   // First line, 1-based
+	End     int      `json:"end"`     // Last line, inclusive
+	Kind    string   `json:"kind"`    // Type of the first construct or section, "preamble" or "lines" for text outside of them
+	Symbols []string `json:"symbols"` // Constructs or sections starting in the chunk, in file order
+}
+
+// ************************************************************************************************
+// FormatChunks encodes chunks as a JSON array for the IndexedFile metadata.
+//
+// Example usage:
+//
+//	file.Metadata[types.ChunksMetadataKey] = types.FormatChunks(chunks)
+func FormatChunks(chunks []Chunk) string {
+	data, err := json.Marshal(chunks)
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+// ************************************************************************************************
+// ParseChunks decodes chunks written by FormatChunks.
+//
+// Returns:
+//   - []Chunk: The decoded chunks.
+//   - error: An error if the value is not a JSON array of chunks.
+func ParseChunks(value string) ([]Chunk, error) {
+	var chunks []Chunk
+	if err := json.Unmarshal([]byte(value), &chunks); err != nil {
+		return nil, fmt.Errorf("invalid chunks\n>    %w", err)
+	}
+	return chunks, nil
+}
+
+// ************************************************************************************************
+// ChunkTopicContent returns the chunks of a file about a topic, most relevant first. A chunk is
+// relevant when its text or one of its symbols contains the topic, case-insensitively; it ranks
+// by the number of occurrences of the topic, a symbol match weighing as much as five. Chunks
+// of equal relevance keep their file order. Each chunk is introduced by its line range and
+// symbols.
+//
+// Returns:
+//   - string: The text of the selected chunks.
+//   - bool: Whether any chunk matches the topic.
+//
+// Example usage:
+//
+//	if content, found := types.ChunkTopicContent(file.Content, chunks, "retry"); found {
+//		docs.WriteString(content)
+//	}
+func ChunkTopicContent(content string, chunks []Chunk, topic string) (string, bool) {
+	topic = strings.ToLower(strings.TrimSpace(topic))
+	if topic == "" || len(chunks) == 0 {
+		return "", false
+	}
+	lines := strings.Split(content, "\n")
+
+	type rankedChunk struct {
+		chunk Chunk
+		text  string
+		score int
+	}
+	var ranked []rankedChunk
+	for _, chunk := range chunks {
+		text := strings.TrimRight(markdownLines(lines, chunk.Start, chunk.End), "\r\n")
+		score := strings.Count(strings.ToLower(text), topic)
+		for _, symbol := range chunk.Symbols {
+			if strings.Contains(strings.ToLower(symbol), topic) {
+				score += 5
+			}
+		}
+		if score > 0 {
+			ranked = append(ranked, rankedChunk{chunk: chunk, text: text, score: score})
+		}
+	}
+	if len(ranked) == 0 {
+		return "", false
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return ranked[a].score > ranked[b].score
+	})
+
+	parts := make([]string, len(ranked))
+	for index, item := range ranked {
+		header := fmt.Sprintf("### Lines %d-%d", item.chunk.Start, item.chunk.End)
+		if len(item.chunk.Symbols) > 0 {
+			header += fmt.Sprintf(" (%s)", strings.Join(item.chunk.Symbols, ", "))
+		}
+		parts[index] = header + "\n\n" + item.text
+	}
+	return strings.Join(parts, "\n\n"), true
+}
@@ -0,0 +1,47 @@
+// ************************************************************************************************
+// Package types - Unit tests for the chunks of large files.
+package types
+
+import "testing"
+
+// ************************************************************************************************
+// Test that topics select chunks by relevance, symbol matches first
+func TestChunkTopicContent(t *testing.T) {
+	content := "package cache\n" + // 1
+		"func Get() {\n" + // 2
+		"\treturn load()\n" + // 3
+		"}\n" + // 4
+		"func Evict() {\n" + // 5
+		"\tlog(\"evict before get\")\n" + // 6
+		"}\n" // 7
+	encoded := FormatChunks([]Chunk{
+		{Start: 1, End: 4, Kind: "func", Symbols: []string{"Get"}},
+		{Start: 5, End: 7, Kind: "func", Symbols: []string{"Evict"}},
+	})
+	chunks, err := ParseChunks(encoded)
+	if err != nil {
+		t.Fatalf("ParseChunks failed: %v", err)
+	}
+
+	tests := []struct {
+		topic    string
+		expected string
+		found    bool
+	}{
+		{"GET", "### Lines 1-4 (Get)\n\npackage cache\nfunc Get() {\n\treturn load()\n}\n\n### Lines 5-7 (Evict)\n\nfunc Evict() {\n\tlog(\"evict before get\")\n}", true},
+		{"evict", "### Lines 5-7 (Evict)\n\nfunc Evict() {\n\tlog(\"evict before get\")\n}", true},
+		{"func", "### Lines 1-4 (Get)\n\npackage cache\nfunc Get() {\n\treturn load()\n}\n\n### Lines 5-7 (Evict)\n\nfunc Evict() {\n\tlog(\"evict before get\")\n}", true},
+		{"missing", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		content, found := ChunkTopicContent(content, chunks, test.topic)
+		if content != test.expected || found != test.found {
+			t.Errorf("Topic %q: expected (%q, %v), got (%q, %v)", test.topic, test.expected, test.found, content, found)
+		}
+	}
+
+	if _, err := ParseChunks("not json"); err == nil {
+		t.Error("Expected an error for malformed chunks")
+	}
+}
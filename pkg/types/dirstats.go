@@ -0,0 +1,80 @@
+// ************************************************************************************************
+// Package types provides per-directory language statistics for the repomix-mcp application.
+// Statistics are rolled up: a directory counts the indexed files of all its subdirectories,
+// and the repository root is the directory ".".
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+)
+
+// ************************************************************************************************
+// DirectoryStatsMetadataKey is the repository metadata key holding the directory statistics.
+const DirectoryStatsMetadataKey = "directory_stats"
+
+// ************************************************************************************************
+// DirectoryStats summarizes the indexed files below a directory.
+type DirectoryStats struct {
+	Files     int            `json:"files"`     // Number of files, subdirectories included
+	Bytes     int64          `json:"bytes"`     // Total size of the files in bytes
+	Languages map[string]int `json:"languages"` // File count by language
+	Dominant  string         `json:"dominant"`  // Language with the most files, ties broken by name
+}
+
+// ************************************************************************************************
+// AddDirectoryStat records a file in the statistics of its directory and every ancestor
+// directory up to the repository root.
+//
+// Example usage:
+//
+//	stats := make(map[string]*types.DirectoryStats)
+//	types.AddDirectoryStat(stats, "cmd/server/main.go", "go", 1024)
+func AddDirectoryStat(stats map[string]*DirectoryStats, relPath, language string, size int64) {
+	dir := path.Dir(path.Clean(relPath))
+	for {
+		entry, exists := stats[dir]
+		if !exists {
+			entry = &DirectoryStats{Languages: make(map[string]int)}
+			stats[dir] = entry
+		}
+		entry.Files++
+		entry.Bytes += size
+		entry.Languages[language]++
+
+		// Counts only grow, so only the language just incremented can take the lead
+		count, lead := entry.Languages[language], entry.Languages[entry.Dominant]
+		if entry.Dominant == "" || count > lead || (count == lead && language < entry.Dominant) {
+			entry.Dominant = language
+		}
+
+		if dir == "." || dir == "/" {
+			return
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// ************************************************************************************************
+// DecodeDirectoryStats reads directory statistics from repository metadata. The value is a
+// typed map right after indexing and a generic JSON object once loaded from the cache.
+//
+// Returns:
+//   - map[string]*DirectoryStats: The statistics by slash-separated directory path.
+//   - error: An error if the value is not a directory statistics map.
+func DecodeDirectoryStats(value interface{}) (map[string]*DirectoryStats, error) {
+	if stats, ok := value.(map[string]*DirectoryStats); ok {
+		return stats, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode directory statistics\n>    %w", err)
+	}
+	var stats map[string]*DirectoryStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("invalid directory statistics\n>    %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,66 @@
+// ************************************************************************************************
+// Package types - Unit tests for per-directory language statistics.
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// ************************************************************************************************
+// Test that files roll up into every ancestor directory with a stable dominant language
+func TestAddDirectoryStat(t *testing.T) {
+	stats := make(map[string]*DirectoryStats)
+	AddDirectoryStat(stats, "cmd/server/main.go", "go", 100)
+	AddDirectoryStat(stats, "cmd/server/README.md", "markdown", 50)
+	AddDirectoryStat(stats, "docs/guide.md", "markdown", 10)
+	AddDirectoryStat(stats, "go.mod", "text", 5)
+
+	root := stats["."]
+	if root == nil || root.Files != 4 || root.Bytes != 165 {
+		t.Fatalf("Expected root to hold 4 files and 165 bytes, got %+v", root)
+	}
+	if root.Dominant != "markdown" {
+		t.Errorf("Expected root dominant language markdown, got %s", root.Dominant)
+	}
+
+	server := stats["cmd/server"]
+	if server == nil || server.Files != 2 {
+		t.Fatalf("Expected cmd/server to hold 2 files, got %+v", server)
+	}
+	// Ties are broken by language name
+	if server.Dominant != "go" {
+		t.Errorf("Expected cmd/server dominant language go, got %s", server.Dominant)
+	}
+	if stats["cmd"].Files != 2 {
+		t.Errorf("Expected cmd to include its subdirectory files, got %d", stats["cmd"].Files)
+	}
+}
+
+// ************************************************************************************************
+// Test that statistics survive the JSON round trip of the cache
+func TestDecodeDirectoryStats(t *testing.T) {
+	stats := make(map[string]*DirectoryStats)
+	AddDirectoryStat(stats, "pkg/types/types.go", "go", 42)
+
+	data, err := json.Marshal(map[string]interface{}{DirectoryStatsMetadataKey: stats})
+	if err != nil {
+		t.Fatalf("Failed to marshal metadata: %v", err)
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatalf("Failed to unmarshal metadata: %v", err)
+	}
+
+	decoded, err := DecodeDirectoryStats(metadata[DirectoryStatsMetadataKey])
+	if err != nil {
+		t.Fatalf("DecodeDirectoryStats failed: %v", err)
+	}
+	if entry := decoded["pkg/types"]; entry == nil || entry.Bytes != 42 || entry.Languages["go"] != 1 {
+		t.Errorf("Expected pkg/types statistics to round trip, got %+v", entry)
+	}
+
+	if _, err := DecodeDirectoryStats("not a map"); err == nil {
+		t.Error("Expected an error for malformed statistics")
+	}
+}
@@ -26,4 +26,9 @@ var (
 	ErrTimeoutError          = fmt.Errorf("0x%X%X timeout_error", "REPOMIX", []byte{0x15})
 	ErrNotInitialized        = fmt.Errorf("0x%X%X not_initialized", "REPOMIX", []byte{0x16})
 	ErrConcurrentAccess      = fmt.Errorf("0x%X%X concurrent_access", "REPOMIX", []byte{0x17})
+	ErrInvalidRepositoryID   = fmt.Errorf("0x%X%X invalid_repository_id", "REPOMIX", []byte{0x18})
+	ErrRepositoryIDCollision = fmt.Errorf("0x%X%X repository_id_collision", "REPOMIX", []byte{0x19})
+	ErrDownloadFailed        = fmt.Errorf("0x%X%X download_failed", "REPOMIX", []byte{0x1A})
+	ErrIndexingDisabled      = fmt.Errorf("0x%X%X indexing_disabled", "REPOMIX", []byte{0x1B})
+	ErrParserUnavailable     = fmt.Errorf("0x%X%X parser_unavailable", "REPOMIX", []byte{0x1C})
 )
\ No newline at end of file
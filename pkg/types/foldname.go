@@ -0,0 +1,98 @@
+// ************************************************************************************************
+// Package types provides Unicode-aware name folding for the repomix-mcp application.
+// Library names given by agents are matched against repository IDs regardless of case and,
+// optionally, of accents, so "Café", "CAFÉ" and "cafe" resolve to the same repository.
+package types
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ************************************************************************************************
+// accentFolds maps the accented Latin and Greek letters, once case folded, to their base letters.
+// Letters are decomposed from this table because golang.org/x/text is not a dependency.
+var accentFolds = buildAccentFolds(map[string]string{
+	"a":  "àáâãäåāăąǎǻ",
+	"c":  "çćĉċč",
+	"d":  "ďđ",
+	"e":  "èéêëēĕėęě",
+	"g":  "ĝğġģ",
+	"h":  "ĥħ",
+	"i":  "ìíîïĩīĭįıǐ",
+	"j":  "ĵ",
+	"k":  "ķ",
+	"l":  "ĺļľŀł",
+	"n":  "ñńņňŉ",
+	"o":  "òóôõöøōŏőǒǿ",
+	"r":  "ŕŗř",
+	"s":  "śŝşšș",
+	"t":  "ţťŧț",
+	"u":  "ùúûüũūŭůűųǔǖǘǚǜ",
+	"w":  "ŵ",
+	"y":  "ýÿŷ",
+	"z":  "źżž",
+	"ae": "æǽ",
+	"oe": "œ",
+	"α":  "ά",
+	"ε":  "έ",
+	"η":  "ή",
+	"ι":  "ίϊΐ",
+	"ο":  "ό",
+	"υ":  "ύϋΰ",
+	"ω":  "ώ",
+})
+
+// ************************************************************************************************
+// buildAccentFolds inverts a base letter to accented letters table.
+func buildAccentFolds(letters map[string]string) map[rune]string {
+	folds := make(map[rune]string)
+	for base, accented := range letters {
+		for _, r := range accented {
+			folds[r] = base
+		}
+	}
+	return folds
+}
+
+// ************************************************************************************************
+// FoldName returns the case folded form of a name for loose comparisons. Case folding maps
+// every case variant of a letter to one form, including those strings.ToLower keeps apart
+// such as "ß" and "ss" or the Kelvin sign and "k". With stripAccents, accented letters
+// are replaced with their base letters and combining marks are dropped.
+//
+// Returns:
+//   - string: The folded name.
+//
+// Example usage:
+//
+//	if strings.Contains(types.FoldName(repoID, true), types.FoldName(query, true)) {
+//		// matched
+//	}
+func FoldName(name string, stripAccents bool) string {
+	var folded strings.Builder
+	folded.Grow(len(name))
+	for _, r := range name {
+		// Upper then lower case merges the variants ToLower alone leaves distinct (ſ, ς, K)
+		r = unicode.ToLower(unicode.ToUpper(r))
+		switch {
+		case r == 'ß' || r == 'ẞ':
+			folded.WriteString("ss")
+		case stripAccents && unicode.Is(unicode.Mn, r):
+			// Combining marks of decomposed input
+		case stripAccents && accentFolds[r] != "":
+			folded.WriteString(accentFolds[r])
+		default:
+			folded.WriteRune(r)
+		}
+	}
+	return folded.String()
+}
+
+// ************************************************************************************************
+// NamesOverlap reports whether one of two names contains the other once folded, the loose
+// matching used to resolve library names.
+func NamesOverlap(a, b string, stripAccents bool) bool {
+	a, b = FoldName(a, stripAccents), FoldName(b, stripAccents)
+	return strings.Contains(a, b) || strings.Contains(b, a)
+}
@@ -0,0 +1,34 @@
+// ************************************************************************************************
+// Package types - Unit tests for Unicode-aware name folding.
+package types
+
+import "testing"
+
+// ************************************************************************************************
+// Test that case variants always fold together and accents only when stripped
+func TestFoldName(t *testing.T) {
+	tests := []struct {
+		a, b         string
+		stripAccents bool
+		equal        bool
+	}{
+		{"CAFÉ", "café", false, true},
+		{"Straße", "STRASSE", false, true},
+		{"ΣΟΦΟΣ", "σοφος", false, true},
+		{"\u212Aelvin", "kelvin", false, true},
+		{"café", "cafe", false, false},
+		{"café", "cafe", true, true},
+		{"cafe\u0301", "CAFE", true, true},
+		{"café", "CAFE", true, true},
+		{"Łódź", "lodz", true, true},
+		{"Æsir", "aesir", true, true},
+		{"日本語", "日本語", true, true},
+	}
+
+	for _, test := range tests {
+		if equal := FoldName(test.a, test.stripAccents) == FoldName(test.b, test.stripAccents); equal != test.equal {
+			t.Errorf("FoldName(%q) == FoldName(%q) with stripAccents=%v: expected %v, got %q and %q",
+				test.a, test.b, test.stripAccents, test.equal, FoldName(test.a, test.stripAccents), FoldName(test.b, test.stripAccents))
+		}
+	}
+}
@@ -7,10 +7,14 @@ package types
 // GoModuleConfig defines configuration options for Go module documentation retrieval.
 // It controls how Go modules are fetched, cached, and processed.
 type GoModuleConfig struct {
-	Enabled        bool   `json:"enabled" mapstructure:"enabled"`               // Whether Go module fallback is enabled
-	TempDirBase    string `json:"tempDirBase" mapstructure:"tempDirBase"`       // Base directory for temporary Go modules
-	CacheTimeout   string `json:"cacheTimeout" mapstructure:"cacheTimeout"`     // How long to cache Go module docs
-	CommandTimeout string `json:"commandTimeout" mapstructure:"commandTimeout"` // Timeout for individual Go commands
-	MaxRetries     int    `json:"maxRetries" mapstructure:"maxRetries"`         // Maximum retries for failed commands
-	MaxConcurrent  int    `json:"maxConcurrent" mapstructure:"maxConcurrent"`   // Maximum concurrent Go operations
+	Enabled         bool     `json:"enabled" mapstructure:"enabled"`                 // Whether Go module fallback is enabled
+	TempDirBase     string   `json:"tempDirBase" mapstructure:"tempDirBase"`         // Base directory for temporary Go modules
+	CacheTimeout    string   `json:"cacheTimeout" mapstructure:"cacheTimeout"`       // How long to cache Go module docs
+	CommandTimeout  string   `json:"commandTimeout" mapstructure:"commandTimeout"`   // Timeout for individual Go commands
+	MaxRetries      int      `json:"maxRetries" mapstructure:"maxRetries"`           // Maximum retries for failed commands
+	MaxConcurrent   int      `json:"maxConcurrent" mapstructure:"maxConcurrent"`     // Maximum concurrent Go operations
+	AllowedHosts    []string `json:"allowedHosts" mapstructure:"allowedHosts"`       // Module hosts permitted for fetching (empty allows all)
+	DeniedHosts     []string `json:"deniedHosts" mapstructure:"deniedHosts"`         // Module hosts never fetched, checked before AllowedHosts
+	VerifyChecksums bool     `json:"verifyChecksums" mapstructure:"verifyChecksums"` // Verify downloads against the checksum database (default true)
+	DocFormat       string   `json:"docFormat" mapstructure:"docFormat"`             // Rendering of go doc output: "markdown" (default) or "raw"
 }
\ No newline at end of file
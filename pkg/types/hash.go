@@ -0,0 +1,124 @@
+// ************************************************************************************************
+// Package types provides content hashing for the repomix-mcp application.
+// The indexer and the working diff share the same hash so cached files can be compared
+// with the files on disk. Every hash is prefixed with the algorithm that produced it, so
+// a cache holding hashes of several algorithms is still compared correctly.
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"lukechampine.com/blake3"
+)
+
+// ************************************************************************************************
+// Content hash algorithms accepted by IndexingConfig.HashAlgorithm and CacheConfig.HashAlgorithm.
+const (
+	// HashAlgorithmSHA256 is collision resistant, the default.
+	HashAlgorithmSHA256 = "sha256"
+
+	// HashAlgorithmXXHash is a fast non-cryptographic 64-bit hash.
+	HashAlgorithmXXHash = "xxhash"
+
+	// HashAlgorithmBLAKE3 is a fast cryptographic hash, 256-bit digest.
+	HashAlgorithmBLAKE3 = "blake3"
+
+	// DefaultHashAlgorithm is used when no algorithm is configured.
+	DefaultHashAlgorithm = HashAlgorithmSHA256
+)
+
+// ************************************************************************************************
+// SourceHashesMetadataKey is the repository metadata key holding the content hashes of the
+// sources summarized by the Go parser, by slash-separated relative path. Those sources are
+// not stored as individual files.
+const SourceHashesMetadataKey = "source_hashes"
+
+// ************************************************************************************************
+// ValidateHashAlgorithm checks a configured hash algorithm name, empty meaning the default.
+//
+// Returns:
+//   - error: ErrInvalidConfig if the algorithm is unknown.
+func ValidateHashAlgorithm(algorithm string) error {
+	switch algorithm {
+	case "", HashAlgorithmSHA256, HashAlgorithmXXHash, HashAlgorithmBLAKE3:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown hash algorithm %q (expected sha256, xxhash or blake3)", ErrInvalidConfig, algorithm)
+	}
+}
+
+// ************************************************************************************************
+// ContentHash hashes content for change detection with the default algorithm.
+//
+// Returns:
+//   - string: The content hash, "algorithm:hex digest".
+func ContentHash(content string) string {
+	return HashContent(DefaultHashAlgorithm, content)
+}
+
+// ************************************************************************************************
+// HashContent hashes content for change detection. An empty or unknown algorithm falls back
+// to DefaultHashAlgorithm; configured names are checked by ValidateHashAlgorithm at load time.
+//
+// Returns:
+//   - string: The content hash, "algorithm:hex digest".
+//
+// Example usage:
+//
+//	hash := types.HashContent(config.HashAlgorithm, content) // "xxhash:9f8c2d7e4a1b3c5d"
+func HashContent(algorithm, content string) string {
+	switch algorithm {
+	case HashAlgorithmXXHash:
+		return fmt.Sprintf("%s:%016x", HashAlgorithmXXHash, xxhash.Sum64String(content))
+	case HashAlgorithmBLAKE3:
+		sum := blake3.Sum256([]byte(content))
+		return HashAlgorithmBLAKE3 + ":" + hex.EncodeToString(sum[:])
+	default:
+		sum := sha256.Sum256([]byte(content))
+		return HashAlgorithmSHA256 + ":" + hex.EncodeToString(sum[:])
+	}
+}
+
+// ************************************************************************************************
+// ContentMatchesHash reports whether content hashes to a stored hash, hashing with the algorithm
+// recorded in the stored hash. Hashes without a known algorithm, such as the length-based hashes
+// written before algorithms were recorded, never match, so their files are hashed again.
+//
+// Example usage:
+//
+//	changed := !types.ContentMatchesHash(string(data), file.Hash)
+func ContentMatchesHash(content, hash string) bool {
+	algorithm, _, found := strings.Cut(hash, ":")
+	if !found || algorithm == "" || ValidateHashAlgorithm(algorithm) != nil {
+		return false
+	}
+	return HashContent(algorithm, content) == hash
+}
+
+// ************************************************************************************************
+// DecodeSourceHashes reads source hashes from repository metadata. The value is a typed map
+// right after indexing and a generic JSON object once loaded from the cache.
+//
+// Returns:
+//   - map[string]string: The content hashes by relative path.
+//   - error: An error if the value is not a map of hashes.
+func DecodeSourceHashes(value interface{}) (map[string]string, error) {
+	if hashes, ok := value.(map[string]string); ok {
+		return hashes, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode source hashes\n>    %w", err)
+	}
+	var hashes map[string]string
+	if err := json.Unmarshal(data, &hashes); err != nil {
+		return nil, fmt.Errorf("invalid source hashes\n>    %w", err)
+	}
+	return hashes, nil
+}
@@ -0,0 +1,50 @@
+// ************************************************************************************************
+// Package types - Unit tests for content hashing.
+package types
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// ************************************************************************************************
+// Test that hashes record their algorithm and are compared with it, legacy hashes never matching
+func TestContentMatchesHash(t *testing.T) {
+	content := "package main\n"
+
+	for _, algorithm := range []string{HashAlgorithmSHA256, HashAlgorithmXXHash, HashAlgorithmBLAKE3} {
+		hash := HashContent(algorithm, content)
+		if !strings.HasPrefix(hash, algorithm+":") {
+			t.Errorf("Expected %s hash to be prefixed with its algorithm, got %q", algorithm, hash)
+		}
+		if !ContentMatchesHash(content, hash) {
+			t.Errorf("Expected content to match its %s hash", algorithm)
+		}
+		if ContentMatchesHash(content+"// edit\n", hash) || ContentMatchesHash("packaxe main\n", hash) {
+			t.Errorf("Expected changed content not to match its %s hash", algorithm)
+		}
+	}
+
+	if hash := HashContent(HashAlgorithmBLAKE3, ""); hash != "blake3:af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262" {
+		t.Errorf("Expected the BLAKE3 digest of empty content, got %q", hash)
+	}
+	if HashContent("", content) != HashContent(DefaultHashAlgorithm, content) {
+		t.Error("Expected an empty algorithm to use the default")
+	}
+
+	// Caches written before algorithms were recorded hold length and first and last characters,
+	// which an edit in the middle keeps
+	for _, edited := range []string{content, "packaxe main\n"} {
+		if ContentMatchesHash(edited, "13_p_\n") {
+			t.Errorf("Expected %q not to match a legacy hash", edited)
+		}
+	}
+	if ContentMatchesHash(content, "md5:"+HashContent(DefaultHashAlgorithm, content)[len("sha256:"):]) || ContentMatchesHash(content, ":") {
+		t.Error("Expected hashes of unknown algorithms not to match")
+	}
+
+	if err := ValidateHashAlgorithm("blake2b"); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for an unknown algorithm, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+// ************************************************************************************************
+// Package types provides the interface implementation index for the repomix-mcp application.
+// The Go parser links every interface of a repository to the named types implementing it,
+// across packages, so agents can find the implementations of an interface without a build.
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ************************************************************************************************
+// ImplementationsMetadataKey is the repository metadata key holding the implementation index.
+const ImplementationsMetadataKey = "implementations"
+
+// ************************************************************************************************
+// InterfaceImplementations lists the types of a repository implementing an interface.
+// Names are qualified by import path, e.g. "example.com/app/storage.Store".
+type InterfaceImplementations struct {
+	Interface       string           `json:"interface"`       // Qualified interface name
+	File            string           `json:"file"`            // File declaring the interface, relative to the repository root
+	Line            int              `json:"line"`            // 1-based line of the declaration
+	Implementations []Implementation `json:"implementations"` // Implementing types, sorted by name
+}
+
+// ************************************************************************************************
+// Implementation is a named type implementing an interface.
+type Implementation struct {
+	Type    string `json:"type"`    // Qualified type name
+	Pointer bool   `json:"pointer"` // Whether only the pointer type implements the interface
+	File    string `json:"file"`    // File declaring the type, relative to the repository root
+	Line    int    `json:"line"`    // 1-based line of the declaration
+}
+
+// ************************************************************************************************
+// DecodeImplementations reads the implementation index from repository metadata. The value is
+// a typed slice right after indexing and a generic JSON array once loaded from the cache.
+//
+// Returns:
+//   - []InterfaceImplementations: The interfaces with their implementations.
+//   - error: An error if the value is not an implementation index.
+func DecodeImplementations(value interface{}) ([]InterfaceImplementations, error) {
+	if index, ok := value.([]InterfaceImplementations); ok {
+		return index, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode implementation index\n>    %w", err)
+	}
+	var index []InterfaceImplementations
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid implementation index\n>    %w", err)
+	}
+	return index, nil
+}
\ No newline at end of file
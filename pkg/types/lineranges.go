@@ -0,0 +1,59 @@
+// ************************************************************************************************
+// Package types provides line range encoding for the repomix-mcp application.
+// The Go parser records which lines of its generated output belong to exported constructs
+// so searches can be limited to the public API.
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ************************************************************************************************
+// ExportedLinesMetadataKey is the IndexedFile metadata key listing the lines that belong to
+// exported constructs. Files without it have no construct information.
+const ExportedLinesMetadataKey = "exported_lines"
+
+// ************************************************************************************************
+// LineRange is an inclusive range of 1-based line numbers.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// ************************************************************************************************
+// FormatLineRanges encodes line ranges as "start-end" pairs separated by commas.
+//
+// Example usage:
+//
+//	value := types.FormatLineRanges([]types.LineRange{{12, 15}, {20, 20}}) // "12-15,20-20"
+func FormatLineRanges(ranges []LineRange) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = fmt.Sprintf("%d-%d", r.Start, r.End)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ************************************************************************************************
+// ParseLineRanges decodes line ranges written by FormatLineRanges. Malformed pairs are skipped.
+//
+// Returns:
+//   - []LineRange: The decoded ranges.
+func ParseLineRanges(value string) []LineRange {
+	var ranges []LineRange
+	for _, part := range strings.Split(value, ",") {
+		start, end, found := strings.Cut(part, "-")
+		if !found {
+			continue
+		}
+		startLine, err1 := strconv.Atoi(start)
+		endLine, err2 := strconv.Atoi(end)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		ranges = append(ranges, LineRange{Start: startLine, End: endLine})
+	}
+	return ranges
+}
@@ -0,0 +1,145 @@
+// ************************************************************************************************
+// Package types provides the Markdown document structure of the repomix-mcp application.
+// Headings, code blocks and links of Markdown files are recorded at indexing time, so the
+// documentation of docs-only repositories can be served section by section.
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ************************************************************************************************
+// MarkdownSectionsMetadataKey is the IndexedFile metadata key holding the sections of a Markdown
+// file, encoded by FormatMarkdownSections.
+const MarkdownSectionsMetadataKey = "markdown_sections"
+
+// ************************************************************************************************
+// MarkdownSection is a heading of a Markdown file and the lines up to the next heading of the
+// same or a higher level.
+type MarkdownSection struct {
+	Level  int                 `json:"level"`  // Heading level 1-6, 0 for the text before the first heading
+	Title  string              `json:"title"`  // Heading text, empty for the text before the first heading
+	Anchor string              `json:"anchor"` // GitHub-style anchor, e.g. "getting-started"
+	Parent int                 `json:"parent"` // Index of the enclosing section, -1 at the top level
+	Start  int                 `json:"start"`  // First line, the heading, 1-based
+	End    int                 `json:"end"`    // Last line, subsections included
+	Code   []MarkdownCodeBlock `json:"code"`   // Fenced code blocks of the section's own text
+	Links  []MarkdownLink      `json:"links"`  // Links of the section's own text
+}
+
+// ************************************************************************************************
+// MarkdownCodeBlock is a fenced code block of a Markdown file.
+type MarkdownCodeBlock struct {
+	Language string `json:"language"` // Info string language, empty if not declared
+	Start    int    `json:"start"`    // Opening fence line, 1-based
+	End      int    `json:"end"`      // Closing fence line, the last line for unclosed blocks
+}
+
+// ************************************************************************************************
+// MarkdownLink is a link of a Markdown file, inline or declared by a reference definition.
+type MarkdownLink struct {
+	Text   string `json:"text"`   // Link text, the label for reference definitions
+	Target string `json:"target"` // URL, relative path or "#anchor"
+	Line   int    `json:"line"`   // Line of the link, 1-based
+}
+
+// ************************************************************************************************
+// FormatMarkdownSections encodes sections as a JSON array for the IndexedFile metadata.
+//
+// Example usage:
+//
+//	file.Metadata[types.MarkdownSectionsMetadataKey] = types.FormatMarkdownSections(sections)
+func FormatMarkdownSections(sections []MarkdownSection) string {
+	data, err := json.Marshal(sections)
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+// ************************************************************************************************
+// ParseMarkdownSections decodes sections written by FormatMarkdownSections.
+//
+// Returns:
+//   - []MarkdownSection: The decoded sections.
+//   - error: An error if the value is not a JSON array of sections.
+func ParseMarkdownSections(value string) ([]MarkdownSection, error) {
+	var sections []MarkdownSection
+	if err := json.Unmarshal([]byte(value), &sections); err != nil {
+		return nil, fmt.Errorf("invalid Markdown sections\n>    %w", err)
+	}
+	return sections, nil
+}
+
+// ************************************************************************************************
+// MarkdownTopicContent returns the sections of a Markdown file about a topic: those whose
+// title contains it or, when no title does, those whose own text does, case-insensitively.
+// Subsections of a selected section are part of it and are not repeated.
+//
+// Returns:
+//   - string: The text of the selected sections, in file order.
+//   - bool: Whether any section matches the topic.
+//
+// Example usage:
+//
+//	if content, found := types.MarkdownTopicContent(file.Content, sections, "install"); found {
+//		docs.WriteString(content)
+//	}
+func MarkdownTopicContent(content string, sections []MarkdownSection, topic string) (string, bool) {
+	topic = strings.ToLower(strings.TrimSpace(topic))
+	if topic == "" || len(sections) == 0 {
+		return "", false
+	}
+	lines := strings.Split(content, "\n")
+
+	// The own text of a section stops at its first subsection
+	ownEnd := make([]int, len(sections))
+	for index, section := range sections {
+		ownEnd[index] = section.End
+	}
+	for _, section := range sections {
+		if section.Parent >= 0 && section.Parent < len(sections) {
+			ownEnd[section.Parent] = min(ownEnd[section.Parent], section.Start-1)
+		}
+	}
+
+	var selected []MarkdownSection
+	for _, section := range sections {
+		if strings.Contains(strings.ToLower(section.Title), topic) {
+			selected = append(selected, section)
+		}
+	}
+	if len(selected) == 0 {
+		for index, section := range sections {
+			if strings.Contains(strings.ToLower(markdownLines(lines, section.Start, ownEnd[index])), topic) {
+				selected = append(selected, section)
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return "", false
+	}
+
+	var parts []string
+	lastEnd := 0
+	for _, section := range selected {
+		if section.Start <= lastEnd {
+			continue // Subsection of a selected section
+		}
+		parts = append(parts, strings.TrimRight(markdownLines(lines, section.Start, section.End), "\r\n"))
+		lastEnd = section.End
+	}
+	return strings.Join(parts, "\n\n"), true
+}
+
+// ************************************************************************************************
+// markdownLines returns the lines from start to end, 1-based and inclusive, clamped to the file.
+func markdownLines(lines []string, start, end int) string {
+	start, end = max(start, 1), min(end, len(lines))
+	if start > end {
+		return ""
+	}
+	return strings.Join(lines[start-1:end], "\n")
+}
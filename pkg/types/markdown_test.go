@@ -0,0 +1,51 @@
+// ************************************************************************************************
+// Package types - Unit tests for the Markdown document structure.
+package types
+
+import "testing"
+
+// ************************************************************************************************
+// Test that topics select sections by title first, then by their own text
+func TestMarkdownTopicContent(t *testing.T) {
+	content := "# Guide\n" + // 1
+		"Overview of the cache.\n" + // 2
+		"## Install\n" + // 3
+		"Run make.\n" + // 4
+		"### Cache setup\n" + // 5
+		"Set the path.\n" + // 6
+		"## Usage\n" + // 7
+		"Call the tool.\n" // 8
+	encoded := FormatMarkdownSections([]MarkdownSection{
+		{Level: 1, Title: "Guide", Parent: -1, Start: 1, End: 8},
+		{Level: 2, Title: "Install", Parent: 0, Start: 3, End: 6},
+		{Level: 3, Title: "Cache setup", Parent: 1, Start: 5, End: 6},
+		{Level: 2, Title: "Usage", Parent: 0, Start: 7, End: 8},
+	})
+	sections, err := ParseMarkdownSections(encoded)
+	if err != nil {
+		t.Fatalf("ParseMarkdownSections failed: %v", err)
+	}
+
+	tests := []struct {
+		topic    string
+		expected string
+		found    bool
+	}{
+		{"INSTALL", "## Install\nRun make.\n### Cache setup\nSet the path.", true},
+		{"cache", "### Cache setup\nSet the path.", true},
+		{"tool", "## Usage\nCall the tool.", true},
+		{"make", "## Install\nRun make.\n### Cache setup\nSet the path.", true},
+		{"missing", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		content, found := MarkdownTopicContent(content, sections, test.topic)
+		if content != test.expected || found != test.found {
+			t.Errorf("Topic %q: expected (%q, %v), got (%q, %v)", test.topic, test.expected, test.found, content, found)
+		}
+	}
+
+	if _, err := ParseMarkdownSections("not json"); err == nil {
+		t.Error("Expected an error for malformed sections")
+	}
+}
@@ -0,0 +1,57 @@
+// ************************************************************************************************
+// Package types provides the reference index for the repomix-mcp application.
+// The Go parser records the call sites of every function and method declared in a repository,
+// so agents can find the callers of a symbol without a build.
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ************************************************************************************************
+// ReferencesMetadataKey is the repository metadata key holding the reference index.
+const ReferencesMetadataKey = "references"
+
+// ************************************************************************************************
+// SymbolReferences lists the call sites of a function or method declared in a repository.
+// Functions are qualified by import path, e.g. "example.com/app/storage.Open", and methods by
+// their receiver type, e.g. "example.com/app/storage.Store.Get".
+type SymbolReferences struct {
+	Symbol     string      `json:"symbol"`     // Qualified function or method name
+	File       string      `json:"file"`       // File declaring the symbol, relative to the repository root
+	Line       int         `json:"line"`       // 1-based line of the declaration
+	References []Reference `json:"references"` // Call sites, sorted by file then position
+}
+
+// ************************************************************************************************
+// Reference is a call site of a symbol.
+type Reference struct {
+	File   string `json:"file"`   // File containing the call, relative to the repository root
+	Line   int    `json:"line"`   // 1-based line of the call
+	Column int    `json:"column"` // 1-based column of the call
+	Caller string `json:"caller"` // Qualified name of the function or method containing the call
+}
+
+// ************************************************************************************************
+// DecodeReferences reads the reference index from repository metadata. The value is a typed
+// slice right after indexing and a generic JSON array once loaded from the cache.
+//
+// Returns:
+//   - []SymbolReferences: The symbols with their call sites.
+//   - error: An error if the value is not a reference index.
+func DecodeReferences(value interface{}) ([]SymbolReferences, error) {
+	if index, ok := value.([]SymbolReferences); ok {
+		return index, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode reference index\n>    %w", err)
+	}
+	var index []SymbolReferences
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid reference index\n>    %w", err)
+	}
+	return index, nil
+}
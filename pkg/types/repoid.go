@@ -0,0 +1,146 @@
+// ************************************************************************************************
+// Package types provides repository ID derivation and validation for the repomix-mcp application.
+// Repository IDs are minted from configured aliases, glob expansion and Go module paths;
+// this file keeps them in one namespace so no two sources can produce the same ID.
+package types
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ************************************************************************************************
+// GoModuleRepositoryPrefix is the reserved prefix of synthetic Go module repository IDs.
+const GoModuleRepositoryPrefix = "gomod:"
+
+// ************************************************************************************************
+// GoModuleRepositoryID returns the synthetic repository ID of a Go module.
+//
+// Returns:
+//   - string: The repository ID ("gomod:<module path>").
+//
+// Example usage:
+//
+//	id := types.GoModuleRepositoryID("github.com/gin-gonic/gin")
+func GoModuleRepositoryID(modulePath string) string {
+	return GoModuleRepositoryPrefix + modulePath
+}
+
+// ************************************************************************************************
+// IsGoModuleRepositoryID reports whether a repository ID belongs to a synthetic Go module.
+func IsGoModuleRepositoryID(id string) bool {
+	return strings.HasPrefix(id, GoModuleRepositoryPrefix)
+}
+
+// ************************************************************************************************
+// WorkspaceRepositoryPrefix is the reserved prefix of virtual workspace repository IDs.
+const WorkspaceRepositoryPrefix = "workspace:"
+
+// ************************************************************************************************
+// WorkspaceRepositoryID returns the virtual repository ID of a configured workspace.
+//
+// Returns:
+//   - string: The repository ID ("workspace:<name>").
+//
+// Example usage:
+//
+//	id := types.WorkspaceRepositoryID("payments")
+func WorkspaceRepositoryID(name string) string {
+	return WorkspaceRepositoryPrefix + name
+}
+
+// ************************************************************************************************
+// IsWorkspaceRepositoryID reports whether a repository ID designates a workspace.
+func IsWorkspaceRepositoryID(id string) bool {
+	return strings.HasPrefix(id, WorkspaceRepositoryPrefix)
+}
+
+// ************************************************************************************************
+// ValidateRepositoryID checks that an ID minted from configuration is usable.
+// IDs must be non-empty, must not use the reserved Go module prefix and must not
+// contain ':' (used as the cache key separator) or whitespace.
+//
+// Returns:
+//   - error: ErrInvalidRepositoryID describing the problem, or nil.
+//
+// Example usage:
+//
+//	if err := types.ValidateRepositoryID(alias); err != nil {
+//		return err
+//	}
+func ValidateRepositoryID(id string) error {
+	if id == "" {
+		return fmt.Errorf("%w: repository ID cannot be empty", ErrInvalidRepositoryID)
+	}
+
+	if IsGoModuleRepositoryID(id) {
+		return fmt.Errorf("%w: %s uses the reserved prefix %s", ErrInvalidRepositoryID, id, GoModuleRepositoryPrefix)
+	}
+
+	for _, r := range id {
+		if r == ':' || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("%w: %q contains invalid character %q", ErrInvalidRepositoryID, id, r)
+		}
+	}
+
+	return nil
+}
+
+// ************************************************************************************************
+// ExpandedRepositoryID derives the ID of a repository discovered by glob expansion.
+// Characters that are not allowed in IDs are replaced so the result always validates.
+//
+// Returns:
+//   - string: The derived repository ID ("<base alias>-<name>").
+//
+// Example usage:
+//
+//	id := types.ExpandedRepositoryID("projects", "api")
+func ExpandedRepositoryID(baseAlias, name string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if r == ':' || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return '-'
+		}
+		return r
+	}, name)
+
+	return fmt.Sprintf("%s-%s", baseAlias, sanitized)
+}
+
+// ************************************************************************************************
+// RepositoryIDSet tracks which source minted each repository ID so collisions between
+// configured aliases, glob expansions and Go modules are detected.
+type RepositoryIDSet struct {
+	owners map[string]string
+}
+
+// ************************************************************************************************
+// NewRepositoryIDSet creates an empty repository ID set.
+//
+// Returns:
+//   - *RepositoryIDSet: The empty set.
+//
+// Example usage:
+//
+//	ids := types.NewRepositoryIDSet()
+//	if err := ids.Claim("api", "alias api"); err != nil {
+//		return err
+//	}
+func NewRepositoryIDSet() *RepositoryIDSet {
+	return &RepositoryIDSet{owners: make(map[string]string)}
+}
+
+// ************************************************************************************************
+// Claim registers an ID for a source. Claiming an ID again from the same source is a no-op.
+//
+// Returns:
+//   - error: ErrRepositoryIDCollision if another source already owns the ID.
+func (s *RepositoryIDSet) Claim(id, source string) error {
+	if owner, exists := s.owners[id]; exists && owner != source {
+		return fmt.Errorf("%w: %s from %s is already used by %s", ErrRepositoryIDCollision, id, source, owner)
+	}
+
+	s.owners[id] = source
+	return nil
+}
@@ -0,0 +1,69 @@
+// ************************************************************************************************
+// Package types - Unit tests for repository ID derivation and validation.
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+// ************************************************************************************************
+// Test ValidateRepositoryID
+func TestValidateRepositoryID(t *testing.T) {
+	tests := []struct {
+		name        string
+		id          string
+		expectError bool
+	}{
+		{"Plain alias", "my-repo", false},
+		{"Alias with slash", "team/api", false},
+		{"Empty alias", "", true},
+		{"Reserved Go module prefix", "gomod:github.com/gin-gonic/gin", true},
+		{"Cache key separator", "repo:name", true},
+		{"Whitespace", "my repo", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRepositoryID(tt.id)
+			if tt.expectError && !errors.Is(err, ErrInvalidRepositoryID) {
+				t.Errorf("Expected ErrInvalidRepositoryID for %q, got %v", tt.id, err)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error for %q: %v", tt.id, err)
+			}
+		})
+	}
+}
+
+// ************************************************************************************************
+// Test that derived IDs never collide with the Go module namespace
+func TestRepositoryIDDerivation(t *testing.T) {
+	if id := GoModuleRepositoryID("github.com/gin-gonic/gin"); !IsGoModuleRepositoryID(id) {
+		t.Errorf("Expected %q to be a Go module repository ID", id)
+	}
+
+	id := ExpandedRepositoryID("gomod", "github.com:gin")
+	if err := ValidateRepositoryID(id); err != nil {
+		t.Errorf("Expected expanded ID %q to validate, got %v", id, err)
+	}
+	if IsGoModuleRepositoryID(id) {
+		t.Errorf("Expanded ID %q must not fall into the Go module namespace", id)
+	}
+}
+
+// ************************************************************************************************
+// Test RepositoryIDSet collision detection
+func TestRepositoryIDSet_Claim(t *testing.T) {
+	ids := NewRepositoryIDSet()
+
+	if err := ids.Claim("projects-api", "projects-api"); err != nil {
+		t.Fatalf("Unexpected error on first claim: %v", err)
+	}
+	if err := ids.Claim("projects-api", "projects-api"); err != nil {
+		t.Errorf("Re-claiming from the same source should succeed, got %v", err)
+	}
+	if err := ids.Claim("projects-api", "projects"); !errors.Is(err, ErrRepositoryIDCollision) {
+		t.Errorf("Expected ErrRepositoryIDCollision, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+// ************************************************************************************************
+// Package types provides byte size parsing for the repomix-mcp application.
+// Size limits in the configuration are written as human-readable strings such as "1MB".
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ************************************************************************************************
+// ParseByteSize converts a human-readable size ("512", "64KB", "1MB", "2GB") into bytes.
+// Units are binary multiples and case-insensitive; a bare number is a byte count.
+//
+// Returns:
+//   - int64: The size in bytes.
+//   - error: An error if the size is malformed or not positive.
+//
+// Example usage:
+//
+//	limit, err := types.ParseByteSize("100MB")
+//	if err != nil {
+//		return fmt.Errorf("invalid size: %w", err)
+//	}
+func ParseByteSize(size string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("%w: invalid size %q", ErrInvalidConfig, size)
+	}
+	return number * multiplier, nil
+}
+
+// ************************************************************************************************
+// FormatByteSize renders a byte count with the largest binary unit that keeps it above 1,
+// the inverse of ParseByteSize for display purposes ("512 B", "1.5 KB", "12.0 MB").
+func FormatByteSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
\ No newline at end of file
@@ -0,0 +1,61 @@
+// ************************************************************************************************
+// Package types provides the symbol index for the repomix-mcp application.
+// The Go parser records every declared function, method, type, variable and constant with its
+// signature, location and doc comment, so agents can look up a single API by name.
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ************************************************************************************************
+// SymbolsMetadataKey is the repository metadata key holding the symbol index.
+const SymbolsMetadataKey = "symbols"
+
+// ************************************************************************************************
+// Symbol is a Go construct declared in a repository. Methods are named after their receiver
+// type, e.g. "Store.Get", and every symbol is qualified by the import path of its package.
+type Symbol struct {
+	Name      string `json:"name"`      // Name within the package, "Type.Method" for methods
+	Kind      string `json:"kind"`      // "func", "method", "struct", "interface", "type", "var" or "const"
+	Package   string `json:"package"`   // Import path of the declaring package
+	Signature string `json:"signature"` // Declaration without body
+	Doc       string `json:"doc"`       // Doc comment text, empty when undocumented
+	File      string `json:"file"`      // File declaring the symbol, relative to the repository root
+	Line      int    `json:"line"`      // 1-based line of the declaration
+	Exported  bool   `json:"exported"`  // Whether the symbol is exported
+}
+
+// ************************************************************************************************
+// QualifiedName returns the symbol name qualified by its import path, e.g.
+// "example.com/app/storage.Store.Get".
+func (s Symbol) QualifiedName() string {
+	if s.Package == "" {
+		return s.Name
+	}
+	return s.Package + "." + s.Name
+}
+
+// ************************************************************************************************
+// DecodeSymbols reads the symbol index from repository metadata. The value is a typed slice
+// right after indexing and a generic JSON array once loaded from the cache.
+//
+// Returns:
+//   - []Symbol: The declared symbols.
+//   - error: An error if the value is not a symbol index.
+func DecodeSymbols(value interface{}) ([]Symbol, error) {
+	if index, ok := value.([]Symbol); ok {
+		return index, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode symbol index\n>    %w", err)
+	}
+	var index []Symbol
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid symbol index\n>    %w", err)
+	}
+	return index, nil
+}
@@ -0,0 +1,110 @@
+// ************************************************************************************************
+// Package types provides work item marker scanning for the repomix-mcp application.
+// Lines carrying markers such as TODO or FIXME are collected at indexing time so the
+// outstanding work of a repository can be listed without reading every file.
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ************************************************************************************************
+// TodosMetadataKey is the repository metadata key holding the collected work items.
+const TodosMetadataKey = "todos"
+
+// ************************************************************************************************
+// DefaultTodoMarkers are collected when IndexingConfig.TodoMarkers is empty.
+var DefaultTodoMarkers = []string{"TODO", "FIXME", "HACK", "XXX"}
+
+// ************************************************************************************************
+// TodoItem is a work item marker found in an indexed file.
+type TodoItem struct {
+	Marker string `json:"marker"` // Marker that matched, e.g. "TODO"
+	File   string `json:"file"`   // File path relative to the repository root
+	Line   int    `json:"line"`   // 1-based line number
+	Text   string `json:"text"`   // Line content from the marker on
+}
+
+// ************************************************************************************************
+// ScanTodos collects the lines of content carrying one of the markers. A marker only matches
+// as a whole word, so "TODOS" or "0xXXX" are not reported.
+//
+// Returns:
+//   - []TodoItem: The items in line order.
+//
+// Example usage:
+//
+//	items := types.ScanTodos("main.go", content, types.DefaultTodoMarkers)
+func ScanTodos(file, content string, markers []string) []TodoItem {
+	if len(markers) == 0 {
+		markers = DefaultTodoMarkers
+	}
+
+	var items []TodoItem
+	for lineNumber, line := range strings.Split(content, "\n") {
+		for _, marker := range markers {
+			index := markerIndex(line, marker)
+			if index < 0 {
+				continue
+			}
+			text := strings.TrimSpace(line[index:])
+			text = strings.TrimSpace(strings.TrimSuffix(text, "*/"))
+			items = append(items, TodoItem{Marker: marker, File: file, Line: lineNumber + 1, Text: text})
+			break
+		}
+	}
+	return items
+}
+
+// ************************************************************************************************
+// markerIndex returns the position of the first whole-word occurrence of marker in line, -1 if none.
+func markerIndex(line, marker string) int {
+	for offset := 0; offset < len(line); {
+		index := strings.Index(line[offset:], marker)
+		if index < 0 {
+			return -1
+		}
+		start, end := offset+index, offset+index+len(marker)
+
+		before, _ := utf8.DecodeLastRuneInString(line[:start])
+		after, _ := utf8.DecodeRuneInString(line[end:])
+		if (start == 0 || !isWordRune(before)) && (end == len(line) || !isWordRune(after)) {
+			return start
+		}
+		offset = end
+	}
+	return -1
+}
+
+// ************************************************************************************************
+// isWordRune reports whether r can be part of an identifier.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// ************************************************************************************************
+// DecodeTodos reads work items from repository metadata. The value is a typed slice right
+// after indexing and a generic JSON array once loaded from the cache.
+//
+// Returns:
+//   - []TodoItem: The work items.
+//   - error: An error if the value is not a list of work items.
+func DecodeTodos(value interface{}) ([]TodoItem, error) {
+	if items, ok := value.([]TodoItem); ok {
+		return items, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode work items\n>    %w", err)
+	}
+	var items []TodoItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("invalid work items\n>    %w", err)
+	}
+	return items, nil
+}
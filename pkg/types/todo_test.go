@@ -0,0 +1,36 @@
+// ************************************************************************************************
+// Package types - Unit tests for work item marker scanning.
+package types
+
+import "testing"
+
+// ************************************************************************************************
+// Test that markers are matched as whole words with their line numbers
+func TestScanTodos(t *testing.T) {
+	content := "package main\n" +
+		"// TODO: handle errors\n" +
+		"x := 0xXXX // not a marker\n" +
+		"/* FIXME(alice): race */\n" +
+		"// TODOS are not markers\n" +
+		"# HACK\n"
+
+	items := ScanTodos("main.go", content, nil)
+	expected := []TodoItem{
+		{Marker: "TODO", File: "main.go", Line: 2, Text: "TODO: handle errors"},
+		{Marker: "FIXME", File: "main.go", Line: 4, Text: "FIXME(alice): race"},
+		{Marker: "HACK", File: "main.go", Line: 6, Text: "HACK"},
+	}
+
+	if len(items) != len(expected) {
+		t.Fatalf("Expected %d items, got %d: %+v", len(expected), len(items), items)
+	}
+	for i := range expected {
+		if items[i] != expected[i] {
+			t.Errorf("Item %d: expected %+v, got %+v", i, expected[i], items[i])
+		}
+	}
+
+	if custom := ScanTodos("main.go", content, []string{"NOTE"}); len(custom) != 0 {
+		t.Errorf("Expected custom markers to replace the defaults, got %+v", custom)
+	}
+}
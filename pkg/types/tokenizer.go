@@ -0,0 +1,141 @@
+// ************************************************************************************************
+// Package types provides the token counting of the repomix-mcp application.
+// Token budgets of tools such as get-library-docs are measured with a tiktoken-compatible BPE
+// encoding, so they match the tokens a language model reads rather than characters. The
+// encodings are embedded in the binary and loaded once per process, on first use.
+package types
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// ************************************************************************************************
+// Token encodings accepted by ServerConfig.Tokenizer, besides the model names of tiktoken such
+// as "gpt-4o".
+const (
+	// TokenizerCL100K is the encoding of GPT-4 and GPT-3.5, the default.
+	TokenizerCL100K = "cl100k_base"
+
+	// TokenizerO200K is the encoding of GPT-4o and later models.
+	TokenizerO200K = "o200k_base"
+
+	// TokenizerChars counts characters, one token per byte, as budgets did before tokenizers.
+	TokenizerChars = "chars"
+
+	// DefaultTokenizer is used when no tokenizer is configured.
+	DefaultTokenizer = TokenizerCL100K
+)
+
+// ************************************************************************************************
+// Tokenizer counts and truncates text in tokens. It is safe for concurrent use.
+type Tokenizer struct {
+	name     string
+	encoding *tiktoken.Tiktoken // nil when counting characters
+}
+
+// ************************************************************************************************
+// tokenizers caches the tokenizers by name, encodings being costly to load.
+var (
+	tokenizers   = make(map[string]*Tokenizer)
+	tokenizersMu sync.Mutex
+)
+
+// ************************************************************************************************
+// NewTokenizer returns the tokenizer of an encoding or model name, empty meaning the default.
+// Names are matched case-insensitively and tokenizers are shared by every caller.
+//
+// Returns:
+//   - *Tokenizer: The tokenizer.
+//   - error: ErrInvalidConfig if the name is neither an encoding nor a known model.
+//
+// Example usage:
+//
+//	tokenizer, err := types.NewTokenizer(config.Server.Tokenizer)
+//	if err != nil {
+//		return err
+//	}
+//	content = tokenizer.Truncate(content, 1000)
+func NewTokenizer(name string) (*Tokenizer, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = DefaultTokenizer
+	}
+
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	if tokenizer, exists := tokenizers[name]; exists {
+		return tokenizer, nil
+	}
+
+	tokenizer := &Tokenizer{name: name}
+	if name != TokenizerChars {
+		// Encodings are read from the binary, never downloaded
+		tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+		encoding, err := tiktoken.GetEncoding(name)
+		if err != nil {
+			if encoding, err = tiktoken.EncodingForModel(name); err != nil {
+				return nil, fmt.Errorf("%w: unknown tokenizer %q (expected an encoding such as cl100k_base or o200k_base, a model name or chars)", ErrInvalidConfig, name)
+			}
+		}
+		tokenizer.encoding = encoding
+	}
+	tokenizers[name] = tokenizer
+	return tokenizer, nil
+}
+
+// ************************************************************************************************
+// Name returns the encoding or model name of the tokenizer.
+func (t *Tokenizer) Name() string {
+	return t.name
+}
+
+// ************************************************************************************************
+// Count returns the number of tokens of text. Special tokens such as "<|endoftext|>" are
+// counted as ordinary text.
+func (t *Tokenizer) Count(text string) int {
+	if t.encoding == nil || text == "" {
+		return len(text)
+	}
+	return len(t.encoding.EncodeOrdinary(text))
+}
+
+// ************************************************************************************************
+// Truncate returns the longest prefix of text holding at most the given number of tokens,
+// cut on a character boundary.
+//
+// Example usage:
+//
+//	summary = tokenizer.Truncate(summary, tokens-100) + "\n\n[Content truncated...]"
+func (t *Tokenizer) Truncate(text string, tokens int) string {
+	if tokens <= 0 {
+		return ""
+	}
+	if len(text) <= tokens {
+		return text // Every token spans at least one byte
+	}
+
+	prefix := text[:tokens]
+	if t.encoding != nil {
+		ids := t.encoding.EncodeOrdinary(text)
+		if len(ids) <= tokens {
+			return text
+		}
+		prefix = t.encoding.Decode(ids[:tokens])
+	}
+
+	// A token may end inside a multi-byte character
+	start := len(prefix) - 1
+	for start > 0 && len(prefix)-start < utf8.UTFMax && !utf8.RuneStart(prefix[start]) {
+		start--
+	}
+	if start >= 0 && !utf8.FullRuneInString(prefix[start:]) {
+		prefix = prefix[:start]
+	}
+	return prefix
+}
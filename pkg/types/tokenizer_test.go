@@ -0,0 +1,67 @@
+// ************************************************************************************************
+// Package types - Unit tests for token counting.
+package types
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// ************************************************************************************************
+// Test that tokens are counted with the configured encoding and text is cut on token boundaries
+func TestTokenizer(t *testing.T) {
+	tokenizer, err := NewTokenizer("")
+	if err != nil {
+		t.Fatalf("NewTokenizer failed: %v", err)
+	}
+	if tokenizer.Name() != DefaultTokenizer {
+		t.Errorf("Expected the default tokenizer, got %s", tokenizer.Name())
+	}
+	if shared, _ := NewTokenizer(" CL100K_BASE "); shared != tokenizer {
+		t.Error("Expected tokenizers to be shared by name")
+	}
+
+	// "hello world" is two cl100k_base tokens, "hello" and " world"
+	if count := tokenizer.Count("hello world"); count != 2 {
+		t.Errorf("Expected 2 tokens, got %d", count)
+	}
+	if count := tokenizer.Count("<|endoftext|>"); count <= 1 {
+		t.Errorf("Expected special tokens to be counted as text, got %d", count)
+	}
+	if got := tokenizer.Truncate("hello world", 1); got != "hello" {
+		t.Errorf("Expected the first token, got %q", got)
+	}
+	if got := tokenizer.Truncate("hello world", 5); got != "hello world" {
+		t.Errorf("Expected text within the budget to be kept, got %q", got)
+	}
+
+	text := strings.Repeat("Le café coûte 3€. ", 50)
+	for tokens := 1; tokens < 40; tokens++ {
+		prefix := tokenizer.Truncate(text, tokens)
+		if !strings.HasPrefix(text, prefix) || !utf8.ValidString(prefix) || tokenizer.Count(prefix) > tokens {
+			t.Fatalf("Unexpected %d-token prefix %q", tokens, prefix)
+		}
+	}
+
+	model, err := NewTokenizer("gpt-4o")
+	if err != nil {
+		t.Fatalf("NewTokenizer failed for a model name: %v", err)
+	}
+	if model.Count(text) >= tokenizer.Count(text)*2 || model.Count(text) == 0 {
+		t.Errorf("Unexpected o200k_base count %d", model.Count(text))
+	}
+
+	chars, err := NewTokenizer(TokenizerChars)
+	if err != nil {
+		t.Fatalf("NewTokenizer failed for chars: %v", err)
+	}
+	if chars.Count("hello world") != 11 || chars.Truncate("café", 4) != "caf" {
+		t.Errorf("Expected characters to be counted by byte and cut on characters, got %d and %q", chars.Count("hello world"), chars.Truncate("café", 4))
+	}
+
+	if _, err := NewTokenizer("gpt-unknown"); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for an unknown tokenizer, got %v", err)
+	}
+}
@@ -18,6 +18,9 @@ const (
 
 	// RepositoryTypeRemote represents a remote Git repository.
 	RepositoryTypeRemote RepositoryType = "remote"
+
+	// RepositoryTypeHTTP represents a single file or archive downloaded over HTTP(S).
+	RepositoryTypeHTTP RepositoryType = "http"
 )
 
 // ************************************************************************************************
@@ -54,18 +57,95 @@ type IndexingConfig struct {
 	IncludePatterns    []string `json:"includePatterns" mapstructure:"includePatterns"`       // File patterns to include
 	MaxFileSize        string   `json:"maxFileSize" mapstructure:"maxFileSize"`               // Maximum file size to index
 	IncludeNonExported bool     `json:"includeNonExported" mapstructure:"includeNonExported"` // Include non-exported constructs (default: false)
+	IncludeVendor      bool     `json:"includeVendor" mapstructure:"includeVendor"`           // Parse the vendor/ tree with the Go parser, tagging its constructs vendored (default: false)
+	IncludeTests       bool     `json:"includeTests" mapstructure:"includeTests"`             // Parse *_test.go files with the Go parser, tagging their constructs test (default: false)
+	FunctionMetrics    bool     `json:"functionMetrics" mapstructure:"functionMetrics"`       // Record function line span and complexity (default: false)
+	MinifiedAvgLine    int      `json:"minifiedAvgLine" mapstructure:"minifiedAvgLine"`       // Average line length above which a file is tagged minified (default: 300, negative disables)
+	MinifiedMaxLine    int      `json:"minifiedMaxLine" mapstructure:"minifiedMaxLine"`       // Longest line length above which a file is tagged minified (default: 5000, negative disables)
+	GitTrackedOnly     bool     `json:"gitTrackedOnly" mapstructure:"gitTrackedOnly"`         // Index only files tracked by git, for git repositories (default: false)
+	AlwaysInclude      []string `json:"alwaysInclude" mapstructure:"alwaysInclude"`           // File patterns indexed regardless of exclude patterns and size limits (up to 10MB)
+	DirectoryStats     bool     `json:"directoryStats" mapstructure:"directoryStats"`         // Record per-directory file count, size and languages (default: false)
+	TodoMarkers        []string `json:"todoMarkers" mapstructure:"todoMarkers"`               // Work item markers collected for list-todos (default: TODO, FIXME, HACK, XXX)
+	Strategy           string   `json:"strategy" mapstructure:"strategy"`                     // Indexing strategy: "auto" (default), "repomix" or "go_native"
+	FallbackStrategies []string `json:"fallbackStrategies" mapstructure:"fallbackStrategies"` // Strategies tried in order when the selected one fails (default: repomix after go_native, "none" disables)
+	HashAlgorithm      string   `json:"hashAlgorithm" mapstructure:"hashAlgorithm"`           // Content hash algorithm: "sha256", "xxhash" or "blake3" (default: cache.hashAlgorithm)
+	APISpecFiles       []string `json:"apiSpecFiles" mapstructure:"apiSpecFiles"`             // File patterns of OpenAPI/Swagger specifications served by get-api-spec (default: openapi/swagger .yaml, .yml, .json)
+	ChunkMaxLines      int      `json:"chunkMaxLines" mapstructure:"chunkMaxLines"`           // Length in lines above which files are split into chunks, and of the longest chunk (default: 200, negative disables)
+	ChunkOverlap       int      `json:"chunkOverlap" mapstructure:"chunkOverlap"`             // Lines shared by the consecutive windows of a construct longer than a chunk (default: 20)
 }
 
+// ************************************************************************************************
+// Indexing strategy names accepted by IndexingConfig.Strategy.
+const (
+	// IndexingStrategyAuto detects the strategy from the repository content.
+	IndexingStrategyAuto = "auto"
+
+	// IndexingStrategyRepomix always runs the repomix CLI, serving full file content.
+	IndexingStrategyRepomix = "repomix"
+
+	// IndexingStrategyGoNative always uses the Go AST parser.
+	IndexingStrategyGoNative = "go_native"
+
+	// IndexingStrategyPythonNative always uses the Python source parser.
+	IndexingStrategyPythonNative = "python_native"
+
+	// IndexingStrategyRustNative always uses the Rust source parser.
+	IndexingStrategyRustNative = "rust_native"
+
+	// IndexingStrategyJavaNative always uses the Java source parser.
+	IndexingStrategyJavaNative = "java_native"
+
+	// IndexingStrategyCSharpNative always uses the C# source parser.
+	IndexingStrategyCSharpNative = "csharp_native"
+
+	// IndexingStrategyTreeSitter always uses the tree-sitter parser, in builds with cgo enabled.
+	IndexingStrategyTreeSitter = "tree_sitter"
+
+	// IndexingStrategyTypeScriptNative always uses the TypeScript/JavaScript source parser.
+	IndexingStrategyTypeScriptNative = "typescript_native"
+
+	// IndexingStrategyNone disables fallbacks when it is the only entry of FallbackStrategies.
+	IndexingStrategyNone = "none"
+)
+
+// ************************************************************************************************
+// Repository metadata keys describing how a repository was indexed.
+const (
+	// IndexingStrategyMetadataKey holds the name of the strategy that produced the index.
+	IndexingStrategyMetadataKey = "indexing_strategy"
+
+	// IndexingFallbacksMetadataKey lists the strategies that failed before it, as "name: error".
+	IndexingFallbacksMetadataKey = "indexing_fallbacks"
+
+	// IndexingConfigMetadataKey holds the hash of the indexing configuration; a repository is
+	// only re-indexed incrementally with the configuration it was indexed with.
+	IndexingConfigMetadataKey = "indexing_config"
+
+	// DirtyFilesMetadataKey lists the files modified or untracked in the git working tree when the
+	// repository was indexed, re-indexed by the next incremental run whether committed or not.
+	DirtyFilesMetadataKey = "dirty_files"
+
+	// IncrementalFilesMetadataKey holds the number of changed files an incremental run
+	// re-indexed; it is absent when the whole repository was indexed.
+	IncrementalFilesMetadataKey = "incremental_files"
+)
+
 // ************************************************************************************************
 // RepositoryConfig represents configuration for a single repository.
 // It contains all necessary information to clone, authenticate, and index a repository.
 type RepositoryConfig struct {
-	Type     RepositoryType `json:"type" mapstructure:"type"`         // Repository source type
-	Path     string         `json:"path" mapstructure:"path"`         // Local path or remote URL
-	URL      string         `json:"url" mapstructure:"url"`           // Git repository URL for remote repos
-	Auth     RepositoryAuth `json:"auth" mapstructure:"auth"`         // Authentication configuration
-	Indexing IndexingConfig `json:"indexing" mapstructure:"indexing"` // Indexing behavior configuration
-	Branch   string         `json:"branch" mapstructure:"branch"`     // Git branch to index (default: main)
+	Type            RepositoryType `json:"type" mapstructure:"type"`                       // Repository source type
+	Path            string         `json:"path" mapstructure:"path"`                       // Local path or remote URL
+	URL             string         `json:"url" mapstructure:"url"`                         // Git repository URL for remote repos, download URL for http repos
+	Auth            RepositoryAuth `json:"auth" mapstructure:"auth"`                       // Authentication configuration
+	Indexing        IndexingConfig `json:"indexing" mapstructure:"indexing"`               // Indexing behavior configuration
+	Branch          string         `json:"branch" mapstructure:"branch"`                   // Git branch to index (default: main)
+	MaxDownloadSize string         `json:"maxDownloadSize" mapstructure:"maxDownloadSize"` // Size limit for http downloads and extracted archives (default: 100MB)
+	MaxGlobMatches  int            `json:"maxGlobMatches" mapstructure:"maxGlobMatches"`   // Maximum directories a local glob path may expand to (default: 100, negative: unlimited)
+	Disabled        bool           `json:"disabled" mapstructure:"disabled"`               // Keep the entry but skip indexing and serving it
+	Scopes          []string       `json:"scopes" mapstructure:"scopes"`                   // Token scopes granting access, any one suffices (default: every client)
+	Watch           bool           `json:"watch" mapstructure:"watch"`                     // Re-index a local repository when its files change while serving
+	RefreshInterval string         `json:"refreshInterval" mapstructure:"refreshInterval"` // Re-index the repository at this interval while serving, e.g. "1h" (default: "", disabled)
 }
 
 // ************************************************************************************************
@@ -75,6 +155,15 @@ type CacheConfig struct {
 	Path    string `json:"path" mapstructure:"path"`       // Cache storage directory path
 	MaxSize string `json:"maxSize" mapstructure:"maxSize"` // Maximum cache size
 	TTL     string `json:"ttl" mapstructure:"ttl"`         // Time-to-live for cached entries
+
+	// Content hash algorithm: "sha256" (default), "xxhash" or "blake3"; repositories may override it
+	HashAlgorithm string `json:"hashAlgorithm" mapstructure:"hashAlgorithm"`
+
+	// Encoded size above which a repository index is split into several entries (default: 1MB, "0" disables)
+	MaxEntrySize string `json:"maxEntrySize" mapstructure:"maxEntrySize"`
+
+	// Interval at which the server deletes expired entries, e.g. "6h" (default: "", disabled)
+	PruneInterval string `json:"pruneInterval" mapstructure:"pruneInterval"`
 }
 
 // ************************************************************************************************
@@ -85,12 +174,130 @@ type ServerConfig struct {
 	LogLevel string `json:"logLevel" mapstructure:"logLevel"` // Logging verbosity level
 	Host     string `json:"host" mapstructure:"host"`         // Server binding host
 
+	// Log line format: "text" (default) or "json", with one attribute per key of a message
+	LogFormat string `json:"logFormat" mapstructure:"logFormat"`
+
+	// Log file, rotated by size, receiving the messages at or above logLevel in addition to stderr
+	LogFile       string `json:"logFile" mapstructure:"logFile"`             // Path of the log file (default: "", stderr only)
+	LogMaxSize    string `json:"logMaxSize" mapstructure:"logMaxSize"`       // Size at which the log file is rotated (default: 100MB, "0" disables rotation)
+	LogMaxAge     string `json:"logMaxAge" mapstructure:"logMaxAge"`         // Age after which rotated files are deleted, e.g. "720h" (default: "", kept)
+	LogMaxBackups int    `json:"logMaxBackups" mapstructure:"logMaxBackups"` // Rotated files kept (default: 0, all)
+	LogFileOnly   bool   `json:"logFileOnly" mapstructure:"logFileOnly"`     // Stop mirroring logs to stderr (default: false)
+
+	// Additional HTTP listen addresses (host:port); when empty, host and port are used
+	ListenAddresses []string `json:"listenAddresses" mapstructure:"listenAddresses"`
+
 	// HTTPS Configuration
 	HTTPSEnabled bool   `json:"httpsEnabled" mapstructure:"httpsEnabled"` // Enable HTTPS server
 	HTTPSPort    int    `json:"httpsPort" mapstructure:"httpsPort"`       // HTTPS server port (default: 9443)
 	CertPath     string `json:"certPath" mapstructure:"certPath"`         // Path to TLS certificate file
 	KeyPath      string `json:"keyPath" mapstructure:"keyPath"`           // Path to TLS private key file
 	AutoGenCert  bool   `json:"autoGenCert" mapstructure:"autoGenCert"`   // Auto-generate self-signed certificate
+
+	// Auto-generated certificate settings
+	CertValidityDays int      `json:"certValidityDays" mapstructure:"certValidityDays"` // Validity period of generated certificates (default: 365)
+	CertKeyAlgorithm string   `json:"certKeyAlgorithm" mapstructure:"certKeyAlgorithm"` // Key algorithm of generated certificates: "rsa" (default) or "ecdsa"
+	CertExtraSANs    []string `json:"certExtraSANs" mapstructure:"certExtraSANs"`       // Additional hostnames/IPs added to generated certificates
+
+	// Mutual TLS: client certificates verified against a CA bundle by the HTTPS server
+	ClientCAPath      string `json:"clientCAPath" mapstructure:"clientCAPath"`           // PEM bundle of the CAs issuing client certificates
+	RequireClientCert bool   `json:"requireClientCert" mapstructure:"requireClientCert"` // Refuse clients without a certificate issued by these CAs
+
+	// Certificates obtained and renewed from an ACME CA such as Let's Encrypt, instead of certPath/keyPath
+	ACME ACMEConfig `json:"acme" mapstructure:"acme"`
+
+	// Connection timeouts (duration strings such as "30s")
+	ReadHeaderTimeout string `json:"readHeaderTimeout" mapstructure:"readHeaderTimeout"` // Time allowed to read request headers (default: 10s)
+	ReadTimeout       string `json:"readTimeout" mapstructure:"readTimeout"`             // Time allowed to read the whole request (default: 30s)
+	WriteTimeout      string `json:"writeTimeout" mapstructure:"writeTimeout"`           // Time allowed to write the response (default: 5m)
+	IdleTimeout       string `json:"idleTimeout" mapstructure:"idleTimeout"`             // Keep-alive idle connection timeout (default: 120s)
+	ShutdownTimeout   string `json:"shutdownTimeout" mapstructure:"shutdownTimeout"`     // Time allowed to the requests being handled on shutdown (default: 30s)
+
+	// Index age above which served documentation is prefixed with an out-of-date note (default: 168h, "0" disables)
+	StalenessThreshold string `json:"stalenessThreshold" mapstructure:"stalenessThreshold"`
+
+	// Token counting of the tokens budgets of get-library-docs: a tiktoken encoding ("cl100k_base" default,
+	// "o200k_base", "p50k_base", "r50k_base"), a model name such as "gpt-4o", or "chars" to count characters
+	Tokenizer string `json:"tokenizer" mapstructure:"tokenizer"`
+
+	// Hard limit on the encoded size of tool responses, text beyond it is truncated (default: 4MB, "0" disables)
+	MaxResponseSize string `json:"maxResponseSize" mapstructure:"maxResponseSize"`
+
+	// Body size from which responses are gzip or deflate compressed for clients accepting it (default: 1KB, "0" disables)
+	CompressionMinSize string `json:"compressionMinSize" mapstructure:"compressionMinSize"`
+
+	// MCP requests handled at once across all listeners, further requests get 503 (default: 0, unlimited)
+	MaxConcurrentRequests int `json:"maxConcurrentRequests" mapstructure:"maxConcurrentRequests"`
+
+	// Worker pool of get-library-docs, refresh and Go module retrievals, shared by every client
+	MaxConcurrentTools int    `json:"maxConcurrentTools" mapstructure:"maxConcurrentTools"` // Expensive tool executions running at once (default: 0, unlimited)
+	ToolQueueTimeout   string `json:"toolQueueTimeout" mapstructure:"toolQueueTimeout"`     // Time an execution waits for a worker before "Server busy" (default: 30s)
+
+	// Quiet period after the last change of a watched repository before it is re-indexed (default: 2s)
+	WatchDebounce string `json:"watchDebounce" mapstructure:"watchDebounce"`
+
+	// Match library names to repository IDs regardless of accents, e.g. "cafe" resolves "café" (default: false)
+	ResolveIgnoreAccents bool `json:"resolveIgnoreAccents" mapstructure:"resolveIgnoreAccents"`
+
+	// In-memory repository eviction, evicted repositories are reloaded from the cache on demand
+	MaxMemoryRepositories int    `json:"maxMemoryRepositories" mapstructure:"maxMemoryRepositories"` // Repositories held in memory before the least recently used are evicted (default: 0, unlimited)
+	MemoryIdleTimeout     string `json:"memoryIdleTimeout" mapstructure:"memoryIdleTimeout"`         // Time after which an unused repository is evicted (default: "", disabled)
+
+	// Authentication of the MCP endpoints, open when no credential is configured
+	Auth ServerAuth `json:"auth" mapstructure:"auth"`
+
+	// Per-client limits of tools/call, clients being identified by API key, token subject or IP address
+	RateLimit RateLimitConfig `json:"rateLimit" mapstructure:"rateLimit"`
+
+	// OpenTelemetry spans of tool calls, cache accesses and subprocesses, exported over OTLP/HTTP
+	Tracing TracingConfig `json:"tracing" mapstructure:"tracing"`
+}
+
+// ************************************************************************************************
+// ACMEConfig configures the HTTPS certificates obtained from an ACME certificate authority. The
+// CA checks that the server answers for the hostnames with a challenge on port 443 (TLS-ALPN-01,
+// on the HTTPS server) or port 80 (HTTP-01, on the HTTP listeners), so the server must be
+// reachable on one of them under these names. Certificates are renewed before they expire.
+type ACMEConfig struct {
+	Enabled      bool     `json:"enabled" mapstructure:"enabled"`           // Obtain the HTTPS certificates from the ACME CA (default: false)
+	Hostnames    []string `json:"hostnames" mapstructure:"hostnames"`       // Hostnames certificates are requested for, others are refused
+	Email        string   `json:"email" mapstructure:"email"`               // Contact address of the ACME account, notified of expiring certificates
+	DirectoryURL string   `json:"directoryUrl" mapstructure:"directoryUrl"` // ACME directory of the CA (default: Let's Encrypt production)
+	CacheDir     string   `json:"cacheDir" mapstructure:"cacheDir"`         // Directory storing the account key and certificates (default: <cache.path>/acme)
+}
+
+// ************************************************************************************************
+// RateLimitConfig limits the tool calls of each client, with a tighter limit on the calls running
+// subprocesses: refresh, index-repository and the retrieval of uncached Go modules.
+type RateLimitConfig struct {
+	ToolCallsPerMinute      int `json:"toolCallsPerMinute" mapstructure:"toolCallsPerMinute"`           // Tool calls per client and minute (default: 0, unlimited)
+	Burst                   int `json:"burst" mapstructure:"burst"`                                     // Tool calls a client may make at once after being idle (default: toolCallsPerMinute)
+	ExpensiveCallsPerMinute int `json:"expensiveCallsPerMinute" mapstructure:"expensiveCallsPerMinute"` // Subprocess-running calls per client and minute (default: 0, unlimited)
+}
+
+// ************************************************************************************************
+// TracingConfig configures the export of OpenTelemetry spans to an OTLP/HTTP collector, such as
+// the OpenTelemetry Collector, Jaeger or Grafana Tempo.
+type TracingConfig struct {
+	Endpoint    string            `json:"endpoint" mapstructure:"endpoint"`       // Collector URL, e.g. "http://localhost:4318" (default: OTEL_EXPORTER_OTLP_ENDPOINT, tracing disabled when empty)
+	ServiceName string            `json:"serviceName" mapstructure:"serviceName"` // service.name of the exported spans (default: OTEL_SERVICE_NAME or "repomix-mcp")
+	SampleRatio *float64          `json:"sampleRatio" mapstructure:"sampleRatio"` // Share of traces recorded, from 0 to 1 (default: 1)
+	Headers     map[string]string `json:"headers" mapstructure:"headers"`         // HTTP headers sent to the collector, e.g. an API key
+}
+
+// ************************************************************************************************
+// ServerAuth contains the credentials accepted by the MCP endpoints. Clients send one of them in
+// an "Authorization: Bearer <key>" header: a static API key, or an OAuth2/OIDC access token (JWT)
+// signed by a key of the configured JWKS.
+type ServerAuth struct {
+	APIKeys []string `json:"apiKeys" mapstructure:"apiKeys"` // Static API keys, at least 16 characters each
+
+	// OAuth2/OIDC bearer tokens, accepted when issuer or jwksUrl is set
+	Issuer     string `json:"issuer" mapstructure:"issuer"`         // Required "iss" claim; its discovery document gives the JWKS when jwksUrl is empty
+	JWKSURL    string `json:"jwksUrl" mapstructure:"jwksUrl"`       // JSON Web Key Set verifying token signatures (default: discovered from issuer)
+	Audience   string `json:"audience" mapstructure:"audience"`     // Required "aud" claim value (default: "", not checked)
+	ScopeClaim string `json:"scopeClaim" mapstructure:"scopeClaim"` // Claim listing the scopes matched against repository scopes (default: "scope")
+	AdminScope string `json:"adminScope" mapstructure:"adminScope"` // Scope access tokens need to delete or reindex repositories through the admin API (default: "repomix:admin")
 }
 
 // ************************************************************************************************
@@ -101,20 +308,37 @@ type Config struct {
 	Cache        CacheConfig                 `json:"cache" mapstructure:"cache"`               // Cache system configuration
 	Server       ServerConfig                `json:"server" mapstructure:"server"`             // MCP server configuration
 	GoModule     GoModuleConfig              `json:"goModule" mapstructure:"goModule"`         // Go module documentation configuration
+	Index        IndexConfig                 `json:"index" mapstructure:"index"`               // Indexing runs configuration
+	Workspaces   map[string]WorkspaceConfig  `json:"workspaces" mapstructure:"workspaces"`     // Named groups of repositories queried as one
+}
+
+// ************************************************************************************************
+// IndexConfig contains the settings of the indexing runs, by the index command or in serve mode.
+type IndexConfig struct {
+	Parallelism int `json:"parallelism" mapstructure:"parallelism"` // Repositories, glob expansions included, indexed at once (default: 1)
+}
+
+// ************************************************************************************************
+// WorkspaceConfig groups related repositories so they can be queried as a single library
+// through the virtual repository ID "workspace:<name>".
+type WorkspaceConfig struct {
+	Repositories []string `json:"repositories" mapstructure:"repositories"` // Member repository aliases, glob entries include their expansions
+	Description  string   `json:"description" mapstructure:"description"`   // Optional description shown by resolve-library-id
 }
 
 // ************************************************************************************************
 // IndexedFile represents a file that has been processed and stored in the cache.
 // It contains metadata and content information for efficient retrieval.
 type IndexedFile struct {
-	Path         string            `json:"path"`         // Relative file path within repository
-	Content      string            `json:"content"`      // File content
-	Hash         string            `json:"hash"`         // Content hash for change detection
-	Size         int64             `json:"size"`         // File size in bytes
-	ModTime      time.Time         `json:"modTime"`      // Last modification time
-	Language     string            `json:"language"`     // Detected programming language
-	RepositoryID string            `json:"repositoryId"` // Repository identifier
-	Metadata     map[string]string `json:"metadata"`     // Additional file metadata
+	Path         string            `json:"path"`                 // Relative file path within repository
+	Content      string            `json:"content"`              // File content
+	ContentRef   string            `json:"contentRef,omitempty"` // Content hash in the cache's content-addressable store (cache internal)
+	Hash         string            `json:"hash"`                 // Content hash for change detection
+	Size         int64             `json:"size"`                 // File size in bytes
+	ModTime      time.Time         `json:"modTime"`              // Last modification time
+	Language     string            `json:"language"`             // Detected programming language
+	RepositoryID string            `json:"repositoryId"`         // Repository identifier
+	Metadata     map[string]string `json:"metadata"`             // Additional file metadata
 }
 
 // ************************************************************************************************
@@ -153,6 +377,35 @@ type SearchQuery struct {
 	MaxResults   int    `json:"maxResults"`   // Maximum number of results
 	Topic        string `json:"topic"`        // Topic filter for focused search
 	Tokens       int    `json:"tokens"`       // Maximum tokens in response
+	ExportedOnly bool   `json:"exportedOnly"` // Only match lines of exported Go constructs
+}
+
+// ************************************************************************************************
+// GrepQuery defines a regular expression search over every line of a single repository.
+type GrepQuery struct {
+	Pattern      string `json:"pattern"`      // Regular expression, RE2 syntax
+	IgnoreCase   bool   `json:"ignoreCase"`   // Match case-insensitively
+	FilePattern  string `json:"filePattern"`  // File name pattern filter
+	Language     string `json:"language"`     // Programming language filter
+	ContextLines int    `json:"contextLines"` // Lines shown before and after each match
+	MaxMatches   int    `json:"maxMatches"`   // Maximum number of matching lines, 0 for no limit
+}
+
+// ************************************************************************************************
+// GrepResult holds the matching lines of a file with their context, in line order.
+type GrepResult struct {
+	Path       string     `json:"path"`       // Relative file path within repository
+	Language   string     `json:"language"`   // Detected programming language
+	MatchCount int        `json:"matchCount"` // Number of matching lines
+	Lines      []GrepLine `json:"lines"`      // Matching and context lines, overlapping contexts merged
+}
+
+// ************************************************************************************************
+// GrepLine is a line of a grep result.
+type GrepLine struct {
+	Number int    `json:"number"` // Line number, 1-based
+	Text   string `json:"text"`   // Line content
+	Match  bool   `json:"match"`  // True for a matching line, false for a context line
 }
 
 // ************************************************************************************************
@@ -222,10 +475,22 @@ type MCPTool struct {
 // ************************************************************************************************
 // MCPToolCallParams represents parameters for tools/call.
 type MCPToolCallParams struct {
-	Name      string                 `json:"name"`      // Tool name
-	Arguments map[string]interface{} `json:"arguments"` // Tool arguments
+	Name      string                 `json:"name"`            // Tool name
+	Arguments map[string]interface{} `json:"arguments"`       // Tool arguments
+	Meta      *MCPRequestMeta        `json:"_meta,omitempty"` // Request metadata
+}
+
+// ************************************************************************************************
+// MCPRequestMeta represents the _meta parameter of an MCP request.
+type MCPRequestMeta struct {
+	ProgressToken interface{} `json:"progressToken,omitempty"` // Token of the progress notifications, a string or a number
 }
 
+// ************************************************************************************************
+// ProgressFunc receives the progress of a long-running operation: the steps done out of total,
+// total being 0 when unknown, and what is being done.
+type ProgressFunc func(progress, total int, message string)
+
 // ************************************************************************************************
 // MCPToolCallResult represents the result of tools/call.
 type MCPToolCallResult struct {
@@ -240,6 +505,49 @@ type MCPContent struct {
 	Text string `json:"text"` // Text content (for type "text")
 }
 
+// ************************************************************************************************
+// MCPPromptsListResult represents the response to prompts/list.
+type MCPPromptsListResult struct {
+	Prompts []MCPPrompt `json:"prompts"` // Available prompts
+}
+
+// ************************************************************************************************
+// MCPPrompt represents a prompt template in MCP.
+type MCPPrompt struct {
+	Name        string              `json:"name"`        // Prompt name
+	Description string              `json:"description"` // Prompt description
+	Arguments   []MCPPromptArgument `json:"arguments"`   // Arguments filling the template
+}
+
+// ************************************************************************************************
+// MCPPromptArgument represents an argument of a prompt template.
+type MCPPromptArgument struct {
+	Name        string `json:"name"`        // Argument name
+	Description string `json:"description"` // Argument description
+	Required    bool   `json:"required"`    // Whether the argument must be given
+}
+
+// ************************************************************************************************
+// MCPPromptGetParams represents parameters for prompts/get.
+type MCPPromptGetParams struct {
+	Name      string            `json:"name"`      // Prompt name
+	Arguments map[string]string `json:"arguments"` // Prompt arguments
+}
+
+// ************************************************************************************************
+// MCPPromptGetResult represents the result of prompts/get.
+type MCPPromptGetResult struct {
+	Description string             `json:"description"` // Description of the filled prompt
+	Messages    []MCPPromptMessage `json:"messages"`    // Messages to send to the model
+}
+
+// ************************************************************************************************
+// MCPPromptMessage represents a message of a prompt.
+type MCPPromptMessage struct {
+	Role    string     `json:"role"`    // "user" or "assistant"
+	Content MCPContent `json:"content"` // Message content
+}
+
 // Legacy types for backward compatibility
 // ************************************************************************************************
 // MCPRequest represents an incoming MCP tool request (legacy).